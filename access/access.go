@@ -0,0 +1,82 @@
+// Package access detects pages whose content is a paywall, login prompt, or cookie consent
+// wall rather than the page itself, though they are served with a 200 status.
+package access
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// Paywall is reported for pages asking the reader to subscribe or pay to read on.
+	Paywall = "paywall"
+	// LoginRequired is reported for pages asking the reader to sign in to see them.
+	LoginRequired = "login_required"
+	// ConsentWall is reported for pages showing only a cookie or privacy consent prompt.
+	ConsentWall = "consent_wall"
+)
+
+const (
+	// sampleSize is how many bytes of the content are examined.
+	sampleSize = 64 * 1024
+	// shortContentWords is the most words content may have for a single matching block to flag
+	// it. Longer content is only flagged when matching blocks make up dominantShare of it.
+	shortContentWords = 150
+	// dominantShare is the share of words matching blocks must hold to flag longer content.
+	dominantShare = 0.5
+)
+
+// patterns holds the phrases that mark a block of text as boilerplate of each issue, checked in
+// order so the earlier issue wins a tie.
+var patterns = []struct {
+	issue   string
+	pattern *regexp.Regexp
+}{
+	{Paywall, regexp.MustCompile(`(?i)\b(subscribe (now )?to (continue|keep) reading|subscribe to (read|unlock)|(continue|keep) reading with a subscription|this (article|story|content) is (only )?(available )?(for|to) (paying )?subscribers|subscribers? only|subscriber[- ]exclusive|already a subscriber|become a (subscriber|member) to|you('ve| have) (reached|used) (your|all of your) (\w+ )?(free )?(article|story) limit|free articles? (remaining|left) this month|unlock (this|the full) (article|story)|start your free trial to read)\b`)},
+	{LoginRequired, regexp.MustCompile(`(?i)\b((sign|log) ?in to (continue|view|see|read|access)|please (sign|log) ?in\b|you (must|need to) (be )?(signed|logged) ?in|(login|sign[- ]in) (is )?required|create (a free|an) account to (continue|read|view))`)},
+	{ConsentWall, regexp.MustCompile(`(?i)\b(we use cookies|this (site|website) uses cookies|accept all( cookies)?|reject all( cookies)?|manage (your )?(cookie )?(consent|preferences|choices)|cookie (settings|preferences|policy)|we value your privacy|your privacy choices|by clicking .?accept)`)},
+}
+
+// wordPattern matches the words counted toward a block's length.
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// Detect returns the access issue that dominates content, parsed text such as markdown, or ""
+// if the content appears to be the page itself. Content is split into blocks at blank lines,
+// and each block matching an issue's phrases counts its words toward that issue. Short content
+// is flagged by any matching block; longer content only when one issue's blocks make up at
+// least half of its words, so an article under a cookie banner is not flagged.
+func Detect(content []byte) string {
+	if len(content) > sampleSize {
+		content = content[:sampleSize]
+	}
+
+	total := 0
+	matched := make(map[string]int)
+	for _, block := range strings.Split(string(content), "\n\n") {
+		words := len(wordPattern.FindAllStringIndex(block, -1))
+		total += words
+		for _, p := range patterns {
+			if p.pattern.MatchString(block) {
+				matched[p.issue] += words
+				break
+			}
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+
+	issue, most := "", 0
+	for _, p := range patterns {
+		if matched[p.issue] > most {
+			issue, most = p.issue, matched[p.issue]
+		}
+	}
+	if most == 0 {
+		return ""
+	}
+	if total <= shortContentWords || float64(most) >= dominantShare*float64(total) {
+		return issue
+	}
+	return ""
+}
@@ -0,0 +1,35 @@
+package access
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDetect verifies content dominated by paywall, login, or consent boilerplate is flagged,
+// while articles that merely include such a block are not.
+func TestDetect(t *testing.T) {
+	article := strings.Repeat("The city council voted on Tuesday to expand the bike lane network across the downtown core, citing safety data collected over the past three years. ", 10)
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"paywall", "# Markets rally as rates hold\n\nStocks rose on Tuesday.\n\nSubscribe to continue reading. Already a subscriber? Sign in.", Paywall},
+		{"article limit", "# Opinion\n\nYou've reached your free article limit. Start your free trial to read on.", Paywall},
+		{"login", "# Dashboard\n\nPlease sign in to continue.\n\n[Forgot password?](/reset)", LoginRequired},
+		{"consent", "# Before you continue\n\nWe use cookies and data to deliver our services. By clicking \"Accept all\", you agree.\n\n[Accept all](#) [Reject all](#)", ConsentWall},
+		{"article with cookie banner", "# Bike lanes\n\n" + article + "\n\nWe use cookies to improve your experience. [Accept all](#)", ""},
+		{"article with subscribe footer", "# Bike lanes\n\n" + article + "\n\nBecome a member to support local journalism.", ""},
+		{"article", "# Bike lanes\n\n" + article, ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Detect([]byte(tt.content)))
+		})
+	}
+}
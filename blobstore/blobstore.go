@@ -0,0 +1,155 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/joeychilson/websurfer/objectstore"
+)
+
+const (
+	// BackendS3 keeps blobs in Amazon S3 or an S3-compatible service.
+	BackendS3 = objectstore.ProviderS3
+	// BackendGCS keeps blobs in Google Cloud Storage.
+	BackendGCS = objectstore.ProviderGCS
+	// BackendFilesystem keeps blobs as files in a local directory.
+	BackendFilesystem = "filesystem"
+)
+
+// ErrNotFound is returned by Get when no blob is stored under the key.
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps blobs too large to hold in Redis.
+type Store interface {
+	// Put stores data under key, replacing any blob already stored there.
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns the blob stored under key, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// Config selects and configures a blob store.
+type Config struct {
+	// Backend is BackendS3, BackendGCS, or BackendFilesystem.
+	Backend string
+	// Dir is the directory blobs are written to by the filesystem backend.
+	Dir string
+	// Object configures the S3 and GCS backends. Its Provider is set from Backend.
+	Object objectstore.Config
+}
+
+// New creates a blob store from the configuration.
+func New(cfg Config) (Store, error) {
+	switch cfg.Backend {
+	case BackendFilesystem:
+		return NewFilesystem(cfg.Dir)
+	case BackendS3, BackendGCS:
+		objectConfig := cfg.Object
+		objectConfig.Provider = cfg.Backend
+		store, err := objectstore.New(objectConfig)
+		if err != nil {
+			return nil, err
+		}
+		return NewObject(store), nil
+	default:
+		return nil, fmt.Errorf("unknown blob store backend %q (supported: %s, %s, %s)", cfg.Backend, BackendS3, BackendGCS, BackendFilesystem)
+	}
+}
+
+// Object is a blob store backed by an S3 or GCS bucket.
+type Object struct {
+	store *objectstore.Store
+}
+
+// NewObject creates a blob store that keeps blobs in an object store.
+func NewObject(store *objectstore.Store) *Object {
+	return &Object{store: store}
+}
+
+// Put uploads data under key.
+func (o *Object) Put(ctx context.Context, key string, data []byte) error {
+	_, err := o.store.Put(ctx, key, "application/octet-stream", data)
+	return err
+}
+
+// Get downloads the blob stored under key.
+func (o *Object) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := o.store.Get(ctx, key)
+	if errors.Is(err, objectstore.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Filesystem is a blob store backed by a local directory. Keys are relative paths within it.
+type Filesystem struct {
+	dir string
+}
+
+// NewFilesystem creates a blob store that writes blobs under dir, creating it if needed.
+func NewFilesystem(dir string) (*Filesystem, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("filesystem blob store requires a directory")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return &Filesystem{dir: dir}, nil
+}
+
+// Put writes data to key's file. The file is replaced atomically, so concurrent readers see
+// either the old or the new blob.
+func (f *Filesystem) Put(ctx context.Context, key string, data []byte) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".blob-*")
+	if err != nil {
+		return fmt.Errorf("failed to create blob file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	return nil
+}
+
+// Get reads key's file.
+func (f *Filesystem) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	return data, nil
+}
+
+// path returns the file holding key, rejecting keys that would escape the directory.
+func (f *Filesystem) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(f.dir, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,114 @@
+package blobstore
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joeychilson/websurfer/objectstore"
+)
+
+// TestFilesystem verifies blobs round-trip through files, are replaced on Put, and missing
+// keys return ErrNotFound.
+func TestFilesystem(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFilesystem(dir)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "ab/abcdef", []byte("first")))
+	require.NoError(t, store.Put(ctx, "ab/abcdef", []byte("second")))
+
+	data, err := store.Get(ctx, "ab/abcdef")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("second"), data)
+
+	onDisk, err := os.ReadFile(filepath.Join(dir, "ab", "abcdef"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("second"), onDisk)
+
+	entries, err := os.ReadDir(filepath.Join(dir, "ab"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files should be cleaned up")
+
+	_, err = store.Get(ctx, "ab/missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestFilesystemInvalidKey verifies keys cannot escape the blob directory.
+func TestFilesystemInvalidKey(t *testing.T) {
+	store, err := NewFilesystem(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"../escape", "/etc/passwd", "", "a/../../b"} {
+		assert.Error(t, store.Put(context.Background(), key, []byte("x")), key)
+		_, err := store.Get(context.Background(), key)
+		assert.Error(t, err, key)
+	}
+}
+
+// TestObject verifies the object backend stores blobs in the bucket and maps missing objects to
+// ErrNotFound.
+func TestObject(t *testing.T) {
+	objects := map[string][]byte{}
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			data, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			objects[r.URL.Path] = data
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				http.Error(w, "NoSuchKey", http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		}
+	}))
+	defer origin.Close()
+
+	store, err := New(Config{
+		Backend: BackendS3,
+		Object:  objectstore.Config{Bucket: "cache", Endpoint: origin.URL, AccessKeyID: "AKID", SecretAccessKey: "secret", Prefix: "bodies/"},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "abc", []byte("large body")))
+	assert.Contains(t, objects, "/cache/bodies/abc")
+
+	data, err := store.Get(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("large body"), data)
+
+	_, err = store.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestNew verifies backend selection and configuration errors.
+func TestNew(t *testing.T) {
+	store, err := New(Config{Backend: BackendFilesystem, Dir: t.TempDir()})
+	require.NoError(t, err)
+	assert.IsType(t, &Filesystem{}, store)
+
+	store, err = New(Config{Backend: BackendGCS, Object: objectstore.Config{Bucket: "cache", AccessKeyID: "AKID", SecretAccessKey: "secret"}})
+	require.NoError(t, err)
+	assert.IsType(t, &Object{}, store)
+
+	for _, cfg := range []Config{
+		{Backend: "azure"},
+		{Backend: BackendFilesystem},
+		{Backend: BackendS3, Object: objectstore.Config{Bucket: "cache"}},
+	} {
+		_, err := New(cfg)
+		assert.Error(t, err, cfg.Backend)
+	}
+}
@@ -0,0 +1,133 @@
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/joeychilson/websurfer/config"
+	urlutil "github.com/joeychilson/websurfer/url"
+)
+
+const (
+	// requestWindow is the window over which max_requests_per_hour is counted.
+	requestWindow = time.Hour
+	// byteWindow is the window over which max_bytes_per_day is counted.
+	byteWindow = 24 * time.Hour
+	// sweepInterval is how often domains with expired windows are removed.
+	sweepInterval = 10 * time.Minute
+)
+
+// ErrBudgetExhausted is returned when a domain has used up its request or byte budget.
+var ErrBudgetExhausted = errors.New("budget exhausted")
+
+// Tracker enforces per-domain request and byte budgets using fixed windows.
+type Tracker struct {
+	mu        sync.Mutex
+	domains   map[string]*domainUsage
+	lastSweep time.Time
+}
+
+// domainUsage holds budget usage for a single domain.
+type domainUsage struct {
+	requests      int
+	requestsReset time.Time
+	bytes         int64
+	bytesReset    time.Time
+}
+
+// New creates a new budget tracker.
+func New() *Tracker {
+	return &Tracker{
+		domains:   make(map[string]*domainUsage),
+		lastSweep: time.Now(),
+	}
+}
+
+// Acquire reserves one request against the domain's budget for the given URL.
+// It returns an error wrapping ErrBudgetExhausted if either budget is used up.
+func (t *Tracker) Acquire(urlStr string, cfg config.BudgetConfig) error {
+	if !cfg.IsEnabled() {
+		return nil
+	}
+
+	domain, err := urlutil.ExtractHost(urlStr)
+	if err != nil {
+		return fmt.Errorf("failed to extract domain: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.sweep(now)
+
+	usage := t.getUsage(domain, now)
+
+	if cfg.MaxBytesPerDay > 0 && usage.bytes >= cfg.MaxBytesPerDay {
+		return fmt.Errorf("%w for %s: max_bytes_per_day (%d) reached, resets at %s",
+			ErrBudgetExhausted, domain, cfg.MaxBytesPerDay, usage.bytesReset.UTC().Format(time.RFC3339))
+	}
+
+	if cfg.MaxRequestsPerHour > 0 && usage.requests >= cfg.MaxRequestsPerHour {
+		return fmt.Errorf("%w for %s: max_requests_per_hour (%d) reached, resets at %s",
+			ErrBudgetExhausted, domain, cfg.MaxRequestsPerHour, usage.requestsReset.UTC().Format(time.RFC3339))
+	}
+
+	usage.requests++
+	return nil
+}
+
+// RecordBytes adds downloaded bytes to the domain's byte budget.
+func (t *Tracker) RecordBytes(urlStr string, n int64, cfg config.BudgetConfig) {
+	if cfg.MaxBytesPerDay <= 0 || n <= 0 {
+		return
+	}
+
+	domain, err := urlutil.ExtractHost(urlStr)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	usage := t.getUsage(domain, time.Now())
+	usage.bytes += n
+}
+
+// getUsage returns the usage for a domain, resetting any expired windows. Callers must hold t.mu.
+func (t *Tracker) getUsage(domain string, now time.Time) *domainUsage {
+	usage, exists := t.domains[domain]
+	if !exists {
+		usage = &domainUsage{}
+		t.domains[domain] = usage
+	}
+
+	if !now.Before(usage.requestsReset) {
+		usage.requests = 0
+		usage.requestsReset = now.Add(requestWindow)
+	}
+
+	if !now.Before(usage.bytesReset) {
+		usage.bytes = 0
+		usage.bytesReset = now.Add(byteWindow)
+	}
+
+	return usage
+}
+
+// sweep removes domains whose windows have all expired. Callers must hold t.mu.
+func (t *Tracker) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < sweepInterval {
+		return
+	}
+	t.lastSweep = now
+
+	for domain, usage := range t.domains {
+		if !now.Before(usage.requestsReset) && !now.Before(usage.bytesReset) {
+			delete(t.domains, domain)
+		}
+	}
+}
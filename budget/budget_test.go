@@ -0,0 +1,68 @@
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joeychilson/websurfer/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTrackerDisabled verifies no limits are enforced without configuration.
+func TestTrackerDisabled(t *testing.T) {
+	tracker := New()
+
+	for range 100 {
+		require.NoError(t, tracker.Acquire("https://example.com/page", config.BudgetConfig{}))
+	}
+}
+
+// TestTrackerMaxRequestsPerHour verifies the request budget is enforced per domain.
+func TestTrackerMaxRequestsPerHour(t *testing.T) {
+	tracker := New()
+	cfg := config.BudgetConfig{MaxRequestsPerHour: 2}
+
+	require.NoError(t, tracker.Acquire("https://example.com/a", cfg))
+	require.NoError(t, tracker.Acquire("https://example.com/b", cfg))
+
+	err := tracker.Acquire("https://example.com/c", cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrBudgetExhausted)
+	assert.Contains(t, err.Error(), "budget exhausted for example.com")
+	assert.Contains(t, err.Error(), "resets at")
+
+	assert.NoError(t, tracker.Acquire("https://other.com/a", cfg), "other domains should have their own budget")
+}
+
+// TestTrackerMaxBytesPerDay verifies the byte budget is enforced once exceeded.
+func TestTrackerMaxBytesPerDay(t *testing.T) {
+	tracker := New()
+	cfg := config.BudgetConfig{MaxBytesPerDay: 1000}
+
+	require.NoError(t, tracker.Acquire("https://example.com/a", cfg))
+	tracker.RecordBytes("https://example.com/a", 600, cfg)
+
+	require.NoError(t, tracker.Acquire("https://example.com/b", cfg))
+	tracker.RecordBytes("https://example.com/b", 600, cfg)
+
+	err := tracker.Acquire("https://example.com/c", cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrBudgetExhausted)
+	assert.Contains(t, err.Error(), "max_bytes_per_day")
+}
+
+// TestTrackerWindowReset verifies budgets reset when their window expires.
+func TestTrackerWindowReset(t *testing.T) {
+	tracker := New()
+	cfg := config.BudgetConfig{MaxRequestsPerHour: 1}
+
+	require.NoError(t, tracker.Acquire("https://example.com/a", cfg))
+	require.Error(t, tracker.Acquire("https://example.com/a", cfg))
+
+	tracker.mu.Lock()
+	tracker.domains["example.com"].requestsReset = time.Now().Add(-time.Second)
+	tracker.mu.Unlock()
+
+	assert.NoError(t, tracker.Acquire("https://example.com/a", cfg), "budget should reset after window")
+}
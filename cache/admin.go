@@ -0,0 +1,217 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// EntryInfo describes how an entry is stored, without its body.
+type EntryInfo struct {
+	URL        string
+	StatusCode int
+	Title      string
+	State      State
+	StoredAt   time.Time
+	TTL        time.Duration
+	StaleTime  time.Duration
+	// ExpiresAt is when Redis drops the entry, at the end of its stale window.
+	ExpiresAt time.Time
+	// BodySize is the size of the body, and StoredSize the size of the Redis value, which
+	// excludes a body kept elsewhere.
+	BodySize   int
+	StoredSize int
+	Compressed bool
+	// BodyHash is set when the body is deduplicated, and BlobKey when it is in the blob store.
+	BodyHash string
+	BlobKey  string
+	Version  int
+	// HistoryVersions is how many previous versions are kept in the URL's history.
+	HistoryVersions int64
+}
+
+// Usage summarizes the entries stored in Redis.
+type Usage struct {
+	Entries int
+	// StoredBytes is the size of the entries in Redis, and UncompressedBytes their size before
+	// compression. Bodies stored separately by deduplication or the blob store are not counted.
+	StoredBytes       int64
+	UncompressedBytes int64
+	// CompressedEntries is how many entries are stored gzip-compressed.
+	CompressedEntries int
+	// Domains counts the entries of each host.
+	Domains map[string]int
+}
+
+// ListURLs returns the cached URLs whose host is domain, sorted.
+func (c *Cache) ListURLs(ctx context.Context, domain string) ([]string, error) {
+	urls, err := c.domainURLs(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(urls)
+	return urls, nil
+}
+
+// Inspect returns how the entry for url is stored, or nil if it is not cached. Unlike Get, it
+// reports entries past their stale window without deleting them and never reads the body from
+// elsewhere.
+func (c *Cache) Inspect(ctx context.Context, url string) (*EntryInfo, error) {
+	key := c.makeKey(url)
+
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis get failed: %w", err)
+	}
+
+	info := &EntryInfo{StoredSize: len(data), Compressed: isGzipped(data)}
+	if info.Compressed {
+		data, err = c.decompress(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress entry: %w", err)
+		}
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal entry: %w", err)
+	}
+
+	info.URL = entry.URL
+	info.StatusCode = entry.StatusCode
+	info.Title = entry.Title
+	info.State = entry.GetState()
+	info.StoredAt = entry.StoredAt
+	info.TTL = entry.TTL
+	info.StaleTime = entry.StaleTime
+	info.ExpiresAt = entry.StoredAt.Add(entry.TTL + entry.StaleTime)
+	info.BodySize = len(entry.Body)
+	info.BodyHash = entry.BodyHash
+	info.BlobKey = entry.BlobKey
+	info.Version = entry.Version
+
+	if entry.BodyHash != "" && len(entry.Body) == 0 {
+		pipe := c.client.Pipeline()
+		sizes := queueSizes(ctx, pipe, c.makeBlobKey(entry.BodyHash))
+		if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("redis blob size failed: %w", err)
+		}
+		_, bodySize, _ := sizes.result()
+		info.BodySize = int(bodySize)
+	}
+
+	info.HistoryVersions, err = c.client.LLen(ctx, c.makeHistoryKey(url)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("redis llen failed: %w", err)
+	}
+
+	return info, nil
+}
+
+// DeleteDomain deletes every cached URL whose host is domain, with its history, and returns
+// the number of URLs deleted.
+func (c *Cache) DeleteDomain(ctx context.Context, domain string) (int, error) {
+	urls, err := c.domainURLs(ctx, domain)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, u := range urls {
+		if err := c.Delete(ctx, u); err != nil {
+			return i, err
+		}
+	}
+	return len(urls), nil
+}
+
+// Usage scans the stored entries and totals their sizes. Uncompressed sizes are read from the
+// gzip trailer of compressed entries, so entries are not decompressed, but every entry is
+// visited, so it is meant for occasional reporting rather than the request path.
+func (c *Cache) Usage(ctx context.Context) (Usage, error) {
+	usage := Usage{Domains: make(map[string]int)}
+
+	var cursor uint64
+	for {
+		keys, next, err := c.client.Scan(ctx, cursor, escapeGlob(c.prefix)+"http*://*", scanBatchSize).Result()
+		if err != nil {
+			return usage, fmt.Errorf("redis scan failed: %w", err)
+		}
+
+		if len(keys) > 0 {
+			pipe := c.client.Pipeline()
+			sizes := make([]storedSize, len(keys))
+			for i, key := range keys {
+				sizes[i] = queueSizes(ctx, pipe, key)
+			}
+			if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+				return usage, fmt.Errorf("redis usage failed: %w", err)
+			}
+
+			for i, key := range keys {
+				stored, uncompressed, compressed := sizes[i].result()
+				if stored == 0 {
+					continue
+				}
+				usage.Entries++
+				usage.StoredBytes += stored
+				usage.UncompressedBytes += uncompressed
+				if compressed {
+					usage.CompressedEntries++
+				}
+				usage.Domains[keyHost(strings.TrimPrefix(key, c.prefix))]++
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	return usage, nil
+}
+
+// keyHost returns the host of a cached URL, without parsing the rest of it.
+func keyHost(rawURL string) string {
+	_, rest, _ := strings.Cut(rawURL, "://")
+	host, _, _ := strings.Cut(rest, "/")
+	host, _, _ = strings.Cut(host, "?")
+	return strings.ToLower(host)
+}
+
+// storedSize holds the pipelined commands that size a stored value.
+type storedSize struct {
+	head, trailer *redis.StringCmd
+	size          *redis.IntCmd
+}
+
+// queueSizes queues the commands that read the size of the value at key and, when it is
+// gzip-compressed, its uncompressed size from the gzip trailer.
+func queueSizes(ctx context.Context, pipe redis.Pipeliner, key string) storedSize {
+	return storedSize{
+		head:    pipe.GetRange(ctx, key, 0, 1),
+		trailer: pipe.GetRange(ctx, key, -4, -1),
+		size:    pipe.StrLen(ctx, key),
+	}
+}
+
+// result returns the stored and uncompressed sizes of the value and whether it is compressed.
+// The gzip trailer records the uncompressed size modulo 4 GiB, which cached values stay under.
+func (s storedSize) result() (stored, uncompressed int64, compressed bool) {
+	stored = s.size.Val()
+	trailer := []byte(s.trailer.Val())
+	if isGzipped([]byte(s.head.Val())) && len(trailer) == 4 {
+		return stored, int64(binary.LittleEndian.Uint32(trailer)), true
+	}
+	return stored, stored, false
+}
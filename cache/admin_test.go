@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInspect verifies entry metadata is reported without the body, including for
+// deduplicated bodies and entries past their stale window.
+func TestInspect(t *testing.T) {
+	ctx := context.Background()
+	c, _ := setupTestCache(t, Config{EnableDeduplication: true, EnableCompression: true, CompressionMinSize: 1})
+
+	body := strings.Repeat("cached body ", 200)
+	storedAt := time.Now().Add(-time.Minute)
+	require.NoError(t, c.Set(ctx, &Entry{URL: "https://example.com/a", StatusCode: 200, Title: "A", Body: []byte(body), StoredAt: storedAt, TTL: time.Hour, StaleTime: time.Hour}))
+
+	info, err := c.Inspect(ctx, "https://example.com/a")
+	require.NoError(t, err)
+	require.NotNil(t, info)
+	assert.Equal(t, "https://example.com/a", info.URL)
+	assert.Equal(t, "A", info.Title)
+	assert.Equal(t, StateFresh, info.State)
+	assert.Equal(t, len(body), info.BodySize)
+	assert.True(t, info.Compressed)
+	assert.NotEmpty(t, info.BodyHash)
+	assert.WithinDuration(t, storedAt.Add(2*time.Hour), info.ExpiresAt, time.Second)
+
+	missing, err := c.Inspect(ctx, "https://example.com/missing")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+// TestDeleteDomain verifies only the domain's entries are deleted.
+func TestDeleteDomain(t *testing.T) {
+	ctx := context.Background()
+	c, _ := setupTestCache(t, Config{})
+
+	for _, u := range []string{"https://example.com/b", "http://example.com/a", "https://example.com.evil.net/c", "https://other.com/d"} {
+		require.NoError(t, c.Set(ctx, &Entry{URL: u, StatusCode: 200, Body: []byte(u), StoredAt: time.Now()}))
+	}
+
+	urls, err := c.ListURLs(ctx, "Example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://example.com/a", "https://example.com/b"}, urls)
+
+	deleted, err := c.DeleteDomain(ctx, "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	urls, err = c.ListURLs(ctx, "example.com")
+	require.NoError(t, err)
+	assert.Empty(t, urls)
+
+	entry, err := c.Get(ctx, "https://other.com/d")
+	require.NoError(t, err)
+	assert.NotNil(t, entry)
+}
+
+// TestUsage verifies entry sizes are totaled, with uncompressed sizes of compressed entries.
+func TestUsage(t *testing.T) {
+	ctx := context.Background()
+	c, _ := setupTestCache(t, Config{EnableCompression: true, CompressionMinSize: 1024})
+
+	large := strings.Repeat("compressible ", 500)
+	require.NoError(t, c.Set(ctx, &Entry{URL: "https://example.com/large", StatusCode: 200, Body: []byte(large), StoredAt: time.Now()}))
+	require.NoError(t, c.Set(ctx, &Entry{URL: "https://example.com/small", StatusCode: 200, Body: []byte("small"), StoredAt: time.Now()}))
+	require.NoError(t, c.Set(ctx, &Entry{URL: "https://other.com/", StatusCode: 200, Body: []byte("other"), StoredAt: time.Now()}))
+
+	usage, err := c.Usage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, usage.Entries)
+	assert.Equal(t, 1, usage.CompressedEntries)
+	assert.Greater(t, usage.UncompressedBytes, int64(len(large)))
+	assert.Less(t, usage.StoredBytes, usage.UncompressedBytes)
+	assert.Equal(t, map[string]int{"example.com": 2, "other.com": 1}, usage.Domains)
+}
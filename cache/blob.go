@@ -0,0 +1,253 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/joeychilson/websurfer/blobstore"
+)
+
+var (
+	// writeEntryScript stores a deduplicated entry in one step: it takes a reference to the
+	// entry's body blob, storing the blob if absent and extending it to cover the entry, records
+	// the body's hash beside the entry, and drops the reference of the body the entry replaces,
+	// deleting that blob once unreferenced. Doing it atomically keeps concurrent writes of a URL
+	// from both releasing the same previous body.
+	//
+	// KEYS are the entry and its body hash key. ARGV are the entry, its expiration in
+	// milliseconds, the body hash ('' for none), the blob, and the blob and reference count key
+	// prefixes.
+	writeEntryScript = redis.NewScript(`
+local ttl = tonumber(ARGV[2])
+local prev = redis.call('GET', KEYS[2])
+if ARGV[3] ~= '' then
+  local blob, refs = ARGV[5] .. ARGV[3], ARGV[6] .. ARGV[3]
+  if redis.call('EXISTS', blob) == 0 then
+    redis.call('SET', blob, ARGV[4], 'PX', ttl)
+  elseif redis.call('PTTL', blob) < ttl then
+    redis.call('PEXPIRE', blob, ttl)
+  end
+  redis.call('INCR', refs)
+  redis.call('PEXPIRE', refs, redis.call('PTTL', blob))
+  redis.call('SET', KEYS[2], ARGV[3], 'PX', ttl)
+else
+  redis.call('DEL', KEYS[2])
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+if prev then
+  local refs = ARGV[6] .. prev
+  if redis.call('DECR', refs) <= 0 then
+    redis.call('DEL', ARGV[5] .. prev, refs)
+  end
+end
+return 1
+`)
+
+	// deleteEntryScript deletes an entry and its body hash key, dropping the entry's reference to
+	// its body and deleting the blob once unreferenced. With a non-empty ARGV[1], the entry is
+	// only deleted if it is still that value. KEYS are the entry and its body hash key, and
+	// ARGV[2] and ARGV[3] the blob and reference count key prefixes.
+	deleteEntryScript = redis.NewScript(`
+if ARGV[1] ~= '' and redis.call('GET', KEYS[1]) ~= ARGV[1] then
+  return 0
+end
+local prev = redis.call('GET', KEYS[2])
+local deleted = redis.call('DEL', KEYS[1], KEYS[2])
+if prev then
+  local refs = ARGV[3] .. prev
+  if redis.call('DECR', refs) <= 0 then
+    redis.call('DEL', ARGV[2] .. prev, refs)
+  end
+end
+return deleted
+`)
+)
+
+// hashBody returns the hex-encoded SHA-256 digest of a body.
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// makeBlobKey creates the Redis key holding the body blob for a hash.
+func (c *Cache) makeBlobKey(hash string) string {
+	return c.prefix + "blob:" + hash
+}
+
+// makeBlobRefsKey creates the Redis key holding the reference count for a hash.
+func (c *Cache) makeBlobRefsKey(hash string) string {
+	return c.prefix + "blobrefs:" + hash
+}
+
+// blobData returns the body as stored in its blob, compressed if compression is enabled.
+func (c *Cache) blobData(body []byte) ([]byte, error) {
+	if c.config.EnableCompression && len(body) >= c.config.CompressionMinSize {
+		compressed, err := c.compress(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compress body: %w", err)
+		}
+		return compressed, nil
+	}
+	return body, nil
+}
+
+// makeBodyHashKey creates the Redis key holding the body hash of the entry for a URL, which the
+// entry scripts read to release the body an entry replaces.
+func (c *Cache) makeBodyHashKey(url string) string {
+	return c.prefix + "bodyhash:" + c.urlKey(url)
+}
+
+// writeEntry stores data as the entry for url, referencing the body blob of hash, stored from
+// blob, and releasing the body of the entry it replaces. Blobs never expire before the entries
+// pointing at them, since each write extends the blob's expiration to at least the entry's.
+func (c *Cache) writeEntry(ctx context.Context, url string, data []byte, expiration time.Duration, hash string, blob []byte) error {
+	keys := []string{c.makeKey(url), c.makeBodyHashKey(url)}
+	args := []any{data, expiration.Milliseconds(), hash, blob, c.makeBlobKey(""), c.makeBlobRefsKey("")}
+	if err := writeEntryScript.Run(ctx, c.client, keys, args...).Err(); err != nil {
+		return fmt.Errorf("redis set failed: %w", err)
+	}
+	return nil
+}
+
+// deleteEntry deletes the entry for url, only if it is still current when current is set, and
+// releases its body.
+func (c *Cache) deleteEntry(ctx context.Context, url string, current []byte) error {
+	keys := []string{c.makeKey(url), c.makeBodyHashKey(url)}
+	if err := deleteEntryScript.Run(ctx, c.client, keys, current, c.makeBlobKey(""), c.makeBlobRefsKey("")).Err(); err != nil {
+		return fmt.Errorf("redis del failed: %w", err)
+	}
+	return nil
+}
+
+// getBlob retrieves the body stored under a hash, returning nil if it has expired.
+func (c *Cache) getBlob(ctx context.Context, hash string) ([]byte, error) {
+	data, err := c.client.Get(ctx, c.makeBlobKey(hash)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis blob get failed: %w", err)
+	}
+
+	if isGzipped(data) {
+		data, err = c.decompress(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress body: %w", err)
+		}
+	}
+
+	return data, nil
+}
+
+// storesExternally reports whether a body is large enough to be kept in the blob store.
+func (c *Cache) storesExternally(body []byte) bool {
+	return c.config.BlobStore != nil && len(body) > 0 && len(body) >= c.config.BlobThreshold
+}
+
+// putExternalBody writes a body to the blob store under its content hash and returns its key.
+func (c *Cache) putExternalBody(ctx context.Context, body []byte) (string, error) {
+	hash := hashBody(body)
+	key := hash[:2] + "/" + hash
+
+	data := body
+	if c.config.EnableCompression {
+		compressed, err := c.compress(data)
+		if err != nil {
+			return "", fmt.Errorf("failed to compress body: %w", err)
+		}
+		data = compressed
+	}
+
+	if err := c.config.BlobStore.Put(ctx, key, data); err != nil {
+		return "", fmt.Errorf("blob store put failed: %w", err)
+	}
+	return key, nil
+}
+
+// getExternalBody reads a body from the blob store, returning nil if it is gone.
+func (c *Cache) getExternalBody(ctx context.Context, key string) ([]byte, error) {
+	if c.config.BlobStore == nil {
+		return nil, nil
+	}
+
+	data, err := c.config.BlobStore.Get(ctx, key)
+	if errors.Is(err, blobstore.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("blob store get failed: %w", err)
+	}
+
+	if isGzipped(data) {
+		data, err = c.decompress(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress body: %w", err)
+		}
+	}
+	return data, nil
+}
+
+// DedupStats summarizes the deduplicated bodies stored in Redis.
+type DedupStats struct {
+	// Bodies is how many distinct bodies are stored, and References how many entries point at them.
+	Bodies     int
+	References int64
+	// StoredBytes is the size of the stored bodies, after compression, and SavedBytes what storing
+	// a copy per reference would have cost on top of that.
+	StoredBytes int64
+	SavedBytes  int64
+}
+
+// DedupStats scans the deduplicated bodies and totals their references and sizes. It visits
+// every body, so it is meant for occasional reporting rather than the request path.
+func (c *Cache) DedupStats(ctx context.Context) (DedupStats, error) {
+	var stats DedupStats
+	refsPrefix := c.makeBlobRefsKey("")
+
+	var cursor uint64
+	for {
+		keys, next, err := c.client.Scan(ctx, cursor, escapeGlob(refsPrefix)+"*", scanBatchSize).Result()
+		if err != nil {
+			return stats, fmt.Errorf("redis scan failed: %w", err)
+		}
+
+		if len(keys) > 0 {
+			pipe := c.client.Pipeline()
+			refs := make([]*redis.StringCmd, len(keys))
+			sizes := make([]*redis.IntCmd, len(keys))
+			for i, key := range keys {
+				refs[i] = pipe.Get(ctx, key)
+				sizes[i] = pipe.StrLen(ctx, c.makeBlobKey(strings.TrimPrefix(key, refsPrefix)))
+			}
+			if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+				return stats, fmt.Errorf("redis blob stats failed: %w", err)
+			}
+
+			for i := range keys {
+				count, err := refs[i].Int64()
+				size := sizes[i].Val()
+				if err != nil || count <= 0 || size == 0 {
+					continue
+				}
+				stats.Bodies++
+				stats.References += count
+				stats.StoredBytes += size
+				stats.SavedBytes += (count - 1) * size
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	return stats, nil
+}
@@ -7,9 +7,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+
+	"github.com/joeychilson/websurfer/blobstore"
+	"github.com/joeychilson/websurfer/forms"
+	"github.com/joeychilson/websurfer/hreflang"
+	"github.com/joeychilson/websurfer/structured"
 )
 
 // State represents the cache state of an entry.
@@ -26,17 +32,55 @@ const (
 
 // Entry represents a cached response.
 type Entry struct {
-	URL          string
-	StatusCode   int
-	Headers      map[string][]string
-	Body         []byte
-	Title        string
-	Description  string
-	FaviconURL   string
+	URL         string
+	StatusCode  int
+	Headers     map[string][]string
+	Body        []byte
+	Title       string
+	Description string
+	FaviconURL  string
+	ImageURL    string
+	NextURL     string
+	License     string
+	LicenseURL  string
+	Robots      []string
+	// Binary is set when Body is binary data, such as an image served as text, kept unparsed.
+	Binary bool
+	// LeadImageURL and Excerpt are the reader-mode main image and opening paragraph.
+	LeadImageURL string
+	Excerpt      string
+	// Structured holds FAQs and how-tos extracted from the page.
+	Structured *structured.Data
+	// Forms lists the page's HTML forms and their fields.
+	Forms []forms.Form
+	// Alternates lists the page's language variants from hreflang links.
+	Alternates []hreflang.Alternate
+	// CanonicalURL is the page's preferred URL, and NofollowLinks the links it asks not to follow.
+	CanonicalURL  string
+	NofollowLinks []string
+	// ArchiveURL and ArchivedAt are set when the entry is a Wayback Machine snapshot of URL.
+	ArchiveURL string
+	ArchivedAt time.Time
+	// OriginSHA256 is the hex SHA-256 of the body as URL's origin sent it, before it was
+	// parsed. It is empty for entries not fetched from the origin, such as imported entries
+	// and Wayback Machine snapshots. Rendered is set when the content was parsed from the page
+	// as rendered in the headless browser instead.
+	OriginSHA256 string
+	Rendered     bool
 	LastModified string
-	StoredAt     time.Time
-	TTL          time.Duration
-	StaleTime    time.Duration
+	BodyHash     string
+	// BlobKey is the key of the body in the blob store, when it was too large to keep in Redis.
+	BlobKey   string
+	StoredAt  time.Time
+	TTL       time.Duration
+	StaleTime time.Duration
+	// Version numbers the distinct contents stored for URL, starting at 1, and ChangedAt is
+	// when this version was first stored. Both are only tracked when MaxVersions is above 1.
+	Version   int
+	ChangedAt time.Time
+	// MaxVersions is how many versions of URL are kept when the entry is stored, including
+	// itself. The versions it replaces are moved to the URL's history.
+	MaxVersions int
 }
 
 // GetState returns the current state of the cache entry, computing the age only once
@@ -64,9 +108,10 @@ func (e *Entry) WithUpdatedTimestamp() *Entry {
 
 // Cache is a Redis-based cache implementation.
 type Cache struct {
-	client *redis.Client
-	config Config
-	prefix string
+	client    *redis.Client
+	config    Config
+	prefix    string
+	normalize func(string) string
 }
 
 // Config holds cache configuration.
@@ -77,6 +122,17 @@ type Config struct {
 	EnableCompression  bool
 	CompressionLevel   int
 	CompressionMinSize int
+	// EnableDeduplication stores bodies once per SHA-256 hash and keeps only a hash pointer
+	// in each per-URL entry, so identical bodies served at many URLs share storage.
+	EnableDeduplication bool
+	// HistoryTTL is how long a URL's previous versions are kept after its content last changed.
+	HistoryTTL time.Duration
+	// BlobStore keeps bodies of at least BlobThreshold bytes, so only a pointer to them is kept
+	// in Redis. Bodies are stored by content hash and never deleted by the cache; expire them
+	// with a bucket lifecycle rule or by file age, after longer than entries and history live.
+	BlobStore     blobstore.Store
+	BlobThreshold int
+	Logger        *slog.Logger
 }
 
 // DefaultConfig returns a cache config with sensible defaults.
@@ -88,6 +144,9 @@ func DefaultConfig() Config {
 		EnableCompression:  true,
 		CompressionLevel:   gzip.DefaultCompression,
 		CompressionMinSize: 1024,
+		HistoryTTL:         7 * 24 * time.Hour,
+		BlobThreshold:      1 << 20,
+		Logger:             slog.Default(),
 	}
 }
 
@@ -113,8 +172,9 @@ func (c *Cache) Get(ctx context.Context, url string) (*Entry, error) {
 	if err != nil {
 		return nil, fmt.Errorf("redis get failed: %w", err)
 	}
+	stored := data
 
-	if c.config.EnableCompression && len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+	if c.config.EnableCompression && isGzipped(data) {
 		data, err = c.decompress(data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decompress entry: %w", err)
@@ -127,10 +187,35 @@ func (c *Cache) Get(ctx context.Context, url string) (*Entry, error) {
 	}
 
 	if entry.GetState() == StateTooOld {
-		c.client.Del(ctx, key)
+		// Only the entry read is deleted; one written since by another caller is left alone.
+		if err := c.deleteEntry(ctx, url, stored); err != nil {
+			c.config.Logger.Warn("failed to delete expired cache entry", "url", url, "error", err)
+		}
 		return nil, nil
 	}
 
+	if entry.BodyHash != "" && len(entry.Body) == 0 {
+		body, err := c.getBlob(ctx, entry.BodyHash)
+		if err != nil {
+			return nil, err
+		}
+		if body == nil {
+			return nil, nil
+		}
+		entry.Body = body
+	}
+
+	if entry.BlobKey != "" && len(entry.Body) == 0 {
+		body, err := c.getExternalBody(ctx, entry.BlobKey)
+		if err != nil {
+			return nil, err
+		}
+		if body == nil {
+			return nil, nil
+		}
+		entry.Body = body
+	}
+
 	return &entry, nil
 }
 
@@ -143,9 +228,42 @@ func (c *Cache) Set(ctx context.Context, entry *Entry) error {
 		entry.StaleTime = c.config.StaleTime
 	}
 
+	if entry.MaxVersions > 1 {
+		if err := c.recordVersion(ctx, entry); err != nil {
+			return err
+		}
+	}
+
 	key := c.makeKey(entry.URL)
+	expiration := entry.TTL + entry.StaleTime
+
+	stored := entry
+	var blob []byte
+	switch {
+	case c.storesExternally(entry.Body):
+		blobKey, err := c.putExternalBody(ctx, entry.Body)
+		if err != nil {
+			return err
+		}
+		pointer := *entry
+		pointer.Body = nil
+		pointer.BodyHash = ""
+		pointer.BlobKey = blobKey
+		stored = &pointer
+	case c.config.EnableDeduplication && len(entry.Body) > 0:
+		data, err := c.blobData(entry.Body)
+		if err != nil {
+			return err
+		}
+		blob = data
+		pointer := *entry
+		pointer.Body = nil
+		pointer.BodyHash = hashBody(entry.Body)
+		pointer.BlobKey = ""
+		stored = &pointer
+	}
 
-	data, err := json.Marshal(entry)
+	data, err := json.Marshal(stored)
 	if err != nil {
 		return fmt.Errorf("failed to marshal entry: %w", err)
 	}
@@ -157,7 +275,9 @@ func (c *Cache) Set(ctx context.Context, entry *Entry) error {
 		}
 	}
 
-	expiration := entry.TTL + entry.StaleTime
+	if c.config.EnableDeduplication {
+		return c.writeEntry(ctx, entry.URL, data, expiration, stored.BodyHash, blob)
+	}
 
 	if err := c.client.Set(ctx, key, data, expiration).Err(); err != nil {
 		return fmt.Errorf("redis set failed: %w", err)
@@ -166,9 +286,39 @@ func (c *Cache) Set(ctx context.Context, entry *Entry) error {
 	return nil
 }
 
+// Delete removes the entry for url and its history from Redis, releasing its deduplicated body.
+func (c *Cache) Delete(ctx context.Context, url string) error {
+	if err := c.client.Del(ctx, c.makeHistoryKey(url)).Err(); err != nil {
+		return fmt.Errorf("redis del failed: %w", err)
+	}
+
+	return c.deleteEntry(ctx, url, nil)
+}
+
+// WithKeyNormalizer normalizes the URLs entries are stored and looked up under with normalize,
+// so trivially different URLs of the same page share one entry. Entries keep the URL they were
+// fetched from.
+func (c *Cache) WithKeyNormalizer(normalize func(string) string) *Cache {
+	c.normalize = normalize
+	return c
+}
+
+// urlKey returns the normalized form of url used in its Redis keys.
+func (c *Cache) urlKey(url string) string {
+	if c.normalize == nil {
+		return url
+	}
+	return c.normalize(url)
+}
+
 // makeKey creates a Redis key with the configured prefix.
 func (c *Cache) makeKey(url string) string {
-	return c.prefix + url
+	return c.prefix + c.urlKey(url)
+}
+
+// isGzipped reports whether data starts with the gzip magic bytes.
+func isGzipped(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
 }
 
 // compress compresses data using gzip.
@@ -220,6 +370,15 @@ func applyDefaults(config Config) Config {
 	if config.StaleTime == 0 {
 		config.StaleTime = defaults.StaleTime
 	}
+	if config.HistoryTTL == 0 {
+		config.HistoryTTL = defaults.HistoryTTL
+	}
+	if config.BlobThreshold == 0 {
+		config.BlobThreshold = defaults.BlobThreshold
+	}
+	if config.Logger == nil {
+		config.Logger = defaults.Logger
+	}
 	if config.EnableCompression {
 		if config.CompressionLevel == 0 {
 			config.CompressionLevel = defaults.CompressionLevel
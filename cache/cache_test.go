@@ -2,6 +2,9 @@ package cache
 
 import (
 	"context"
+	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -9,6 +12,8 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/joeychilson/websurfer/blobstore"
 )
 
 func setupTestCache(t *testing.T, config Config) (*Cache, *miniredis.Miniredis) {
@@ -316,3 +321,277 @@ func TestCacheRedisExpiration(t *testing.T) {
 	assert.InDelta(t, expectedTTL.Seconds(), ttl.Seconds(), 1.0,
 		"Redis TTL should be TTL + StaleTime")
 }
+
+// TestCacheDeduplicationSharesBody verifies identical bodies at different URLs are stored once.
+func TestCacheDeduplicationSharesBody(t *testing.T) {
+	config := DefaultConfig()
+	config.EnableDeduplication = true
+
+	cache, mr := setupTestCache(t, config)
+	ctx := context.Background()
+
+	body := []byte("<html><body>Shared content</body></html>")
+	urls := []string{
+		"https://example.com/page?utm_source=a",
+		"https://example.com/page?utm_source=b",
+		"https://mirror.example.com/page",
+	}
+
+	for _, u := range urls {
+		err := cache.Set(ctx, &Entry{URL: u, StatusCode: 200, Body: body, StoredAt: time.Now()})
+		require.NoError(t, err)
+	}
+
+	hash := hashBody(body)
+	assert.True(t, mr.Exists(cache.makeBlobKey(hash)), "blob should be stored under its hash")
+	refs, err := mr.Get(cache.makeBlobRefsKey(hash))
+	require.NoError(t, err)
+	assert.Equal(t, "3", refs, "each URL should hold one reference")
+
+	for _, u := range urls {
+		raw, err := mr.Get(cache.makeKey(u))
+		require.NoError(t, err)
+		assert.NotContains(t, raw, "Shared content", "per-URL entry should not embed the body")
+
+		retrieved, err := cache.Get(ctx, u)
+		require.NoError(t, err)
+		require.NotNil(t, retrieved)
+		assert.Equal(t, body, retrieved.Body)
+		assert.Equal(t, hash, retrieved.BodyHash)
+	}
+}
+
+// TestCacheDeduplicationReleasesOnOverwrite verifies replaced bodies are deleted once unreferenced.
+func TestCacheDeduplicationReleasesOnOverwrite(t *testing.T) {
+	config := DefaultConfig()
+	config.EnableDeduplication = true
+
+	cache, mr := setupTestCache(t, config)
+	ctx := context.Background()
+
+	oldBody := []byte("old content")
+	newBody := []byte("new content")
+
+	err := cache.Set(ctx, &Entry{URL: "https://example.com", Body: oldBody, StoredAt: time.Now()})
+	require.NoError(t, err)
+
+	// Re-storing the same body must not change the reference count.
+	err = cache.Set(ctx, &Entry{URL: "https://example.com", Body: oldBody, StoredAt: time.Now()})
+	require.NoError(t, err)
+	refs, err := mr.Get(cache.makeBlobRefsKey(hashBody(oldBody)))
+	require.NoError(t, err)
+	assert.Equal(t, "1", refs)
+
+	err = cache.Set(ctx, &Entry{URL: "https://example.com", Body: newBody, StoredAt: time.Now()})
+	require.NoError(t, err)
+
+	assert.False(t, mr.Exists(cache.makeBlobKey(hashBody(oldBody))), "unreferenced blob should be deleted")
+	assert.True(t, mr.Exists(cache.makeBlobKey(hashBody(newBody))))
+
+	retrieved, err := cache.Get(ctx, "https://example.com")
+	require.NoError(t, err)
+	require.NotNil(t, retrieved)
+	assert.Equal(t, newBody, retrieved.Body)
+}
+
+// TestCacheDeduplicationConcurrentSet verifies concurrent writes of a URL keep reference counts
+// exact, so a body another entry still points at is never deleted.
+func TestCacheDeduplicationConcurrentSet(t *testing.T) {
+	config := DefaultConfig()
+	config.EnableDeduplication = true
+
+	cache, mr := setupTestCache(t, config)
+	ctx := context.Background()
+
+	shared := []byte("shared content")
+	other := []byte("other content")
+	require.NoError(t, cache.Set(ctx, &Entry{URL: "https://example.com/shared", Body: shared, StoredAt: time.Now()}))
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body := shared
+			if i%2 == 1 {
+				body = other
+			}
+			for range 10 {
+				assert.NoError(t, cache.Set(ctx, &Entry{URL: "https://example.com/page", Body: body, StoredAt: time.Now()}))
+			}
+		}()
+	}
+	wg.Wait()
+
+	page, err := cache.Get(ctx, "https://example.com/page")
+	require.NoError(t, err)
+	require.NotNil(t, page)
+
+	refs := func(body []byte) string {
+		count, _ := mr.Get(cache.makeBlobRefsKey(hashBody(body)))
+		return count
+	}
+	if string(page.Body) == string(shared) {
+		assert.Equal(t, "2", refs(shared))
+		assert.False(t, mr.Exists(cache.makeBlobKey(hashBody(other))), "unreferenced blob should be deleted")
+	} else {
+		assert.Equal(t, other, page.Body)
+		assert.Equal(t, "1", refs(shared))
+		assert.Equal(t, "1", refs(other))
+	}
+
+	retrieved, err := cache.Get(ctx, "https://example.com/shared")
+	require.NoError(t, err)
+	require.NotNil(t, retrieved, "a body still referenced should not be deleted")
+	assert.Equal(t, shared, retrieved.Body)
+}
+
+// TestCacheDeleteReleasesBody verifies deleting an entry removes it and its unreferenced body.
+func TestCacheDeleteReleasesBody(t *testing.T) {
+	config := DefaultConfig()
+	config.EnableDeduplication = true
+
+	cache, mr := setupTestCache(t, config)
+	ctx := context.Background()
+
+	body := []byte("private content")
+	err := cache.Set(ctx, &Entry{URL: "https://example.com", Body: body, StoredAt: time.Now()})
+	require.NoError(t, err)
+
+	require.NoError(t, cache.Delete(ctx, "https://example.com"))
+	assert.False(t, mr.Exists(cache.makeKey("https://example.com")))
+	assert.False(t, mr.Exists(cache.makeBlobKey(hashBody(body))), "unreferenced blob should be deleted")
+
+	require.NoError(t, cache.Delete(ctx, "https://example.com"), "deleting a missing entry should succeed")
+}
+
+// TestCacheDeduplicationBlobOutlivesEntries verifies blob expiration covers the longest-lived reference.
+func TestCacheDeduplicationBlobOutlivesEntries(t *testing.T) {
+	config := DefaultConfig()
+	config.EnableDeduplication = true
+
+	cache, mr := setupTestCache(t, config)
+	ctx := context.Background()
+
+	body := []byte("shared")
+	err := cache.Set(ctx, &Entry{URL: "https://a.example.com", Body: body, StoredAt: time.Now(), TTL: time.Minute, StaleTime: time.Minute})
+	require.NoError(t, err)
+	err = cache.Set(ctx, &Entry{URL: "https://b.example.com", Body: body, StoredAt: time.Now(), TTL: time.Hour, StaleTime: time.Hour})
+	require.NoError(t, err)
+
+	assert.InDelta(t, (2 * time.Hour).Seconds(), mr.TTL(cache.makeBlobKey(hashBody(body))).Seconds(), 1.0)
+}
+
+// TestCacheBlobStoreLargeBody verifies bodies over the threshold are kept in the blob store with
+// only a pointer in Redis, and are read back transparently.
+func TestCacheBlobStoreLargeBody(t *testing.T) {
+	blobs, err := blobstore.NewFilesystem(t.TempDir())
+	require.NoError(t, err)
+
+	config := DefaultConfig()
+	config.EnableDeduplication = true
+	config.BlobStore = blobs
+	config.BlobThreshold = 1024
+
+	cache, mr := setupTestCache(t, config)
+	ctx := context.Background()
+
+	large := []byte(strings.Repeat("Large content. ", 200))
+	small := []byte("Small content")
+
+	err = cache.Set(ctx, &Entry{URL: "https://example.com/large", StatusCode: 200, Body: large, StoredAt: time.Now()})
+	require.NoError(t, err)
+	err = cache.Set(ctx, &Entry{URL: "https://example.com/small", StatusCode: 200, Body: small, StoredAt: time.Now()})
+	require.NoError(t, err)
+
+	hash := hashBody(large)
+	assert.False(t, mr.Exists(cache.makeBlobKey(hash)), "large body should not be stored in Redis")
+	_, err = blobs.Get(ctx, hash[:2]+"/"+hash)
+	require.NoError(t, err, "large body should be in the blob store")
+	assert.True(t, mr.Exists(cache.makeBlobKey(hashBody(small))), "small body should stay in Redis")
+
+	retrieved, err := cache.Get(ctx, "https://example.com/large")
+	require.NoError(t, err)
+	require.NotNil(t, retrieved)
+	assert.Equal(t, large, retrieved.Body)
+	assert.Equal(t, hash[:2]+"/"+hash, retrieved.BlobKey)
+
+	retrieved, err = cache.Get(ctx, "https://example.com/small")
+	require.NoError(t, err)
+	require.NotNil(t, retrieved)
+	assert.Equal(t, small, retrieved.Body)
+	assert.Empty(t, retrieved.BlobKey)
+}
+
+// TestCacheBlobStoreMissingBody verifies entries whose body is gone from the blob store are misses.
+func TestCacheBlobStoreMissingBody(t *testing.T) {
+	dir := t.TempDir()
+	blobs, err := blobstore.NewFilesystem(dir)
+	require.NoError(t, err)
+
+	config := DefaultConfig()
+	config.BlobStore = blobs
+	config.BlobThreshold = 16
+
+	cache, _ := setupTestCache(t, config)
+	ctx := context.Background()
+
+	err = cache.Set(ctx, &Entry{URL: "https://example.com", Body: []byte("a body past the threshold"), StoredAt: time.Now()})
+	require.NoError(t, err)
+	require.NoError(t, os.RemoveAll(dir))
+
+	retrieved, err := cache.Get(ctx, "https://example.com")
+	require.NoError(t, err)
+	assert.Nil(t, retrieved)
+}
+
+// TestCacheDedupStats verifies shared bodies are counted once with the bytes their sharing saves.
+func TestCacheDedupStats(t *testing.T) {
+	cache, mr := setupTestCache(t, Config{EnableDeduplication: true})
+	ctx := context.Background()
+
+	shared := []byte("<html><body>Mirrored docs</body></html>")
+	for _, u := range []string{"https://a.example.com/docs", "https://b.example.com/docs", "https://c.example.com/docs"} {
+		require.NoError(t, cache.Set(ctx, &Entry{URL: u, Body: shared, StoredAt: time.Now()}))
+	}
+	unique := []byte("unique page")
+	require.NoError(t, cache.Set(ctx, &Entry{URL: "https://example.com/other", Body: unique, StoredAt: time.Now()}))
+
+	stats, err := cache.DedupStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Bodies)
+	assert.Equal(t, int64(4), stats.References)
+	assert.Equal(t, int64(len(shared)+len(unique)), stats.StoredBytes)
+	assert.Equal(t, int64(2*len(shared)), stats.SavedBytes)
+
+	mr.Del(cache.makeBlobKey(hashBody(unique)))
+	stats, err = cache.DedupStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Bodies, "bodies that expired should not be counted")
+}
+
+// TestCacheRecentFetch verifies the most recent origin fetch of a URL is kept apart from its
+// cached entry, for the interval it was recorded with.
+func TestCacheRecentFetch(t *testing.T) {
+	c, mr := setupTestCache(t, Config{Prefix: "test:"})
+	ctx := context.Background()
+
+	entry, _, err := c.GetRecent(ctx, "https://example.com")
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+
+	before := time.Now()
+	require.NoError(t, c.SetRecent(ctx, "https://example.com", &Entry{URL: "https://example.com", StatusCode: 200, Body: []byte("fetched")}, time.Minute))
+	require.NoError(t, c.Set(ctx, &Entry{URL: "https://example.com", StatusCode: 200, Body: []byte("cached"), StoredAt: time.Now()}))
+
+	entry, fetchedAt, err := c.GetRecent(ctx, "https://example.com")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, "fetched", string(entry.Body))
+	assert.False(t, fetchedAt.Before(before))
+
+	mr.FastForward(time.Minute)
+	entry, _, err = c.GetRecent(ctx, "https://example.com")
+	require.NoError(t, err)
+	assert.Nil(t, entry, "the fetch should be forgotten after its interval")
+}
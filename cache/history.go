@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// makeHistoryKey creates the Redis key holding the previous versions of a URL, newest first.
+func (c *Cache) makeHistoryKey(url string) string {
+	return c.prefix + "history:" + c.urlKey(url)
+}
+
+// recordVersion numbers entry against the URL's current entry. When the content changed,
+// the current entry is moved to the URL's history, which is trimmed to entry.MaxVersions-1.
+func (c *Cache) recordVersion(ctx context.Context, entry *Entry) error {
+	prev, err := c.Get(ctx, entry.URL)
+	if err != nil {
+		return err
+	}
+
+	if prev != nil && bytes.Equal(prev.Body, entry.Body) {
+		entry.Version = max(prev.Version, 1)
+		entry.ChangedAt = prev.ChangedAt
+		if entry.ChangedAt.IsZero() {
+			entry.ChangedAt = prev.StoredAt
+		}
+		return nil
+	}
+
+	entry.ChangedAt = entry.StoredAt
+
+	if prev == nil {
+		// The current entry expired without being replaced, so continue from the newest
+		// version still in the history.
+		history, err := c.readHistory(ctx, entry.URL, 1)
+		if err != nil {
+			return err
+		}
+		entry.Version = 1
+		if len(history) > 0 {
+			entry.Version = history[0].Version + 1
+		}
+		return nil
+	}
+
+	prev.Version = max(prev.Version, 1)
+	if prev.ChangedAt.IsZero() {
+		prev.ChangedAt = prev.StoredAt
+	}
+	prev.BodyHash = ""
+	if prev.BlobKey != "" {
+		prev.Body = nil
+	}
+	entry.Version = prev.Version + 1
+
+	data, err := json.Marshal(prev)
+	if err != nil {
+		return fmt.Errorf("failed to marshal entry: %w", err)
+	}
+	if c.config.EnableCompression && len(data) >= c.config.CompressionMinSize {
+		data, err = c.compress(data)
+		if err != nil {
+			return fmt.Errorf("failed to compress entry: %w", err)
+		}
+	}
+
+	key := c.makeHistoryKey(entry.URL)
+	pipe := c.client.TxPipeline()
+	pipe.LPush(ctx, key, data)
+	pipe.LTrim(ctx, key, 0, int64(entry.MaxVersions-2))
+	pipe.Expire(ctx, key, c.config.HistoryTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("redis history push failed: %w", err)
+	}
+	return nil
+}
+
+// History returns the previous versions of url, newest first. The current version is not
+// included; use Get for it. Versions whose body is gone from the blob store are skipped.
+func (c *Cache) History(ctx context.Context, url string) ([]*Entry, error) {
+	history, err := c.readHistory(ctx, url, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := history[:0]
+	for _, entry := range history {
+		if entry.BlobKey != "" && len(entry.Body) == 0 {
+			body, err := c.getExternalBody(ctx, entry.BlobKey)
+			if err != nil {
+				return nil, err
+			}
+			if body == nil {
+				continue
+			}
+			entry.Body = body
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// readHistory reads up to limit previous versions of url, newest first. A limit of 0 reads all.
+func (c *Cache) readHistory(ctx context.Context, url string, limit int64) ([]*Entry, error) {
+	items, err := c.client.LRange(ctx, c.makeHistoryKey(url), 0, limit-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis history read failed: %w", err)
+	}
+
+	entries := make([]*Entry, 0, len(items))
+	for _, item := range items {
+		data := []byte(item)
+		if c.config.EnableCompression && isGzipped(data) {
+			data, err = c.decompress(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decompress entry: %w", err)
+			}
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal entry: %w", err)
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
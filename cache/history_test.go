@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joeychilson/websurfer/blobstore"
+)
+
+// TestCacheHistoryKeepsPreviousVersions verifies changed content moves the previous entry to
+// the history, which is trimmed to MaxVersions.
+func TestCacheHistoryKeepsPreviousVersions(t *testing.T) {
+	cache, _ := setupTestCache(t, Config{EnableCompression: true, CompressionMinSize: 1})
+	ctx := context.Background()
+	url := "https://example.com"
+	start := time.Now().Add(-time.Hour)
+
+	for i := range 4 {
+		err := cache.Set(ctx, &Entry{
+			URL:         url,
+			StatusCode:  200,
+			Body:        fmt.Appendf(nil, "version %d", i+1),
+			StoredAt:    start.Add(time.Duration(i) * time.Minute),
+			TTL:         time.Hour,
+			StaleTime:   time.Hour,
+			MaxVersions: 3,
+		})
+		require.NoError(t, err)
+	}
+
+	current, err := cache.Get(ctx, url)
+	require.NoError(t, err)
+	require.NotNil(t, current)
+	assert.Equal(t, 4, current.Version)
+	assert.WithinDuration(t, start.Add(3*time.Minute), current.ChangedAt, time.Millisecond)
+
+	history, err := cache.History(ctx, url)
+	require.NoError(t, err)
+	require.Len(t, history, 2, "history should hold MaxVersions-1 entries")
+	assert.Equal(t, 3, history[0].Version)
+	assert.Equal(t, "version 3", string(history[0].Body))
+	assert.Equal(t, 2, history[1].Version)
+	assert.Equal(t, "version 2", string(history[1].Body))
+}
+
+// TestCacheHistoryUnchangedContent verifies storing the same content keeps its version and
+// change time without adding history.
+func TestCacheHistoryUnchangedContent(t *testing.T) {
+	cache, _ := setupTestCache(t, Config{EnableDeduplication: true})
+	ctx := context.Background()
+	url := "https://example.com"
+	first := time.Now().Add(-time.Minute)
+
+	for _, storedAt := range []time.Time{first, time.Now()} {
+		err := cache.Set(ctx, &Entry{
+			URL:         url,
+			Body:        []byte("same"),
+			StoredAt:    storedAt,
+			MaxVersions: 5,
+		})
+		require.NoError(t, err)
+	}
+
+	current, err := cache.Get(ctx, url)
+	require.NoError(t, err)
+	require.NotNil(t, current)
+	assert.Equal(t, 1, current.Version)
+	assert.WithinDuration(t, first, current.ChangedAt, time.Millisecond)
+
+	history, err := cache.History(ctx, url)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+// TestCacheHistoryDisabled verifies entries without MaxVersions keep no history.
+func TestCacheHistoryDisabled(t *testing.T) {
+	cache, mr := setupTestCache(t, Config{})
+	ctx := context.Background()
+
+	for _, body := range []string{"one", "two"} {
+		require.NoError(t, cache.Set(ctx, &Entry{URL: "https://example.com", Body: []byte(body), StoredAt: time.Now()}))
+	}
+
+	current, err := cache.Get(ctx, "https://example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 0, current.Version)
+	assert.False(t, mr.Exists(cache.makeHistoryKey("https://example.com")))
+}
+
+// TestCacheDeleteRemovesHistory verifies deleting an entry also deletes its history.
+func TestCacheDeleteRemovesHistory(t *testing.T) {
+	cache, mr := setupTestCache(t, Config{})
+	ctx := context.Background()
+
+	for _, body := range []string{"one", "two"} {
+		require.NoError(t, cache.Set(ctx, &Entry{URL: "https://example.com", Body: []byte(body), StoredAt: time.Now(), MaxVersions: 2}))
+	}
+	require.True(t, mr.Exists(cache.makeHistoryKey("https://example.com")))
+
+	require.NoError(t, cache.Delete(ctx, "https://example.com"))
+	assert.False(t, mr.Exists(cache.makeHistoryKey("https://example.com")))
+}
+
+// TestCacheHistoryBlobStore verifies previous versions with bodies in the blob store keep only
+// the pointer in Redis and are read back from the blob store.
+func TestCacheHistoryBlobStore(t *testing.T) {
+	blobs, err := blobstore.NewFilesystem(t.TempDir())
+	require.NoError(t, err)
+
+	cache, mr := setupTestCache(t, Config{BlobStore: blobs, BlobThreshold: 8})
+	ctx := context.Background()
+	url := "https://example.com"
+
+	for i := range 2 {
+		err := cache.Set(ctx, &Entry{URL: url, Body: fmt.Appendf(nil, "large version %d", i+1), StoredAt: time.Now(), MaxVersions: 2})
+		require.NoError(t, err)
+	}
+
+	raw, err := mr.List(cache.makeHistoryKey(url))
+	require.NoError(t, err)
+	require.Len(t, raw, 1)
+	var stored Entry
+	require.NoError(t, json.Unmarshal([]byte(raw[0]), &stored))
+	assert.Empty(t, stored.Body, "history should not embed the body")
+	assert.NotEmpty(t, stored.BlobKey)
+
+	history, err := cache.History(ctx, url)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, 1, history[0].Version)
+	assert.Equal(t, "large version 1", string(history[0].Body))
+}
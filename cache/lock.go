@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// unlockScript deletes a fetch lock only if it is still held by the token that took it, so a
+// lock that expired and was taken by another instance is left alone.
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// makeLockKey creates the Redis key holding the fetch lock of a URL.
+func (c *Cache) makeLockKey(url string) string {
+	return c.prefix + "lock:" + c.urlKey(url)
+}
+
+// Lock takes the fetch lock of url for ttl, so that only one instance of a deployment fetches
+// the page from origin while the others wait for it to be cached. It reports false if another
+// caller holds the lock. The returned release function frees the lock if this caller still
+// holds it.
+func (c *Cache) Lock(ctx context.Context, url string, ttl time.Duration) (func(), bool, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, false, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+	key := c.makeLockKey(url)
+
+	acquired, err := c.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("redis lock failed: %w", err)
+	}
+	if !acquired {
+		return nil, false, nil
+	}
+
+	release := func() {
+		// The lock is released even if the fetch's context was canceled.
+		_ = unlockScript.Run(context.WithoutCancel(ctx), c.client, []string{key}, token).Err()
+	}
+	return release, true, nil
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCacheLock verifies a fetch lock is held by one caller until released or expired, and a
+// stale release does not free a lock taken since.
+func TestCacheLock(t *testing.T) {
+	cache, mr := setupTestCache(t, Config{})
+	ctx := context.Background()
+	url := "https://example.com/page"
+
+	release, acquired, err := cache.Lock(ctx, url, time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	_, acquired, err = cache.Lock(ctx, url, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired, "a held lock should not be taken again")
+
+	_, acquired, err = cache.Lock(ctx, "https://example.com/other", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired, "locks should be per URL")
+
+	release()
+	second, acquired, err := cache.Lock(ctx, url, time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired, "a released lock should be free")
+
+	mr.FastForward(2 * time.Minute)
+	_, acquired, err = cache.Lock(ctx, url, time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired, "an expired lock should be free")
+
+	second()
+	assert.True(t, mr.Exists(cache.makeLockKey(url)), "releasing an expired lock should not free the new holder's")
+}
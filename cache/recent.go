@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// recentFetch is the most recent origin fetch of a URL.
+type recentFetch struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Entry     *Entry    `json:"entry"`
+}
+
+// makeRecentKey creates the Redis key holding the most recent origin fetch of a URL.
+func (c *Cache) makeRecentKey(url string) string {
+	return c.prefix + "recent:" + c.urlKey(url)
+}
+
+// SetRecent records entry as the most recent origin fetch of url, kept for interval. It is
+// stored apart from the cached entry, so it is kept for sites that aren't cached, and rewrites
+// of the cached entry, such as renewing it after a 304, don't change when url was fetched.
+func (c *Cache) SetRecent(ctx context.Context, url string, entry *Entry, interval time.Duration) error {
+	data, err := json.Marshal(recentFetch{FetchedAt: time.Now(), Entry: entry})
+	if err != nil {
+		return fmt.Errorf("failed to marshal recent fetch: %w", err)
+	}
+
+	if c.config.EnableCompression && len(data) >= c.config.CompressionMinSize {
+		data, err = c.compress(data)
+		if err != nil {
+			return fmt.Errorf("failed to compress recent fetch: %w", err)
+		}
+	}
+
+	if err := c.client.Set(ctx, c.makeRecentKey(url), data, interval).Err(); err != nil {
+		return fmt.Errorf("redis set failed: %w", err)
+	}
+	return nil
+}
+
+// GetRecent returns the entry of the most recent origin fetch of url and when it was fetched,
+// or a nil entry if none was recorded within the interval it was kept for.
+func (c *Cache) GetRecent(ctx context.Context, url string) (*Entry, time.Time, error) {
+	data, err := c.client.Get(ctx, c.makeRecentKey(url)).Bytes()
+	if err == redis.Nil {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("redis get failed: %w", err)
+	}
+
+	if isGzipped(data) {
+		data, err = c.decompress(data)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to decompress recent fetch: %w", err)
+		}
+	}
+
+	var recent recentFetch
+	if err := json.Unmarshal(data, &recent); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to unmarshal recent fetch: %w", err)
+	}
+	return recent.Entry, recent.FetchedAt, nil
+}
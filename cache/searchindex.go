@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// makeSearchIndexKey creates the Redis key holding the search index of the content with hash.
+func (c *Cache) makeSearchIndexKey(hash string) string {
+	return c.prefix + "searchindex:" + hash
+}
+
+// GetSearchIndex returns the search index stored for the content with hash, or nil if there is
+// none. Reading an index extends its expiration, so indexes of pages still being searched stay
+// cached.
+func (c *Cache) GetSearchIndex(ctx context.Context, hash string, expiration time.Duration) ([]byte, error) {
+	data, err := c.client.GetEx(ctx, c.makeSearchIndexKey(hash), c.searchIndexExpiration(expiration)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis search index get failed: %w", err)
+	}
+
+	if isGzipped(data) {
+		data, err = c.decompress(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress search index: %w", err)
+		}
+	}
+	return data, nil
+}
+
+// SetSearchIndex stores the search index of the content with hash. Indexes are keyed by
+// content rather than URL, so they outlive refetches of unchanged pages and are shared by URLs
+// serving the same content. A zero expiration keeps the index as long as an entry.
+func (c *Cache) SetSearchIndex(ctx context.Context, hash string, data []byte, expiration time.Duration) error {
+	if c.config.EnableCompression && len(data) >= c.config.CompressionMinSize {
+		compressed, err := c.compress(data)
+		if err != nil {
+			return fmt.Errorf("failed to compress search index: %w", err)
+		}
+		data = compressed
+	}
+
+	if err := c.client.Set(ctx, c.makeSearchIndexKey(hash), data, c.searchIndexExpiration(expiration)).Err(); err != nil {
+		return fmt.Errorf("redis search index set failed: %w", err)
+	}
+	return nil
+}
+
+// searchIndexExpiration returns expiration, or the lifetime of an entry when it is zero.
+func (c *Cache) searchIndexExpiration(expiration time.Duration) time.Duration {
+	if expiration > 0 {
+		return expiration
+	}
+	return c.config.TTL + c.config.StaleTime
+}
@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSearchIndex verifies search indexes round-trip, compressed, and expire.
+func TestSearchIndex(t *testing.T) {
+	ctx := context.Background()
+	c, mr := setupTestCache(t, Config{EnableCompression: true, CompressionMinSize: 16})
+
+	data, err := c.GetSearchIndex(ctx, "abc", 0)
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	index := []byte(`{"words":{"` + strings.Repeat("word", 100) + `":[0]}}`)
+	require.NoError(t, c.SetSearchIndex(ctx, "abc", index, time.Minute))
+
+	data, err = c.GetSearchIndex(ctx, "abc", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, index, data)
+	assert.Equal(t, time.Hour, mr.TTL("websurfer:searchindex:abc"), "reads extend the expiration")
+
+	mr.FastForward(2 * time.Hour)
+	data, err = c.GetSearchIndex(ctx, "abc", 0)
+	require.NoError(t, err)
+	assert.Nil(t, data)
+}
@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// snapshotFormat identifies snapshot archives in their header.
+	snapshotFormat = "websurfer-cache-snapshot"
+	// snapshotVersion is the version of the snapshot format written by Export.
+	snapshotVersion = 1
+	// scanBatchSize is how many keys are requested per SCAN call when exporting a domain.
+	scanBatchSize = 500
+)
+
+// snapshotHeader is the first record of a snapshot archive.
+type snapshotHeader struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportFilter selects the entries written to a snapshot. Set Domain to export every cached URL
+// on a host, or URLs to export specific URLs.
+type ExportFilter struct {
+	Domain string
+	URLs   []string
+}
+
+// ImportOptions controls how snapshot entries are stored.
+type ImportOptions struct {
+	// TTL overrides how long imported entries stay fresh. Zero keeps each entry's exported TTL.
+	TTL time.Duration
+}
+
+// Export writes the cached entries selected by filter to w as a gzip-compressed stream of JSON
+// records, a header followed by one entry per URL, and returns the number of entries written.
+// URLs that are not cached are skipped.
+func (c *Cache) Export(ctx context.Context, w io.Writer, filter ExportFilter) (int, error) {
+	urls := filter.URLs
+	if filter.Domain != "" {
+		var err error
+		urls, err = c.domainURLs(ctx, filter.Domain)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	gz := gzip.NewWriter(w)
+	encoder := json.NewEncoder(gz)
+	if err := encoder.Encode(snapshotHeader{Format: snapshotFormat, Version: snapshotVersion, CreatedAt: time.Now().UTC()}); err != nil {
+		return 0, fmt.Errorf("failed to write snapshot header: %w", err)
+	}
+
+	count := 0
+	for _, u := range urls {
+		entry, err := c.Get(ctx, u)
+		if err != nil {
+			return count, err
+		}
+		if entry == nil {
+			continue
+		}
+
+		entry.BodyHash = ""
+		entry.BlobKey = ""
+		entry.OriginSHA256 = ""
+		if err := encoder.Encode(entry); err != nil {
+			return count, fmt.Errorf("failed to write snapshot entry: %w", err)
+		}
+		count++
+	}
+
+	if err := gz.Close(); err != nil {
+		return count, fmt.Errorf("failed to finish snapshot: %w", err)
+	}
+	return count, nil
+}
+
+// Import stores the entries of a snapshot written by Export and returns the number imported.
+// Entries are stored as if just fetched, so they are fresh for their TTL from now.
+func (c *Cache) Import(ctx context.Context, r io.Reader, opts ImportOptions) (int, error) {
+	gz, err := gzip.NewReader(bufio.NewReader(r))
+	if err != nil {
+		return 0, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	defer gz.Close()
+
+	decoder := json.NewDecoder(gz)
+
+	var header snapshotHeader
+	if err := decoder.Decode(&header); err != nil || header.Format != snapshotFormat {
+		return 0, fmt.Errorf("invalid snapshot: missing header")
+	}
+	if header.Version > snapshotVersion {
+		return 0, fmt.Errorf("unsupported snapshot version %d", header.Version)
+	}
+
+	count := 0
+	for {
+		var entry Entry
+		err := decoder.Decode(&entry)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return count, fmt.Errorf("invalid snapshot entry: %w", err)
+		}
+		if entry.URL == "" {
+			continue
+		}
+
+		entry.BodyHash = ""
+		entry.BlobKey = ""
+		entry.OriginSHA256 = ""
+		entry.StoredAt = time.Now()
+		if opts.TTL > 0 {
+			entry.TTL = opts.TTL
+		}
+		if err := c.Set(ctx, &entry); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// domainURLs returns the cached URLs whose host is domain.
+func (c *Cache) domainURLs(ctx context.Context, domain string) ([]string, error) {
+	domain = strings.ToLower(domain)
+	pattern := c.prefix + "http*://" + escapeGlob(domain) + "*"
+
+	var urls []string
+	var cursor uint64
+	for {
+		keys, next, err := c.client.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return nil, fmt.Errorf("redis scan failed: %w", err)
+		}
+
+		for _, key := range keys {
+			raw := strings.TrimPrefix(key, c.prefix)
+			u, err := url.Parse(raw)
+			if err != nil || (u.Host != domain && u.Hostname() != domain) {
+				continue
+			}
+			urls = append(urls, raw)
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	return urls, nil
+}
+
+// escapeGlob escapes the characters Redis treats as glob syntax in MATCH patterns.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
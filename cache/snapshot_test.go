@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSnapshotRoundTrip verifies exported entries import into another cache as fresh entries.
+func TestSnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source, _ := setupTestCache(t, Config{EnableDeduplication: true, EnableCompression: true})
+
+	for _, u := range []string{"https://example.com/a", "https://example.com/b", "https://example.com.evil.net/c", "https://other.com/d"} {
+		require.NoError(t, source.Set(ctx, &Entry{URL: u, StatusCode: 200, Body: []byte("body of " + u), Title: u, StoredAt: time.Now().Add(-time.Minute)}))
+	}
+
+	var archive bytes.Buffer
+	count, err := source.Export(ctx, &archive, ExportFilter{Domain: "example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count, "only entries on the exact host should be exported")
+
+	target, _ := setupTestCache(t, Config{})
+	count, err = target.Import(ctx, bytes.NewReader(archive.Bytes()), ImportOptions{TTL: 24 * time.Hour})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	entry, err := target.Get(ctx, "https://example.com/a")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, "body of https://example.com/a", string(entry.Body))
+	assert.Equal(t, "https://example.com/a", entry.Title)
+	assert.Equal(t, 24*time.Hour, entry.TTL)
+	assert.Equal(t, StateFresh, entry.GetState())
+
+	entry, err = target.Get(ctx, "https://other.com/d")
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+}
+
+// TestSnapshotExportURLs verifies exporting a URL list skips URLs that are not cached.
+func TestSnapshotExportURLs(t *testing.T) {
+	ctx := context.Background()
+	c, _ := setupTestCache(t, Config{})
+	require.NoError(t, c.Set(ctx, &Entry{URL: "https://example.com/a", Body: []byte("a"), StoredAt: time.Now()}))
+
+	var archive bytes.Buffer
+	count, err := c.Export(ctx, &archive, ExportFilter{URLs: []string{"https://example.com/a", "https://example.com/missing"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+// TestSnapshotImportInvalid verifies archives that are not snapshots are rejected.
+func TestSnapshotImportInvalid(t *testing.T) {
+	c, _ := setupTestCache(t, Config{})
+
+	_, err := c.Import(context.Background(), bytes.NewReader([]byte("not gzip")), ImportOptions{})
+	assert.Error(t, err)
+
+	var empty bytes.Buffer
+	_, err = c.Export(context.Background(), &empty, ExportFilter{})
+	require.NoError(t, err)
+	count, err := c.Import(context.Background(), &empty, ImportOptions{})
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
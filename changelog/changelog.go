@@ -0,0 +1,201 @@
+package changelog
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// versionRegex matches a version number such as 1.2, v2.0.1, or 3.0.0-beta.1.
+	versionRegex = regexp.MustCompile(`(?i)(?:^|[\s\[(/@])(v?\d+(?:\.\d+)+(?:[-+][0-9a-z][0-9a-z.-]*)?)\b`)
+	// unreleasedRegex matches headings for changes that have not been released yet.
+	unreleasedRegex = regexp.MustCompile(`(?i)^\W*unreleased\b`)
+	// isoDateRegex matches an ISO 8601 date, such as 2024-03-05.
+	isoDateRegex = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	// writtenDateRegex matches a date with a month name, such as March 5, 2024, Mar 5 2024, or
+	// 5 March 2024.
+	writtenDateRegex = regexp.MustCompile(`(?i)\b(?:(\d{1,2})(?:st|nd|rd|th)?\s+([a-z]{3,9})\.?,?\s+(\d{4})|([a-z]{3,9})\.?\s+(\d{1,2})(?:st|nd|rd|th)?,?\s+(\d{4}))\b`)
+	// headingRegex matches a markdown ATX heading.
+	headingRegex = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	// bulletRegex matches a markdown list item.
+	bulletRegex = regexp.MustCompile(`^(\s*)(?:[-*+]|\d+[.)])\s+(.*)$`)
+	// linkRegex matches a markdown link or image, capturing its text.
+	linkRegex = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+)
+
+// Entry is a release in a changelog.
+type Entry struct {
+	// Version is the release's version, such as "1.2.0", or "Unreleased".
+	Version string `json:"version"`
+	// Date is the release date as YYYY-MM-DD, when the changelog states one.
+	Date string `json:"date,omitempty"`
+	// Title is the release's heading.
+	Title   string   `json:"title"`
+	Changes []Change `json:"changes"`
+}
+
+// Change is a single change in a release.
+type Change struct {
+	// Type is the heading the change is listed under within the release, such as "Added" or
+	// "Bug Fixes", if any.
+	Type string `json:"type,omitempty"`
+	Text string `json:"text"`
+}
+
+// Extract returns the releases listed in a markdown changelog or release notes page, in the
+// order they appear. A release starts at a heading that names a version (or "Unreleased") and
+// runs until the next heading at the same or a higher level. Its list items are its changes,
+// typed by any subheading they appear under. The release date is read from the heading or the
+// first paragraph below it.
+func Extract(markdown []byte) []Entry {
+	var (
+		entries []Entry
+		current *Entry
+		level   int
+		// changeType is the subheading changes are currently listed under.
+		changeType string
+		// lastIndent is the indentation of the last list item, so continuation lines can be
+		// told apart from new paragraphs.
+		lastIndent = -1
+		inFence    bool
+	)
+
+	finish := func() {
+		if current != nil {
+			entries = append(entries, *current)
+			current = nil
+		}
+	}
+
+	for _, line := range strings.Split(string(markdown), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		if m := headingRegex.FindStringSubmatch(trimmed); m != nil {
+			headingLevel, text := len(m[1]), cleanText(m[2])
+			lastIndent = -1
+
+			if version := findVersion(text); version != "" && (current == nil || headingLevel <= level) {
+				finish()
+				current = &Entry{Version: version, Date: findDate(text), Title: text, Changes: []Change{}}
+				level = headingLevel
+				changeType = ""
+				continue
+			}
+
+			if current != nil && headingLevel <= level {
+				finish()
+			}
+			if current != nil {
+				changeType = text
+			}
+			continue
+		}
+
+		if current == nil || trimmed == "" {
+			continue
+		}
+
+		if m := bulletRegex.FindStringSubmatch(line); m != nil {
+			if text := cleanText(m[2]); text != "" {
+				current.Changes = append(current.Changes, Change{Type: changeType, Text: text})
+				lastIndent = len(m[1])
+			}
+			continue
+		}
+
+		if lastIndent >= 0 && indentation(line) > lastIndent && len(current.Changes) > 0 {
+			last := &current.Changes[len(current.Changes)-1]
+			last.Text += " " + cleanText(trimmed)
+			continue
+		}
+		lastIndent = -1
+
+		if current.Date == "" && len(current.Changes) == 0 {
+			current.Date = findDate(trimmed)
+		}
+	}
+	finish()
+
+	return entries
+}
+
+// findVersion returns the version named in a heading, or "" if there is none. Headings that
+// are just a date, such as "2024-03-05", are not versions.
+func findVersion(heading string) string {
+	if unreleasedRegex.MatchString(heading) {
+		return "Unreleased"
+	}
+
+	withoutDates := isoDateRegex.ReplaceAllString(heading, " ")
+	m := versionRegex.FindStringSubmatch(withoutDates)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// findDate returns the first date in text as YYYY-MM-DD, or "" if there is none.
+func findDate(text string) string {
+	if m := isoDateRegex.FindStringSubmatch(text); m != nil {
+		if t, err := time.Parse("2006-01-02", m[0]); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+
+	for _, m := range writtenDateRegex.FindAllStringSubmatch(text, -1) {
+		day, month, year := m[1], m[2], m[3]
+		if day == "" {
+			month, day, year = m[4], m[5], m[6]
+		}
+		if t, ok := parseWrittenDate(day, month, year); ok {
+			return t.Format("2006-01-02")
+		}
+	}
+	return ""
+}
+
+// parseWrittenDate parses a date with a full or abbreviated month name.
+func parseWrittenDate(day, month, year string) (time.Time, bool) {
+	value := day + " " + strings.ToLower(month) + " " + year
+	for _, layout := range []string{"2 January 2006", "2 Jan 2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	if strings.EqualFold(month, "sept") {
+		return parseWrittenDate(day, "sep", year)
+	}
+	return time.Time{}, false
+}
+
+// cleanText reduces markdown inline formatting to plain text: links keep their text, and
+// emphasis markers are removed.
+func cleanText(text string) string {
+	text = linkRegex.ReplaceAllString(text, "$1")
+	text = strings.NewReplacer("**", "", "__", "").Replace(text)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// indentation returns how many leading spaces a line has, counting tabs as four.
+func indentation(line string) int {
+	n := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			n++
+		case '\t':
+			n += 4
+		default:
+			return n
+		}
+	}
+	return n
+}
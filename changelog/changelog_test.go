@@ -0,0 +1,96 @@
+package changelog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExtractKeepAChangelog verifies releases, dates, and typed changes are extracted from a
+// Keep a Changelog style page.
+func TestExtractKeepAChangelog(t *testing.T) {
+	content := []byte(`# Changelog
+
+All notable changes to this project will be documented in this file.
+
+## [Unreleased]
+
+### Added
+- Support for [custom tokenizers](https://example.com/docs).
+
+## [1.2.0] - 2024-03-05
+
+### Added
+- **Streaming** responses.
+- Retry budget that spans
+  multiple lines.
+
+### Fixed
+* Crash on empty bodies.
+
+## [1.1.0] - 2023-12-01
+
+- Initial release.
+
+[1.2.0]: https://example.com/compare/v1.1.0...v1.2.0`)
+
+	entries := Extract(content)
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, "Unreleased", entries[0].Version)
+	assert.Empty(t, entries[0].Date)
+	assert.Equal(t, []Change{{Type: "Added", Text: "Support for custom tokenizers."}}, entries[0].Changes)
+
+	assert.Equal(t, "1.2.0", entries[1].Version)
+	assert.Equal(t, "2024-03-05", entries[1].Date)
+	assert.Equal(t, []Change{
+		{Type: "Added", Text: "Streaming responses."},
+		{Type: "Added", Text: "Retry budget that spans multiple lines."},
+		{Type: "Fixed", Text: "Crash on empty bodies."},
+	}, entries[1].Changes)
+
+	assert.Equal(t, "1.1.0", entries[2].Version)
+	assert.Equal(t, "2023-12-01", entries[2].Date)
+	assert.Equal(t, []Change{{Text: "Initial release."}}, entries[2].Changes)
+}
+
+// TestExtractReleaseNotes verifies release notes with prefixed versions and written dates below
+// the heading are extracted.
+func TestExtractReleaseNotes(t *testing.T) {
+	content := []byte(`# Release Notes
+
+## Version v2.0.0-beta.1
+
+Released March 5th, 2024
+
+1. Rewrote the parser.
+2. Dropped Go 1.20.
+
+## Release 1.9 (5 Sept 2023)
+
+- Faster caching.
+
+## Support
+
+- Email us.`)
+
+	entries := Extract(content)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "v2.0.0-beta.1", entries[0].Version)
+	assert.Equal(t, "Version v2.0.0-beta.1", entries[0].Title)
+	assert.Equal(t, "2024-03-05", entries[0].Date)
+	assert.Equal(t, []Change{{Text: "Rewrote the parser."}, {Text: "Dropped Go 1.20."}}, entries[0].Changes)
+
+	assert.Equal(t, "1.9", entries[1].Version)
+	assert.Equal(t, "2023-09-05", entries[1].Date)
+	assert.Equal(t, []Change{{Text: "Faster caching."}}, entries[1].Changes)
+}
+
+// TestExtractNoReleases verifies pages without version headings yield no entries.
+func TestExtractNoReleases(t *testing.T) {
+	content := []byte("# Blog\n\n## 2024-03-05\n\n- Not a release.\n\n```\n## 1.0.0\n```")
+
+	assert.Empty(t, Extract(content))
+}
@@ -0,0 +1,205 @@
+package charset
+
+import (
+	"bytes"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	htmlcharset "golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+const (
+	// UTF8 is the name of the UTF-8 encoding.
+	UTF8 = "utf-8"
+	// fallback is the encoding assumed when nothing else identifies one, as browsers do.
+	fallback = "windows-1252"
+	// prescanSize is how much of a document is searched for an encoding declaration.
+	prescanSize = 1024
+	// binarySampleSize is how much of a body is inspected to tell binary data from text.
+	binarySampleSize = 8192
+	// maxControlRatio is the share of control characters above which a body is binary.
+	maxControlRatio = 0.05
+	// maxInvalidRatio is the share of invalid bytes above which a UTF-8 body is binary.
+	maxInvalidRatio = 0.3
+)
+
+// xmlDeclRegex extracts the encoding from an XML declaration.
+var xmlDeclRegex = regexp.MustCompile(`^\s*<\?xml[^>]*\sencoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// Detect returns the name of body's character encoding, such as "utf-8", "shift_jis", or
+// "windows-1252". The encoding is taken, in order, from a byte order mark, the charset
+// parameter of contentType, a <meta charset> or XML declaration, and finally by sniffing the
+// bytes: valid UTF-8, then Shift_JIS or GBK text, then windows-1252.
+func Detect(body []byte, contentType string) string {
+	head := body[:min(len(body), prescanSize)]
+
+	if _, name, certain := htmlcharset.DetermineEncoding(head, contentType); certain {
+		return name
+	}
+
+	if m := xmlDeclRegex.FindSubmatch(head); m != nil {
+		if _, name := htmlcharset.Lookup(string(m[1])); name != "" {
+			return name
+		}
+	}
+	if _, name, _ := htmlcharset.DetermineEncoding(head, "text/html"); name != UTF8 && name != fallback {
+		return name
+	}
+
+	return sniff(body)
+}
+
+// ToUTF8 transcodes body to UTF-8 from the encoding Detect finds, returning the body and the
+// encoding's name. UTF-8 bodies are returned unchanged, without a leading byte order mark.
+func ToUTF8(body []byte, contentType string) ([]byte, string, error) {
+	name := Detect(body, contentType)
+	if name == UTF8 {
+		return bytes.TrimPrefix(body, []byte("\xef\xbb\xbf")), name, nil
+	}
+
+	enc, _ := htmlcharset.Lookup(name)
+	if enc == nil {
+		return body, name, nil
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return body, name, err
+	}
+	return decoded, name, nil
+}
+
+// IsText reports whether contentType is a text format that may need transcoding: text/* and
+// the HTML and XML application types.
+func IsText(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == "application/xhtml+xml" ||
+		mediaType == "application/xml" ||
+		strings.HasSuffix(mediaType, "+xml")
+}
+
+// IsBinary reports whether body looks like binary data rather than text, as when an image or
+// archive is served as text/html. The start of body is binary when it holds NUL bytes, which
+// only UTF-16 text has, when more than 5% of it is control characters, or, when the text is
+// UTF-8 by its declaration or format, such as JSON, when more than 30% of it is invalid UTF-8.
+func IsBinary(body []byte, contentType string) bool {
+	sample := body[:min(len(body), binarySampleSize)]
+	if len(sample) == 0 {
+		return false
+	}
+
+	name := Detect(sample, contentType)
+	if strings.HasPrefix(name, "utf-16") {
+		return false
+	}
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+
+	var controls int
+	for _, b := range sample {
+		if b < 0x20 && b != '\t' && b != '\n' && b != '\v' && b != '\f' && b != '\r' && b != 0x1b {
+			controls++
+		}
+	}
+	if float64(controls) > maxControlRatio*float64(len(sample)) {
+		return true
+	}
+
+	if name != UTF8 && !isUTF8Format(contentType) {
+		return false
+	}
+	var invalid int
+	for i := 0; i < len(sample); {
+		r, size := utf8.DecodeRune(sample[i:])
+		if r == utf8.RuneError && size == 1 {
+			// A character cut off by the end of the sample is not invalid.
+			if len(sample) < len(body) && !utf8.FullRune(sample[i:]) {
+				break
+			}
+			invalid++
+		}
+		i += size
+	}
+	return float64(invalid) > maxInvalidRatio*float64(len(sample))
+}
+
+// isUTF8Format reports whether contentType is a format that is always UTF-8, such as JSON.
+func isUTF8Format(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// WithUTF8 returns contentType with its charset parameter set to utf-8.
+func WithUTF8(contentType string) string {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	params["charset"] = UTF8
+	return mime.FormatMediaType(mediaType, params)
+}
+
+// sniff guesses the encoding of a body without a declared encoding.
+func sniff(body []byte) string {
+	if utf8.Valid(body) {
+		return UTF8
+	}
+
+	if isJapanese(decodes(japanese.ShiftJIS, body)) {
+		return "shift_jis"
+	}
+	if isChinese(decodes(simplifiedchinese.GBK, body)) {
+		return "gbk"
+	}
+	return fallback
+}
+
+// decodes returns body decoded with enc, or "" if body is not valid in enc.
+func decodes(enc encoding.Encoding, body []byte) string {
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil || bytes.ContainsRune(decoded, utf8.RuneError) {
+		return ""
+	}
+	return string(decoded)
+}
+
+// isJapanese reports whether text contains kana, which Japanese text nearly always does and
+// other encodings decoded as Shift_JIS rarely produce.
+func isJapanese(text string) bool {
+	for _, r := range text {
+		if unicode.In(r, unicode.Hiragana, unicode.Katakana) && r < 0xff00 {
+			return true
+		}
+	}
+	return false
+}
+
+// isChinese reports whether most of the non-ASCII characters in text are Han ideographs or
+// CJK punctuation.
+func isChinese(text string) bool {
+	var han, other int
+	for _, r := range text {
+		switch {
+		case r < utf8.RuneSelf:
+		case unicode.Is(unicode.Han, r) || (r >= 0x3000 && r <= 0x303f) || (r >= 0xff00 && r <= 0xffef):
+			han++
+		default:
+			other++
+		}
+	}
+	return han > 0 && han >= 4*other
+}
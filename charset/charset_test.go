@@ -0,0 +1,105 @@
+package charset
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// TestDetect verifies the encoding is taken from the Content-Type, declarations, and bytes.
+func TestDetect(t *testing.T) {
+	latin1, err := charmap.ISO8859_1.NewEncoder().Bytes([]byte("<p>Café crème</p>"))
+	require.NoError(t, err)
+	sjis, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte("<p>こんにちは、世界。日本語のページです。</p>"))
+	require.NoError(t, err)
+	gbk, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte("<p>你好，世界。这是一个中文页面。</p>"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		want        string
+	}{
+		{"content type charset", latin1, "text/html; charset=ISO-8859-1", "windows-1252"},
+		{"byte order mark", []byte("\xef\xbb\xbf<p>hi</p>"), "text/html; charset=shift_jis", "utf-8"},
+		{"meta charset", append([]byte(`<meta charset="shift_jis">`), sjis...), "text/html", "shift_jis"},
+		{"xml declaration", append([]byte(`<?xml version="1.0" encoding="GBK"?>`), gbk...), "application/xml", "gbk"},
+		{"valid utf-8", []byte("<p>" + string(make([]byte, 2000)) + "Café</p>"), "text/html", "utf-8"},
+		{"sniffed shift_jis", sjis, "text/html", "shift_jis"},
+		{"sniffed gbk", gbk, "text/html", "gbk"},
+		{"fallback", latin1, "text/plain", "windows-1252"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Detect(tt.body, tt.contentType))
+		})
+	}
+}
+
+// TestToUTF8 verifies bodies are transcoded to UTF-8 and UTF-8 bodies are kept as is.
+func TestToUTF8(t *testing.T) {
+	sjis, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte("<p>日本語のページ</p>"))
+	require.NoError(t, err)
+
+	body, name, err := ToUTF8(sjis, "text/html; charset=Shift_JIS")
+	require.NoError(t, err)
+	assert.Equal(t, "shift_jis", name)
+	assert.Equal(t, "<p>日本語のページ</p>", string(body))
+
+	latin1 := []byte("<p>Caf\xe9</p>")
+	body, name, err = ToUTF8(latin1, "text/html")
+	require.NoError(t, err)
+	assert.Equal(t, "windows-1252", name)
+	assert.Equal(t, "<p>Café</p>", string(body))
+
+	utf8Body := []byte("\xef\xbb\xbf<p>Café</p>")
+	body, name, err = ToUTF8(utf8Body, "text/html")
+	require.NoError(t, err)
+	assert.Equal(t, "utf-8", name)
+	assert.Equal(t, "<p>Café</p>", string(body))
+}
+
+// TestIsText verifies which content types are transcoded.
+func TestIsText(t *testing.T) {
+	assert.True(t, IsText("text/html; charset=utf-8"))
+	assert.True(t, IsText("text/csv"))
+	assert.True(t, IsText("application/xhtml+xml"))
+	assert.True(t, IsText("application/rss+xml"))
+	assert.False(t, IsText("application/pdf"))
+	assert.False(t, IsText("application/json"))
+	assert.False(t, IsText(""))
+}
+
+// TestIsBinary verifies binary bodies are told apart from text in any encoding.
+func TestIsBinary(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x01\x00")
+	assert.True(t, IsBinary(png, "text/html"))
+	assert.True(t, IsBinary(bytes.Repeat([]byte("\x01\x02abc\xff"), 100), "text/plain"))
+	assert.True(t, IsBinary(bytes.Repeat([]byte("a\xfe\xc3\x28"), 100), "application/json"))
+
+	assert.False(t, IsBinary([]byte("<p>Café, déjà vu.</p>"), "text/html; charset=utf-8"))
+	assert.False(t, IsBinary([]byte("<p>Caf\xe9, d\xe9j\xe0 vu.</p>"), "text/html"))
+	assert.False(t, IsBinary([]byte("\xff\xfeh\x00i\x00"), "text/plain"))
+	assert.False(t, IsBinary([]byte("\x1b[31mred\x1b[0m\tlog\n"), "text/plain"))
+
+	shiftJIS := []byte("\x82\xb1\x82\xf1\x82\xc9\x82\xbf\x82\xcd")
+	assert.False(t, IsBinary(shiftJIS, "text/plain; charset=shift_jis"))
+
+	// A character cut off at the end of the sample is not counted as invalid.
+	long := append(bytes.Repeat([]byte("é"), binarySampleSize/2-1), "xé"...)
+	assert.False(t, IsBinary(long, "text/plain; charset=utf-8"))
+	assert.False(t, IsBinary(nil, "text/plain"))
+}
+
+// TestWithUTF8 verifies the charset parameter is replaced and other parameters are kept.
+func TestWithUTF8(t *testing.T) {
+	assert.Equal(t, "text/html; charset=utf-8", WithUTF8("text/html; charset=Shift_JIS"))
+	assert.Equal(t, "text/csv; charset=utf-8; header=present", WithUTF8("text/csv; header=present"))
+}
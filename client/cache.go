@@ -3,15 +3,23 @@ package client
 import (
 	"context"
 	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"github.com/joeychilson/websurfer/cache"
+	"github.com/joeychilson/websurfer/lines"
+	"github.com/joeychilson/websurfer/tracing"
 )
 
 const (
 	// backgroundRefreshTimeout is the maximum time allowed for background cache refresh operations.
 	backgroundRefreshTimeout = 30 * time.Second
+	// fetchLockPollInterval is how often a fetch waiting on another instance's fetch lock
+	// checks whether it was released.
+	fetchLockPollInterval = 50 * time.Millisecond
 )
 
 // CacheManager handles all caching operations including background refresh.
@@ -22,6 +30,23 @@ type CacheManager struct {
 	shutdownCtx    context.Context
 	shutdownCancel context.CancelFunc
 	coordinator    *FetchCoordinator
+
+	statsMu      sync.Mutex
+	refreshStats RefreshStats
+}
+
+// RefreshStats counts the outcomes of background refreshes since startup.
+type RefreshStats struct {
+	// Updated counts refreshes that rewrote the cached entry with new content, and Skipped
+	// those that kept it because the content changed less than the site's refresh_min_change.
+	Updated int64
+	Skipped int64
+	// NotModified counts refreshes the origin answered with 304 Not Modified, and Failed those
+	// that could not fetch the page.
+	NotModified int64
+	Failed      int64
+	// ChangeSum is the sum of the change ratios of the Updated and Skipped refreshes.
+	ChangeSum float64
 }
 
 // NewCacheManager creates a new cache manager.
@@ -50,7 +75,10 @@ func (m *CacheManager) Get(ctx context.Context, urlStr string) *cache.Entry {
 		return nil
 	}
 
+	ctx, span := tracing.Start(ctx, "websurfer.cache.get", attribute.String("url.full", urlStr))
 	entry, err := m.cache.Get(ctx, urlStr)
+	span.SetAttributes(attribute.Bool("websurfer.cache.hit", entry != nil))
+	tracing.End(span, err)
 	if err != nil {
 		m.logger.Error("cache get failed", "url", urlStr, "error", err)
 		return nil
@@ -65,11 +93,125 @@ func (m *CacheManager) Set(ctx context.Context, entry *cache.Entry) {
 		return
 	}
 
-	if err := m.cache.Set(ctx, entry); err != nil {
+	ctx, span := tracing.Start(ctx, "websurfer.cache.set", attribute.String("url.full", entry.URL), attribute.Int("websurfer.body_size", len(entry.Body)))
+	err := m.cache.Set(ctx, entry)
+	tracing.End(span, err)
+	if err != nil {
 		m.logger.Error("cache set failed", "url", entry.URL, "error", err)
 	}
 }
 
+// Delete removes an entry from cache, logging errors but not failing.
+func (m *CacheManager) Delete(ctx context.Context, urlStr string) {
+	if m.cache == nil {
+		return
+	}
+
+	if err := m.cache.Delete(ctx, urlStr); err != nil {
+		m.logger.Error("cache delete failed", "url", urlStr, "error", err)
+	}
+}
+
+// SetRecent records entry as the most recent origin fetch of urlStr when its site has a
+// min_refetch_interval, logging errors but not failing.
+func (m *CacheManager) SetRecent(ctx context.Context, urlStr string, entry *cache.Entry) {
+	if m.cache == nil {
+		return
+	}
+
+	interval := m.coordinator.config.GetConfigForURL(urlStr).Cache.MinRefetchInterval
+	if interval <= 0 {
+		return
+	}
+	if err := m.cache.SetRecent(ctx, urlStr, entry, interval); err != nil {
+		m.logger.Error("recent fetch set failed", "url", urlStr, "error", err)
+	}
+}
+
+// GetRecent returns the entry urlStr was last fetched from origin as if that was within
+// interval, or nil.
+func (m *CacheManager) GetRecent(ctx context.Context, urlStr string, interval time.Duration) *cache.Entry {
+	if m.cache == nil || interval <= 0 {
+		return nil
+	}
+
+	entry, fetchedAt, err := m.cache.GetRecent(ctx, urlStr)
+	if err != nil {
+		m.logger.Error("recent fetch get failed", "url", urlStr, "error", err)
+		return nil
+	}
+	if entry == nil || time.Since(fetchedAt) >= interval {
+		return nil
+	}
+	return entry
+}
+
+// History returns the cached versions of urlStr, newest first, starting with the current entry.
+func (m *CacheManager) History(ctx context.Context, urlStr string) ([]*cache.Entry, error) {
+	if m.cache == nil {
+		return nil, nil
+	}
+
+	current, err := m.cache.Get(ctx, urlStr)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := m.cache.History(ctx, urlStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if current == nil {
+		return previous, nil
+	}
+	return append([]*cache.Entry{current}, previous...), nil
+}
+
+// LockFetch takes the fetch lock of urlStr for ttl before it is fetched from origin. While
+// another instance holds the lock, it waits for the lock to be released and returns the fresh
+// entry that instance cached, if any. Otherwise it returns a function releasing the lock once
+// the fetch is cached. Fetches proceed without the lock when Redis fails or the wait exceeds ttl.
+func (m *CacheManager) LockFetch(ctx context.Context, urlStr string, ttl time.Duration) (*cache.Entry, func()) {
+	noop := func() {}
+	if m.cache == nil {
+		return nil, noop
+	}
+
+	deadline := time.Now().Add(ttl)
+	waited := false
+	for {
+		release, acquired, err := m.cache.Lock(ctx, urlStr, ttl)
+		if err != nil {
+			m.logger.Warn("fetch lock failed, fetching without it", "url", urlStr, "error", err)
+			return nil, noop
+		}
+		if acquired {
+			if waited {
+				if entry := m.Get(ctx, urlStr); entry != nil && entry.GetState() == cache.StateFresh {
+					release()
+					return entry, noop
+				}
+			}
+			return nil, release
+		}
+
+		if !waited {
+			m.logger.Debug("waiting for another instance's fetch", "url", urlStr)
+			waited = true
+		}
+		if time.Now().After(deadline) {
+			m.logger.Warn("fetch lock wait timed out, fetching without it", "url", urlStr, "timeout", ttl)
+			return nil, noop
+		}
+		select {
+		case <-ctx.Done():
+			return nil, noop
+		case <-time.After(fetchLockPollInterval):
+		}
+	}
+}
+
 // StartBackgroundRefresh initiates a background refresh of stale cache content.
 func (m *CacheManager) StartBackgroundRefresh(urlStr string, entry *cache.Entry) {
 	if m.cache == nil {
@@ -97,7 +239,7 @@ func (m *CacheManager) refreshInBackground(urlStr string, entry *cache.Entry) {
 	refreshCtx, cancel := context.WithTimeout(m.shutdownCtx, backgroundRefreshTimeout)
 	defer cancel()
 
-	newEntry, err := m.coordinator.Fetch(refreshCtx, urlStr, entry.LastModified)
+	result, err := m.coordinator.Fetch(refreshCtx, urlStr, entry.LastModified)
 	if err != nil {
 		if m.shutdownCtx.Err() != nil {
 			m.logger.Debug("background refresh cancelled due to shutdown", "url", urlStr)
@@ -105,32 +247,94 @@ func (m *CacheManager) refreshInBackground(urlStr string, entry *cache.Entry) {
 		}
 		if refreshCtx.Err() == context.DeadlineExceeded {
 			m.logger.Warn("background refresh timed out", "url", urlStr, "timeout", backgroundRefreshTimeout)
+			m.recordRefresh(func(stats *RefreshStats) { stats.Failed++ })
 			return
 		}
 		m.logger.Error("background refresh failed", "url", urlStr, "error", err)
+		m.recordRefresh(func(stats *RefreshStats) { stats.Failed++ })
 		return
 	}
 
-	if newEntry != nil {
-		m.handleRefreshWithNewContent(refreshCtx, urlStr, newEntry)
+	if result.Entry != nil {
+		m.handleRefreshWithNewContent(refreshCtx, urlStr, entry, result.Entry)
 	} else {
 		m.handleRefreshNotModified(refreshCtx, urlStr, entry)
 	}
 }
 
-// handleRefreshWithNewContent stores newly fetched content from background refresh.
-func (m *CacheManager) handleRefreshWithNewContent(ctx context.Context, urlStr string, newEntry *cache.Entry) {
+// handleRefreshWithNewContent stores newly fetched content from background refresh. Content
+// the origin marked no-store replaces the stale entry by removing it, and content that changed
+// less than the site's refresh_min_change only renews the stale entry's timestamp, so pages
+// with rotating ads or timestamps don't rewrite the cache on every refresh.
+func (m *CacheManager) handleRefreshWithNewContent(ctx context.Context, urlStr string, entry, newEntry *cache.Entry) {
+	if isNoStore(newEntry.Headers) {
+		m.logger.Debug("background refresh: content is no-store, removing cached entry", "url", urlStr)
+		m.Delete(ctx, urlStr)
+		return
+	}
+
+	m.SetRecent(ctx, urlStr, newEntry)
+
+	change := lines.Change(string(entry.Body), string(newEntry.Body))
+	minChange := m.coordinator.config.GetConfigForURL(urlStr).Cache.RefreshMinChange
+	m.logger.Debug("background refresh: content fetched", "url", urlStr, "change", change)
+
+	if change < minChange && newEntry.StatusCode == entry.StatusCode {
+		m.recordRefresh(func(stats *RefreshStats) {
+			stats.Skipped++
+			stats.ChangeSum += change
+		})
+		if err := m.cache.Set(ctx, entry.WithUpdatedTimestamp()); err != nil {
+			m.logger.Error("background refresh timestamp update failed", "url", urlStr, "error", err)
+		} else {
+			m.logger.Debug("background refresh completed (change below threshold)", "url", urlStr, "change", change, "min_change", minChange)
+		}
+		return
+	}
+
+	m.recordRefresh(func(stats *RefreshStats) {
+		stats.Updated++
+		stats.ChangeSum += change
+	})
 	if err := m.cache.Set(ctx, newEntry); err != nil {
 		m.logger.Error("background refresh cache set failed", "url", urlStr, "error", err)
 	} else {
-		m.logger.Debug("background refresh completed with new content", "url", urlStr)
+		m.logger.Debug("background refresh completed with new content", "url", urlStr, "change", change)
+	}
+}
+
+// recordRefresh updates the background refresh counters.
+func (m *CacheManager) recordRefresh(update func(*RefreshStats)) {
+	m.statsMu.Lock()
+	defer m.statsMu.Unlock()
+	update(&m.refreshStats)
+}
+
+// RefreshStats returns the background refresh counters.
+func (m *CacheManager) RefreshStats() RefreshStats {
+	m.statsMu.Lock()
+	defer m.statsMu.Unlock()
+	return m.refreshStats
+}
+
+// isNoStore reports whether headers carry a Cache-Control: no-store directive.
+func isNoStore(headers map[string][]string) bool {
+	for _, value := range headers["Cache-Control"] {
+		for directive := range strings.SplitSeq(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+				return true
+			}
+		}
 	}
+	return false
 }
 
 // handleRefreshNotModified updates the cache timestamp when content hasn't changed.
 func (m *CacheManager) handleRefreshNotModified(ctx context.Context, urlStr string, entry *cache.Entry) {
 	m.logger.Debug("background refresh: content not modified", "url", urlStr)
+	m.recordRefresh(func(stats *RefreshStats) { stats.NotModified++ })
 	updatedEntry := entry.WithUpdatedTimestamp()
+	m.SetRecent(ctx, urlStr, updatedEntry)
 	if err := m.cache.Set(ctx, updatedEntry); err != nil {
 		m.logger.Error("background refresh timestamp update failed", "url", urlStr, "error", err)
 	} else {
@@ -4,17 +4,36 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/joeychilson/websurfer/budget"
 	"github.com/joeychilson/websurfer/cache"
 	"github.com/joeychilson/websurfer/config"
+	"github.com/joeychilson/websurfer/fetcher"
+	"github.com/joeychilson/websurfer/forms"
 	"github.com/joeychilson/websurfer/headless"
+	"github.com/joeychilson/websurfer/hreflang"
 	"github.com/joeychilson/websurfer/parser"
+	csvparser "github.com/joeychilson/websurfer/parser/csv"
 	htmlparser "github.com/joeychilson/websurfer/parser/html"
 	"github.com/joeychilson/websurfer/parser/pdf"
 	"github.com/joeychilson/websurfer/parser/rules"
+	xmlparser "github.com/joeychilson/websurfer/parser/xml"
 	"github.com/joeychilson/websurfer/ratelimit"
+	"github.com/joeychilson/websurfer/retry"
+	"github.com/joeychilson/websurfer/robots"
+	"github.com/joeychilson/websurfer/structured"
+	"github.com/joeychilson/websurfer/tracing"
 	urlpkg "github.com/joeychilson/websurfer/url"
+	"github.com/joeychilson/websurfer/urlnorm"
+	"github.com/joeychilson/websurfer/wayback"
 )
 
 // Client is a thin facade that coordinates FetchCoordinator and CacheManager.
@@ -22,6 +41,8 @@ type Client struct {
 	coordinator  *FetchCoordinator
 	cacheManager *CacheManager
 	logger       *slog.Logger
+	// flights shares one origin fetch among concurrent cache misses for the same URL.
+	flights singleflight.Group
 }
 
 // New creates a new Client with the given configuration.
@@ -45,6 +66,7 @@ func New(cfg *config.Config) (*Client, error) {
 		htmlparser.WithRules(
 			rules.NewSECRule(),
 			rules.NewSECTableRule(),
+			rules.NewWikipediaRule(),
 		),
 	)
 
@@ -52,10 +74,19 @@ func New(cfg *config.Config) (*Client, error) {
 	parserRegistry := parser.New()
 	parserRegistry.Register([]string{"text/html", "application/xhtml+xml"}, htmlParser)
 	parserRegistry.Register([]string{"application/pdf"}, pdfParser)
+	parserRegistry.Register([]string{"application/xml", "text/xml"}, xmlparser.New())
+	parserRegistry.Register([]string{"text/csv", "application/csv"}, csvparser.New())
+	parserRegistry.Register([]string{"text/tab-separated-values"}, csvparser.New(csvparser.WithDelimiter('\t')))
+
+	headlessPool := headless.NewPool(
+		headless.New(headless.WithLogger(logger)),
+		headless.WithPoolSize(cfg.Headless.PoolSize),
+		headless.WithRecycleAfter(cfg.Headless.RecycleAfter),
+	)
 
-	headlessBrowser := headless.New(headless.WithLogger(logger))
+	budgetTracker := budget.New()
 
-	coordinator := NewFetchCoordinator(cfg, limiter, parserRegistry, headlessBrowser, logger)
+	coordinator := NewFetchCoordinator(cfg, limiter, budgetTracker, parserRegistry, headlessPool, logger)
 	cacheManager := NewCacheManager(nil, logger, coordinator)
 
 	return &Client{
@@ -77,7 +108,25 @@ func NewFromFile(path string) (*Client, error) {
 
 // WithCache sets the cache for response caching.
 func (c *Client) WithCache(responseCache *cache.Cache) *Client {
-	c.cacheManager.cache = responseCache
+	c.cacheManager.cache = responseCache.WithKeyNormalizer(c.NormalizeURL)
+	return c
+}
+
+// WithRateLimitStore shares per-domain rate limit state with other instances through store.
+func (c *Client) WithRateLimitStore(store ratelimit.Store) *Client {
+	c.coordinator.limiter.WithStore(store)
+	return c
+}
+
+// WithRateLimitState persists per-domain request pacing through store, so it survives restarts.
+func (c *Client) WithRateLimitState(store ratelimit.StateStore) *Client {
+	c.coordinator.limiter.WithStateStore(store)
+	return c
+}
+
+// WithRobotsCache shares fetched robots.txt files with other instances through cache.
+func (c *Client) WithRobotsCache(cache robots.Cache) *Client {
+	c.coordinator.robots.WithCache(cache)
 	return c
 }
 
@@ -95,6 +144,53 @@ func (c *Client) Close() {
 	c.coordinator.Close()
 }
 
+// Config returns the client's configuration.
+func (c *Client) Config() *config.Config {
+	return c.coordinator.config
+}
+
+// WithWayback sets the client used to look up Wayback Machine snapshots for fallback_to_wayback.
+func (c *Client) WithWayback(archive *wayback.Client) *Client {
+	c.coordinator.wayback = archive
+	return c
+}
+
+// Cache returns the response cache, or nil if caching is disabled.
+func (c *Client) Cache() *cache.Cache {
+	return c.cacheManager.cache
+}
+
+// Stats is a snapshot of client state for operational visibility.
+type Stats struct {
+	RateLimit ratelimit.Stats
+	Headless  headless.PoolStats
+	Refresh   RefreshStats
+}
+
+// Stats returns a snapshot of the client's current state.
+func (c *Client) Stats() Stats {
+	return Stats{
+		RateLimit: c.coordinator.limiter.Stats(),
+		Headless:  c.coordinator.headless.Stats(),
+		Refresh:   c.cacheManager.RefreshStats(),
+	}
+}
+
+// Queue returns the requests currently waiting on rate limits or in flight, grouped by domain.
+func (c *Client) Queue() []ratelimit.DomainQueue {
+	return c.coordinator.limiter.Queue()
+}
+
+// GetSitemapsFromRobotsTxt returns the sitemaps declared in the robots.txt of urlStr's host.
+// robots.txt files are cached, so repeated calls for a host don't refetch it.
+func (c *Client) GetSitemapsFromRobotsTxt(ctx context.Context, urlStr string) ([]string, error) {
+	r, err := c.coordinator.robots.Get(ctx, urlStr)
+	if err != nil {
+		return nil, err
+	}
+	return r.Sitemaps, nil
+}
+
 // Response represents a fetched webpage with metadata.
 type Response struct {
 	URL         string
@@ -104,65 +200,349 @@ type Response struct {
 	Title       string
 	Description string
 	FaviconURL  string
-	CacheState  string
-	CachedAt    time.Time
+	ImageURL    string
+	// NextURL is the next page of a paginated series, if the page links to one.
+	NextURL string
+	// License and LicenseURL are the license the page declares, such as "CC BY 4.0".
+	License    string
+	LicenseURL string
+	// Robots lists the page's robots meta and X-Robots-Tag directives, such as "noindex".
+	Robots []string
+	// Binary is set when Body is binary data mislabeled as text, such as an image served as
+	// text/html. Body is then the unparsed bytes and no metadata is extracted.
+	Binary bool
+	// LeadImageURL and Excerpt are the main content image and a first-paragraph excerpt,
+	// estimated from the article body rather than social meta tags.
+	LeadImageURL string
+	Excerpt      string
+	// Structured holds the page's FAQs and how-tos, from schema.org markup or Q&A patterns.
+	Structured *structured.Data
+	// Forms lists the page's HTML forms, with their actions, methods, and fields.
+	Forms []forms.Form
+	// Extracted holds the values matched by FetchOptions.Selectors, by name.
+	Extracted map[string][]string
+	// Raw is the body the content was parsed from, set when FetchOptions.IncludeRaw is: the
+	// page's HTML as fetched, or as rendered in the headless browser, transcoded to UTF-8.
+	Raw []byte
+	// Alternates lists the page's language variants, from hreflang links.
+	Alternates []hreflang.Alternate
+	// CanonicalURL is the page's preferred URL, from rel="canonical" in the HTML or Link header.
+	CanonicalURL string
+	// NofollowLinks lists the targets of the page's links marked rel="nofollow", "ugc", or
+	// "sponsored", resolved against the page URL.
+	NofollowLinks []string
+	// OriginalURL is set when the page was gone or unreachable and was served from the
+	// Wayback Machine. URL is then the snapshot, archived at ArchivedAt.
+	OriginalURL string
+	ArchivedAt  time.Time
+	// OriginSHA256 is the hex SHA-256 of the body as the origin sent it, empty when the page
+	// was not fetched from its origin, such as imported entries and Wayback Machine snapshots.
+	// Rendered is set when the content was parsed from the page as rendered in the headless
+	// browser instead.
+	OriginSHA256      string
+	Rendered          bool
+	CacheState        string
+	CachedAt          time.Time
+	RefetchSuppressed bool
+	// Version numbers the page's distinct contents when the site keeps cache history, and
+	// ChangedAt is when this version was first fetched.
+	Version   int
+	ChangedAt time.Time
+	// Attempts is the origin fetch history, empty when served from cache.
+	Attempts []retry.Attempt
+	// Transfer describes how the response was transferred from origin, nil when served from cache.
+	Transfer *fetcher.Transfer
+}
+
+// Cache states reported in Response.CacheState.
+const (
+	CacheStateHit   = "hit"
+	CacheStateStale = "stale"
+	CacheStateMiss  = "miss"
+	// CacheStateDisabled means the site's cache is disabled, so the page was fetched and not stored.
+	CacheStateDisabled = "disabled"
+	// CacheStateNoStore means the origin sent Cache-Control: no-store, so the page was not stored.
+	CacheStateNoStore = "no_store"
+)
+
+// FetchOptions contains optional parameters for fetch requests.
+type FetchOptions struct {
+	// BypassCache skips cached content and fetches from origin, unless the URL was
+	// fetched from origin within the site's min_refetch_interval.
+	BypassCache bool
+	// ParseOptions controls how images and links are rendered. Non-default options
+	// skip the cache, since cached entries hold default parser output.
+	ParseOptions parser.Options
+	// Headers and Cookies are sent with this fetch, over the site's configured headers.
+	// They skip the cache, since the response may be personalized.
+	Headers map[string]string
+	Cookies map[string]string
+	// CookieJar sends its cookies with this fetch and keeps the cookies set by every response,
+	// including redirects and headless renders. It skips the cache, like Cookies.
+	CookieJar http.CookieJar
+	// SkipCache fetches from origin without reading or writing the cache, for fetches whose
+	// response depends on the caller's state, such as a browsing session.
+	SkipCache bool
+	// Version serves that version of the page from cache history instead of fetching it.
+	// AsOf serves the version that was current at that time. Neither ever fetches.
+	Version int
+	AsOf    time.Time
+	// Selectors names CSS selectors evaluated against the page's HTML, rendered if the site
+	// renders it, such as {"price": ".product-price", "image": "img.hero @src"}. Their values
+	// are returned in Response.Extracted. They skip the cache, since it holds no HTML.
+	Selectors map[string]string
+	// IncludeRaw returns the body the content was parsed from in Response.Raw. It skips the
+	// cache, since it holds only parsed content.
+	IncludeRaw bool
+}
+
+// requestHeaders returns the header overrides for opts, with Cookies joined into a Cookie
+// header in name order.
+func (o *FetchOptions) requestHeaders() map[string]string {
+	if len(o.Headers) == 0 && len(o.Cookies) == 0 {
+		return nil
+	}
+
+	headers := maps.Clone(o.Headers)
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+	if len(o.Cookies) > 0 {
+		cookies := make([]string, 0, len(o.Cookies))
+		for _, name := range slices.Sorted(maps.Keys(o.Cookies)) {
+			cookies = append(cookies, (&http.Cookie{Name: name, Value: o.Cookies[name]}).String())
+		}
+		headers["Cookie"] = strings.Join(cookies, "; ")
+	}
+	return headers
 }
 
 // Fetch retrieves content from the given URL with rate limiting.
 func (c *Client) Fetch(ctx context.Context, urlStr string) (*Response, error) {
-	urlStr = urlpkg.Transform(urlStr)
+	return c.FetchWithOptions(ctx, urlStr, nil)
+}
+
+// FetchWithOptions retrieves content from the given URL with optional fetch options.
+func (c *Client) FetchWithOptions(ctx context.Context, urlStr string, opts *FetchOptions) (*Response, error) {
+	if opts == nil {
+		opts = &FetchOptions{}
+	}
+
+	urlStr = c.fetchURL(urlStr)
+
+	ctx, span := tracing.Start(ctx, "websurfer.fetch", attribute.String("url.full", urlStr))
+	resp, err := c.fetchWithOptions(ctx, urlStr, opts)
+	if resp != nil {
+		span.SetAttributes(attribute.String("websurfer.cache_state", resp.CacheState), attribute.Int("http.response.status_code", resp.StatusCode))
+	}
+	tracing.End(span, err)
+	return resp, err
+}
+
+// fetchWithOptions retrieves urlStr from cache or origin.
+func (c *Client) fetchWithOptions(ctx context.Context, urlStr string, opts *FetchOptions) (*Response, error) {
+	c.logger.Debug("fetch started", "url", urlStr, "bypass_cache", opts.BypassCache)
+
+	if opts.Version > 0 || !opts.AsOf.IsZero() {
+		return c.fetchVersion(ctx, urlStr, opts)
+	}
+
+	if opts.SkipCache || !opts.ParseOptions.IsDefault() || len(opts.Headers) > 0 || len(opts.Cookies) > 0 || opts.CookieJar != nil || len(opts.Selectors) > 0 || opts.IncludeRaw {
+		c.logger.Debug("cache skipped (custom fetch options)", "url", urlStr, "include_raw", opts.IncludeRaw, "skip_cache", opts.SkipCache, "images", opts.ParseOptions.Images, "links", opts.ParseOptions.Links, "format", opts.ParseOptions.Format, "headers", len(opts.Headers), "cookies", len(opts.Cookies), "cookie_jar", opts.CookieJar != nil, "selectors", len(opts.Selectors))
+		return c.fetchUncached(ctx, urlStr, opts, CacheStateMiss)
+	}
 
-	c.logger.Debug("fetch started", "url", urlStr)
+	cacheConfig := c.coordinator.config.GetConfigForURL(urlStr).Cache
+	if !cacheConfig.IsEnabled() {
+		if resp := c.recentFetch(ctx, urlStr, cacheConfig.MinRefetchInterval); resp != nil {
+			return resp, nil
+		}
+		c.logger.Debug("cache skipped (disabled for site)", "url", urlStr)
+		return c.fetchUncached(ctx, urlStr, opts, CacheStateDisabled)
+	}
+
+	if opts.BypassCache {
+		if resp := c.recentFetch(ctx, urlStr, cacheConfig.MinRefetchInterval); resp != nil {
+			return resp, nil
+		}
+	}
 
 	entry := c.cacheManager.Get(ctx, urlStr)
 
-	if entry != nil {
+	switch {
+	case entry != nil && opts.BypassCache:
+		c.logger.Debug("cache bypassed", "url", urlStr)
+
+	case entry != nil:
 		state := entry.GetState()
 
 		switch state {
 		case cache.StateFresh:
 			c.logger.Debug("cache hit (fresh)", "url", urlStr)
-			return buildResponse(entry, "hit"), nil
+			return buildResponse(entry, CacheStateHit), nil
 
 		case cache.StateStale:
 			c.logger.Debug("cache hit (stale, refreshing in background)", "url", urlStr)
 			c.cacheManager.StartBackgroundRefresh(urlStr, entry)
-			return buildResponse(entry, "stale"), nil
+			return buildResponse(entry, CacheStateStale), nil
 
 		case cache.StateTooOld:
 			c.logger.Debug("cache entry too old", "url", urlStr)
 		}
-	} else {
+
+	default:
 		c.logger.Debug("cache miss", "url", urlStr)
 	}
 
-	entry, err := c.coordinator.Fetch(ctx, urlStr, "")
+	return c.fetchShared(ctx, urlStr, cacheConfig)
+}
+
+// recentFetch returns the most recent origin fetch of urlStr, flagged as a suppressed refetch,
+// if it was fetched within interval, the site's min_refetch_interval. Otherwise it returns nil.
+func (c *Client) recentFetch(ctx context.Context, urlStr string, interval time.Duration) *Response {
+	entry := c.cacheManager.GetRecent(ctx, urlStr, interval)
+	if entry == nil {
+		return nil
+	}
+
+	c.logger.Debug("refetch suppressed (within min refetch interval)", "url", urlStr, "interval", interval)
+	resp := buildResponse(entry, CacheStateHit)
+	resp.RefetchSuppressed = true
+	return resp
+}
+
+// NormalizeURL returns urlStr normalized with its site's URL normalization rules, so that
+// trivially different URLs of the same page compare equal.
+func (c *Client) NormalizeURL(urlStr string) string {
+	normalization := c.coordinator.config.GetConfigForURL(urlStr).URLNormalization
+	return urlnorm.Normalize(urlStr, normalization.GetRules())
+}
+
+// CacheKey returns the URL urlStr is cached under, after it is transformed and normalized.
+func (c *Client) CacheKey(urlStr string) string {
+	return c.NormalizeURL(c.fetchURL(urlStr))
+}
+
+// fetchURL returns the URL fetched for urlStr, transformed to its optimal fetch format. The
+// cache normalizes it into the key the response is stored under.
+func (c *Client) fetchURL(urlStr string) string {
+	return urlpkg.Transform(urlStr)
+}
+
+// Cached returns the cached response for urlStr without fetching it, or nil if it is not cached.
+func (c *Client) Cached(ctx context.Context, urlStr string) *Response {
+	entry := c.cacheManager.Get(ctx, c.fetchURL(urlStr))
+	if entry == nil {
+		return nil
+	}
+	return buildResponse(entry, CacheStateHit)
+}
+
+// RobotsAllowed reports whether the site's robots.txt allows fetching urlStr, without fetching
+// the page itself. It is always true for sites that do not respect robots.txt.
+func (c *Client) RobotsAllowed(ctx context.Context, urlStr string) (bool, error) {
+	urlStr = c.fetchURL(urlStr)
+
+	resolved := c.coordinator.config.GetConfigForURL(urlStr)
+	if !resolved.Fetch.GetRespectRobots() {
+		return true, nil
+	}
+	return c.coordinator.robots.Allowed(ctx, urlStr, resolved.Fetch.GetRobotsUserAgent())
+}
+
+// Probe checks whether urlStr responds, requesting only its headers. Probes never read or
+// write the cache and are not counted against the site's budget.
+func (c *Client) Probe(ctx context.Context, urlStr string) (*fetcher.ProbeResult, error) {
+	urlStr = c.fetchURL(urlStr)
+
+	result, err := c.coordinator.Probe(ctx, urlStr)
+	if err != nil {
+		c.logger.Debug("probe failed", "url", urlStr, "error", err)
+		return nil, err
+	}
+
+	c.logger.Debug("probe completed", "url", urlStr, "status_code", result.StatusCode, "method", result.Method, "first_byte", result.FirstByte)
+	return result, nil
+}
+
+// fetchUncached fetches from origin with opts' parse options and request headers, bypassing
+// the cache. cacheState records why the cache was skipped. Fetches of sites with the cache
+// disabled are still recorded as the URL's most recent origin fetch, for min_refetch_interval.
+func (c *Client) fetchUncached(ctx context.Context, urlStr string, opts *FetchOptions, cacheState string) (*Response, error) {
+	ctx = parser.WithOptions(ctx, opts.ParseOptions)
+	if headers := opts.requestHeaders(); headers != nil {
+		ctx = fetcher.WithHeaders(ctx, headers)
+	}
+	if opts.CookieJar != nil {
+		ctx = fetcher.WithCookieJar(ctx, opts.CookieJar)
+	}
+	if len(opts.Selectors) > 0 {
+		ctx = withSelectors(ctx, opts.Selectors)
+	}
+	if opts.IncludeRaw {
+		ctx = withRaw(ctx)
+	}
+
+	result, err := c.coordinator.Fetch(ctx, urlStr, "")
+	if err == nil && result.Entry == nil {
+		err = fmt.Errorf("%w: %s", ErrNotModified, urlStr)
+	}
 	if err != nil {
 		c.logger.Error("fetch failed", "url", urlStr, "error", err)
 		return nil, err
 	}
 
-	c.cacheManager.Set(ctx, entry)
+	c.logger.Info("fetch completed", "url", urlStr, "status_code", result.Entry.StatusCode, "body_size", len(result.Entry.Body), "attempts", len(result.Attempts))
+	if cacheState == CacheStateDisabled && !isNoStore(result.Entry.Headers) {
+		c.cacheManager.SetRecent(ctx, urlStr, result.Entry)
+	}
 
-	c.logger.Info("fetch completed", "url", urlStr, "status_code", entry.StatusCode, "body_size", len(entry.Body))
-	return buildResponse(entry, "miss"), nil
+	resp := buildResponse(result.Entry, cacheState)
+	resp.Attempts = result.Attempts
+	resp.Transfer = result.Transfer
+	resp.Extracted = result.Extracted
+	resp.Raw = result.Raw
+	return resp, nil
 }
 
 // buildResponse creates a Response from a cache Entry.
 func buildResponse(entry *cache.Entry, cacheState string) *Response {
-	cachedAt := entry.StoredAt
-	if cacheState == "miss" {
-		cachedAt = time.Time{}
-	}
-	return &Response{
-		URL:         entry.URL,
-		StatusCode:  entry.StatusCode,
-		Headers:     entry.Headers,
-		Body:        entry.Body,
-		Title:       entry.Title,
-		Description: entry.Description,
-		FaviconURL:  entry.FaviconURL,
-		CacheState:  cacheState,
-		CachedAt:    cachedAt,
+	var cachedAt time.Time
+	if cacheState == CacheStateHit || cacheState == CacheStateStale {
+		cachedAt = entry.StoredAt
+	}
+	resp := &Response{
+		URL:           entry.URL,
+		StatusCode:    entry.StatusCode,
+		Headers:       entry.Headers,
+		Body:          entry.Body,
+		Title:         entry.Title,
+		Description:   entry.Description,
+		FaviconURL:    entry.FaviconURL,
+		ImageURL:      entry.ImageURL,
+		NextURL:       entry.NextURL,
+		License:       entry.License,
+		LicenseURL:    entry.LicenseURL,
+		Robots:        entry.Robots,
+		Binary:        entry.Binary,
+		Structured:    entry.Structured,
+		Forms:         entry.Forms,
+		Alternates:    entry.Alternates,
+		CanonicalURL:  entry.CanonicalURL,
+		NofollowLinks: entry.NofollowLinks,
+		LeadImageURL:  entry.LeadImageURL,
+		Excerpt:       entry.Excerpt,
+		OriginSHA256:  entry.OriginSHA256,
+		Rendered:      entry.Rendered,
+		CacheState:    cacheState,
+		CachedAt:      cachedAt,
+		Version:       entry.Version,
+		ChangedAt:     entry.ChangedAt,
+	}
+	if entry.ArchiveURL != "" {
+		resp.URL = entry.ArchiveURL
+		resp.OriginalURL = entry.URL
+		resp.ArchivedAt = entry.ArchivedAt
 	}
+	return resp
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -13,9 +14,21 @@ import (
 	"github.com/alicebob/miniredis/v2"
 	"github.com/joeychilson/websurfer/cache"
 	"github.com/joeychilson/websurfer/config"
+	"github.com/joeychilson/websurfer/forms"
+	"github.com/joeychilson/websurfer/hreflang"
+	"github.com/joeychilson/websurfer/parser"
+	"github.com/joeychilson/websurfer/robots"
+	"github.com/joeychilson/websurfer/structured"
+	"github.com/joeychilson/websurfer/transform"
+	"github.com/joeychilson/websurfer/wayback"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"golang.org/x/text/encoding/japanese"
 )
 
 // TestClientCreation verifies client can be created with default config.
@@ -118,6 +131,417 @@ func TestClientFetchTitleAndDescription(t *testing.T) {
 	assert.Equal(t, "This is a test page", resp.Description)
 }
 
+// TestClientFetchTranscodesCharset verifies non-UTF-8 pages are transcoded before parsing.
+func TestClientFetchTranscodesCharset(t *testing.T) {
+	page, err := japanese.ShiftJIS.NewEncoder().String(`<html><head><meta charset="Shift_JIS"><title>日本語のページ</title></head><body><p>こんにちは、世界。</p></body></html>`)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	client, err := New(nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	resp, err := client.Fetch(context.Background(), server.URL)
+
+	require.NoError(t, err)
+	assert.Equal(t, "日本語のページ", resp.Title)
+	assert.Contains(t, string(resp.Body), "こんにちは、世界。")
+	assert.Equal(t, "text/html; charset=utf-8", resp.Headers["Content-Type"][0])
+}
+
+// TestClientFetchImageURLs verifies favicon and social preview image URLs are extracted and resolved.
+func TestClientFetchImageURLs(t *testing.T) {
+	html := `<html>
+<head>
+<link rel="icon" href="/static/icon.png">
+<meta name="twitter:image" content="https://cdn.example.com/twitter.png">
+<meta property="og:image" content="/images/preview.jpg">
+</head>
+<body>Content</body>
+</html>`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(html))
+	}))
+	defer server.Close()
+
+	client, err := New(nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	resp, err := client.Fetch(context.Background(), server.URL+"/page")
+
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/static/icon.png", resp.FaviconURL)
+	assert.Equal(t, server.URL+"/images/preview.jpg", resp.ImageURL)
+}
+
+// TestClientFetchNextURL verifies next page links are found from rel="next", link labels, and
+// the Link header.
+func TestClientFetchNextURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/rel":
+			w.Write([]byte(`<html><head><link rel="next" href="/rel?page=2"></head><body><a href="/other">Next</a></body></html>`))
+		case "/label":
+			w.Write([]byte(`<html><body><a href="#top">Next</a><a href="page/3">Next page &raquo;</a></body></html>`))
+		case "/header":
+			w.Header().Set("Link", `</header?page=1>; rel="prev", </header?page=3>; rel="next"`)
+			w.Write([]byte(`<html><body>Content</body></html>`))
+		default:
+			w.Write([]byte(`<html><body><a href="/next-steps">Next steps for your project</a></body></html>`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := New(nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	tests := map[string]string{
+		"/rel":    server.URL + "/rel?page=2",
+		"/label":  server.URL + "/page/3",
+		"/header": server.URL + "/header?page=3",
+		"/none":   "",
+	}
+	for path, expected := range tests {
+		resp, err := client.Fetch(context.Background(), server.URL+path)
+		require.NoError(t, err)
+		assert.Equal(t, expected, resp.NextURL, path)
+	}
+}
+
+// TestClientFetchLicenseAndRobots verifies license and robots directives reach the response.
+func TestClientFetchLicenseAndRobots(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("X-Robots-Tag", "noai")
+		w.Write([]byte(`<html><head><meta name="robots" content="noindex"><link rel="license" href="https://creativecommons.org/licenses/by/4.0/"></head><body>Content</body></html>`))
+	}))
+	defer server.Close()
+
+	client, err := New(nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	resp, err := client.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "CC BY 4.0", resp.License)
+	assert.Equal(t, "https://creativecommons.org/licenses/by/4.0/", resp.LicenseURL)
+	assert.Equal(t, []string{"noindex", "noai"}, resp.Robots)
+}
+
+// TestClientFetchAlternates verifies hreflang links from the HTML or Link header reach the response.
+func TestClientFetchAlternates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/header" {
+			w.Header().Set("Link", `</de/header>; rel="alternate"; hreflang="de", </header>; rel="canonical"`)
+			w.Write([]byte(`<html><body>Content</body></html>`))
+			return
+		}
+		w.Write([]byte(`<html><head>
+<link rel="alternate" hreflang="en" href="/en/">
+<link rel="alternate" hreflang="de-DE" href="https://example.de/">
+<link rel="alternate" hreflang="x-default" href="/">
+<link rel="alternate" type="application/rss+xml" href="/feed.xml">
+</head><body>Content</body></html>`))
+	}))
+	defer server.Close()
+
+	client, err := New(nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	resp, err := client.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, []hreflang.Alternate{
+		{Lang: "en", URL: server.URL + "/en/"},
+		{Lang: "de-DE", URL: "https://example.de/"},
+		{Lang: "x-default", URL: server.URL + "/"},
+	}, resp.Alternates)
+
+	resp, err = client.Fetch(context.Background(), server.URL+"/header")
+	require.NoError(t, err)
+	assert.Equal(t, []hreflang.Alternate{{Lang: "de", URL: server.URL + "/de/header"}}, resp.Alternates)
+}
+
+// TestClientFetchCanonicalNofollow verifies canonical URLs and nofollow links reach the response.
+func TestClientFetchCanonicalNofollow(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/header" {
+			w.Header().Set("Link", `</docs>; rel="canonical"`)
+			w.Write([]byte(`<html><body>Content</body></html>`))
+			return
+		}
+		w.Write([]byte(`<html><head><link rel="canonical" href="/docs"></head><body>
+<a href="/guide">Guide</a> <a rel="nofollow noopener" href="/login">Log in</a> <a rel="ugc" href="https://spam.example/">Comment</a>
+</body></html>`))
+	}))
+	defer server.Close()
+
+	client, err := New(nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	resp, err := client.Fetch(context.Background(), server.URL+"/docs?ref=nav")
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/docs", resp.CanonicalURL)
+	assert.Equal(t, []string{server.URL + "/login", "https://spam.example/"}, resp.NofollowLinks)
+
+	resp, err = client.Fetch(context.Background(), server.URL+"/header")
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/docs", resp.CanonicalURL)
+}
+
+// TestClientFetchStructured verifies FAQs found in the page reach the response.
+func TestClientFetchStructured(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><h1>FAQ</h1><details><summary>Is it fast?</summary>Very.</details></body></html>`))
+	}))
+	defer server.Close()
+
+	client, err := New(nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	resp, err := client.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	require.NotNil(t, resp.Structured)
+	assert.Equal(t, []structured.QA{{Question: "Is it fast?", Answer: "Very."}}, resp.Structured.FAQ)
+}
+
+// TestClientFetchForms verifies forms found in the page reach the response with absolute actions.
+func TestClientFetchForms(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><form action="/search"><input name="q" aria-label="Query"></form></body></html>`))
+	}))
+	defer server.Close()
+
+	client, err := New(nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	resp, err := client.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	require.Len(t, resp.Forms, 1)
+	assert.Equal(t, server.URL+"/search", resp.Forms[0].Action)
+	assert.Equal(t, []forms.Field{{Name: "q", Type: "text", Label: "Query"}}, resp.Forms[0].Fields)
+}
+
+// TestClientFetchSanitizeProfile verifies a site's sanitization profile is applied to its pages.
+func TestClientFetchSanitizeProfile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><main><h1>Docs</h1><p>Run <code>make test</code> first.</p><blockquote><p>Quoted advice.</p></blockquote></main></body></html>`))
+	}))
+	defer server.Close()
+
+	cfg := config.New()
+	cfg.Sites = []config.SiteConfig{{
+		Pattern: strings.TrimPrefix(server.URL, "http://"),
+		Fetch:   &config.FetchConfig{Sanitize: "permissive"},
+	}}
+
+	client, err := New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+
+	resp, err := client.Fetch(context.Background(), server.URL+"/docs")
+	require.NoError(t, err)
+	assert.Contains(t, string(resp.Body), "`make test`")
+	assert.Contains(t, string(resp.Body), "> Quoted advice.")
+}
+
+// TestClientFetchTransforms verifies the site's transforms rewrite the parsed content before
+// it is cached.
+func TestClientFetchTransforms(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><main><h1>Docs</h1><p>Getting started.</p><h2>Site menu</h2><ul><li><a href="/">Home</a></li></ul><h2>Install</h2><p>Run the installer.</p><p>Sponsored: try our cloud.</p></main></body></html>`))
+	}))
+	defer server.Close()
+
+	cfg := config.New()
+	cfg.Sites = []config.SiteConfig{{
+		Pattern: strings.TrimPrefix(server.URL, "http://"),
+		Transforms: []transform.Rule{
+			{Type: transform.StripSection, Pattern: `^Site menu$`},
+			{Type: transform.DropLines, Pattern: `^Sponsored:`},
+		},
+	}}
+	require.NoError(t, cfg.Validate())
+
+	client, err := New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+
+	resp, err := client.Fetch(context.Background(), server.URL+"/docs")
+	require.NoError(t, err)
+	assert.Contains(t, string(resp.Body), "Run the installer.")
+	assert.NotContains(t, string(resp.Body), "Site menu")
+	assert.NotContains(t, string(resp.Body), "Home")
+	assert.NotContains(t, string(resp.Body), "Sponsored")
+}
+
+// TestClientFetchMainContent verifies sites with a main content region are converted from the
+// matching elements only, keeping the page's metadata.
+func TestClientFetchMainContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/plain" {
+			w.Write([]byte(`<html><head><title>Plain</title></head><body><p>Whole page.</p></body></html>`))
+			return
+		}
+		w.Write([]byte(`<html><head><title>Release notes</title></head><body><div id="nav">Products Pricing Login</div><div class="layout"><div class="col-main"><h1>Release notes</h1><p>Version 2 is out.</p></div><div class="col-side">Newsletter signup</div></div></body></html>`))
+	}))
+	defer server.Close()
+
+	cfg := config.New()
+	cfg.Sites = []config.SiteConfig{{
+		Pattern: strings.TrimPrefix(server.URL, "http://"),
+		Fetch:   &config.FetchConfig{MainContent: "//div[contains(@class, 'col-main')]"},
+	}}
+	require.NoError(t, cfg.Validate())
+
+	client, err := New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+
+	resp, err := client.Fetch(context.Background(), server.URL+"/notes")
+	require.NoError(t, err)
+	assert.Equal(t, "Release notes", resp.Title)
+	assert.Contains(t, string(resp.Body), "Version 2 is out.")
+	assert.NotContains(t, string(resp.Body), "Pricing")
+	assert.NotContains(t, string(resp.Body), "Newsletter")
+
+	resp, err = client.Fetch(context.Background(), server.URL+"/plain")
+	require.NoError(t, err)
+	assert.Contains(t, string(resp.Body), "Whole page.")
+
+	cfg.Sites[0].Fetch.MainContent = "//div["
+	assert.Error(t, cfg.Validate())
+}
+
+// TestClientFetchFormat verifies the raw_html format returns the whole page as fetched, ignoring
+// the main content region, and the text format a plain-text rendering, neither cached.
+func TestClientFetchFormat(t *testing.T) {
+	page := `<html><head><title>Docs</title></head><body><div id="nav">Home</div><main><h1>Install</h1><p>Run <a href="/setup">setup</a>.</p></main></body></html>`
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer redisClient.Close()
+
+	cfg := config.New()
+	cfg.Sites = []config.SiteConfig{{
+		Pattern: strings.TrimPrefix(server.URL, "http://"),
+		Fetch:   &config.FetchConfig{MainContent: "main"},
+	}}
+	require.NoError(t, cfg.Validate())
+
+	client, err := New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+	client.WithCache(cache.New(redisClient, cache.Config{}))
+
+	resp, err := client.FetchWithOptions(context.Background(), server.URL, &FetchOptions{ParseOptions: parser.Options{Format: parser.FormatRawHTML}})
+	require.NoError(t, err)
+	assert.Equal(t, page, string(resp.Body))
+	assert.Equal(t, "Docs", resp.Title)
+
+	resp, err = client.FetchWithOptions(context.Background(), server.URL, &FetchOptions{ParseOptions: parser.Options{Format: parser.FormatText}})
+	require.NoError(t, err)
+	assert.Equal(t, "Install\n\nRun setup.", string(resp.Body))
+
+	resp, err = client.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Contains(t, string(resp.Body), "# Install")
+	assert.Equal(t, CacheStateMiss, resp.CacheState, "other formats should not have been cached")
+	assert.Equal(t, int32(3), fetches.Load())
+}
+
+// TestClientFetchIncludeRaw verifies the raw body is returned alongside the parsed content,
+// whole even when the site narrows the content to its main region, and only when requested.
+func TestClientFetchIncludeRaw(t *testing.T) {
+	page := `<html><head><title>Docs</title></head><body><div id="nav">Home</div><main><h1>Install</h1></main></body></html>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	cfg := config.New()
+	cfg.Sites = []config.SiteConfig{{
+		Pattern: strings.TrimPrefix(server.URL, "http://"),
+		Fetch:   &config.FetchConfig{MainContent: "main"},
+	}}
+	require.NoError(t, cfg.Validate())
+
+	client, err := New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+
+	resp, err := client.FetchWithOptions(context.Background(), server.URL, &FetchOptions{IncludeRaw: true})
+	require.NoError(t, err)
+	assert.Equal(t, page, string(resp.Raw))
+	assert.Contains(t, string(resp.Body), "# Install")
+	assert.NotContains(t, string(resp.Body), "Home")
+
+	resp, err = client.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Nil(t, resp.Raw)
+}
+
+// TestClientFetchYouTubeTranscript verifies YouTube videos are fetched as their transcript.
+func TestClientFetchYouTubeTranscript(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/watch":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<html><body><script>var ytInitialPlayerResponse = {"playabilityStatus":{"status":"OK"},"videoDetails":{"videoId":"f6kdp27TYZs","title":"Go Concurrency Patterns","lengthSeconds":"3106","author":"Google for Developers"},"captions":{"playerCaptionsTracklistRenderer":{"captionTracks":[{"baseUrl":"%s/api/timedtext?v=f6kdp27TYZs&lang=en","name":{"simpleText":"English"},"languageCode":"en"}]}}};</script></body></html>`, server.URL)
+		case "/api/timedtext":
+			w.Header().Set("Content-Type", "text/xml")
+			w.Write([]byte(`<transcript><text start="0" dur="2">Hello, gophers.</text><text start="40" dur="2">Channels.</text></transcript>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cfg := config.New()
+	cfg.Default.Fetch.URLRewrites = []config.URLRewrite{{Pattern: "https://www.youtube.com", Replacement: server.URL}}
+
+	client, err := New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+
+	resp, err := client.Fetch(context.Background(), "https://youtu.be/f6kdp27TYZs")
+	require.NoError(t, err)
+	assert.Equal(t, "https://www.youtube.com/watch?v=f6kdp27TYZs", resp.URL)
+	assert.Equal(t, "Go Concurrency Patterns", resp.Title)
+	assert.Contains(t, string(resp.Body), "- Channel: Google for Developers\n- Duration: 51:46\n")
+	assert.Contains(t, string(resp.Body), "[0:00](https://www.youtube.com/watch?v=f6kdp27TYZs) Hello, gophers.\n\n[0:40](https://www.youtube.com/watch?v=f6kdp27TYZs&t=40s) Channels.\n")
+}
+
 // TestClientFetchCacheMiss verifies cache state on first fetch.
 func TestClientFetchCacheMiss(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -340,43 +764,240 @@ func TestClientCacheStaleBehavior(t *testing.T) {
 	t.Logf("Stale response returned in %v (target: < 50ms)", staleDuration)
 }
 
-// TestClientFetchWithConditionalRequest verifies If-Modified-Since handling.
-// Reduces bandwidth and server load when content hasn't changed.
-func TestClientFetchWithConditionalRequest(t *testing.T) {
-	lastModified := "Wed, 21 Oct 2015 07:28:00 GMT"
-	requestCount := 0
-
+// TestClientRefreshMinChange verifies background refreshes changing less than the site's
+// refresh_min_change keep the cached content, while larger changes replace it.
+func TestClientRefreshMinChange(t *testing.T) {
+	var adsCount, editionCount atomic.Int32
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		requestCount++
-
-		if r.URL.Path == "/robots.txt" {
-			w.Header().Set("Content-Type", "text/plain")
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte("User-agent: *\nAllow: /\n"))
-			return
-		}
-
-		if r.URL.Path == "/page" {
-			ifModifiedSince := r.Header.Get("If-Modified-Since")
-			if ifModifiedSince == lastModified {
-				// Content not modified
-				w.WriteHeader(http.StatusNotModified)
-				return
-			}
-
-			w.Header().Set("Last-Modified", lastModified)
-			w.Header().Set("Content-Type", "text/plain")
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte("Content"))
+		w.Header().Set("Content-Type", "text/plain")
+		if r.URL.Path == "/ads" {
+			fmt.Fprintf(w, "Intro\nBody one\nBody two\nBody three\nAd %d\n", adsCount.Add(1))
 			return
 		}
+		fmt.Fprintf(w, "Edition %d\n", editionCount.Add(1))
 	}))
 	defer server.Close()
 
-	// Note: This tests the fetcher level, client integration with cache would handle this
-	// For now, documenting the expected behavior
-	t.Log("Conditional request behavior is tested at fetcher level")
-}
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer redisClient.Close()
+
+	cfg := config.New()
+	cfg.Default.Cache.RefreshMinChange = 0.5
+	require.NoError(t, cfg.Validate())
+
+	client, err := New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+	client.WithCache(cache.New(redisClient, cache.Config{Prefix: "test:refresh:", TTL: 200 * time.Millisecond, StaleTime: 5 * time.Second}))
+
+	ctx := context.Background()
+	for _, path := range []string{"/ads", "/edition"} {
+		_, err := client.Fetch(ctx, server.URL+path)
+		require.NoError(t, err)
+	}
+	time.Sleep(250 * time.Millisecond)
+	for _, path := range []string{"/ads", "/edition"} {
+		resp, err := client.Fetch(ctx, server.URL+path)
+		require.NoError(t, err)
+		assert.Equal(t, CacheStateStale, resp.CacheState)
+	}
+
+	require.Eventually(t, func() bool {
+		stats := client.Stats().Refresh
+		return stats.Updated+stats.Skipped == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	stats := client.Stats().Refresh
+	assert.Equal(t, int64(1), stats.Skipped)
+	assert.Equal(t, int64(1), stats.Updated)
+	assert.InDelta(t, 1.2, stats.ChangeSum, 0.001)
+
+	resp, err := client.Fetch(ctx, server.URL+"/ads")
+	require.NoError(t, err)
+	assert.Contains(t, string(resp.Body), "Ad 1", "a one-line change should keep the cached content")
+
+	resp, err = client.Fetch(ctx, server.URL+"/edition")
+	require.NoError(t, err)
+	assert.Contains(t, string(resp.Body), "Edition 2")
+}
+
+// TestClientFetchSharesConcurrentMisses verifies concurrent cache misses for a URL share one
+// origin fetch, and a canceled caller does not cancel it for the others.
+func TestClientFetchSharesConcurrentMisses(t *testing.T) {
+	var fetchCount atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetchCount.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("Shared"))
+	}))
+	defer server.Close()
+
+	client, err := New(nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	var cancelErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, cancelErr = client.Fetch(canceled, server.URL+"/page")
+	}()
+
+	bodies := make([]string, 5)
+	for i := range bodies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Fetch(context.Background(), server.URL+"/page")
+			if assert.NoError(t, err) {
+				bodies[i] = string(resp.Body)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.ErrorIs(t, cancelErr, context.Canceled)
+	assert.Equal(t, []string{"Shared", "Shared", "Shared", "Shared", "Shared"}, bodies)
+	assert.Equal(t, int32(1), fetchCount.Load())
+}
+
+// TestClientFetchFailureAttempts verifies a failed fetch returns the attempts it made on its error.
+func TestClientFetchFailureAttempts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg := config.New()
+	cfg.Default.Retry = config.RetryConfig{MaxRetries: 1, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, RetryOn: []int{http.StatusServiceUnavailable}}
+
+	client, err := New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.FetchWithOptions(context.Background(), server.URL, &FetchOptions{SkipCache: true})
+	require.Error(t, err)
+	require.Equal(t, int32(2), calls.Load())
+
+	attempts := Attempts(err)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, http.StatusServiceUnavailable, attempts[0].StatusCode)
+	assert.Equal(t, http.StatusServiceUnavailable, attempts[1].StatusCode)
+}
+
+// TestClientFetchUnexpectedNotModified verifies an origin answering an unconditional fetch with
+// 304 Not Modified fails the fetch, whether or not it goes through the cache.
+func TestClientFetchUnexpectedNotModified(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer server.Close()
+
+	client, err := New(nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.FetchWithOptions(context.Background(), server.URL+"/cached", &FetchOptions{})
+	assert.ErrorIs(t, err, ErrNotModified)
+
+	_, err = client.FetchWithOptions(context.Background(), server.URL+"/uncached", &FetchOptions{SkipCache: true})
+	assert.ErrorIs(t, err, ErrNotModified)
+}
+
+// TestClientFetchLock verifies instances sharing a cache with a fetch_lock wait for the page
+// another instance is fetching instead of fetching it too.
+func TestClientFetchLock(t *testing.T) {
+	var fetchCount atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetchCount.Add(1)
+		time.Sleep(200 * time.Millisecond)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("Locked"))
+	}))
+	defer server.Close()
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer redisClient.Close()
+
+	cfg := config.New()
+	cfg.Default.Cache.FetchLock = 5 * time.Second
+
+	instances := make([]*Client, 2)
+	for i := range instances {
+		client, err := New(cfg)
+		require.NoError(t, err)
+		defer client.Close()
+		instances[i] = client.WithCache(cache.New(redisClient, cache.Config{Prefix: "test:lock:"}))
+	}
+
+	states := make([]string, len(instances))
+	var wg sync.WaitGroup
+	for i, client := range instances {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			time.Sleep(time.Duration(i) * 50 * time.Millisecond)
+			resp, err := client.Fetch(context.Background(), server.URL+"/page")
+			if assert.NoError(t, err) {
+				assert.Equal(t, "Locked", string(resp.Body))
+				states[i] = resp.CacheState
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), fetchCount.Load())
+	assert.Equal(t, []string{CacheStateMiss, CacheStateHit}, states)
+	assert.False(t, mr.Exists("test:lock:lock:"+server.URL+"/page"), "the lock should be released")
+}
+
+// TestClientFetchWithConditionalRequest verifies If-Modified-Since handling.
+// Reduces bandwidth and server load when content hasn't changed.
+func TestClientFetchWithConditionalRequest(t *testing.T) {
+	lastModified := "Wed, 21 Oct 2015 07:28:00 GMT"
+	requestCount := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+
+		if r.URL.Path == "/robots.txt" {
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("User-agent: *\nAllow: /\n"))
+			return
+		}
+
+		if r.URL.Path == "/page" {
+			ifModifiedSince := r.Header.Get("If-Modified-Since")
+			if ifModifiedSince == lastModified {
+				// Content not modified
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.Header().Set("Last-Modified", lastModified)
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("Content"))
+			return
+		}
+	}))
+	defer server.Close()
+
+	// Note: This tests the fetcher level, client integration with cache would handle this
+	// For now, documenting the expected behavior
+	t.Log("Conditional request behavior is tested at fetcher level")
+}
 
 // TestClientFetchTimeout verifies fetch respects context deadline.
 // CRITICAL: LLM tools should timeout after reasonable period, not hang forever.
@@ -572,3 +1193,532 @@ func TestClientResponseMetadataCompleteness(t *testing.T) {
 	assert.Equal(t, "A test page with all metadata", resp.Description, "should extract description")
 	assert.NotEmpty(t, resp.CacheState, "should have cache state")
 }
+
+// TestClientFetchBypassCacheMinRefetchInterval verifies cache bypasses are suppressed within the refetch interval.
+func TestClientFetchBypassCacheMinRefetchInterval(t *testing.T) {
+	var fetchCount atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count := fetchCount.Add(1)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(fmt.Sprintf("Version %d", count)))
+	}))
+	defer server.Close()
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer redisClient.Close()
+
+	cfg := config.New()
+	cfg.Default.Cache.MinRefetchInterval = 200 * time.Millisecond
+
+	client, err := New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+	client.WithCache(cache.New(redisClient, cache.Config{Prefix: "test:refetch:"}))
+
+	ctx := context.Background()
+	opts := &FetchOptions{BypassCache: true}
+
+	resp1, err := client.FetchWithOptions(ctx, server.URL, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Version 1", string(resp1.Body))
+	assert.False(t, resp1.RefetchSuppressed)
+
+	resp2, err := client.FetchWithOptions(ctx, server.URL, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Version 1", string(resp2.Body), "bypass within interval should return most recent result")
+	assert.True(t, resp2.RefetchSuppressed)
+	assert.Equal(t, int32(1), fetchCount.Load())
+
+	time.Sleep(250 * time.Millisecond)
+
+	resp3, err := client.FetchWithOptions(ctx, server.URL, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Version 2", string(resp3.Body), "bypass after interval should refetch from origin")
+	assert.False(t, resp3.RefetchSuppressed)
+	assert.Equal(t, "miss", resp3.CacheState)
+}
+
+// TestClientFetchMinRefetchIntervalUncached verifies min_refetch_interval applies to sites with
+// the cache disabled.
+func TestClientFetchMinRefetchIntervalUncached(t *testing.T) {
+	var fetchCount atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count := fetchCount.Add(1)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(fmt.Sprintf("Version %d", count)))
+	}))
+	defer server.Close()
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer redisClient.Close()
+
+	cfg := config.New()
+	cfg.Default.Cache.Enabled = boolPtr(false)
+	cfg.Default.Cache.MinRefetchInterval = 200 * time.Millisecond
+
+	client, err := New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+	client.WithCache(cache.New(redisClient, cache.Config{Prefix: "test:refetch-uncached:"}))
+
+	ctx := context.Background()
+
+	resp, err := client.Fetch(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "Version 1", string(resp.Body))
+	assert.False(t, resp.RefetchSuppressed)
+	assert.Equal(t, "disabled", resp.CacheState)
+
+	resp, err = client.Fetch(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "Version 1", string(resp.Body), "a fetch within the interval should reuse the most recent result")
+	assert.True(t, resp.RefetchSuppressed)
+	assert.Equal(t, int32(1), fetchCount.Load())
+
+	time.Sleep(250 * time.Millisecond)
+
+	resp, err = client.Fetch(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "Version 2", string(resp.Body))
+	assert.False(t, resp.RefetchSuppressed)
+}
+
+// TestClientFetchRequestHeaders verifies request headers and cookies are sent and personalized responses are not cached.
+func TestClientFetchRequestHeaders(t *testing.T) {
+	var fetchCount atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetchCount.Add(1)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("auth=" + r.Header.Get("Authorization") + " cookie=" + r.Header.Get("Cookie")))
+	}))
+	defer server.Close()
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer redisClient.Close()
+
+	client, err := New(nil)
+	require.NoError(t, err)
+	defer client.Close()
+	client.WithCache(cache.New(redisClient, cache.Config{Prefix: "test:headers:"}))
+
+	ctx := context.Background()
+	resp, err := client.FetchWithOptions(ctx, server.URL, &FetchOptions{
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+		Cookies: map[string]string{"b": "2", "a": "1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "auth=Bearer secret cookie=a=1; b=2", string(resp.Body))
+
+	time.Sleep(50 * time.Millisecond)
+
+	resp, err = client.Fetch(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "auth= cookie=", string(resp.Body), "personalized response must not be served from cache")
+	assert.Equal(t, int32(2), fetchCount.Load())
+}
+
+// TestClientFetchRespectRobots verifies fetches disallowed by robots.txt fail when enabled.
+func TestClientFetchRespectRobots(t *testing.T) {
+	var pageFetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+			return
+		}
+		pageFetches.Add(1)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("page"))
+	}))
+	defer server.Close()
+
+	respectRobots := true
+	cfg := config.New()
+	cfg.Default.Fetch.RespectRobots = &respectRobots
+
+	client, err := New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Fetch(context.Background(), server.URL+"/private/page")
+	require.ErrorIs(t, err, robots.ErrDisallowed)
+	assert.Equal(t, int32(0), pageFetches.Load())
+
+	resp, err := client.Fetch(context.Background(), server.URL+"/public")
+	require.NoError(t, err)
+	assert.Equal(t, "page", string(resp.Body))
+}
+
+// TestClientRobotsAllowed verifies robots.txt is checked without fetching the page, and only
+// for sites that respect it.
+func TestClientRobotsAllowed(t *testing.T) {
+	var pageFetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+			return
+		}
+		pageFetches.Add(1)
+	}))
+	defer server.Close()
+
+	respectRobots := true
+	cfg := config.New()
+	cfg.Default.Fetch.RespectRobots = &respectRobots
+
+	client, err := New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+
+	allowed, err := client.RobotsAllowed(context.Background(), server.URL+"/private/page")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	allowed, err = client.RobotsAllowed(context.Background(), server.URL+"/public")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, int32(0), pageFetches.Load())
+
+	ignoring, err := New(nil)
+	require.NoError(t, err)
+	defer ignoring.Close()
+
+	allowed, err = ignoring.RobotsAllowed(context.Background(), server.URL+"/private/page")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+// TestClientFetchWaybackFallback verifies dead pages are served from their latest snapshot when enabled.
+func TestClientFetchWaybackFallback(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/available":
+			if r.URL.Query().Get("url") != server.URL+"/gone" {
+				w.Write([]byte(`{"archived_snapshots":{}}`))
+				return
+			}
+			fmt.Fprintf(w, `{"archived_snapshots":{"closest":{"status":"200","available":true,"url":"%s/web/20230115083000/%s/gone","timestamp":"20230115083000"}}}`, server.URL, server.URL)
+		case r.URL.Path == "/web/20230115083000id_/"+server.URL+"/gone":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><head><title>Archived</title></head><body><p>Old content</p></body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fallback := true
+	cfg := config.New()
+	cfg.Default.Fetch.FallbackToWayback = &fallback
+
+	client, err := New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+	client.WithWayback(wayback.New(wayback.WithAPIURL(server.URL + "/available")))
+
+	resp, err := client.Fetch(context.Background(), server.URL+"/gone")
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Contains(t, string(resp.Body), "Old content")
+	assert.Equal(t, "Archived", resp.Title)
+	assert.Equal(t, server.URL+"/gone", resp.OriginalURL)
+	assert.Equal(t, server.URL+"/web/20230115083000/"+server.URL+"/gone", resp.URL)
+	assert.Equal(t, time.Date(2023, 1, 15, 8, 30, 0, 0, time.UTC), resp.ArchivedAt)
+
+	resp, err = client.Fetch(context.Background(), server.URL+"/missing")
+	require.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode, "pages without snapshots should keep their original response")
+	assert.Empty(t, resp.OriginalURL)
+
+	disabled, err := New(nil)
+	require.NoError(t, err)
+	defer disabled.Close()
+	disabled.WithWayback(wayback.New(wayback.WithAPIURL(server.URL + "/available")))
+
+	resp, err = disabled.Fetch(context.Background(), server.URL+"/gone")
+	require.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+// TestClientFetchCacheDisabled verifies pages of sites with caching disabled are never stored.
+func TestClientFetchCacheDisabled(t *testing.T) {
+	var fetchCount atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetchCount.Add(1)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("account balance"))
+	}))
+	defer server.Close()
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer redisClient.Close()
+
+	disabled := false
+	cfg := config.New()
+	cfg.Sites = []config.SiteConfig{{
+		Pattern: strings.TrimPrefix(server.URL, "http://"),
+		Cache:   &config.CacheConfig{Enabled: &disabled},
+	}}
+
+	client, err := New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+	client.WithCache(cache.New(redisClient, cache.Config{Prefix: "test:disabled:"}))
+
+	ctx := context.Background()
+	for range 2 {
+		resp, err := client.Fetch(ctx, server.URL+"/account")
+		require.NoError(t, err)
+		assert.Equal(t, CacheStateDisabled, resp.CacheState)
+		assert.True(t, resp.CachedAt.IsZero())
+	}
+	assert.Equal(t, int32(2), fetchCount.Load())
+	assert.Empty(t, mr.Keys(), "nothing should be written to redis")
+}
+
+// TestClientFetchNoStore verifies responses marked Cache-Control: no-store are not cached and
+// replace a previously cached version.
+func TestClientFetchNoStore(t *testing.T) {
+	var noStore atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		if noStore.Load() {
+			w.Header().Set("Cache-Control", "private, No-Store")
+		}
+		w.Write([]byte("dashboard"))
+	}))
+	defer server.Close()
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer redisClient.Close()
+
+	client, err := New(nil)
+	require.NoError(t, err)
+	defer client.Close()
+	client.WithCache(cache.New(redisClient, cache.Config{Prefix: "test:nostore:"}))
+
+	ctx := context.Background()
+	resp, err := client.Fetch(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, CacheStateMiss, resp.CacheState)
+	require.NotNil(t, client.Cached(ctx, server.URL))
+
+	noStore.Store(true)
+	resp, err = client.FetchWithOptions(ctx, server.URL, &FetchOptions{BypassCache: true})
+	require.NoError(t, err)
+	assert.Equal(t, CacheStateNoStore, resp.CacheState)
+	assert.Nil(t, client.Cached(ctx, server.URL), "the previously cached version should be removed")
+
+	resp, err = client.Fetch(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, CacheStateNoStore, resp.CacheState)
+	assert.Empty(t, mr.Keys())
+}
+
+// TestClientFetchNormalizedCacheKey verifies trivially different URLs of a page share a cache
+// entry, and that sites can keep parameters that matter to them.
+func TestClientFetchNormalizedCacheKey(t *testing.T) {
+	var fetchCount atomic.Int32
+	var requested atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetchCount.Add(1)
+		requested.Store(r.URL.RawQuery)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("docs"))
+	}))
+	defer server.Close()
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer redisClient.Close()
+
+	cfg := config.New()
+	cfg.Sites = []config.SiteConfig{{
+		Pattern:          strings.TrimPrefix(server.URL, "http://"),
+		URLNormalization: &config.URLNormalizationConfig{StripParams: []string{"sid"}, KeepParams: []string{"utm_campaign"}},
+	}}
+
+	client, err := New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+	client.WithCache(cache.New(redisClient, cache.Config{Prefix: "test:normalize:"}))
+
+	ctx := context.Background()
+	resp, err := client.Fetch(ctx, server.URL+"/docs/page?b=2&a=1&utm_source=news")
+	require.NoError(t, err)
+	assert.Equal(t, CacheStateMiss, resp.CacheState)
+	assert.Equal(t, server.URL+"/docs/page?b=2&a=1&utm_source=news", resp.URL)
+	assert.Equal(t, "b=2&a=1&utm_source=news", requested.Load(), "the origin receives the URL as given")
+	assert.Equal(t, server.URL+"/docs/page?a=1&b=2", client.CacheKey(server.URL+"/docs/./page?b=2&a=1&utm_source=news#intro"))
+
+	for _, variant := range []string{
+		server.URL + "/docs/page?a=1&b=2",
+		strings.Replace(server.URL, "http://", "HTTP://", 1) + "/docs/page?b=2&sid=42&a=1&fbclid=x",
+	} {
+		resp, err = client.Fetch(ctx, variant)
+		require.NoError(t, err)
+		assert.Equal(t, CacheStateHit, resp.CacheState, variant)
+	}
+	assert.Equal(t, int32(1), fetchCount.Load())
+
+	resp, err = client.Fetch(ctx, server.URL+"/docs/page?a=1&b=2&utm_campaign=launch")
+	require.NoError(t, err)
+	assert.Equal(t, CacheStateMiss, resp.CacheState, "kept parameters are part of the cache key")
+}
+
+// TestClientFetchVersion verifies sites with max_versions keep previous versions that can be
+// fetched by number or by time.
+func TestClientFetchVersion(t *testing.T) {
+	var version atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "price: %d", 10+version.Load())
+	}))
+	defer server.Close()
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer redisClient.Close()
+
+	cfg := config.New()
+	cfg.Sites = []config.SiteConfig{{
+		Pattern: strings.TrimPrefix(server.URL, "http://"),
+		Cache:   &config.CacheConfig{MaxVersions: 3},
+	}}
+
+	client, err := New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+	client.WithCache(cache.New(redisClient, cache.Config{Prefix: "test:versions:"}))
+
+	ctx := context.Background()
+	var changed []time.Time
+	for range 3 {
+		resp, err := client.FetchWithOptions(ctx, server.URL, &FetchOptions{BypassCache: true})
+		require.NoError(t, err)
+		changed = append(changed, resp.ChangedAt)
+		version.Add(1)
+	}
+
+	history, err := client.History(ctx, server.URL)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, 3, history[0].Version)
+	assert.Equal(t, "price: 12", string(history[0].Body))
+	assert.Equal(t, 1, history[2].Version)
+
+	resp, err := client.FetchWithOptions(ctx, server.URL, &FetchOptions{Version: 2})
+	require.NoError(t, err)
+	assert.Equal(t, "price: 11", string(resp.Body))
+	assert.Equal(t, CacheStateHit, resp.CacheState)
+
+	resp, err = client.FetchWithOptions(ctx, server.URL, &FetchOptions{AsOf: changed[0]})
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.Version)
+
+	_, err = client.FetchWithOptions(ctx, server.URL, &FetchOptions{Version: 7})
+	assert.ErrorIs(t, err, ErrVersionNotFound)
+
+	_, err = client.FetchWithOptions(ctx, server.URL, &FetchOptions{AsOf: changed[0].Add(-time.Hour)})
+	assert.ErrorIs(t, err, ErrVersionNotFound)
+}
+
+// TestClientFetchTracing verifies the fetch pipeline records nested spans.
+func TestClientFetchTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body><h1>Traced</h1></body></html>"))
+	}))
+	defer server.Close()
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer redisClient.Close()
+
+	client, err := New(nil)
+	require.NoError(t, err)
+	defer client.Close()
+	client = client.WithCache(cache.New(redisClient, cache.Config{}))
+
+	_, err = client.Fetch(context.Background(), server.URL+"/page")
+	require.NoError(t, err)
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	for _, name := range []string{"websurfer.fetch", "websurfer.cache.get", "websurfer.ratelimit.wait", "websurfer.fetch.origin", "websurfer.fetch.attempt", "websurfer.parse", "websurfer.cache.set"} {
+		require.Contains(t, spans, name)
+	}
+
+	root := spans["websurfer.fetch"]
+	assert.Equal(t, root.SpanContext().SpanID(), spans["websurfer.cache.get"].Parent().SpanID())
+	assert.Equal(t, root.SpanContext().SpanID(), spans["websurfer.fetch.origin"].Parent().SpanID())
+	assert.Equal(t, spans["websurfer.fetch.origin"].SpanContext().SpanID(), spans["websurfer.fetch.attempt"].Parent().SpanID())
+	assert.Contains(t, root.Attributes(), attribute.String("websurfer.cache_state", CacheStateMiss))
+}
+
+// TestShouldRender verifies a site's render mode overrides the JavaScript shell heuristic.
+func TestShouldRender(t *testing.T) {
+	shell := []byte(`<html><body><div id="root"></div><script src="/app.js"></script></body></html>`)
+	static := []byte(`<html><body><p>Hello</p></body></html>`)
+
+	assert.True(t, shouldRender("", shell, nil))
+	assert.True(t, shouldRender(config.RenderAuto, shell, nil))
+	assert.False(t, shouldRender(config.RenderNever, shell, nil))
+
+	assert.False(t, shouldRender(config.RenderAuto, static, []byte("Hello")))
+	assert.True(t, shouldRender(config.RenderAlways, static, []byte("Hello")))
+}
+
+// TestClientFetchSelectors verifies selector values are extracted from the page's HTML, even
+// when the page is cached, since the cache holds no HTML.
+func TestClientFetchSelectors(t *testing.T) {
+	var fetchCount atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetchCount.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><main><h1>Widget</h1><span class="price">$19.99</span><a class="next" href="/page/2">Next</a></main></body></html>`))
+	}))
+	defer server.Close()
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer redisClient.Close()
+
+	client, err := New(nil)
+	require.NoError(t, err)
+	defer client.Close()
+	client.WithCache(cache.New(redisClient, cache.Config{Prefix: "test:selectors:"}))
+
+	ctx := context.Background()
+	resp, err := client.Fetch(ctx, server.URL+"/widget")
+	require.NoError(t, err)
+	assert.Nil(t, resp.Extracted)
+
+	time.Sleep(50 * time.Millisecond)
+
+	resp, err = client.FetchWithOptions(ctx, server.URL+"/widget", &FetchOptions{
+		Selectors: map[string]string{"title": "h1", "price": ".price", "next": "a.next @href", "rating": ".rating"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"title":  {"Widget"},
+		"price":  {"$19.99"},
+		"next":   {server.URL + "/page/2"},
+		"rating": {},
+	}, resp.Extracted)
+	assert.Contains(t, string(resp.Body), "Widget")
+	assert.Equal(t, int32(2), fetchCount.Load())
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/joeychilson/websurfer/retry"
+	urlpkg "github.com/joeychilson/websurfer/url"
+)
+
+// sniffLen is how many bytes of a download are read ahead to sniff its content type.
+const sniffLen = 512
+
+// Download is an asset fetched from origin with its body unparsed.
+type Download struct {
+	URL         string
+	StatusCode  int
+	ContentType string
+	Headers     map[string][]string
+	// Body streams the asset from origin. Reading it fails with fetcher.ErrBodyTooLarge past
+	// the site's max_body_size. The caller must close it.
+	Body     io.ReadCloser
+	Attempts []retry.Attempt
+}
+
+// Download fetches urlStr from origin and returns its raw bytes, for assets such as PDFs,
+// images, and archives that Fetch would parse to text. The body is streamed rather than held
+// in memory, so assets up to the site's max_body_size can be passed on at any size. Downloads
+// never read or write the cache. The content type is sniffed from the body when the origin
+// sends none.
+func (c *Client) Download(ctx context.Context, urlStr string) (*Download, error) {
+	urlStr = urlpkg.Transform(urlStr)
+
+	c.logger.Debug("download started", "url", urlStr)
+
+	resp, attempts, err := c.coordinator.Download(ctx, urlStr)
+	if err != nil {
+		c.logger.Error("download failed", "url", urlStr, "error", err)
+		return nil, withAttempts(err, attempts)
+	}
+
+	stream := resp.Stream
+	if stream == nil {
+		stream = io.NopCloser(bytes.NewReader(resp.Body))
+	}
+	body := bufio.NewReaderSize(stream, sniffLen)
+
+	contentType := resp.Headers.Get("Content-Type")
+	if contentType == "" {
+		if head, _ := body.Peek(sniffLen); len(head) > 0 {
+			contentType = http.DetectContentType(head)
+		}
+	}
+
+	c.logger.Info("download responded", "url", urlStr, "status_code", resp.StatusCode, "content_type", contentType, "content_length", resp.Headers.Get("Content-Length"), "attempts", len(attempts))
+	return &Download{
+		URL:         resp.URL,
+		StatusCode:  resp.StatusCode,
+		ContentType: contentType,
+		Headers:     resp.Headers,
+		Body:        &downloadBody{Reader: body, Closer: stream},
+		Attempts:    attempts,
+	}, nil
+}
+
+// downloadBody reads a download through the buffer its content type was sniffed with.
+type downloadBody struct {
+	io.Reader
+	io.Closer
+}
+
+// budgetedBody records the bytes read from a streamed body against a budget once it is closed.
+type budgetedBody struct {
+	io.ReadCloser
+	record func(n int64)
+	n      int64
+	closed bool
+}
+
+// Read implements io.Reader.
+func (b *budgetedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+// Close implements io.Closer.
+func (b *budgetedBody) Close() error {
+	if !b.closed {
+		b.closed = true
+		b.record(b.n)
+	}
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/joeychilson/websurfer/cache"
+	"github.com/joeychilson/websurfer/config"
+	"github.com/joeychilson/websurfer/fetcher"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngHeader is the signature that starts every PNG file.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// TestClientDownload verifies downloads return the raw body without parsing or caching it, and
+// sniff the content type when the origin sends none.
+func TestClientDownload(t *testing.T) {
+	pdf := []byte("%PDF-1.7\n1 0 obj << /Type /Catalog >> endobj\n%%EOF")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/report.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write(pdf)
+		case "/logo":
+			w.Header()["Content-Type"] = nil
+			w.Write(pngHeader)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer redisClient.Close()
+
+	client, err := New(nil)
+	require.NoError(t, err)
+	defer client.Close()
+	client.WithCache(cache.New(redisClient, cache.Config{Prefix: "test:download:"}))
+
+	ctx := context.Background()
+	download, err := client.Download(ctx, server.URL+"/report.pdf")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, download.StatusCode)
+	assert.Equal(t, "application/pdf", download.ContentType)
+	body, err := io.ReadAll(download.Body)
+	require.NoError(t, err)
+	assert.Equal(t, pdf, body)
+	require.NoError(t, download.Body.Close())
+
+	download, err = client.Download(ctx, server.URL+"/logo")
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", download.ContentType)
+	body, err = io.ReadAll(download.Body)
+	require.NoError(t, err)
+	assert.Equal(t, pngHeader, body, "sniffing should not consume the body")
+	download.Body.Close()
+
+	assert.Nil(t, client.Cached(ctx, server.URL+"/report.pdf"))
+	assert.Empty(t, mr.Keys())
+}
+
+// TestClientDownloadMaxBodySize verifies downloads honor the site's max_body_size, whether the
+// origin declares the size or it is only found while streaming.
+func TestClientDownloadMaxBodySize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/zip")
+		if r.URL.Path == "/archive.zip" {
+			w.Header().Set("Content-Length", "4096")
+		}
+		w.Write(make([]byte, 4096))
+	}))
+	defer server.Close()
+
+	cfg := config.New()
+	cfg.Default.Fetch.MaxBodySize = 1024
+	client, err := New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Download(ctx, server.URL+"/archive.zip")
+	assert.ErrorIs(t, err, fetcher.ErrBodyTooLarge)
+
+	download, err := client.Download(ctx, server.URL+"/streamed.zip")
+	require.NoError(t, err)
+	defer download.Body.Close()
+	_, err = io.Copy(io.Discard, download.Body)
+	assert.ErrorIs(t, err, fetcher.ErrBodyTooLarge)
+}
@@ -3,29 +3,94 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/net/html"
 
+	"github.com/joeychilson/websurfer/budget"
 	"github.com/joeychilson/websurfer/cache"
+	"github.com/joeychilson/websurfer/charset"
 	"github.com/joeychilson/websurfer/config"
 	"github.com/joeychilson/websurfer/fetcher"
+	"github.com/joeychilson/websurfer/forms"
 	"github.com/joeychilson/websurfer/headless"
+	"github.com/joeychilson/websurfer/hreflang"
 	"github.com/joeychilson/websurfer/parser"
 	"github.com/joeychilson/websurfer/ratelimit"
 	"github.com/joeychilson/websurfer/retry"
+	"github.com/joeychilson/websurfer/robots"
+	"github.com/joeychilson/websurfer/selector"
+	"github.com/joeychilson/websurfer/structured"
+	"github.com/joeychilson/websurfer/tracing"
+	"github.com/joeychilson/websurfer/wayback"
+	"github.com/joeychilson/websurfer/youtube"
 )
 
+const (
+	// maxRobotsSize is how much of a robots.txt file is parsed, per RFC 9309.
+	maxRobotsSize = 500 * 1024
+)
+
+// ErrParse is returned when fetched content cannot be parsed.
+var ErrParse = errors.New("failed to parse content")
+
+// ErrNotModified is returned when the origin answers an unconditional fetch with 304 Not
+// Modified, leaving no content to return.
+var ErrNotModified = errors.New("origin reported content not modified without a cached copy")
+
+// AttemptsError wraps the error of a fetch that failed after reaching the origin with the
+// attempts it made.
+type AttemptsError struct {
+	Err      error
+	Attempts []retry.Attempt
+}
+
+// Error implements the error interface.
+func (e *AttemptsError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *AttemptsError) Unwrap() error {
+	return e.Err
+}
+
+// Attempts returns the origin fetch attempts recorded on the error of a failed fetch, if any.
+func Attempts(err error) []retry.Attempt {
+	var attemptsErr *AttemptsError
+	if errors.As(err, &attemptsErr) {
+		return attemptsErr.Attempts
+	}
+	return nil
+}
+
+// withAttempts wraps err with attempts, when any were made.
+func withAttempts(err error, attempts []retry.Attempt) error {
+	if len(attempts) == 0 {
+		return err
+	}
+	return &AttemptsError{Err: err, Attempts: attempts}
+}
+
 // FetchCoordinator coordinates rate limiting and HTTP fetching.
 type FetchCoordinator struct {
 	config   *config.Config
 	limiter  *ratelimit.Limiter
+	budget   *budget.Tracker
 	parser   *parser.Registry
-	headless *headless.Browser
+	headless *headless.Pool
+	robots   *robots.Checker
+	wayback  *wayback.Client
 	logger   *slog.Logger
 }
 
@@ -33,17 +98,22 @@ type FetchCoordinator struct {
 func NewFetchCoordinator(
 	cfg *config.Config,
 	limiter *ratelimit.Limiter,
+	budgetTracker *budget.Tracker,
 	parser *parser.Registry,
-	headlessBrowser *headless.Browser,
+	headlessPool *headless.Pool,
 	logger *slog.Logger,
 ) *FetchCoordinator {
-	return &FetchCoordinator{
+	f := &FetchCoordinator{
 		config:   cfg,
 		limiter:  limiter,
+		budget:   budgetTracker,
 		parser:   parser,
-		headless: headlessBrowser,
+		headless: headlessPool,
+		wayback:  wayback.New(),
 		logger:   logger,
 	}
+	f.robots = robots.NewChecker(f.fetchRobots, robots.Config{Logger: logger})
+	return f
 }
 
 // Close releases resources.
@@ -51,46 +121,293 @@ func (f *FetchCoordinator) Close() {
 	if f.limiter != nil {
 		f.limiter.Close()
 	}
+	if f.headless != nil {
+		f.headless.Close()
+	}
+}
+
+// FetchResult holds a fetched cache entry along with details about how it was fetched.
+// Entry is nil when the origin reported the content as not modified.
+type FetchResult struct {
+	Entry    *cache.Entry
+	Attempts []retry.Attempt
+	Transfer *fetcher.Transfer
+	// Extracted holds the values of the CSS selectors the fetch requested, and Raw the body the
+	// content was parsed from when it asked for it. Both are kept out of Entry, since they
+	// belong to this fetch alone and must never be cached.
+	Extracted map[string][]string
+	Raw       []byte
 }
 
-// Fetch performs a complete fetch operation with rate limiting and parsing.
-func (f *FetchCoordinator) Fetch(ctx context.Context, urlStr string, ifModifiedSince string) (*cache.Entry, error) {
+// Fetch performs a complete fetch operation with rate limiting and parsing. YouTube videos are
+// fetched as their transcript, and pages that are gone or unreachable are served from the
+// Wayback Machine when the site enables fallback_to_wayback.
+func (f *FetchCoordinator) Fetch(ctx context.Context, urlStr string, ifModifiedSince string) (*FetchResult, error) {
 	resolved := f.config.GetConfigForURL(urlStr)
 
-	fetcherResp, err := f.performFetch(ctx, urlStr, resolved, ifModifiedSince)
+	if videoID, ok := youtube.VideoID(urlStr); ok {
+		result, err := f.fetchTranscript(ctx, urlStr, videoID, resolved)
+		if err == nil {
+			return result, nil
+		}
+		f.logger.Warn("youtube transcript unavailable, fetching page", "url", urlStr, "error", err)
+	}
+
+	result, err := f.fetch(ctx, urlStr, ifModifiedSince, resolved)
+	if !resolved.Fetch.GetFallbackToWayback() || !isDeadLink(result, err) || wayback.IsArchiveURL(urlStr) {
+		return result, err
+	}
+
+	archived, archiveErr := f.fetchArchived(ctx, urlStr)
+	if archiveErr != nil {
+		f.logger.Debug("wayback fallback failed", "url", urlStr, "error", archiveErr)
+		return result, err
+	}
+
+	prior := result.attempts()
 	if err != nil {
-		return nil, err
+		prior = Attempts(err)
+	}
+	f.logger.Info("served archived snapshot", "url", urlStr, "archive_url", archived.Entry.ArchiveURL)
+	archived.Attempts = append(prior, archived.Attempts...)
+	return archived, nil
+}
+
+// fetch fetches and parses urlStr from origin. Errors after the origin was reached carry the
+// attempts made, which Attempts returns.
+func (f *FetchCoordinator) fetch(ctx context.Context, urlStr string, ifModifiedSince string, resolved config.ResolvedConfig) (*FetchResult, error) {
+	var opts *fetcher.FetchOptions
+	if ifModifiedSince != "" {
+		f.logger.Debug("using conditional request", "url", urlStr, "if_modified_since", ifModifiedSince)
+		opts = &fetcher.FetchOptions{IfModifiedSince: ifModifiedSince}
+	}
+
+	fetcherResp, attempts, err := f.fetchOrigin(ctx, urlStr, opts, resolved)
+	if err != nil {
+		return nil, withAttempts(err, attempts)
 	}
 
 	if fetcherResp.StatusCode == 304 {
 		f.logger.Debug("content not modified, reusing cached content", "url", urlStr)
-		return nil, nil
+		return &FetchResult{Attempts: attempts, Transfer: &fetcherResp.Transfer}, nil
+	}
+
+	if opts := parser.GetOptions(ctx); opts.Sanitize == "" && resolved.Fetch.Sanitize != "" {
+		opts.Sanitize = resolved.Fetch.Sanitize
+		ctx = parser.WithOptions(ctx, opts)
+	}
+
+	result, err := f.buildCacheEntry(ctx, urlStr, fetcherResp, resolved.Fetch)
+	if err != nil {
+		return nil, withAttempts(err, attempts)
+	}
+	entry := result.Entry
+	entry.MaxVersions = resolved.Cache.GetMaxVersions()
+
+	if !entry.Binary {
+		if resolved.TransformsErr != nil {
+			return nil, withAttempts(fmt.Errorf("invalid transforms: %w", resolved.TransformsErr), attempts)
+		}
+		entry.Body = resolved.Transforms.Apply(entry.Body)
 	}
 
-	return f.buildCacheEntry(ctx, urlStr, fetcherResp)
+	result.Attempts = attempts
+	result.Transfer = &fetcherResp.Transfer
+	return result, nil
+}
+
+// Download fetches urlStr from origin without parsing it, for binary assets. Robots rules,
+// budgets, rate limits, retries, and the site's max_body_size apply as for any fetch. A
+// successful response's body is left unread in its Stream, which the caller must close.
+func (f *FetchCoordinator) Download(ctx context.Context, urlStr string) (*fetcher.Response, []retry.Attempt, error) {
+	return f.fetchOrigin(ctx, urlStr, &fetcher.FetchOptions{Stream: true}, f.config.GetConfigForURL(urlStr))
+}
+
+// fetchOrigin checks robots.txt and the site's budget, then fetches urlStr from origin and
+// records the bytes received against the budget, once read for streamed bodies.
+func (f *FetchCoordinator) fetchOrigin(ctx context.Context, urlStr string, opts *fetcher.FetchOptions, resolved config.ResolvedConfig) (*fetcher.Response, []retry.Attempt, error) {
+	if resolved.Fetch.GetRespectRobots() {
+		if err := f.checkRobots(ctx, urlStr, resolved); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := f.budget.Acquire(urlStr, resolved.Budget); err != nil {
+		return nil, nil, err
+	}
+
+	fetcherResp, attempts, err := f.performFetch(ctx, urlStr, resolved, opts)
+	if err != nil {
+		return nil, attempts, err
+	}
+
+	if fetcherResp.Stream != nil {
+		fetcherResp.Stream = &budgetedBody{ReadCloser: fetcherResp.Stream, record: func(n int64) {
+			f.budget.RecordBytes(urlStr, n, resolved.Budget)
+		}}
+		return fetcherResp, attempts, nil
+	}
+
+	f.budget.RecordBytes(urlStr, int64(len(fetcherResp.Body)), resolved.Budget)
+	return fetcherResp, attempts, nil
+}
+
+// fetchArchived fetches the most recent Wayback Machine snapshot of urlStr. The entry keeps
+// urlStr as its URL, so it is cached in place of the dead page. Request headers and cookies
+// meant for the origin are not sent to the archive.
+func (f *FetchCoordinator) fetchArchived(ctx context.Context, urlStr string) (*FetchResult, error) {
+	snapshot, err := f.wayback.Lookup(ctx, urlStr)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := f.fetch(fetcher.WithCookieJar(fetcher.WithHeaders(ctx, nil), nil), snapshot.RawURL, "", f.config.GetConfigForURL(snapshot.RawURL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch snapshot: %w", err)
+	}
+	if result.Entry == nil || result.Entry.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("snapshot unavailable: %s", snapshot.URL)
+	}
+
+	result.Entry.URL = urlStr
+	result.Entry.ArchiveURL = snapshot.URL
+	result.Entry.ArchivedAt = snapshot.Timestamp
+	result.Entry.OriginSHA256 = ""
+	return result, nil
+}
+
+// isDeadLink reports whether a fetch found the page gone (404 or 410) or the site unreachable.
+func isDeadLink(result *FetchResult, err error) bool {
+	if err != nil {
+		return fetcher.ClassifyError(err) != ""
+	}
+	if result.Entry == nil {
+		return false
+	}
+	return result.Entry.StatusCode == http.StatusNotFound || result.Entry.StatusCode == http.StatusGone
+}
+
+// attempts returns the result's attempts, tolerating a nil result.
+func (r *FetchResult) attempts() []retry.Attempt {
+	if r == nil {
+		return nil
+	}
+	return r.Attempts
 }
 
 // performFetch executes the HTTP fetch with retry logic.
-func (f *FetchCoordinator) performFetch(ctx context.Context, urlStr string, resolved config.ResolvedConfig, cachedLastModified string) (*fetcher.Response, error) {
+func (f *FetchCoordinator) performFetch(ctx context.Context, urlStr string, resolved config.ResolvedConfig, opts *fetcher.FetchOptions) (*fetcher.Response, []retry.Attempt, error) {
 	fetch, err := fetcher.New(resolved.Fetch)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create fetcher: %w", err)
+		return nil, nil, fmt.Errorf("failed to create fetcher: %w", err)
 	}
 	r := retry.New(fetch, f.limiter, resolved.Retry)
 
-	if cachedLastModified != "" {
-		f.logger.Debug("using conditional request", "url", urlStr, "if_modified_since", cachedLastModified)
-		opts := &fetcher.FetchOptions{
-			IfModifiedSince: cachedLastModified,
+	ctx, span := tracing.Start(ctx, "websurfer.fetch.origin", attribute.String("url.full", urlStr))
+
+	resp, attempts, err := r.FetchWithOptions(ctx, urlStr, opts)
+	span.SetAttributes(attribute.Int("websurfer.attempts", len(attempts)))
+	if resp != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode), attribute.Int("websurfer.body_size", len(resp.Body)))
+	}
+	tracing.End(span, err)
+	return resp, attempts, err
+}
+
+// checkRobots returns robots.ErrDisallowed if the site's robots.txt does not allow fetching urlStr.
+func (f *FetchCoordinator) checkRobots(ctx context.Context, urlStr string, resolved config.ResolvedConfig) error {
+	ctx, span := tracing.Start(ctx, "websurfer.robots", attribute.String("url.full", urlStr))
+	allowed, err := f.robots.Allowed(ctx, urlStr, resolved.Fetch.GetRobotsUserAgent())
+	span.SetAttributes(attribute.Bool("websurfer.robots.allowed", allowed))
+	tracing.End(span, err)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		f.logger.Debug("fetch disallowed by robots.txt", "url", urlStr)
+		return fmt.Errorf("%w: %s", robots.ErrDisallowed, urlStr)
+	}
+	return nil
+}
+
+// fetchRobots fetches a robots.txt file with the site's fetch settings, without retries.
+func (f *FetchCoordinator) fetchRobots(ctx context.Context, robotsURL string) (int, []byte, error) {
+	resolved := f.config.GetConfigForURL(robotsURL)
+
+	fetch, err := fetcher.New(resolved.Fetch)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create fetcher: %w", err)
+	}
+
+	ctx, span := tracing.Start(ctx, "websurfer.robots.fetch", attribute.String("url.full", robotsURL))
+	defer span.End()
+
+	if err := f.limiter.Wait(ctx, robotsURL); err != nil {
+		span.RecordError(err)
+		return 0, nil, err
+	}
+	defer f.limiter.Release(robotsURL)
+
+	resp, err := fetch.FetchWithOptions(ctx, robotsURL, nil)
+	if resp == nil {
+		span.RecordError(err)
+		return 0, nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+
+	body := resp.Body
+	if len(body) > maxRobotsSize {
+		body = body[:maxRobotsSize]
+	}
+	return resp.StatusCode, body, nil
+}
+
+// Probe requests urlStr once with HEAD, or GET if the site rejects HEAD, after checking
+// robots.txt and waiting for the site's rate limit. The response body is never read.
+func (f *FetchCoordinator) Probe(ctx context.Context, urlStr string) (*fetcher.ProbeResult, error) {
+	resolved := f.config.GetConfigForURL(urlStr)
+	if resolved.Fetch.GetRespectRobots() {
+		if err := f.checkRobots(ctx, urlStr, resolved); err != nil {
+			return nil, err
 		}
-		return r.FetchWithOptions(ctx, urlStr, opts)
 	}
 
-	return r.Fetch(ctx, urlStr)
+	fetch, err := fetcher.New(resolved.Fetch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fetcher: %w", err)
+	}
+
+	ctx, span := tracing.Start(ctx, "websurfer.probe", attribute.String("url.full", urlStr))
+
+	if err := f.limiter.Wait(ctx, urlStr); err != nil {
+		tracing.End(span, err)
+		return nil, err
+	}
+	defer f.limiter.Release(urlStr)
+
+	result, err := fetch.Probe(ctx, urlStr)
+	if result != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", result.StatusCode), attribute.String("http.request.method", result.Method))
+	}
+	tracing.End(span, err)
+	return result, err
 }
 
-// buildCacheEntry constructs a cache entry from the fetcher response.
-func (f *FetchCoordinator) buildCacheEntry(ctx context.Context, urlStr string, fetcherResp *fetcher.Response) (*cache.Entry, error) {
+// buildCacheEntry constructs a cache entry from the fetcher response, returned in a result with
+// the values of any selectors the fetch requested and the raw body if it asked for it. HTML pages are rendered in the headless
+// browser according to the site's render mode, with the fetch's cookie jar and Referer, and
+// narrowed to the site's main content region before they are parsed, unless the raw_html
+// format asks for the whole page.
+func (f *FetchCoordinator) buildCacheEntry(ctx context.Context, urlStr string, fetcherResp *fetcher.Response, fetchCfg config.FetchConfig) (*FetchResult, error) {
+	var region selector.Matcher
+	if fetchCfg.MainContent != "" {
+		var err error
+		region, err = selector.CompileMatcher(fetchCfg.MainContent)
+		if err != nil {
+			return nil, fmt.Errorf("invalid main content: %w", err)
+		}
+	}
+
 	var (
 		contentType  string
 		lastModified string
@@ -106,24 +423,88 @@ func (f *FetchCoordinator) buildCacheEntry(ctx context.Context, urlStr string, f
 	entryStatus := fetcherResp.StatusCode
 	entryHeaders := fetcherResp.Headers
 
-	var title, description, faviconURL string
-	if strings.Contains(strings.ToLower(contentType), "html") && len(fetcherResp.Body) > 0 {
-		title, description, faviconURL = extractMetadataFromHTML(fetcherResp.Body)
-		if faviconURL != "" {
-			faviconURL = resolveFaviconURL(fetcherResp.URL, faviconURL)
+	rawBody := fetcherResp.Body
+	originHash := sha256.Sum256(rawBody)
+	originSHA256 := hex.EncodeToString(originHash[:])
+	if (charset.IsText(contentType) || !f.parser.HasParser(contentType)) && charset.IsBinary(rawBody, contentType) {
+		f.logger.Warn("binary body served as text", "url", urlStr, "content_type", contentType)
+		return &FetchResult{Entry: &cache.Entry{
+			URL:          entryURL,
+			StatusCode:   entryStatus,
+			Headers:      entryHeaders,
+			Body:         rawBody,
+			Binary:       true,
+			Robots:       robotsDirectives(entryHeaders["X-Robots-Tag"]...),
+			OriginSHA256: originSHA256,
+			LastModified: lastModified,
+			StoredAt:     time.Now(),
+		}}, nil
+	}
+	if charset.IsText(contentType) && len(rawBody) > 0 {
+		transcoded, name, err := charset.ToUTF8(rawBody, contentType)
+		if err != nil {
+			f.logger.Warn("failed to transcode body", "url", urlStr, "charset", name, "error", err)
+		} else {
+			if name != charset.UTF8 {
+				f.logger.Debug("transcoded body to utf-8", "url", urlStr, "charset", name)
+				contentType = charset.WithUTF8(contentType)
+				entryHeaders = maps.Clone(entryHeaders)
+				entryHeaders["Content-Type"] = []string{contentType}
+			}
+			rawBody = transcoded
+		}
+	}
+
+	var (
+		meta      pageMetadata
+		extracted *structured.Data
+		pageForms []forms.Form
+		// pageHTML is the HTML the content was parsed from, for selectors.
+		pageHTML []byte
+		// sourceBody is the body the content was parsed from, before any main content region.
+		sourceBody = rawBody
+		rendered   bool
+	)
+	if strings.Contains(strings.ToLower(contentType), "html") && len(rawBody) > 0 {
+		pageHTML = rawBody
+		meta = extractMetadataFromHTML(rawBody)
+		meta.resolve(fetcherResp.URL)
+		extracted = structured.Extract(rawBody)
+		pageForms = forms.Extract(rawBody, fetcherResp.URL)
+	}
+	if meta.NextURL == "" {
+		meta.NextURL = resolveURL(fetcherResp.URL, nextFromLinkHeader(fetcherResp.Headers["Link"]))
+	}
+	if meta.LicenseURL == "" && meta.License == "" {
+		meta.LicenseURL, meta.License = splitLicense(fetcherResp.URL, licenseFromLinkHeader(fetcherResp.Headers["Link"]))
+	}
+	if meta.CanonicalURL == "" {
+		meta.CanonicalURL = resolveURL(fetcherResp.URL, canonicalFromLinkHeader(fetcherResp.Headers["Link"]))
+	}
+	if len(meta.Alternates) == 0 {
+		for _, alt := range alternatesFromLinkHeader(fetcherResp.Headers["Link"]) {
+			alt.URL = resolveURL(fetcherResp.URL, alt.URL)
+			meta.Alternates = append(meta.Alternates, alt)
 		}
 	}
 
-	body, err := f.parseContent(ctx, urlStr, contentType, fetcherResp.Body)
+	contentHTML := f.mainContent(urlStr, contentType, rawBody, region)
+	body, err := f.parseContent(ctx, urlStr, contentType, contentHTML)
 	if err != nil {
 		return nil, err
 	}
 
 	if f.headless != nil && strings.Contains(strings.ToLower(contentType), "html") {
-		if headless.NeedsRendering(fetcherResp.Body, body) {
-			f.logger.Info("using headless rendering", "url", urlStr)
-
-			headlessResp, err := f.headless.Render(ctx, urlStr)
+		if shouldRender(fetchCfg.Render, contentHTML, body) {
+			f.logger.Info("using headless rendering", "url", urlStr, "render", fetchCfg.Render)
+
+			renderCtx, span := tracing.Start(ctx, "websurfer.headless.render", attribute.String("url.full", urlStr))
+			renderCtx = headless.WithRequest(renderCtx, headless.Request{
+				Jar:     fetcher.GetCookieJar(ctx),
+				Referer: fetcher.GetHeaders(ctx)["Referer"],
+			})
+			headlessResp, err := f.headless.Render(renderCtx, urlStr)
+			tracing.End(span, err)
 			if err != nil {
 				f.logger.Warn("headless rendering failed, using static content", "url", urlStr, "error", err)
 			} else {
@@ -137,17 +518,20 @@ func (f *FetchCoordinator) buildCacheEntry(ctx context.Context, urlStr string, f
 					entryHeaders = headlessResp.Headers
 				}
 
-				title, description, faviconURL = extractMetadataFromHTML(headlessResp.Body)
-				if faviconURL != "" {
-					faviconURL = resolveFaviconURL(entryURL, faviconURL)
-				}
+				meta = extractMetadataFromHTML(headlessResp.Body)
+				meta.resolve(entryURL)
+				extracted = structured.Extract(headlessResp.Body)
+				pageForms = forms.Extract(headlessResp.Body, entryURL)
+				pageHTML = headlessResp.Body
+				sourceBody = headlessResp.Body
+				rendered = true
 
 				headlessContentType := contentType
 				if values, ok := headlessResp.Headers["Content-Type"]; ok && len(values) > 0 {
 					headlessContentType = values[0]
 				}
 
-				body, err = f.parseContent(ctx, urlStr, headlessContentType, headlessResp.Body)
+				body, err = f.parseContent(ctx, urlStr, headlessContentType, f.mainContent(urlStr, headlessContentType, headlessResp.Body, region))
 				if err != nil {
 					f.logger.Warn("failed to parse headless content", "url", urlStr, "error", err)
 				}
@@ -155,17 +539,59 @@ func (f *FetchCoordinator) buildCacheEntry(ctx context.Context, urlStr string, f
 		}
 	}
 
-	return &cache.Entry{
-		URL:          entryURL,
-		StatusCode:   entryStatus,
-		Headers:      entryHeaders,
-		Body:         body,
-		Title:        title,
-		Description:  description,
-		FaviconURL:   faviconURL,
-		LastModified: lastModified,
-		StoredAt:     time.Now(),
-	}, nil
+	selected, err := selectValues(ctx, pageHTML, entryURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.GetOptions(ctx).Format == parser.FormatRawHTML && pageHTML != nil {
+		body = pageHTML
+	}
+	var raw []byte
+	if includeRaw(ctx) {
+		raw = sourceBody
+	}
+
+	entry := &cache.Entry{
+		URL:           entryURL,
+		StatusCode:    entryStatus,
+		Headers:       entryHeaders,
+		Body:          body,
+		Title:         meta.Title,
+		Description:   meta.Description,
+		FaviconURL:    meta.FaviconURL,
+		ImageURL:      meta.ImageURL,
+		NextURL:       meta.NextURL,
+		License:       meta.License,
+		LicenseURL:    meta.LicenseURL,
+		Robots:        robotsDirectives(append(meta.Robots, entryHeaders["X-Robots-Tag"]...)...),
+		Structured:    extracted,
+		Forms:         pageForms,
+		LeadImageURL:  meta.LeadImageURL,
+		Excerpt:       meta.Excerpt,
+		Alternates:    meta.Alternates,
+		CanonicalURL:  meta.CanonicalURL,
+		NofollowLinks: meta.NofollowLinks,
+		OriginSHA256:  originSHA256,
+		Rendered:      rendered,
+		LastModified:  lastModified,
+		StoredAt:      time.Now(),
+	}
+	return &FetchResult{Entry: entry, Extracted: selected, Raw: raw}, nil
+}
+
+// shouldRender reports whether an HTML page is rendered in the headless browser under a site's
+// render mode: always, never, or, by default, when its static content looks like an empty
+// JavaScript shell.
+func shouldRender(render string, rawHTML, parsed []byte) bool {
+	switch render {
+	case config.RenderAlways:
+		return true
+	case config.RenderNever:
+		return false
+	default:
+		return headless.NeedsRendering(rawHTML, parsed)
+	}
 }
 
 // parseContent parses the response body using the appropriate parser.
@@ -176,58 +602,165 @@ func (f *FetchCoordinator) parseContent(ctx context.Context, urlStr, contentType
 
 	f.logger.Debug("parsing content", "url", urlStr, "content_type", contentType, "original_size", len(body))
 
-	parserCtx := ctx
+	parserCtx, span := tracing.Start(ctx, "websurfer.parse", attribute.String("websurfer.content_type", contentType), attribute.Int("websurfer.body_size", len(body)))
 	if urlStr != "" {
-		parserCtx = parser.WithURL(ctx, urlStr)
+		parserCtx = parser.WithURL(parserCtx, urlStr)
 	}
 
 	parsed, err := f.parser.Parse(parserCtx, contentType, body)
+	span.SetAttributes(attribute.Int("websurfer.parsed_size", len(parsed)))
+	tracing.End(span, err)
 	if err != nil {
 		f.logger.Error("failed to parse content", "url", urlStr, "content_type", contentType, "error", err)
-		return nil, fmt.Errorf("failed to parse content: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrParse, err)
 	}
 
 	f.logger.Debug("parsing completed", "url", urlStr, "original_size", len(body), "parsed_size", len(parsed))
 	return parsed, nil
 }
 
-// extractMetadataFromHTML extracts title, description, and favicon URL from HTML by parsing the DOM.
-func extractMetadataFromHTML(htmlContent []byte) (title, description, faviconURL string) {
+// pageMetadata holds metadata extracted from an HTML document.
+type pageMetadata struct {
+	Title       string
+	Description string
+	FaviconURL  string
+	ImageURL    string
+	// NextURL is the next page of a paginated series, from rel="next" or a "next page" link.
+	NextURL string
+	// License is the declared license, a URL or a name, until resolve splits it into
+	// LicenseURL and a display name.
+	License    string
+	LicenseURL string
+	// Robots holds the content of robots meta tags.
+	Robots []string
+	// LeadImageURL and Excerpt are the main content image and opening paragraph.
+	LeadImageURL string
+	Excerpt      string
+	// Alternates lists the page's language variants from hreflang links.
+	Alternates []hreflang.Alternate
+	// CanonicalURL is the page's preferred URL from rel="canonical".
+	CanonicalURL string
+	// NofollowLinks lists the targets of links marked rel="nofollow", "ugc", or "sponsored".
+	NofollowLinks []string
+}
+
+// resolve resolves the metadata's URLs against the document URL.
+func (m *pageMetadata) resolve(baseURL string) {
+	m.FaviconURL = resolveURL(baseURL, m.FaviconURL)
+	m.ImageURL = resolveURL(baseURL, m.ImageURL)
+	m.LeadImageURL = resolveURL(baseURL, m.LeadImageURL)
+	m.NextURL = resolveURL(baseURL, m.NextURL)
+	m.LicenseURL, m.License = splitLicense(baseURL, m.License)
+	for i := range m.Alternates {
+		m.Alternates[i].URL = resolveURL(baseURL, m.Alternates[i].URL)
+	}
+	m.CanonicalURL = resolveURL(baseURL, m.CanonicalURL)
+	for i := range m.NofollowLinks {
+		m.NofollowLinks[i] = resolveURL(baseURL, m.NofollowLinks[i])
+	}
+}
+
+// extractMetadataFromHTML extracts the title, description, favicon, social preview image, next
+// page link, license, robots directives, hreflang alternates, canonical URL, nofollow links,
+// lead image, and excerpt from HTML. The og:image is preferred over
+// twitter:image, rel="next" over links labeled as a next page, and rel="license" over JSON-LD
+// and Dublin Core licenses.
+func extractMetadataFromHTML(htmlContent []byte) pageMetadata {
+	var meta pageMetadata
+
 	doc, err := html.Parse(bytes.NewReader(htmlContent))
 	if err != nil {
-		return "", "", ""
+		return meta
 	}
 
+	var twitterImage, labeledNext, jsonLDLicenseValue, metaLicense string
+
 	var extract func(*html.Node)
 	extract = func(node *html.Node) {
 		if node.Type == html.ElementNode {
 			switch node.Data {
 			case "title":
-				if title == "" {
-					title = getNodeText(node)
+				if meta.Title == "" {
+					meta.Title = getNodeText(node)
 				}
 			case "meta":
-				if description == "" {
-					name := getAttr(node, "name")
-					property := getAttr(node, "property")
+				name := getAttr(node, "name")
+				property := getAttr(node, "property")
 
+				if meta.Description == "" {
 					if name == "description" {
-						description = getAttr(node, "content")
+						meta.Description = getAttr(node, "content")
 					}
-					if property == "og:description" && description == "" {
-						description = getAttr(node, "content")
+					if property == "og:description" && meta.Description == "" {
+						meta.Description = getAttr(node, "content")
+					}
+				}
+
+				if meta.ImageURL == "" && (property == "og:image" || property == "og:image:url") {
+					meta.ImageURL = strings.TrimSpace(getAttr(node, "content"))
+				}
+				if twitterImage == "" && (name == "twitter:image" || property == "twitter:image") {
+					twitterImage = strings.TrimSpace(getAttr(node, "content"))
+				}
+
+				switch strings.ToLower(name) {
+				case "robots":
+					meta.Robots = append(meta.Robots, getAttr(node, "content"))
+				case "dcterms.license", "dc.license", "dc.rights", "dcterms.rights", "license":
+					if metaLicense == "" {
+						metaLicense = strings.TrimSpace(getAttr(node, "content"))
 					}
 				}
+				if metaLicense == "" && getAttr(node, "itemprop") == "license" {
+					metaLicense = strings.TrimSpace(getAttr(node, "content"))
+				}
 			case "link":
-				if faviconURL == "" {
-					rel := strings.ToLower(getAttr(node, "rel"))
+				rel := strings.ToLower(getAttr(node, "rel"))
+				if meta.FaviconURL == "" {
 					if rel == "icon" || rel == "shortcut icon" || rel == "apple-touch-icon" {
 						href := getAttr(node, "href")
 						if href != "" {
-							faviconURL = href
+							meta.FaviconURL = href
 						}
 					}
 				}
+				if meta.NextURL == "" && hasRel(rel, "next") {
+					meta.NextURL = strings.TrimSpace(getAttr(node, "href"))
+				}
+				if meta.License == "" && hasRel(rel, "license") {
+					meta.License = strings.TrimSpace(getAttr(node, "href"))
+				}
+				if hasRel(rel, "alternate") {
+					meta.Alternates = hreflang.Add(meta.Alternates, getAttr(node, "hreflang"), getAttr(node, "href"))
+				}
+				if meta.CanonicalURL == "" && hasRel(rel, "canonical") {
+					meta.CanonicalURL = strings.TrimSpace(getAttr(node, "href"))
+				}
+				if metaLicense == "" && getAttr(node, "itemprop") == "license" {
+					metaLicense = strings.TrimSpace(getAttr(node, "href"))
+				}
+			case "script":
+				if jsonLDLicenseValue == "" && strings.EqualFold(strings.TrimSpace(getAttr(node, "type")), "application/ld+json") {
+					jsonLDLicenseValue = jsonLDLicense(getNodeText(node))
+				}
+			case "a":
+				href := strings.TrimSpace(getAttr(node, "href"))
+				if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+					break
+				}
+				rel := strings.ToLower(getAttr(node, "rel"))
+				if meta.NextURL == "" && hasRel(rel, "next") {
+					meta.NextURL = href
+				}
+				if meta.License == "" && hasRel(rel, "license") {
+					meta.License = href
+				}
+				if hasRel(rel, "nofollow") || hasRel(rel, "ugc") || hasRel(rel, "sponsored") {
+					meta.NofollowLinks = append(meta.NofollowLinks, href)
+				}
+				if labeledNext == "" && (isNextLabel(getAttr(node, "aria-label")) || isNextLabel(getNodeText(node))) {
+					labeledNext = href
+				}
 			}
 		}
 
@@ -237,11 +770,107 @@ func extractMetadataFromHTML(htmlContent []byte) (title, description, faviconURL
 	}
 
 	extract(doc)
+	meta.LeadImageURL, meta.Excerpt = extractLead(doc)
+
+	meta.Title = strings.TrimSpace(meta.Title)
+	meta.Description = strings.TrimSpace(meta.Description)
+	if meta.ImageURL == "" {
+		meta.ImageURL = twitterImage
+	}
+	if meta.NextURL == "" {
+		meta.NextURL = labeledNext
+	}
+	if meta.License == "" {
+		meta.License = jsonLDLicenseValue
+	}
+	if meta.License == "" {
+		meta.License = metaLicense
+	}
+
+	return meta
+}
+
+// hasRel reports whether a space-separated rel attribute contains value.
+func hasRel(rel, value string) bool {
+	for _, r := range strings.Fields(rel) {
+		if r == value {
+			return true
+		}
+	}
+	return false
+}
+
+// isNextLabel reports whether link text or an aria-label reads as a link to the next page.
+func isNextLabel(label string) bool {
+	label = strings.ToLower(strings.Join(strings.Fields(label), " "))
+	label = strings.TrimSpace(strings.Trim(label, "»›→>"))
+	switch label {
+	case "next", "next page", "next part", "continue reading on next page":
+		return true
+	}
+	return false
+}
+
+// nextFromLinkHeader returns the target of a rel="next" entry in HTTP Link headers.
+func nextFromLinkHeader(values []string) string {
+	return linkHeaderTarget(values, "next")
+}
+
+// licenseFromLinkHeader returns the target of a rel="license" entry in HTTP Link headers.
+func licenseFromLinkHeader(values []string) string {
+	return linkHeaderTarget(values, "license")
+}
 
-	title = strings.TrimSpace(title)
-	description = strings.TrimSpace(description)
+// canonicalFromLinkHeader returns the target of a rel="canonical" entry in HTTP Link headers.
+func canonicalFromLinkHeader(values []string) string {
+	return linkHeaderTarget(values, "canonical")
+}
+
+// alternatesFromLinkHeader returns the language variants declared by rel="alternate" entries
+// with an hreflang in HTTP Link headers.
+func alternatesFromLinkHeader(values []string) []hreflang.Alternate {
+	var alternates []hreflang.Alternate
+	for _, value := range values {
+		for _, link := range strings.Split(value, ",") {
+			target, params, ok := strings.Cut(link, ";")
+			if !ok {
+				continue
+			}
+			var rel, lang string
+			for _, param := range strings.Split(params, ";") {
+				key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+				switch strings.ToLower(key) {
+				case "rel":
+					rel = strings.ToLower(strings.Trim(val, `"`))
+				case "hreflang":
+					lang = strings.Trim(val, `"`)
+				}
+			}
+			if hasRel(rel, "alternate") {
+				alternates = hreflang.Add(alternates, lang, strings.Trim(strings.TrimSpace(target), "<>"))
+			}
+		}
+	}
+	return alternates
+}
 
-	return title, description, faviconURL
+// linkHeaderTarget returns the target of the first entry in HTTP Link headers with relation rel.
+func linkHeaderTarget(values []string, rel string) string {
+	for _, value := range values {
+		for _, link := range strings.Split(value, ",") {
+			target, params, ok := strings.Cut(link, ";")
+			if !ok {
+				continue
+			}
+			for _, param := range strings.Split(params, ";") {
+				key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(key, "rel") && hasRel(strings.ToLower(strings.Trim(val, `"`)), rel) {
+					return strings.Trim(strings.TrimSpace(target), "<>")
+				}
+			}
+		}
+	}
+	return ""
 }
 
 // getNodeText extracts all text content from a node and its children.
@@ -268,22 +897,22 @@ func getAttr(n *html.Node, key string) string {
 	return ""
 }
 
-// resolveFaviconURL resolves a relative favicon URL to an absolute URL using the base page URL.
-func resolveFaviconURL(baseURL, faviconPath string) string {
-	if faviconPath == "" {
+// resolveURL resolves a relative URL, such as a favicon path, to an absolute URL using the base page URL.
+func resolveURL(baseURL, ref string) string {
+	if ref == "" {
 		return ""
 	}
 
 	base, err := url.Parse(baseURL)
 	if err != nil {
-		return faviconPath
+		return ref
 	}
 
-	favicon, err := url.Parse(faviconPath)
+	refURL, err := url.Parse(ref)
 	if err != nil {
-		return faviconPath
+		return ref
 	}
 
-	resolved := base.ResolveReference(favicon)
+	resolved := base.ResolveReference(refURL)
 	return resolved.String()
 }
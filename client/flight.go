@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/joeychilson/websurfer/config"
+)
+
+// fetchShared fetches urlStr from origin and caches it. Concurrent callers missing the cache for
+// the same normalized URL share one origin fetch, which is not canceled when one of them gives
+// up. Sites with a fetch_lock also take a lock in Redis, so callers on other instances wait for
+// the cached entry instead of fetching the page too. A panic in the shared fetch is returned as
+// an error, since singleflight would otherwise re-panic it where no handler can recover it.
+func (c *Client) fetchShared(ctx context.Context, urlStr string, cacheConfig config.CacheConfig) (*Response, error) {
+	ch := c.flights.DoChan(c.NormalizeURL(urlStr), func() (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				c.logger.Error("fetch panicked", "url", urlStr, "panic", r, "stack", string(debug.Stack()))
+				resp, err = nil, fmt.Errorf("fetch panicked: %v", r)
+			}
+		}()
+		return c.fetchAndCache(context.WithoutCancel(ctx), urlStr, cacheConfig)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-ch:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		if result.Shared {
+			c.logger.Debug("shared in-flight fetch", "url", urlStr)
+		}
+		resp := *result.Val.(*Response)
+		return &resp, nil
+	}
+}
+
+// fetchAndCache fetches urlStr from origin and stores it in the cache, unless the origin
+// marked it no-store. When the site has a fetch_lock and another instance is already fetching
+// the page, the entry it caches is returned instead.
+func (c *Client) fetchAndCache(ctx context.Context, urlStr string, cacheConfig config.CacheConfig) (*Response, error) {
+	if cacheConfig.FetchLock > 0 {
+		entry, release := c.cacheManager.LockFetch(ctx, urlStr, cacheConfig.FetchLock)
+		if entry != nil {
+			c.logger.Debug("cache hit (fetched by another instance)", "url", urlStr)
+			return buildResponse(entry, CacheStateHit), nil
+		}
+		defer release()
+	}
+
+	result, err := c.coordinator.Fetch(ctx, urlStr, "")
+	if err == nil && result.Entry == nil {
+		err = fmt.Errorf("%w: %s", ErrNotModified, urlStr)
+	}
+	if err != nil {
+		c.logger.Error("fetch failed", "url", urlStr, "error", err)
+		return nil, err
+	}
+
+	cacheState := CacheStateMiss
+	if isNoStore(result.Entry.Headers) {
+		c.logger.Debug("cache skipped (no-store)", "url", urlStr)
+		c.cacheManager.Delete(ctx, result.Entry.URL)
+		cacheState = CacheStateNoStore
+	} else {
+		c.cacheManager.Set(ctx, result.Entry)
+		c.cacheManager.SetRecent(ctx, urlStr, result.Entry)
+	}
+
+	c.logger.Info("fetch completed", "url", urlStr, "status_code", result.Entry.StatusCode, "body_size", len(result.Entry.Body), "attempts", len(result.Attempts))
+	resp := buildResponse(result.Entry, cacheState)
+	resp.Attempts = result.Attempts
+	resp.Transfer = result.Transfer
+	return resp, nil
+}
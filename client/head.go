@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// HeadResult describes a URL from the headers of its response, without its body.
+type HeadResult struct {
+	// URL is the URL that responded, after redirects.
+	URL         string
+	StatusCode  int
+	Headers     http.Header
+	ContentType string
+	// ContentLength is the body size the server declared, or -1 if it declared none.
+	ContentLength int64
+}
+
+// Head requests only the headers of urlStr, as Probe does, so its existence, type, and size
+// can be checked without downloading it. Robots rules and rate limits apply as for fetches.
+func (c *Client) Head(ctx context.Context, urlStr string) (*HeadResult, error) {
+	result, err := c.Probe(ctx, urlStr)
+	if err != nil {
+		return nil, err
+	}
+
+	contentLength := int64(-1)
+	if n, err := strconv.ParseInt(result.Headers.Get("Content-Length"), 10, 64); err == nil && n >= 0 {
+		contentLength = n
+	}
+	return &HeadResult{
+		URL:           result.URL,
+		StatusCode:    result.StatusCode,
+		Headers:       result.Headers,
+		ContentType:   result.Headers.Get("Content-Type"),
+		ContentLength: contentLength,
+	}, nil
+}
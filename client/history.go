@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/joeychilson/websurfer/cache"
+)
+
+// ErrVersionNotFound is returned when a requested version of a page is not in the cache history.
+var ErrVersionNotFound = errors.New("version not found")
+
+// History returns the cached versions of urlStr, newest first, starting with the current one.
+// It is empty when the page is not cached.
+func (c *Client) History(ctx context.Context, urlStr string) ([]*Response, error) {
+	entries, err := c.cacheManager.History(ctx, c.fetchURL(urlStr))
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]*Response, 0, len(entries))
+	for _, entry := range entries {
+		versions = append(versions, buildResponse(entry, CacheStateHit))
+	}
+	return versions, nil
+}
+
+// fetchVersion serves the version of urlStr selected by opts.Version or opts.AsOf from the
+// cache history, without fetching.
+func (c *Client) fetchVersion(ctx context.Context, urlStr string, opts *FetchOptions) (*Response, error) {
+	entries, err := c.cacheManager.History(ctx, urlStr)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if opts.Version > 0 && entry.Version == opts.Version {
+			return buildResponse(entry, CacheStateHit), nil
+		}
+		if opts.Version == 0 && !changedAt(entry).After(opts.AsOf) {
+			return buildResponse(entry, CacheStateHit), nil
+		}
+	}
+
+	if opts.Version > 0 {
+		return nil, fmt.Errorf("%w: version %d of %s", ErrVersionNotFound, opts.Version, urlStr)
+	}
+	return nil, fmt.Errorf("%w: no version of %s as of %s", ErrVersionNotFound, urlStr, opts.AsOf.UTC().Format(time.RFC3339))
+}
+
+// changedAt returns when entry's content was first stored, falling back to StoredAt for
+// entries stored without history.
+func changedAt(entry *cache.Entry) time.Time {
+	if entry.ChangedAt.IsZero() {
+		return entry.StoredAt
+	}
+	return entry.ChangedAt
+}
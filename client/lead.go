@@ -0,0 +1,128 @@
+package client
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// minExcerptLength is the shortest paragraph, in characters, taken as the excerpt.
+	minExcerptLength = 80
+	// maxExcerptLength caps the excerpt, which is cut at a word boundary.
+	maxExcerptLength = 300
+	// minLeadImageSize is the smallest declared width or height of a lead image, in pixels.
+	minLeadImageSize = 100
+)
+
+// skippedLeadImageHints mark images that are page chrome rather than content.
+var skippedLeadImageHints = []string{"logo", "icon", "avatar", "sprite", "badge", "pixel", "spacer", "emoji", "ads/"}
+
+// extractLead estimates the lead image and a first-paragraph excerpt the way reader modes do:
+// from the page's <article>, or <main>, or the body, skipping navigation, headers, footers,
+// and asides.
+func extractLead(doc *html.Node) (leadImageURL, excerpt string) {
+	root := findElement(doc, "article")
+	if root == nil {
+		root = findElement(doc, "main")
+	}
+	if root == nil {
+		root = findElement(doc, "body")
+	}
+	if root == nil {
+		return "", ""
+	}
+
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		if leadImageURL != "" && excerpt != "" {
+			return
+		}
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "nav", "header", "footer", "aside", "form", "script", "style", "noscript", "figcaption":
+				return
+			case "img":
+				if leadImageURL == "" && isLeadImage(n) {
+					leadImageURL = leadImageSource(n)
+				}
+			case "p":
+				if excerpt == "" {
+					if text := strings.Join(strings.Fields(getNodeText(n)), " "); len(text) >= minExcerptLength {
+						excerpt = truncateExcerpt(text)
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(root)
+
+	return leadImageURL, excerpt
+}
+
+// findElement returns the first element named tag in document order.
+func findElement(n *html.Node, tag string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == tag {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// isLeadImage reports whether img looks like content: not declared tiny, not inline data, and
+// not hinted to be a logo, icon, or similar.
+func isLeadImage(img *html.Node) bool {
+	src := leadImageSource(img)
+	if src == "" || strings.HasPrefix(strings.ToLower(src), "data:") {
+		return false
+	}
+
+	for _, dim := range []string{"width", "height"} {
+		if size, err := strconv.Atoi(strings.TrimSuffix(getAttr(img, dim), "px")); err == nil && size < minLeadImageSize {
+			return false
+		}
+	}
+
+	hints := strings.ToLower(src + " " + getAttr(img, "class") + " " + getAttr(img, "id") + " " + getAttr(img, "alt"))
+	for _, hint := range skippedLeadImageHints {
+		if strings.Contains(hints, hint) {
+			return false
+		}
+	}
+	return true
+}
+
+// leadImageSource returns the image URL, preferring lazy-loading attributes that hold the real
+// source over a placeholder src.
+func leadImageSource(img *html.Node) string {
+	for _, key := range []string{"data-src", "data-lazy-src", "src"} {
+		if src := strings.TrimSpace(getAttr(img, key)); src != "" {
+			return src
+		}
+	}
+	return ""
+}
+
+// truncateExcerpt cuts text to maxExcerptLength at a word boundary, marking the cut with "…".
+func truncateExcerpt(text string) string {
+	if len(text) <= maxExcerptLength {
+		return text
+	}
+	cut := strings.LastIndex(text[:maxExcerptLength], " ")
+	if cut <= 0 {
+		cut = maxExcerptLength
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+	}
+	return strings.TrimRight(text[:cut], " ,;:") + "…"
+}
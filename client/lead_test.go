@@ -0,0 +1,49 @@
+package client
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExtractLead verifies the lead image and excerpt come from the article, skipping page chrome.
+func TestExtractLead(t *testing.T) {
+	page := `<html><head><meta name="description" content="Buy now! The best deals."></head><body>
+		<header><img src="/logo.png"><p>Subscribe to our newsletter for the latest news and updates from our team every week.</p></header>
+		<article>
+			<img src="/tracker.gif" width="1" height="1">
+			<img class="author-avatar" src="/jane.jpg">
+			<p>Short intro.</p>
+			<figure><img data-src="/images/hero.jpg" src="data:image/gif;base64,R0lGOD"></figure>
+			<p>The city council voted on Tuesday to expand the bike lane network, adding forty miles of protected lanes by 2027.</p>
+		</article>
+	</body></html>`
+
+	meta := extractMetadataFromHTML([]byte(page))
+	meta.resolve("https://example.com/news/bikes")
+	assert.Equal(t, "https://example.com/images/hero.jpg", meta.LeadImageURL)
+	assert.Equal(t, "The city council voted on Tuesday to expand the bike lane network, adding forty miles of protected lanes by 2027.", meta.Excerpt)
+	assert.Equal(t, "Buy now! The best deals.", meta.Description)
+}
+
+// TestExtractLeadFallsBackToBody verifies pages without an article or main element use the body.
+func TestExtractLeadFallsBackToBody(t *testing.T) {
+	page := `<html><body><nav><p>` + strings.Repeat("Navigation link text ", 10) + `</p></nav>
+		<div><img src="photo.jpg" width="800"><p>` + strings.Repeat("word ", 100) + `</p></div></body></html>`
+
+	meta := extractMetadataFromHTML([]byte(page))
+	meta.resolve("https://example.com/post/")
+	assert.Equal(t, "https://example.com/post/photo.jpg", meta.LeadImageURL)
+	assert.True(t, strings.HasPrefix(meta.Excerpt, "word word"))
+	assert.True(t, strings.HasSuffix(meta.Excerpt, "word…"))
+	assert.LessOrEqual(t, len(meta.Excerpt), maxExcerptLength+len("…"))
+}
+
+// TestTruncateExcerpt verifies excerpts without spaces are cut on a rune boundary.
+func TestTruncateExcerpt(t *testing.T) {
+	excerpt := truncateExcerpt(strings.Repeat("é", maxExcerptLength))
+	assert.True(t, utf8.ValidString(excerpt))
+	assert.True(t, strings.HasSuffix(excerpt, "…"))
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// ccLicenseNames maps Creative Commons license codes to their display names.
+var ccLicenseNames = map[string]string{
+	"by":       "CC BY",
+	"by-sa":    "CC BY-SA",
+	"by-nd":    "CC BY-ND",
+	"by-nc":    "CC BY-NC",
+	"by-nc-sa": "CC BY-NC-SA",
+	"by-nc-nd": "CC BY-NC-ND",
+}
+
+// licenseName returns the display name of a well-known license URL, such as "CC BY-SA 4.0",
+// or an empty string if the URL is not recognized.
+func licenseName(licenseURL string) string {
+	u, err := url.Parse(licenseURL)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	segments := strings.FieldsFunc(strings.ToLower(u.Path), func(r rune) bool { return r == '/' })
+
+	switch host {
+	case "creativecommons.org":
+		if len(segments) < 2 {
+			return ""
+		}
+		var name string
+		switch {
+		case segments[0] == "licenses":
+			name = ccLicenseNames[segments[1]]
+		case segments[0] == "publicdomain" && segments[1] == "zero":
+			name = "CC0"
+		case segments[0] == "publicdomain" && segments[1] == "mark":
+			name = "Public Domain Mark"
+		}
+		if name != "" && len(segments) > 2 {
+			name += " " + segments[2]
+		}
+		return name
+	case "opensource.org", "spdx.org":
+		if len(segments) >= 2 && segments[0] == "licenses" {
+			return strings.ToUpper(strings.TrimSuffix(segments[1], ".html"))
+		}
+	case "gnu.org":
+		if len(segments) >= 2 && segments[0] == "licenses" {
+			return strings.ToUpper(strings.TrimSuffix(strings.TrimSuffix(segments[1], ".html"), ".en"))
+		}
+	}
+	return ""
+}
+
+// jsonLDLicense returns the license declared in a JSON-LD document, checking the top-level
+// object, arrays of objects, and @graph items. The license may be a URL, a name, or an object
+// with url, @id, or name.
+func jsonLDLicense(data string) string {
+	var doc any
+	if err := json.Unmarshal([]byte(data), &doc); err != nil {
+		return ""
+	}
+
+	var find func(v any, depth int) string
+	find = func(v any, depth int) string {
+		if depth > 2 {
+			return ""
+		}
+		switch node := v.(type) {
+		case []any:
+			for _, item := range node {
+				if license := find(item, depth+1); license != "" {
+					return license
+				}
+			}
+		case map[string]any:
+			if license := licenseValue(node["license"]); license != "" {
+				return license
+			}
+			return find(node["@graph"], depth+1)
+		}
+		return ""
+	}
+
+	return find(doc, 0)
+}
+
+// licenseValue extracts a license from a JSON-LD license property value.
+func licenseValue(v any) string {
+	switch value := v.(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case []any:
+		if len(value) > 0 {
+			return licenseValue(value[0])
+		}
+	case map[string]any:
+		for _, key := range []string{"url", "@id", "name"} {
+			if s, ok := value[key].(string); ok && strings.TrimSpace(s) != "" {
+				return strings.TrimSpace(s)
+			}
+		}
+	}
+	return ""
+}
+
+// splitLicense resolves a declared license into its URL and display name. Values that are not
+// URLs are treated as names.
+func splitLicense(baseURL, declared string) (licenseURL, name string) {
+	if declared == "" {
+		return "", ""
+	}
+
+	lower := strings.ToLower(declared)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") && !strings.HasPrefix(declared, "/") {
+		return "", declared
+	}
+
+	licenseURL = resolveURL(baseURL, declared)
+	return licenseURL, licenseName(licenseURL)
+}
+
+// robotsDirectives returns the lowercase, de-duplicated directives from robots meta tag
+// contents and X-Robots-Tag header values. Header values scoped to a specific crawler, such
+// as "googlebot: noindex", are skipped.
+func robotsDirectives(values ...string) []string {
+	var directives []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+			if directive == "" || seen[directive] {
+				continue
+			}
+			if name, _, scoped := strings.Cut(directive, ":"); scoped && !isRobotsDirective(name) {
+				continue
+			}
+			seen[directive] = true
+			directives = append(directives, directive)
+		}
+	}
+	return directives
+}
+
+// isRobotsDirective reports whether name is a directive that takes a value, like
+// "max-snippet: 50", rather than a crawler name.
+func isRobotsDirective(name string) bool {
+	switch strings.TrimSpace(name) {
+	case "max-snippet", "max-image-preview", "max-video-preview", "unavailable_after":
+		return true
+	}
+	return false
+}
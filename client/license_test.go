@@ -0,0 +1,75 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLicenseName verifies well-known license URLs are named and others are not.
+func TestLicenseName(t *testing.T) {
+	tests := map[string]string{
+		"https://creativecommons.org/licenses/by-sa/4.0/":          "CC BY-SA 4.0",
+		"http://creativecommons.org/licenses/by-nc-nd/3.0/deed.en": "CC BY-NC-ND 3.0",
+		"https://creativecommons.org/publicdomain/zero/1.0/":       "CC0 1.0",
+		"https://opensource.org/licenses/mit":                      "MIT",
+		"https://www.gnu.org/licenses/gpl-3.0.html":                "GPL-3.0",
+		"https://example.com/terms":                                "",
+	}
+	for licenseURL, expected := range tests {
+		assert.Equal(t, expected, licenseName(licenseURL), licenseURL)
+	}
+}
+
+// TestExtractLicense verifies licenses are found in rel links, JSON-LD, and meta tags, in that order of preference.
+func TestExtractLicense(t *testing.T) {
+	tests := []struct {
+		name        string
+		html        string
+		expectedURL string
+		expected    string
+	}{
+		{
+			name:        "rel license",
+			html:        `<html><head><meta name="dcterms.license" content="All rights reserved"></head><body><a rel="license" href="https://creativecommons.org/licenses/by/4.0/">CC</a></body></html>`,
+			expectedURL: "https://creativecommons.org/licenses/by/4.0/",
+			expected:    "CC BY 4.0",
+		},
+		{
+			name:        "json-ld",
+			html:        `<html><head><script type="application/ld+json">{"@graph":[{"@type":"Article","license":{"@id":"https://creativecommons.org/licenses/by-sa/4.0/"}}]}</script></head></html>`,
+			expectedURL: "https://creativecommons.org/licenses/by-sa/4.0/",
+			expected:    "CC BY-SA 4.0",
+		},
+		{
+			name:     "dublin core text",
+			html:     `<html><head><meta name="DC.rights" content="All rights reserved"></head></html>`,
+			expected: "All rights reserved",
+		},
+		{
+			name:        "relative",
+			html:        `<html><head><link rel="license" href="/license"></head></html>`,
+			expectedURL: "https://example.com/license",
+		},
+		{
+			name: "none",
+			html: `<html><body>No license</body></html>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := extractMetadataFromHTML([]byte(tt.html))
+			meta.resolve("https://example.com/article")
+			assert.Equal(t, tt.expectedURL, meta.LicenseURL)
+			assert.Equal(t, tt.expected, meta.License)
+		})
+	}
+}
+
+// TestRobotsDirectives verifies directives are normalized and crawler-scoped header values skipped.
+func TestRobotsDirectives(t *testing.T) {
+	directives := robotsDirectives("NoIndex, nofollow", "noai, noindex", "googlebot: noarchive", "max-snippet: 50")
+	assert.Equal(t, []string{"noindex", "nofollow", "noai", "max-snippet: 50"}, directives)
+	assert.Nil(t, robotsDirectives())
+}
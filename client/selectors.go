@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"strings"
+
+	"github.com/joeychilson/websurfer/selector"
+)
+
+// selectorsKey is the context key for the selectors of a fetch.
+type selectorsKey struct{}
+
+// withSelectors returns a context that evaluates selectors against the fetched page.
+func withSelectors(ctx context.Context, selectors map[string]string) context.Context {
+	return context.WithValue(ctx, selectorsKey{}, selectors)
+}
+
+// rawKey is the context key marking a fetch that returns the body its content was parsed from.
+type rawKey struct{}
+
+// withRaw returns a context that keeps the fetched page's body alongside its content.
+func withRaw(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rawKey{}, true)
+}
+
+// includeRaw reports whether the context's fetch keeps the page's body.
+func includeRaw(ctx context.Context) bool {
+	keep, _ := ctx.Value(rawKey{}).(bool)
+	return keep
+}
+
+// selectValues evaluates the context's selectors against the page's HTML, resolving URLs
+// against pageURL. Pages without HTML match nothing.
+func selectValues(ctx context.Context, pageHTML []byte, pageURL string) (map[string][]string, error) {
+	selectors, _ := ctx.Value(selectorsKey{}).(map[string]string)
+	if len(selectors) == 0 {
+		return nil, nil
+	}
+
+	if len(pageHTML) == 0 {
+		values := make(map[string][]string, len(selectors))
+		for name := range selectors {
+			values[name] = []string{}
+		}
+		return values, nil
+	}
+	return selector.Extract(pageHTML, pageURL, selectors)
+}
+
+// mainContent narrows an HTML page to the elements region matches, for sites configuring their
+// main content region. Pages where nothing matches are returned whole.
+func (f *FetchCoordinator) mainContent(urlStr, contentType string, pageHTML []byte, region selector.Matcher) []byte {
+	if region == nil || len(pageHTML) == 0 || !strings.Contains(strings.ToLower(contentType), "html") {
+		return pageHTML
+	}
+
+	narrowed, ok, err := selector.MainContent(pageHTML, region)
+	if err != nil {
+		f.logger.Warn("failed to select main content", "url", urlStr, "error", err)
+		return pageHTML
+	}
+	if !ok {
+		f.logger.Debug("main content region not found, using whole page", "url", urlStr)
+		return pageHTML
+	}
+	return narrowed
+}
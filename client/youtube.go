@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/joeychilson/websurfer/cache"
+	"github.com/joeychilson/websurfer/config"
+	"github.com/joeychilson/websurfer/youtube"
+)
+
+// fetchTranscript fetches a YouTube video's watch page and caption track and returns its
+// details and transcript as markdown, cached under urlStr. A video without captions is
+// returned with its details alone.
+func (f *FetchCoordinator) fetchTranscript(ctx context.Context, urlStr, videoID string, resolved config.ResolvedConfig) (*FetchResult, error) {
+	watchURL := youtube.WatchURL(videoID, 0)
+	page, attempts, err := f.fetchOrigin(ctx, watchURL, nil, resolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch watch page: %w", err)
+	}
+
+	video, err := youtube.ParseWatchPage(page.Body)
+	if err != nil {
+		return nil, err
+	}
+	if video.ID == "" {
+		video.ID = videoID
+	}
+
+	var (
+		cues  []youtube.Cue
+		track *youtube.Track
+	)
+	if selected, ok := video.Track(); ok {
+		captions, captionAttempts, err := f.fetchOrigin(ctx, selected.URL, nil, f.config.GetConfigForURL(selected.URL))
+		attempts = append(attempts, captionAttempts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch captions: %w", err)
+		}
+		if cues, err = youtube.ParseCaptions(captions.Body); err != nil {
+			return nil, err
+		}
+		track = &selected
+	}
+
+	f.logger.Info("fetched youtube transcript", "url", urlStr, "video_id", video.ID, "cues", len(cues))
+	return &FetchResult{
+		Entry: &cache.Entry{
+			URL:        watchURL,
+			StatusCode: http.StatusOK,
+			Headers:    map[string][]string{"Content-Type": {"text/markdown; charset=utf-8"}},
+			Body:       youtube.Markdown(video, track, cues),
+			Title:      video.Title,
+			ImageURL:   video.ThumbnailURL,
+			StoredAt:   time.Now(),
+		},
+		Attempts: attempts,
+		Transfer: &page.Transfer,
+	}, nil
+}
@@ -0,0 +1,185 @@
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// renewScript extends the leadership lease if the caller still holds it.
+	renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+	// releaseScript gives up the leadership lease if the caller still holds it.
+	releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+)
+
+// Config holds cluster configuration.
+type Config struct {
+	Prefix string
+	// InstanceID identifies this instance in leader election. Defaults to the hostname plus a random suffix.
+	InstanceID string
+	// LeaseTTL is how long leadership is held without renewal before another instance may take over.
+	LeaseTTL time.Duration
+	Logger   *slog.Logger
+}
+
+// DefaultConfig returns a cluster config with sensible defaults.
+func DefaultConfig() Config {
+	return Config{
+		Prefix:   "websurfer:cluster:",
+		LeaseTTL: 15 * time.Second,
+		Logger:   slog.Default(),
+	}
+}
+
+// applyDefaults fills in zero values with defaults.
+func applyDefaults(config Config) Config {
+	defaults := DefaultConfig()
+	if config.Prefix == "" {
+		config.Prefix = defaults.Prefix
+	}
+	if config.InstanceID == "" {
+		config.InstanceID = newInstanceID()
+	}
+	if config.LeaseTTL <= 0 {
+		config.LeaseTTL = defaults.LeaseTTL
+	}
+	if config.Logger == nil {
+		config.Logger = defaults.Logger
+	}
+	return config
+}
+
+// Elector elects a single leader among instances sharing the same Redis, so singleton work
+// like scheduling sweeps runs once per cluster rather than once per instance.
+type Elector struct {
+	client *redis.Client
+	config Config
+	key    string
+	leader atomic.Bool
+}
+
+// NewElector creates an elector for the named role.
+func NewElector(client *redis.Client, role string, config Config) *Elector {
+	config = applyDefaults(config)
+	return &Elector{
+		client: client,
+		config: config,
+		key:    config.Prefix + "leader:" + role,
+	}
+}
+
+// InstanceID returns the identifier this instance campaigns with.
+func (e *Elector) InstanceID() string {
+	return e.config.InstanceID
+}
+
+// IsLeader reports whether this instance currently holds leadership.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Leader returns the instance ID of the current leader, or an empty string if there is none.
+func (e *Elector) Leader(ctx context.Context) (string, error) {
+	id, err := e.client.Get(ctx, e.key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get leader: %w", err)
+	}
+	return id, nil
+}
+
+// Run campaigns for leadership and renews it until ctx is canceled, then releases it so
+// another instance can take over without waiting for the lease to expire.
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.config.LeaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		e.campaign(ctx)
+
+		select {
+		case <-ctx.Done():
+			e.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// campaign acquires leadership if it is free, or renews it if this instance holds it.
+func (e *Elector) campaign(ctx context.Context) {
+	logger := e.config.Logger.With("role_key", e.key, "instance_id", e.config.InstanceID)
+
+	var held bool
+	if e.leader.Load() {
+		n, err := renewScript.Run(ctx, e.client, []string{e.key}, e.config.InstanceID, e.config.LeaseTTL.Milliseconds()).Int()
+		if err != nil {
+			logger.Error("failed to renew leadership", "error", err)
+		}
+		held = err == nil && n == 1
+	} else {
+		ok, err := e.client.SetNX(ctx, e.key, e.config.InstanceID, e.config.LeaseTTL).Result()
+		if err != nil {
+			logger.Error("failed to campaign for leadership", "error", err)
+		}
+		held = err == nil && ok
+	}
+
+	if was := e.leader.Swap(held); was != held {
+		if held {
+			logger.Info("became cluster leader")
+		} else {
+			logger.Info("lost cluster leadership")
+		}
+	}
+}
+
+// release gives up leadership if this instance holds it.
+func (e *Elector) release() {
+	if !e.leader.Swap(false) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.LeaseTTL)
+	defer cancel()
+
+	if err := releaseScript.Run(ctx, e.client, []string{e.key}, e.config.InstanceID).Err(); err != nil {
+		e.config.Logger.Error("failed to release leadership", "error", err)
+	}
+}
+
+// newInstanceID generates an instance ID from the hostname and a random suffix.
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "websurfer"
+	}
+
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return host
+	}
+	return host + "-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,175 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	return redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	})
+}
+
+// TestElectorSingleLeader verifies only one instance becomes leader and another takes over after it stops.
+func TestElectorSingleLeader(t *testing.T) {
+	client := setupTestRedis(t)
+	ctx := context.Background()
+
+	a := NewElector(client, "scheduler", Config{InstanceID: "a"})
+	b := NewElector(client, "scheduler", Config{InstanceID: "b"})
+
+	a.campaign(ctx)
+	b.campaign(ctx)
+	assert.True(t, a.IsLeader())
+	assert.False(t, b.IsLeader())
+
+	leader, err := a.Leader(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "a", leader)
+
+	a.campaign(ctx)
+	assert.True(t, a.IsLeader(), "leader should renew its lease")
+
+	a.release()
+	assert.False(t, a.IsLeader())
+
+	b.campaign(ctx)
+	assert.True(t, b.IsLeader(), "another instance should take over after release")
+}
+
+// TestElectorLosesExpiredLease verifies a leader whose lease was taken over steps down on renewal.
+func TestElectorLosesExpiredLease(t *testing.T) {
+	client := setupTestRedis(t)
+	ctx := context.Background()
+
+	a := NewElector(client, "scheduler", Config{InstanceID: "a"})
+	a.campaign(ctx)
+	require.True(t, a.IsLeader())
+
+	require.NoError(t, client.Set(ctx, a.key, "b", 0).Err())
+
+	a.campaign(ctx)
+	assert.False(t, a.IsLeader())
+}
+
+// TestElectorRunReleasesOnShutdown verifies Run gives up leadership when its context is canceled.
+func TestElectorRunReleasesOnShutdown(t *testing.T) {
+	client := setupTestRedis(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	e := NewElector(client, "scheduler", Config{InstanceID: "a", LeaseTTL: 30 * time.Millisecond})
+	done := make(chan struct{})
+	go func() {
+		e.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, e.IsLeader, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-done
+
+	leader, err := e.Leader(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, leader)
+}
+
+// TestRateLimitStoreReserve verifies reservations are spaced by the interval across callers.
+func TestRateLimitStoreReserve(t *testing.T) {
+	client := setupTestRedis(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	a := NewRateLimitStore(client, Config{})
+	b := NewRateLimitStore(client, Config{})
+	a.now = func() time.Time { return now }
+	b.now = func() time.Time { return now }
+
+	wait, err := a.Reserve(ctx, "example.com", time.Second, 1)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), wait)
+
+	wait, err = b.Reserve(ctx, "example.com", time.Second, 1)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, wait, "second instance should wait for the next slot")
+
+	wait, err = a.Reserve(ctx, "example.com", time.Second, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, wait)
+
+	wait, err = a.Reserve(ctx, "other.com", time.Second, 1)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), wait, "domains should be paced independently")
+}
+
+// TestRateLimitStoreReserveBurst verifies burst requests are allowed without waiting.
+func TestRateLimitStoreReserveBurst(t *testing.T) {
+	client := setupTestRedis(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	s := NewRateLimitStore(client, Config{})
+	s.now = func() time.Time { return now }
+
+	for range 3 {
+		wait, err := s.Reserve(ctx, "example.com", time.Second, 3)
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), wait)
+	}
+
+	wait, err := s.Reserve(ctx, "example.com", time.Second, 3)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, wait)
+}
+
+// TestRateLimitStoreRetryAfter verifies the latest Retry-After is shared and kept.
+func TestRateLimitStoreRetryAfter(t *testing.T) {
+	client := setupTestRedis(t)
+	ctx := context.Background()
+	s := NewRateLimitStore(client, Config{})
+
+	until, err := s.RetryAfter(ctx, "example.com")
+	require.NoError(t, err)
+	assert.True(t, until.IsZero())
+
+	later := time.Now().Add(time.Minute).Truncate(time.Millisecond)
+	require.NoError(t, s.SetRetryAfter(ctx, "example.com", later))
+	require.NoError(t, s.SetRetryAfter(ctx, "example.com", later.Add(-30*time.Second)))
+
+	until, err = s.RetryAfter(ctx, "example.com")
+	require.NoError(t, err)
+	assert.True(t, later.Equal(until), "earlier Retry-After should not replace a later one")
+}
+
+// TestRateLimitStoreState verifies local limiter state is saved with an expiry at its full time,
+// and the latest state is kept.
+func TestRateLimitStoreState(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+	s := NewRateLimitStore(client, Config{})
+
+	fullAt, err := s.LoadState(ctx, "example.com")
+	require.NoError(t, err)
+	assert.True(t, fullAt.IsZero())
+
+	later := time.Now().Add(10 * time.Second).Truncate(time.Millisecond)
+	require.NoError(t, s.SaveState(ctx, "example.com", later))
+	require.NoError(t, s.SaveState(ctx, "example.com", later.Add(-5*time.Second)))
+	require.NoError(t, s.SaveState(ctx, "example.com", time.Now().Add(-time.Second)), "past states should be ignored")
+
+	fullAt, err = s.LoadState(ctx, "example.com")
+	require.NoError(t, err)
+	assert.True(t, later.Equal(fullAt), "earlier state should not replace a later one")
+	assert.InDelta(t, (10 * time.Second).Seconds(), mr.TTL("websurfer:cluster:rate_state:example.com").Seconds(), 1.0)
+}
@@ -0,0 +1,148 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// reserveScript schedules the next request to a domain using GCRA. It stores the domain's
+	// theoretical arrival time and returns how long the caller must wait for its slot.
+	reserveScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local tolerance = interval * (tonumber(ARGV[3]) - 1)
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+  tat = now
+end
+local wait = tat - tolerance - now
+if wait < 0 then
+  wait = 0
+end
+local new_tat = tat + interval
+redis.call('SET', KEYS[1], new_tat, 'PX', new_tat - now + interval)
+return wait
+`)
+
+	// setLaterScript records a time, in milliseconds, unless a later one is already stored.
+	setLaterScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or 0)
+if tonumber(ARGV[1]) > current then
+  redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+end
+return 1
+`)
+)
+
+// RateLimitStore shares per-domain request pacing and Retry-After back-off between instances,
+// so the configured rate applies to the cluster as a whole rather than to each instance. It
+// also persists the pacing of instances that limit locally, across restarts.
+type RateLimitStore struct {
+	client *redis.Client
+	prefix string
+	now    func() time.Time
+}
+
+// NewRateLimitStore creates a shared rate limit store with the provided client and configuration.
+func NewRateLimitStore(client *redis.Client, config Config) *RateLimitStore {
+	config = applyDefaults(config)
+	return &RateLimitStore{
+		client: client,
+		prefix: config.Prefix,
+		now:    time.Now,
+	}
+}
+
+// Reserve reserves the next request slot for domain and returns how long to wait for it.
+func (s *RateLimitStore) Reserve(ctx context.Context, domain string, interval time.Duration, burst int) (time.Duration, error) {
+	if burst < 1 {
+		burst = 1
+	}
+
+	wait, err := reserveScript.Run(ctx, s.client,
+		[]string{s.prefix + "rate:" + domain},
+		s.now().UnixMilli(), max(interval.Milliseconds(), 1), burst,
+	).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to reserve rate limit slot: %w", err)
+	}
+	return time.Duration(wait) * time.Millisecond, nil
+}
+
+// SetRetryAfter records that domain asked clients to back off until the given time.
+func (s *RateLimitStore) SetRetryAfter(ctx context.Context, domain string, until time.Time) error {
+	ttl := until.Sub(s.now())
+	if ttl <= 0 {
+		return nil
+	}
+
+	err := setLaterScript.Run(ctx, s.client,
+		[]string{s.prefix + "retry_after:" + domain},
+		until.UnixMilli(), max(ttl.Milliseconds(), 1),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("failed to set retry-after: %w", err)
+	}
+	return nil
+}
+
+// RetryAfter returns the latest back-off time recorded for domain, or the zero time if there is none.
+func (s *RateLimitStore) RetryAfter(ctx context.Context, domain string) (time.Time, error) {
+	until, err := s.getTime(ctx, s.prefix+"retry_after:"+domain)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get retry-after: %w", err)
+	}
+	return until, nil
+}
+
+// SaveState records when domain's local limiter has its full burst available again. The key
+// expires at that time, and the latest time recorded by any instance is kept, so instances
+// restarting with the state resume the most conservative pacing.
+func (s *RateLimitStore) SaveState(ctx context.Context, domain string, fullAt time.Time) error {
+	ttl := fullAt.Sub(s.now())
+	if ttl <= 0 {
+		return nil
+	}
+
+	err := setLaterScript.Run(ctx, s.client,
+		[]string{s.prefix + "rate_state:" + domain},
+		fullAt.UnixMilli(), max(ttl.Milliseconds(), 1),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("failed to save rate limit state: %w", err)
+	}
+	return nil
+}
+
+// LoadState returns when domain's local limiter has its full burst available again, or the zero
+// time if no state is recorded.
+func (s *RateLimitStore) LoadState(ctx context.Context, domain string) (time.Time, error) {
+	fullAt, err := s.getTime(ctx, s.prefix+"rate_state:"+domain)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load rate limit state: %w", err)
+	}
+	return fullAt, nil
+}
+
+// getTime reads a time stored in milliseconds at key, or the zero time if the key is missing.
+func (s *RateLimitStore) getTime(ctx context.Context, key string) (time.Time, error) {
+	value, err := s.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time value %q: %w", value, err)
+	}
+	return time.UnixMilli(ms), nil
+}
@@ -6,15 +6,22 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 
+	"github.com/joeychilson/websurfer/blobstore"
 	"github.com/joeychilson/websurfer/cache"
 	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/cluster"
 	"github.com/joeychilson/websurfer/config"
+	"github.com/joeychilson/websurfer/objectstore"
+	"github.com/joeychilson/websurfer/search/semantic"
 	"github.com/joeychilson/websurfer/server"
+	"github.com/joeychilson/websurfer/tracing"
+	"github.com/joeychilson/websurfer/translate"
 )
 
 const (
@@ -32,6 +39,16 @@ func main() {
 	configFile := getEnv("CONFIG_FILE", defaultConfigFile)
 	redisURL := getEnv("REDIS_URL", "")
 	logLevel := getEnv("LOG_LEVEL", defaultLogLevel)
+	webhookSecret := getEnv("WEBHOOK_SECRET", "")
+	signingKey := getEnv("SIGNING_KEY", "")
+	clusterMode := getEnv("CLUSTER_MODE", "") == "true"
+	sharedRateLimits := clusterMode || getEnv("SHARED_RATE_LIMITS", "") == "true"
+	instanceID := getEnv("INSTANCE_ID", "")
+	embedderProvider := getEnv("EMBEDDER", "")
+	objectStoreProvider := getEnv("OBJECT_STORE", "")
+	blobStoreBackend := getEnv("BLOB_STORE", "")
+	translatorProvider := getEnv("TRANSLATOR", "")
+	tracingEnabled := getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", getEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")) != ""
 
 	var level slog.Level
 	switch logLevel {
@@ -77,6 +94,24 @@ func main() {
 
 	log.Info("redis connection established", "url", redisURL)
 
+	sampleRatio, err := strconv.ParseFloat(getEnv("TRACE_SAMPLE_RATIO", "1"), 64)
+	if err != nil {
+		log.Error("invalid TRACE_SAMPLE_RATIO", "value", getEnv("TRACE_SAMPLE_RATIO", ""))
+		os.Exit(1)
+	}
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Enabled:     tracingEnabled,
+		ServiceName: getEnv("OTEL_SERVICE_NAME", ""),
+		SampleRatio: sampleRatio,
+	})
+	if err != nil {
+		log.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	if tracingEnabled {
+		log.Info("exporting traces over OTLP", "sample_ratio", sampleRatio)
+	}
+
 	var c *client.Client
 	if _, statErr := os.Stat(configFile); statErr == nil {
 		log.Info("loading config from file", "file", configFile)
@@ -97,14 +132,108 @@ func main() {
 	c = c.WithLogger(log)
 	defer c.Close()
 
-	c = c.WithCache(cache.New(redisClient, cache.Config{}))
+	cacheConfig := cache.Config{EnableDeduplication: getEnv("CACHE_DEDUPLICATION", "true") != "false", Logger: log}
+	if blobStoreBackend != "" {
+		cacheConfig.BlobStore, err = blobstore.New(blobstore.Config{
+			Backend: blobStoreBackend,
+			Dir:     getEnv("BLOB_STORE_DIR", ""),
+			Object: objectstore.Config{
+				Bucket:          getEnv("BLOB_STORE_BUCKET", getEnv("OBJECT_STORE_BUCKET", "")),
+				Endpoint:        getEnv("OBJECT_STORE_ENDPOINT", ""),
+				Region:          getEnv("OBJECT_STORE_REGION", ""),
+				AccessKeyID:     getEnv("OBJECT_STORE_ACCESS_KEY_ID", ""),
+				SecretAccessKey: getEnv("OBJECT_STORE_SECRET_ACCESS_KEY", ""),
+				SessionToken:    getEnv("OBJECT_STORE_SESSION_TOKEN", ""),
+				Prefix:          getEnv("BLOB_STORE_PREFIX", "bodies/"),
+			},
+		})
+		if err != nil {
+			log.Error("failed to create blob store", "error", err)
+			os.Exit(1)
+		}
+
+		cacheConfig.BlobThreshold, err = strconv.Atoi(getEnv("BLOB_THRESHOLD", "0"))
+		if err != nil || cacheConfig.BlobThreshold < 0 {
+			log.Error("invalid BLOB_THRESHOLD", "value", getEnv("BLOB_THRESHOLD", ""))
+			os.Exit(1)
+		}
+		log.Info("blob store enabled for large cached bodies", "backend", blobStoreBackend)
+	}
+
+	c = c.WithCache(cache.New(redisClient, cacheConfig))
 	log.Info("redis cache enabled")
 
-	srv, err := server.New(c, log, &server.ServerConfig{RedisClient: redisClient})
+	rateLimitStore := cluster.NewRateLimitStore(redisClient, cluster.Config{})
+	if sharedRateLimits {
+		c = c.WithRateLimitStore(rateLimitStore)
+		log.Info("sharing rate limits over redis", "cluster_mode", clusterMode)
+	} else {
+		c = c.WithRateLimitState(rateLimitStore)
+	}
+
+	var embedder semantic.Embedder
+	if embedderProvider != "" {
+		embedder, err = semantic.NewEmbedder(semantic.EmbedderConfig{
+			Provider: embedderProvider,
+			URL:      getEnv("EMBEDDER_URL", ""),
+			Model:    getEnv("EMBEDDER_MODEL", ""),
+			APIKey:   getEnv("EMBEDDER_API_KEY", ""),
+		})
+		if err != nil {
+			log.Error("failed to create embedder", "error", err)
+			os.Exit(1)
+		}
+		log.Info("semantic search enabled", "embedder", embedder.Name())
+	}
+
+	var objectStore *objectstore.Store
+	if objectStoreProvider != "" {
+		objectStore, err = objectstore.New(objectstore.Config{
+			Provider:        objectStoreProvider,
+			Bucket:          getEnv("OBJECT_STORE_BUCKET", ""),
+			Endpoint:        getEnv("OBJECT_STORE_ENDPOINT", ""),
+			Region:          getEnv("OBJECT_STORE_REGION", ""),
+			AccessKeyID:     getEnv("OBJECT_STORE_ACCESS_KEY_ID", ""),
+			SecretAccessKey: getEnv("OBJECT_STORE_SECRET_ACCESS_KEY", ""),
+			SessionToken:    getEnv("OBJECT_STORE_SESSION_TOKEN", ""),
+			Prefix:          getEnv("OBJECT_STORE_PREFIX", ""),
+		})
+		if err != nil {
+			log.Error("failed to create object store", "error", err)
+			os.Exit(1)
+		}
+		log.Info("object store enabled", "provider", objectStoreProvider)
+	}
+
+	var translator translate.Translator
+	if translatorProvider != "" {
+		translator, err = translate.New(translate.Config{
+			Provider: translatorProvider,
+			URL:      getEnv("TRANSLATOR_URL", ""),
+			APIKey:   getEnv("TRANSLATOR_API_KEY", ""),
+		})
+		if err != nil {
+			log.Error("failed to create translator", "error", err)
+			os.Exit(1)
+		}
+		log.Info("translation enabled", "provider", translatorProvider)
+	}
+
+	srv, err := server.New(c, log, &server.ServerConfig{
+		RedisClient:   redisClient,
+		WebhookSecret: webhookSecret,
+		SigningKey:    signingKey,
+		ClusterMode:   clusterMode,
+		InstanceID:    instanceID,
+		Embedder:      embedder,
+		ObjectStore:   objectStore,
+		Translator:    translator,
+	})
 	if err != nil {
 		log.Error("failed to create server", "error", err)
 		os.Exit(1)
 	}
+	defer srv.Close()
 
 	httpServer := &http.Server{
 		Addr:         addr,
@@ -140,6 +269,9 @@ func main() {
 			log.Error("server shutdown error", "error", err)
 			os.Exit(1)
 		}
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			log.Error("failed to flush traces", "error", err)
+		}
 	case err := <-errCh:
 		log.Error("server error", "error", err)
 		os.Exit(1)
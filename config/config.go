@@ -1,21 +1,84 @@
 package config
 
 import (
+	"crypto/tls"
 	"fmt"
 	"maps"
+	"math/rand/v2"
+	"net"
 	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"go.yaml.in/yaml/v2"
+
+	"github.com/joeychilson/websurfer/parser"
+	"github.com/joeychilson/websurfer/postprocess"
+	"github.com/joeychilson/websurfer/selector"
+	"github.com/joeychilson/websurfer/transform"
+	"github.com/joeychilson/websurfer/urlnorm"
 )
 
 const (
 	DefaultUserAgent = "websurfer/1.0 (webpage retriever; +https://github.com/joeychilson/websurfer)"
 )
 
+const (
+	// UserAgentPresetDefaultBot identifies as websurfer (DefaultUserAgent).
+	UserAgentPresetDefaultBot = "default-bot"
+	// UserAgentPresetBrowserChrome identifies as desktop Chrome on Windows.
+	UserAgentPresetBrowserChrome = "browser-chrome"
+	// UserAgentPresetBrowserSafari identifies as desktop Safari on macOS.
+	UserAgentPresetBrowserSafari = "browser-safari"
+	// UserAgentPresetCurl identifies as curl.
+	UserAgentPresetCurl = "curl"
+)
+
+// userAgentPresets maps preset names to the user agent strings they stand for.
+var userAgentPresets = map[string]string{
+	UserAgentPresetDefaultBot:    DefaultUserAgent,
+	UserAgentPresetBrowserChrome: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
+	UserAgentPresetBrowserSafari: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.1 Safari/605.1.15",
+	UserAgentPresetCurl:          "curl/8.7.1",
+}
+
+// ResolveUserAgent returns the user agent string for a preset name, or value unchanged if it
+// is not a preset.
+func ResolveUserAgent(value string) string {
+	if ua, ok := userAgentPresets[value]; ok {
+		return ua
+	}
+	return value
+}
+
+const (
+	// IPFamilyAuto uses both address families with standard Happy Eyeballs (default).
+	IPFamilyAuto = "auto"
+	// IPFamilyIPv4 connects over IPv4 only.
+	IPFamilyIPv4 = "ipv4"
+	// IPFamilyIPv6 connects over IPv6 only.
+	IPFamilyIPv6 = "ipv6"
+	// IPFamilyPreferIPv4 tries IPv4 first and falls back to IPv6.
+	IPFamilyPreferIPv4 = "prefer_ipv4"
+	// IPFamilyPreferIPv6 tries IPv6 first and falls back to IPv4.
+	IPFamilyPreferIPv6 = "prefer_ipv6"
+)
+
+const (
+	// RenderAuto renders HTML pages in the headless browser when their static content looks
+	// like an empty JavaScript shell (default).
+	RenderAuto = "auto"
+	// RenderAlways renders every HTML page in the headless browser, for single-page apps.
+	RenderAlways = "always"
+	// RenderNever never renders pages in the headless browser, for static sites.
+	RenderNever = "never"
+)
+
 // patternType indicates the type of pattern matching to use.
 type patternType int
 
@@ -36,18 +99,38 @@ type compiledPattern struct {
 	path        string
 }
 
-// compiledSiteConfig holds a site config with pre-compiled pattern.
+// compiledSiteConfig holds a site config with pre-compiled pattern and transforms.
 type compiledSiteConfig struct {
-	pattern compiledPattern
-	config  SiteConfig
+	pattern       compiledPattern
+	config        SiteConfig
+	transforms    transform.Pipeline
+	transformsErr error
 }
 
 // Config represents the top-level configuration structure for the webpage retriever.
 type Config struct {
+	// Region names the region this instance fetches from, reported in response metadata.
+	Region string       `yaml:"region,omitempty"`
+	Peers  []PeerConfig `yaml:"peers,omitempty"`
+	// Policy restricts which domains the server may reach.
+	Policy PolicyConfig `yaml:"policy,omitempty"`
+	// Limits caps the work of a single map or crawl, whatever the request asks for.
+	Limits LimitsConfig `yaml:"limits,omitempty"`
+	// Headless sizes the pool of headless browser contexts pages are rendered in.
+	Headless HeadlessConfig `yaml:"headless,omitempty"`
+	// Callers names API clients by their keys and weights their share of a domain's rate and
+	// concurrency limits when several wait on the same domain.
+	Callers []CallerConfig `yaml:"callers,omitempty"`
+	// Tokenizer selects how tokens are counted for max_tokens truncation
+	// (heuristic, cl100k_base, o200k_base, or claude). Defaults to heuristic.
+	Tokenizer     string        `yaml:"tokenizer,omitempty"`
 	Default       DefaultConfig `yaml:"default"`
 	Sites         []SiteConfig  `yaml:"sites"`
 	compiledSites []compiledSiteConfig
-	compiledOnce  bool
+	// defaultTransforms and defaultTransformsErr are Default.Transforms compiled.
+	defaultTransforms    transform.Pipeline
+	defaultTransformsErr error
+	compileOnce          sync.Once
 }
 
 // New returns a new Config with sensible defaults.
@@ -69,6 +152,18 @@ type ResolvedConfig struct {
 	Fetch     FetchConfig
 	RateLimit RateLimitConfig
 	Retry     RetryConfig
+	Budget    BudgetConfig
+	Routing   RoutingConfig
+	// URLNormalization is how the site's URLs are normalized.
+	URLNormalization URLNormalizationConfig
+	// Processors is the chain of post-processing steps applied to the site's content.
+	Processors []postprocess.Spec
+	// Transforms is the compiled pipeline rewriting the site's parsed content before it is
+	// cached.
+	Transforms transform.Pipeline
+	// TransformsErr is why the site's transforms failed to compile, for configs used without
+	// being validated.
+	TransformsErr error
 }
 
 // GetConfigForURL returns the merged configuration for a given URL.
@@ -80,6 +175,13 @@ func (c *Config) GetConfigForURL(url string) ResolvedConfig {
 		Fetch:     c.Default.Fetch,
 		RateLimit: c.Default.RateLimit,
 		Retry:     c.Default.Retry,
+		Budget:    c.Default.Budget,
+		Routing:   c.Default.Routing,
+
+		URLNormalization: c.Default.URLNormalization,
+		Processors:       c.Default.Processors,
+		Transforms:       c.defaultTransforms,
+		TransformsErr:    c.defaultTransformsErr,
 	}
 
 	for _, compiled := range c.compiledSites {
@@ -90,6 +192,11 @@ func (c *Config) GetConfigForURL(url string) ResolvedConfig {
 			}
 			if site.Fetch != nil {
 				resolved.Fetch = mergeFetch(resolved.Fetch, *site.Fetch)
+				if site.Fetch.SSHTunnel != nil {
+					tunnel := *site.Fetch.SSHTunnel
+					tunnel.SitePattern = site.Pattern
+					resolved.Fetch.SSHTunnel = &tunnel
+				}
 			}
 			if site.RateLimit != nil {
 				resolved.RateLimit = mergeRateLimit(resolved.RateLimit, *site.RateLimit)
@@ -97,26 +204,56 @@ func (c *Config) GetConfigForURL(url string) ResolvedConfig {
 			if site.Retry != nil {
 				resolved.Retry = mergeRetry(resolved.Retry, *site.Retry)
 			}
+			if site.Budget != nil {
+				resolved.Budget = mergeBudget(resolved.Budget, *site.Budget)
+			}
+			if site.Routing != nil {
+				resolved.Routing = mergeRouting(resolved.Routing, *site.Routing)
+			}
+			if site.URLNormalization != nil {
+				resolved.URLNormalization = mergeURLNormalization(resolved.URLNormalization, *site.URLNormalization)
+			}
+			if site.Processors != nil {
+				resolved.Processors = site.Processors
+			}
+			if len(site.Transforms) > 0 {
+				resolved.Transforms = append(slices.Clip(resolved.Transforms), compiled.transforms...)
+				if resolved.TransformsErr == nil {
+					resolved.TransformsErr = compiled.transformsErr
+				}
+			}
 		}
 	}
 	return resolved
 }
 
-// compilePatterns pre-compiles all site patterns for fast matching.
+// compilePatterns pre-compiles all site patterns for fast matching, and the transforms they
+// apply, once, even when the configuration is first resolved by concurrent fetches.
 func (c *Config) compilePatterns() {
-	if c.compiledOnce {
-		return
-	}
-	c.compiledOnce = true
-	c.compiledSites = make([]compiledSiteConfig, 0, len(c.Sites))
-
-	for _, site := range c.Sites {
-		compiled := compiledSiteConfig{
-			pattern: compilePattern(site.Pattern),
-			config:  site,
+	c.compileOnce.Do(func() {
+		c.defaultTransforms, c.defaultTransformsErr = compileTransforms("default", c.Default.Transforms)
+		c.compiledSites = make([]compiledSiteConfig, 0, len(c.Sites))
+		for i, site := range c.Sites {
+			compiled := compiledSiteConfig{
+				pattern: compilePattern(site.Pattern),
+				config:  site,
+			}
+			compiled.transforms, compiled.transformsErr = compileTransforms(fmt.Sprintf("sites[%d](%s)", i, site.Pattern), site.Transforms)
+			c.compiledSites = append(c.compiledSites, compiled)
 		}
-		c.compiledSites = append(c.compiledSites, compiled)
+	})
+}
+
+// compileTransforms compiles the transform rules configured at path.
+func compileTransforms(path string, rules []transform.Rule) (transform.Pipeline, error) {
+	if len(rules) == 0 {
+		return nil, nil
 	}
+	pipeline, err := transform.New(rules)
+	if err != nil {
+		return nil, fmt.Errorf("%s.%w", path, err)
+	}
+	return pipeline, nil
 }
 
 // compilePattern pre-parses a pattern string into a compiledPattern.
@@ -159,18 +296,83 @@ type DefaultConfig struct {
 	Fetch     FetchConfig     `yaml:"fetch"`
 	RateLimit RateLimitConfig `yaml:"rate_limit"`
 	Retry     RetryConfig     `yaml:"retry"`
+	Budget    BudgetConfig    `yaml:"budget"`
+	Routing   RoutingConfig   `yaml:"routing"`
+	// URLNormalization normalizes URLs for cache keys, crawl deduplication, and link extraction.
+	URLNormalization URLNormalizationConfig `yaml:"url_normalization,omitempty"`
+	// Processors post-process parsed content in order, such as normalize, strip_boilerplate,
+	// redact, translate, and summarize.
+	Processors []postprocess.Spec `yaml:"processors,omitempty"`
+	// Transforms rewrite parsed content before it is cached, such as stripping sections or
+	// dropping lines of site chrome.
+	Transforms []transform.Rule `yaml:"transforms,omitempty"`
 }
 
 // CacheConfig defines caching behavior for fetched webpages.
 type CacheConfig struct {
-	TTL       time.Duration `yaml:"ttl,omitempty"`
-	StaleTime time.Duration `yaml:"stale_time,omitempty"`
+	// Enabled stores fetched pages in the cache (default: true). `cache: false` is shorthand
+	// for disabling it.
+	Enabled            *bool         `yaml:"enabled,omitempty"`
+	TTL                time.Duration `yaml:"ttl,omitempty"`
+	StaleTime          time.Duration `yaml:"stale_time,omitempty"`
+	MinRefetchInterval time.Duration `yaml:"min_refetch_interval,omitempty"`
+	// MaxVersions is how many versions of each page are kept, including the current one
+	// (default: 1, no history).
+	MaxVersions int `yaml:"max_versions,omitempty"`
+	// MapTTL is how long /v1/map results for the site are served without checking its
+	// sitemaps for changes (default: 10m).
+	MapTTL time.Duration `yaml:"map_ttl,omitempty"`
+	// RefreshMinChange is the fraction of lines, from 0 to 1, a page's content must change for
+	// a background refresh to rewrite its cached entry. Smaller changes only renew the entry's
+	// timestamp (default: 0, always rewrite).
+	RefreshMinChange float64 `yaml:"refresh_min_change,omitempty"`
+	// FetchLock is how long a fetch of one of the site's pages holds a lock in Redis, so other
+	// instances missing the cache for the page wait for it to be cached instead of fetching it
+	// too (default: 0, no lock). Fetches on one instance always share a single origin fetch.
+	FetchLock time.Duration `yaml:"fetch_lock,omitempty"`
+}
+
+// UnmarshalYAML accepts a boolean in place of the cache section, so `cache: false` disables caching.
+func (c *CacheConfig) UnmarshalYAML(unmarshal func(any) error) error {
+	var enabled bool
+	if err := unmarshal(&enabled); err == nil {
+		c.Enabled = &enabled
+		return nil
+	}
+
+	type plain CacheConfig
+	return unmarshal((*plain)(c))
+}
+
+// IsEnabled returns whether fetched pages are cached (default: true)
+func (c *CacheConfig) IsEnabled() bool {
+	if c.Enabled != nil {
+		return *c.Enabled
+	}
+	return true
+}
+
+// GetMaxVersions returns how many versions of each page are kept (default: 1)
+func (c *CacheConfig) GetMaxVersions() int {
+	if c.MaxVersions > 0 {
+		return c.MaxVersions
+	}
+	return 1
+}
+
+// GetMapTTL returns how long map results are served before revalidation (default: 10m)
+func (c *CacheConfig) GetMapTTL() time.Duration {
+	if c.MapTTL > 0 {
+		return c.MapTTL
+	}
+	return 10 * time.Minute
 }
 
 // FetchConfig defines how to fetch webpages, including HTTP client settings.
 type FetchConfig struct {
 	Timeout              time.Duration     `yaml:"timeout,omitempty"`
 	UserAgent            string            `yaml:"user_agent,omitempty"`
+	UserAgents           []string          `yaml:"user_agents,omitempty"`
 	Headers              map[string]string `yaml:"headers,omitempty"`
 	CheckFormats         []string          `yaml:"check_formats,omitempty"`
 	URLRewrites          []URLRewrite      `yaml:"url_rewrites,omitempty"`
@@ -178,6 +380,22 @@ type FetchConfig struct {
 	MaxRedirects         int               `yaml:"max_redirects,omitempty"`
 	EnableSSRFProtection *bool             `yaml:"enable_ssrf_protection,omitempty"`
 	MaxBodySize          int64             `yaml:"max_body_size,omitempty"`
+	Signing              *SigningConfig    `yaml:"signing,omitempty"`
+	SSHTunnel            *SSHTunnelConfig  `yaml:"ssh_tunnel,omitempty"`
+	Transport            *TransportConfig  `yaml:"transport,omitempty"`
+	IPFamily             string            `yaml:"ip_family,omitempty"`
+	HappyEyeballsDelay   time.Duration     `yaml:"happy_eyeballs_delay,omitempty"`
+	RespectRobots        *bool             `yaml:"respect_robots,omitempty"`
+	FallbackToWayback    *bool             `yaml:"fallback_to_wayback,omitempty"`
+	// Render controls headless rendering of HTML pages: auto, always, or never.
+	Render string `yaml:"render,omitempty"`
+	// Sanitize selects which HTML tags and attributes survive conversion to markdown: strict,
+	// standard, or permissive.
+	Sanitize string `yaml:"sanitize,omitempty"`
+	// MainContent is a CSS selector or XPath expression for the region of HTML pages holding
+	// their main content. Only the matching elements are converted to markdown; pages where
+	// nothing matches are converted whole.
+	MainContent string `yaml:"main_content,omitempty"`
 }
 
 // GetFollowRedirects returns whether to follow redirects (default: false)
@@ -196,14 +414,49 @@ func (f *FetchConfig) GetEnableSSRFProtection() bool {
 	return false
 }
 
+// GetRespectRobots returns whether fetches must be allowed by the site's robots.txt (default: false)
+func (f *FetchConfig) GetRespectRobots() bool {
+	if f.RespectRobots != nil {
+		return *f.RespectRobots
+	}
+	return false
+}
+
+// GetFallbackToWayback returns whether dead pages are served from the Wayback Machine (default: false)
+func (f *FetchConfig) GetFallbackToWayback() bool {
+	if f.FallbackToWayback != nil {
+		return *f.FallbackToWayback
+	}
+	return false
+}
+
+// GetUserAgent returns the user agent to send with a request: a random entry of the
+// UserAgents rotation if set, otherwise UserAgent (default: DefaultUserAgent). Preset names
+// are resolved to their user agent strings.
+func (f *FetchConfig) GetUserAgent() string {
+	if len(f.UserAgents) > 0 {
+		return ResolveUserAgent(f.UserAgents[rand.IntN(len(f.UserAgents))])
+	}
+	return f.GetRobotsUserAgent()
+}
+
+// GetRobotsUserAgent returns the user agent robots.txt rules are evaluated for. It is stable
+// across requests even when user agents rotate: UserAgent if set, otherwise the first entry
+// of UserAgents (default: DefaultUserAgent).
+func (f *FetchConfig) GetRobotsUserAgent() string {
+	switch {
+	case f.UserAgent != "":
+		return ResolveUserAgent(f.UserAgent)
+	case len(f.UserAgents) > 0:
+		return ResolveUserAgent(f.UserAgents[0])
+	}
+	return DefaultUserAgent
+}
+
 // GetHeaders returns the headers to use for a request
 func (f *FetchConfig) GetHeaders() map[string]string {
 	headers := make(map[string]string)
-	if f.UserAgent != "" {
-		headers["User-Agent"] = f.UserAgent
-	} else {
-		headers["User-Agent"] = DefaultUserAgent
-	}
+	headers["User-Agent"] = f.GetUserAgent()
 	maps.Copy(headers, f.Headers)
 	return headers
 }
@@ -227,6 +480,125 @@ func (f *FetchConfig) GetMaxBodySize() int64 {
 	return 100 * 1024 * 1024
 }
 
+// SigningConfig defines how outbound requests are signed. Secrets are read from environment
+// variables so they never live in the config file.
+type SigningConfig struct {
+	Type string `yaml:"type"`
+	// HMAC signing
+	Header    string `yaml:"header,omitempty"`
+	SecretEnv string `yaml:"secret_env,omitempty"`
+	// AWS SigV4 signing
+	Region             string `yaml:"region,omitempty"`
+	Service            string `yaml:"service,omitempty"`
+	AccessKeyIDEnv     string `yaml:"access_key_id_env,omitempty"`
+	SecretAccessKeyEnv string `yaml:"secret_access_key_env,omitempty"`
+	SessionTokenEnv    string `yaml:"session_token_env,omitempty"`
+}
+
+// GetService returns the AWS service name with a default of "s3"
+func (s *SigningConfig) GetService() string {
+	if s.Service != "" {
+		return s.Service
+	}
+	return "s3"
+}
+
+// GetAccessKeyIDEnv returns the access key environment variable with a default of AWS_ACCESS_KEY_ID
+func (s *SigningConfig) GetAccessKeyIDEnv() string {
+	if s.AccessKeyIDEnv != "" {
+		return s.AccessKeyIDEnv
+	}
+	return "AWS_ACCESS_KEY_ID"
+}
+
+// GetSecretAccessKeyEnv returns the secret key environment variable with a default of AWS_SECRET_ACCESS_KEY
+func (s *SigningConfig) GetSecretAccessKeyEnv() string {
+	if s.SecretAccessKeyEnv != "" {
+		return s.SecretAccessKeyEnv
+	}
+	return "AWS_SECRET_ACCESS_KEY"
+}
+
+// GetSessionTokenEnv returns the session token environment variable with a default of AWS_SESSION_TOKEN
+func (s *SigningConfig) GetSessionTokenEnv() string {
+	if s.SessionTokenEnv != "" {
+		return s.SessionTokenEnv
+	}
+	return "AWS_SESSION_TOKEN"
+}
+
+// TransportConfig tunes the HTTP transport used for a site's connections.
+type TransportConfig struct {
+	EnableHTTP2         *bool  `yaml:"enable_http2,omitempty"`
+	MaxIdleConnsPerHost int    `yaml:"max_idle_conns_per_host,omitempty"`
+	TLSMinVersion       string `yaml:"tls_min_version,omitempty"`
+	DisableKeepAlives   bool   `yaml:"disable_keep_alives,omitempty"`
+	// RootCAFiles are PEM bundles trusted in addition to the system roots.
+	RootCAFiles []string `yaml:"root_ca_files,omitempty"`
+}
+
+// tlsVersions maps tls_min_version values to TLS versions.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// GetEnableHTTP2 returns whether HTTP/2 is negotiated with servers that support it (default: true)
+func (t *TransportConfig) GetEnableHTTP2() bool {
+	if t.EnableHTTP2 != nil {
+		return *t.EnableHTTP2
+	}
+	return true
+}
+
+// GetTLSMinVersion returns the minimum TLS version (default: 0, Go's default minimum)
+func (t *TransportConfig) GetTLSMinVersion() uint16 {
+	return tlsVersions[t.TLSMinVersion]
+}
+
+// SSHTunnelConfig defines an SSH jump host that fetches are tunneled through.
+type SSHTunnelConfig struct {
+	Host                     string `yaml:"host"`
+	User                     string `yaml:"user"`
+	KeyFile                  string `yaml:"key_file"`
+	KnownHostsFile           string `yaml:"known_hosts_file,omitempty"`
+	InsecureSkipHostKeyCheck bool   `yaml:"insecure_skip_host_key_check,omitempty"`
+	// SitePattern is the pattern of the site the tunnel is configured for, set when a URL's
+	// configuration is resolved. It is empty for a tunnel in the defaults.
+	SitePattern string `yaml:"-"`
+}
+
+// Allows reports whether urlStr is within the site the tunnel is configured for, so redirects
+// out of the site are not followed through the tunnel. A tunnel in the defaults allows any URL.
+func (s *SSHTunnelConfig) Allows(urlStr string) bool {
+	if s.SitePattern == "" {
+		return true
+	}
+	return matchCompiledPattern(urlStr, compilePattern(s.SitePattern))
+}
+
+// GetAddress returns the jump host address, adding the default SSH port 22 if none is set
+func (s *SSHTunnelConfig) GetAddress() string {
+	if _, _, err := net.SplitHostPort(s.Host); err == nil {
+		return s.Host
+	}
+	return net.JoinHostPort(s.Host, "22")
+}
+
+// GetKnownHostsFile returns the known_hosts path with a default of ~/.ssh/known_hosts
+func (s *SSHTunnelConfig) GetKnownHostsFile() string {
+	if s.KnownHostsFile != "" {
+		return s.KnownHostsFile
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ssh", "known_hosts")
+}
+
 // URLRewrite defines a URL transformation rule applied before fetching.
 type URLRewrite struct {
 	Type        string `yaml:"type"`
@@ -234,6 +606,44 @@ type URLRewrite struct {
 	Replacement string `yaml:"replacement,omitempty"`
 }
 
+// URLNormalizationConfig defines how URLs are normalized so that trivially different URLs share
+// a cache entry and are crawled once. The scheme and host are always lowercased and default
+// ports, dot segments, fragments, and tracking parameters such as utm_* removed.
+type URLNormalizationConfig struct {
+	// Enabled normalizes URLs (default: true).
+	Enabled *bool `yaml:"enabled,omitempty"`
+	// StripParams are query parameters removed in addition to the tracking parameters, and
+	// KeepParams are never removed. A trailing "*" matches a prefix, as in "session_*".
+	StripParams []string `yaml:"strip_params,omitempty"`
+	KeepParams  []string `yaml:"keep_params,omitempty"`
+	// KeepQueryOrder leaves query parameters unsorted (default: false).
+	KeepQueryOrder *bool `yaml:"keep_query_order,omitempty"`
+	// StripTrailingSlash removes trailing slashes from paths (default: false).
+	StripTrailingSlash *bool `yaml:"strip_trailing_slash,omitempty"`
+	// KeepFragment keeps the fragment, for sites that route on it (default: false).
+	KeepFragment *bool `yaml:"keep_fragment,omitempty"`
+}
+
+// IsEnabled returns whether URLs are normalized (default: true)
+func (n *URLNormalizationConfig) IsEnabled() bool {
+	if n.Enabled != nil {
+		return *n.Enabled
+	}
+	return true
+}
+
+// GetRules returns the normalization rules the configuration describes.
+func (n *URLNormalizationConfig) GetRules() urlnorm.Rules {
+	return urlnorm.Rules{
+		Disabled:           !n.IsEnabled(),
+		StripParams:        n.StripParams,
+		KeepParams:         n.KeepParams,
+		KeepQueryOrder:     n.KeepQueryOrder != nil && *n.KeepQueryOrder,
+		StripTrailingSlash: n.StripTrailingSlash != nil && *n.StripTrailingSlash,
+		KeepFragment:       n.KeepFragment != nil && *n.KeepFragment,
+	}
+}
+
 // SiteConfig represents configuration overrides for URLs matching a specific pattern.
 type SiteConfig struct {
 	Pattern   string           `yaml:"pattern"`
@@ -241,6 +651,14 @@ type SiteConfig struct {
 	Fetch     *FetchConfig     `yaml:"fetch,omitempty"`
 	RateLimit *RateLimitConfig `yaml:"rate_limit,omitempty"`
 	Retry     *RetryConfig     `yaml:"retry,omitempty"`
+	Budget    *BudgetConfig    `yaml:"budget,omitempty"`
+	Routing   *RoutingConfig   `yaml:"routing,omitempty"`
+	// URLNormalization overrides the default URL normalization rules.
+	URLNormalization *URLNormalizationConfig `yaml:"url_normalization,omitempty"`
+	// Processors replaces the default processor chain; an empty list disables it.
+	Processors []postprocess.Spec `yaml:"processors,omitempty"`
+	// Transforms run after the default transforms.
+	Transforms []transform.Rule `yaml:"transforms,omitempty"`
 }
 
 // RateLimitConfig defines rate limiting behavior to avoid overwhelming servers.
@@ -250,6 +668,73 @@ type RateLimitConfig struct {
 	Delay             time.Duration `yaml:"delay,omitempty"`
 	MaxConcurrent     int           `yaml:"max_concurrent,omitempty"`
 	RespectRetryAfter *bool         `yaml:"respect_retry_after,omitempty"`
+	// ErrorPause pauses a domain whose error rate suggests a block or outage.
+	ErrorPause ErrorPauseConfig `yaml:"error_pause,omitempty"`
+}
+
+// ErrorPauseConfig defines when a domain is paused for a high error rate and how it resumes.
+// While paused, requests fail fast; afterwards single probe requests are let through until
+// enough succeed in a row to resume normal traffic.
+type ErrorPauseConfig struct {
+	// Threshold is the error rate (0-1] over the window that triggers a pause. Zero disables it.
+	Threshold     float64       `yaml:"threshold,omitempty"`
+	Window        time.Duration `yaml:"window,omitempty"`
+	MinRequests   int           `yaml:"min_requests,omitempty"`
+	PauseDuration time.Duration `yaml:"pause_duration,omitempty"`
+	// ProbeSuccesses is how many consecutive probes must succeed before the domain resumes.
+	ProbeSuccesses int `yaml:"probe_successes,omitempty"`
+	// StatusCodes are the response codes counted as errors, in addition to connection failures.
+	StatusCodes []int `yaml:"status_codes,omitempty"`
+}
+
+// IsEnabled returns true if error-rate pausing is configured
+func (e *ErrorPauseConfig) IsEnabled() bool {
+	return e.Threshold > 0
+}
+
+// GetWindow returns the error-rate window with a default of 1 minute
+func (e *ErrorPauseConfig) GetWindow() time.Duration {
+	if e.Window > 0 {
+		return e.Window
+	}
+	return time.Minute
+}
+
+// GetMinRequests returns the minimum requests in the window before pausing with a default of 10
+func (e *ErrorPauseConfig) GetMinRequests() int {
+	if e.MinRequests > 0 {
+		return e.MinRequests
+	}
+	return 10
+}
+
+// GetPauseDuration returns how long a domain is paused before probing with a default of 1 minute
+func (e *ErrorPauseConfig) GetPauseDuration() time.Duration {
+	if e.PauseDuration > 0 {
+		return e.PauseDuration
+	}
+	return time.Minute
+}
+
+// GetProbeSuccesses returns the successful probes needed to resume with a default of 3
+func (e *ErrorPauseConfig) GetProbeSuccesses() int {
+	if e.ProbeSuccesses > 0 {
+		return e.ProbeSuccesses
+	}
+	return 3
+}
+
+// GetStatusCodes returns the status codes counted as errors with a default of [429, 500, 502, 503, 504]
+func (e *ErrorPauseConfig) GetStatusCodes() []int {
+	if len(e.StatusCodes) > 0 {
+		return e.StatusCodes
+	}
+	return []int{429, 500, 502, 503, 504}
+}
+
+// IsError returns true if a response with the given status code or error counts toward the error rate
+func (e *ErrorPauseConfig) IsError(statusCode int, err error) bool {
+	return err != nil || slices.Contains(e.GetStatusCodes(), statusCode)
 }
 
 // GetRespectRetryAfter returns whether to respect Retry-After headers (default: false)
@@ -273,7 +758,7 @@ func (r *RateLimitConfig) GetDelay() time.Duration {
 
 // IsEnabled returns true if any rate limiting is configured
 func (r *RateLimitConfig) IsEnabled() bool {
-	return r.RequestsPerSecond > 0 || r.Delay > 0 || r.MaxConcurrent > 0 || r.GetRespectRetryAfter()
+	return r.RequestsPerSecond > 0 || r.Delay > 0 || r.MaxConcurrent > 0 || r.GetRespectRetryAfter() || r.ErrorPause.IsEnabled()
 }
 
 // GetMaxConcurrent returns the max concurrent requests (default unlimited)
@@ -291,6 +776,9 @@ type RetryConfig struct {
 	MaxDelay     time.Duration `yaml:"max_delay,omitempty"`
 	Multiplier   float64       `yaml:"multiplier,omitempty"`
 	RetryOn      []int         `yaml:"retry_on,omitempty"`
+	// RetryOnNetworkErrors retries attempts that got no response for a transient network
+	// reason, such as a reset connection or a DNS lookup timeout, with a backoff of its own.
+	RetryOnNetworkErrors NetworkRetryConfig `yaml:"retry_on_network_errors,omitempty"`
 }
 
 // GetMaxRetries returns the max retries with a default of 0 (no retries)
@@ -338,6 +826,237 @@ func (r *RetryConfig) ShouldRetry(statusCode int) bool {
 	return slices.Contains(r.GetRetryOn(), statusCode)
 }
 
+// GetMaxNetworkRetries returns how many times an attempt failing with a transient network error
+// is retried: the network max_retries if set, otherwise max_retries, and 0 when disabled
+func (r *RetryConfig) GetMaxNetworkRetries() int {
+	if !r.RetryOnNetworkErrors.IsEnabled() {
+		return 0
+	}
+	if r.RetryOnNetworkErrors.MaxRetries > 0 {
+		return r.RetryOnNetworkErrors.MaxRetries
+	}
+	return r.GetMaxRetries()
+}
+
+// NetworkRetryConfig defines how attempts failing with transient network errors, such as reset
+// connections, temporary DNS failures, timeouts, and responses cut short, are retried. These
+// failures usually clear quickly, so they back off from a shorter delay than status codes.
+type NetworkRetryConfig struct {
+	// Enabled retries transient network errors (default: true). `retry_on_network_errors: false`
+	// is shorthand for disabling it.
+	Enabled *bool `yaml:"enabled,omitempty"`
+	// MaxRetries overrides the retry section's max_retries for network errors.
+	MaxRetries   int           `yaml:"max_retries,omitempty"`
+	InitialDelay time.Duration `yaml:"initial_delay,omitempty"`
+	MaxDelay     time.Duration `yaml:"max_delay,omitempty"`
+	Multiplier   float64       `yaml:"multiplier,omitempty"`
+}
+
+// UnmarshalYAML accepts a boolean in place of the section, so `retry_on_network_errors: false`
+// disables it.
+func (n *NetworkRetryConfig) UnmarshalYAML(unmarshal func(any) error) error {
+	var enabled bool
+	if err := unmarshal(&enabled); err == nil {
+		n.Enabled = &enabled
+		return nil
+	}
+
+	type plain NetworkRetryConfig
+	return unmarshal((*plain)(n))
+}
+
+// IsEnabled returns whether transient network errors are retried (default: true)
+func (n *NetworkRetryConfig) IsEnabled() bool {
+	if n.Enabled != nil {
+		return *n.Enabled
+	}
+	return true
+}
+
+// GetInitialDelay returns the initial delay with a default of 250 milliseconds
+func (n *NetworkRetryConfig) GetInitialDelay() time.Duration {
+	if n.InitialDelay > 0 {
+		return n.InitialDelay
+	}
+	return 250 * time.Millisecond
+}
+
+// GetMaxDelay returns the max delay with a default of 5 seconds
+func (n *NetworkRetryConfig) GetMaxDelay() time.Duration {
+	if n.MaxDelay > 0 {
+		return n.MaxDelay
+	}
+	return 5 * time.Second
+}
+
+// GetMultiplier returns the backoff multiplier with a default of 2.0
+func (n *NetworkRetryConfig) GetMultiplier() float64 {
+	if n.Multiplier > 0 {
+		return n.Multiplier
+	}
+	return 2.0
+}
+
+// BudgetConfig defines hard per-domain limits on origin requests and downloaded bytes.
+type BudgetConfig struct {
+	MaxRequestsPerHour int   `yaml:"max_requests_per_hour,omitempty"`
+	MaxBytesPerDay     int64 `yaml:"max_bytes_per_day,omitempty"`
+}
+
+// IsEnabled returns true if any budget limit is configured
+func (b *BudgetConfig) IsEnabled() bool {
+	return b.MaxRequestsPerHour > 0 || b.MaxBytesPerDay > 0
+}
+
+// PolicyConfig restricts the URLs the server fetches, maps, and crawls. Domains match
+// themselves and their subdomains; patterns are regular expressions matched against full URLs.
+type PolicyConfig struct {
+	AllowedDomains  []string `yaml:"allowed_domains,omitempty"`
+	BlockedDomains  []string `yaml:"blocked_domains,omitempty"`
+	BlockedTLDs     []string `yaml:"blocked_tlds,omitempty"`
+	AllowedPatterns []string `yaml:"allowed_patterns,omitempty"`
+	BlockedPatterns []string `yaml:"blocked_patterns,omitempty"`
+}
+
+// IsEmpty reports whether the policy places no restrictions.
+func (p *PolicyConfig) IsEmpty() bool {
+	return len(p.AllowedDomains) == 0 && len(p.BlockedDomains) == 0 && len(p.BlockedTLDs) == 0 &&
+		len(p.AllowedPatterns) == 0 && len(p.BlockedPatterns) == 0
+}
+
+// LimitsConfig defines server-wide safety limits on fetch, map, and crawl requests, enforced
+// regardless of request parameters.
+type LimitsConfig struct {
+	MaxDuration time.Duration `yaml:"max_duration,omitempty"`
+	MaxPages    int           `yaml:"max_pages,omitempty"`
+	MaxBytes    int64         `yaml:"max_bytes,omitempty"`
+	// MaxExternalFetches caps the pages fetched from origin servers rather than served from cache.
+	MaxExternalFetches int `yaml:"max_external_fetches,omitempty"`
+	// MaxURLs caps the URLs a map collects from sitemaps or links.
+	MaxURLs int `yaml:"max_urls,omitempty"`
+	// DefaultMaxTokens is the max_tokens of fetches that don't set it. Zero falls back to
+	// MaxTokens.
+	DefaultMaxTokens int `yaml:"default_max_tokens,omitempty"`
+	// MaxTokens caps the content tokens a fetch returns. Zero leaves fetches uncapped.
+	MaxTokens int `yaml:"max_tokens,omitempty"`
+	// MaxRawBytes caps the raw body a fetch with include_raw returns in its response. Larger
+	// bodies are uploaded to the object store when one is configured, or else truncated.
+	MaxRawBytes int64 `yaml:"max_raw_bytes,omitempty"`
+}
+
+// GetMaxDuration returns the maximum map or crawl duration with a default of 30 minutes
+func (l *LimitsConfig) GetMaxDuration() time.Duration {
+	if l.MaxDuration > 0 {
+		return l.MaxDuration
+	}
+	return 30 * time.Minute
+}
+
+// GetMaxPages returns the maximum pages fetched by a map or crawl with a default of 1000
+func (l *LimitsConfig) GetMaxPages() int {
+	if l.MaxPages > 0 {
+		return l.MaxPages
+	}
+	return 1000
+}
+
+// GetMaxBytes returns the maximum bytes fetched by a map or crawl with a default of 256MB
+func (l *LimitsConfig) GetMaxBytes() int64 {
+	if l.MaxBytes > 0 {
+		return l.MaxBytes
+	}
+	return 256 * 1024 * 1024
+}
+
+// GetMaxExternalFetches returns the maximum origin fetches of a map or crawl, defaulting to the
+// page limit
+func (l *LimitsConfig) GetMaxExternalFetches() int {
+	if l.MaxExternalFetches > 0 {
+		return l.MaxExternalFetches
+	}
+	return l.GetMaxPages()
+}
+
+// GetMaxURLs returns the maximum URLs collected by a map with a default of 10000
+func (l *LimitsConfig) GetMaxURLs() int {
+	if l.MaxURLs > 0 {
+		return l.MaxURLs
+	}
+	return 10000
+}
+
+// GetDefaultMaxTokens returns the max_tokens of fetches that don't set it, defaulting to the
+// MaxTokens ceiling, or 0 to return full content
+func (l *LimitsConfig) GetDefaultMaxTokens() int {
+	if l.DefaultMaxTokens > 0 {
+		return l.DefaultMaxTokens
+	}
+	return l.MaxTokens
+}
+
+// GetMaxRawBytes returns the largest raw body returned in a fetch response with a default of 1MB
+func (l *LimitsConfig) GetMaxRawBytes() int64 {
+	if l.MaxRawBytes > 0 {
+		return l.MaxRawBytes
+	}
+	return 1024 * 1024
+}
+
+// HeadlessConfig sizes the pool of browser contexts headless renders run in. PoolSize caps the
+// pages rendered at once (default 4), and RecycleAfter replaces a context after it renders that
+// many pages (default 50).
+type HeadlessConfig struct {
+	PoolSize     int `yaml:"pool_size,omitempty"`
+	RecycleAfter int `yaml:"recycle_after,omitempty"`
+}
+
+// CallerConfig identifies an API client by the key it sends, read from the APIKeyEnv
+// environment variable, and sets its Weight (default 1): a caller with weight 2 gets twice the
+// turns of a caller with weight 1 at a domain both are waiting on.
+type CallerConfig struct {
+	Name      string  `yaml:"name"`
+	APIKeyEnv string  `yaml:"api_key_env"`
+	Weight    float64 `yaml:"weight,omitempty"`
+}
+
+// GetWeight returns the caller's weight with a default of 1
+func (c *CallerConfig) GetWeight() float64 {
+	if c.Weight > 0 {
+		return c.Weight
+	}
+	return 1
+}
+
+// PeerConfig defines a remote websurfer instance in another region that fetches can be delegated to.
+type PeerConfig struct {
+	Region    string `yaml:"region"`
+	URL       string `yaml:"url"`
+	APIKeyEnv string `yaml:"api_key_env,omitempty"`
+}
+
+// RoutingConfig defines when fetches are delegated to peers in other regions.
+type RoutingConfig struct {
+	// Regions always delegates fetches to these peer regions, tried in order.
+	Regions []string `yaml:"regions,omitempty"`
+	// FallbackRegions are tried in order when the local fetch looks geo-blocked.
+	FallbackRegions []string `yaml:"fallback_regions,omitempty"`
+	// GeoBlockStatusCodes are the origin status codes treated as a geo-block.
+	GeoBlockStatusCodes []int `yaml:"geo_block_status_codes,omitempty"`
+}
+
+// GetGeoBlockStatusCodes returns the geo-block status codes with a default of [451]
+func (r *RoutingConfig) GetGeoBlockStatusCodes() []int {
+	if len(r.GeoBlockStatusCodes) > 0 {
+		return r.GeoBlockStatusCodes
+	}
+	return []int{451}
+}
+
+// IsGeoBlocked returns true if the status code indicates the origin blocked this region
+func (r *RoutingConfig) IsGeoBlocked(statusCode int) bool {
+	return slices.Contains(r.GetGeoBlockStatusCodes(), statusCode)
+}
+
 // LoadConfig loads configuration from a YAML file
 func LoadConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
@@ -356,6 +1075,9 @@ func LoadConfig(path string) (*Config, error) {
 
 // Validate checks the configuration for errors and conflicts
 func (c *Config) Validate() error {
+	if err := c.validateCache("default", c.Default.Cache); err != nil {
+		return err
+	}
 	if err := c.validateRateLimit("default", c.Default.RateLimit); err != nil {
 		return err
 	}
@@ -365,6 +1087,36 @@ func (c *Config) Validate() error {
 	if err := c.validateFetch("default", c.Default.Fetch); err != nil {
 		return err
 	}
+	if err := c.validateBudget("default", c.Default.Budget); err != nil {
+		return err
+	}
+	if err := c.validatePeers(); err != nil {
+		return err
+	}
+	if err := c.validateCallers(); err != nil {
+		return err
+	}
+	if err := c.validateRouting("default", c.Default.Routing); err != nil {
+		return err
+	}
+	if err := c.validateURLNormalization("default", c.Default.URLNormalization); err != nil {
+		return err
+	}
+	if err := postprocess.Validate(c.Default.Processors); err != nil {
+		return fmt.Errorf("default.%w", err)
+	}
+	if err := transform.Validate(c.Default.Transforms); err != nil {
+		return fmt.Errorf("default.%w", err)
+	}
+	if err := c.validatePolicy(); err != nil {
+		return err
+	}
+	if err := c.validateLimits(); err != nil {
+		return err
+	}
+	if err := c.validateHeadless(); err != nil {
+		return err
+	}
 
 	for i, site := range c.Sites {
 		if site.Pattern == "" {
@@ -373,6 +1125,11 @@ func (c *Config) Validate() error {
 
 		siteCtx := fmt.Sprintf("sites[%d](%s)", i, site.Pattern)
 
+		if site.Cache != nil {
+			if err := c.validateCache(siteCtx, *site.Cache); err != nil {
+				return err
+			}
+		}
 		if site.RateLimit != nil {
 			if err := c.validateRateLimit(siteCtx, *site.RateLimit); err != nil {
 				return err
@@ -388,6 +1145,116 @@ func (c *Config) Validate() error {
 				return err
 			}
 		}
+		if site.Budget != nil {
+			if err := c.validateBudget(siteCtx, *site.Budget); err != nil {
+				return err
+			}
+		}
+		if site.Routing != nil {
+			if err := c.validateRouting(siteCtx, *site.Routing); err != nil {
+				return err
+			}
+		}
+		if site.URLNormalization != nil {
+			if err := c.validateURLNormalization(siteCtx, *site.URLNormalization); err != nil {
+				return err
+			}
+		}
+		if err := postprocess.Validate(site.Processors); err != nil {
+			return fmt.Errorf("%s.%w", siteCtx, err)
+		}
+		if err := transform.Validate(site.Transforms); err != nil {
+			return fmt.Errorf("%s.%w", siteCtx, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) validatePolicy() error {
+	for field, patterns := range map[string][]string{
+		"allowed_patterns": c.Policy.AllowedPatterns,
+		"blocked_patterns": c.Policy.BlockedPatterns,
+	} {
+		for i, pattern := range patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("policy.%s[%d]: invalid regex %q: %w", field, i, pattern, err)
+			}
+		}
+	}
+
+	for field, domains := range map[string][]string{
+		"allowed_domains": c.Policy.AllowedDomains,
+		"blocked_domains": c.Policy.BlockedDomains,
+		"blocked_tlds":    c.Policy.BlockedTLDs,
+	} {
+		for i, domain := range domains {
+			if strings.Trim(domain, "*. ") == "" {
+				return fmt.Errorf("policy.%s[%d]: cannot be empty", field, i)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) validateLimits() error {
+	if c.Limits.MaxDuration < 0 {
+		return fmt.Errorf("limits: 'max_duration' must be >= 0")
+	}
+	if c.Limits.MaxPages < 0 {
+		return fmt.Errorf("limits: 'max_pages' must be >= 0")
+	}
+	if c.Limits.MaxBytes < 0 {
+		return fmt.Errorf("limits: 'max_bytes' must be >= 0")
+	}
+	if c.Limits.MaxExternalFetches < 0 {
+		return fmt.Errorf("limits: 'max_external_fetches' must be >= 0")
+	}
+	if c.Limits.DefaultMaxTokens < 0 {
+		return fmt.Errorf("limits: 'default_max_tokens' must be >= 0")
+	}
+	if c.Limits.MaxTokens < 0 {
+		return fmt.Errorf("limits: 'max_tokens' must be >= 0")
+	}
+	if c.Limits.MaxTokens > 0 && c.Limits.DefaultMaxTokens > c.Limits.MaxTokens {
+		return fmt.Errorf("limits: 'default_max_tokens' cannot exceed 'max_tokens'")
+	}
+	if c.Limits.MaxRawBytes < 0 {
+		return fmt.Errorf("limits: 'max_raw_bytes' must be >= 0")
+	}
+	return nil
+}
+
+func (c *Config) validateHeadless() error {
+	if c.Headless.PoolSize < 0 {
+		return fmt.Errorf("headless: 'pool_size' must be >= 0")
+	}
+	if c.Headless.RecycleAfter < 0 {
+		return fmt.Errorf("headless: 'recycle_after' must be >= 0")
+	}
+	return nil
+}
+
+func (c *Config) validateCache(ctx string, cc CacheConfig) error {
+	if cc.MinRefetchInterval < 0 {
+		return fmt.Errorf("%s.cache: 'min_refetch_interval' must be >= 0", ctx)
+	}
+
+	if cc.MaxVersions < 0 {
+		return fmt.Errorf("%s.cache: 'max_versions' must be >= 0", ctx)
+	}
+
+	if cc.MapTTL < 0 {
+		return fmt.Errorf("%s.cache: 'map_ttl' must be >= 0", ctx)
+	}
+
+	if cc.RefreshMinChange < 0 || cc.RefreshMinChange > 1 {
+		return fmt.Errorf("%s.cache: 'refresh_min_change' must be between 0 and 1", ctx)
+	}
+
+	if cc.FetchLock < 0 {
+		return fmt.Errorf("%s.cache: 'fetch_lock' must be >= 0", ctx)
 	}
 
 	return nil
@@ -406,6 +1273,25 @@ func (c *Config) validateRateLimit(ctx string, rl RateLimitConfig) error {
 		return fmt.Errorf("%s.rate_limit: 'max_concurrent' must be >= 0", ctx)
 	}
 
+	ep := rl.ErrorPause
+	if ep.Threshold < 0 || ep.Threshold > 1 {
+		return fmt.Errorf("%s.rate_limit.error_pause: 'threshold' must be between 0 and 1 (got %.2f)", ctx, ep.Threshold)
+	}
+
+	if ep.Window < 0 || ep.PauseDuration < 0 {
+		return fmt.Errorf("%s.rate_limit.error_pause: 'window' and 'pause_duration' must be >= 0", ctx)
+	}
+
+	if ep.MinRequests < 0 || ep.ProbeSuccesses < 0 {
+		return fmt.Errorf("%s.rate_limit.error_pause: 'min_requests' and 'probe_successes' must be >= 0", ctx)
+	}
+
+	for _, code := range ep.StatusCodes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("%s.rate_limit.error_pause: invalid status code %d", ctx, code)
+		}
+	}
+
 	return nil
 }
 
@@ -429,6 +1315,20 @@ func (c *Config) validateRetry(ctx string, r RetryConfig) error {
 		}
 	}
 
+	n := r.RetryOnNetworkErrors
+	if n.Multiplier > 0 && n.Multiplier < 1.0 {
+		return fmt.Errorf("%s.retry.retry_on_network_errors: 'multiplier' must be >= 1.0 (got %.2f)", ctx, n.Multiplier)
+	}
+
+	if n.MaxRetries < 0 {
+		return fmt.Errorf("%s.retry.retry_on_network_errors: 'max_retries' must be >= 0", ctx)
+	}
+
+	if n.MaxDelay > 0 && n.InitialDelay > n.MaxDelay {
+		return fmt.Errorf("%s.retry.retry_on_network_errors: 'initial_delay' (%s) cannot be greater than 'max_delay' (%s)",
+			ctx, n.InitialDelay, n.MaxDelay)
+	}
+
 	return nil
 }
 
@@ -445,6 +1345,37 @@ func (c *Config) validateFetch(ctx string, f FetchConfig) error {
 		return fmt.Errorf("%s.fetch: 'max_body_size' must be >= 0", ctx)
 	}
 
+	switch f.IPFamily {
+	case "", IPFamilyAuto, IPFamilyIPv4, IPFamilyIPv6, IPFamilyPreferIPv4, IPFamilyPreferIPv6:
+	default:
+		return fmt.Errorf("%s.fetch: 'ip_family' must be one of %q, %q, %q, %q, %q", ctx,
+			IPFamilyAuto, IPFamilyIPv4, IPFamilyIPv6, IPFamilyPreferIPv4, IPFamilyPreferIPv6)
+	}
+
+	switch f.Render {
+	case "", RenderAuto, RenderAlways, RenderNever:
+	default:
+		return fmt.Errorf("%s.fetch: 'render' must be one of %q, %q, %q", ctx, RenderAuto, RenderAlways, RenderNever)
+	}
+
+	switch f.Sanitize {
+	case "", parser.SanitizeStrict, parser.SanitizeStandard, parser.SanitizePermissive:
+	default:
+		return fmt.Errorf("%s.fetch: 'sanitize' must be one of %q, %q, %q", ctx, parser.SanitizeStrict, parser.SanitizeStandard, parser.SanitizePermissive)
+	}
+
+	if f.MainContent != "" {
+		if _, err := selector.CompileMatcher(f.MainContent); err != nil {
+			return fmt.Errorf("%s.fetch.main_content: %w", ctx, err)
+		}
+	}
+
+	for i, ua := range f.UserAgents {
+		if strings.TrimSpace(ua) == "" {
+			return fmt.Errorf("%s.fetch.user_agents[%d]: user agent cannot be empty", ctx, i)
+		}
+	}
+
 	for i, format := range f.CheckFormats {
 		if format == "" {
 			return fmt.Errorf("%s.fetch.check_formats[%d]: format cannot be empty", ctx, i)
@@ -463,6 +1394,131 @@ func (c *Config) validateFetch(ctx string, f FetchConfig) error {
 		}
 	}
 
+	if f.Signing != nil {
+		switch f.Signing.Type {
+		case "":
+			return fmt.Errorf("%s.fetch.signing: 'type' cannot be empty", ctx)
+		case "hmac":
+			if f.Signing.SecretEnv == "" {
+				return fmt.Errorf("%s.fetch.signing: 'secret_env' is required for hmac signing", ctx)
+			}
+		case "aws_sigv4":
+			if f.Signing.Region == "" {
+				return fmt.Errorf("%s.fetch.signing: 'region' is required for aws_sigv4 signing", ctx)
+			}
+		}
+	}
+
+	if f.SSHTunnel != nil {
+		if f.SSHTunnel.Host == "" {
+			return fmt.Errorf("%s.fetch.ssh_tunnel: 'host' cannot be empty", ctx)
+		}
+		if f.SSHTunnel.User == "" {
+			return fmt.Errorf("%s.fetch.ssh_tunnel: 'user' cannot be empty", ctx)
+		}
+		if f.SSHTunnel.KeyFile == "" {
+			return fmt.Errorf("%s.fetch.ssh_tunnel: 'key_file' cannot be empty", ctx)
+		}
+	}
+
+	if f.Transport != nil {
+		if f.Transport.MaxIdleConnsPerHost < 0 {
+			return fmt.Errorf("%s.fetch.transport: 'max_idle_conns_per_host' must be >= 0", ctx)
+		}
+		if _, ok := tlsVersions[f.Transport.TLSMinVersion]; f.Transport.TLSMinVersion != "" && !ok {
+			return fmt.Errorf("%s.fetch.transport: 'tls_min_version' must be one of \"1.0\", \"1.1\", \"1.2\", \"1.3\"", ctx)
+		}
+		for i, file := range f.Transport.RootCAFiles {
+			if file == "" {
+				return fmt.Errorf("%s.fetch.transport.root_ca_files[%d]: path cannot be empty", ctx, i)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) validateBudget(ctx string, b BudgetConfig) error {
+	if b.MaxRequestsPerHour < 0 {
+		return fmt.Errorf("%s.budget: 'max_requests_per_hour' must be >= 0", ctx)
+	}
+
+	if b.MaxBytesPerDay < 0 {
+		return fmt.Errorf("%s.budget: 'max_bytes_per_day' must be >= 0", ctx)
+	}
+
+	return nil
+}
+
+func (c *Config) validatePeers() error {
+	seen := make(map[string]bool, len(c.Peers))
+	for i, peer := range c.Peers {
+		if peer.Region == "" {
+			return fmt.Errorf("peers[%d]: 'region' cannot be empty", i)
+		}
+		if peer.Region == c.Region {
+			return fmt.Errorf("peers[%d]: region %q is the local region", i, peer.Region)
+		}
+		if seen[peer.Region] {
+			return fmt.Errorf("peers[%d]: duplicate region %q", i, peer.Region)
+		}
+		seen[peer.Region] = true
+
+		parsed, err := url.Parse(peer.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("peers[%d]: 'url' must be an absolute http(s) URL", i)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) validateCallers() error {
+	seen := make(map[string]bool, len(c.Callers))
+	for i, caller := range c.Callers {
+		if caller.Name == "" {
+			return fmt.Errorf("callers[%d]: 'name' cannot be empty", i)
+		}
+		if seen[caller.Name] {
+			return fmt.Errorf("callers[%d]: duplicate name %q", i, caller.Name)
+		}
+		seen[caller.Name] = true
+
+		if caller.APIKeyEnv == "" {
+			return fmt.Errorf("callers[%d]: 'api_key_env' cannot be empty", i)
+		}
+		if caller.Weight < 0 {
+			return fmt.Errorf("callers[%d]: 'weight' must be >= 0", i)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) validateRouting(ctx string, r RoutingConfig) error {
+	for _, region := range slices.Concat(r.Regions, r.FallbackRegions) {
+		if !slices.ContainsFunc(c.Peers, func(p PeerConfig) bool { return p.Region == region }) {
+			return fmt.Errorf("%s.routing: region %q has no configured peer", ctx, region)
+		}
+	}
+
+	for _, code := range r.GeoBlockStatusCodes {
+		if code < 400 || code > 599 {
+			return fmt.Errorf("%s.routing: 'geo_block_status_codes' must be HTTP error codes, got %d", ctx, code)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) validateURLNormalization(ctx string, n URLNormalizationConfig) error {
+	for field, params := range map[string][]string{"strip_params": n.StripParams, "keep_params": n.KeepParams} {
+		for _, param := range params {
+			if param == "" || param == "*" {
+				return fmt.Errorf("%s.url_normalization: '%s' entries must name a parameter or prefix, got %q", ctx, field, param)
+			}
+		}
+	}
 	return nil
 }
 
@@ -530,6 +1586,10 @@ func matchPathPattern(path, pattern string) bool {
 func mergeCache(base, override CacheConfig) CacheConfig {
 	result := base
 
+	if override.Enabled != nil {
+		result.Enabled = override.Enabled
+	}
+
 	if override.TTL != 0 {
 		result.TTL = override.TTL
 	}
@@ -538,6 +1598,26 @@ func mergeCache(base, override CacheConfig) CacheConfig {
 		result.StaleTime = override.StaleTime
 	}
 
+	if override.MinRefetchInterval != 0 {
+		result.MinRefetchInterval = override.MinRefetchInterval
+	}
+
+	if override.MaxVersions != 0 {
+		result.MaxVersions = override.MaxVersions
+	}
+
+	if override.MapTTL != 0 {
+		result.MapTTL = override.MapTTL
+	}
+
+	if override.RefreshMinChange != 0 {
+		result.RefreshMinChange = override.RefreshMinChange
+	}
+
+	if override.FetchLock != 0 {
+		result.FetchLock = override.FetchLock
+	}
+
 	return result
 }
 
@@ -550,6 +1630,11 @@ func mergeFetch(base, override FetchConfig) FetchConfig {
 
 	if override.UserAgent != "" {
 		result.UserAgent = override.UserAgent
+		result.UserAgents = nil
+	}
+
+	if len(override.UserAgents) > 0 {
+		result.UserAgents = override.UserAgents
 	}
 
 	if result.Headers == nil {
@@ -576,10 +1661,50 @@ func mergeFetch(base, override FetchConfig) FetchConfig {
 		result.EnableSSRFProtection = override.EnableSSRFProtection
 	}
 
+	if override.RespectRobots != nil {
+		result.RespectRobots = override.RespectRobots
+	}
+
+	if override.FallbackToWayback != nil {
+		result.FallbackToWayback = override.FallbackToWayback
+	}
+
 	if override.MaxBodySize > 0 {
 		result.MaxBodySize = override.MaxBodySize
 	}
 
+	if override.IPFamily != "" {
+		result.IPFamily = override.IPFamily
+	}
+
+	if override.Render != "" {
+		result.Render = override.Render
+	}
+
+	if override.Sanitize != "" {
+		result.Sanitize = override.Sanitize
+	}
+
+	if override.MainContent != "" {
+		result.MainContent = override.MainContent
+	}
+
+	if override.HappyEyeballsDelay != 0 {
+		result.HappyEyeballsDelay = override.HappyEyeballsDelay
+	}
+
+	if override.Signing != nil {
+		result.Signing = override.Signing
+	}
+
+	if override.SSHTunnel != nil {
+		result.SSHTunnel = override.SSHTunnel
+	}
+
+	if override.Transport != nil {
+		result.Transport = override.Transport
+	}
+
 	return result
 }
 
@@ -606,6 +1731,38 @@ func mergeRateLimit(base, override RateLimitConfig) RateLimitConfig {
 		result.RespectRetryAfter = override.RespectRetryAfter
 	}
 
+	result.ErrorPause = mergeErrorPause(result.ErrorPause, override.ErrorPause)
+
+	return result
+}
+
+func mergeErrorPause(base, override ErrorPauseConfig) ErrorPauseConfig {
+	result := base
+
+	if override.Threshold > 0 {
+		result.Threshold = override.Threshold
+	}
+
+	if override.Window > 0 {
+		result.Window = override.Window
+	}
+
+	if override.MinRequests > 0 {
+		result.MinRequests = override.MinRequests
+	}
+
+	if override.PauseDuration > 0 {
+		result.PauseDuration = override.PauseDuration
+	}
+
+	if override.ProbeSuccesses > 0 {
+		result.ProbeSuccesses = override.ProbeSuccesses
+	}
+
+	if len(override.StatusCodes) > 0 {
+		result.StatusCodes = override.StatusCodes
+	}
+
 	return result
 }
 
@@ -632,5 +1789,95 @@ func mergeRetry(base, override RetryConfig) RetryConfig {
 		result.RetryOn = override.RetryOn
 	}
 
+	result.RetryOnNetworkErrors = mergeNetworkRetry(result.RetryOnNetworkErrors, override.RetryOnNetworkErrors)
+
+	return result
+}
+
+func mergeNetworkRetry(base, override NetworkRetryConfig) NetworkRetryConfig {
+	result := base
+
+	if override.Enabled != nil {
+		result.Enabled = override.Enabled
+	}
+
+	if override.MaxRetries > 0 {
+		result.MaxRetries = override.MaxRetries
+	}
+
+	if override.InitialDelay > 0 {
+		result.InitialDelay = override.InitialDelay
+	}
+
+	if override.MaxDelay > 0 {
+		result.MaxDelay = override.MaxDelay
+	}
+
+	if override.Multiplier > 0 {
+		result.Multiplier = override.Multiplier
+	}
+
+	return result
+}
+
+func mergeBudget(base, override BudgetConfig) BudgetConfig {
+	result := base
+
+	if override.MaxRequestsPerHour > 0 {
+		result.MaxRequestsPerHour = override.MaxRequestsPerHour
+	}
+
+	if override.MaxBytesPerDay > 0 {
+		result.MaxBytesPerDay = override.MaxBytesPerDay
+	}
+
+	return result
+}
+
+func mergeRouting(base, override RoutingConfig) RoutingConfig {
+	result := base
+
+	if len(override.Regions) > 0 {
+		result.Regions = override.Regions
+	}
+
+	if len(override.FallbackRegions) > 0 {
+		result.FallbackRegions = override.FallbackRegions
+	}
+
+	if len(override.GeoBlockStatusCodes) > 0 {
+		result.GeoBlockStatusCodes = override.GeoBlockStatusCodes
+	}
+
+	return result
+}
+
+func mergeURLNormalization(base, override URLNormalizationConfig) URLNormalizationConfig {
+	result := base
+
+	if override.Enabled != nil {
+		result.Enabled = override.Enabled
+	}
+
+	if override.StripParams != nil {
+		result.StripParams = override.StripParams
+	}
+
+	if override.KeepParams != nil {
+		result.KeepParams = override.KeepParams
+	}
+
+	if override.KeepQueryOrder != nil {
+		result.KeepQueryOrder = override.KeepQueryOrder
+	}
+
+	if override.StripTrailingSlash != nil {
+		result.StripTrailingSlash = override.StripTrailingSlash
+	}
+
+	if override.KeepFragment != nil {
+		result.KeepFragment = override.KeepFragment
+	}
+
 	return result
 }
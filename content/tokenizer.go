@@ -0,0 +1,136 @@
+package content
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+const (
+	// TokenizerHeuristic estimates tokens from per-content-type character ratios.
+	TokenizerHeuristic = "heuristic"
+	// TokenizerCL100K is the BPE encoding used by GPT-4 and GPT-3.5 models.
+	TokenizerCL100K = "cl100k_base"
+	// TokenizerO200K is the BPE encoding used by GPT-4o and later OpenAI models.
+	TokenizerO200K = "o200k_base"
+	// TokenizerClaude approximates Claude models, whose tokenizer is not published.
+	TokenizerClaude = "claude"
+)
+
+// claudeTokenScale inflates cl100k_base counts to approximate Claude's tokenizer. Claude
+// produces more tokens than cl100k_base for the same text, so the count errs on the high
+// side to keep truncated content within a Claude context budget.
+const claudeTokenScale = 1.2
+
+// Tokenizer counts tokens and maps token counts back to byte offsets.
+type Tokenizer interface {
+	// Name returns the tokenizer's configuration name.
+	Name() string
+	// Count returns the number of tokens in content.
+	Count(content []byte, contentType string) int
+	// BytesForTokens returns how many leading bytes of content fit in the given number of tokens.
+	BytesForTokens(content []byte, contentType string, tokens int) int
+}
+
+// Heuristic is the default tokenizer, which estimates tokens without encoding the content.
+var Heuristic Tokenizer = heuristicTokenizer{}
+
+var (
+	loaderOnce sync.Once
+	encodings  sync.Map
+)
+
+// NewTokenizer returns the tokenizer with the given name. An empty name returns Heuristic.
+// BPE vocabularies are embedded and loaded once on first use.
+func NewTokenizer(name string) (Tokenizer, error) {
+	switch name {
+	case "", TokenizerHeuristic:
+		return Heuristic, nil
+	case TokenizerCL100K, TokenizerO200K:
+		enc, err := loadEncoding(name)
+		if err != nil {
+			return nil, err
+		}
+		return &bpeTokenizer{name: name, enc: enc, scale: 1}, nil
+	case TokenizerClaude:
+		enc, err := loadEncoding(TokenizerCL100K)
+		if err != nil {
+			return nil, err
+		}
+		return &bpeTokenizer{name: name, enc: enc, scale: claudeTokenScale}, nil
+	default:
+		return nil, fmt.Errorf("unknown tokenizer %q (must be one of: %s, %s, %s, %s)",
+			name, TokenizerHeuristic, TokenizerCL100K, TokenizerO200K, TokenizerClaude)
+	}
+}
+
+// loadEncoding returns the cached BPE encoding with the given name.
+func loadEncoding(name string) (*tiktoken.Tiktoken, error) {
+	if enc, ok := encodings.Load(name); ok {
+		return enc.(*tiktoken.Tiktoken), nil
+	}
+
+	loaderOnce.Do(func() {
+		tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+	})
+
+	enc, err := tiktoken.GetEncoding(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s encoding: %w", name, err)
+	}
+
+	actual, _ := encodings.LoadOrStore(name, enc)
+	return actual.(*tiktoken.Tiktoken), nil
+}
+
+// heuristicTokenizer estimates tokens from characters-per-token ratios.
+type heuristicTokenizer struct{}
+
+// Name returns the tokenizer's configuration name.
+func (heuristicTokenizer) Name() string {
+	return TokenizerHeuristic
+}
+
+// Count estimates the number of tokens in content.
+func (heuristicTokenizer) Count(content []byte, contentType string) int {
+	return EstimateTokens(content, contentType)
+}
+
+// BytesForTokens estimates how many bytes the given number of tokens covers.
+func (heuristicTokenizer) BytesForTokens(_ []byte, contentType string, tokens int) int {
+	return charsForTokens(tokens, contentType)
+}
+
+// bpeTokenizer counts tokens with a byte pair encoding, optionally scaled.
+type bpeTokenizer struct {
+	name  string
+	enc   *tiktoken.Tiktoken
+	scale float64
+}
+
+// Name returns the tokenizer's configuration name.
+func (t *bpeTokenizer) Name() string {
+	return t.name
+}
+
+// Count encodes content and returns its token count.
+func (t *bpeTokenizer) Count(content []byte, _ string) int {
+	if len(content) == 0 {
+		return 0
+	}
+	return int(math.Ceil(float64(len(t.enc.EncodeOrdinary(string(content)))) * t.scale))
+}
+
+// BytesForTokens encodes content and returns the byte length of its first tokens.
+func (t *bpeTokenizer) BytesForTokens(content []byte, _ string, tokens int) int {
+	ids := t.enc.EncodeOrdinary(string(content))
+
+	n := int(float64(tokens) / t.scale)
+	if n >= len(ids) {
+		return len(content)
+	}
+	return len(t.enc.Decode(ids[:n]))
+}
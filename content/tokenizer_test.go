@@ -0,0 +1,87 @@
+package content
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewTokenizerDefault verifies an empty name selects the heuristic tokenizer.
+func TestNewTokenizerDefault(t *testing.T) {
+	tok, err := NewTokenizer("")
+	require.NoError(t, err)
+	assert.Equal(t, Heuristic, tok)
+	assert.Equal(t, TokenizerHeuristic, tok.Name())
+}
+
+// TestNewTokenizerUnknown verifies unknown tokenizer names are rejected.
+func TestNewTokenizerUnknown(t *testing.T) {
+	_, err := NewTokenizer("gpt2")
+	assert.ErrorContains(t, err, "unknown tokenizer")
+}
+
+// TestBPETokenizerCount verifies BPE tokenizers count tokens exactly.
+func TestBPETokenizerCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected int
+	}{
+		{TokenizerCL100K, "hello world", 2},
+		{TokenizerO200K, "hello world", 2},
+		{TokenizerCL100K, "", 0},
+	}
+
+	for _, tt := range tests {
+		tok, err := NewTokenizer(tt.name)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, tok.Count([]byte(tt.content), "text/plain"), "%s: %q", tt.name, tt.content)
+	}
+}
+
+// TestBPETokenizerIgnoresSpecialTokens verifies special token text in content is encoded as ordinary text.
+func TestBPETokenizerIgnoresSpecialTokens(t *testing.T) {
+	tok, err := NewTokenizer(TokenizerCL100K)
+	require.NoError(t, err)
+
+	assert.NotPanics(t, func() {
+		assert.Greater(t, tok.Count([]byte("before <|endoftext|> after"), "text/plain"), 3)
+	})
+}
+
+// TestClaudeTokenizerScalesCount verifies the Claude tokenizer counts at least as many tokens as cl100k_base.
+func TestClaudeTokenizerScalesCount(t *testing.T) {
+	cl100k, err := NewTokenizer(TokenizerCL100K)
+	require.NoError(t, err)
+	claude, err := NewTokenizer(TokenizerClaude)
+	require.NoError(t, err)
+
+	content := []byte(strings.Repeat("The quick brown fox jumps over the lazy dog. ", 20))
+	assert.Greater(t, claude.Count(content, "text/plain"), cl100k.Count(content, "text/plain"))
+}
+
+// TestBPETokenizerBytesForTokens verifies byte offsets land on token boundaries.
+func TestBPETokenizerBytesForTokens(t *testing.T) {
+	tok, err := NewTokenizer(TokenizerCL100K)
+	require.NoError(t, err)
+
+	content := []byte("hello world, hello again")
+	assert.Equal(t, len("hello world"), tok.BytesForTokens(content, "text/plain", 2))
+	assert.Equal(t, len(content), tok.BytesForTokens(content, "text/plain", 1000))
+}
+
+// TestTruncateWithBPETokenizer verifies truncation respects exact token counts.
+func TestTruncateWithBPETokenizer(t *testing.T) {
+	tok, err := NewTokenizer(TokenizerO200K)
+	require.NoError(t, err)
+
+	content := []byte(strings.Repeat("Tokenizers split text into pieces. ", 200))
+	result := TruncateWith(tok, content, "text/markdown", 100)
+
+	assert.True(t, result.Truncated)
+	assert.LessOrEqual(t, result.ReturnedTokens, 100)
+	assert.Equal(t, tok.Count([]byte(result.Content), "text/markdown"), result.ReturnedTokens)
+	assert.Equal(t, tok.Count(content, "text/markdown"), result.TotalTokens)
+}
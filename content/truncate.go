@@ -26,8 +26,13 @@ type TruncateResult struct {
 
 // TruncateBytes truncates content to fit within maxTokens using smart boundaries.
 func Truncate(content []byte, contentType string, maxTokens int) *TruncateResult {
+	return TruncateWith(Heuristic, content, contentType, maxTokens)
+}
+
+// TruncateWith truncates content to fit within maxTokens as counted by the given tokenizer.
+func TruncateWith(tokenizer Tokenizer, content []byte, contentType string, maxTokens int) *TruncateResult {
 	totalChars := len(content)
-	totalTokens := EstimateTokens(content, contentType)
+	totalTokens := tokenizer.Count(content, contentType)
 
 	if totalTokens <= maxTokens {
 		return &TruncateResult{
@@ -41,12 +46,12 @@ func Truncate(content []byte, contentType string, maxTokens int) *TruncateResult
 		}
 	}
 
-	targetChars := charsForTokens(maxTokens, contentType)
+	targetChars := tokenizer.BytesForTokens(content, contentType, maxTokens)
 
 	truncateAt := findTruncationPoint(content, contentType, targetChars)
 
 	truncated := content[:truncateAt]
-	returnedTokens := EstimateTokens(truncated, contentType)
+	returnedTokens := tokenizer.Count(truncated, contentType)
 
 	return &TruncateResult{
 		Content:        string(truncated),
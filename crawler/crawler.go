@@ -0,0 +1,306 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/joeychilson/websurfer/hreflang"
+	"github.com/joeychilson/websurfer/urlnorm"
+)
+
+const (
+	// CanonicalIgnore crawls pages under the URL they were reached by, whatever their canonical URL.
+	CanonicalIgnore = "ignore"
+	// CanonicalSkip drops pages whose canonical URL is another page and crawls that page instead.
+	CanonicalSkip = "skip"
+	// CanonicalRemap reports pages under their canonical URL, once per canonical URL.
+	CanonicalRemap = "remap"
+)
+
+var (
+	// markdownLinkRegex extracts link targets from markdown content.
+	markdownLinkRegex = regexp.MustCompile(`\]\(([^)\s]+)`)
+)
+
+// Config holds crawler configuration.
+type Config struct {
+	// MaxPages caps how many pages are fetched (default: 100).
+	MaxPages int
+	// MaxDepth caps how many links away from the start page the crawl goes (default: 3).
+	MaxDepth int
+	// Concurrency is how many pages are fetched at once (default: 4).
+	Concurrency int
+	// RespectNofollow skips links marked nofollow, and every link of pages whose robots
+	// directives include nofollow.
+	RespectNofollow bool
+	// Canonical is CanonicalIgnore (default), CanonicalSkip, or CanonicalRemap. Canonical URLs
+	// on other hosts are ignored.
+	Canonical string
+	// NormalizeURL normalizes the URLs of discovered pages, so each page is crawled once however
+	// it is linked (default: urlnorm's default rules).
+	NormalizeURL func(string) string
+	// Discovered, if set, is called with the number of distinct pages found so far, including
+	// the start page, each time links add pages to the crawl. It may exceed MaxPages, since
+	// the crawl stops before fetching every page it finds.
+	Discovered func(n int)
+	Logger     *slog.Logger
+}
+
+// DefaultConfig returns a crawler config with sensible defaults.
+func DefaultConfig() Config {
+	return Config{
+		MaxPages:    100,
+		MaxDepth:    3,
+		Concurrency: 4,
+		NormalizeURL: func(u string) string {
+			return urlnorm.Normalize(u, urlnorm.Rules{})
+		},
+		Logger: slog.Default(),
+	}
+}
+
+// Result is a fetched page as returned by a FetchFunc. Body is expected to be markdown so its
+// links can be followed.
+type Result struct {
+	URL          string
+	StatusCode   int
+	ContentType  string
+	Title        string
+	LastModified string
+	Body         []byte
+	// CanonicalURL is the page's preferred URL, if it declares one.
+	CanonicalURL string
+	// Nofollow is set when the page's robots directives ask for none of its links to be followed,
+	// and NofollowLinks lists the targets of its individual nofollow links.
+	Nofollow      bool
+	NofollowLinks []string
+	// Alternates lists the page's language variants from hreflang links.
+	Alternates []hreflang.Alternate
+}
+
+// FetchFunc fetches a single page.
+type FetchFunc func(ctx context.Context, pageURL string) (*Result, error)
+
+// Page is a page visited during a crawl. Result is nil if the fetch failed, in which case Err
+// is set. RemappedFrom is the URL the page was reached by when it is reported under its
+// canonical URL.
+type Page struct {
+	URL          string
+	Depth        int
+	Result       *Result
+	Err          error
+	RemappedFrom string
+}
+
+// Crawler walks the pages of a site breadth-first, following same-host links.
+type Crawler struct {
+	fetch  FetchFunc
+	config Config
+}
+
+// New creates a crawler that retrieves pages with fetch.
+func New(fetch FetchFunc, config Config) *Crawler {
+	defaults := DefaultConfig()
+	if config.MaxPages <= 0 {
+		config.MaxPages = defaults.MaxPages
+	}
+	if config.MaxDepth <= 0 {
+		config.MaxDepth = defaults.MaxDepth
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = defaults.Concurrency
+	}
+	if config.NormalizeURL == nil {
+		config.NormalizeURL = defaults.NormalizeURL
+	}
+	if config.Logger == nil {
+		config.Logger = defaults.Logger
+	}
+	return &Crawler{fetch: fetch, config: config}
+}
+
+// Crawl fetches startURL and the same-host pages it links to, level by level, calling visit
+// for each page in discovery order. Pages are not retained, so visit should keep whatever it
+// needs. Crawl stops early, returning the context's error, if ctx is canceled.
+func (c *Crawler) Crawl(ctx context.Context, startURL string, visit func(*Page)) error {
+	start, err := url.Parse(c.config.NormalizeURL(startURL))
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if start.Host == "" {
+		return fmt.Errorf("url has no host: %s", startURL)
+	}
+	start.Fragment = ""
+
+	seen := map[string]bool{start.String(): true}
+	reported := make(map[string]bool)
+	frontier := []string{start.String()}
+	fetched := 0
+
+	for depth := 0; len(frontier) > 0 && depth <= c.config.MaxDepth; depth++ {
+		// A page remapped to its canonical URL stands in for that URL, so it is not fetched again.
+		frontier = slices.DeleteFunc(frontier, func(u string) bool { return reported[u] })
+		if remaining := c.config.MaxPages - fetched; len(frontier) > remaining {
+			frontier = frontier[:remaining]
+		}
+
+		pages := c.fetchLevel(ctx, frontier, depth)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		fetched += len(pages)
+
+		var next []string
+		enqueue := func(link *url.URL) {
+			if link.Host != start.Host {
+				return
+			}
+			if key := link.String(); !seen[key] {
+				seen[key] = true
+				next = append(next, key)
+				if c.config.Discovered != nil {
+					c.config.Discovered(len(seen))
+				}
+			}
+		}
+
+		for _, page := range pages {
+			if canonical := c.canonicalOf(page, start.Host); canonical != nil {
+				if c.config.Canonical == CanonicalSkip {
+					enqueue(canonical)
+					continue
+				}
+				page.RemappedFrom = page.URL
+				page.URL = canonical.String()
+				seen[page.URL] = true
+			}
+			if reported[page.URL] {
+				continue
+			}
+			reported[page.URL] = true
+
+			visit(page)
+
+			if page.Result == nil || fetched >= c.config.MaxPages || depth == c.config.MaxDepth {
+				continue
+			}
+			if c.config.RespectNofollow && page.Result.Nofollow {
+				continue
+			}
+
+			pageURL, err := url.Parse(page.Result.URL)
+			if err != nil || pageURL.Host == "" {
+				pageURL, _ = url.Parse(page.URL)
+			}
+			nofollow := c.nofollowLinks(page.Result, pageURL)
+			for _, link := range ExtractLinks(page.Result.Body, pageURL, c.config.NormalizeURL) {
+				if !nofollow[link.String()] {
+					enqueue(link)
+				}
+			}
+		}
+
+		frontier = next
+	}
+
+	return nil
+}
+
+// canonicalOf returns the page's canonical URL when canonical handling is enabled and it names
+// another page on host, or nil.
+func (c *Crawler) canonicalOf(page *Page, host string) *url.URL {
+	if c.config.Canonical != CanonicalSkip && c.config.Canonical != CanonicalRemap {
+		return nil
+	}
+	if page.Result == nil || page.Result.CanonicalURL == "" {
+		return nil
+	}
+
+	canonical, err := url.Parse(c.config.NormalizeURL(page.Result.CanonicalURL))
+	if err != nil || canonical.Host != host || (canonical.Scheme != "http" && canonical.Scheme != "https") {
+		return nil
+	}
+	canonical.Fragment = ""
+
+	if key := canonical.String(); key == page.URL || key == c.config.NormalizeURL(page.Result.URL) {
+		return nil
+	}
+	return canonical
+}
+
+// nofollowLinks returns the set of the page's nofollow link targets, resolved against pageURL,
+// or nil when nofollow is not respected.
+func (c *Crawler) nofollowLinks(result *Result, pageURL *url.URL) map[string]bool {
+	if !c.config.RespectNofollow || len(result.NofollowLinks) == 0 {
+		return nil
+	}
+
+	links := make(map[string]bool, len(result.NofollowLinks))
+	for _, href := range result.NofollowLinks {
+		ref, err := url.Parse(href)
+		if err != nil {
+			continue
+		}
+		u := pageURL.ResolveReference(ref)
+		u.Fragment = ""
+		links[c.config.NormalizeURL(u.String())] = true
+	}
+	return links
+}
+
+// fetchLevel fetches every URL in urls concurrently and returns the pages in the same order.
+func (c *Crawler) fetchLevel(ctx context.Context, urls []string, depth int) []*Page {
+	pages := make([]*Page, len(urls))
+	sem := make(chan struct{}, c.config.Concurrency)
+
+	var wg sync.WaitGroup
+	for i, pageURL := range urls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			page := &Page{URL: pageURL, Depth: depth}
+			page.Result, page.Err = c.fetch(ctx, pageURL)
+			if page.Err != nil {
+				page.Result = nil
+				c.config.Logger.Debug("crawl fetch failed", "url", pageURL, "error", page.Err)
+			}
+			pages[i] = page
+		}()
+	}
+	wg.Wait()
+
+	return pages
+}
+
+// ExtractLinks returns the http and https links in markdown content, resolved against pageURL
+// and without fragments. Links are normalized with normalize unless it is nil.
+func ExtractLinks(body []byte, pageURL *url.URL, normalize func(string) string) []*url.URL {
+	var links []*url.URL
+	for _, match := range markdownLinkRegex.FindAllSubmatch(body, -1) {
+		ref, err := url.Parse(string(match[1]))
+		if err != nil {
+			continue
+		}
+
+		u := pageURL.ResolveReference(ref)
+		u.Fragment = ""
+		if u.Scheme != "http" && u.Scheme != "https" {
+			continue
+		}
+		if normalize != nil {
+			if normalized, err := url.Parse(normalize(u.String())); err == nil {
+				u = normalized
+			}
+		}
+		links = append(links, u)
+	}
+	return links
+}
@@ -0,0 +1,233 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joeychilson/websurfer/urlnorm"
+)
+
+// fakeSite returns a fetch function serving markdown pages from a map, counting fetches per URL.
+func fakeSite(pages map[string]string) (FetchFunc, map[string]int) {
+	var mu sync.Mutex
+	fetches := make(map[string]int)
+	return func(ctx context.Context, pageURL string) (*Result, error) {
+		mu.Lock()
+		fetches[pageURL]++
+		mu.Unlock()
+
+		body, ok := pages[pageURL]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return &Result{URL: pageURL, StatusCode: 200, Body: []byte(body)}, nil
+	}, fetches
+}
+
+// TestCrawl verifies pages are visited breadth-first on the same host, once each.
+func TestCrawl(t *testing.T) {
+	fetch, fetches := fakeSite(map[string]string{
+		"https://example.com/":       "[A](/a) [B](/b#top) [Other](https://other.com/x)",
+		"https://example.com/a":      "[Home](/) [Deep](/a/deep)",
+		"https://example.com/b":      "[A](/a)",
+		"https://example.com/a/deep": "no links",
+	})
+
+	var visited []string
+	depths := make(map[string]int)
+	err := New(fetch, Config{}).Crawl(context.Background(), "https://example.com/", func(p *Page) {
+		visited = append(visited, p.URL)
+		depths[p.URL] = p.Depth
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"https://example.com/",
+		"https://example.com/a",
+		"https://example.com/b",
+		"https://example.com/a/deep",
+	}, visited)
+	assert.Equal(t, 2, depths["https://example.com/a/deep"])
+	assert.NotContains(t, fetches, "https://other.com/x", "other hosts should not be crawled")
+	for u, n := range fetches {
+		assert.Equal(t, 1, n, "each page should be fetched once: %s", u)
+	}
+}
+
+// TestCrawlLimits verifies MaxDepth and MaxPages bound the crawl.
+func TestCrawlLimits(t *testing.T) {
+	site := map[string]string{
+		"https://example.com/":  "[1](/1) [2](/2) [3](/3)",
+		"https://example.com/1": "[4](/4)",
+		"https://example.com/2": "",
+		"https://example.com/3": "",
+		"https://example.com/4": "",
+	}
+
+	fetch, _ := fakeSite(site)
+	var count int
+	err := New(fetch, Config{MaxDepth: 1}).Crawl(context.Background(), "https://example.com/", func(p *Page) { count++ })
+	require.NoError(t, err)
+	assert.Equal(t, 4, count, "depth 2 pages should not be visited")
+
+	fetch, _ = fakeSite(site)
+	count = 0
+	var discovered []int
+	err = New(fetch, Config{MaxPages: 2, Discovered: func(n int) { discovered = append(discovered, n) }}).Crawl(context.Background(), "https://example.com/", func(p *Page) { count++ })
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []int{2, 3, 4}, discovered, "pages found past max_pages should be counted")
+}
+
+// TestCrawlFetchErrors verifies failed pages are reported without stopping the crawl.
+func TestCrawlFetchErrors(t *testing.T) {
+	fetch, _ := fakeSite(map[string]string{
+		"https://example.com/":   "[Missing](/missing) [Ok](/ok)",
+		"https://example.com/ok": "",
+	})
+
+	var failed, ok int
+	err := New(fetch, Config{}).Crawl(context.Background(), "https://example.com/", func(p *Page) {
+		if p.Err != nil {
+			assert.Nil(t, p.Result)
+			failed++
+		} else {
+			ok++
+		}
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, 2, ok)
+}
+
+// TestCrawlCanceled verifies a canceled context stops the crawl with its error.
+func TestCrawlCanceled(t *testing.T) {
+	fetch, _ := fakeSite(map[string]string{"https://example.com/": ""})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := New(fetch, Config{}).Crawl(ctx, "https://example.com/", func(p *Page) {})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// TestExtractLinks verifies markdown links are resolved and non-http links are dropped.
+func TestExtractLinks(t *testing.T) {
+	base, _ := url.Parse("https://example.com/docs/")
+	links := ExtractLinks([]byte("[a](intro) [b](/x#frag) [c](mailto:a@b.com) [d](https://other.com)"), base, nil)
+
+	var got []string
+	for _, l := range links {
+		got = append(got, l.String())
+	}
+	assert.Equal(t, []string{
+		"https://example.com/docs/intro",
+		"https://example.com/x",
+		"https://other.com",
+	}, got)
+}
+
+// TestCrawlNormalizesURLs verifies trivially different links to a page are crawled once.
+func TestCrawlNormalizesURLs(t *testing.T) {
+	fetch, fetches := fakeSite(map[string]string{
+		"https://example.com/":          "[A](/a?utm_source=nav) [A again](https://EXAMPLE.com:443/docs/../a) [Q](/q?b=2&a=1)",
+		"https://example.com/a":         "[Q](/q?a=1&b=2&fbclid=x) [Session](/s?sid=1)",
+		"https://example.com/q?a=1&b=2": "no links",
+		"https://example.com/s":         "no links",
+	})
+
+	var visited []string
+	normalize := func(u string) string {
+		return urlnorm.Normalize(u, urlnorm.Rules{StripParams: []string{"sid"}})
+	}
+	err := New(fetch, Config{NormalizeURL: normalize}).Crawl(context.Background(), "HTTPS://example.com", func(p *Page) {
+		visited = append(visited, p.URL)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"https://example.com/",
+		"https://example.com/a",
+		"https://example.com/q?a=1&b=2",
+		"https://example.com/s",
+	}, visited)
+	for u, n := range fetches {
+		assert.Equal(t, 1, n, "each page should be fetched once: %s", u)
+	}
+}
+
+// TestCrawlRespectNofollow verifies nofollow links and pages marked nofollow are not followed.
+func TestCrawlRespectNofollow(t *testing.T) {
+	pages := map[string]string{
+		"https://example.com/":       "[A](/a) [Ad](/ad) [Closed](/closed)",
+		"https://example.com/a":      "no links",
+		"https://example.com/ad":     "no links",
+		"https://example.com/closed": "[Hidden](/hidden)",
+		"https://example.com/hidden": "no links",
+	}
+	fetch := func(ctx context.Context, pageURL string) (*Result, error) {
+		result := &Result{URL: pageURL, StatusCode: 200, Body: []byte(pages[pageURL])}
+		switch pageURL {
+		case "https://example.com/":
+			result.NofollowLinks = []string{"/ad"}
+		case "https://example.com/closed":
+			result.Nofollow = true
+		}
+		return result, nil
+	}
+
+	var visited []string
+	err := New(fetch, Config{RespectNofollow: true}).Crawl(context.Background(), "https://example.com/", func(p *Page) {
+		visited = append(visited, p.URL)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"https://example.com/", "https://example.com/a", "https://example.com/closed"}, visited)
+}
+
+// canonicalSite serves a page linking to query-string variants of a page that declare it canonical.
+func canonicalSite() FetchFunc {
+	return func(ctx context.Context, pageURL string) (*Result, error) {
+		result := &Result{URL: pageURL, StatusCode: 200}
+		switch pageURL {
+		case "https://example.com/":
+			result.Body = []byte("[A](/docs?ref=nav) [B](/docs?ref=footer)")
+		case "https://example.com/docs?ref=nav", "https://example.com/docs?ref=footer", "https://example.com/docs":
+			result.Body = []byte("[Guide](/guide)")
+			result.CanonicalURL = "https://example.com/docs"
+		default:
+			result.Body = []byte("no links")
+		}
+		return result, nil
+	}
+}
+
+// TestCrawlCanonicalSkip verifies variants are dropped in favor of crawling their canonical URL.
+func TestCrawlCanonicalSkip(t *testing.T) {
+	var visited []string
+	err := New(canonicalSite(), Config{Canonical: CanonicalSkip}).Crawl(context.Background(), "https://example.com/", func(p *Page) {
+		visited = append(visited, p.URL)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"https://example.com/", "https://example.com/docs", "https://example.com/guide"}, visited)
+}
+
+// TestCrawlCanonicalRemap verifies variants are reported once under their canonical URL.
+func TestCrawlCanonicalRemap(t *testing.T) {
+	var visited []*Page
+	err := New(canonicalSite(), Config{Canonical: CanonicalRemap}).Crawl(context.Background(), "https://example.com/", func(p *Page) {
+		visited = append(visited, p)
+	})
+	require.NoError(t, err)
+
+	require.Len(t, visited, 3)
+	assert.Equal(t, "https://example.com/docs", visited[1].URL)
+	assert.Equal(t, "https://example.com/docs?ref=nav", visited[1].RemappedFrom)
+	assert.Equal(t, "https://example.com/guide", visited[2].URL)
+}
@@ -0,0 +1,101 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"syscall"
+)
+
+const (
+	// FailureDNS means the hostname could not be resolved.
+	FailureDNS = "dns_error"
+	// FailureTLS means the TLS handshake or certificate verification failed.
+	FailureTLS = "tls_error"
+	// FailureConnRefused means the host actively refused the connection.
+	FailureConnRefused = "conn_refused"
+	// FailureReset means the connection was reset or closed mid-request.
+	FailureReset = "reset"
+	// FailureTimeout means the connection or request timed out.
+	FailureTimeout = "timeout"
+)
+
+// ClassifyError maps a network failure to one of the Failure* types. It returns an empty
+// string for errors that are not connection failures, such as HTTP status errors.
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.IsTimeout {
+			return FailureTimeout
+		}
+		return FailureDNS
+	}
+
+	if isTLSError(err) {
+		return FailureTLS
+	}
+
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return FailureConnRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE), errors.Is(err, io.ErrUnexpectedEOF):
+		return FailureReset
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return FailureTimeout
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return FailureTimeout
+	}
+
+	// A server that closes the connection before sending a response surfaces as a bare EOF.
+	if errors.Is(err, io.EOF) {
+		return FailureReset
+	}
+
+	return ""
+}
+
+// IsTransient reports whether err is a network failure likely to clear on its own, so the
+// request is worth retrying: a reset connection or a response cut short, a timeout, including a
+// TLS handshake timeout, or a temporary DNS failure. Unknown hosts, refused connections, and
+// certificate errors are not transient.
+func IsTransient(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+
+	switch ClassifyError(err) {
+	case FailureReset, FailureTimeout:
+		return true
+	}
+	return false
+}
+
+// isTLSError reports whether err came from the TLS handshake or certificate verification.
+func isTLSError(err error) bool {
+	var (
+		certVerifyErr   *tls.CertificateVerificationError
+		recordHeaderErr tls.RecordHeaderError
+		alertErr        tls.AlertError
+		unknownAuthErr  x509.UnknownAuthorityError
+		hostnameErr     x509.HostnameError
+		certInvalidErr  x509.CertificateInvalidError
+	)
+	return errors.As(err, &certVerifyErr) ||
+		errors.As(err, &recordHeaderErr) ||
+		errors.As(err, &alertErr) ||
+		errors.As(err, &unknownAuthErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &certInvalidErr)
+}
@@ -0,0 +1,101 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/joeychilson/websurfer/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClassifyErrorWrapped verifies classification sees through wrapped errors.
+func TestClassifyErrorWrapped(t *testing.T) {
+	dnsErr := &net.DNSError{Err: "no such host", Name: "missing.example", IsNotFound: true}
+	dnsTimeout := &net.DNSError{Err: "i/o timeout", Name: "slow.example", IsTimeout: true}
+
+	assert.Equal(t, FailureDNS, ClassifyError(fmt.Errorf("attempt 1 failed: %w", dnsErr)))
+	assert.Equal(t, FailureTimeout, ClassifyError(fmt.Errorf("attempt 1 failed: %w", dnsTimeout)))
+	assert.Equal(t, FailureTimeout, ClassifyError(fmt.Errorf("wrapped: %w", context.DeadlineExceeded)))
+	assert.Equal(t, "", ClassifyError(errors.New("attempt 1: HTTP 503")), "HTTP errors are not connection failures")
+	assert.Equal(t, "", ClassifyError(nil))
+}
+
+// fetchError fetches urlStr with a fresh fetcher and returns the error.
+func fetchError(t *testing.T, cfg config.FetchConfig, urlStr string) error {
+	t.Helper()
+
+	fetcher, err := New(cfg)
+	require.NoError(t, err)
+
+	_, err = fetcher.FetchWithOptions(context.Background(), urlStr, nil)
+	require.Error(t, err)
+	return err
+}
+
+// TestClassifyErrorConnRefused verifies refused connections are classified.
+func TestClassifyErrorConnRefused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	err = fetchError(t, config.FetchConfig{}, "http://"+addr+"/")
+	assert.Equal(t, FailureConnRefused, ClassifyError(err))
+}
+
+// TestClassifyErrorTLS verifies certificate failures are classified.
+func TestClassifyErrorTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	err := fetchError(t, config.FetchConfig{}, server.URL)
+	assert.Equal(t, FailureTLS, ClassifyError(err))
+}
+
+// TestClassifyErrorReset verifies connections closed without a response are classified.
+func TestClassifyErrorReset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		conn.Close()
+	}))
+	defer server.Close()
+
+	err := fetchError(t, config.FetchConfig{}, server.URL)
+	assert.Equal(t, FailureReset, ClassifyError(err))
+}
+
+// TestClassifyErrorTimeout verifies request timeouts are classified.
+func TestClassifyErrorTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	err := fetchError(t, config.FetchConfig{Timeout: 20 * time.Millisecond}, server.URL)
+	assert.Equal(t, FailureTimeout, ClassifyError(err))
+}
+
+// TestIsTransient verifies only network failures likely to clear on their own are transient.
+func TestIsTransient(t *testing.T) {
+	assert.True(t, IsTransient(fmt.Errorf("wrapped: %w", &net.DNSError{Err: "server misbehaving", IsTemporary: true})))
+	assert.True(t, IsTransient(&net.DNSError{Err: "i/o timeout", IsTimeout: true}))
+	assert.True(t, IsTransient(fmt.Errorf("read: %w", io.ErrUnexpectedEOF)))
+	assert.True(t, IsTransient(&net.OpError{Op: "read", Err: syscall.ECONNRESET}))
+	assert.True(t, IsTransient(context.DeadlineExceeded))
+
+	assert.False(t, IsTransient(&net.DNSError{Err: "no such host", IsNotFound: true}))
+	assert.False(t, IsTransient(&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}))
+	assert.False(t, IsTransient(context.Canceled))
+	assert.False(t, IsTransient(errors.New("response body too large")))
+	assert.False(t, IsTransient(nil))
+}
@@ -0,0 +1,130 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/joeychilson/websurfer/config"
+)
+
+// dialFunc matches the signature of http.Transport.DialContext.
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// newDialer creates a dialer with the configured Happy Eyeballs fallback delay and an optional
+// control function that runs against each resolved IP before connecting.
+func newDialer(cfg config.FetchConfig, control func(network, address string, c syscall.RawConn) error) *net.Dialer {
+	return &net.Dialer{
+		Timeout:       dialTimeout,
+		KeepAlive:     dialKeepAlive,
+		FallbackDelay: cfg.HappyEyeballsDelay,
+		Control:       control,
+	}
+}
+
+// newFamilyDialContext returns a dial function that applies the configured IP family preference.
+func newFamilyDialContext(dialer *net.Dialer, family string) dialFunc {
+	switch family {
+	case config.IPFamilyIPv4:
+		return forceFamily(dialer, "tcp4")
+	case config.IPFamilyIPv6:
+		return forceFamily(dialer, "tcp6")
+	case config.IPFamilyPreferIPv4:
+		return preferFamily(dialer, "tcp4", "tcp6")
+	case config.IPFamilyPreferIPv6:
+		return preferFamily(dialer, "tcp6", "tcp4")
+	default:
+		return dialer.DialContext
+	}
+}
+
+// forceFamily returns a dial function that only connects over the given network.
+func forceFamily(dialer *net.Dialer, network string) dialFunc {
+	return func(ctx context.Context, _, address string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, address)
+	}
+}
+
+// defaultFallbackDelay matches the net package's Happy Eyeballs default.
+const defaultFallbackDelay = 300 * time.Millisecond
+
+// dialResult is the outcome of a single dial attempt in a preferFamily race.
+type dialResult struct {
+	conn    net.Conn
+	err     error
+	primary bool
+}
+
+// preferFamily returns a dial function that races the preferred network against the fallback
+// network, giving the preferred one a head start of the dialer's fallback delay (Happy Eyeballs).
+// Whichever connects first wins; the fallback starts early if the preferred attempt fails.
+// A negative fallback delay disables racing, so the fallback is only tried after a failure.
+func preferFamily(dialer *net.Dialer, primary, fallback string) dialFunc {
+	return func(ctx context.Context, _, address string) (net.Conn, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		results := make(chan dialResult, 2)
+		dial := func(network string, isPrimary bool) {
+			conn, err := dialer.DialContext(ctx, network, address)
+			results <- dialResult{conn: conn, err: err, primary: isPrimary}
+		}
+
+		go dial(primary, true)
+		pending := 1
+
+		var fallbackC <-chan time.Time
+		if delay := dialer.FallbackDelay; delay >= 0 {
+			if delay == 0 {
+				delay = defaultFallbackDelay
+			}
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			fallbackC = timer.C
+		}
+
+		var primaryErr error
+		for {
+			select {
+			case <-fallbackC:
+				fallbackC = nil
+				pending++
+				go dial(fallback, false)
+
+			case res := <-results:
+				pending--
+				if res.err == nil {
+					go closeLosers(results, pending)
+					return res.conn, nil
+				}
+
+				if res.primary {
+					primaryErr = res.err
+					if fallbackC != nil || dialer.FallbackDelay < 0 {
+						fallbackC = nil
+						pending++
+						go dial(fallback, false)
+						continue
+					}
+				}
+				if pending == 0 {
+					if primaryErr == nil {
+						primaryErr = res.err
+					}
+					return nil, fmt.Errorf("dial %s failed over %s and %s: %w", address, primary, fallback, primaryErr)
+				}
+			}
+		}
+	}
+}
+
+// closeLosers closes connections from dial attempts that lost the race.
+func closeLosers(results <-chan dialResult, pending int) {
+	for range pending {
+		if res := <-results; res.conn != nil {
+			res.conn.Close()
+		}
+	}
+}
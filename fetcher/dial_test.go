@@ -0,0 +1,94 @@
+package fetcher
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/joeychilson/websurfer/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFamilyDialContextForced verifies forced families only dial matching addresses.
+func TestFamilyDialContextForced(t *testing.T) {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	addr := listener.Addr().String()
+
+	dialer := newDialer(config.FetchConfig{}, nil)
+
+	conn, err := newFamilyDialContext(dialer, config.IPFamilyIPv4)(context.Background(), "tcp", addr)
+	require.NoError(t, err)
+	conn.Close()
+
+	_, err = newFamilyDialContext(dialer, config.IPFamilyIPv6)(context.Background(), "tcp", addr)
+	assert.Error(t, err, "ipv6-only dialing should not reach an IPv4 address")
+}
+
+// TestFamilyDialContextPreferFallsBack verifies a preferred family falls back when it cannot connect.
+func TestFamilyDialContextPreferFallsBack(t *testing.T) {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	addr := listener.Addr().String()
+
+	for _, family := range []string{config.IPFamilyPreferIPv4, config.IPFamilyPreferIPv6} {
+		for _, cfg := range []config.FetchConfig{{}, {HappyEyeballsDelay: -1}} {
+			conn, err := newFamilyDialContext(newDialer(cfg, nil), family)(context.Background(), "tcp", addr)
+			require.NoError(t, err, "family %s, delay %s", family, cfg.HappyEyeballsDelay)
+			conn.Close()
+		}
+	}
+}
+
+// TestFamilyDialContextPreferBothFail verifies the preferred family's error is reported when both fail.
+func TestFamilyDialContextPreferBothFail(t *testing.T) {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	_, err = newFamilyDialContext(newDialer(config.FetchConfig{}, nil), config.IPFamilyPreferIPv4)(context.Background(), "tcp", addr)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tcp4 and tcp6")
+}
+
+// TestFetcherIPFamily verifies the fetcher honors the configured IP family.
+func TestFetcherIPFamily(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	fetcher, err := New(config.FetchConfig{IPFamily: config.IPFamilyPreferIPv6})
+	require.NoError(t, err)
+	resp, err := fetcher.FetchWithOptions(context.Background(), server.URL, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(resp.Body))
+
+	fetcher, err = New(config.FetchConfig{IPFamily: config.IPFamilyIPv6})
+	require.NoError(t, err)
+	_, err = fetcher.FetchWithOptions(context.Background(), server.URL, nil)
+	assert.Error(t, err, "ipv6-only fetcher should not reach an IPv4 test server")
+}
+
+// TestFetcherIPFamilyTransportShared verifies fetchers with the same IP family settings share a
+// transport, and fetchers with different ones do not.
+func TestFetcherIPFamilyTransportShared(t *testing.T) {
+	cfg := config.FetchConfig{IPFamily: config.IPFamilyPreferIPv4, HappyEyeballsDelay: 50 * time.Millisecond}
+
+	first, err := New(cfg)
+	require.NoError(t, err)
+	second, err := New(cfg)
+	require.NoError(t, err)
+	assert.Same(t, first.GetHTTPClient().Transport, second.GetHTTPClient().Transport)
+
+	other, err := New(config.FetchConfig{IPFamily: config.IPFamilyPreferIPv6})
+	require.NoError(t, err)
+	assert.NotSame(t, first.GetHTTPClient().Transport, other.GetHTTPClient().Transport)
+}
@@ -2,12 +2,16 @@ package fetcher
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/joeychilson/websurfer/config"
 	urlutil "github.com/joeychilson/websurfer/url"
@@ -19,11 +23,65 @@ type Response struct {
 	StatusCode int
 	Headers    http.Header
 	Body       []byte
+	// Stream holds the unread body of a successful response fetched with FetchOptions.Stream,
+	// in place of Body. Reading it fails with ErrBodyTooLarge past the max body size. The
+	// caller must close it.
+	Stream   io.ReadCloser
+	Transfer Transfer
+}
+
+// ErrBodyTooLarge is returned when a response body exceeds the maximum size.
+var ErrBodyTooLarge = errors.New("response body too large")
+
+// StatusError reports a response with an unsuccessful HTTP status code.
+type StatusError struct {
+	StatusCode int
+}
+
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("HTTP %d", e.StatusCode)
 }
 
 // FetchOptions contains optional parameters for fetch requests.
 type FetchOptions struct {
 	IfModifiedSince string
+	// Stream leaves the body of a successful response unread, in Response.Stream, for assets
+	// too large to hold in memory. Unsuccessful responses are read as usual.
+	Stream bool
+}
+
+// contextKey is a type for context keys used by the fetcher.
+type contextKey string
+
+// headersContextKey stores per-request header overrides in the context.
+const headersContextKey contextKey = "fetcher_headers"
+
+// WithHeaders adds headers to the context that override the configured headers for fetches
+// made with it.
+func WithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	return context.WithValue(ctx, headersContextKey, headers)
+}
+
+// GetHeaders retrieves the header overrides from the context, or nil if there are none.
+func GetHeaders(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(headersContextKey).(map[string]string)
+	return headers
+}
+
+// cookieJarContextKey stores the cookie jar for fetches made with the context.
+const cookieJarContextKey contextKey = "fetcher_cookie_jar"
+
+// WithCookieJar adds a cookie jar to the context. Fetches made with it send the jar's cookies
+// and store the cookies set by every response, including redirects. A nil jar disables it.
+func WithCookieJar(ctx context.Context, jar http.CookieJar) context.Context {
+	return context.WithValue(ctx, cookieJarContextKey, jar)
+}
+
+// GetCookieJar retrieves the cookie jar from the context, or nil if there is none.
+func GetCookieJar(ctx context.Context) http.CookieJar {
+	jar, _ := ctx.Value(cookieJarContextKey).(http.CookieJar)
+	return jar
 }
 
 // Fetcher fetches webpages using the provided configuration.
@@ -32,6 +90,7 @@ type Fetcher struct {
 	client           *http.Client
 	compiledRewrites []*compiledRewrite
 	literalRewrites  []config.URLRewrite
+	signer           Signer
 }
 
 // compiledRewrite holds a pre-compiled regex and its replacement.
@@ -40,11 +99,38 @@ type compiledRewrite struct {
 	replacement string
 }
 
-// ssrfProtectedTransport wraps http.DefaultTransport with SSRF protection.
+const (
+	// dialTimeout is the maximum time allowed to establish a TCP connection.
+	dialTimeout = 30 * time.Second
+	// dialKeepAlive is the keep-alive period for established connections.
+	dialKeepAlive = 30 * time.Second
+)
+
+// ssrfProtectedTransport wraps an http.Transport with SSRF protection. The destination is
+// validated both before the request and at dial time; the dial-time check runs against the IP
+// the connection is actually made to, so a hostname that re-resolves to a private address after
+// validation (DNS rebinding) cannot slip through.
 type ssrfProtectedTransport struct {
 	base http.RoundTripper
 }
 
+// SSRFDialControl rejects connections to private, loopback, or link-local addresses, for use as
+// a net.Dialer Control function.
+// It is called after DNS resolution with the literal IP:port being dialed.
+func SSRFDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid dial address %q: %w", address, err)
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("dial address is not an IP: %s", address)
+	}
+
+	return urlutil.ValidateIP(ip)
+}
+
 // RoundTrip validates that the destination IP is not private/internal before making the request.
 func (t *ssrfProtectedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	if err := urlutil.ValidateNotPrivate(req.URL.Host); err != nil {
@@ -59,9 +145,23 @@ func New(cfg config.FetchConfig) (*Fetcher, error) {
 	maxRedirects := cfg.GetMaxRedirects()
 
 	var transport http.RoundTripper = http.DefaultTransport
-	if cfg.GetEnableSSRFProtection() {
-		transport = &ssrfProtectedTransport{
-			base: http.DefaultTransport,
+	switch {
+	case cfg.SSHTunnel != nil:
+		tunnel, err := newSSHTunnel(*cfg.SSHTunnel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create ssh tunnel: %w", err)
+		}
+		transport, err = transports.load("ssh|"+tunnel.key+"|"+transportKey(cfg), func() (http.RoundTripper, error) {
+			return newTunnelTransport(tunnel, cfg)
+		})
+		if err != nil {
+			return nil, err
+		}
+	case cfg.Transport != nil || cfg.GetEnableSSRFProtection() || cfg.IPFamily != "" || cfg.HappyEyeballsDelay != 0:
+		var err error
+		transport, err = transports.get(cfg)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -75,6 +175,14 @@ func New(cfg config.FetchConfig) (*Fetcher, error) {
 			if len(via) >= maxRedirects {
 				return fmt.Errorf("stopped after %d redirects", maxRedirects)
 			}
+			// Tunneled requests skip SSRF protection, so a tunneled site must not redirect them
+			// to other hosts on the jump host's network.
+			if cfg.SSHTunnel != nil && !cfg.SSHTunnel.Allows(req.URL.String()) {
+				return fmt.Errorf("%w: redirect to %s leaves tunneled site %s", urlutil.ErrBlockedAddress, req.URL.Redacted(), cfg.SSHTunnel.SitePattern)
+			}
+			if cfg.Signing != nil && req.URL.Host != via[0].URL.Host {
+				stripSignature(req, *cfg.Signing)
+			}
 			return nil
 		},
 	}
@@ -97,11 +205,21 @@ func New(cfg config.FetchConfig) (*Fetcher, error) {
 		}
 	}
 
+	var signer Signer
+	if cfg.Signing != nil {
+		s, err := newSigner(*cfg.Signing)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request signer: %w", err)
+		}
+		signer = s
+	}
+
 	return &Fetcher{
 		config:           cfg,
 		client:           client,
 		compiledRewrites: compiledRewrites,
 		literalRewrites:  literalRewrites,
+		signer:           signer,
 	}, nil
 }
 
@@ -127,7 +245,7 @@ func (f *Fetcher) FetchWithOptions(ctx context.Context, urlStr string, opts *Fet
 		}
 
 		lastResp = resp
-		lastErr = fmt.Errorf("HTTP %d", resp.StatusCode)
+		lastErr = &StatusError{StatusCode: resp.StatusCode}
 	}
 
 	if lastResp != nil {
@@ -148,69 +266,127 @@ func (f *Fetcher) GetHTTPClient() *http.Client {
 
 // fetchURL performs the actual HTTP request for a single URL.
 func (f *Fetcher) fetchURL(ctx context.Context, urlStr string, opts *FetchOptions) (*Response, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlStr, nil)
+	trace := &transferTrace{}
+	req, err := f.newRequest(withTransferTrace(ctx, trace), http.MethodGet, urlStr, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	for key, value := range f.config.GetHeaders() {
-		req.Header.Set(key, value)
+		return nil, err
 	}
 
-	if opts != nil && opts.IfModifiedSince != "" {
-		req.Header.Set("If-Modified-Since", opts.IfModifiedSince)
+	client := f.client
+	if jar := GetCookieJar(ctx); jar != nil {
+		withJar := *f.client
+		withJar.Jar = jar
+		client = &withJar
 	}
 
-	resp, err := f.client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
-	defer resp.Body.Close()
 
+	// The limit applies to the decoded body, so a small compressed response cannot expand past
+	// it.
 	maxBodySize := f.config.GetMaxBodySize()
-	if maxBodySize > 0 {
-		body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
-		if err != nil {
-			return nil, fmt.Errorf("failed to read response body: %w", err)
-		}
 
-		if int64(len(body)) >= maxBodySize {
-			return nil, fmt.Errorf("response body exceeds maximum size of %d bytes", maxBodySize)
-		}
+	transfer := newTransfer(resp, trace)
+	received := &countingReader{r: resp.Body}
+	decoder, decoded, err := decodeBody(transfer.ContentEncoding, received, maxBodySize)
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to decode %s response body: %w", transfer.ContentEncoding, err)
+	}
+	var reader io.Reader = decoder
+	if decoded && transfer.ContentEncoding != "" {
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+	}
 
+	if opts != nil && opts.Stream && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if maxBodySize > 0 && transfer.ContentEncoding == "" && resp.ContentLength > maxBodySize {
+			decoder.Close()
+			resp.Body.Close()
+			return nil, fmt.Errorf("%w: %d bytes exceeds maximum size of %d bytes", ErrBodyTooLarge, resp.ContentLength, maxBodySize)
+		}
 		return &Response{
 			URL:        resp.Request.URL.String(),
 			StatusCode: resp.StatusCode,
 			Headers:    resp.Header,
-			Body:       body,
+			Stream:     LimitBody(&streamedBody{Reader: decoder, decoder: decoder, body: resp.Body}, maxBodySize),
+			Transfer:   transfer,
 		}, nil
 	}
+	defer resp.Body.Close()
+	defer decoder.Close()
+
+	if maxBodySize > 0 {
+		reader = io.LimitReader(reader, maxBodySize+1)
+	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := io.ReadAll(reader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
+	if maxBodySize > 0 && int64(len(body)) >= maxBodySize {
+		return nil, fmt.Errorf("%w: exceeds maximum size of %d bytes", ErrBodyTooLarge, maxBodySize)
+	}
+
+	transfer.CompressedSize = received.n
+	transfer.DecompressedSize = int64(len(body))
+
 	return &Response{
 		URL:        resp.Request.URL.String(),
 		StatusCode: resp.StatusCode,
 		Headers:    resp.Header,
 		Body:       body,
+		Transfer:   transfer,
 	}, nil
 }
 
-// buildURLsToTry creates a list of URLs to attempt based on CheckFormats.
+// newRequest creates a request for urlStr with the configured and context headers, signed
+// when the site requires it.
+func (f *Fetcher) newRequest(ctx context.Context, method, urlStr string, opts *FetchOptions) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for key, value := range f.config.GetHeaders() {
+		req.Header.Set(key, value)
+	}
+	for key, value := range GetHeaders(ctx) {
+		req.Header.Set(key, value)
+	}
+
+	if opts != nil && opts.IfModifiedSince != "" {
+		req.Header.Set("If-Modified-Since", opts.IfModifiedSince)
+	}
+
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	if f.signer != nil {
+		if err := f.signer.Sign(req); err != nil {
+			return nil, fmt.Errorf("failed to sign request: %w", err)
+		}
+	}
+	return req, nil
+}
+
+// buildURLsToTry creates a list of URLs to attempt: the READMEs of a code repository URL,
+// then the formats of CheckFormats, then urlStr itself.
 func (f *Fetcher) buildURLsToTry(urlStr string) []string {
+	urls := urlutil.Readmes(urlStr)
 	if len(f.config.CheckFormats) == 0 {
-		return []string{urlStr}
+		return append(urls, urlStr)
 	}
 
 	parsedURL, err := url.Parse(urlStr)
 	if err != nil {
-		return []string{urlStr}
+		return append(urls, urlStr)
 	}
 
-	urls := []string{}
 	for _, format := range f.config.CheckFormats {
 		tryURL := f.applyFormat(parsedURL, format)
 		if tryURL != urlStr {
@@ -2,6 +2,7 @@ package fetcher
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -169,6 +170,35 @@ func TestFetcherCustomHeaders(t *testing.T) {
 	assert.Equal(t, "custom-value", receivedCustom)
 }
 
+// TestFetcherUserAgentPresets verifies preset names are sent as their user agent strings and rotation stays within the list.
+func TestFetcherUserAgentPresets(t *testing.T) {
+	received := make(map[string]bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received[r.Header.Get("User-Agent")] = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	fetcher, err := New(config.FetchConfig{UserAgent: config.UserAgentPresetCurl})
+	require.NoError(t, err)
+	_, err = fetcher.FetchWithOptions(context.Background(), server.URL, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{config.ResolveUserAgent(config.UserAgentPresetCurl): true}, received)
+
+	clear(received)
+	rotation := config.FetchConfig{UserAgents: []string{config.UserAgentPresetBrowserChrome, "CustomBot/2.0"}}
+	fetcher, err = New(rotation)
+	require.NoError(t, err)
+	for range 20 {
+		_, err = fetcher.FetchWithOptions(context.Background(), server.URL, nil)
+		require.NoError(t, err)
+	}
+	for ua := range received {
+		assert.Contains(t, []string{config.ResolveUserAgent(config.UserAgentPresetBrowserChrome), "CustomBot/2.0"}, ua)
+	}
+	assert.Equal(t, config.ResolveUserAgent(config.UserAgentPresetBrowserChrome), rotation.GetRobotsUserAgent())
+}
+
 // TestFetcherMaxBodySize verifies body size limit is enforced.
 func TestFetcherMaxBodySize(t *testing.T) {
 	largeBody := strings.Repeat("a", 2000)
@@ -189,6 +219,49 @@ func TestFetcherMaxBodySize(t *testing.T) {
 	assert.Contains(t, err.Error(), "exceeds maximum size", "error should mention size limit")
 }
 
+// TestFetcherStream verifies streamed bodies are left unread, fail once read past the max body
+// size, and are rejected up front when the origin declares a larger size.
+func TestFetcherStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/chunked":
+			// Flushing before writing the body sends it without a Content-Length.
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			w.Write([]byte(strings.Repeat("a", 2000)))
+		case "/sized":
+			w.Write([]byte(strings.Repeat("a", 2000)))
+		default:
+			w.Write([]byte(strings.Repeat("a", 500)))
+		}
+	}))
+	defer server.Close()
+
+	fetcher, err := New(config.FetchConfig{MaxBodySize: 1000})
+	require.NoError(t, err)
+	ctx := context.Background()
+	opts := &FetchOptions{Stream: true}
+
+	resp, err := fetcher.FetchWithOptions(ctx, server.URL+"/small", opts)
+	require.NoError(t, err)
+	require.NotNil(t, resp.Stream)
+	assert.Empty(t, resp.Body)
+	body, err := io.ReadAll(resp.Stream)
+	require.NoError(t, err)
+	assert.Len(t, body, 500)
+	require.NoError(t, resp.Stream.Close())
+
+	resp, err = fetcher.FetchWithOptions(ctx, server.URL+"/chunked", opts)
+	require.NoError(t, err)
+	body, err = io.ReadAll(resp.Stream)
+	assert.ErrorIs(t, err, ErrBodyTooLarge)
+	assert.Len(t, body, 1000, "the body should be read up to the limit")
+	resp.Stream.Close()
+
+	_, err = fetcher.FetchWithOptions(ctx, server.URL+"/sized", opts)
+	assert.ErrorIs(t, err, ErrBodyTooLarge)
+}
+
 // TestFetcherTimeout verifies timeout is enforced.
 func TestFetcherTimeout(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -330,6 +403,22 @@ func TestFetcherAlternativeFormats(t *testing.T) {
 	assert.Contains(t, resp.URL, "/page.md", "should have tried .md format")
 }
 
+// TestFetcherRepositoryReadmes verifies the READMEs of a repository URL are tried before the
+// repository page itself.
+func TestFetcherRepositoryReadmes(t *testing.T) {
+	fetcher, err := New(config.FetchConfig{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"https://raw.githubusercontent.com/golang/go/HEAD/README.md",
+		"https://raw.githubusercontent.com/golang/go/HEAD/README.rst",
+		"https://raw.githubusercontent.com/golang/go/HEAD/README",
+		"https://raw.githubusercontent.com/golang/go/HEAD/readme.md",
+		"https://github.com/golang/go",
+	}, fetcher.buildURLsToTry("https://github.com/golang/go"))
+	assert.Equal(t, []string{"https://example.com/page"}, fetcher.buildURLsToTry("https://example.com/page"))
+}
+
 // TestFetcherSSRFProtection verifies SSRF protection blocks private IPs.
 func TestFetcherSSRFProtection(t *testing.T) {
 	enableSSRF := true
@@ -348,6 +437,52 @@ func TestFetcherSSRFProtection(t *testing.T) {
 	assert.Error(t, err, "should block private IP")
 }
 
+// TestFetcherSSRFDialTimeValidation verifies the dialer rejects private IPs even when the pre-request check is bypassed.
+func TestFetcherSSRFDialTimeValidation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	enableSSRF := true
+	tuned, err := newTunedTransport(config.FetchConfig{EnableSSRFProtection: &enableSSRF})
+	require.NoError(t, err)
+	transport, ok := tuned.(*ssrfProtectedTransport)
+	require.True(t, ok)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	// Call the underlying transport directly to simulate a hostname that passed
+	// validation but re-resolved to a loopback address at connect time.
+	_, err = transport.base.RoundTrip(req)
+	assert.Error(t, err, "dialer should block loopback address")
+	assert.Contains(t, err.Error(), "private", "error should mention private IP")
+}
+
+// TestFetcherSSRFTransportShared verifies fetchers with SSRF protection share one transport, so
+// fetchers created per request still reuse connections.
+func TestFetcherSSRFTransportShared(t *testing.T) {
+	enableSSRF := true
+	cfg := config.FetchConfig{EnableSSRFProtection: &enableSSRF}
+
+	first, err := New(cfg)
+	require.NoError(t, err)
+	second, err := New(cfg)
+	require.NoError(t, err)
+	assert.Same(t, first.GetHTTPClient().Transport, second.GetHTTPClient().Transport)
+	assert.IsType(t, &ssrfProtectedTransport{}, first.GetHTTPClient().Transport)
+}
+
+// TestSSRFDialControl verifies dial-time validation of resolved addresses.
+func TestSSRFDialControl(t *testing.T) {
+	assert.NoError(t, SSRFDialControl("tcp4", "93.184.216.34:443", nil))
+	assert.Error(t, SSRFDialControl("tcp4", "127.0.0.1:80", nil))
+	assert.Error(t, SSRFDialControl("tcp4", "169.254.169.254:80", nil))
+	assert.Error(t, SSRFDialControl("tcp6", "[::1]:443", nil))
+	assert.Error(t, SSRFDialControl("tcp", "not-an-address", nil))
+}
+
 // TestFetcherSSRFProtectionDisabled verifies SSRF protection can be disabled.
 func TestFetcherSSRFProtectionDisabled(t *testing.T) {
 	enableSSRF := false
@@ -0,0 +1,85 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// ProbeResult describes the response to a probe, up to its headers.
+type ProbeResult struct {
+	// URL is the URL that responded, after redirects.
+	URL string
+	// Method is the request method that produced the response: HEAD, or GET when the server
+	// does not support HEAD.
+	Method     string
+	StatusCode int
+	Headers    http.Header
+	// FirstByte is the time from starting the request, including connecting, to receiving the
+	// first byte of the response.
+	FirstByte time.Duration
+	Transfer  Transfer
+}
+
+// Probe requests urlStr with HEAD, falling back to GET when the server rejects HEAD, and
+// returns the response without reading its body. It makes a single request per method, with
+// no retries and no alternate formats.
+func (f *Fetcher) Probe(ctx context.Context, urlStr string) (*ProbeResult, error) {
+	urlStr = f.applyRewrites(urlStr)
+
+	result, err := f.probe(ctx, http.MethodHead, urlStr)
+	if err != nil {
+		return nil, err
+	}
+	if result.StatusCode == http.StatusMethodNotAllowed || result.StatusCode == http.StatusNotImplemented {
+		return f.probe(ctx, http.MethodGet, urlStr)
+	}
+	return result, nil
+}
+
+// probe sends a single request for urlStr and closes the response as soon as its headers arrive.
+func (f *Fetcher) probe(ctx context.Context, method, urlStr string) (*ProbeResult, error) {
+	trace := &transferTrace{}
+	var (
+		mu        sync.Mutex
+		firstByte time.Time
+	)
+	ctx = httptrace.WithClientTrace(withTransferTrace(ctx, trace), &httptrace.ClientTrace{
+		GotFirstResponseByte: func() {
+			mu.Lock()
+			firstByte = time.Now()
+			mu.Unlock()
+		},
+	})
+
+	req, err := f.newRequest(ctx, method, urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	resp.Body.Close()
+
+	mu.Lock()
+	elapsed := firstByte.Sub(start)
+	mu.Unlock()
+	if elapsed < 0 {
+		elapsed = time.Since(start)
+	}
+
+	return &ProbeResult{
+		URL:        resp.Request.URL.String(),
+		Method:     method,
+		StatusCode: resp.StatusCode,
+		Headers:    resp.Header,
+		FirstByte:  elapsed,
+		Transfer:   newTransfer(resp, trace),
+	}, nil
+}
@@ -0,0 +1,56 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/joeychilson/websurfer/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProbe verifies probes send HEAD and return the response headers without a body.
+func TestProbe(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		assert.Equal(t, "probe-test", r.Header.Get("X-Test"))
+		w.Header().Set("Server", "test-server")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	fetcher, err := New(config.FetchConfig{Headers: map[string]string{"X-Test": "probe-test"}})
+	require.NoError(t, err)
+
+	result, err := fetcher.Probe(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, []string{http.MethodHead}, methods)
+	assert.Equal(t, http.MethodHead, result.Method)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, "test-server", result.Headers.Get("Server"))
+	assert.Equal(t, "127.0.0.1", result.Transfer.RemoteIP)
+	assert.Positive(t, result.FirstByte)
+}
+
+// TestProbeFallsBackToGet verifies servers rejecting HEAD are probed with GET.
+func TestProbeFallsBackToGet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	fetcher, err := New(config.FetchConfig{})
+	require.NoError(t, err)
+
+	result, err := fetcher.Probe(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodGet, result.Method)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+}
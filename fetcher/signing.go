@@ -0,0 +1,270 @@
+package fetcher
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joeychilson/websurfer/config"
+)
+
+const (
+	// emptyPayloadHash is the SHA-256 hash of an empty request body.
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	// sigV4Algorithm is the AWS Signature Version 4 algorithm identifier.
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+)
+
+// Signer signs outbound requests before they are sent.
+type Signer interface {
+	Sign(req *http.Request) error
+}
+
+// SignerFactory creates a Signer from a signing configuration.
+type SignerFactory func(cfg config.SigningConfig) (Signer, error)
+
+var (
+	signersMu sync.RWMutex
+	signers   = map[string]SignerFactory{
+		"hmac":      newHMACSigner,
+		"aws_sigv4": newSigV4Signer,
+	}
+)
+
+// RegisterSigner registers a custom signer type that can be referenced from site configs.
+func RegisterSigner(signerType string, factory SignerFactory) {
+	signersMu.Lock()
+	defer signersMu.Unlock()
+	signers[signerType] = factory
+}
+
+// newSigner creates the signer configured for a site.
+func newSigner(cfg config.SigningConfig) (Signer, error) {
+	signersMu.RLock()
+	factory, exists := signers[cfg.Type]
+	signersMu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("unknown signing type %q", cfg.Type)
+	}
+
+	return factory(cfg)
+}
+
+// stripSignature removes the headers the configured built-in signer sets from req, so a
+// redirect to another host does not carry a signature or session token meant for the signed
+// host. Authorization goes too, as the client keeps it across ports of the same host.
+func stripSignature(req *http.Request, cfg config.SigningConfig) {
+	req.Header.Del("Authorization")
+
+	header := cfg.Header
+	if header == "" {
+		header = "X-Signature"
+	}
+	req.Header.Del(header)
+	req.Header.Del(header + "-Timestamp")
+
+	for key := range req.Header {
+		if strings.HasPrefix(strings.ToLower(key), "x-amz-") {
+			req.Header.Del(key)
+		}
+	}
+}
+
+// hmacSigner adds an HMAC-SHA256 signature over the method, request URI, and timestamp.
+type hmacSigner struct {
+	secret []byte
+	header string
+	now    func() time.Time
+}
+
+// newHMACSigner creates an HMAC signer reading its secret from the configured environment variable.
+func newHMACSigner(cfg config.SigningConfig) (Signer, error) {
+	secret := os.Getenv(cfg.SecretEnv)
+	if secret == "" {
+		return nil, fmt.Errorf("hmac signing: environment variable %q is not set", cfg.SecretEnv)
+	}
+
+	header := cfg.Header
+	if header == "" {
+		header = "X-Signature"
+	}
+
+	return &hmacSigner{
+		secret: []byte(secret),
+		header: header,
+		now:    time.Now,
+	}, nil
+}
+
+// Sign sets the timestamp and signature headers on the request.
+func (s *hmacSigner) Sign(req *http.Request) error {
+	timestamp := s.now().UTC().Format(time.RFC3339)
+	payload := req.Method + "\n" + req.URL.RequestURI() + "\n" + timestamp
+
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+
+	req.Header.Set(s.header+"-Timestamp", timestamp)
+	req.Header.Set(s.header, hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
+
+// sigV4Signer signs requests with AWS Signature Version 4.
+type sigV4Signer struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	region          string
+	service         string
+	now             func() time.Time
+}
+
+// newSigV4Signer creates an AWS SigV4 signer reading credentials from the environment.
+func newSigV4Signer(cfg config.SigningConfig) (Signer, error) {
+	accessKeyID := os.Getenv(cfg.GetAccessKeyIDEnv())
+	secretAccessKey := os.Getenv(cfg.GetSecretAccessKeyEnv())
+	if accessKeyID == "" || secretAccessKey == "" {
+		return nil, fmt.Errorf("aws_sigv4 signing: credentials not found in %s/%s", cfg.GetAccessKeyIDEnv(), cfg.GetSecretAccessKeyEnv())
+	}
+
+	return NewSigV4Signer(accessKeyID, secretAccessKey, os.Getenv(cfg.GetSessionTokenEnv()), cfg.Region, cfg.GetService()), nil
+}
+
+// NewSigV4Signer creates an AWS SigV4 signer with the given credentials, for signing requests
+// outside of site configs. Requests with a body should set X-Amz-Content-Sha256 to the body's
+// hex SHA-256 hash before signing; otherwise an empty body is assumed.
+func NewSigV4Signer(accessKeyID, secretAccessKey, sessionToken, region, service string) Signer {
+	return &sigV4Signer{
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		sessionToken:    sessionToken,
+		region:          region,
+		service:         service,
+		now:             time.Now,
+	}
+}
+
+// Sign adds the SigV4 Authorization header and supporting x-amz-* headers to the request.
+func (s *sigV4Signer) Sign(req *http.Request) error {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+
+	payloadHash := req.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash == "" {
+		payloadHash = emptyPayloadHash
+	}
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	canonicalHeaders, signedHeaders := sigV4CanonicalHeaders(req)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		sigV4CanonicalURI(req.URL),
+		sigV4CanonicalQuery(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := dateStamp + "/" + s.region + "/" + s.service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := sigV4Algorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), dateStamp)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.accessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+// sigV4CanonicalURI returns the URI-encoded path, defaulting to "/".
+func sigV4CanonicalURI(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// sigV4CanonicalQuery returns the query string sorted by key with RFC 3986 encoding.
+func sigV4CanonicalQuery(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, sigV4Escape(key)+"="+sigV4Escape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// sigV4Escape percent-encodes a string per RFC 3986, as SigV4 requires.
+func sigV4Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// sigV4CanonicalHeaders returns the canonical header block and the signed header list.
+// Host, Content-Type, and all x-amz-* headers are signed.
+func sigV4CanonicalHeaders(req *http.Request) (string, string) {
+	headers := map[string]string{
+		"host": req.URL.Host,
+	}
+	if req.Host != "" {
+		headers["host"] = req.Host
+	}
+	for key, values := range req.Header {
+		lower := strings.ToLower(key)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + headers[name] + "\n")
+	}
+
+	return canonical.String(), strings.Join(names, ";")
+}
+
+// hmacSHA256 computes an HMAC-SHA256 of data with key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,201 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joeychilson/websurfer/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSigV4SignerKnownVector verifies SigV4 signing against the AWS documentation example.
+func TestSigV4SignerKnownVector(t *testing.T) {
+	signer := &sigV4Signer{
+		accessKeyID:     "AKIDEXAMPLE",
+		secretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		region:          "us-east-1",
+		service:         "iam",
+		now: func() time.Time {
+			return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+		},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	require.NoError(t, signer.Sign(req))
+
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t,
+		"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, "+
+			"SignedHeaders=content-type;host;x-amz-date, "+
+			"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		req.Header.Get("Authorization"))
+}
+
+// TestSigV4SignerS3Headers verifies S3 requests include the payload hash and session token.
+func TestSigV4SignerS3Headers(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "token")
+
+	signer, err := newSigner(config.SigningConfig{Type: "aws_sigv4", Region: "us-west-2"})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "https://bucket.s3.amazonaws.com/key.txt", nil)
+	require.NoError(t, err)
+	require.NoError(t, signer.Sign(req))
+
+	assert.Equal(t, emptyPayloadHash, req.Header.Get("X-Amz-Content-Sha256"))
+	assert.Equal(t, "token", req.Header.Get("X-Amz-Security-Token"))
+	assert.Contains(t, req.Header.Get("Authorization"), "/us-west-2/s3/aws4_request")
+	assert.Contains(t, req.Header.Get("Authorization"), "SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token")
+}
+
+// TestSigV4SignerPayloadHash verifies a preset payload hash is signed in place of the empty one.
+func TestSigV4SignerPayloadHash(t *testing.T) {
+	signer := NewSigV4Signer("AKIDEXAMPLE", "secret", "", "us-east-1", "s3")
+
+	sign := func(payloadHash string) *http.Request {
+		req, err := http.NewRequest(http.MethodPut, "https://s3.amazonaws.com/bucket/key.pdf", nil)
+		require.NoError(t, err)
+		if payloadHash != "" {
+			req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+		}
+		require.NoError(t, signer.Sign(req))
+		return req
+	}
+
+	hash := sha256.Sum256([]byte("%PDF-1.7"))
+	withBody := sign(hex.EncodeToString(hash[:]))
+	empty := sign("")
+
+	assert.Equal(t, hex.EncodeToString(hash[:]), withBody.Header.Get("X-Amz-Content-Sha256"))
+	assert.Equal(t, emptyPayloadHash, empty.Header.Get("X-Amz-Content-Sha256"))
+	assert.NotEqual(t, empty.Header.Get("Authorization"), withBody.Header.Get("Authorization"))
+}
+
+// TestFetcherHMACSigning verifies fetches are signed with an HMAC header when configured.
+func TestFetcherHMACSigning(t *testing.T) {
+	t.Setenv("TEST_SIGNING_SECRET", "topsecret")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timestamp := r.Header.Get("X-Signature-Timestamp")
+		mac := hmac.New(sha256.New, []byte("topsecret"))
+		mac.Write([]byte(r.Method + "\n" + r.URL.RequestURI() + "\n" + timestamp))
+
+		if r.Header.Get("X-Signature") != hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	f, err := New(config.FetchConfig{
+		Signing: &config.SigningConfig{Type: "hmac", SecretEnv: "TEST_SIGNING_SECRET"},
+	})
+	require.NoError(t, err)
+
+	resp, err := f.FetchWithOptions(context.Background(), server.URL+"/data?x=1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+// TestFetcherSigningRedirect verifies signing headers are dropped when a signed fetch is
+// redirected to another host.
+func TestFetcherSigningRedirect(t *testing.T) {
+	t.Setenv("TEST_SIGNING_SECRET", "topsecret")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+
+	tests := []struct {
+		name    string
+		signing config.SigningConfig
+	}{
+		{"hmac", config.SigningConfig{Type: "hmac", SecretEnv: "TEST_SIGNING_SECRET"}},
+		{"aws_sigv4", config.SigningConfig{Type: "aws_sigv4", Region: "us-east-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received http.Header
+			target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r.Header.Clone()
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer target.Close()
+
+			var signed http.Header
+			origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				signed = r.Header.Clone()
+				http.Redirect(w, r, target.URL+"/moved", http.StatusFound)
+			}))
+			defer origin.Close()
+
+			f, err := New(config.FetchConfig{Signing: &tt.signing, MaxRedirects: 2})
+			require.NoError(t, err)
+
+			_, err = f.FetchWithOptions(context.Background(), origin.URL, nil)
+			require.NoError(t, err)
+
+			require.NotNil(t, received)
+			assert.Empty(t, received.Get("Authorization"))
+			for key := range signed {
+				if strings.HasPrefix(key, "X-Signature") || strings.HasPrefix(key, "X-Amz-") {
+					assert.Empty(t, received.Get(key), key)
+				}
+			}
+			assert.NotEmpty(t, signed.Get("X-Signature")+signed.Get("X-Amz-Security-Token"))
+		})
+	}
+}
+
+// TestFetcherSigningMissingSecret verifies fetcher creation fails when the secret is unavailable.
+func TestFetcherSigningMissingSecret(t *testing.T) {
+	_, err := New(config.FetchConfig{
+		Signing: &config.SigningConfig{Type: "hmac", SecretEnv: "TEST_SIGNING_SECRET_UNSET"},
+	})
+	assert.Error(t, err)
+}
+
+// customSigner is a test signer registered through RegisterSigner.
+type customSigner struct{}
+
+// Sign sets a fixed header.
+func (customSigner) Sign(req *http.Request) error {
+	req.Header.Set("X-Custom-Signed", "yes")
+	return nil
+}
+
+// TestRegisterSigner verifies custom signer types can be plugged in.
+func TestRegisterSigner(t *testing.T) {
+	RegisterSigner("test_custom", func(cfg config.SigningConfig) (Signer, error) {
+		return customSigner{}, nil
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "yes", r.Header.Get("X-Custom-Signed"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	f, err := New(config.FetchConfig{Signing: &config.SigningConfig{Type: "test_custom"}})
+	require.NoError(t, err)
+
+	_, err = f.FetchWithOptions(context.Background(), server.URL, nil)
+	require.NoError(t, err)
+
+	_, err = New(config.FetchConfig{Signing: &config.SigningConfig{Type: "unknown"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,62 @@
+package fetcher
+
+import (
+	"fmt"
+	"io"
+)
+
+// limitedBody reads a body, failing with ErrBodyTooLarge once more than max bytes are read.
+type limitedBody struct {
+	r      io.Reader
+	closer io.Closer
+	max    int64
+	n      int64
+}
+
+// LimitBody returns a reader of body that fails with ErrBodyTooLarge once more than max bytes
+// are read from it, without reading past max+1 bytes. A max of 0 or less reads body whole.
+// Closing it closes body.
+func LimitBody(body io.ReadCloser, max int64) io.ReadCloser {
+	return &limitedBody{r: body, closer: body, max: max}
+}
+
+// Read implements io.Reader.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.max > 0 {
+		if b.n > b.max {
+			return 0, b.tooLarge()
+		}
+		p = p[:min(int64(len(p)), b.max-b.n+1)]
+	}
+
+	n, err := b.r.Read(p)
+	b.n += int64(n)
+	if b.max > 0 && b.n > b.max {
+		return n - int(b.n-b.max), b.tooLarge()
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (b *limitedBody) Close() error {
+	return b.closer.Close()
+}
+
+// tooLarge returns the error of a body exceeding the limit.
+func (b *limitedBody) tooLarge() error {
+	return fmt.Errorf("%w: exceeds maximum size of %d bytes", ErrBodyTooLarge, b.max)
+}
+
+// streamedBody reads a response body through its content decoders, and releases the decoders
+// and the response body on Close.
+type streamedBody struct {
+	io.Reader
+	decoder io.Closer
+	body    io.Closer
+}
+
+// Close implements io.Closer.
+func (s *streamedBody) Close() error {
+	s.decoder.Close()
+	return s.body.Close()
+}
@@ -0,0 +1,204 @@
+package fetcher
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// acceptEncoding is the Accept-Encoding sent when the request does not set one. The fetcher
+// decodes these itself, rather than leaving it to the transport, so it can measure the
+// compressed size.
+const acceptEncoding = "gzip, deflate, br, zstd"
+
+// Transfer describes how a response was transferred.
+type Transfer struct {
+	// Protocol is the HTTP version, such as "HTTP/1.1" or "HTTP/2.0".
+	Protocol string
+	// TLSVersion is the TLS version, such as "TLS 1.3", or empty for plain HTTP.
+	TLSVersion string
+	// RemoteIP is the address the response was received from.
+	RemoteIP string
+	// ContentEncoding is the response's Content-Encoding, such as "gzip", if any.
+	ContentEncoding string
+	// CompressedSize is the size of the body as received, and DecompressedSize its size after
+	// decoding the content encoding. They are equal for unencoded bodies.
+	CompressedSize   int64
+	DecompressedSize int64
+}
+
+// transferTrace records the remote address of the connection a request is sent on.
+type transferTrace struct {
+	mu         sync.Mutex
+	remoteAddr string
+}
+
+// withTransferTrace returns a context that records connections made for a request in trace.
+func withTransferTrace(ctx context.Context, trace *transferTrace) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Conn == nil || info.Conn.RemoteAddr() == nil {
+				return
+			}
+			trace.mu.Lock()
+			trace.remoteAddr = info.Conn.RemoteAddr().String()
+			trace.mu.Unlock()
+		},
+	})
+}
+
+// remoteIP returns the IP of the last connection used, without its port.
+func (t *transferTrace) remoteIP() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if host, _, err := net.SplitHostPort(t.remoteAddr); err == nil {
+		return host
+	}
+	return t.remoteAddr
+}
+
+// newTransfer describes the transfer of resp.
+func newTransfer(resp *http.Response, trace *transferTrace) Transfer {
+	transfer := Transfer{
+		Protocol:        resp.Proto,
+		RemoteIP:        trace.remoteIP(),
+		ContentEncoding: strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))),
+	}
+	if resp.TLS != nil {
+		transfer.TLSVersion = tls.VersionName(resp.TLS.Version)
+	}
+	return transfer
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read implements io.Reader.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// decodedBody reads a body through its content decoders and releases them on Close.
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close releases the decoders.
+func (d *decodedBody) Close() error {
+	for _, c := range d.closers {
+		c.Close()
+	}
+	return nil
+}
+
+// decodeBody returns a reader that decodes body from the given content encoding, which may list
+// several codings in the order they were applied, such as "gzip, br". Encodings the fetcher
+// cannot decode are returned as is, and reported by the second result being false. Decoders
+// that allocate by what the body declares are bounded by maxSize, the max body size, unless it
+// is 0. The caller must close the returned reader.
+func decodeBody(encoding string, body io.Reader, maxSize int64) (io.ReadCloser, bool, error) {
+	var codings []string
+	for coding := range strings.SplitSeq(encoding, ",") {
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		switch coding {
+		case "", "identity":
+		case "gzip", "x-gzip", "deflate", "br", "zstd":
+			codings = append(codings, coding)
+		default:
+			return io.NopCloser(body), false, nil
+		}
+	}
+
+	// Servers send empty bodies with an encoding header, which the decoders would reject.
+	br := bufio.NewReader(body)
+	if _, err := br.Peek(1); errors.Is(err, io.EOF) || len(codings) == 0 {
+		return io.NopCloser(br), true, nil
+	}
+
+	d := &decodedBody{Reader: br}
+	for _, coding := range slices.Backward(codings) {
+		r, err := newDecoder(coding, d.Reader, maxSize)
+		if err != nil {
+			d.Close()
+			return nil, false, err
+		}
+		if c, ok := r.(io.Closer); ok {
+			d.closers = append(d.closers, c)
+		}
+		d.Reader = r
+	}
+	return d, true, nil
+}
+
+// zstdBody reads a zstd body, reporting frames that exceed the decoder's limits as
+// ErrBodyTooLarge.
+type zstdBody struct {
+	io.ReadCloser
+	maxSize int64
+}
+
+// Read implements io.Reader.
+func (z *zstdBody) Read(p []byte) (int, error) {
+	n, err := z.ReadCloser.Read(p)
+	if errors.Is(err, zstd.ErrWindowSizeExceeded) || errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+		err = fmt.Errorf("%w: zstd frame needs more than %d bytes to decode: %w", ErrBodyTooLarge, z.maxSize, err)
+	}
+	return n, err
+}
+
+// newDecoder returns a reader that decodes r from a single content coding, bounded by maxSize
+// as decodeBody describes.
+func newDecoder(coding string, r io.Reader, maxSize int64) (io.Reader, error) {
+	switch coding {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		br := bufio.NewReader(r)
+		header, _ := br.Peek(2)
+		// Servers send either zlib-wrapped (as the spec requires) or raw deflate data.
+		if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	case "br":
+		return brotli.NewReader(r), nil
+	case "zstd":
+		// A single decoder decodes synchronously rather than in background goroutines. Frames
+		// declare the window the decoder allocates, so it is capped at the max body size to
+		// keep a small hostile body from claiming the default of hundreds of megabytes.
+		opts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+		if maxSize > 0 {
+			limit := uint64(max(maxSize, zstd.MinWindowSize))
+			opts = append(opts, zstd.WithDecoderMaxWindow(limit), zstd.WithDecoderMaxMemory(limit))
+		}
+		zr, err := zstd.NewReader(r, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &zstdBody{ReadCloser: zr.IOReadCloser(), maxSize: maxSize}, nil
+	default:
+		return nil, fmt.Errorf("unsupported content coding %q", coding)
+	}
+}
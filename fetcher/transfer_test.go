@@ -0,0 +1,224 @@
+package fetcher
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/joeychilson/websurfer/config"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFetcherTransferCompressed verifies compressed bodies are decoded and their sizes reported.
+func TestFetcherTransferCompressed(t *testing.T) {
+	body := strings.Repeat("Hello, World! ", 100)
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, err := gz.Write([]byte(body))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	var acceptEncodingHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncodingHeader = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+
+	fetcher, err := New(config.FetchConfig{})
+	require.NoError(t, err)
+
+	resp, err := fetcher.FetchWithOptions(context.Background(), server.URL, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "gzip, deflate, br, zstd", acceptEncodingHeader)
+	assert.Equal(t, body, string(resp.Body))
+	assert.Empty(t, resp.Headers.Get("Content-Encoding"), "decoded body should not keep its encoding header")
+	assert.Equal(t, "HTTP/1.1", resp.Transfer.Protocol)
+	assert.Empty(t, resp.Transfer.TLSVersion)
+	assert.Equal(t, "127.0.0.1", resp.Transfer.RemoteIP)
+	assert.Equal(t, "gzip", resp.Transfer.ContentEncoding)
+	assert.Equal(t, int64(compressed.Len()), resp.Transfer.CompressedSize)
+	assert.Equal(t, int64(len(body)), resp.Transfer.DecompressedSize)
+}
+
+// TestFetcherTransferUncompressed verifies unencoded bodies report equal sizes.
+func TestFetcherTransferUncompressed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Hello, World!"))
+	}))
+	defer server.Close()
+
+	fetcher, err := New(config.FetchConfig{})
+	require.NoError(t, err)
+
+	resp, err := fetcher.FetchWithOptions(context.Background(), server.URL, nil)
+	require.NoError(t, err)
+
+	assert.Empty(t, resp.Transfer.ContentEncoding)
+	assert.Equal(t, int64(13), resp.Transfer.CompressedSize)
+	assert.Equal(t, int64(13), resp.Transfer.DecompressedSize)
+}
+
+// TestFetcherTransferTLS verifies the TLS version is reported for HTTPS responses.
+func TestFetcherTransferTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secure"))
+	}))
+	defer server.Close()
+
+	fetcher, err := New(config.FetchConfig{})
+	require.NoError(t, err)
+	fetcher.client.Transport = server.Client().Transport
+
+	resp, err := fetcher.FetchWithOptions(context.Background(), server.URL, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "secure", string(resp.Body))
+	assert.True(t, strings.HasPrefix(resp.Transfer.TLSVersion, "TLS 1."), resp.Transfer.TLSVersion)
+}
+
+// TestDecodeBody verifies each supported content encoding is decoded.
+func TestDecodeBody(t *testing.T) {
+	const body = "Hello, World!"
+
+	var gzipped, zlibbed, raw, brotlied, zstded, stacked bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write([]byte(body))
+	gz.Close()
+	zw := zlib.NewWriter(&zlibbed)
+	zw.Write([]byte(body))
+	zw.Close()
+	fw, err := flate.NewWriter(&raw, flate.DefaultCompression)
+	require.NoError(t, err)
+	fw.Write([]byte(body))
+	fw.Close()
+	bw := brotli.NewWriter(&brotlied)
+	bw.Write([]byte(body))
+	bw.Close()
+	zsw, err := zstd.NewWriter(&zstded)
+	require.NoError(t, err)
+	zsw.Write([]byte(body))
+	zsw.Close()
+	bw = brotli.NewWriter(&stacked)
+	bw.Write(gzipped.Bytes())
+	bw.Close()
+
+	tests := []struct {
+		name     string
+		encoding string
+		data     []byte
+		want     string
+		decoded  bool
+	}{
+		{"gzip", "gzip", gzipped.Bytes(), body, true},
+		{"zlib deflate", "deflate", zlibbed.Bytes(), body, true},
+		{"raw deflate", "deflate", raw.Bytes(), body, true},
+		{"brotli", "br", brotlied.Bytes(), body, true},
+		{"zstd", "zstd", zstded.Bytes(), body, true},
+		{"stacked", "gzip, br", stacked.Bytes(), body, true},
+		{"mixed case", " GZIP ", gzipped.Bytes(), body, true},
+		{"identity", "identity", []byte(body), body, true},
+		{"empty gzip", "gzip", nil, "", true},
+		{"empty zstd", "zstd", nil, "", true},
+		{"unsupported", "compress", []byte(body), body, false},
+		{"partly unsupported", "gzip, compress", []byte(body), body, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, decoded, err := decodeBody(tt.encoding, bytes.NewReader(tt.data), 0)
+			require.NoError(t, err)
+			assert.Equal(t, tt.decoded, decoded)
+
+			defer r.Close()
+
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+// TestFetcherTransferDecompressedLimit verifies the body size limit applies to the decoded body,
+// so a small compressed response cannot expand past it.
+func TestFetcherTransferDecompressedLimit(t *testing.T) {
+	var compressed bytes.Buffer
+	zw, err := zstd.NewWriter(&compressed)
+	require.NoError(t, err)
+	zw.Write(bytes.Repeat([]byte("a"), 1<<20))
+	zw.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "zstd")
+		w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+
+	fetcher, err := New(config.FetchConfig{MaxBodySize: 64 * 1024})
+	require.NoError(t, err)
+
+	_, err = fetcher.FetchWithOptions(context.Background(), server.URL, nil)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrBodyTooLarge)
+	assert.Less(t, compressed.Len(), 64*1024, "compressed body should be under the limit")
+}
+
+// TestDecodeBodyZstdWindow verifies a zstd frame declaring a window larger than the max body
+// size is refused before the decoder allocates it.
+func TestDecodeBodyZstdWindow(t *testing.T) {
+	var compressed bytes.Buffer
+	zw, err := zstd.NewWriter(&compressed, zstd.WithWindowSize(1<<20), zstd.WithSingleSegment(false))
+	require.NoError(t, err)
+	zw.Write(bytes.Repeat([]byte("a"), 1<<20))
+	zw.Close()
+
+	r, decoded, err := decodeBody("zstd", bytes.NewReader(compressed.Bytes()), 64*1024)
+	require.NoError(t, err)
+	assert.True(t, decoded)
+	defer r.Close()
+
+	_, err = io.ReadAll(r)
+	assert.ErrorIs(t, err, ErrBodyTooLarge)
+	assert.ErrorIs(t, err, zstd.ErrWindowSizeExceeded)
+}
+
+// TestFetcherTransferBrotli verifies brotli bodies are decoded and their encoding reported.
+func TestFetcherTransferBrotli(t *testing.T) {
+	body := strings.Repeat("Hello, World! ", 100)
+
+	var compressed bytes.Buffer
+	bw := brotli.NewWriter(&compressed)
+	bw.Write([]byte(body))
+	bw.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "br")
+		w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+
+	fetcher, err := New(config.FetchConfig{})
+	require.NoError(t, err)
+
+	resp, err := fetcher.FetchWithOptions(context.Background(), server.URL, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, body, string(resp.Body))
+	assert.Empty(t, resp.Headers.Get("Content-Encoding"))
+	assert.Equal(t, "br", resp.Transfer.ContentEncoding)
+	assert.Equal(t, int64(compressed.Len()), resp.Transfer.CompressedSize)
+	assert.Equal(t, int64(len(body)), resp.Transfer.DecompressedSize)
+}
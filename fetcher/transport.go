@@ -0,0 +1,130 @@
+package fetcher
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/joeychilson/websurfer/config"
+)
+
+// transports shares tuned and SSRF-protected transports between fetchers with the same settings.
+var transports = &transportPool{
+	transports: make(map[string]http.RoundTripper),
+}
+
+// transportPool caches tuned transports so fetchers created per request still reuse
+// connections, keyed by the settings that shape the transport.
+type transportPool struct {
+	mu         sync.Mutex
+	transports map[string]http.RoundTripper
+}
+
+// get returns the shared transport for cfg, creating it if there is none.
+func (p *transportPool) get(cfg config.FetchConfig) (http.RoundTripper, error) {
+	return p.load(transportKey(cfg), func() (http.RoundTripper, error) {
+		return newTunedTransport(cfg)
+	})
+}
+
+// load returns the transport cached under key, creating it with create if there is none.
+func (p *transportPool) load(key string, create func() (http.RoundTripper, error)) (http.RoundTripper, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if transport, exists := p.transports[key]; exists {
+		return transport, nil
+	}
+
+	transport, err := create()
+	if err != nil {
+		return nil, err
+	}
+	p.transports[key] = transport
+	return transport, nil
+}
+
+// transportKey identifies the transport settings of cfg.
+func transportKey(cfg config.FetchConfig) string {
+	t := cfg.Transport
+	if t == nil {
+		t = &config.TransportConfig{}
+	}
+	return fmt.Sprintf("%t|%s|%s|%t|%d|%s|%t|%s",
+		cfg.GetEnableSSRFProtection(), cfg.IPFamily, cfg.HappyEyeballsDelay,
+		t.GetEnableHTTP2(), t.MaxIdleConnsPerHost, t.TLSMinVersion, t.DisableKeepAlives,
+		strings.Join(t.RootCAFiles, ","))
+}
+
+// newTunedTransport creates a transport with cfg's transport settings, dialing with its IP
+// family preference and SSRF protection.
+func newTunedTransport(cfg config.FetchConfig) (http.RoundTripper, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.GetEnableSSRFProtection() {
+		base.DialContext = newFamilyDialContext(newDialer(cfg, SSRFDialControl), cfg.IPFamily)
+	} else if cfg.IPFamily != "" || cfg.HappyEyeballsDelay != 0 {
+		base.DialContext = newFamilyDialContext(newDialer(cfg, nil), cfg.IPFamily)
+	}
+
+	if err := applyTransportConfig(base, cfg.Transport); err != nil {
+		return nil, err
+	}
+
+	if cfg.GetEnableSSRFProtection() {
+		return &ssrfProtectedTransport{base: base}, nil
+	}
+	return base, nil
+}
+
+// applyTransportConfig applies HTTP/2, connection pool, and TLS settings to transport.
+func applyTransportConfig(transport *http.Transport, tc *config.TransportConfig) error {
+	if tc == nil {
+		return nil
+	}
+
+	if !tc.GetEnableHTTP2() {
+		transport.ForceAttemptHTTP2 = false
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		transport.Protocols = protocols
+	}
+
+	if tc.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = tc.MaxIdleConnsPerHost
+		if transport.MaxIdleConns > 0 && transport.MaxIdleConns < tc.MaxIdleConnsPerHost {
+			transport.MaxIdleConns = tc.MaxIdleConnsPerHost
+		}
+	}
+
+	transport.DisableKeepAlives = tc.DisableKeepAlives
+
+	if tc.TLSMinVersion == "" && len(tc.RootCAFiles) == 0 {
+		return nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tc.GetTLSMinVersion()}
+	if len(tc.RootCAFiles) > 0 {
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		for _, file := range tc.RootCAFiles {
+			pem, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("failed to read root CA file: %w", err)
+			}
+			if !roots.AppendCertsFromPEM(pem) {
+				return fmt.Errorf("no certificates found in root CA file %s", file)
+			}
+		}
+		tlsConfig.RootCAs = roots
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/joeychilson/websurfer/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFetcherTransportConfig verifies root CAs are trusted and HTTP/2 can be disabled.
+func TestFetcherTransportConfig(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+
+	untrusted, err := New(config.FetchConfig{})
+	require.NoError(t, err)
+	_, err = untrusted.FetchWithOptions(context.Background(), server.URL, nil)
+	assert.Error(t, err, "test certificate is not trusted without root_ca_files")
+
+	fetcher, err := New(config.FetchConfig{Transport: &config.TransportConfig{
+		RootCAFiles:   []string{caFile},
+		TLSMinVersion: "1.2",
+	}})
+	require.NoError(t, err)
+	resp, err := fetcher.FetchWithOptions(context.Background(), server.URL, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/2.0", string(resp.Body))
+
+	enableHTTP2 := false
+	fetcher, err = New(config.FetchConfig{Transport: &config.TransportConfig{
+		RootCAFiles: []string{caFile},
+		EnableHTTP2: &enableHTTP2,
+	}})
+	require.NoError(t, err)
+	resp, err = fetcher.FetchWithOptions(context.Background(), server.URL, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1", string(resp.Body))
+}
+
+// TestFetcherTransportShared verifies fetchers with the same transport settings share a transport.
+func TestFetcherTransportShared(t *testing.T) {
+	cfg := config.FetchConfig{Transport: &config.TransportConfig{MaxIdleConnsPerHost: 64}}
+
+	first, err := New(cfg)
+	require.NoError(t, err)
+	second, err := New(cfg)
+	require.NoError(t, err)
+	assert.Same(t, first.GetHTTPClient().Transport, second.GetHTTPClient().Transport)
+
+	transport, ok := first.GetHTTPClient().Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, 64, transport.MaxIdleConnsPerHost)
+}
+
+// TestFetcherTransportInvalidCA verifies unreadable root CA files are rejected.
+func TestFetcherTransportInvalidCA(t *testing.T) {
+	_, err := New(config.FetchConfig{Transport: &config.TransportConfig{RootCAFiles: []string{filepath.Join(t.TempDir(), "missing.pem")}}})
+	assert.Error(t, err)
+}
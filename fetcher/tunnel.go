@@ -0,0 +1,221 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/joeychilson/websurfer/config"
+)
+
+// sshTunnels holds SSH connections shared by every fetcher, since fetchers are created per request.
+var sshTunnels = &tunnelPool{
+	clients: make(map[string]*ssh.Client),
+	dialing: make(map[string]*tunnelDial),
+}
+
+// tunnelPool caches SSH client connections keyed by jump host, user, and key.
+type tunnelPool struct {
+	mu      sync.Mutex
+	clients map[string]*ssh.Client
+	// dialing holds the connection in progress for each key, so callers needing the same jump
+	// host wait for one dial while other jump hosts are not held up by it.
+	dialing map[string]*tunnelDial
+}
+
+// tunnelDial is an SSH connection in progress, whose result is set before done is closed.
+type tunnelDial struct {
+	done   chan struct{}
+	client *ssh.Client
+	err    error
+}
+
+// sshTunnel dials connections through an SSH jump host.
+type sshTunnel struct {
+	cfg  config.SSHTunnelConfig
+	key  string
+	pool *tunnelPool
+}
+
+// newSSHTunnel validates the tunnel configuration and returns a dialer for it.
+func newSSHTunnel(cfg config.SSHTunnelConfig) (*sshTunnel, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("ssh tunnel host is required")
+	}
+	if cfg.User == "" {
+		return nil, fmt.Errorf("ssh tunnel user is required")
+	}
+	if cfg.KeyFile == "" {
+		return nil, fmt.Errorf("ssh tunnel key_file is required")
+	}
+
+	return &sshTunnel{
+		cfg:  cfg,
+		key:  cfg.User + "@" + cfg.GetAddress() + "|" + cfg.KeyFile,
+		pool: sshTunnels,
+	}, nil
+}
+
+// newTunnelTransport creates a transport that dials every connection through the SSH tunnel.
+// SSRF protection is not applied: names are resolved by the jump host, and reaching hosts in its
+// private network is the point of the tunnel, so checking them against this host's resolver and
+// address ranges would reject exactly the targets the tunnel is configured for. Instead, the
+// fetcher only follows redirects within the tunneled site.
+func newTunnelTransport(tunnel *sshTunnel, cfg config.FetchConfig) (http.RoundTripper, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.Proxy = nil
+	base.DialContext = tunnel.DialContext
+
+	if err := applyTransportConfig(base, cfg.Transport); err != nil {
+		return nil, err
+	}
+	return base, nil
+}
+
+// DialContext opens a connection to address from the jump host. The address is resolved by the
+// jump host, so names that only exist inside the remote network work as expected.
+func (t *sshTunnel) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	client, err := t.pool.get(ctx, t.key, t.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := client.DialContext(ctx, network, address)
+	if err == nil {
+		return conn, nil
+	}
+
+	// The cached connection may have been dropped by the jump host; reconnect once.
+	t.pool.evict(t.key, client)
+	client, err = t.pool.get(ctx, t.key, t.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err = client.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s via ssh tunnel %s: %w", address, t.cfg.GetAddress(), err)
+	}
+	return conn, nil
+}
+
+// get returns the cached SSH client for key, connecting if there is none. The connection is
+// made outside the pool's lock, so a slow or unreachable jump host only holds up its own fetches.
+func (p *tunnelPool) get(ctx context.Context, key string, cfg config.SSHTunnelConfig) (*ssh.Client, error) {
+	p.mu.Lock()
+	if client, exists := p.clients[key]; exists {
+		p.mu.Unlock()
+		return client, nil
+	}
+	dial, inFlight := p.dialing[key]
+	if !inFlight {
+		dial = &tunnelDial{done: make(chan struct{})}
+		p.dialing[key] = dial
+		go p.dial(key, cfg, dial)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-dial.done:
+		return dial.client, dial.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// dial connects to the jump host for key and caches the client. It does not use a caller's
+// context, since other callers may be waiting on the same connection; dialSSH bounds it instead.
+func (p *tunnelPool) dial(key string, cfg config.SSHTunnelConfig, dial *tunnelDial) {
+	client, err := dialSSH(context.Background(), cfg)
+
+	p.mu.Lock()
+	delete(p.dialing, key)
+	if err == nil {
+		p.clients[key] = client
+	}
+	p.mu.Unlock()
+
+	dial.client, dial.err = client, err
+	close(dial.done)
+
+	if err == nil {
+		go func() {
+			_ = client.Wait()
+			p.evict(key, client)
+		}()
+	}
+}
+
+// evict removes client from the pool if it is still the cached connection for key.
+func (p *tunnelPool) evict(key string, client *ssh.Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.clients[key] == client {
+		delete(p.clients, key)
+		_ = client.Close()
+	}
+}
+
+// dialSSH connects and authenticates to the jump host.
+func dialSSH(ctx context.Context, cfg config.SSHTunnelConfig) (*ssh.Client, error) {
+	keyBytes, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ssh key: %w", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ssh key: %w", err)
+	}
+
+	hostKeyCallback, err := sshHostKeyCallback(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	clientConfig := &ssh.ClientConfig{
+		User:            cfg.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         dialTimeout,
+	}
+
+	addr := cfg.GetAddress()
+	dialer := &net.Dialer{Timeout: dialTimeout, KeepAlive: dialKeepAlive}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ssh jump host %s: %w", addr, err)
+	}
+
+	// NewClientConn does not apply clientConfig.Timeout, so bound the handshake explicitly.
+	_ = conn.SetDeadline(time.Now().Add(dialTimeout))
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, clientConfig)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ssh handshake with %s failed: %w", addr, err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+// sshHostKeyCallback returns the host key verification for the jump host.
+func sshHostKeyCallback(cfg config.SSHTunnelConfig) (ssh.HostKeyCallback, error) {
+	if cfg.InsecureSkipHostKeyCheck {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	callback, err := knownhosts.New(cfg.GetKnownHostsFile())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ssh known_hosts: %w", err)
+	}
+	return callback, nil
+}
@@ -0,0 +1,319 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/joeychilson/websurfer/config"
+	urlutil "github.com/joeychilson/websurfer/url"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// startSSHJumpHost starts an in-process SSH server that forwards direct-tcpip channels.
+// It returns the server address and a counter of forwarded channels.
+func startSSHJumpHost(t *testing.T, clientKey ssh.PublicKey) (string, *atomic.Int32) {
+	t.Helper()
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	require.NoError(t, err)
+
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "tunnel" && string(key.Marshal()) == string(clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, assert.AnError
+		},
+	}
+	serverConfig.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	var forwarded atomic.Int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSHConn(conn, serverConfig, &forwarded)
+		}
+	}()
+
+	return listener.Addr().String(), &forwarded
+}
+
+// serveSSHConn handles a single SSH connection, forwarding direct-tcpip channels.
+func serveSSHConn(conn net.Conn, serverConfig *ssh.ServerConfig, forwarded *atomic.Int32) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, serverConfig)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+
+		extra := newChannel.ExtraData()
+		hostLen := binary.BigEndian.Uint32(extra[:4])
+		host := string(extra[4 : 4+hostLen])
+		port := binary.BigEndian.Uint32(extra[4+hostLen : 8+hostLen])
+
+		target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		channel, chanReqs, err := newChannel.Accept()
+		if err != nil {
+			target.Close()
+			continue
+		}
+		go ssh.DiscardRequests(chanReqs)
+		forwarded.Add(1)
+
+		go func() {
+			defer channel.Close()
+			defer target.Close()
+			go io.Copy(target, channel)
+			io.Copy(channel, target)
+		}()
+	}
+}
+
+// writeTestSSHKey writes a fresh private key to a temp file and returns its path and public key.
+func writeTestSSHKey(t *testing.T) (string, ssh.PublicKey) {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0600))
+
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+
+	return path, signer.PublicKey()
+}
+
+// TestFetcherSSHTunnel verifies fetches are routed through the configured SSH jump host.
+func TestFetcherSSHTunnel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("behind the bastion"))
+	}))
+	defer server.Close()
+
+	keyFile, publicKey := writeTestSSHKey(t)
+	jumpHost, forwarded := startSSHJumpHost(t, publicKey)
+
+	fetcher, err := New(config.FetchConfig{
+		SSHTunnel: &config.SSHTunnelConfig{
+			Host:                     jumpHost,
+			User:                     "tunnel",
+			KeyFile:                  keyFile,
+			InsecureSkipHostKeyCheck: true,
+		},
+	})
+	require.NoError(t, err)
+
+	resp, err := fetcher.FetchWithOptions(context.Background(), server.URL, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "behind the bastion", string(resp.Body))
+	assert.Equal(t, int32(1), forwarded.Load(), "request should be forwarded by the jump host")
+}
+
+// TestFetcherSSHTunnelAuthFailure verifies an unauthorized key surfaces a handshake error.
+func TestFetcherSSHTunnelAuthFailure(t *testing.T) {
+	keyFile, _ := writeTestSSHKey(t)
+	_, otherKey := writeTestSSHKey(t)
+	jumpHost, _ := startSSHJumpHost(t, otherKey)
+
+	fetcher, err := New(config.FetchConfig{
+		SSHTunnel: &config.SSHTunnelConfig{
+			Host:                     jumpHost,
+			User:                     "tunnel",
+			KeyFile:                  keyFile,
+			InsecureSkipHostKeyCheck: true,
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = fetcher.FetchWithOptions(context.Background(), "http://internal.example/", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ssh handshake")
+}
+
+// TestNewSSHTunnelValidation verifies incomplete tunnel configs are rejected.
+func TestNewSSHTunnelValidation(t *testing.T) {
+	_, err := newSSHTunnel(config.SSHTunnelConfig{User: "u", KeyFile: "k"})
+	assert.Error(t, err)
+
+	_, err = newSSHTunnel(config.SSHTunnelConfig{Host: "h", KeyFile: "k"})
+	assert.Error(t, err)
+
+	_, err = newSSHTunnel(config.SSHTunnelConfig{Host: "h", User: "u"})
+	assert.Error(t, err)
+
+	tunnel, err := newSSHTunnel(config.SSHTunnelConfig{Host: "bastion.internal", User: "u", KeyFile: "k"})
+	require.NoError(t, err)
+	assert.Equal(t, "bastion.internal:22", tunnel.cfg.GetAddress())
+}
+
+// TestFetcherSSHTunnelSSRF verifies SSRF protection does not reject hosts reached through the
+// tunnel, which may only resolve or be routable behind the jump host, and that fetchers for the
+// same tunnel share a transport.
+func TestFetcherSSHTunnelSSRF(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("private network"))
+	}))
+	defer server.Close()
+
+	keyFile, publicKey := writeTestSSHKey(t)
+	jumpHost, _ := startSSHJumpHost(t, publicKey)
+
+	enableSSRF := true
+	cfg := config.FetchConfig{
+		EnableSSRFProtection: &enableSSRF,
+		SSHTunnel: &config.SSHTunnelConfig{
+			Host:                     jumpHost,
+			User:                     "tunnel",
+			KeyFile:                  keyFile,
+			InsecureSkipHostKeyCheck: true,
+		},
+	}
+
+	fetcher, err := New(cfg)
+	require.NoError(t, err)
+	resp, err := fetcher.FetchWithOptions(context.Background(), server.URL, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "private network", string(resp.Body))
+
+	second, err := New(cfg)
+	require.NoError(t, err)
+	assert.Same(t, fetcher.GetHTTPClient().Transport, second.GetHTTPClient().Transport)
+}
+
+// TestFetcherSSHTunnelRedirects verifies redirects are followed through the tunnel only within
+// the tunneled site.
+func TestFetcherSSHTunnelRedirects(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/inside":
+			http.Redirect(w, r, "/page", http.StatusFound)
+		case "/away":
+			http.Redirect(w, r, strings.Replace(server.URL, "127.0.0.1", "localhost", 1)+"/page", http.StatusFound)
+		default:
+			w.Write([]byte("tunneled page"))
+		}
+	}))
+	defer server.Close()
+
+	keyFile, publicKey := writeTestSSHKey(t)
+	jumpHost, _ := startSSHJumpHost(t, publicKey)
+
+	fetcher, err := New(config.FetchConfig{
+		MaxRedirects: 5,
+		SSHTunnel: &config.SSHTunnelConfig{
+			Host:                     jumpHost,
+			User:                     "tunnel",
+			KeyFile:                  keyFile,
+			InsecureSkipHostKeyCheck: true,
+			SitePattern:              strings.TrimPrefix(server.URL, "http://"),
+		},
+	})
+	require.NoError(t, err)
+
+	resp, err := fetcher.FetchWithOptions(context.Background(), server.URL+"/inside", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "tunneled page", string(resp.Body))
+
+	_, err = fetcher.FetchWithOptions(context.Background(), server.URL+"/away", nil)
+	assert.ErrorIs(t, err, urlutil.ErrBlockedAddress)
+}
+
+// TestTunnelPoolSlowJumpHost verifies a jump host that never completes its handshake does not
+// hold up connections to other jump hosts, and its callers give up with their context.
+func TestTunnelPoolSlowJumpHost(t *testing.T) {
+	stalled, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { stalled.Close() })
+
+	var accepted []net.Conn
+	var mu sync.Mutex
+	go func() {
+		for {
+			conn, err := stalled.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			accepted = append(accepted, conn)
+			mu.Unlock()
+		}
+	}()
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range accepted {
+			conn.Close()
+		}
+	})
+
+	keyFile, publicKey := writeTestSSHKey(t)
+	jumpHost, _ := startSSHJumpHost(t, publicKey)
+
+	pool := &tunnelPool{clients: make(map[string]*ssh.Client), dialing: make(map[string]*tunnelDial)}
+	stalledCfg := config.SSHTunnelConfig{Host: stalled.Addr().String(), User: "tunnel", KeyFile: keyFile, InsecureSkipHostKeyCheck: true}
+	workingCfg := config.SSHTunnelConfig{Host: jumpHost, User: "tunnel", KeyFile: keyFile, InsecureSkipHostKeyCheck: true}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	stalledErr := make(chan error, 1)
+	go func() {
+		_, err := pool.get(ctx, "stalled", stalledCfg)
+		stalledErr <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		pool.mu.Lock()
+		defer pool.mu.Unlock()
+		return pool.dialing["stalled"] != nil
+	}, time.Second, 5*time.Millisecond)
+
+	client, err := pool.get(context.Background(), "working", workingCfg)
+	require.NoError(t, err)
+	defer client.Close()
+
+	assert.ErrorIs(t, <-stalledErr, context.DeadlineExceeded)
+}
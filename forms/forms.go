@@ -0,0 +1,366 @@
+// Package forms extracts the forms of an HTML document, describing the inputs a page takes
+// even though forms are dropped when the page is converted to markdown.
+package forms
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// maxForms is the most forms extracted from a document.
+	maxForms = 50
+	// maxFields is the most fields extracted from a form.
+	maxFields = 200
+)
+
+// Form is an HTML form. Action is absolute, and Method is "GET", "POST", or "DIALOG".
+type Form struct {
+	ID      string  `json:"id,omitempty"`
+	Name    string  `json:"name,omitempty"`
+	Action  string  `json:"action"`
+	Method  string  `json:"method"`
+	Enctype string  `json:"enctype,omitempty"`
+	Fields  []Field `json:"fields"`
+}
+
+// Field is a control of a form. Type is the input type, such as "text", "email", "hidden", or
+// "submit", or "select" or "textarea". Radio buttons and checkboxes sharing a name are one
+// field whose Options are the choices.
+type Field struct {
+	Name        string   `json:"name,omitempty"`
+	Type        string   `json:"type"`
+	Label       string   `json:"label,omitempty"`
+	Value       string   `json:"value,omitempty"`
+	Placeholder string   `json:"placeholder,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Multiple    bool     `json:"multiple,omitempty"`
+	Options     []Option `json:"options,omitempty"`
+}
+
+// Option is a choice of a select, radio, or checkbox field.
+type Option struct {
+	Value    string `json:"value"`
+	Label    string `json:"label,omitempty"`
+	Selected bool   `json:"selected,omitempty"`
+}
+
+// Extract returns the forms of an HTML document, with actions resolved against the document's
+// <base> or pageURL, or nil if it has none. Controls outside a form that name it with a form
+// attribute are included in it. Forms without fields are skipped.
+func Extract(htmlContent []byte, pageURL string) []Form {
+	doc, err := html.Parse(bytes.NewReader(htmlContent))
+	if err != nil {
+		return nil
+	}
+
+	page, _ := url.Parse(pageURL)
+	base := page
+	labels := make(map[string]string)
+	var (
+		formNodes []*html.Node
+		detached  []*html.Node
+	)
+	walk(doc, func(n *html.Node) bool {
+		switch n.Data {
+		case "base":
+			if href := attr(n, "href"); href != "" && base != nil {
+				if resolved, err := base.Parse(href); err == nil {
+					base = resolved
+				}
+			}
+		case "label":
+			if id := attr(n, "for"); id != "" {
+				if _, ok := labels[id]; !ok {
+					labels[id] = labelText(n)
+				}
+			}
+		case "form":
+			formNodes = append(formNodes, n)
+		case "input", "select", "textarea", "button":
+			if attr(n, "form") != "" && formAncestor(n) == nil {
+				detached = append(detached, n)
+			}
+		}
+		return true
+	})
+
+	var forms []Form
+	for _, n := range formNodes {
+		if len(forms) == maxForms {
+			break
+		}
+
+		form := Form{
+			ID:     attr(n, "id"),
+			Name:   attr(n, "name"),
+			Action: resolve(page, base, attr(n, "action")),
+			Method: strings.ToUpper(strings.TrimSpace(attr(n, "method"))),
+		}
+		switch form.Method {
+		case "POST":
+			form.Enctype = strings.ToLower(strings.TrimSpace(attr(n, "enctype")))
+		case "DIALOG":
+		default:
+			form.Method = "GET"
+		}
+
+		var controls []*html.Node
+		walk(n, func(c *html.Node) bool {
+			switch c.Data {
+			case "form":
+				return false
+			case "input", "select", "textarea", "button":
+				if owner := attr(c, "form"); owner == "" || owner == form.ID {
+					controls = append(controls, c)
+				}
+				return c.Data != "select"
+			}
+			return true
+		})
+		if form.ID != "" {
+			for _, c := range detached {
+				if attr(c, "form") == form.ID {
+					controls = append(controls, c)
+				}
+			}
+		}
+
+		for _, c := range controls {
+			if len(form.Fields) == maxFields {
+				break
+			}
+			form.Fields = addField(form.Fields, c, labels)
+		}
+		if len(form.Fields) > 0 {
+			forms = append(forms, form)
+		}
+	}
+	return forms
+}
+
+// addField adds the field of control c to fields, merging radio buttons and checkboxes into
+// the field of the same name.
+func addField(fields []Field, c *html.Node, labels map[string]string) []Field {
+	field := Field{
+		Name:     attr(c, "name"),
+		Label:    controlLabel(c, labels),
+		Required: hasAttr(c, "required"),
+	}
+
+	switch c.Data {
+	case "select":
+		field.Type = "select"
+		field.Multiple = hasAttr(c, "multiple")
+		walk(c, func(o *html.Node) bool {
+			if o.Data == "option" {
+				label := collapse(text(o))
+				value, ok := attrOK(o, "value")
+				if !ok {
+					value = label
+				}
+				option := Option{Value: value, Selected: hasAttr(o, "selected")}
+				if label != value {
+					option.Label = label
+				}
+				field.Options = append(field.Options, option)
+			}
+			return true
+		})
+	case "textarea":
+		field.Type = "textarea"
+		field.Value = strings.TrimSpace(text(c))
+		field.Placeholder = attr(c, "placeholder")
+	case "button":
+		field.Type = strings.ToLower(strings.TrimSpace(attr(c, "type")))
+		if field.Type == "" {
+			field.Type = "submit"
+		}
+		if field.Type != "submit" {
+			return fields
+		}
+		field.Value = attr(c, "value")
+		if field.Label == "" {
+			field.Label = collapse(text(c))
+		}
+	default:
+		field.Type = strings.ToLower(strings.TrimSpace(attr(c, "type")))
+		switch field.Type {
+		case "":
+			field.Type = "text"
+		case "reset", "button":
+			return fields
+		case "image":
+			field.Type = "submit"
+			if field.Label == "" {
+				field.Label = attr(c, "alt")
+			}
+		}
+		field.Placeholder = attr(c, "placeholder")
+
+		switch field.Type {
+		case "radio", "checkbox":
+			value, ok := attrOK(c, "value")
+			if !ok {
+				value = "on"
+			}
+			option := Option{Value: value, Label: field.Label, Selected: hasAttr(c, "checked")}
+			for i := range fields {
+				if fields[i].Type == field.Type && fields[i].Name == field.Name && field.Name != "" {
+					fields[i].Options = append(fields[i].Options, option)
+					fields[i].Required = fields[i].Required || field.Required
+					return fields
+				}
+			}
+			if legend := groupLabel(c); legend != "" {
+				field.Label = legend
+			}
+			field.Options = []Option{option}
+		case "password":
+		default:
+			field.Value = attr(c, "value")
+		}
+	}
+	return append(fields, field)
+}
+
+// controlLabel returns the label of control c: a <label> naming its id, the <label> around it,
+// or its aria-label or title.
+func controlLabel(c *html.Node, labels map[string]string) string {
+	if id := attr(c, "id"); id != "" {
+		if label := labels[id]; label != "" {
+			return label
+		}
+	}
+	for p := c.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.Data == "label" {
+			if label := labelText(p); label != "" {
+				return label
+			}
+			break
+		}
+	}
+	if label := strings.TrimSpace(attr(c, "aria-label")); label != "" {
+		return label
+	}
+	return strings.TrimSpace(attr(c, "title"))
+}
+
+// groupLabel returns the legend of the fieldset around a radio button or checkbox, which
+// labels its group.
+func groupLabel(c *html.Node) string {
+	for p := c.Parent; p != nil; p = p.Parent {
+		if p.Type != html.ElementNode || p.Data != "fieldset" {
+			continue
+		}
+		for l := p.FirstChild; l != nil; l = l.NextSibling {
+			if l.Type == html.ElementNode && l.Data == "legend" {
+				return collapse(text(l))
+			}
+		}
+		return ""
+	}
+	return ""
+}
+
+// labelText returns the text of a label, leaving out the controls inside it.
+func labelText(n *html.Node) string {
+	var b strings.Builder
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch {
+			case c.Type == html.TextNode:
+				b.WriteString(c.Data)
+				b.WriteByte(' ')
+			case c.Type == html.ElementNode && (c.Data == "select" || c.Data == "textarea" || c.Data == "button"):
+			default:
+				visit(c)
+			}
+		}
+	}
+	visit(n)
+	return collapse(b.String())
+}
+
+// formAncestor returns the form containing n, or nil.
+func formAncestor(n *html.Node) *html.Node {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.Data == "form" {
+			return p
+		}
+	}
+	return nil
+}
+
+// resolve returns action resolved against base. An empty action submits to the page itself,
+// regardless of base.
+func resolve(page, base *url.URL, action string) string {
+	action = strings.TrimSpace(action)
+	if action == "" && page != nil {
+		resolved := *page
+		resolved.Fragment = ""
+		return resolved.String()
+	}
+	if base == nil {
+		return action
+	}
+	resolved, err := base.Parse(action)
+	if err != nil {
+		return action
+	}
+	return resolved.String()
+}
+
+// walk calls visit for each element below n, descending into an element only when visit
+// returns true.
+func walk(n *html.Node, visit func(*html.Node) bool) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && !visit(c) {
+			continue
+		}
+		walk(c, visit)
+	}
+}
+
+// text returns the text content of n and its children.
+func text(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(text(c))
+	}
+	return b.String()
+}
+
+// attr returns the value of an attribute of n.
+func attr(n *html.Node, key string) string {
+	value, _ := attrOK(n, key)
+	return value
+}
+
+// attrOK returns the value of an attribute of n and whether n has it.
+func attrOK(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// hasAttr reports whether n has an attribute, such as the boolean attribute required.
+func hasAttr(n *html.Node, key string) bool {
+	_, ok := attrOK(n, key)
+	return ok
+}
+
+// collapse trims s and collapses its runs of whitespace to single spaces.
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
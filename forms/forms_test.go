@@ -0,0 +1,73 @@
+package forms
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExtract verifies form actions, methods, and fields are extracted with their labels.
+func TestExtract(t *testing.T) {
+	html := `<html><body>
+	<form id="signup" action="/signup#top" method="post" enctype="multipart/form-data">
+		<label for="email">Email address</label>
+		<input id="email" name="email" type="email" placeholder="you@example.com" required>
+		<label>Password <input name="password" type="password" value="secret"></label>
+		<input type="hidden" name="csrf" value="abc123">
+		<select name="plan" aria-label="Plan">
+			<option value="free">Free tier</option>
+			<option selected>Pro</option>
+		</select>
+		<fieldset><legend>Contact me by</legend>
+			<label><input type="radio" name="contact" value="email" checked> Email</label>
+			<label><input type="radio" name="contact" value="phone"> Phone</label>
+		</fieldset>
+		<label><input type="checkbox" name="terms" required> I agree</label>
+		<textarea name="bio">Hello</textarea>
+		<input type="reset">
+		<button>Create account</button>
+	</form>
+	<input form="signup" name="referrer" type="text" title="Referrer">
+	<form action="https://search.example.com/find"><input name="q"></form>
+	<form action="/empty"><p>No fields.</p></form>
+	</body></html>`
+
+	forms := Extract([]byte(html), "https://example.com/join?ref=1")
+	require.Len(t, forms, 2)
+
+	signup := forms[0]
+	assert.Equal(t, "signup", signup.ID)
+	assert.Equal(t, "https://example.com/signup#top", signup.Action)
+	assert.Equal(t, "POST", signup.Method)
+	assert.Equal(t, "multipart/form-data", signup.Enctype)
+	assert.Equal(t, []Field{
+		{Name: "email", Type: "email", Label: "Email address", Placeholder: "you@example.com", Required: true},
+		{Name: "password", Type: "password", Label: "Password"},
+		{Name: "csrf", Type: "hidden", Value: "abc123"},
+		{Name: "plan", Type: "select", Label: "Plan", Options: []Option{{Value: "free", Label: "Free tier"}, {Value: "Pro", Selected: true}}},
+		{Name: "contact", Type: "radio", Label: "Contact me by", Options: []Option{{Value: "email", Label: "Email", Selected: true}, {Value: "phone", Label: "Phone"}}},
+		{Name: "terms", Type: "checkbox", Label: "I agree", Required: true, Options: []Option{{Value: "on", Label: "I agree"}}},
+		{Name: "bio", Type: "textarea", Value: "Hello"},
+		{Type: "submit", Label: "Create account"},
+		{Name: "referrer", Type: "text", Label: "Referrer"},
+	}, signup.Fields)
+
+	search := forms[1]
+	assert.Equal(t, "https://search.example.com/find", search.Action)
+	assert.Equal(t, "GET", search.Method)
+	assert.Equal(t, []Field{{Name: "q", Type: "text"}}, search.Fields)
+}
+
+// TestExtractDefaults verifies actions are resolved against <base>, and an empty action submits
+// to the page itself.
+func TestExtractDefaults(t *testing.T) {
+	html := `<head><base href="https://cdn.example.com/app/"></head><form><input name="q"></form><form action="next"><input name="q"></form>`
+
+	forms := Extract([]byte(html), "https://example.com/page#section")
+	require.Len(t, forms, 2)
+	assert.Equal(t, "https://example.com/page", forms[0].Action)
+	assert.Equal(t, "https://cdn.example.com/app/next", forms[1].Action)
+
+	assert.Nil(t, Extract([]byte(`<p>No forms here.</p>`), "https://example.com/"))
+}
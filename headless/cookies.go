@@ -0,0 +1,102 @@
+package headless
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+// contextKey is a type for context keys used by the headless package.
+type contextKey string
+
+// requestContextKey stores the Request of renders made with the context.
+const requestContextKey contextKey = "headless_request"
+
+// Request is the browsing state a render sends with the page, such as a session's.
+type Request struct {
+	// Jar supplies the cookies sent with the page and keeps the cookies it sets, including
+	// during redirects and from scripts.
+	Jar http.CookieJar
+	// Referer is sent with the page's request.
+	Referer string
+}
+
+// WithRequest adds a Request to the context, sent with pages rendered with it.
+func WithRequest(ctx context.Context, req Request) context.Context {
+	return context.WithValue(ctx, requestContextKey, req)
+}
+
+// requestFrom returns the Request of ctx, or the zero Request if there is none.
+func requestFrom(ctx context.Context) Request {
+	req, _ := ctx.Value(requestContextKey).(Request)
+	return req
+}
+
+// seedCookies sets the cookies jar sends to pageURL in the browser, returning their values by
+// name.
+func seedCookies(ctx context.Context, jar http.CookieJar, pageURL string) (map[string]string, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	cookies := jar.Cookies(u)
+	if len(cookies) == 0 {
+		return nil, nil
+	}
+
+	params := make([]*network.CookieParam, 0, len(cookies))
+	seeded := make(map[string]string, len(cookies))
+	for _, cookie := range cookies {
+		params = append(params, &network.CookieParam{
+			Name:  cookie.Name,
+			Value: cookie.Value,
+			URL:   pageURL,
+			Path:  "/",
+		})
+		seeded[cookie.Name] = cookie.Value
+	}
+	return seeded, network.SetCookies(params).Do(ctx)
+}
+
+// storeCookies keeps the cookies the browser holds for the documents it loaded in jar. Cookies
+// it was seeded with are skipped unless the page changed them, so the jar keeps their scope.
+func storeCookies(ctx context.Context, jar http.CookieJar, documents []string, seeded map[string]string) error {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	cookies, err := network.GetCookies().WithURLs(documents).Do(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, cookie := range cookies {
+		if value, ok := seeded[cookie.Name]; ok && value == cookie.Value {
+			continue
+		}
+
+		host := strings.TrimPrefix(cookie.Domain, ".")
+		received := &http.Cookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Path:     cookie.Path,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HTTPOnly,
+		}
+		if strings.HasPrefix(cookie.Domain, ".") {
+			received.Domain = host
+		}
+		if !cookie.Session {
+			sec, frac := math.Modf(cookie.Expires)
+			received.Expires = time.Unix(int64(sec), int64(frac*float64(time.Second)))
+		}
+		jar.SetCookies(&url.URL{Scheme: "https", Host: host, Path: cookie.Path}, []*http.Cookie{received})
+	}
+	return nil
+}
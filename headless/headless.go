@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"slices"
 	"sync"
 	"time"
 
@@ -66,40 +67,47 @@ func New(opts ...Option) *Browser {
 	return b
 }
 
-// Render fetches a URL using a headless browser and returns the rendered HTML.
+// Render fetches a URL using a headless browser and returns the rendered HTML, sending the
+// Request added to ctx with WithRequest. Each call launches its own browser; use a Pool to
+// render many pages.
 func (b *Browser) Render(ctx context.Context, url string) (*Response, error) {
-	b.logger.Debug("headless render started", "url", url)
+	allocCtx, allocCancel := b.allocate(ctx)
+	defer allocCancel()
 
-	var (
-		allocCtx    context.Context
-		allocCancel context.CancelFunc
-	)
+	taskCtx, taskCancel := chromedp.NewContext(allocCtx)
+	defer taskCancel()
+
+	return b.render(taskCtx, url, requestFrom(ctx))
+}
+
+// allocate returns an allocator for a local Chrome, or for the remote CDP endpoint if set.
+func (b *Browser) allocate(ctx context.Context) (context.Context, context.CancelFunc) {
 	if b.cdpURL != "" {
 		b.logger.Debug("using remote CDP endpoint", "url", b.cdpURL)
-		allocCtx, allocCancel = chromedp.NewRemoteAllocator(ctx, b.cdpURL, chromedp.NoModifyURL)
-	} else {
-		opts := make([]chromedp.ExecAllocatorOption, len(chromedp.DefaultExecAllocatorOptions))
-		copy(opts, chromedp.DefaultExecAllocatorOptions[:])
-		opts = append(opts,
-			chromedp.Flag("headless", true),
-			chromedp.Flag("disable-gpu", true),
-			chromedp.Flag("no-sandbox", true),
-			chromedp.Flag("disable-dev-shm-usage", true),
-			chromedp.Flag("disable-extensions", true),
-			chromedp.Flag("disable-background-networking", true),
-			chromedp.Flag("disable-sync", true),
-			chromedp.Flag("disable-translate", true),
-			chromedp.Flag("mute-audio", true),
-			chromedp.Flag("hide-scrollbars", true),
-			chromedp.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
-		)
-
-		allocCtx, allocCancel = chromedp.NewExecAllocator(ctx, opts...)
+		return chromedp.NewRemoteAllocator(ctx, b.cdpURL, chromedp.NoModifyURL)
 	}
-	defer allocCancel()
 
-	taskCtx, taskCancel := chromedp.NewContext(allocCtx)
-	defer taskCancel()
+	opts := make([]chromedp.ExecAllocatorOption, len(chromedp.DefaultExecAllocatorOptions))
+	copy(opts, chromedp.DefaultExecAllocatorOptions[:])
+	opts = append(opts,
+		chromedp.Flag("headless", true),
+		chromedp.Flag("disable-gpu", true),
+		chromedp.Flag("no-sandbox", true),
+		chromedp.Flag("disable-dev-shm-usage", true),
+		chromedp.Flag("disable-extensions", true),
+		chromedp.Flag("disable-background-networking", true),
+		chromedp.Flag("disable-sync", true),
+		chromedp.Flag("disable-translate", true),
+		chromedp.Flag("mute-audio", true),
+		chromedp.Flag("hide-scrollbars", true),
+		chromedp.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
+	)
+	return chromedp.NewExecAllocator(ctx, opts...)
+}
+
+// render renders a URL in the tab of taskCtx, sending req with it.
+func (b *Browser) render(taskCtx context.Context, url string, req Request) (*Response, error) {
+	b.logger.Debug("headless render started", "url", url)
 
 	taskCtx, timeoutCancel := context.WithTimeout(taskCtx, b.timeout)
 	defer timeoutCancel()
@@ -109,6 +117,7 @@ func (b *Browser) Render(ctx context.Context, url string) (*Response, error) {
 		statusCode int
 		finalURL   string
 		headers    http.Header
+		seeded     map[string]string
 	)
 
 	state := &pageState{}
@@ -117,6 +126,9 @@ func (b *Browser) Render(ctx context.Context, url string) (*Response, error) {
 		switch e := ev.(type) {
 		case *network.EventRequestWillBeSent:
 			state.addRequest()
+			if e.Type == network.ResourceTypeDocument {
+				state.addDocument(e.Request.URL)
+			}
 		case *network.EventLoadingFinished, *network.EventLoadingFailed:
 			state.removeRequest()
 		case *network.EventResponseReceived:
@@ -133,13 +145,25 @@ func (b *Browser) Render(ctx context.Context, url string) (*Response, error) {
 		network.Enable(),
 		page.Enable(),
 		page.SetLifecycleEventsEnabled(true),
-		chromedp.Navigate(url),
+		chromedp.ActionFunc(func(ctx context.Context) (err error) {
+			if req.Jar != nil {
+				seeded, err = seedCookies(ctx, req.Jar, url)
+			}
+			return err
+		}),
+		navigate(url, req.Referer),
 		chromedp.WaitReady("body"),
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			return waitForPageReady(ctx, state, b.logger)
 		}),
 		chromedp.Location(&finalURL),
 		chromedp.OuterHTML("html", &html),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			if req.Jar == nil {
+				return nil
+			}
+			return storeCookies(ctx, req.Jar, state.getDocuments(), seeded)
+		}),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("headless render failed: %w", err)
@@ -159,12 +183,44 @@ func (b *Browser) Render(ctx context.Context, url string) (*Response, error) {
 	}, nil
 }
 
+// navigate navigates to url, sending referer as its Referer if set.
+func navigate(url, referer string) chromedp.NavigateAction {
+	if referer == "" {
+		return chromedp.Navigate(url)
+	}
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		switch _, _, errorText, _, err := page.Navigate(url).WithReferrer(referer).Do(ctx); {
+		case err != nil:
+			return err
+		case errorText != "":
+			return fmt.Errorf("page load error %s", errorText)
+		}
+		return nil
+	})
+}
+
 // pageState tracks the loading state of a page.
 type pageState struct {
 	mu              sync.Mutex
 	inflight        int
 	lastNetActivity time.Time
 	networkIdle     bool
+	// documents lists the URLs of the documents requested, including redirects.
+	documents []string
+}
+
+// addDocument records the URL of a document request.
+func (s *pageState) addDocument(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.documents = append(s.documents, url)
+}
+
+// getDocuments returns the URLs of the documents requested.
+func (s *pageState) getDocuments() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.documents)
 }
 
 // addRequest increments the number of inflight requests and updates the last activity time.
@@ -0,0 +1,346 @@
+package headless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// DefaultPoolSize is the number of browser contexts a pool keeps when no size is given.
+	DefaultPoolSize = 4
+	// DefaultRecycleAfter is the number of pages a browser context renders before it is
+	// replaced when no limit is given.
+	DefaultRecycleAfter = 50
+)
+
+// ErrPoolClosed is returned when rendering with a closed pool.
+var ErrPoolClosed = errors.New("headless pool closed")
+
+// PoolStats is a point-in-time snapshot of a pool.
+type PoolStats struct {
+	// Size is the number of browser contexts the pool keeps, and so the most pages it
+	// renders at once.
+	Size int
+	// Warm is the number of browser contexts currently open.
+	Warm int
+	// Active is the number of renders in progress.
+	Active int
+	// Waiting is the number of renders waiting for a free browser context.
+	Waiting int
+	// Renders, Failures, and Recycled count the pages rendered, the renders that failed, and
+	// the browser contexts replaced since the pool was created.
+	Renders  int64
+	Failures int64
+	Recycled int64
+}
+
+// session is a browser context of a pool, in which pages are rendered one at a time.
+type session interface {
+	render(ctx context.Context, url string, req Request) (*Response, error)
+	close()
+}
+
+// slot holds one of a pool's browser contexts, opened on demand, and the pages it rendered.
+type slot struct {
+	session session
+	pages   int
+}
+
+// Pool renders pages in a fixed number of warm browser contexts of one shared browser. Each
+// context is isolated, with its own cookies and storage. At most one page renders in a context
+// at a time, so renders beyond the pool's size wait for a context to free up, and a context is
+// replaced after rendering a number of pages, or failing to, so memory leaked by pages doesn't
+// accumulate.
+type Pool struct {
+	browser      *Browser
+	size         int
+	recycleAfter int
+	open         func() (session, error)
+
+	slots     chan *slot
+	done      chan struct{}
+	warmOnce  sync.Once
+	closeOnce sync.Once
+
+	mu            sync.Mutex
+	closed        bool
+	browserCtx    context.Context
+	browserCancel context.CancelFunc
+
+	warm     atomic.Int64
+	active   atomic.Int64
+	waiting  atomic.Int64
+	renders  atomic.Int64
+	failures atomic.Int64
+	recycled atomic.Int64
+}
+
+// PoolOption configures the Pool.
+type PoolOption func(*Pool)
+
+// WithPoolSize sets the number of browser contexts, and so the most pages rendered at once.
+func WithPoolSize(n int) PoolOption {
+	return func(p *Pool) {
+		if n > 0 {
+			p.size = n
+		}
+	}
+}
+
+// WithRecycleAfter sets the number of pages a browser context renders before it is replaced.
+func WithRecycleAfter(n int) PoolOption {
+	return func(p *Pool) {
+		if n > 0 {
+			p.recycleAfter = n
+		}
+	}
+}
+
+// NewPool creates a pool rendering pages with browser. The browser is launched, and the pool's
+// contexts opened, on the first render.
+func NewPool(browser *Browser, opts ...PoolOption) *Pool {
+	p := &Pool{
+		browser:      browser,
+		size:         DefaultPoolSize,
+		recycleAfter: DefaultRecycleAfter,
+		done:         make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.open = p.openSession
+
+	p.slots = make(chan *slot, p.size)
+	for range p.size {
+		p.slots <- &slot{}
+	}
+	return p
+}
+
+// Render renders a URL in a free browser context of the pool, waiting for one if all are busy.
+// A render sending a cookie jar, added to ctx with WithRequest, runs in a context that rendered
+// no other page and is recycled afterwards, so its cookies aren't shared with other renders.
+func (p *Pool) Render(ctx context.Context, url string) (*Response, error) {
+	p.warmOnce.Do(func() { go p.warmUp() })
+
+	s, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.active.Add(1)
+	defer p.active.Add(-1)
+
+	req := requestFrom(ctx)
+	if req.Jar != nil && s.pages > 0 {
+		p.discard(s)
+	}
+
+	if s.session == nil {
+		if s.session, err = p.open(); err != nil {
+			p.failures.Add(1)
+			p.slots <- s
+			return nil, err
+		}
+		p.warm.Add(1)
+	}
+
+	resp, err := s.session.render(ctx, url, req)
+	s.pages++
+	p.renders.Add(1)
+	if err != nil {
+		p.failures.Add(1)
+	}
+
+	if req.Jar != nil || s.pages >= p.recycleAfter || (err != nil && ctx.Err() == nil) {
+		p.recycle(s)
+	} else {
+		p.slots <- s
+	}
+	return resp, err
+}
+
+// acquire waits for a free slot.
+func (p *Pool) acquire(ctx context.Context) (*slot, error) {
+	p.waiting.Add(1)
+	defer p.waiting.Add(-1)
+
+	select {
+	case s := <-p.slots:
+		select {
+		case <-p.done:
+			p.slots <- s
+			return nil, ErrPoolClosed
+		default:
+			return s, nil
+		}
+	case <-p.done:
+		return nil, ErrPoolClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// recycle replaces the browser context of a slot in the background, returning the slot to
+// the pool once the new context is open.
+func (p *Pool) recycle(s *slot) {
+	p.recycled.Add(1)
+	old := s.session
+	s.session, s.pages = nil, 0
+
+	go func() {
+		old.close()
+		p.warm.Add(-1)
+		p.fill(s)
+		p.slots <- s
+	}()
+}
+
+// discard closes the browser context of a slot in the background, leaving the slot empty.
+func (p *Pool) discard(s *slot) {
+	p.recycled.Add(1)
+	p.warm.Add(-1)
+	old := s.session
+	s.session, s.pages = nil, 0
+	go old.close()
+}
+
+// warmUp opens the browser contexts of the free slots.
+func (p *Pool) warmUp() {
+	for range p.size {
+		select {
+		case s := <-p.slots:
+			p.fill(s)
+			p.slots <- s
+		default:
+			return
+		}
+	}
+}
+
+// fill opens the browser context of a slot that has none. A slot that fails to open one is
+// left empty, to be retried by the next render using it.
+func (p *Pool) fill(s *slot) {
+	if s.session != nil {
+		return
+	}
+	sess, err := p.open()
+	if err != nil {
+		if !errors.Is(err, ErrPoolClosed) {
+			p.browser.logger.Warn("failed to open headless browser context", "error", err)
+		}
+		return
+	}
+	s.session = sess
+	p.warm.Add(1)
+}
+
+// Stats returns a snapshot of the pool.
+func (p *Pool) Stats() PoolStats {
+	return PoolStats{
+		Size:     p.size,
+		Warm:     int(p.warm.Load()),
+		Active:   int(p.active.Load()),
+		Waiting:  int(p.waiting.Load()),
+		Renders:  p.renders.Load(),
+		Failures: p.failures.Load(),
+		Recycled: p.recycled.Load(),
+	}
+}
+
+// Close stops the browser and closes the pool's browser contexts, once the renders in progress
+// return. Renders started after Close return ErrPoolClosed.
+func (p *Pool) Close() {
+	p.closeOnce.Do(func() {
+		p.mu.Lock()
+		p.closed = true
+		if p.browserCancel != nil {
+			p.browserCancel()
+		}
+		p.mu.Unlock()
+		close(p.done)
+
+		for range p.size {
+			s := <-p.slots
+			if s.session != nil {
+				s.session.close()
+				p.warm.Add(-1)
+			}
+		}
+	})
+}
+
+// openSession opens a new browser context in the pool's browser, launching the browser if it
+// isn't running.
+func (p *Pool) openSession() (session, error) {
+	browserCtx, err := p.browserContext()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := chromedp.NewContext(browserCtx, chromedp.WithNewBrowserContext())
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to open browser context: %w", err)
+	}
+	return &browserSession{browser: p.browser, ctx: ctx, cancel: cancel}, nil
+}
+
+// browserContext returns the context of the pool's browser, launching it if it isn't running.
+func (p *Pool) browserContext() (context.Context, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	if p.browserCtx != nil && p.browserCtx.Err() == nil {
+		return p.browserCtx, nil
+	}
+	if p.browserCancel != nil {
+		p.browserCancel()
+	}
+
+	allocCtx, allocCancel := p.browser.allocate(context.Background())
+	browserCtx, browserCancel := chromedp.NewContext(allocCtx)
+	if err := chromedp.Run(browserCtx); err != nil {
+		browserCancel()
+		allocCancel()
+		return nil, fmt.Errorf("failed to start browser: %w", err)
+	}
+
+	p.browser.logger.Debug("headless browser started", "pool_size", p.size)
+	p.browserCtx = browserCtx
+	p.browserCancel = func() {
+		browserCancel()
+		allocCancel()
+	}
+	return browserCtx, nil
+}
+
+// browserSession is a browser context of a running browser. Each page renders in a new tab
+// of the context, closed once the page is rendered.
+type browserSession struct {
+	browser *Browser
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// render renders a URL in a new tab, closing it early if ctx is done.
+func (s *browserSession) render(ctx context.Context, url string, req Request) (*Response, error) {
+	tabCtx, tabCancel := chromedp.NewContext(s.ctx)
+	defer tabCancel()
+	stop := context.AfterFunc(ctx, tabCancel)
+	defer stop()
+
+	return s.browser.render(tabCtx, url, req)
+}
+
+// close closes the browser context and its tabs.
+func (s *browserSession) close() {
+	s.cancel()
+}
@@ -0,0 +1,195 @@
+package headless
+
+import (
+	"context"
+	"errors"
+	"net/http/cookiejar"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSession renders pages without a browser, blocking each render on gate when set.
+type fakeSession struct {
+	id     int
+	gate   chan struct{}
+	fail   bool
+	mu     sync.Mutex
+	pages  int
+	closed bool
+}
+
+func (s *fakeSession) render(ctx context.Context, url string, req Request) (*Response, error) {
+	if s.gate != nil {
+		select {
+		case <-s.gate:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pages++
+	if s.fail {
+		return nil, errors.New("render failed")
+	}
+	return &Response{URL: url, StatusCode: 200}, nil
+}
+
+func (s *fakeSession) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+}
+
+// newFakePool creates a pool whose browser contexts are fakeSessions, returning the sessions it
+// opened.
+func newFakePool(t *testing.T, gate chan struct{}, opts ...PoolOption) (*Pool, func() []*fakeSession) {
+	t.Helper()
+	var (
+		mu       sync.Mutex
+		sessions []*fakeSession
+	)
+	p := NewPool(New(), opts...)
+	p.open = func() (session, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		s := &fakeSession{id: len(sessions), gate: gate}
+		sessions = append(sessions, s)
+		return s, nil
+	}
+	t.Cleanup(p.Close)
+	return p, func() []*fakeSession {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*fakeSession(nil), sessions...)
+	}
+}
+
+// TestPoolLimitsConcurrency verifies renders beyond the pool size wait for a free context.
+func TestPoolLimitsConcurrency(t *testing.T) {
+	gate := make(chan struct{})
+	p, _ := newFakePool(t, gate, WithPoolSize(2))
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := p.Render(context.Background(), "https://example.com/")
+			assert.NoError(t, err)
+			assert.Equal(t, 200, resp.StatusCode)
+		}()
+	}
+
+	require.Eventually(t, func() bool {
+		stats := p.Stats()
+		return stats.Active == 2 && stats.Waiting == 1
+	}, time.Second, 5*time.Millisecond)
+	close(gate)
+	wg.Wait()
+
+	stats := p.Stats()
+	assert.Equal(t, 2, stats.Size)
+	assert.Equal(t, 2, stats.Warm)
+	assert.Zero(t, stats.Active)
+	assert.Zero(t, stats.Waiting)
+	assert.Equal(t, int64(3), stats.Renders)
+}
+
+// TestPoolRecyclesContexts verifies a context is closed and replaced after rendering
+// recycle_after pages, or failing to render one.
+func TestPoolRecyclesContexts(t *testing.T) {
+	p, sessions := newFakePool(t, nil, WithPoolSize(1), WithRecycleAfter(2))
+
+	for range 5 {
+		_, err := p.Render(context.Background(), "https://example.com/")
+		require.NoError(t, err)
+	}
+	require.Eventually(t, func() bool { return len(sessions()) == 3 }, time.Second, 5*time.Millisecond)
+
+	opened := sessions()
+	for _, s := range opened[:2] {
+		assert.True(t, s.closed)
+		assert.Equal(t, 2, s.pages)
+	}
+	assert.False(t, opened[2].closed)
+	assert.Equal(t, int64(2), p.Stats().Recycled)
+
+	opened[2].fail = true
+	_, err := p.Render(context.Background(), "https://example.com/")
+	require.Error(t, err)
+	require.Eventually(t, func() bool { return len(sessions()) == 4 }, time.Second, 5*time.Millisecond)
+	assert.True(t, opened[2].closed, "a failed render should recycle its context")
+
+	stats := p.Stats()
+	assert.Equal(t, int64(3), stats.Recycled)
+	assert.Equal(t, int64(1), stats.Failures)
+	assert.Equal(t, 1, stats.Warm)
+}
+
+// TestPoolIsolatesCookieRenders verifies a render with a cookie jar runs in a fresh context
+// that is recycled afterwards.
+func TestPoolIsolatesCookieRenders(t *testing.T) {
+	p, sessions := newFakePool(t, nil, WithPoolSize(1), WithRecycleAfter(10))
+
+	_, err := p.Render(context.Background(), "https://example.com/")
+	require.NoError(t, err)
+
+	ctx := WithRequest(context.Background(), Request{Jar: &cookiejar.Jar{}, Referer: "https://example.com/"})
+	_, err = p.Render(ctx, "https://example.com/account")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(sessions()) == 3 }, time.Second, 5*time.Millisecond)
+
+	opened := sessions()
+	require.Eventually(t, func() bool {
+		opened[0].mu.Lock()
+		defer opened[0].mu.Unlock()
+		return opened[0].closed
+	}, time.Second, 5*time.Millisecond, "the context of an earlier render should be replaced")
+	assert.Equal(t, 1, opened[1].pages)
+	assert.True(t, opened[1].closed, "the cookie render's context should be recycled")
+	assert.Equal(t, int64(2), p.Stats().Recycled)
+}
+
+// TestPoolWaitCanceled verifies a render waiting for a context returns when its context is done.
+func TestPoolWaitCanceled(t *testing.T) {
+	gate := make(chan struct{})
+	p, _ := newFakePool(t, gate, WithPoolSize(1))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = p.Render(context.Background(), "https://example.com/")
+	}()
+	require.Eventually(t, func() bool { return p.Stats().Active == 1 }, time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := p.Render(ctx, "https://example.com/")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(gate)
+	<-done
+}
+
+// TestPoolClose verifies closing the pool closes its contexts and rejects further renders.
+func TestPoolClose(t *testing.T) {
+	p, sessions := newFakePool(t, nil, WithPoolSize(2))
+
+	_, err := p.Render(context.Background(), "https://example.com/")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return p.Stats().Warm == 2 }, time.Second, 5*time.Millisecond)
+
+	p.Close()
+	for _, s := range sessions() {
+		assert.True(t, s.closed)
+	}
+	assert.Zero(t, p.Stats().Warm)
+
+	_, err = p.Render(context.Background(), "https://example.com/")
+	assert.ErrorIs(t, err, ErrPoolClosed)
+}
@@ -0,0 +1,78 @@
+package hreflang
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// XDefault is the hreflang of the variant served to languages no other variant matches.
+const XDefault = "x-default"
+
+// Alternate is a language variant of a page, declared with an hreflang link.
+type Alternate struct {
+	Lang string `json:"lang"`
+	URL  string `json:"url"`
+}
+
+// Add appends the variant for lang at url to alternates, unless lang is not a valid language
+// tag or x-default, or a variant for lang is already listed.
+func Add(alternates []Alternate, lang, url string) []Alternate {
+	lang = strings.TrimSpace(lang)
+	url = strings.TrimSpace(url)
+	if lang == "" || url == "" {
+		return alternates
+	}
+
+	if strings.EqualFold(lang, XDefault) {
+		lang = XDefault
+	} else {
+		tag, err := language.Parse(lang)
+		if err != nil {
+			return alternates
+		}
+		lang = tag.String()
+	}
+
+	for _, alt := range alternates {
+		if alt.Lang == lang {
+			return alternates
+		}
+	}
+	return append(alternates, Alternate{Lang: lang, URL: url})
+}
+
+// Select returns the variant that best matches lang, a BCP 47 tag such as "de" or "pt-BR".
+// A variant in the same language but another region is accepted when there is no exact match.
+// It reports false when no variant is in the requested language; x-default is never selected.
+func Select(alternates []Alternate, lang string) (Alternate, bool) {
+	want, err := language.Parse(lang)
+	if err != nil {
+		return Alternate{}, false
+	}
+
+	var (
+		tags       []language.Tag
+		candidates []Alternate
+	)
+	for _, alt := range alternates {
+		if alt.Lang == XDefault {
+			continue
+		}
+		tag, err := language.Parse(alt.Lang)
+		if err != nil {
+			continue
+		}
+		tags = append(tags, tag)
+		candidates = append(candidates, alt)
+	}
+	if len(tags) == 0 {
+		return Alternate{}, false
+	}
+
+	_, index, confidence := language.NewMatcher(tags).Match(want)
+	if confidence == language.No {
+		return Alternate{}, false
+	}
+	return candidates[index], true
+}
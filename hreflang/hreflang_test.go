@@ -0,0 +1,57 @@
+package hreflang
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAdd verifies languages are normalized and invalid or duplicate variants are skipped.
+func TestAdd(t *testing.T) {
+	var alternates []Alternate
+	alternates = Add(alternates, "en-us", "https://example.com/en-us/")
+	alternates = Add(alternates, "X-Default", "https://example.com/")
+	alternates = Add(alternates, "en-US", "https://example.com/duplicate/")
+	alternates = Add(alternates, "not a language", "https://example.com/invalid/")
+	alternates = Add(alternates, "de", "")
+
+	assert.Equal(t, []Alternate{
+		{Lang: "en-US", URL: "https://example.com/en-us/"},
+		{Lang: "x-default", URL: "https://example.com/"},
+	}, alternates)
+}
+
+// TestSelect verifies the closest variant in the requested language is selected.
+func TestSelect(t *testing.T) {
+	alternates := []Alternate{
+		{Lang: "x-default", URL: "https://example.com/"},
+		{Lang: "en-US", URL: "https://example.com/en-us/"},
+		{Lang: "en-GB", URL: "https://example.com/en-gb/"},
+		{Lang: "de", URL: "https://example.com/de/"},
+		{Lang: "pt-BR", URL: "https://example.com/pt-br/"},
+	}
+
+	tests := []struct {
+		lang string
+		want string
+		ok   bool
+	}{
+		{"en-GB", "https://example.com/en-gb/", true},
+		{"de", "https://example.com/de/", true},
+		{"de-AT", "https://example.com/de/", true},
+		{"pt", "https://example.com/pt-br/", true},
+		{"fr", "", false},
+		{"invalid language", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			alt, ok := Select(alternates, tt.lang)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, alt.URL)
+		})
+	}
+
+	_, ok := Select(nil, "en")
+	assert.False(t, ok)
+}
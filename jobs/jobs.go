@@ -0,0 +1,434 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/joeychilson/websurfer/ratelimit"
+)
+
+const (
+	// StatusQueued means the job is waiting for a worker.
+	StatusQueued = "queued"
+	// StatusRunning means a worker holds the job's lease.
+	StatusRunning = "running"
+	// StatusCompleted means the job finished and its result is stored.
+	StatusCompleted = "completed"
+	// StatusFailed means the job finished with an error.
+	StatusFailed = "failed"
+	// StatusCanceled means the job was canceled before it finished.
+	StatusCanceled = "canceled"
+)
+
+var (
+	// ErrNotFound is returned when a job does not exist or its result has expired.
+	ErrNotFound = errors.New("job not found")
+	// ErrFinished is returned when canceling a job that has already finished.
+	ErrFinished = errors.New("job already finished")
+	// ErrLeaseLost is returned when a worker no longer holds a job, because it was canceled or
+	// its lease expired and the job was handed to another worker.
+	ErrLeaseLost = errors.New("job lease lost")
+)
+
+var (
+	// claimScript pops the next queued job, marks it running, and records its lease deadline.
+	// Jobs canceled while queued are skipped.
+	claimScript = redis.NewScript(`
+while true do
+  local id = redis.call('RPOP', KEYS[1])
+  if not id then
+    return false
+  end
+  local key = ARGV[1] .. id
+  if redis.call('HGET', key, 'status') == 'queued' then
+    redis.call('HINCRBY', key, 'attempts', 1)
+    redis.call('HSET', key, 'status', 'running', 'updated_at', ARGV[2])
+    redis.call('ZADD', KEYS[2], ARGV[3], id)
+    return id
+  end
+end
+`)
+
+	// heartbeatScript extends a running job's lease if the caller still holds it.
+	heartbeatScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'status') ~= 'running' or redis.call('HGET', KEYS[1], 'attempts') ~= ARGV[2] then
+  return 0
+end
+redis.call('ZADD', KEYS[2], ARGV[3], ARGV[1])
+redis.call('HSET', KEYS[1], 'updated_at', ARGV[4])
+return 1
+`)
+
+	// progressScript records a running job's progress if the caller still holds its lease.
+	progressScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'status') ~= 'running' or redis.call('HGET', KEYS[1], 'attempts') ~= ARGV[1] then
+  return 0
+end
+redis.call('HSET', KEYS[1], 'progress', ARGV[2])
+return 1
+`)
+
+	// finishScript records a running job's outcome if the caller still holds its lease.
+	finishScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'status') ~= 'running' or redis.call('HGET', KEYS[1], 'attempts') ~= ARGV[2] then
+  return 0
+end
+redis.call('HSET', KEYS[1], 'status', ARGV[3], ARGV[4], ARGV[5], 'updated_at', ARGV[6])
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[7])
+return 1
+`)
+
+	// cancelScript cancels a queued or running job.
+	cancelScript = redis.NewScript(`
+local status = redis.call('HGET', KEYS[1], 'status')
+if not status then
+  return -1
+end
+if status ~= 'queued' and status ~= 'running' then
+  return 0
+end
+redis.call('HSET', KEYS[1], 'status', 'canceled', 'updated_at', ARGV[2])
+redis.call('LREM', KEYS[2], 0, ARGV[1])
+redis.call('ZREM', KEYS[3], ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return 1
+`)
+
+	// requeueScript returns jobs with expired leases to the front of the queue, or fails them
+	// once they have used up their attempts.
+	requeueScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[2])
+local count = 0
+for _, id in ipairs(ids) do
+  redis.call('ZREM', KEYS[2], id)
+  local key = ARGV[1] .. id
+  if redis.call('HGET', key, 'status') == 'running' then
+    if tonumber(redis.call('HGET', key, 'attempts')) >= tonumber(ARGV[3]) then
+      redis.call('HSET', key, 'status', 'failed', 'error', 'lease expired after maximum attempts', 'updated_at', ARGV[2])
+      redis.call('PEXPIRE', key, ARGV[4])
+    else
+      redis.call('HSET', key, 'status', 'queued', 'updated_at', ARGV[2])
+      redis.call('RPUSH', KEYS[1], id)
+    end
+    count = count + 1
+  end
+end
+return count
+`)
+)
+
+// Job is a unit of work stored in the queue.
+type Job struct {
+	ID        string
+	Status    string
+	Payload   json.RawMessage
+	Result    json.RawMessage
+	Error     string
+	Attempts  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Progress is the latest progress report of a running job, kept once it finishes.
+	Progress json.RawMessage
+	// Caller is who enqueued the job, taken from the enqueuing context, so its fetches share
+	// domain limits as that caller's rather than as anonymous background work.
+	Caller ratelimit.Caller
+}
+
+// Config holds job queue configuration.
+type Config struct {
+	Prefix string
+	// LeaseTimeout is how long a claimed job may go without a heartbeat before it is requeued.
+	LeaseTimeout time.Duration
+	// ResultTTL is how long finished jobs are kept for polling.
+	ResultTTL time.Duration
+	// MaxAttempts is how many times a job may be claimed before an expired lease fails it.
+	MaxAttempts int
+	// PollInterval is how long an idle worker waits before checking the queue again.
+	PollInterval time.Duration
+	// IsLeader, when set, limits requeueing expired leases to the instance for which it returns
+	// true, so a cluster sweeps once per poll rather than once per worker.
+	IsLeader func() bool
+	Logger   *slog.Logger
+}
+
+// DefaultConfig returns a job queue config with sensible defaults.
+func DefaultConfig() Config {
+	return Config{
+		Prefix:       "websurfer:jobs:",
+		LeaseTimeout: 30 * time.Second,
+		ResultTTL:    24 * time.Hour,
+		MaxAttempts:  3,
+		PollInterval: time.Second,
+		Logger:       slog.Default(),
+	}
+}
+
+// applyDefaults fills in zero values with defaults.
+func applyDefaults(config Config) Config {
+	defaults := DefaultConfig()
+	if config.Prefix == "" {
+		config.Prefix = defaults.Prefix
+	}
+	if config.LeaseTimeout <= 0 {
+		config.LeaseTimeout = defaults.LeaseTimeout
+	}
+	if config.ResultTTL <= 0 {
+		config.ResultTTL = defaults.ResultTTL
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.Logger == nil {
+		config.Logger = defaults.Logger
+	}
+	return config
+}
+
+// Queue is a Redis-backed job queue with leases, heartbeats, and result storage.
+// Because all state lives in Redis, jobs survive server restarts: a job whose worker died
+// is requeued once its lease expires.
+type Queue struct {
+	client *redis.Client
+	config Config
+	now    func() time.Time
+}
+
+// New creates a new job queue with the provided client and configuration.
+func New(client *redis.Client, config Config) *Queue {
+	return &Queue{
+		client: client,
+		config: applyDefaults(config),
+		now:    time.Now,
+	}
+}
+
+// makeJobKey creates the Redis key holding a job's fields.
+func (q *Queue) makeJobKey(id string) string {
+	return q.config.Prefix + "job:" + id
+}
+
+// makeQueueKey creates the Redis key of the pending job list.
+func (q *Queue) makeQueueKey() string {
+	return q.config.Prefix + "queue"
+}
+
+// makeLeasesKey creates the Redis key of the sorted set of running jobs by lease deadline.
+func (q *Queue) makeLeasesKey() string {
+	return q.config.Prefix + "leases"
+}
+
+// Enqueue stores a new job with the given payload and adds it to the queue, recording the
+// caller set on ctx with ratelimit.WithCaller as the job's caller.
+func (q *Queue) Enqueue(ctx context.Context, payload json.RawMessage) (*Job, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job id: %w", err)
+	}
+
+	now := q.now()
+	job := &Job{
+		ID:        id,
+		Status:    StatusQueued,
+		Payload:   payload,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Caller:    ratelimit.CallerFromContext(ctx),
+	}
+
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, q.makeJobKey(id),
+			"id", id,
+			"status", StatusQueued,
+			"payload", string(payload),
+			"attempts", 0,
+			"created_at", now.UnixMilli(),
+			"updated_at", now.UnixMilli(),
+			"caller", job.Caller.ID,
+			"caller_weight", strconv.FormatFloat(job.Caller.Weight, 'g', -1, 64),
+		)
+		pipe.LPush(ctx, q.makeQueueKey(), id)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("redis enqueue failed: %w", err)
+	}
+
+	return job, nil
+}
+
+// Get returns the job with the given ID.
+func (q *Queue) Get(ctx context.Context, id string) (*Job, error) {
+	fields, err := q.client.HGetAll(ctx, q.makeJobKey(id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis get failed: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return parseJob(fields), nil
+}
+
+// Claim takes the next queued job and leases it to the caller. It returns nil if the queue is empty.
+func (q *Queue) Claim(ctx context.Context) (*Job, error) {
+	now := q.now()
+	keys := []string{q.makeQueueKey(), q.makeLeasesKey()}
+	args := []any{q.makeJobKey(""), now.UnixMilli(), now.Add(q.config.LeaseTimeout).UnixMilli()}
+
+	id, err := claimScript.Run(ctx, q.client, keys, args...).Text()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis claim failed: %w", err)
+	}
+
+	return q.Get(ctx, id)
+}
+
+// Heartbeat extends the lease on a running job. It returns ErrLeaseLost if the caller no
+// longer holds the job.
+func (q *Queue) Heartbeat(ctx context.Context, job *Job) error {
+	now := q.now()
+	keys := []string{q.makeJobKey(job.ID), q.makeLeasesKey()}
+	args := []any{job.ID, job.Attempts, now.Add(q.config.LeaseTimeout).UnixMilli(), now.UnixMilli()}
+
+	held, err := heartbeatScript.Run(ctx, q.client, keys, args...).Int()
+	if err != nil {
+		return fmt.Errorf("redis heartbeat failed: %w", err)
+	}
+	if held == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// SetProgress records a progress report on a running job, for status polls while it runs. It
+// returns ErrLeaseLost if the caller no longer holds the job.
+func (q *Queue) SetProgress(ctx context.Context, job *Job, progress json.RawMessage) error {
+	keys := []string{q.makeJobKey(job.ID)}
+	args := []any{job.Attempts, string(progress)}
+
+	held, err := progressScript.Run(ctx, q.client, keys, args...).Int()
+	if err != nil {
+		return fmt.Errorf("redis progress failed: %w", err)
+	}
+	if held == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Complete stores the result of a running job and marks it completed.
+func (q *Queue) Complete(ctx context.Context, job *Job, result json.RawMessage) error {
+	return q.finish(ctx, job, StatusCompleted, "result", string(result))
+}
+
+// Fail records the error of a running job and marks it failed.
+func (q *Queue) Fail(ctx context.Context, job *Job, jobErr error) error {
+	return q.finish(ctx, job, StatusFailed, "error", jobErr.Error())
+}
+
+// finish records a running job's outcome, returning ErrLeaseLost if the caller no longer holds it.
+func (q *Queue) finish(ctx context.Context, job *Job, status, field, value string) error {
+	keys := []string{q.makeJobKey(job.ID), q.makeLeasesKey()}
+	args := []any{job.ID, job.Attempts, status, field, value, q.now().UnixMilli(), q.config.ResultTTL.Milliseconds()}
+
+	held, err := finishScript.Run(ctx, q.client, keys, args...).Int()
+	if err != nil {
+		return fmt.Errorf("redis finish failed: %w", err)
+	}
+	if held == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Cancel cancels a queued or running job. A running job's worker notices on its next heartbeat.
+func (q *Queue) Cancel(ctx context.Context, id string) (*Job, error) {
+	keys := []string{q.makeJobKey(id), q.makeQueueKey(), q.makeLeasesKey()}
+	args := []any{id, q.now().UnixMilli(), q.config.ResultTTL.Milliseconds()}
+
+	result, err := cancelScript.Run(ctx, q.client, keys, args...).Int()
+	if err != nil {
+		return nil, fmt.Errorf("redis cancel failed: %w", err)
+	}
+
+	switch result {
+	case -1:
+		return nil, ErrNotFound
+	case 0:
+		return nil, ErrFinished
+	}
+
+	return q.Get(ctx, id)
+}
+
+// RequeueExpired returns running jobs whose leases have expired to the queue and reports how
+// many were recovered.
+func (q *Queue) RequeueExpired(ctx context.Context) (int, error) {
+	keys := []string{q.makeQueueKey(), q.makeLeasesKey()}
+	args := []any{q.makeJobKey(""), q.now().UnixMilli(), q.config.MaxAttempts, q.config.ResultTTL.Milliseconds()}
+
+	count, err := requeueScript.Run(ctx, q.client, keys, args...).Int()
+	if err != nil {
+		return 0, fmt.Errorf("redis requeue failed: %w", err)
+	}
+	return count, nil
+}
+
+// parseJob builds a Job from its Redis hash fields.
+func parseJob(fields map[string]string) *Job {
+	attempts, _ := strconv.Atoi(fields["attempts"])
+	callerWeight, _ := strconv.ParseFloat(fields["caller_weight"], 64)
+	job := &Job{
+		ID:        fields["id"],
+		Status:    fields["status"],
+		Error:     fields["error"],
+		Attempts:  attempts,
+		CreatedAt: parseMillis(fields["created_at"]),
+		UpdatedAt: parseMillis(fields["updated_at"]),
+		Caller:    ratelimit.Caller{ID: fields["caller"], Weight: callerWeight},
+	}
+	if payload := fields["payload"]; payload != "" {
+		job.Payload = json.RawMessage(payload)
+	}
+	if result := fields["result"]; result != "" {
+		job.Result = json.RawMessage(result)
+	}
+	if progress := fields["progress"]; progress != "" {
+		job.Progress = json.RawMessage(progress)
+	}
+	return job
+}
+
+// parseMillis converts a Unix millisecond string to a time.
+func parseMillis(s string) time.Time {
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// newID returns a random job identifier.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
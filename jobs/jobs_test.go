@@ -0,0 +1,254 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joeychilson/websurfer/ratelimit"
+)
+
+func setupTestQueue(t *testing.T, config Config) *Queue {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	})
+
+	return New(client, config)
+}
+
+// TestQueueLifecycle verifies a job moves from queued to running to completed with its result stored.
+func TestQueueLifecycle(t *testing.T) {
+	q := setupTestQueue(t, Config{})
+	ctx := context.Background()
+
+	job, err := q.Enqueue(ctx, json.RawMessage(`{"url":"https://example.com"}`))
+	require.NoError(t, err)
+	assert.Equal(t, StatusQueued, job.Status)
+
+	claimed, err := q.Claim(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, job.ID, claimed.ID)
+	assert.Equal(t, StatusRunning, claimed.Status)
+	assert.Equal(t, 1, claimed.Attempts)
+	assert.JSONEq(t, `{"url":"https://example.com"}`, string(claimed.Payload))
+
+	require.NoError(t, q.Heartbeat(ctx, claimed))
+	require.NoError(t, q.Complete(ctx, claimed, json.RawMessage(`{"content":"hello"}`)))
+
+	stored, err := q.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCompleted, stored.Status)
+	assert.JSONEq(t, `{"content":"hello"}`, string(stored.Result))
+
+	next, err := q.Claim(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, next, "queue should be empty")
+}
+
+// TestQueueCaller verifies a job keeps the caller it was enqueued for.
+func TestQueueCaller(t *testing.T) {
+	q := setupTestQueue(t, Config{})
+	ctx := ratelimit.WithCaller(context.Background(), ratelimit.Caller{ID: "crawler", Weight: 2.5})
+
+	job, err := q.Enqueue(ctx, json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, ratelimit.Caller{ID: "crawler", Weight: 2.5}, job.Caller)
+
+	claimed, err := q.Claim(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, ratelimit.Caller{ID: "crawler", Weight: 2.5}, claimed.Caller)
+}
+
+// TestQueueSetProgress verifies progress is recorded on running jobs only, and kept once they finish.
+func TestQueueSetProgress(t *testing.T) {
+	q := setupTestQueue(t, Config{})
+	ctx := context.Background()
+
+	job, err := q.Enqueue(ctx, json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.ErrorIs(t, q.SetProgress(ctx, job, json.RawMessage(`{"fetched":1}`)), ErrLeaseLost)
+
+	claimed, err := q.Claim(ctx)
+	require.NoError(t, err)
+	require.NoError(t, q.SetProgress(ctx, claimed, json.RawMessage(`{"fetched":1}`)))
+	require.NoError(t, q.SetProgress(ctx, claimed, json.RawMessage(`{"fetched":2}`)))
+
+	stored, err := q.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"fetched":2}`, string(stored.Progress))
+
+	require.NoError(t, q.Complete(ctx, claimed, json.RawMessage(`{}`)))
+	assert.ErrorIs(t, q.SetProgress(ctx, claimed, json.RawMessage(`{"fetched":3}`)), ErrLeaseLost)
+
+	stored, err = q.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"fetched":2}`, string(stored.Progress))
+}
+
+// TestQueueFail verifies failed jobs record their error.
+func TestQueueFail(t *testing.T) {
+	q := setupTestQueue(t, Config{})
+	ctx := context.Background()
+
+	job, err := q.Enqueue(ctx, json.RawMessage(`{}`))
+	require.NoError(t, err)
+	claimed, err := q.Claim(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, q.Fail(ctx, claimed, errors.New("connection refused")))
+
+	stored, err := q.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, stored.Status)
+	assert.Equal(t, "connection refused", stored.Error)
+}
+
+// TestQueueGetNotFound verifies unknown jobs return ErrNotFound.
+func TestQueueGetNotFound(t *testing.T) {
+	q := setupTestQueue(t, Config{})
+
+	_, err := q.Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestQueueCancel verifies canceling queued, running, and finished jobs.
+func TestQueueCancel(t *testing.T) {
+	q := setupTestQueue(t, Config{})
+	ctx := context.Background()
+
+	queued, err := q.Enqueue(ctx, json.RawMessage(`{}`))
+	require.NoError(t, err)
+	canceled, err := q.Cancel(ctx, queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCanceled, canceled.Status)
+
+	claimed, err := q.Claim(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, claimed, "canceled job should not be claimed")
+
+	running, err := q.Enqueue(ctx, json.RawMessage(`{}`))
+	require.NoError(t, err)
+	claimed, err = q.Claim(ctx)
+	require.NoError(t, err)
+	_, err = q.Cancel(ctx, running.ID)
+	require.NoError(t, err)
+	assert.ErrorIs(t, q.Heartbeat(ctx, claimed), ErrLeaseLost, "worker should lose the lease")
+	assert.ErrorIs(t, q.Complete(ctx, claimed, nil), ErrLeaseLost)
+
+	_, err = q.Cancel(ctx, running.ID)
+	assert.ErrorIs(t, err, ErrFinished)
+
+	_, err = q.Cancel(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestQueueRequeueExpired verifies jobs with expired leases are requeued, then failed after max attempts.
+func TestQueueRequeueExpired(t *testing.T) {
+	q := setupTestQueue(t, Config{LeaseTimeout: time.Minute, MaxAttempts: 2})
+	ctx := context.Background()
+	now := time.Now()
+	q.now = func() time.Time { return now }
+
+	job, err := q.Enqueue(ctx, json.RawMessage(`{}`))
+	require.NoError(t, err)
+	first, err := q.Claim(ctx)
+	require.NoError(t, err)
+
+	count, err := q.RequeueExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count, "lease has not expired yet")
+
+	now = now.Add(2 * time.Minute)
+	count, err = q.RequeueExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	second, err := q.Claim(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, second)
+	assert.Equal(t, job.ID, second.ID)
+	assert.Equal(t, 2, second.Attempts)
+	assert.ErrorIs(t, q.Complete(ctx, first, nil), ErrLeaseLost, "stale worker should not record a result")
+
+	now = now.Add(2 * time.Minute)
+	_, err = q.RequeueExpired(ctx)
+	require.NoError(t, err)
+
+	stored, err := q.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, stored.Status)
+	assert.Contains(t, stored.Error, "lease expired")
+}
+
+// TestQueueWork verifies workers run claimed jobs and store their results.
+func TestQueueWork(t *testing.T) {
+	q := setupTestQueue(t, Config{PollInterval: 10 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ok, err := q.Enqueue(ctx, json.RawMessage(`"ok"`))
+	require.NoError(t, err)
+	bad, err := q.Enqueue(ctx, json.RawMessage(`"bad"`))
+	require.NoError(t, err)
+
+	go q.Work(ctx, func(ctx context.Context, job *Job) (json.RawMessage, error) {
+		if string(job.Payload) == `"bad"` {
+			return nil, errors.New("boom")
+		}
+		return json.RawMessage(`{"done":true}`), nil
+	})
+
+	assert.Eventually(t, func() bool {
+		okJob, _ := q.Get(ctx, ok.ID)
+		badJob, _ := q.Get(ctx, bad.ID)
+		return okJob != nil && okJob.Status == StatusCompleted && badJob != nil && badJob.Status == StatusFailed
+	}, 2*time.Second, 10*time.Millisecond)
+
+	badJob, err := q.Get(ctx, bad.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "boom", badJob.Error)
+}
+
+// TestQueueWorkFollowerSkipsRequeue verifies only the leader requeues jobs with expired leases.
+func TestQueueWorkFollowerSkipsRequeue(t *testing.T) {
+	q := setupTestQueue(t, Config{LeaseTimeout: time.Minute, PollInterval: 10 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now()
+	q.now = func() time.Time { return now }
+
+	job, err := q.Enqueue(ctx, json.RawMessage(`{}`))
+	require.NoError(t, err)
+	_, err = q.Claim(ctx)
+	require.NoError(t, err)
+	now = now.Add(2 * time.Minute)
+
+	follower := New(q.client, Config{PollInterval: 10 * time.Millisecond, IsLeader: func() bool { return false }})
+	follower.now = q.now
+	followerCtx, stopFollower := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer stopFollower()
+	follower.Work(followerCtx, func(ctx context.Context, job *Job) (json.RawMessage, error) {
+		return nil, nil
+	})
+
+	stored, err := q.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusRunning, stored.Status, "follower should not requeue")
+
+	count, err := q.RequeueExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "leader should requeue")
+}
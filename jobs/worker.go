@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Handler runs a job and returns its JSON result.
+type Handler func(ctx context.Context, job *Job) (json.RawMessage, error)
+
+// Work claims and runs jobs until ctx is canceled. Several workers may run concurrently, in
+// this process or others sharing the same Redis. Jobs interrupted by shutdown keep their lease
+// and are requeued for another worker once it expires, by the leader if IsLeader is set.
+func (q *Queue) Work(ctx context.Context, handler Handler) {
+	logger := q.config.Logger
+
+	for ctx.Err() == nil {
+		if q.config.IsLeader == nil || q.config.IsLeader() {
+			if n, err := q.RequeueExpired(ctx); err != nil {
+				logger.Error("failed to requeue expired jobs", "error", err)
+			} else if n > 0 {
+				logger.Info("requeued jobs with expired leases", "count", n)
+			}
+		}
+
+		job, err := q.Claim(ctx)
+		if err != nil {
+			logger.Error("failed to claim job", "error", err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(q.config.PollInterval):
+			}
+			continue
+		}
+
+		q.run(ctx, job, handler)
+	}
+}
+
+// run executes a single claimed job, heartbeating its lease until the handler returns.
+func (q *Queue) run(ctx context.Context, job *Job, handler Handler) {
+	logger := q.config.Logger.With("job_id", job.ID, "attempt", job.Attempts)
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	defer close(done)
+	go q.heartbeat(jobCtx, job, cancel, done)
+
+	result, err := handler(jobCtx, job)
+
+	if ctx.Err() != nil {
+		logger.Info("job interrupted by shutdown, leaving lease to expire")
+		return
+	}
+
+	// Use a fresh context so the outcome is recorded even if the handler's context was
+	// canceled because the job was canceled.
+	finishCtx, finishCancel := context.WithTimeout(context.WithoutCancel(ctx), q.config.LeaseTimeout)
+	defer finishCancel()
+
+	if err != nil {
+		err = q.Fail(finishCtx, job, err)
+	} else {
+		err = q.Complete(finishCtx, job, result)
+	}
+
+	switch {
+	case errors.Is(err, ErrLeaseLost):
+		logger.Info("job was canceled or reassigned before it finished")
+	case err != nil:
+		logger.Error("failed to record job outcome", "error", err)
+	}
+}
+
+// heartbeat extends the job's lease until done is closed, canceling the job if the lease is lost.
+func (q *Queue) heartbeat(ctx context.Context, job *Job, cancel context.CancelFunc, done <-chan struct{}) {
+	ticker := time.NewTicker(q.config.LeaseTimeout / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := q.Heartbeat(ctx, job)
+			if errors.Is(err, ErrLeaseLost) {
+				q.config.Logger.Info("job lease lost, stopping", "job_id", job.ID)
+				cancel()
+				return
+			}
+			if err != nil {
+				q.config.Logger.Error("job heartbeat failed", "job_id", job.ID, "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package langdetect
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// sampleSize is how many bytes of the text are examined.
+	sampleSize = 16 * 1024
+	// minLetters is how many letters a text needs before its language is guessed.
+	minLetters = 20
+	// minStopwords is how many stopwords of a language a Latin-script text needs to be detected.
+	minStopwords = 3
+)
+
+// stopwords holds frequent short words of each Latin-script language. Words shared by several
+// languages count toward each of them, so they only break ties.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "in", "is", "that", "for", "it", "with", "as", "was", "on", "are", "be", "this", "by", "you", "not", "or", "have", "from", "which", "but", "they", "can", "will"},
+	"de": {"der", "die", "und", "in", "den", "von", "zu", "das", "mit", "sich", "des", "auf", "für", "ist", "im", "dem", "nicht", "ein", "eine", "als", "auch", "es", "an", "werden", "aus", "er", "hat", "dass", "sie", "nach", "wird", "bei", "oder", "wir"},
+	"fr": {"le", "la", "les", "de", "des", "et", "en", "du", "un", "une", "est", "que", "qui", "dans", "pour", "pas", "au", "sur", "par", "plus", "ne", "se", "avec", "il", "sont", "nous", "vous", "aux", "ce", "cette"},
+	"es": {"el", "la", "de", "que", "y", "en", "los", "del", "se", "las", "por", "un", "con", "no", "una", "su", "para", "es", "al", "lo", "como", "más", "pero", "sus", "le", "ha", "este", "está", "muy", "también"},
+	"it": {"il", "di", "che", "la", "e", "per", "un", "in", "del", "della", "non", "una", "sono", "le", "si", "da", "con", "gli", "al", "dei", "alla", "anche", "più", "questo", "ha", "nel", "come", "delle", "ma", "essere"},
+	"pt": {"de", "que", "e", "do", "da", "em", "um", "para", "com", "não", "uma", "os", "no", "se", "na", "por", "mais", "as", "dos", "como", "mas", "ao", "ele", "das", "à", "seu", "sua", "ou", "quando", "também", "são"},
+	"nl": {"de", "het", "een", "van", "en", "in", "is", "dat", "op", "te", "zijn", "voor", "met", "die", "niet", "aan", "er", "om", "ook", "als", "bij", "of", "door", "maar", "worden", "naar", "dan", "wordt", "kan", "heeft"},
+	"sv": {"och", "att", "det", "som", "en", "på", "är", "av", "för", "med", "till", "den", "inte", "har", "de", "om", "ett", "var", "jag", "men", "från", "kan", "så", "vi", "också", "eller", "när"},
+	"da": {"og", "at", "det", "en", "den", "til", "er", "som", "på", "de", "med", "han", "af", "for", "ikke", "der", "var", "mig", "sig", "men", "et", "har", "om", "vi", "fra", "også", "kan", "eller"},
+	"pl": {"i", "w", "się", "na", "nie", "z", "do", "to", "że", "jest", "o", "jak", "ale", "po", "co", "tak", "za", "od", "przez", "jego", "dla", "jej", "są", "lub", "oraz", "tylko", "czy", "może"},
+	"tr": {"ve", "bir", "bu", "da", "de", "için", "ile", "olarak", "çok", "daha", "olan", "gibi", "en", "ne", "ama", "kadar", "sonra", "her", "değil", "var", "veya", "mi", "ya", "olduğu", "ise"},
+	"id": {"yang", "dan", "di", "ini", "itu", "dengan", "untuk", "tidak", "dari", "dalam", "akan", "pada", "juga", "ke", "karena", "ada", "atau", "oleh", "saya", "mereka", "bisa", "sudah", "adalah", "kami"},
+}
+
+// stopwordIndex maps each stopword to the languages it belongs to.
+var stopwordIndex = buildStopwordIndex()
+
+// buildStopwordIndex inverts stopwords.
+func buildStopwordIndex() map[string][]string {
+	index := make(map[string][]string)
+	for lang, words := range stopwords {
+		for _, word := range words {
+			index[word] = append(index[word], lang)
+		}
+	}
+	return index
+}
+
+// Detect returns the ISO 639-1 code of the language text is written in, such as "en" or "ja",
+// or "" if it cannot tell. Scripts used by a single language, such as Hangul or Greek, decide
+// the language directly. Latin-script languages are told apart by how often each language's
+// most frequent words occur, so short texts are left undetected.
+func Detect(text string) string {
+	if len(text) > sampleSize {
+		text = text[:sampleSize]
+	}
+
+	var letters, latin, han, kana, hangul, cyrillic, ukrainian, arabic, persian, greek, hebrew, thai, devanagari int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+			if strings.ContainsRune("іїєґІЇЄҐ", r) {
+				ukrainian++
+			}
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+			if strings.ContainsRune("پچژگ", r) {
+				persian++
+			}
+		case unicode.Is(unicode.Greek, r):
+			greek++
+		case unicode.Is(unicode.Hebrew, r):
+			hebrew++
+		case unicode.Is(unicode.Thai, r):
+			thai++
+		case unicode.Is(unicode.Devanagari, r):
+			devanagari++
+		}
+	}
+	if letters < minLetters {
+		return ""
+	}
+
+	// Pages in other scripts often contain Latin navigation or code, so a script decides the
+	// language once it makes up a third of the letters.
+	dominant := func(n int) bool { return n*3 >= letters }
+	switch {
+	case dominant(kana + han):
+		if kana*10 >= han {
+			return "ja"
+		}
+		return "zh"
+	case dominant(hangul):
+		return "ko"
+	case dominant(cyrillic):
+		if ukrainian*50 >= cyrillic {
+			return "uk"
+		}
+		return "ru"
+	case dominant(arabic):
+		if persian*50 >= arabic {
+			return "fa"
+		}
+		return "ar"
+	case dominant(greek):
+		return "el"
+	case dominant(hebrew):
+		return "he"
+	case dominant(thai):
+		return "th"
+	case dominant(devanagari):
+		return "hi"
+	case dominant(latin):
+		return detectLatin(text)
+	}
+	return ""
+}
+
+// detectLatin returns the Latin-script language whose stopwords occur most often in text.
+func detectLatin(text string) string {
+	scores := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, word := range words {
+		for _, lang := range stopwordIndex[word] {
+			scores[lang]++
+		}
+	}
+
+	best, bestScore, tied := "", 0, false
+	for lang, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, tied = lang, score, false
+		case score == bestScore:
+			tied = true
+		}
+	}
+	if bestScore < minStopwords || tied {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,38 @@
+package langdetect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDetect verifies languages are detected from their script or most frequent words.
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"english", "The quick brown fox jumps over the lazy dog, and it is not the first time that this has happened.", "en"},
+		{"german", "Die Katze sitzt auf dem Tisch und es ist nicht das erste Mal, dass sie sich dort ausruht.", "de"},
+		{"french", "Le chat est sur la table et ce n'est pas la première fois qu'il se repose dans la cuisine.", "fr"},
+		{"spanish", "El gato está en la mesa y no es la primera vez que se queda dormido en la cocina de los vecinos.", "es"},
+		{"portuguese", "O gato está em cima da mesa e não é a primeira vez que ele dorme na cozinha dos vizinhos.", "pt"},
+		{"dutch", "De kat zit op de tafel en het is niet de eerste keer dat hij daar in de keuken slaapt.", "nl"},
+		{"japanese", "これは日本語のページです。猫がテーブルの上で寝ています。", "ja"},
+		{"chinese", "这是一个中文页面。猫正在桌子上睡觉，这不是第一次了。", "zh"},
+		{"korean", "이것은 한국어 페이지입니다. 고양이가 테이블 위에서 자고 있습니다.", "ko"},
+		{"russian", "Это русская страница. Кошка спит на столе, и это не в первый раз.", "ru"},
+		{"ukrainian", "Це українська сторінка. Кішка спить на столі, і це не вперше її бачать тут.", "uk"},
+		{"greek", "Αυτή είναι μια ελληνική σελίδα. Η γάτα κοιμάται στο τραπέζι.", "el"},
+		{"mixed script", "Документация API: используйте endpoint /v1/fetch для загрузки страниц и получения содержимого.", "ru"},
+		{"too short", "Hello", ""},
+		{"no stopwords", "Lorem ipsum dolor sit amet consectetur adipiscing elit sed eiusmod tempor", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Detect(tt.text))
+		})
+	}
+}
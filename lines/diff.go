@@ -0,0 +1,32 @@
+package lines
+
+import "github.com/pmezard/go-difflib/difflib"
+
+// Change returns the fraction of lines, from 0 to 1, that differ between two versions of a
+// document. Long lines are soft-wrapped at DefaultWidth, so a change to a minified page does
+// not count as the whole page changing.
+func Change(previous, current string) float64 {
+	if previous == current {
+		return 0
+	}
+	return 1 - difflib.NewMatcher(texts(previous), texts(current)).Ratio()
+}
+
+// Unified returns a unified diff of two versions of a document, split into lines like Change,
+// with context unchanged lines around each hunk. The dates label the versions in its header.
+func Unified(previous, current, previousDate, currentDate string, context int) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        texts(previous),
+		B:        texts(current),
+		FromFile: "previous",
+		ToFile:   "current",
+		FromDate: previousDate,
+		ToDate:   currentDate,
+		Context:  context,
+	})
+}
+
+// texts returns the lines of content, soft-wrapped at DefaultWidth, as Texts does.
+func texts(content string) []string {
+	return Split(content, DefaultWidth).Texts(content)
+}
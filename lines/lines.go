@@ -0,0 +1,116 @@
+// Package lines segments documents into lines for navigation. Lines longer than a width are
+// soft-wrapped, so minified HTML, JSON, and other documents without newlines still split into
+// lines of a useful size. Every line maps back to its offsets in the original document.
+package lines
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultWidth is the width lines are soft-wrapped at when no width is given.
+const DefaultWidth = 200
+
+// wrapAfter are the characters a long line is preferably wrapped after when it has no
+// whitespace to wrap at, such as the ends of tags and the separators of minified JSON and code.
+const wrapAfter = ">,;{}[]"
+
+// Line is a line of a document. CharStart and CharEnd are its byte offsets in the document,
+// excluding the newline that ends it.
+type Line struct {
+	// Number is the line's 1-based position among all lines, counting wrapped segments.
+	Number    int `json:"number"`
+	CharStart int `json:"char_start"`
+	CharEnd   int `json:"char_end"`
+	// Wrapped is set on the segments of a long line after its first, which continue it
+	// rather than following a newline.
+	Wrapped bool `json:"wrapped,omitempty"`
+}
+
+// Lines is a segmented document.
+type Lines []Line
+
+// Split segments content into lines, wrapping lines longer than width bytes. A width of zero
+// or less uses DefaultWidth. Lines are wrapped after whitespace when there is any in the last
+// half of the width, then after punctuation such as ">" and ",", and otherwise at the width,
+// never inside a UTF-8 character.
+func Split(content string, width int) Lines {
+	if width <= 0 {
+		width = DefaultWidth
+	}
+
+	var lines Lines
+	start := 0
+	for {
+		end := strings.IndexByte(content[start:], '\n')
+		if end < 0 {
+			end = len(content)
+		} else {
+			end += start
+		}
+
+		segStart, wrapped := start, false
+		for end-segStart > width {
+			segEnd := wrapPoint(content, segStart, width)
+			lines = append(lines, Line{Number: len(lines) + 1, CharStart: segStart, CharEnd: segEnd, Wrapped: wrapped})
+			segStart, wrapped = segEnd, true
+		}
+		if segStart < end || !wrapped {
+			lines = append(lines, Line{Number: len(lines) + 1, CharStart: segStart, CharEnd: end, Wrapped: wrapped})
+		}
+
+		// A final newline ends the last line rather than starting an empty one.
+		if end >= len(content)-1 {
+			break
+		}
+		start = end + 1
+	}
+	return lines
+}
+
+// wrapPoint returns where the line segment starting at start, longer than width, is wrapped.
+func wrapPoint(content string, start, width int) int {
+	limit := start + width
+	window := content[start+width/2 : limit]
+	if i := strings.LastIndexAny(window, " \t"); i >= 0 {
+		return start + width/2 + i + 1
+	}
+	if i := strings.LastIndexAny(window, wrapAfter); i >= 0 {
+		return start + width/2 + i + 1
+	}
+	for limit > start+1 && !utf8.RuneStart(content[limit]) {
+		limit--
+	}
+	return limit
+}
+
+// Locate returns the number of the line containing the byte offset, or 0 when there are no
+// lines. Offsets past the end are in the last line, and a newline belongs to the line it ends.
+func (ls Lines) Locate(offset int) int {
+	if len(ls) == 0 {
+		return 0
+	}
+	i := sort.Search(len(ls), func(i int) bool { return ls[i].CharStart > offset })
+	return max(i, 1)
+}
+
+// Range returns the byte offsets in the document spanned by lines first through last,
+// inclusive and 1-based, clamped to the document. ok is false when first is past the last line.
+func (ls Lines) Range(first, last int) (start, end int, ok bool) {
+	if first < 1 || first > len(ls) || last < first {
+		return 0, 0, false
+	}
+	last = min(last, len(ls))
+	return ls[first-1].CharStart, ls[last-1].CharEnd, true
+}
+
+// Texts returns the text of each line of content, ending with a newline, as line-based tools
+// such as diffs expect.
+func (ls Lines) Texts(content string) []string {
+	texts := make([]string, len(ls))
+	for i, line := range ls {
+		texts[i] = content[line.CharStart:line.CharEnd] + "\n"
+	}
+	return texts
+}
@@ -0,0 +1,87 @@
+package lines
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSplitNewlines verifies short lines are split at newlines.
+func TestSplitNewlines(t *testing.T) {
+	content := "first\n\nthird\n"
+	lines := Split(content, 0)
+
+	assert.Equal(t, Lines{
+		{Number: 1, CharStart: 0, CharEnd: 5},
+		{Number: 2, CharStart: 6, CharEnd: 6},
+		{Number: 3, CharStart: 7, CharEnd: 12},
+	}, lines)
+	assert.Equal(t, []string{"first\n", "\n", "third\n"}, lines.Texts(content))
+	assert.Equal(t, Lines{{Number: 1}}, Split("", 0))
+}
+
+// TestSplitWraps verifies long lines are wrapped at whitespace, then punctuation, then the
+// width, and the segments cover the line without gaps.
+func TestSplitWraps(t *testing.T) {
+	for name, tc := range map[string]struct {
+		content string
+		want    []string
+	}{
+		"whitespace":  {"aaaa bbbb cccc dd", []string{"aaaa bbbb ", "cccc dd"}},
+		"punctuation": {`{"a":1,"b":2,"c":3}`, []string{`{"a":1,`, `"b":2,`, `"c":3}`}},
+		"hard":        {strings.Repeat("x", 25), []string{strings.Repeat("x", 10), strings.Repeat("x", 10), strings.Repeat("x", 5)}},
+		"utf8":        {strings.Repeat("é", 8), []string{strings.Repeat("é", 5), strings.Repeat("é", 3)}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			lines := Split(tc.content, 10)
+			var texts []string
+			for i, line := range lines {
+				texts = append(texts, tc.content[line.CharStart:line.CharEnd])
+				assert.Equal(t, i > 0, line.Wrapped)
+			}
+			assert.Equal(t, tc.want, texts)
+		})
+	}
+}
+
+// TestLocateAndRange verifies offsets map to lines and line ranges map back to offsets.
+func TestLocateAndRange(t *testing.T) {
+	content := "short\n" + strings.Repeat("word ", 10)
+	lines := Split(content, 20)
+	require.Len(t, lines, 4)
+
+	assert.Equal(t, 1, lines.Locate(0))
+	assert.Equal(t, 1, lines.Locate(5), "a newline belongs to the line it ends")
+	assert.Equal(t, 2, lines.Locate(6))
+	assert.Equal(t, 3, lines.Locate(lines[2].CharStart))
+	assert.Equal(t, 4, lines.Locate(len(content)+10))
+
+	start, end, ok := lines.Range(2, 3)
+	require.True(t, ok)
+	assert.Equal(t, strings.Repeat("word ", 8), content[start:end])
+
+	_, end, ok = lines.Range(3, 100)
+	require.True(t, ok)
+	assert.Equal(t, len(content), end)
+
+	_, _, ok = lines.Range(5, 6)
+	assert.False(t, ok)
+}
+
+// TestChange verifies the changed fraction of lines ignores a trailing newline and counts the
+// wrapped segments of long lines.
+func TestChange(t *testing.T) {
+	assert.Zero(t, Change("a\nb\n", "a\nb\n"))
+	assert.Zero(t, Change("a\nb", "a\nb\n"))
+	assert.Equal(t, 0.5, Change("a\nb\n", "a\nc\n"))
+	assert.Equal(t, 1.0, Change("", "a\n"))
+
+	minified := strings.Repeat("word ", 200)
+	assert.Less(t, Change(minified, minified+"more"), 0.5, "a change to one long line should not change it all")
+
+	diff, err := Unified("a\nb\n", "a\nc\n", "then", "now", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "--- previous\tthen\n+++ current\tnow\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n", diff)
+}
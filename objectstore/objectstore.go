@@ -0,0 +1,211 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/joeychilson/websurfer/fetcher"
+)
+
+const (
+	// ProviderS3 stores objects in Amazon S3 or an S3-compatible service such as MinIO or R2.
+	ProviderS3 = "s3"
+	// ProviderGCS stores objects in Google Cloud Storage through its S3-compatible XML API,
+	// authenticated with HMAC keys.
+	ProviderGCS = "gcs"
+)
+
+const (
+	// defaultS3Region is the region used for S3 when none is configured.
+	defaultS3Region = "us-east-1"
+	// gcsEndpoint is the endpoint of Google Cloud Storage's XML API.
+	gcsEndpoint = "https://storage.googleapis.com"
+	// gcsRegion is the signing region Google Cloud Storage expects for HMAC requests.
+	gcsRegion = "auto"
+	// requestTimeout bounds a single upload or download.
+	requestTimeout = 5 * time.Minute
+	// maxErrorBody caps how much of an error response is included in errors.
+	maxErrorBody = 512
+)
+
+// ErrNotFound is returned by Get when the object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Config selects and configures an object store.
+type Config struct {
+	// Provider is ProviderS3 or ProviderGCS.
+	Provider string
+	Bucket   string
+	// Endpoint overrides the provider's endpoint, for S3-compatible services.
+	Endpoint string
+	// Region is the S3 region (default: "us-east-1"). It is ignored for GCS.
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// Prefix is prepended to every object key, such as "downloads/".
+	Prefix string
+}
+
+// Object is a stored object.
+type Object struct {
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+	// Location is the provider URI of the object, such as s3://bucket/key or gs://bucket/key.
+	Location string `json:"location"`
+	// URL is the HTTPS URL of the object. Unless the bucket is public, reading it requires
+	// credentials.
+	URL  string `json:"url"`
+	Size int64  `json:"size"`
+}
+
+// Store uploads and downloads objects in a bucket.
+type Store struct {
+	client   *http.Client
+	signer   fetcher.Signer
+	provider string
+	endpoint string
+	bucket   string
+	prefix   string
+}
+
+// New creates an object store from the configuration.
+func New(cfg Config) (*Store, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("object store requires a bucket")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("object store requires an access key id and secret access key")
+	}
+
+	endpoint := cfg.Endpoint
+	region := cfg.Region
+	switch cfg.Provider {
+	case ProviderS3:
+		if region == "" {
+			region = defaultS3Region
+		}
+		if endpoint == "" {
+			endpoint = "https://s3." + region + ".amazonaws.com"
+		}
+	case ProviderGCS:
+		region = gcsRegion
+		if endpoint == "" {
+			endpoint = gcsEndpoint
+		}
+	default:
+		return nil, fmt.Errorf("unknown object store provider %q (supported: %s, %s)", cfg.Provider, ProviderS3, ProviderGCS)
+	}
+
+	if u, err := url.Parse(endpoint); err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, fmt.Errorf("invalid object store endpoint %q", endpoint)
+	}
+
+	return &Store{
+		client:   &http.Client{Timeout: requestTimeout},
+		signer:   fetcher.NewSigV4Signer(cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken, region, "s3"),
+		provider: cfg.Provider,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		bucket:   cfg.Bucket,
+		prefix:   cfg.Prefix,
+	}, nil
+}
+
+// Put uploads data under key, after the store's prefix, and returns the stored object.
+func (s *Store) Put(ctx context.Context, key, contentType string, data []byte) (*Object, error) {
+	key = s.prefix + key
+
+	hash := sha256.Sum256(data)
+	header := http.Header{"X-Amz-Content-Sha256": {hex.EncodeToString(hash[:])}}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.send(ctx, http.MethodPut, key, header, data)
+	if err != nil {
+		return nil, fmt.Errorf("upload failed: %w", err)
+	}
+	resp.Body.Close()
+
+	scheme := "s3://"
+	if s.provider == ProviderGCS {
+		scheme = "gs://"
+	}
+	return &Object{
+		Bucket:   s.bucket,
+		Key:      key,
+		Location: scheme + s.bucket + "/" + key,
+		URL:      s.objectURL(key),
+		Size:     int64(len(data)),
+	}, nil
+}
+
+// Get downloads the object stored under key, after the store's prefix. It returns ErrNotFound
+// if there is no such object.
+func (s *Store) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.send(ctx, http.MethodGet, s.prefix+key, http.Header{}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	return data, nil
+}
+
+// send makes a signed request for key and returns the response if it succeeded. Downloads of
+// a missing object return ErrNotFound.
+func (s *Store) send(ctx context.Context, method, key string, header http.Header, data []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key), bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if err := s.signer.Sign(req); err != nil {
+		return nil, fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound && method == http.MethodGet {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+// objectURL returns the path-style URL of key, which every supported provider accepts.
+func (s *Store) objectURL(key string) string {
+	return s.endpoint + "/" + url.PathEscape(s.bucket) + "/" + escapeKey(key)
+}
+
+// escapeKey percent-encodes each segment of an object key, keeping its slashes.
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
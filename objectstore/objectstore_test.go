@@ -0,0 +1,113 @@
+package objectstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStorePut verifies uploads are signed path-style PUTs of the object under the prefix.
+func TestStorePut(t *testing.T) {
+	data := []byte("%PDF-1.7 report")
+	hash := sha256.Sum256(data)
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/assets/downloads/report%201.pdf", r.URL.EscapedPath())
+		assert.Equal(t, "application/pdf", r.Header.Get("Content-Type"))
+		assert.Equal(t, hex.EncodeToString(hash[:]), r.Header.Get("X-Amz-Content-Sha256"))
+		assert.Contains(t, r.Header.Get("Authorization"), "Credential=AKID/")
+		assert.Contains(t, r.Header.Get("Authorization"), "/auto/s3/aws4_request")
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, data, body)
+	}))
+	defer origin.Close()
+
+	store, err := New(Config{
+		Provider:        ProviderGCS,
+		Bucket:          "assets",
+		Endpoint:        origin.URL,
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+		Prefix:          "downloads/",
+	})
+	require.NoError(t, err)
+
+	object, err := store.Put(context.Background(), "report 1.pdf", "application/pdf", data)
+	require.NoError(t, err)
+	assert.Equal(t, "downloads/report 1.pdf", object.Key)
+	assert.Equal(t, "gs://assets/downloads/report 1.pdf", object.Location)
+	assert.Equal(t, origin.URL+"/assets/downloads/report%201.pdf", object.URL)
+	assert.Equal(t, int64(len(data)), object.Size)
+}
+
+// TestStorePutError verifies failed uploads report the provider's status and message.
+func TestStorePutError(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "<Error><Code>AccessDenied</Code></Error>", http.StatusForbidden)
+	}))
+	defer origin.Close()
+
+	store, err := New(Config{Provider: ProviderS3, Bucket: "assets", Endpoint: origin.URL, AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	require.NoError(t, err)
+
+	_, err = store.Put(context.Background(), "a.zip", "application/zip", []byte("PK"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP 403")
+	assert.Contains(t, err.Error(), "AccessDenied")
+}
+
+// TestStoreGet verifies downloads are signed GETs of the object under the prefix, and missing
+// objects return ErrNotFound.
+func TestStoreGet(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Contains(t, r.Header.Get("Authorization"), "Credential=AKID/")
+		if r.URL.Path != "/assets/bodies/abc" {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("stored body"))
+	}))
+	defer origin.Close()
+
+	store, err := New(Config{Provider: ProviderS3, Bucket: "assets", Endpoint: origin.URL, AccessKeyID: "AKID", SecretAccessKey: "secret", Prefix: "bodies/"})
+	require.NoError(t, err)
+
+	data, err := store.Get(context.Background(), "abc")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("stored body"), data)
+
+	_, err = store.Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestNew verifies provider defaults and configuration errors.
+func TestNew(t *testing.T) {
+	store, err := New(Config{Provider: ProviderS3, Bucket: "assets", Region: "eu-west-1", AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://s3.eu-west-1.amazonaws.com/assets/a.pdf", store.objectURL("a.pdf"))
+
+	store, err = New(Config{Provider: ProviderGCS, Bucket: "assets", AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://storage.googleapis.com/assets/a.pdf", store.objectURL("a.pdf"))
+
+	for _, cfg := range []Config{
+		{Provider: "azure", Bucket: "assets", AccessKeyID: "AKID", SecretAccessKey: "secret"},
+		{Provider: ProviderS3, AccessKeyID: "AKID", SecretAccessKey: "secret"},
+		{Provider: ProviderS3, Bucket: "assets"},
+		{Provider: ProviderS3, Bucket: "assets", Endpoint: "ftp://example.com", AccessKeyID: "AKID", SecretAccessKey: "secret"},
+	} {
+		_, err := New(cfg)
+		assert.Error(t, err, "%+v", cfg)
+	}
+}
@@ -0,0 +1,51 @@
+package outline
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	// inlineLinkRegex matches markdown links and images, capturing their text.
+	inlineLinkRegex = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+)
+
+// Slugger generates GitHub-style heading anchors, keeping them unique within a document.
+type Slugger struct {
+	seen map[string]int
+}
+
+// NewSlugger creates a slugger for a single document.
+func NewSlugger() *Slugger {
+	return &Slugger{seen: make(map[string]int)}
+}
+
+// Slug returns the anchor for a heading. Repeated headings get -1, -2, ... suffixes.
+func (s *Slugger) Slug(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(PlainText(text)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsNumber(r), r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+
+	slug := b.String()
+	count, exists := s.seen[slug]
+	s.seen[slug] = count + 1
+	if exists {
+		slug += "-" + strconv.Itoa(count)
+	}
+	return slug
+}
+
+// PlainText strips inline markdown links, images, and emphasis from heading text.
+func PlainText(text string) string {
+	text = inlineLinkRegex.ReplaceAllString(text, "$1")
+	text = strings.NewReplacer("**", "", "__", "", "`", "").Replace(text)
+	return strings.TrimSpace(text)
+}
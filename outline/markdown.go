@@ -2,6 +2,7 @@ package outline
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -21,6 +22,7 @@ func extractMarkdown(content string) *Outline {
 // extractMarkdownHeadings extracts # headings from markdown
 func extractMarkdownHeadings(lines []string, contentLen int) []Heading {
 	headings := []Heading{}
+	slugger := NewSlugger()
 	charPos := 0
 
 	for _, line := range lines {
@@ -37,6 +39,7 @@ func extractMarkdownHeadings(lines []string, contentLen int) []Heading {
 						headings = append(headings, Heading{
 							Level:     level,
 							Text:      text,
+							Anchor:    slugger.Slug(text),
 							CharStart: charPos,
 							CharEnd:   0,
 						})
@@ -59,9 +62,48 @@ func extractMarkdownHeadings(lines []string, contentLen int) []Heading {
 		}
 	}
 
+	numberHeadings(headings)
 	return headings
 }
 
+// numberHeadings sets the number and path of each heading from the headings above it. A
+// heading's parent is the closest preceding heading of a higher level, so skipped levels do
+// not add empty numbers.
+func numberHeadings(headings []Heading) {
+	type ancestor struct {
+		level int
+		count int
+		text  string
+	}
+	var stack []ancestor
+
+	for i := range headings {
+		h := &headings[i]
+		// A heading that takes the place of deeper ones, as an h2 after an h1 > h3, continues
+		// their numbering so no number repeats.
+		count := 1
+		for len(stack) > 0 && stack[len(stack)-1].level > h.Level {
+			count = stack[len(stack)-1].count + 1
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 && stack[len(stack)-1].level == h.Level {
+			stack[len(stack)-1].count++
+			stack[len(stack)-1].text = h.Text
+		} else {
+			stack = append(stack, ancestor{level: h.Level, count: count, text: h.Text})
+		}
+
+		numbers := make([]string, len(stack))
+		texts := make([]string, len(stack))
+		for j, a := range stack {
+			numbers[j] = strconv.Itoa(a.count)
+			texts[j] = a.text
+		}
+		h.Number = strings.Join(numbers, ".")
+		h.Path = strings.Join(texts, PathSeparator)
+	}
+}
+
 // extractMarkdownTables extracts table structures from markdown
 func extractMarkdownTables(lines []string) []Table {
 	tables := []Table{}
@@ -9,10 +9,17 @@ type Outline struct {
 	Lists    []List    `json:"lists,omitempty"`
 }
 
-// Heading represents a document heading
+// PathSeparator joins the headings of a section path, as in "Guide > Setup > Linux".
+const PathSeparator = " > "
+
+// Heading represents a document heading. Number is its hierarchical number, such as "1.2.3",
+// and Path the texts of it and its parent headings joined with PathSeparator.
 type Heading struct {
 	Level     int    `json:"level"`
 	Text      string `json:"text"`
+	Anchor    string `json:"anchor"`
+	Number    string `json:"number"`
+	Path      string `json:"path"`
 	CharStart int    `json:"char_start"`
 	CharEnd   int    `json:"char_end"`
 }
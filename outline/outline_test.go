@@ -58,6 +58,38 @@ func TestExtractMarkdownHeadings(t *testing.T) {
 	assert.Equal(t, "Level 4", result.Headings[3].Text)
 }
 
+// TestExtractMarkdownHeadingsNumbering verifies headings are numbered hierarchically with
+// breadcrumb paths, including when levels are skipped.
+func TestExtractMarkdownHeadingsNumbering(t *testing.T) {
+	content := `# Guide
+## Setup
+### Linux
+### macOS
+## Usage
+#### Flags
+### Examples
+# Reference`
+
+	result := extractMarkdown(content)
+
+	var numbers, paths []string
+	for _, h := range result.Headings {
+		numbers = append(numbers, h.Number)
+		paths = append(paths, h.Path)
+	}
+	assert.Equal(t, []string{"1", "1.1", "1.1.1", "1.1.2", "1.2", "1.2.1", "1.2.2", "2"}, numbers)
+	assert.Equal(t, []string{
+		"Guide",
+		"Guide > Setup",
+		"Guide > Setup > Linux",
+		"Guide > Setup > macOS",
+		"Guide > Usage",
+		"Guide > Usage > Flags",
+		"Guide > Usage > Examples",
+		"Reference",
+	}, paths)
+}
+
 // TestExtractMarkdownHeadingsCharPositions verifies character positions are tracked.
 func TestExtractMarkdownHeadingsCharPositions(t *testing.T) {
 	content := `# First
@@ -336,3 +368,24 @@ func TestExtractBytesEmpty(t *testing.T) {
 	assert.Empty(t, result.Tables)
 	assert.Empty(t, result.Lists)
 }
+
+// TestExtractBytesHeadingAnchors verifies headings get unique GitHub-style anchors.
+func TestExtractBytesHeadingAnchors(t *testing.T) {
+	content := []byte(`# Hello, World!
+
+## API [Reference](https://example.com/api)
+
+## Setup
+
+## Setup
+
+### snake_case & More`)
+
+	result := ExtractBytes(content, "text/markdown")
+
+	anchors := make([]string, 0, len(result.Headings))
+	for _, h := range result.Headings {
+		anchors = append(anchors, h.Anchor)
+	}
+	assert.Equal(t, []string{"hello-world", "api-reference", "setup", "setup-1", "snake_case--more"}, anchors)
+}
@@ -0,0 +1,255 @@
+package csv
+
+import (
+	"bytes"
+	"context"
+	enccsv "encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultMaxRows is how many data rows are rendered in the table by default.
+	defaultMaxRows = 100
+)
+
+// Column types reported in the summary.
+const (
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeDate    = "date"
+	TypeString  = "string"
+	TypeEmpty   = "empty"
+)
+
+// dateLayouts are the formats recognized as dates when inferring column types.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006/01/02",
+	"01/02/2006",
+}
+
+// Parser converts CSV and TSV data into a markdown table followed by summary statistics.
+type Parser struct {
+	maxRows   int
+	delimiter rune
+}
+
+// Option is a functional option for configuring the Parser.
+type Option func(*Parser)
+
+// WithMaxRows sets how many data rows are rendered in the table (default: 100). Statistics
+// always cover every row.
+func WithMaxRows(n int) Option {
+	return func(p *Parser) {
+		if n > 0 {
+			p.maxRows = n
+		}
+	}
+}
+
+// WithDelimiter fixes the field delimiter, such as '\t' for TSV, instead of detecting it.
+func WithDelimiter(delimiter rune) Option {
+	return func(p *Parser) {
+		p.delimiter = delimiter
+	}
+}
+
+// New creates a new CSV parser.
+func New(opts ...Option) *Parser {
+	p := &Parser{maxRows: defaultMaxRows}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// column accumulates statistics for one column.
+type column struct {
+	name     string
+	empty    int
+	integer  bool
+	number   bool
+	boolean  bool
+	date     bool
+	nonEmpty int
+	min, max float64
+}
+
+// Parse converts delimited data into a markdown table of the first rows and a summary of row
+// count, column names, and inferred column types. Unless set with WithDelimiter, the delimiter
+// (comma, tab, or semicolon) is detected from the header line. Data that cannot be read as CSV
+// is returned unchanged.
+func (p *Parser) Parse(ctx context.Context, content []byte) ([]byte, error) {
+	if len(content) == 0 {
+		return content, nil
+	}
+
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+
+	reader := enccsv.NewReader(bytes.NewReader(content))
+	reader.Comma = p.delimiter
+	if reader.Comma == 0 {
+		reader.Comma = detectDelimiter(content)
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return content, nil
+	}
+
+	columns := make([]*column, len(header))
+	for i, name := range header {
+		columns[i] = &column{name: strings.TrimSpace(name), integer: true, number: true, boolean: true, date: true}
+	}
+
+	var (
+		buf  bytes.Buffer
+		rows int
+	)
+	writeRow(&buf, header, len(columns))
+	buf.WriteString("|" + strings.Repeat(" --- |", len(columns)) + "\n")
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return content, nil
+		}
+
+		rows++
+		if rows <= p.maxRows {
+			writeRow(&buf, record, len(columns))
+		}
+		for i, col := range columns {
+			var value string
+			if i < len(record) {
+				value = record[i]
+			}
+			col.observe(strings.TrimSpace(value))
+		}
+	}
+
+	if rows > p.maxRows {
+		fmt.Fprintf(&buf, "\n_Showing %d of %d rows._\n", p.maxRows, rows)
+	}
+
+	fmt.Fprintf(&buf, "\n## Summary\n\n- Rows: %d\n- Columns: %d\n\n", rows, len(columns))
+	buf.WriteString("| Column | Type | Empty | Min | Max |\n| --- | --- | --- | --- | --- |\n")
+	for _, col := range columns {
+		typ := col.inferredType()
+		var minValue, maxValue string
+		if typ == TypeInteger || typ == TypeNumber {
+			minValue = strconv.FormatFloat(col.min, 'f', -1, 64)
+			maxValue = strconv.FormatFloat(col.max, 'f', -1, 64)
+		}
+		fmt.Fprintf(&buf, "| %s | %s | %d | %s | %s |\n", escapeCell(col.name), typ, col.empty, minValue, maxValue)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// observe updates the column's statistics with one value.
+func (c *column) observe(value string) {
+	if value == "" {
+		c.empty++
+		return
+	}
+	c.nonEmpty++
+
+	if c.integer {
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			c.integer = false
+		}
+	}
+	if c.number {
+		if n, err := strconv.ParseFloat(value, 64); err != nil {
+			c.number = false
+		} else if c.nonEmpty == 1 {
+			c.min, c.max = n, n
+		} else {
+			c.min, c.max = min(c.min, n), max(c.max, n)
+		}
+	}
+	if c.boolean {
+		switch strings.ToLower(value) {
+		case "true", "false", "yes", "no":
+		default:
+			c.boolean = false
+		}
+	}
+	if c.date {
+		c.date = isDate(value)
+	}
+}
+
+// inferredType returns the most specific type that fits every non-empty value in the column.
+func (c *column) inferredType() string {
+	switch {
+	case c.nonEmpty == 0:
+		return TypeEmpty
+	case c.integer:
+		return TypeInteger
+	case c.number:
+		return TypeNumber
+	case c.boolean:
+		return TypeBoolean
+	case c.date:
+		return TypeDate
+	default:
+		return TypeString
+	}
+}
+
+// isDate reports whether value matches one of the recognized date layouts.
+func isDate(value string) bool {
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// detectDelimiter picks the delimiter that appears most often in the first line, defaulting to comma.
+func detectDelimiter(content []byte) rune {
+	line, _, _ := bytes.Cut(content, []byte("\n"))
+
+	delimiter, best := ',', bytes.Count(line, []byte(","))
+	for _, candidate := range []rune{'\t', ';'} {
+		if n := bytes.Count(line, []byte(string(candidate))); n > best {
+			delimiter, best = candidate, n
+		}
+	}
+	return delimiter
+}
+
+// writeRow writes a record as a markdown table row padded or truncated to width cells.
+func writeRow(buf *bytes.Buffer, record []string, width int) {
+	buf.WriteString("|")
+	for i := range width {
+		var value string
+		if i < len(record) {
+			value = record[i]
+		}
+		fmt.Fprintf(buf, " %s |", escapeCell(value))
+	}
+	buf.WriteString("\n")
+}
+
+// escapeCell flattens newlines and escapes pipes so a value stays within its table cell.
+func escapeCell(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	return strings.ReplaceAll(value, "|", `\|`)
+}
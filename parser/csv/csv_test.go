@@ -0,0 +1,81 @@
+package csv
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCSVParseTableAndSummary verifies rows become a markdown table followed by column statistics.
+func TestCSVParseTableAndSummary(t *testing.T) {
+	content := "name,age,score,active,joined,notes\n" +
+		"Ada,36,9.5,true,2024-01-02,\"likes | pipes\"\n" +
+		"Brian,41,7,no,2023-12-31,\n"
+
+	out, err := New().Parse(context.Background(), []byte(content))
+	require.NoError(t, err)
+
+	expected := `| name | age | score | active | joined | notes |
+| --- | --- | --- | --- | --- | --- |
+| Ada | 36 | 9.5 | true | 2024-01-02 | likes \| pipes |
+| Brian | 41 | 7 | no | 2023-12-31 |  |
+
+## Summary
+
+- Rows: 2
+- Columns: 6
+
+| Column | Type | Empty | Min | Max |
+| --- | --- | --- | --- | --- |
+| name | string | 0 |  |  |
+| age | integer | 0 | 36 | 41 |
+| score | number | 0 | 7 | 9.5 |
+| active | boolean | 0 |  |  |
+| joined | date | 0 |  |  |
+| notes | string | 1 |  |  |
+`
+	assert.Equal(t, expected, string(out))
+}
+
+// TestCSVParseMaxRows verifies the table is capped while statistics cover every row.
+func TestCSVParseMaxRows(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("id\n")
+	for i := range 10 {
+		b.WriteString(strings.Repeat("1", i+1) + "\n")
+	}
+
+	out, err := New(WithMaxRows(3)).Parse(context.Background(), []byte(b.String()))
+	require.NoError(t, err)
+
+	assert.Contains(t, string(out), "| 111 |\n\n_Showing 3 of 10 rows._")
+	assert.NotContains(t, string(out), "| 1111 |\n")
+	assert.Contains(t, string(out), "- Rows: 10")
+	assert.Contains(t, string(out), "| id | integer | 0 | 1 | 1111111111 |")
+}
+
+// TestCSVParseTSV verifies tab-separated data is detected, or used when set explicitly.
+func TestCSVParseTSV(t *testing.T) {
+	out, err := New().Parse(context.Background(), []byte("city\tcountry\tpopulation\nParis\tFrance\t2100000\n"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(out), "| city | country | population |\n"))
+	assert.Contains(t, string(out), "| Paris | France | 2100000 |")
+
+	out, err = New(WithDelimiter('\t')).Parse(context.Background(), []byte("city\tpopulation, approx\nParis\t2100000\n"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(out), "| city | population, approx |\n"))
+}
+
+// TestCSVParseRaggedRows verifies short and long rows are padded or truncated to the header width.
+func TestCSVParseRaggedRows(t *testing.T) {
+	content := "a,b\n1\n2,3,4\n"
+
+	out, err := New().Parse(context.Background(), []byte(content))
+	require.NoError(t, err)
+
+	assert.Contains(t, string(out), "| 1 |  |\n| 2 | 3 |\n")
+	assert.Contains(t, string(out), "| b | integer | 1 | 3 | 3 |")
+}
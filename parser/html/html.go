@@ -1,7 +1,9 @@
 package html
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"regexp"
 	"strings"
 	"unicode"
@@ -13,10 +15,14 @@ import (
 	"github.com/microcosm-cc/bluemonday"
 	"golang.org/x/net/html"
 
+	"github.com/joeychilson/websurfer/outline"
 	"github.com/joeychilson/websurfer/parser"
 	"github.com/joeychilson/websurfer/parser/rules"
 )
 
+// minTOCHeadings is the fewest headings a document needs before a table of contents is added.
+const minTOCHeadings = 2
+
 var (
 	whitespaceRegex = regexp.MustCompile(`\s+`)
 	voidElements    = map[string]bool{
@@ -27,10 +33,19 @@ var (
 	}
 )
 
+// sanitizeProfiles are the sanitization profiles a parser has policies for.
+var sanitizeProfiles = []string{parser.SanitizeStrict, parser.SanitizeStandard, parser.SanitizePermissive}
+
 // Parser cleans HTML content into a minified format optimized for LLM consumption.
 type Parser struct {
-	policy *bluemonday.Policy
-	rules  *rules.RuleChain
+	policies map[policyKey]*bluemonday.Policy
+	rules    *rules.RuleChain
+}
+
+// policyKey identifies the sanitization policy of a profile, with or without images.
+type policyKey struct {
+	profile string
+	images  bool
 }
 
 // Option is a functional option for configuring the Parser.
@@ -48,10 +63,13 @@ func WithRules(ruleList ...rules.Rule) Option {
 	}
 }
 
-// New creates a new HTML parser with default sanitization settings.
+// New creates a new HTML parser, sanitizing with the standard profile unless the parse options
+// select another.
 func New(opts ...Option) *Parser {
-	p := &Parser{
-		policy: createSanitizationPolicy(),
+	p := &Parser{policies: make(map[policyKey]*bluemonday.Policy)}
+	for _, profile := range sanitizeProfiles {
+		p.policies[policyKey{profile, false}] = createSanitizationPolicy(profile, false)
+		p.policies[policyKey{profile, true}] = createSanitizationPolicy(profile, true)
 	}
 
 	for _, opt := range opts {
@@ -61,7 +79,8 @@ func New(opts ...Option) *Parser {
 	return p
 }
 
-// Parse transforms HTML into LLM-friendly Markdown.
+// Parse transforms HTML into LLM-friendly Markdown, or plain text when the parse options select
+// the text format.
 func (p *Parser) Parse(ctx context.Context, content []byte) ([]byte, error) {
 	if len(content) == 0 {
 		return content, nil
@@ -75,13 +94,31 @@ func (p *Parser) Parse(ctx context.Context, content []byte) ([]byte, error) {
 		}
 	}
 
-	sanitized := p.policy.Sanitize(string(result))
+	parseOpts := parser.GetOptions(ctx)
+
+	profile := parseOpts.Sanitize
+	if profile == "" {
+		profile = parser.SanitizeStandard
+	}
+	withImages := parseOpts.Images == parser.ImagesInline || parseOpts.Images == parser.ImagesAppendix
+	policy, ok := p.policies[policyKey{profile, withImages}]
+	if !ok {
+		return nil, fmt.Errorf("unknown sanitization profile %q", profile)
+	}
+
+	sanitized := policy.Sanitize(string(result))
 
 	doc, err := html.Parse(strings.NewReader(sanitized))
 	if err != nil {
 		return nil, err
 	}
 
+	if profile == parser.SanitizePermissive {
+		linkEmbeds(doc)
+	}
+	if parseOpts.Format == parser.FormatText {
+		return renderText(doc), nil
+	}
 	optimizeHTML(doc)
 
 	opts := []converter.ConvertOptionFunc{}
@@ -89,38 +126,229 @@ func (p *Parser) Parse(ctx context.Context, content []byte) ([]byte, error) {
 		opts = append(opts, converter.WithDomain(urlStr))
 	}
 
+	tablePlugin := table.NewTablePlugin()
+	if profile == parser.SanitizePermissive {
+		tablePlugin = table.NewTablePlugin(table.WithSpanCellBehavior(table.SpanBehaviorMirror))
+	}
+
 	conv := converter.NewConverter(
 		converter.WithPlugins(
 			base.NewBasePlugin(),
 			commonmark.NewCommonmarkPlugin(),
-			table.NewTablePlugin(),
+			tablePlugin,
 		),
 	)
 
+	var images, references collector
+	if parseOpts.Images == parser.ImagesAppendix {
+		conv.Register.RendererFor("img", converter.TagTypeInline, images.renderImage, converter.PriorityEarly)
+	}
+	if parseOpts.Links == parser.LinksReferences {
+		conv.Register.RendererFor("a", converter.TagTypeInline, references.renderLinkReference, converter.PriorityEarly)
+	}
+
 	markdownBytes, err := conv.ConvertNode(doc, opts...)
 	if err != nil {
 		return nil, err
 	}
 
+	markdownBytes = images.appendSection(markdownBytes, "Images", func(i int, item collectedItem) string {
+		return fmt.Sprintf("%d. ![%s](%s)", i+1, item.text, item.url)
+	})
+	markdownBytes = references.appendSection(markdownBytes, "References", func(i int, item collectedItem) string {
+		return fmt.Sprintf("[%d]: %s", i+1, item.url)
+	})
+
+	if parseOpts.TOC {
+		markdownBytes = prependTOC(markdownBytes)
+	}
+
 	return markdownBytes, nil
 }
 
-// createSanitizationPolicy creates a policy that keeps structural/semantic elements only.
-func createSanitizationPolicy() *bluemonday.Policy {
+// prependTOC adds a table of contents linking to each heading's anchor. Anchors follow the
+// same rules as outline headings, so both point at the same sections.
+func prependTOC(markdown []byte) []byte {
+	headings := outline.ExtractBytes(markdown, "text/markdown").Headings
+	if len(headings) < minTOCHeadings {
+		return markdown
+	}
+
+	minLevel := headings[0].Level
+	for _, h := range headings {
+		minLevel = min(minLevel, h.Level)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("**Contents**\n\n")
+	for _, h := range headings {
+		fmt.Fprintf(&b, "%s- [%s](#%s)\n", strings.Repeat("  ", h.Level-minLevel), outline.PlainText(h.Text), h.Anchor)
+	}
+	b.WriteString("\n")
+	b.Write(markdown)
+
+	return b.Bytes()
+}
+
+// collectedItem is an image or link pulled out of the body into an appendix section.
+type collectedItem struct {
+	text string
+	url  string
+}
+
+// collector gathers images or links during conversion so they can be listed after the body.
+type collector struct {
+	items []collectedItem
+	index map[string]int
+}
+
+// add records a URL and returns its 1-based number, reusing the number for repeated URLs.
+func (c *collector) add(text, url string) int {
+	if c.index == nil {
+		c.index = make(map[string]int)
+	}
+	if n, exists := c.index[url]; exists {
+		return n
+	}
+	c.items = append(c.items, collectedItem{text: text, url: url})
+	c.index[url] = len(c.items)
+	return len(c.items)
+}
+
+// renderImage collects an image for the appendix and renders nothing in its place.
+func (c *collector) renderImage(ctx converter.Context, w converter.Writer, n *html.Node) converter.RenderStatus {
+	src := strings.TrimSpace(getAttr(n, "src"))
+	if src == "" {
+		return converter.RenderSuccess
+	}
+
+	alt := strings.Join(strings.Fields(getAttr(n, "alt")), " ")
+	alt = strings.NewReplacer("[", "\\[", "]", "\\]").Replace(alt)
+	c.add(alt, ctx.AssembleAbsoluteURL(ctx, "img", src))
+	return converter.RenderSuccess
+}
+
+// renderLinkReference renders a link's text followed by a numbered reference marker.
+func (c *collector) renderLinkReference(ctx converter.Context, w converter.Writer, n *html.Node) converter.RenderStatus {
+	href := strings.TrimSpace(getAttr(n, "href"))
+	if href == "" {
+		return converter.RenderTryNext
+	}
+	href = ctx.AssembleAbsoluteURL(ctx, "a", href)
+
+	var buf bytes.Buffer
+	ctx.RenderChildNodes(ctx, &buf, n)
+	if len(bytes.TrimSpace(buf.Bytes())) == 0 {
+		return converter.RenderTryNext
+	}
+
+	number := c.add("", href)
+	w.Write(bytes.TrimRight(buf.Bytes(), " "))
+	fmt.Fprintf(w, "[%d]", number)
+	if bytes.HasSuffix(buf.Bytes(), []byte(" ")) {
+		w.WriteByte(' ')
+	}
+	return converter.RenderSuccess
+}
+
+// appendSection appends a markdown section listing the collected items.
+func (c *collector) appendSection(markdown []byte, title string, format func(int, collectedItem) string) []byte {
+	if len(c.items) == 0 {
+		return markdown
+	}
+
+	var b bytes.Buffer
+	b.Write(bytes.TrimRight(markdown, "\n"))
+	b.WriteString("\n\n## " + title + "\n\n")
+	for i, item := range c.items {
+		b.WriteString(format(i, item))
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// getAttr returns the value of an attribute from an HTML node.
+func getAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// createSanitizationPolicy creates the policy of a sanitization profile, which keeps structural
+// and semantic elements only. With images, it also keeps images and their alt text.
+func createSanitizationPolicy(profile string, images bool) *bluemonday.Policy {
 	policy := bluemonday.NewPolicy()
 
 	policy.AllowElements("div", "p", "h1", "h2", "h3", "h4", "h5", "h6",
 		"main",
 		"ul", "ol", "li",
 		"table", "thead", "tbody", "tr", "td", "th",
-		"a", "br", "hr")
+		"br", "hr")
+
+	if profile != parser.SanitizeStrict {
+		policy.AllowElements("a")
+		policy.AllowAttrs("href").OnElements("a")
+		policy.AllowAttrs("colspan", "rowspan").OnElements("td", "th")
+	}
+
+	if profile == parser.SanitizePermissive {
+		policy.AllowElements("pre", "code", "blockquote",
+			"strong", "b", "em", "i", "del", "s", "sub", "sup",
+			"dl", "dt", "dd",
+			"caption", "tfoot", "figure", "figcaption")
+		policy.AllowAttrs("src", "title").OnElements("iframe", "video", "audio")
+		policy.AllowAttrs("src").OnElements("source")
+	}
 
-	policy.AllowAttrs("href").OnElements("a")
-	policy.AllowAttrs("colspan", "rowspan").OnElements("td", "th")
+	if images {
+		policy.AllowAttrs("src", "alt").OnElements("img")
+	}
 
 	return policy
 }
 
+// embedLabels are the link text of embedded frames and media without a title.
+var embedLabels = map[string]string{
+	"iframe": "Embedded content",
+	"video":  "Video",
+	"audio":  "Audio",
+}
+
+// linkEmbeds replaces embedded frames and media with links to their source, labeled with their
+// title. Media without a src link to their first <source>.
+func linkEmbeds(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		linkEmbeds(c)
+		c = next
+	}
+
+	label, ok := embedLabels[n.Data]
+	if n.Type != html.ElementNode || !ok || n.Parent == nil {
+		return
+	}
+
+	src := strings.TrimSpace(getAttr(n, "src"))
+	for c := n.FirstChild; c != nil && src == ""; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == "source" {
+			src = strings.TrimSpace(getAttr(c, "src"))
+		}
+	}
+	if title := strings.Join(strings.Fields(getAttr(n, "title")), " "); title != "" {
+		label = title
+	}
+
+	if src != "" {
+		link := &html.Node{Type: html.ElementNode, Data: "a", Attr: []html.Attribute{{Key: "href", Val: src}}}
+		link.AppendChild(&html.Node{Type: html.TextNode, Data: label})
+		n.Parent.InsertBefore(link, n)
+	}
+	n.Parent.RemoveChild(n)
+}
+
 // optimizeHTML performs all HTML optimizations in a single tree traversal.
 func optimizeHTML(n *html.Node) {
 	for c := n.FirstChild; c != nil; {
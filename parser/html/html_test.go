@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/joeychilson/websurfer/parser"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -299,3 +300,146 @@ func TestHTMLParserCreation(t *testing.T) {
 	parser := New()
 	assert.NotNil(t, parser, "should create parser")
 }
+
+// TestHTMLImagesInline verifies inline image mode keeps images with alt text in place.
+func TestHTMLImagesInline(t *testing.T) {
+	p := New()
+	html := `<p>Text before <img src="/image.jpg" alt="A chart"> text after</p>`
+	ctx := parser.WithOptions(parser.WithURL(context.Background(), "https://example.com/page"), parser.Options{Images: parser.ImagesInline})
+
+	result, err := p.Parse(ctx, []byte(html))
+
+	require.NoError(t, err)
+	markdown := string(result)
+	assert.Contains(t, markdown, "![A chart](https://example.com/image.jpg)", "should render image inline with absolute src")
+	assert.Contains(t, markdown, "text after")
+}
+
+// TestHTMLImagesAppendix verifies appendix image mode lists images after the body.
+func TestHTMLImagesAppendix(t *testing.T) {
+	p := New()
+	html := `<p>Intro <img src="/a.png" alt="First"> middle <img src="/b.png" alt="Second"></p><p>Outro</p>`
+	ctx := parser.WithOptions(parser.WithURL(context.Background(), "https://example.com/"), parser.Options{Images: parser.ImagesAppendix})
+
+	result, err := p.Parse(ctx, []byte(html))
+
+	require.NoError(t, err)
+	markdown := string(result)
+	body, appendix, found := strings.Cut(markdown, "## Images")
+	require.True(t, found, "should include images appendix")
+	assert.NotContains(t, body, "a.png", "should remove images from the body")
+	assert.Contains(t, body, "Outro")
+	assert.Contains(t, appendix, "1. ![First](https://example.com/a.png)")
+	assert.Contains(t, appendix, "2. ![Second](https://example.com/b.png)")
+}
+
+// TestHTMLLinksReferences verifies reference link mode numbers links and lists their URLs.
+func TestHTMLLinksReferences(t *testing.T) {
+	p := New()
+	html := `<p>See <a href="/docs">the docs</a> and <a href="https://other.com">another site</a>, or <a href="/docs">docs again</a>.</p>`
+	ctx := parser.WithOptions(parser.WithURL(context.Background(), "https://example.com/"), parser.Options{Links: parser.LinksReferences})
+
+	result, err := p.Parse(ctx, []byte(html))
+
+	require.NoError(t, err)
+	markdown := string(result)
+	assert.Contains(t, markdown, "See the docs[1] and another site[2], or docs again[1].")
+	assert.Contains(t, markdown, "## References\n\n[1]: https://example.com/docs\n[2]: https://other.com")
+	assert.NotContains(t, markdown, "](", "should not render inline links")
+}
+
+// TestHTMLTableOfContents verifies the TOC option links to each heading's anchor.
+func TestHTMLTableOfContents(t *testing.T) {
+	p := New()
+	html := `<h1>Guide</h1><p>Intro</p><h2>Getting Started</h2><p>Steps</p><h2>FAQ</h2><h3>Why?</h3><h2>FAQ</h2>`
+	ctx := parser.WithOptions(context.Background(), parser.Options{TOC: true})
+
+	result, err := p.Parse(ctx, []byte(html))
+
+	require.NoError(t, err)
+	markdown := string(result)
+	assert.True(t, strings.HasPrefix(markdown, "**Contents**\n\n"), "should start with the table of contents")
+	assert.Contains(t, markdown, "- [Guide](#guide)\n  - [Getting Started](#getting-started)\n  - [FAQ](#faq)\n    - [Why?](#why)\n  - [FAQ](#faq-1)\n")
+	assert.Contains(t, markdown, "# Guide")
+}
+
+// TestHTMLTableOfContentsSkipsShortDocuments verifies no TOC is added with fewer than two headings.
+func TestHTMLTableOfContentsSkipsShortDocuments(t *testing.T) {
+	p := New()
+	ctx := parser.WithOptions(context.Background(), parser.Options{TOC: true})
+
+	result, err := p.Parse(ctx, []byte(`<h1>Only</h1><p>Body</p>`))
+
+	require.NoError(t, err)
+	assert.NotContains(t, string(result), "Contents")
+}
+
+// TestHTMLDefaultOptionsUnchanged verifies explicit default options match the default output.
+func TestHTMLDefaultOptionsUnchanged(t *testing.T) {
+	p := New()
+	html := `<p>Text <img src="image.jpg" alt="Alt"> with <a href="https://example.com">a link</a></p>`
+	ctx := parser.WithOptions(context.Background(), parser.Options{Images: parser.ImagesNone, Links: parser.LinksInline})
+
+	expected, err := p.Parse(context.Background(), []byte(html))
+	require.NoError(t, err)
+	result, err := p.Parse(ctx, []byte(html))
+	require.NoError(t, err)
+
+	assert.Equal(t, string(expected), string(result))
+}
+
+// TestHTMLSanitizeProfiles verifies the sanitization profiles keep progressively more content.
+func TestHTMLSanitizeProfiles(t *testing.T) {
+	p := New()
+	html := `<h1>Docs</h1>
+<p>See <a href="/guide">the guide</a> for <strong>details</strong>.</p>
+<pre><code>go run .</code></pre>
+<iframe src="https://www.youtube.com/embed/abc" title="Intro video"></iframe>
+<video><source src="/demo.mp4"></video>
+<table><tr><th>Plan</th><th>Q1</th><th>Q2</th></tr><tr><td>Pro</td><td colspan="2">Included</td></tr></table>`
+	parse := func(profile string) string {
+		ctx := parser.WithOptions(parser.WithURL(context.Background(), "https://example.com/"), parser.Options{Sanitize: profile})
+		result, err := p.Parse(ctx, []byte(html))
+		require.NoError(t, err)
+		return string(result)
+	}
+
+	strict := parse(parser.SanitizeStrict)
+	assert.Contains(t, strict, "See the guide for details.")
+	assert.NotContains(t, strict, "](")
+	assert.NotContains(t, strict, "youtube")
+
+	standard := parse(parser.SanitizeStandard)
+	assert.Equal(t, standard, parse(""), "standard should be the default")
+	assert.Contains(t, standard, "[the guide](https://example.com/guide)")
+	assert.NotContains(t, standard, "**details**")
+	assert.NotContains(t, standard, "youtube")
+
+	permissive := parse(parser.SanitizePermissive)
+	assert.Contains(t, permissive, "**details**")
+	assert.Contains(t, permissive, "```\ngo run .\n```")
+	assert.Contains(t, permissive, "[Intro video](https://www.youtube.com/embed/abc)")
+	assert.Contains(t, permissive, "[Video](https://example.com/demo.mp4)")
+	assert.Contains(t, permissive, "| Pro  | Included | Included |")
+}
+
+// TestHTMLTextFormat verifies the text format renders blocks, list items, and table rows on
+// their own lines, without markdown syntax.
+func TestHTMLTextFormat(t *testing.T) {
+	p := New()
+	html := `<html><head><title>Ignored</title><script>var x = 1;</script></head><body>
+<h1>Getting   Started</h1>
+<p>Read <a href="/guide">the guide</a> first.<br>Then install.</p>
+<ul><li>One</li><li>Two</li></ul>
+<table><tr><th>Plan</th><th>Price</th></tr><tr><td>Pro</td><td>$10</td></tr></table>
+<pre>go  run .
+go test</pre>
+</body></html>`
+	ctx := parser.WithOptions(context.Background(), parser.Options{Format: parser.FormatText, Sanitize: parser.SanitizePermissive})
+
+	result, err := p.Parse(ctx, []byte(html))
+	require.NoError(t, err)
+
+	expected := "Getting Started\n\nRead the guide first.\nThen install.\n\nOne\nTwo\n\nPlan\tPrice\nPro\t$10\n\ngo  run .\ngo test"
+	assert.Equal(t, expected, string(result))
+}
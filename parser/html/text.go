@@ -0,0 +1,124 @@
+package html
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// textBreaks is how many line breaks separate each block element from its neighbors in plain
+// text: a blank line around paragraph-like blocks, and a line break around rows and items.
+var textBreaks = map[string]int{
+	"p": 2, "h1": 2, "h2": 2, "h3": 2, "h4": 2, "h5": 2, "h6": 2,
+	"ul": 2, "ol": 2, "dl": 2, "table": 2, "pre": 2, "blockquote": 2, "figure": 2, "hr": 2,
+	"div": 1, "main": 1, "li": 1, "tr": 1, "dt": 1, "dd": 1, "caption": 1, "figcaption": 1,
+}
+
+// textWriter renders a document as plain text, collapsing whitespace outside <pre> and
+// separating blocks with line breaks. Breaks and separators are held until the next word, so
+// the text never starts or ends with them.
+type textWriter struct {
+	b      strings.Builder
+	breaks int
+	sep    string
+}
+
+// renderText renders the text of a sanitized document, with table cells separated by tabs.
+func renderText(doc *html.Node) []byte {
+	var w textWriter
+	w.render(doc, false)
+	return []byte(w.b.String())
+}
+
+// render writes the text of n and its descendants.
+func (w *textWriter) render(n *html.Node, pre bool) {
+	switch n.Type {
+	case html.TextNode:
+		if pre {
+			w.write(n.Data)
+		} else {
+			w.words(n.Data)
+		}
+		return
+	case html.ElementNode:
+	case html.DocumentNode:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			w.render(c, pre)
+		}
+		return
+	default:
+		return
+	}
+
+	if n.Data == "br" {
+		w.lineBreak(1)
+		return
+	}
+
+	breaks := textBreaks[n.Data]
+	w.lineBreak(breaks)
+	if (n.Data == "td" || n.Data == "th") && hasPreviousCell(n) {
+		w.sep = "\t"
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.render(c, pre || n.Data == "pre")
+	}
+	w.lineBreak(breaks)
+}
+
+// words writes text with its whitespace collapsed to single spaces.
+func (w *textWriter) words(text string) {
+	if strings.TrimLeftFunc(text, isSpace) != text && w.sep == "" {
+		w.sep = " "
+	}
+	for i, word := range strings.Fields(text) {
+		if i > 0 {
+			w.sep = " "
+		}
+		w.write(word)
+	}
+	if strings.TrimRightFunc(text, isSpace) != text && w.sep == "" {
+		w.sep = " "
+	}
+}
+
+// write writes text verbatim after any pending break or separator.
+func (w *textWriter) write(text string) {
+	if text == "" {
+		return
+	}
+	if w.b.Len() > 0 {
+		if w.breaks > 0 {
+			w.b.WriteString(strings.Repeat("\n", w.breaks))
+		} else {
+			w.b.WriteString(w.sep)
+		}
+	}
+	w.breaks, w.sep = 0, ""
+	w.b.WriteString(text)
+}
+
+// lineBreak ends the current line with at least n line breaks, replacing any pending
+// separator.
+func (w *textWriter) lineBreak(n int) {
+	if n == 0 || w.b.Len() == 0 {
+		return
+	}
+	w.breaks = max(w.breaks, n)
+	w.sep = ""
+}
+
+// hasPreviousCell reports whether a table cell follows another in its row.
+func hasPreviousCell(n *html.Node) bool {
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode && (s.Data == "td" || s.Data == "th") {
+			return true
+		}
+	}
+	return false
+}
+
+// isSpace reports whether r is HTML whitespace.
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f'
+}
@@ -12,8 +12,94 @@ type contextKey string
 const (
 	// urlContextKey stores the URL being parsed in the context.
 	urlContextKey contextKey = "parser_url"
+	// optionsContextKey stores the parse options in the context.
+	optionsContextKey contextKey = "parser_options"
 )
 
+const (
+	// ImagesNone drops images from the output (default).
+	ImagesNone = "none"
+	// ImagesInline emits images in place as markdown image syntax with alt text.
+	ImagesInline = "inline"
+	// ImagesAppendix removes images from the body and lists them in an appendix.
+	ImagesAppendix = "appendix"
+
+	// LinksInline emits links as inline markdown links (default).
+	LinksInline = "inline"
+	// LinksReferences replaces links with numbered markers and lists URLs in a references section.
+	LinksReferences = "references"
+)
+
+const (
+	// SanitizeStrict keeps only headings, paragraphs, lists, and plain tables, dropping link
+	// targets and table cell spans.
+	SanitizeStrict = "strict"
+	// SanitizeStandard also keeps links and table cell spans (default).
+	SanitizeStandard = "standard"
+	// SanitizePermissive also keeps code blocks, quotes, inline formatting, definition lists,
+	// and captions, renders embedded frames and media as links to their source, and repeats
+	// the content of spanning table cells in each column and row they span.
+	SanitizePermissive = "permissive"
+)
+
+const (
+	// FormatMarkdown converts pages to markdown (default).
+	FormatMarkdown = "markdown"
+	// FormatRawHTML returns HTML pages as fetched, or as rendered by the headless browser,
+	// without converting them.
+	FormatRawHTML = "raw_html"
+	// FormatText renders HTML pages as plain text, with blocks separated by line breaks.
+	FormatText = "text"
+)
+
+// Options controls what content parsers preserve. The zero value is the default behavior.
+type Options struct {
+	Images string
+	Links  string
+	// TOC prepends a table of contents linking to each heading's anchor.
+	TOC bool
+	// Sanitize selects which HTML tags and attributes survive conversion: strict, standard
+	// (default), or permissive.
+	Sanitize string
+	// Format selects the output for HTML pages: markdown (default), raw_html, or text.
+	Format string
+}
+
+// IsDefault returns true if the options produce the default parser output.
+func (o Options) IsDefault() bool {
+	return (o.Images == "" || o.Images == ImagesNone) && (o.Links == "" || o.Links == LinksInline) && !o.TOC &&
+		(o.Sanitize == "" || o.Sanitize == SanitizeStandard) && (o.Format == "" || o.Format == FormatMarkdown)
+}
+
+// Validate checks that the option values are recognized.
+func (o Options) Validate() error {
+	switch o.Images {
+	case "", ImagesNone, ImagesInline, ImagesAppendix:
+	default:
+		return fmt.Errorf("images must be one of %q, %q, %q", ImagesNone, ImagesInline, ImagesAppendix)
+	}
+
+	switch o.Links {
+	case "", LinksInline, LinksReferences:
+	default:
+		return fmt.Errorf("links must be one of %q, %q", LinksInline, LinksReferences)
+	}
+
+	switch o.Sanitize {
+	case "", SanitizeStrict, SanitizeStandard, SanitizePermissive:
+	default:
+		return fmt.Errorf("sanitize must be one of %q, %q, %q", SanitizeStrict, SanitizeStandard, SanitizePermissive)
+	}
+
+	switch o.Format {
+	case "", FormatMarkdown, FormatRawHTML, FormatText:
+	default:
+		return fmt.Errorf("format must be one of %q, %q, %q", FormatMarkdown, FormatRawHTML, FormatText)
+	}
+
+	return nil
+}
+
 // Parser transforms content into an LLM-friendly format.
 type Parser interface {
 	// Parse transforms the content and returns the cleaned result.
@@ -35,6 +121,19 @@ func GetURL(ctx context.Context) string {
 	return ""
 }
 
+// WithOptions adds parse options to the context for parsers to use.
+func WithOptions(ctx context.Context, opts Options) context.Context {
+	return context.WithValue(ctx, optionsContextKey, opts)
+}
+
+// GetOptions retrieves the parse options from the context, returning defaults if unset.
+func GetOptions(ctx context.Context) Options {
+	if opts, ok := ctx.Value(optionsContextKey).(Options); ok {
+		return opts
+	}
+	return Options{}
+}
+
 // Registry manages multiple parsers and routes content based on content-type.
 type Registry struct {
 	parsers map[string]Parser
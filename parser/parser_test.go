@@ -194,3 +194,31 @@ func TestRegistryContentTypeNormalizationInParse(t *testing.T) {
 		assert.Equal(t, []byte("normalized"), result, "should normalize: %s", input)
 	}
 }
+
+// TestOptionsValidate verifies parse option values are checked.
+func TestOptionsValidate(t *testing.T) {
+	assert.NoError(t, Options{}.Validate())
+	assert.NoError(t, Options{Images: ImagesAppendix, Links: LinksReferences}.Validate())
+	assert.Error(t, Options{Images: "embedded"}.Validate())
+	assert.Error(t, Options{Links: "footnotes"}.Validate())
+	assert.NoError(t, Options{Format: FormatRawHTML}.Validate())
+	assert.Error(t, Options{Format: "pdf"}.Validate())
+}
+
+// TestOptionsIsDefault verifies explicit defaults are treated like the zero value.
+func TestOptionsIsDefault(t *testing.T) {
+	assert.True(t, Options{}.IsDefault())
+	assert.True(t, Options{Images: ImagesNone, Links: LinksInline}.IsDefault())
+	assert.False(t, Options{Images: ImagesInline}.IsDefault())
+	assert.False(t, Options{Links: LinksReferences}.IsDefault())
+	assert.True(t, Options{Format: FormatMarkdown}.IsDefault())
+	assert.False(t, Options{Format: FormatText}.IsDefault())
+}
+
+// TestWithOptions verifies parse options can be added to and read from context.
+func TestWithOptions(t *testing.T) {
+	ctx := WithOptions(context.Background(), Options{Images: ImagesAppendix})
+
+	assert.Equal(t, Options{Images: ImagesAppendix}, GetOptions(ctx))
+	assert.Equal(t, Options{}, GetOptions(context.Background()))
+}
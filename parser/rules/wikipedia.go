@@ -0,0 +1,152 @@
+package rules
+
+import (
+	"bytes"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/joeychilson/websurfer/parser"
+)
+
+// wikiDomains are the Wikimedia project domains served by MediaWiki.
+var wikiDomains = []string{
+	"wikipedia.org", "wiktionary.org", "wikibooks.org", "wikinews.org", "wikiquote.org",
+	"wikisource.org", "wikiversity.org", "wikivoyage.org", "wikimedia.org", "mediawiki.org",
+}
+
+// wikiChromeClasses mark the navigation, maintenance, and citation elements removed from
+// articles.
+var wikiChromeClasses = []string{
+	"infobox", "navbox", "vertical-navbox", "navbox-styles", "sidebar", "metadata", "ambox",
+	"mbox-small", "sistersitebox", "portalbox", "noprint", "mw-editsection", "mw-empty-elt",
+	"reference", "shortdescription", "toc", "catlinks", "printfooter", "mw-jump-link",
+}
+
+// wikiChromeTags are elements removed from articles regardless of class.
+var wikiChromeTags = []string{"style", "script", "link", "meta"}
+
+// WikipediaRule reduces MediaWiki article pages to the article itself: its title and body,
+// without the site navigation, infoboxes, navboxes, edit links, or citation markers around it.
+// Headings keep their text alone, so their anchors match the article's sections.
+// Only applies to text/html content from Wikimedia project domains.
+type WikipediaRule struct{}
+
+// NewWikipediaRule creates a new MediaWiki article cleanup rule for HTML content.
+func NewWikipediaRule() *WikipediaRule {
+	return &WikipediaRule{}
+}
+
+// Match returns true for Wikimedia project URLs with HTML content.
+func (r *WikipediaRule) Match(urlStr, contentType string) bool {
+	ct := parser.NormalizeContentType(contentType)
+	if ct != "text/html" && ct != "application/xhtml+xml" {
+		return false
+	}
+
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return slices.ContainsFunc(wikiDomains, func(domain string) bool {
+		return host == domain || strings.HasSuffix(host, "."+domain)
+	})
+}
+
+// Apply extracts the article title and body, removing the chrome within it. Pages without a
+// MediaWiki content area are returned unchanged.
+func (r *WikipediaRule) Apply(content []byte) []byte {
+	doc, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return content
+	}
+
+	body := findElement(doc, func(n *html.Node) bool { return attr(n, "id") == "mw-content-text" })
+	if body == nil {
+		return content
+	}
+	if output := findElement(body, func(n *html.Node) bool { return hasClass(n, "mw-parser-output") }); output != nil {
+		body = output
+	}
+	removeWikiChrome(body)
+
+	var b bytes.Buffer
+	b.WriteString("<html><body><main>")
+	if heading := findElement(doc, func(n *html.Node) bool { return attr(n, "id") == "firstHeading" }); heading != nil {
+		b.WriteString("<h1>")
+		b.WriteString(html.EscapeString(textContent(heading)))
+		b.WriteString("</h1>")
+	}
+	for c := body.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&b, c); err != nil {
+			return content
+		}
+	}
+	b.WriteString("</main></body></html>")
+	return b.Bytes()
+}
+
+// Name returns the rule's name.
+func (r *WikipediaRule) Name() string {
+	return "MediaWiki Article Cleanup"
+}
+
+// removeWikiChrome removes the chrome elements below n.
+func removeWikiChrome(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode && (slices.Contains(wikiChromeTags, c.Data) || slices.ContainsFunc(wikiChromeClasses, func(class string) bool { return hasClass(c, class) })) {
+			n.RemoveChild(c)
+		} else {
+			removeWikiChrome(c)
+		}
+		c = next
+	}
+}
+
+// findElement returns the first element below n, in document order, that match accepts.
+func findElement(n *html.Node, match func(*html.Node) bool) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && match(c) {
+			return c
+		}
+		if found := findElement(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// attr returns the value of an element's attribute, or "" if it has none.
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasClass reports whether an element's class attribute lists class.
+func hasClass(n *html.Node, class string) bool {
+	return slices.Contains(strings.Fields(attr(n, "class")), class)
+}
+
+// textContent returns the whitespace-collapsed text below n.
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
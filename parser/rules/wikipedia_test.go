@@ -0,0 +1,72 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// wikipediaArticle is a trimmed-down Wikipedia article page.
+const wikipediaArticle = `<!DOCTYPE html><html><head><title>Go (programming language) - Wikipedia</title><style>.x{}</style></head>
+<body>
+<a class="mw-jump-link" href="#bodyContent">Jump to content</a>
+<div id="mw-navigation"><a href="/wiki/Main_Page">Main page</a><a href="/wiki/Special:Random">Random article</a></div>
+<h1 id="firstHeading" class="firstHeading"><span class="mw-page-title-main">Go (programming language)</span></h1>
+<div id="bodyContent">
+<div id="mw-content-text"><div class="mw-content-ltr mw-parser-output">
+<div class="shortdescription nomobile noexcerpt noprint searchaux">Programming language</div>
+<table class="infobox vevent"><tr><th>Paradigm</th><td>Multi-paradigm</td></tr></table>
+<p><b>Go</b> is a statically typed language designed at Google.<sup id="cite_ref-1" class="reference"><a href="#cite_note-1">[1]</a></sup></p>
+<div class="mw-heading mw-heading2"><h2 id="History">History</h2><span class="mw-editsection"><span class="mw-editsection-bracket">[</span><a href="/w/index.php?title=Go&amp;action=edit&amp;section=1">edit</a><span class="mw-editsection-bracket">]</span></span></div>
+<p>Go was designed in 2007.</p>
+<div role="navigation" class="navbox"><a href="/wiki/Google">Google</a> products</div>
+</div></div>
+</div>
+<div id="catlinks" class="catlinks">Categories: Programming languages</div>
+<footer id="footer">This page was last edited on 1 January 2026.</footer>
+</body></html>`
+
+// TestWikipediaRuleMatch verifies the rule matches Wikimedia project URLs with HTML content.
+func TestWikipediaRuleMatch(t *testing.T) {
+	rule := NewWikipediaRule()
+
+	tests := []struct {
+		url         string
+		contentType string
+		shouldMatch bool
+	}{
+		{"https://en.wikipedia.org/wiki/Go_(programming_language)", "text/html; charset=UTF-8", true},
+		{"https://de.m.wikipedia.org/wiki/Go", "text/html", true},
+		{"https://en.wiktionary.org/wiki/surf", "text/html", true},
+		{"https://www.mediawiki.org/wiki/MediaWiki", "application/xhtml+xml", true},
+		{"https://en.wikipedia.org/w/api.php", "application/json", false},
+		{"https://notwikipedia.org/wiki/Go", "text/html", false},
+		{"https://example.com/?ref=wikipedia.org", "text/html", false},
+	}
+
+	for _, tt := range tests {
+		result := rule.Match(tt.url, tt.contentType)
+		assert.Equal(t, tt.shouldMatch, result, "url=%s, contentType=%s", tt.url, tt.contentType)
+	}
+}
+
+// TestWikipediaRuleApply verifies the article title and body are kept without the chrome in
+// and around them.
+func TestWikipediaRuleApply(t *testing.T) {
+	result := string(NewWikipediaRule().Apply([]byte(wikipediaArticle)))
+
+	assert.Contains(t, result, "<h1>Go (programming language)</h1>")
+	assert.Contains(t, result, "<b>Go</b> is a statically typed language designed at Google.</p>")
+	assert.Contains(t, result, `<h2 id="History">History</h2></div>`, "edit links should be removed from headings")
+	assert.Contains(t, result, "Go was designed in 2007.")
+
+	for _, chrome := range []string{"Jump to content", "Random article", "Programming language<", "Multi-paradigm", "[1]", "edit", "products", "Categories", "last edited", ".x{}"} {
+		assert.NotContains(t, result, chrome)
+	}
+}
+
+// TestWikipediaRuleApplyNonArticle verifies pages without a MediaWiki content area are unchanged.
+func TestWikipediaRuleApplyNonArticle(t *testing.T) {
+	content := []byte(`<html><body><p>Search results</p></body></html>`)
+	assert.Equal(t, content, NewWikipediaRule().Apply(content))
+}
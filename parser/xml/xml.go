@@ -0,0 +1,292 @@
+package xml
+
+import (
+	"bytes"
+	"context"
+	encxml "encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// defaultMaxRows is how many records are rendered as table rows by default.
+	defaultMaxRows = 500
+	// indent is the indentation used per nesting level when pretty-printing.
+	indent = "  "
+)
+
+// sitemapRoots are root elements of documents handled by the sitemap package, which are
+// passed through unchanged so they remain machine-readable.
+var sitemapRoots = map[string]bool{
+	"urlset":       true,
+	"sitemapindex": true,
+}
+
+// Parser converts XML into a readable form: record-like documents become markdown tables and
+// everything else is pretty-printed with indentation.
+type Parser struct {
+	maxRows int
+}
+
+// Option is a functional option for configuring the Parser.
+type Option func(*Parser)
+
+// WithMaxRows sets how many records are rendered as table rows (default: 500).
+func WithMaxRows(n int) Option {
+	return func(p *Parser) {
+		if n > 0 {
+			p.maxRows = n
+		}
+	}
+}
+
+// New creates a new XML parser.
+func New(opts ...Option) *Parser {
+	p := &Parser{maxRows: defaultMaxRows}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// node is an element in a parsed XML document.
+type node struct {
+	name     string
+	attrs    []encxml.Attr
+	text     string
+	children []*node
+}
+
+// Parse converts XML into a markdown table or indented XML. Sitemaps and malformed documents
+// are returned unchanged.
+func (p *Parser) Parse(ctx context.Context, content []byte) ([]byte, error) {
+	if len(content) == 0 {
+		return content, nil
+	}
+
+	root, err := parseTree(content)
+	if err != nil || root == nil || sitemapRoots[root.name] {
+		return content, nil
+	}
+
+	var buf bytes.Buffer
+	if container, ok := findRecords(root); ok {
+		p.writeTable(&buf, container)
+	} else {
+		writeNode(&buf, root, 0)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// parseTree decodes content into a tree of elements, dropping comments, processing
+// instructions, and whitespace-only text.
+func parseTree(content []byte) (*node, error) {
+	decoder := encxml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+	decoder.Entity = encxml.HTMLEntity
+
+	var (
+		root  *node
+		stack []*node
+	)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode xml: %w", err)
+		}
+
+		switch t := token.(type) {
+		case encxml.StartElement:
+			// Namespace URLs are dropped since the original prefixes are lost in decoding.
+			n := &node{name: t.Name.Local, attrs: visibleAttrs(t.Attr)}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, fmt.Errorf("multiple root elements")
+				}
+				root = n
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			}
+			stack = append(stack, n)
+		case encxml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case encxml.CharData:
+			if len(stack) > 0 {
+				if text := strings.Join(strings.Fields(string(t)), " "); text != "" {
+					current := stack[len(stack)-1]
+					if current.text != "" {
+						current.text += " "
+					}
+					current.text += text
+				}
+			}
+		}
+	}
+
+	return root, nil
+}
+
+// visibleAttrs returns attrs without namespace declarations.
+func visibleAttrs(attrs []encxml.Attr) []encxml.Attr {
+	var visible []encxml.Attr
+	for _, attr := range attrs {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		visible = append(visible, attr)
+	}
+	return visible
+}
+
+// findRecords descends through single-child wrapper elements and returns the first element
+// whose children are two or more same-named, flat records.
+func findRecords(n *node) (*node, bool) {
+	for len(n.children) == 1 && n.text == "" {
+		n = n.children[0]
+	}
+
+	if len(n.children) < 2 {
+		return nil, false
+	}
+
+	name := n.children[0].name
+	for _, record := range n.children {
+		if record.name != name || !isFlat(record) {
+			return nil, false
+		}
+	}
+
+	return n, true
+}
+
+// isFlat reports whether a record has only leaf fields, so it fits in a table row.
+func isFlat(record *node) bool {
+	if record.text != "" && len(record.children) > 0 {
+		return false
+	}
+	for _, field := range record.children {
+		if len(field.children) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// writeTable renders the records under container as a markdown table. Attributes become
+// columns prefixed with "@", and repeated fields are joined with "; ".
+func (p *Parser) writeTable(buf *bytes.Buffer, container *node) {
+	var columns []string
+	seen := make(map[string]bool)
+	addColumn := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			columns = append(columns, name)
+		}
+	}
+
+	for _, record := range container.children {
+		for _, attr := range record.attrs {
+			addColumn("@" + attr.Name.Local)
+		}
+		if record.text != "" {
+			addColumn(record.name)
+		}
+		for _, field := range record.children {
+			addColumn(field.name)
+		}
+	}
+
+	fmt.Fprintf(buf, "## %s\n\n", container.name)
+
+	buf.WriteString("|")
+	for _, column := range columns {
+		fmt.Fprintf(buf, " %s |", escapeCell(column))
+	}
+	buf.WriteString("\n|")
+	for range columns {
+		buf.WriteString(" --- |")
+	}
+	buf.WriteString("\n")
+
+	records := container.children
+	if len(records) > p.maxRows {
+		records = records[:p.maxRows]
+	}
+
+	for _, record := range records {
+		values := make(map[string][]string)
+		for _, attr := range record.attrs {
+			values["@"+attr.Name.Local] = append(values["@"+attr.Name.Local], attr.Value)
+		}
+		if record.text != "" {
+			values[record.name] = append(values[record.name], record.text)
+		}
+		for _, field := range record.children {
+			values[field.name] = append(values[field.name], field.text)
+		}
+
+		buf.WriteString("|")
+		for _, column := range columns {
+			fmt.Fprintf(buf, " %s |", escapeCell(strings.Join(values[column], "; ")))
+		}
+		buf.WriteString("\n")
+	}
+
+	if omitted := len(container.children) - len(records); omitted > 0 {
+		fmt.Fprintf(buf, "\n_%d more %s records omitted._\n", omitted, container.children[0].name)
+	}
+}
+
+// escapeCell escapes pipes so a value stays within its table cell.
+func escapeCell(value string) string {
+	return strings.ReplaceAll(value, "|", `\|`)
+}
+
+// writeNode pretty-prints an element and its children with one level of indentation per depth.
+func writeNode(buf *bytes.Buffer, n *node, depth int) {
+	prefix := strings.Repeat(indent, depth)
+
+	buf.WriteString(prefix)
+	buf.WriteString("<")
+	buf.WriteString(n.name)
+	for _, attr := range n.attrs {
+		fmt.Fprintf(buf, ` %s="%s"`, attr.Name.Local, escapeText(attr.Value))
+	}
+
+	if n.text == "" && len(n.children) == 0 {
+		buf.WriteString("/>\n")
+		return
+	}
+	buf.WriteString(">")
+
+	if len(n.children) == 0 {
+		buf.WriteString(escapeText(n.text))
+		fmt.Fprintf(buf, "</%s>\n", n.name)
+		return
+	}
+
+	buf.WriteString("\n")
+	if n.text != "" {
+		buf.WriteString(prefix + indent + escapeText(n.text) + "\n")
+	}
+	for _, child := range n.children {
+		writeNode(buf, child, depth+1)
+	}
+	fmt.Fprintf(buf, "%s</%s>\n", prefix, n.name)
+}
+
+// escapeText escapes characters that would otherwise be read as markup.
+func escapeText(text string) string {
+	var buf bytes.Buffer
+	_ = encxml.EscapeText(&buf, []byte(text))
+	return buf.String()
+}
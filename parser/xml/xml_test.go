@@ -0,0 +1,80 @@
+package xml
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestXMLParseRecordsAsTable verifies record-like XML is rendered as a markdown table.
+func TestXMLParseRecordsAsTable(t *testing.T) {
+	content := `<?xml version="1.0"?>
+<response>
+  <books>
+    <book id="1"><title>Go | Programming</title><author>Alan</author><author>Brian</author></book>
+    <book id="2"><title>SICP</title><year>1985</year></book>
+  </books>
+</response>`
+
+	out, err := New().Parse(context.Background(), []byte(content))
+	require.NoError(t, err)
+
+	expected := `## books
+
+| @id | title | author | year |
+| --- | --- | --- | --- |
+| 1 | Go \| Programming | Alan; Brian |  |
+| 2 | SICP |  | 1985 |
+`
+	assert.Equal(t, expected, string(out))
+}
+
+// TestXMLParseMaxRows verifies tables are capped with a note about omitted records.
+func TestXMLParseMaxRows(t *testing.T) {
+	content := `<items><item>a</item><item>b</item><item>c</item></items>`
+
+	out, err := New(WithMaxRows(2)).Parse(context.Background(), []byte(content))
+	require.NoError(t, err)
+
+	assert.Contains(t, string(out), "| a |\n| b |\n")
+	assert.NotContains(t, string(out), "| c |")
+	assert.Contains(t, string(out), "_1 more item records omitted._")
+}
+
+// TestXMLParsePrettyPrint verifies nested XML is re-indented with comments and blank text removed.
+func TestXMLParsePrettyPrint(t *testing.T) {
+	content := `<config xmlns="urn:example"><!-- comment --><server port="80"><name>web &amp; api</name><tls/></server><debug>true</debug></config>`
+
+	out, err := New().Parse(context.Background(), []byte(content))
+	require.NoError(t, err)
+
+	expected := `<config>
+  <server port="80">
+    <name>web &amp; api</name>
+    <tls/>
+  </server>
+  <debug>true</debug>
+</config>
+`
+	assert.Equal(t, expected, string(out))
+}
+
+// TestXMLParseSitemapUnchanged verifies sitemaps pass through so they stay machine-readable.
+func TestXMLParseSitemapUnchanged(t *testing.T) {
+	content := []byte(`<urlset><url><loc>https://example.com/</loc></url><url><loc>https://example.com/a</loc></url></urlset>`)
+
+	out, err := New().Parse(context.Background(), content)
+	require.NoError(t, err)
+	assert.Equal(t, content, out)
+}
+
+// TestXMLParseMalformedUnchanged verifies content that isn't XML is returned as-is.
+func TestXMLParseMalformedUnchanged(t *testing.T) {
+	content := []byte(`not xml at all`)
+
+	out, err := New().Parse(context.Background(), content)
+	require.NoError(t, err)
+	assert.Equal(t, content, out)
+}
@@ -0,0 +1,123 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/joeychilson/websurfer/config"
+)
+
+// ErrViolation is returned when a URL is not permitted by the server's domain policy.
+var ErrViolation = errors.New("blocked by domain policy")
+
+// Policy decides which URLs the server may reach.
+type Policy struct {
+	allowedDomains  []string
+	blockedDomains  []string
+	blockedTLDs     []string
+	allowedPatterns []*regexp.Regexp
+	blockedPatterns []*regexp.Regexp
+}
+
+// New compiles the policy configuration. It returns nil if cfg places no restrictions.
+func New(cfg config.PolicyConfig) (*Policy, error) {
+	if cfg.IsEmpty() {
+		return nil, nil
+	}
+
+	p := &Policy{
+		allowedDomains: normalizeDomains(cfg.AllowedDomains),
+		blockedDomains: normalizeDomains(cfg.BlockedDomains),
+	}
+	for _, tld := range cfg.BlockedTLDs {
+		p.blockedTLDs = append(p.blockedTLDs, strings.Trim(strings.ToLower(tld), ". "))
+	}
+
+	var err error
+	if p.allowedPatterns, err = compilePatterns(cfg.AllowedPatterns); err != nil {
+		return nil, fmt.Errorf("invalid allowed_patterns: %w", err)
+	}
+	if p.blockedPatterns, err = compilePatterns(cfg.BlockedPatterns); err != nil {
+		return nil, fmt.Errorf("invalid blocked_patterns: %w", err)
+	}
+
+	return p, nil
+}
+
+// Check returns an error wrapping ErrViolation if rawURL is not permitted. Blocks take
+// precedence over allows; when any allow rule is set, URLs must match one of them. A nil
+// Policy permits every URL.
+func (p *Policy) Check(rawURL string) error {
+	if p == nil {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+
+	for _, tld := range p.blockedTLDs {
+		if host == tld || strings.HasSuffix(host, "."+tld) {
+			return fmt.Errorf("%w: top-level domain .%s is blocked", ErrViolation, tld)
+		}
+	}
+	for _, domain := range p.blockedDomains {
+		if matchesDomain(host, domain) {
+			return fmt.Errorf("%w: domain %s is blocked", ErrViolation, domain)
+		}
+	}
+	for _, pattern := range p.blockedPatterns {
+		if pattern.MatchString(rawURL) {
+			return fmt.Errorf("%w: url matches blocked pattern %q", ErrViolation, pattern.String())
+		}
+	}
+
+	if len(p.allowedDomains) == 0 && len(p.allowedPatterns) == 0 {
+		return nil
+	}
+	for _, domain := range p.allowedDomains {
+		if matchesDomain(host, domain) {
+			return nil
+		}
+	}
+	for _, pattern := range p.allowedPatterns {
+		if pattern.MatchString(rawURL) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not an allowed domain", ErrViolation, host)
+}
+
+// matchesDomain reports whether host is domain or one of its subdomains.
+func matchesDomain(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// normalizeDomains lowercases domains and strips wildcard prefixes, since every domain
+// also matches its subdomains.
+func normalizeDomains(domains []string) []string {
+	normalized := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "*.")
+		normalized = append(normalized, strings.Trim(domain, "."))
+	}
+	return normalized
+}
+
+// compilePatterns compiles regular expressions matched against full URLs.
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
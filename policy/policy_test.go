@@ -0,0 +1,65 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joeychilson/websurfer/config"
+)
+
+// TestCheckBlocked verifies blocked domains, TLDs, and patterns are rejected with ErrViolation.
+func TestCheckBlocked(t *testing.T) {
+	p, err := New(config.PolicyConfig{
+		BlockedDomains:  []string{"*.Evil.com"},
+		BlockedTLDs:     []string{".zip"},
+		BlockedPatterns: []string{`/admin(/|$)`},
+	})
+	require.NoError(t, err)
+
+	for _, u := range []string{
+		"https://evil.com/page",
+		"https://cdn.evil.com/page",
+		"https://download.zip/file",
+		"https://example.com/admin/users",
+	} {
+		assert.ErrorIs(t, p.Check(u), ErrViolation, u)
+	}
+
+	for _, u := range []string{
+		"https://notevil.com",
+		"https://example.com/administrator",
+		"https://example.com",
+	} {
+		assert.NoError(t, p.Check(u), u)
+	}
+}
+
+// TestCheckAllowed verifies that with allow rules, only matching URLs pass and blocks still win.
+func TestCheckAllowed(t *testing.T) {
+	p, err := New(config.PolicyConfig{
+		AllowedDomains:  []string{"example.com"},
+		AllowedPatterns: []string{`^https://docs\.partner\.io/`},
+		BlockedDomains:  []string{"private.example.com"},
+	})
+	require.NoError(t, err)
+
+	assert.NoError(t, p.Check("https://example.com"))
+	assert.NoError(t, p.Check("https://www.example.com/a"))
+	assert.NoError(t, p.Check("https://docs.partner.io/guide"))
+	assert.ErrorIs(t, p.Check("https://partner.io"), ErrViolation)
+	assert.ErrorIs(t, p.Check("https://other.org"), ErrViolation)
+	assert.ErrorIs(t, p.Check("https://private.example.com"), ErrViolation)
+}
+
+// TestNewEmpty verifies an empty policy is nil and permits every URL.
+func TestNewEmpty(t *testing.T) {
+	p, err := New(config.PolicyConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, p)
+	assert.NoError(t, p.Check("https://anything.example"))
+
+	_, err = New(config.PolicyConfig{BlockedPatterns: []string{"("}})
+	assert.Error(t, err)
+}
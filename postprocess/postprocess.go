@@ -0,0 +1,157 @@
+// Package postprocess applies a configurable chain of processing steps, such as normalizing,
+// redacting, or translating, to parsed page content.
+package postprocess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/joeychilson/websurfer/translate"
+)
+
+// Step types, in the order they are usually chained.
+const (
+	// StepNormalize normalizes Unicode and whitespace.
+	StepNormalize = "normalize"
+	// StepStripBoilerplate removes cookie notices, share prompts, and similar lines.
+	StepStripBoilerplate = "strip_boilerplate"
+	// StepRedact replaces personal data such as email addresses and phone numbers.
+	StepRedact = "redact"
+	// StepTranslate translates the content to another language.
+	StepTranslate = "translate"
+	// StepSummarize extracts a short summary of the content.
+	StepSummarize = "summarize"
+)
+
+// ErrNoTranslator is returned when a chain has a translate step but no translator is available.
+var ErrNoTranslator = errors.New("translate step requires a translator")
+
+// Document is the content a chain processes. Steps edit it in place.
+type Document struct {
+	URL         string
+	ContentType string
+	Content     string
+	// Language is the content's language, if known. OriginalLanguage is set when a step
+	// translated the content from it.
+	Language         string
+	OriginalLanguage string
+	// Summary is set by the summarize step.
+	Summary string
+}
+
+// Processor is a single processing step.
+type Processor interface {
+	// Name returns the step type, such as StepRedact.
+	Name() string
+	// Process edits the document in place.
+	Process(ctx context.Context, doc *Document) error
+}
+
+// Spec configures a step of a chain.
+type Spec struct {
+	// Type is the step type, such as StepNormalize.
+	Type string `json:"type" yaml:"type"`
+	// Language is the target language of a translate step, such as "en".
+	Language string `json:"language,omitempty" yaml:"language,omitempty"`
+	// Redact lists the kinds of data a redact step replaces (default: all).
+	Redact []string `json:"redact,omitempty" yaml:"redact,omitempty"`
+	// MaxSentences is the length of a summarize step's summary (default: 3).
+	MaxSentences int `json:"max_sentences,omitempty" yaml:"max_sentences,omitempty"`
+}
+
+// Chain runs processors in order.
+type Chain []Processor
+
+// New builds the chain described by specs. translator is used by translate steps and may be
+// nil when there are none.
+func New(specs []Spec, translator translate.Translator) (Chain, error) {
+	chain := make(Chain, 0, len(specs))
+	for i, spec := range specs {
+		p, err := newProcessor(spec, translator)
+		if err != nil {
+			return nil, fmt.Errorf("processors[%d]: %w", i, err)
+		}
+		chain = append(chain, p)
+	}
+	return chain, nil
+}
+
+// Validate reports whether specs describe a valid chain, without building it.
+func Validate(specs []Spec) error {
+	for i, spec := range specs {
+		if err := spec.validate(); err != nil {
+			return fmt.Errorf("processors[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Run runs each processor on doc in order, stopping at the first error.
+func (c Chain) Run(ctx context.Context, doc *Document) error {
+	for _, p := range c {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := p.Process(ctx, doc); err != nil {
+			return fmt.Errorf("%s: %w", p.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Names returns the step types of the chain, in order.
+func (c Chain) Names() []string {
+	names := make([]string, len(c))
+	for i, p := range c {
+		names[i] = p.Name()
+	}
+	return names
+}
+
+// validate checks the spec's type and options.
+func (s Spec) validate() error {
+	switch s.Type {
+	case StepNormalize, StepStripBoilerplate, StepSummarize:
+	case StepRedact:
+		for _, kind := range s.Redact {
+			if _, ok := redactPatterns[kind]; !ok {
+				return fmt.Errorf("unknown redact kind %q (supported: %s, %s, %s, %s)", kind, RedactEmail, RedactPhone, RedactCreditCard, RedactIP)
+			}
+		}
+	case StepTranslate:
+		if s.Language == "" {
+			return fmt.Errorf("translate step requires a language")
+		}
+	case "":
+		return fmt.Errorf("type cannot be empty")
+	default:
+		return fmt.Errorf("unknown type %q (supported: %s, %s, %s, %s, %s)", s.Type, StepNormalize, StepStripBoilerplate, StepRedact, StepTranslate, StepSummarize)
+	}
+	if s.MaxSentences < 0 {
+		return fmt.Errorf("max_sentences must be non-negative")
+	}
+	return nil
+}
+
+// newProcessor creates the processor described by spec.
+func newProcessor(spec Spec, translator translate.Translator) (Processor, error) {
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+	switch spec.Type {
+	case StepNormalize:
+		return Normalize{}, nil
+	case StepStripBoilerplate:
+		return StripBoilerplate{}, nil
+	case StepRedact:
+		return Redact{Kinds: spec.Redact}, nil
+	case StepTranslate:
+		if translator == nil {
+			return nil, ErrNoTranslator
+		}
+		return Translate{Translator: translator, Target: spec.Language}, nil
+	default:
+		return Summarize{MaxSentences: spec.MaxSentences}, nil
+	}
+}
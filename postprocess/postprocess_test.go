@@ -0,0 +1,142 @@
+package postprocess
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// upperTranslator translates text by upper-casing it.
+type upperTranslator struct{}
+
+// Translate implements translate.Translator.
+func (upperTranslator) Translate(ctx context.Context, texts []string, source, target string) ([]string, error) {
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		translated[i] = strings.ToUpper(text)
+	}
+	return translated, nil
+}
+
+// TestNew verifies chains are built in order and invalid specs are rejected.
+func TestNew(t *testing.T) {
+	chain, err := New([]Spec{{Type: StepNormalize}, {Type: StepRedact, Redact: []string{RedactEmail}}, {Type: StepSummarize}}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{StepNormalize, StepRedact, StepSummarize}, chain.Names())
+
+	_, err = New([]Spec{{Type: StepTranslate, Language: "en"}}, nil)
+	assert.ErrorIs(t, err, ErrNoTranslator)
+
+	tests := []struct {
+		name string
+		spec Spec
+		want string
+	}{
+		{"empty type", Spec{}, "type cannot be empty"},
+		{"unknown type", Spec{Type: "shout"}, "unknown type"},
+		{"unknown redact kind", Spec{Type: StepRedact, Redact: []string{"ssn"}}, "unknown redact kind"},
+		{"translate without language", Spec{Type: StepTranslate}, "requires a language"},
+		{"negative max_sentences", Spec{Type: StepSummarize, MaxSentences: -1}, "non-negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate([]Spec{{Type: StepNormalize}, tt.spec})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "processors[1]")
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+// TestChainRun verifies steps see the output of the steps before them.
+func TestChainRun(t *testing.T) {
+	chain, err := New([]Spec{
+		{Type: StepNormalize},
+		{Type: StepStripBoilerplate},
+		{Type: StepRedact},
+		{Type: StepTranslate, Language: "en"},
+	}, upperTranslator{})
+	require.NoError(t, err)
+
+	doc := &Document{Content: "Skip to content\r\n\n\n\nKontakt: anna@example.de   \n\n© 2024 Beispiel GmbH", Language: "de"}
+	require.NoError(t, chain.Run(context.Background(), doc))
+	assert.Equal(t, "KONTAKT: [REDACTED:EMAIL]", doc.Content)
+	assert.Equal(t, "en", doc.Language)
+	assert.Equal(t, "de", doc.OriginalLanguage)
+}
+
+// TestNormalize verifies Unicode, invisible characters, and whitespace are normalized.
+func TestNormalize(t *testing.T) {
+	doc := &Document{Content: "\n\nCafe\u0301 bar\u200b\u00a0 \r\n\n\n\n\n  indented\n"}
+	require.NoError(t, Normalize{}.Process(context.Background(), doc))
+	assert.Equal(t, "Caf\u00e9 bar\n\n  indented", doc.Content)
+}
+
+// TestStripBoilerplate verifies site chrome is removed while prose and code are kept.
+func TestStripBoilerplate(t *testing.T) {
+	content := strings.Join([]string{
+		"[Skip to main content](#main)",
+		"# Release notes",
+		"We use cookies to improve your experience.",
+		"Our cookie policy changed this year, and this paragraph explains at some length why we use cookies and how to accept all cookies or reject them.",
+		"```",
+		"Back to top",
+		"```",
+		"- Share on Twitter",
+		"Back to top",
+		"Copyright 2024 Example Inc. All rights reserved.",
+	}, "\n")
+
+	doc := &Document{Content: content}
+	require.NoError(t, StripBoilerplate{}.Process(context.Background(), doc))
+	assert.Equal(t, strings.Join([]string{
+		"# Release notes",
+		"Our cookie policy changed this year, and this paragraph explains at some length why we use cookies and how to accept all cookies or reject them.",
+		"```",
+		"Back to top",
+		"```",
+	}, "\n"), doc.Content)
+}
+
+// TestRedact verifies each kind of personal data is replaced and look-alikes are kept.
+func TestRedact(t *testing.T) {
+	content := "Mail jane.doe@example.com or call (555) 123-4567. Card 4111 1111 1111 1111, order 1234567890123. Server 192.168.1.20, version 1.2.3.4567."
+
+	doc := &Document{Content: content}
+	require.NoError(t, Redact{}.Process(context.Background(), doc))
+	assert.Equal(t, "Mail [REDACTED:email] or call [REDACTED:phone]. Card [REDACTED:credit_card], order 1234567890123. Server [REDACTED:ip], version 1.2.3.4567.", doc.Content)
+
+	doc = &Document{Content: content}
+	require.NoError(t, Redact{Kinds: []string{RedactEmail}}.Process(context.Background(), doc))
+	assert.Equal(t, strings.Replace(content, "jane.doe@example.com", "[REDACTED:email]", 1), doc.Content)
+}
+
+// TestTranslateSameLanguage verifies content already in the target language is left alone.
+func TestTranslateSameLanguage(t *testing.T) {
+	doc := &Document{Content: "The quick brown fox jumps over the lazy dog and then it is gone.", Language: "en-US"}
+	require.NoError(t, Translate{Translator: upperTranslator{}, Target: "en"}.Process(context.Background(), doc))
+	assert.Equal(t, "The quick brown fox jumps over the lazy dog and then it is gone.", doc.Content)
+	assert.Empty(t, doc.OriginalLanguage)
+}
+
+// TestSummarize verifies the sentences sharing the most frequent words are picked, in order.
+func TestSummarize(t *testing.T) {
+	content := strings.Join([]string{
+		"# Rust ownership",
+		"Rust ownership rules govern memory. The weather was nice today.",
+		"",
+		"- Ownership moves values between variables.",
+		"```",
+		"let ownership = rules; // ownership memory rules",
+		"```",
+		"Borrowing lets code use memory without taking ownership. Lunch was pasta.",
+	}, "\n")
+
+	doc := &Document{Content: content}
+	require.NoError(t, Summarize{MaxSentences: 2}.Process(context.Background(), doc))
+	assert.Equal(t, "Rust ownership rules govern memory. Borrowing lets code use memory without taking ownership.", doc.Summary)
+	assert.Equal(t, content, doc.Content)
+}
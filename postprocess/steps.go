@@ -0,0 +1,330 @@
+package postprocess
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/joeychilson/websurfer/langdetect"
+	"github.com/joeychilson/websurfer/translate"
+)
+
+// Kinds of data the redact step replaces.
+const (
+	RedactEmail      = "email"
+	RedactPhone      = "phone"
+	RedactCreditCard = "credit_card"
+	RedactIP         = "ip"
+)
+
+const (
+	// defaultSummarySentences is the summary length when MaxSentences is unset.
+	defaultSummarySentences = 3
+	// maxBoilerplateLine is the longest line strip_boilerplate considers, so sentences that
+	// merely mention cookies or newsletters are kept.
+	maxBoilerplateLine = 120
+)
+
+var (
+	// blankRunRegex matches three or more line breaks, leaving at most one blank line.
+	blankRunRegex = regexp.MustCompile(`\n{3,}`)
+	// invisibleReplacer removes zero-width characters and turns non-breaking spaces into spaces.
+	invisibleReplacer = strings.NewReplacer("\u200b", "", "\u200c", "", "\u200d", "", "\ufeff", "", "\u00a0", " ", "\r\n", "\n", "\r", "\n")
+
+	// boilerplateRegexes match whole lines of site chrome that parsers leave in the content.
+	boilerplateRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^\[?skip to (main )?(content|navigation)\]?`),
+		regexp.MustCompile(`(?i)\b(accept|reject|manage) (all )?cookies\b`),
+		regexp.MustCompile(`(?i)^(this (web)?site|we) uses? cookies\b`),
+		regexp.MustCompile(`(?i)^(share|tweet|post) (this|on) (facebook|twitter|x|linkedin|reddit|email)?`),
+		regexp.MustCompile(`(?i)^(follow us|subscribe to (our|the) newsletter|sign up for (our|the) newsletter)\b`),
+		regexp.MustCompile(`(?i)^(back to top|scroll to top|print this page)$`),
+		regexp.MustCompile(`(?i)^(©|\(c\)|copyright)\s*\d{4}`),
+		regexp.MustCompile(`(?i)\ball rights reserved\.?$`),
+		regexp.MustCompile(`(?i)^(advertisement|sponsored content)$`),
+	}
+
+	// redactPatterns match each kind of redacted data. Matches are checked by redactValid.
+	redactPatterns = map[string]*regexp.Regexp{
+		RedactEmail:      regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+		RedactPhone:      regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{3}\)|\b\d{3})[ .-]\d{3}[ .-]\d{4}\b`),
+		RedactCreditCard: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		RedactIP:         regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}\b`),
+	}
+	// redactOrder applies card numbers before phone numbers, whose pattern they can contain.
+	redactOrder = []string{RedactEmail, RedactCreditCard, RedactPhone, RedactIP}
+
+	// sentenceRegex splits prose into sentences, which end in punctuation followed by a space.
+	sentenceRegex = regexp.MustCompile(`.+?[.!?]+["')\]]*(?:\s+|$)`)
+	// listItemRegex matches the marker of a markdown list item.
+	listItemRegex = regexp.MustCompile(`^([-*+]|\d+[.)])\s`)
+	// wordRegex matches the words summaries are scored by.
+	wordRegex = regexp.MustCompile(`[\p{L}\p{N}]+`)
+	// summaryStopwords are frequent words that say nothing about a sentence's topic.
+	summaryStopwords = map[string]bool{
+		"the": true, "and": true, "that": true, "this": true, "with": true, "for": true, "are": true,
+		"was": true, "were": true, "from": true, "have": true, "has": true, "had": true, "not": true,
+		"but": true, "you": true, "your": true, "its": true, "they": true, "their": true, "which": true,
+		"will": true, "can": true, "been": true, "also": true, "more": true, "than": true, "into": true,
+		"there": true, "these": true, "those": true, "when": true, "what": true, "such": true,
+	}
+)
+
+// Normalize normalizes the content to Unicode NFC, removes zero-width characters, trims
+// trailing whitespace, and collapses runs of blank lines.
+type Normalize struct{}
+
+// Name returns StepNormalize.
+func (Normalize) Name() string { return StepNormalize }
+
+// Process normalizes doc's content.
+func (Normalize) Process(_ context.Context, doc *Document) error {
+	content := invisibleReplacer.Replace(norm.NFC.String(doc.Content))
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRightFunc(line, unicode.IsSpace)
+	}
+	doc.Content = strings.TrimSpace(blankRunRegex.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+	return nil
+}
+
+// StripBoilerplate removes short lines of site chrome, such as cookie notices, skip links,
+// share prompts, and copyright footers. Code blocks are left untouched.
+type StripBoilerplate struct{}
+
+// Name returns StepStripBoilerplate.
+func (StripBoilerplate) Name() string { return StepStripBoilerplate }
+
+// Process removes boilerplate lines from doc's content.
+func (StripBoilerplate) Process(_ context.Context, doc *Document) error {
+	lines := strings.Split(doc.Content, "\n")
+	kept := lines[:0]
+	inFence, removed := false, false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+		}
+		if !inFence && isBoilerplate(trimmed) {
+			removed = true
+			continue
+		}
+		// Blank lines left next to a removed line are dropped, so no gaps are left behind.
+		if removed && trimmed == "" && (len(kept) == 0 || strings.TrimSpace(kept[len(kept)-1]) == "") {
+			continue
+		}
+		removed = false
+		kept = append(kept, line)
+	}
+	for len(kept) > 0 && removed && strings.TrimSpace(kept[len(kept)-1]) == "" {
+		kept = kept[:len(kept)-1]
+	}
+	doc.Content = strings.Join(kept, "\n")
+	return nil
+}
+
+// isBoilerplate reports whether a line, without its list or heading markers, is site chrome.
+func isBoilerplate(line string) bool {
+	line = strings.TrimSpace(strings.TrimLeft(line, "#*->| "))
+	if line == "" || len(line) > maxBoilerplateLine {
+		return false
+	}
+	for _, re := range boilerplateRegexes {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact replaces personal data in the content with a placeholder naming its kind, such as
+// "[REDACTED:email]". Kinds lists the kinds replaced, or all of them when empty.
+type Redact struct {
+	Kinds []string
+}
+
+// Name returns StepRedact.
+func (Redact) Name() string { return StepRedact }
+
+// Process redacts doc's content.
+func (r Redact) Process(_ context.Context, doc *Document) error {
+	for _, kind := range redactOrder {
+		if len(r.Kinds) > 0 && !slices.Contains(r.Kinds, kind) {
+			continue
+		}
+		placeholder := "[REDACTED:" + kind + "]"
+		doc.Content = redactPatterns[kind].ReplaceAllStringFunc(doc.Content, func(match string) string {
+			if !redactValid(kind, match) {
+				return match
+			}
+			return placeholder
+		})
+	}
+	return nil
+}
+
+// redactValid filters out pattern matches that are not the kind of data they look like.
+func redactValid(kind, match string) bool {
+	switch kind {
+	case RedactCreditCard:
+		return luhnValid(match)
+	case RedactIP:
+		return net.ParseIP(match) != nil
+	default:
+		return true
+	}
+}
+
+// luhnValid reports whether the digits of s pass the Luhn checksum used by card numbers.
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// Translate translates the content to Target unless it is already in that language. The
+// source language is doc.Language, or detected from the content when unknown.
+type Translate struct {
+	Translator translate.Translator
+	Target     string
+}
+
+// Name returns StepTranslate.
+func (Translate) Name() string { return StepTranslate }
+
+// Process translates doc's content.
+func (t Translate) Process(ctx context.Context, doc *Document) error {
+	source := doc.Language
+	if source == "" {
+		source = langdetect.Detect(doc.Content)
+	}
+	if baseLanguage(source) == baseLanguage(t.Target) || strings.TrimSpace(doc.Content) == "" {
+		return nil
+	}
+
+	translated, err := translate.Markdown(ctx, t.Translator, doc.Content, baseLanguage(source), t.Target)
+	if err != nil {
+		return fmt.Errorf("failed to translate content: %w", err)
+	}
+	doc.Content = translated
+	if doc.OriginalLanguage == "" {
+		doc.OriginalLanguage = source
+	}
+	doc.Language = t.Target
+	return nil
+}
+
+// Summarize sets doc.Summary to the MaxSentences sentences of the content whose words are
+// most frequent in it, in their original order. Headings, list items, tables, and code are
+// not summarized.
+type Summarize struct {
+	MaxSentences int
+}
+
+// Name returns StepSummarize.
+func (Summarize) Name() string { return StepSummarize }
+
+// Process summarizes doc's content.
+func (s Summarize) Process(_ context.Context, doc *Document) error {
+	limit := s.MaxSentences
+	if limit == 0 {
+		limit = defaultSummarySentences
+	}
+
+	sentences := proseSentences(doc.Content)
+	freq := make(map[string]int)
+	for _, sentence := range sentences {
+		for _, word := range summaryWords(sentence) {
+			freq[word]++
+		}
+	}
+
+	type scored struct {
+		index int
+		score float64
+	}
+	ranked := make([]scored, 0, len(sentences))
+	for i, sentence := range sentences {
+		words := summaryWords(sentence)
+		if len(words) == 0 {
+			continue
+		}
+		total := 0
+		for _, word := range words {
+			total += freq[word]
+		}
+		ranked = append(ranked, scored{index: i, score: float64(total) / float64(len(words))})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].index < ranked[j].index })
+
+	picked := make([]string, len(ranked))
+	for i, r := range ranked {
+		picked[i] = sentences[r.index]
+	}
+	doc.Summary = strings.Join(picked, " ")
+	return nil
+}
+
+// proseSentences returns the sentences of the content's paragraphs, skipping code blocks and
+// lines that are markdown headings, list items, tables, or quotes.
+func proseSentences(content string) []string {
+	var sentences []string
+	inFence := false
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || trimmed == "" || strings.ContainsAny(trimmed[:1], "#|>![") || listItemRegex.MatchString(trimmed) {
+			continue
+		}
+		for _, sentence := range sentenceRegex.FindAllString(trimmed, -1) {
+			if sentence = strings.TrimSpace(sentence); sentence != "" {
+				sentences = append(sentences, sentence)
+			}
+		}
+	}
+	return sentences
+}
+
+// summaryWords returns the lowercase words of a sentence that say something about its topic.
+func summaryWords(sentence string) []string {
+	var words []string
+	for _, word := range wordRegex.FindAllString(strings.ToLower(sentence), -1) {
+		if len(word) > 2 && !summaryStopwords[word] {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// baseLanguage returns the lowercase primary subtag of a language tag, such as "pt" for "pt-BR".
+func baseLanguage(tag string) string {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	return base
+}
@@ -0,0 +1,123 @@
+package provenance
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// Algorithm is the signature algorithm reported in proofs.
+	Algorithm = "ed25519"
+	// version prefixes the signed message so the format can change without ambiguity.
+	version = "websurfer-provenance-v2"
+)
+
+// ErrInvalidSignature is returned when a proof does not verify.
+var ErrInvalidSignature = errors.New("invalid provenance signature")
+
+// Proof attests, by the holder of the key identified by KeyID, that a body hashing to
+// OriginSHA256 was fetched from URL at FetchedAt, and that content hashing to ContentSHA256
+// was derived from it by Transforms, in order.
+type Proof struct {
+	URL           string   `json:"url"`
+	OriginSHA256  string   `json:"origin_sha256"`
+	Transforms    []string `json:"transforms"`
+	ContentSHA256 string   `json:"content_sha256"`
+	FetchedAt     string   `json:"fetched_at"`
+	Algorithm     string   `json:"algorithm"`
+	KeyID         string   `json:"key_id"`
+	Signature     string   `json:"signature"`
+}
+
+// Signer signs proofs with an Ed25519 private key.
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+}
+
+// NewSigner creates a signer from a base64-encoded 32-byte Ed25519 seed.
+func NewSigner(seed string) (*Signer, error) {
+	raw, err := base64.StdEncoding.DecodeString(seed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key: %w", err)
+	}
+	if len(raw) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid signing key: expected %d bytes, got %d", ed25519.SeedSize, len(raw))
+	}
+
+	key := ed25519.NewKeyFromSeed(raw)
+	return &Signer{key: key, keyID: KeyID(key.Public().(ed25519.PublicKey))}, nil
+}
+
+// PublicKey returns the base64-encoded public key used to verify proofs.
+func (s *Signer) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+// KeyID returns the identifier of the signer's key.
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+// Sign returns a proof that a body hashing to originSHA256 was fetched from url at fetchedAt,
+// and that content was derived from it by transforms. Transform names must not contain spaces.
+func (s *Signer) Sign(url, originSHA256 string, transforms []string, content []byte, fetchedAt time.Time) *Proof {
+	if transforms == nil {
+		transforms = []string{}
+	}
+	proof := &Proof{
+		URL:           url,
+		OriginSHA256:  originSHA256,
+		Transforms:    transforms,
+		ContentSHA256: hashContent(content),
+		FetchedAt:     fetchedAt.UTC().Format(time.RFC3339),
+		Algorithm:     Algorithm,
+		KeyID:         s.keyID,
+	}
+	proof.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, proof.message()))
+	return proof
+}
+
+// Verify checks that proof was signed by publicKey (base64-encoded) and that content matches
+// its hash.
+func Verify(publicKey string, proof *Proof, content []byte) error {
+	raw, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key")
+	}
+	if proof.Algorithm != Algorithm {
+		return fmt.Errorf("unsupported algorithm: %s", proof.Algorithm)
+	}
+	if proof.ContentSHA256 != hashContent(content) {
+		return fmt.Errorf("content does not match hash: %w", ErrInvalidSignature)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(proof.Signature)
+	if err != nil || !ed25519.Verify(raw, proof.message(), signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// KeyID returns a short identifier for a public key: the first 8 bytes of its SHA-256, in hex.
+func KeyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:8])
+}
+
+// message returns the bytes covered by the signature.
+func (p *Proof) message() []byte {
+	return []byte(version + "\n" + p.URL + "\n" + p.OriginSHA256 + "\n" + strings.Join(p.Transforms, " ") + "\n" + p.ContentSHA256 + "\n" + p.FetchedAt)
+}
+
+// hashContent returns the hex SHA-256 of content.
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,70 @@
+package provenance
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSeed = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+
+// TestSignVerify verifies signed proofs verify against the public key and the original content.
+func TestSignVerify(t *testing.T) {
+	signer, err := NewSigner(testSeed)
+	require.NoError(t, err)
+
+	fetchedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	origin := hashContent([]byte("<p>hello</p>"))
+	proof := signer.Sign("https://example.com", origin, []string{"parse:markdown"}, []byte("hello"), fetchedAt)
+
+	assert.Equal(t, "2024-05-01T12:00:00Z", proof.FetchedAt)
+	assert.Equal(t, origin, proof.OriginSHA256)
+	assert.Equal(t, []string{"parse:markdown"}, proof.Transforms)
+	assert.Equal(t, Algorithm, proof.Algorithm)
+	assert.Equal(t, signer.KeyID(), proof.KeyID)
+	assert.Len(t, proof.ContentSHA256, 64)
+
+	require.NoError(t, Verify(signer.PublicKey(), proof, []byte("hello")))
+	assert.ErrorIs(t, Verify(signer.PublicKey(), proof, []byte("tampered")), ErrInvalidSignature)
+
+	forged := *proof
+	forged.URL = "https://evil.example"
+	assert.ErrorIs(t, Verify(signer.PublicKey(), &forged, []byte("hello")), ErrInvalidSignature)
+
+	forged = *proof
+	forged.FetchedAt = "2020-01-01T00:00:00Z"
+	assert.ErrorIs(t, Verify(signer.PublicKey(), &forged, []byte("hello")), ErrInvalidSignature)
+
+	forged = *proof
+	forged.OriginSHA256 = hashContent([]byte("other"))
+	assert.ErrorIs(t, Verify(signer.PublicKey(), &forged, []byte("hello")), ErrInvalidSignature)
+
+	forged = *proof
+	forged.Transforms = nil
+	assert.ErrorIs(t, Verify(signer.PublicKey(), &forged, []byte("hello")), ErrInvalidSignature)
+}
+
+// TestVerifyWrongKey verifies proofs do not verify under another key.
+func TestVerifyWrongKey(t *testing.T) {
+	signer, err := NewSigner(testSeed)
+	require.NoError(t, err)
+	other, err := NewSigner(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("x", 32))))
+	require.NoError(t, err)
+
+	proof := signer.Sign("https://example.com", hashContent([]byte("hello")), nil, []byte("hello"), time.Now())
+	assert.ErrorIs(t, Verify(other.PublicKey(), proof, []byte("hello")), ErrInvalidSignature)
+	assert.NotEqual(t, signer.KeyID(), other.KeyID())
+}
+
+// TestNewSignerInvalid verifies malformed keys are rejected.
+func TestNewSignerInvalid(t *testing.T) {
+	_, err := NewSigner("not base64!")
+	assert.Error(t, err)
+
+	_, err = NewSigner(base64.StdEncoding.EncodeToString([]byte("short")))
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+)
+
+// Caller identifies who a request is made for, such as an API client, so a domain's capacity
+// is shared fairly between callers rather than in arrival order.
+type Caller struct {
+	// ID distinguishes callers. Requests without a caller share the empty ID.
+	ID string
+	// Weight is the caller's share of a domain's capacity relative to other waiting callers;
+	// a caller with weight 2 is let through twice as often as one with weight 1. Values below
+	// or equal to zero count as 1.
+	Weight float64
+}
+
+// callerKey is the context key for the caller of a request.
+type callerKey struct{}
+
+// WithCaller returns a context whose requests are queued as made by caller.
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext returns the caller set with WithCaller, or the zero Caller.
+func CallerFromContext(ctx context.Context) Caller {
+	caller, _ := ctx.Value(callerKey{}).(Caller)
+	return caller
+}
+
+// cost returns the virtual time one request of the caller takes.
+func (c Caller) cost() float64 {
+	if c.Weight <= 0 {
+		return 1
+	}
+	return 1 / c.Weight
+}
+
+// fairQueue decides which waiting request takes a domain's next turn through its concurrency
+// and rate limits, using weighted fair queuing: each request is tagged with the virtual time
+// its caller would finish at if every waiting caller were served at its weight, and the
+// lowest tag goes next. A caller that queues many requests pushes its own tags further out,
+// so callers with fewer waiting requests are not starved behind it.
+type fairQueue struct {
+	mu sync.Mutex
+	// busy is set while a request holds the turn.
+	busy bool
+	// virtual is the start tag of the request that last took the turn.
+	virtual float64
+	// finish holds the finish tag of each caller's latest queued request.
+	finish  map[string]float64
+	waiting []*fairWaiter
+	seq     uint64
+}
+
+// fairWaiter is a request waiting for its turn.
+type fairWaiter struct {
+	caller string
+	cost   float64
+	start  float64
+	tag    float64
+	seq    uint64
+	ready  chan struct{}
+}
+
+// newFairQueue creates an idle fair queue.
+func newFairQueue() *fairQueue {
+	return &fairQueue{finish: make(map[string]float64)}
+}
+
+// acquire blocks until it is the caller's turn. The turn must be given back with release.
+func (f *fairQueue) acquire(ctx context.Context, caller Caller) error {
+	f.mu.Lock()
+	start := max(f.virtual, f.finish[caller.ID])
+	w := &fairWaiter{caller: caller.ID, cost: caller.cost(), start: start, tag: start + caller.cost(), seq: f.seq}
+	f.seq++
+	f.finish[caller.ID] = w.tag
+
+	if !f.busy {
+		f.busy = true
+		f.virtual = start
+		f.mu.Unlock()
+		return nil
+	}
+	w.ready = make(chan struct{})
+	f.waiting = append(f.waiting, w)
+	f.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	f.mu.Lock()
+	for i, queued := range f.waiting {
+		if queued == w {
+			f.waiting = append(f.waiting[:i], f.waiting[i+1:]...)
+			// Give back the virtual time it would have taken, if nothing was queued after it.
+			if f.finish[w.caller] == w.tag {
+				f.finish[w.caller] -= w.cost
+			}
+			f.mu.Unlock()
+			return ctx.Err()
+		}
+	}
+	f.mu.Unlock()
+
+	// The turn was handed over as the context ended, so pass it on.
+	f.release()
+	return ctx.Err()
+}
+
+// release gives up the turn to the waiting request with the lowest finish tag, the earliest
+// queued among equal tags.
+func (f *fairQueue) release() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.waiting) == 0 {
+		f.busy = false
+		clear(f.finish)
+		return
+	}
+
+	next := 0
+	for i, w := range f.waiting[1:] {
+		if w.tag < f.waiting[next].tag || (w.tag == f.waiting[next].tag && w.seq < f.waiting[next].seq) {
+			next = i + 1
+		}
+	}
+	w := f.waiting[next]
+	f.waiting = append(f.waiting[:next], f.waiting[next+1:]...)
+	f.virtual = w.start
+
+	// Callers whose latest tag has passed have nothing queued that it affects.
+	for id, tag := range f.finish {
+		if tag <= f.virtual {
+			delete(f.finish, id)
+		}
+	}
+	close(w.ready)
+}
@@ -2,9 +2,13 @@ package ratelimit
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"math/rand/v2"
 	"net/http"
 	"runtime"
+	"sort"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -21,25 +25,166 @@ const (
 	cleanupInterval = 10 * time.Minute
 	// inactiveThreshold is how long a domain must be inactive before cleanup
 	inactiveThreshold = 30 * time.Minute
+	// pauseThreshold is how many distinct URLs on a domain must return 503 with Retry-After
+	// before all traffic to the domain is paused
+	pauseThreshold = 2
+	// storeTimeout bounds shared store updates made outside a request context
+	storeTimeout = 2 * time.Second
+	// maxErrorPauseMultiplier caps how far the error pause grows after repeated failed probes
+	maxErrorPauseMultiplier = 8
+	// retryAfterJitter is the fraction of a back-off added at random to each waiting request,
+	// so requests queued for a domain resume spread out rather than all at once
+	retryAfterJitter = 0.1
+	// maxRetryAfterJitter caps the jitter added to a back-off
+	maxRetryAfterJitter = 5 * time.Second
 )
 
+const (
+	// PauseReasonUnavailable means multiple URLs on the domain returned 503 with Retry-After.
+	PauseReasonUnavailable = "unavailable"
+	// PauseReasonErrorRate means the domain's error rate exceeded the configured threshold.
+	PauseReasonErrorRate = "error_rate"
+)
+
+const (
+	// WaitReasonRetryAfter means the request is waiting out a Retry-After sent by the domain.
+	WaitReasonRetryAfter = "retry_after"
+	// WaitReasonConcurrency means the request is waiting for another request to the domain to finish.
+	WaitReasonConcurrency = "concurrency"
+	// WaitReasonRateLimit means the request is waiting for the domain's request rate to allow it.
+	WaitReasonRateLimit = "rate_limit"
+	// WaitReasonQueued means the request is waiting for requests queued ahead of it, by other
+	// callers or its own, to take their turn at the domain's limits.
+	WaitReasonQueued = "queued"
+)
+
+// ErrDomainPaused is returned by Wait when a domain is paused after repeated 503 responses.
+var ErrDomainPaused = errors.New("domain paused")
+
+// Stats is a point-in-time snapshot of limiter state.
+type Stats struct {
+	ActiveDomains  int
+	PausedDomains  []DomainPause
+	ProbingDomains []DomainProbe
+	// ErrorPauses counts how many times a domain has been paused for its error rate.
+	ErrorPauses int64
+}
+
+// DomainPause describes a domain whose traffic is temporarily paused.
+type DomainPause struct {
+	Domain    string
+	Until     time.Time
+	Reason    string
+	URLs      int
+	ErrorRate float64
+}
+
+// DomainProbe describes a domain resuming after an error pause, one probe request at a time.
+type DomainProbe struct {
+	Domain    string
+	Successes int
+	Required  int
+}
+
+// DomainQueue lists the requests waiting on and in flight to a domain.
+type DomainQueue struct {
+	Domain   string
+	Waiting  []QueuedRequest
+	InFlight []QueuedRequest
+}
+
+// QueuedRequest describes a request waiting on a domain's limits or in flight to it.
+type QueuedRequest struct {
+	URL string
+	// Caller is the ID of the caller the request is made for, if any.
+	Caller string
+	// Reason is the WaitReason the request is blocked on; empty once it is in flight.
+	Reason string
+	// Since is when the request started waiting on Reason, or went in flight.
+	Since time.Time
+}
+
+// Store shares per-domain rate limit state between instances.
+type Store interface {
+	// Reserve reserves the next request slot for domain and returns how long to wait for it.
+	Reserve(ctx context.Context, domain string, interval time.Duration, burst int) (time.Duration, error)
+	// SetRetryAfter records that domain asked clients to back off until the given time.
+	SetRetryAfter(ctx context.Context, domain string, until time.Time) error
+	// RetryAfter returns the latest back-off time recorded for domain.
+	RetryAfter(ctx context.Context, domain string) (time.Time, error)
+}
+
+// StateStore persists per-domain pacing, so a restarted limiter resumes it instead of allowing
+// a full burst to every domain.
+type StateStore interface {
+	// SaveState records that domain's limiter has its full burst available again at fullAt.
+	SaveState(ctx context.Context, domain string, fullAt time.Time) error
+	// LoadState returns when domain's limiter has its full burst available again, or the zero
+	// time if nothing is recorded.
+	LoadState(ctx context.Context, domain string) (time.Time, error)
+}
+
 // Limiter manages rate limiting for multiple domains.
 type Limiter struct {
 	config   config.RateLimitConfig
+	store    Store
+	state    StateStore
 	mu       sync.RWMutex
 	limiters map[string]*domainLimiter
 	stopCh   chan struct{}
 	closed   atomic.Bool
 	wg       sync.WaitGroup
+
+	errorPauses atomic.Int64
 }
 
 // domainLimiter holds rate limiting state for a single domain.
 type domainLimiter struct {
-	limiter    *rate.Limiter
-	semaphore  chan struct{}
+	limiter *rate.Limiter
+	// reserve schedules requests through the shared store; limiter is the fallback if it fails
+	reserve func(ctx context.Context) (time.Duration, error)
+	// loadState and saveState persist limiter pacing through the state store; it is loaded once, before the first request
+	loadState func(ctx context.Context) (time.Time, error)
+	saveState func(ctx context.Context, fullAt time.Time)
+	restore   sync.Once
+	semaphore chan struct{}
+	// fair orders requests from different callers through the semaphore and limiter
+	fair       *fairQueue
 	retryAfter time.Time
 	lastAccess time.Time
-	mu         sync.RWMutex
+	// unavailable maps URLs that returned 503 with Retry-After to when that Retry-After expires
+	unavailable map[string]time.Time
+	pausedUntil time.Time
+
+	// outcomes holds recent request results, oldest first, for error-rate pausing
+	outcomes         []outcome
+	errorRate        float64
+	errorPausedUntil time.Time
+	// probing is set from an error pause until enough probe requests succeed in a row
+	probing        bool
+	probeStarted   time.Time
+	probeSuccesses int
+	failedProbes   int
+
+	// queue holds requests between Wait and Release, in arrival order
+	queue []*queuedRequest
+
+	mu sync.RWMutex
+}
+
+// queuedRequest tracks a request from Wait until Release.
+type queuedRequest struct {
+	url      string
+	caller   string
+	reason   string
+	since    time.Time
+	inFlight bool
+}
+
+// outcome records whether a single request to a domain failed.
+type outcome struct {
+	at     time.Time
+	failed bool
 }
 
 // New creates a new rate limiter with the given configuration.
@@ -60,6 +205,22 @@ func New(cfg config.RateLimitConfig) *Limiter {
 	return l
 }
 
+// WithStore shares request pacing and Retry-After back-off through the given store, so limits
+// apply across every instance using it. Concurrency limits remain per instance. It must be
+// called before the limiter is used.
+func (l *Limiter) WithStore(store Store) *Limiter {
+	l.store = store
+	return l
+}
+
+// WithStateStore persists each domain's request pacing through the given store, so it carries
+// over when the limiter is recreated, such as after a restart. It has no effect on domains
+// paced through a shared Store. It must be called before the limiter is used.
+func (l *Limiter) WithStateStore(state StateStore) *Limiter {
+	l.state = state
+	return l
+}
+
 // Wait blocks until the rate limit allows a request to the given URL.
 func (l *Limiter) Wait(ctx context.Context, urlStr string) error {
 	if l.closed.Load() {
@@ -77,7 +238,21 @@ func (l *Limiter) Wait(ctx context.Context, urlStr string) error {
 
 	dl := l.getLimiterForDomain(domain)
 
-	if err := dl.wait(ctx); err != nil {
+	if until := dl.getPausedUntil(); time.Now().Before(until) {
+		return fmt.Errorf("%w: %s until %s after repeated 503 responses", ErrDomainPaused, domain, until.UTC().Format(time.RFC3339))
+	}
+
+	if err := dl.admitErrorPause(l.config.ErrorPause, time.Now()); err != nil {
+		return fmt.Errorf("%w: %s %w", ErrDomainPaused, domain, err)
+	}
+
+	if l.store != nil {
+		if retryAfter, err := l.store.RetryAfter(ctx, domain); err == nil {
+			dl.setRetryAfter(retryAfter)
+		}
+	}
+
+	if err := dl.wait(ctx, urlStr); err != nil {
 		return err
 	}
 
@@ -100,7 +275,7 @@ func (l *Limiter) Release(urlStr string) {
 	}
 
 	dl := l.getLimiterForDomain(domain)
-	dl.release()
+	dl.release(urlStr)
 }
 
 // UpdateRetryAfter updates the retry-after time for a domain based on HTTP response headers.
@@ -128,8 +303,179 @@ func (l *Limiter) UpdateRetryAfter(urlStr string, headers http.Header) {
 		return
 	}
 
+	l.setRetryAfter(domain, retryAfter)
+}
+
+// Backoff makes every request to the URL's domain wait d from now before it is sent, as when the
+// domain sends Retry-After, so requests queued for a throttling domain back off together rather
+// than each retrying on its own schedule. It never shortens a back-off already in place.
+func (l *Limiter) Backoff(urlStr string, d time.Duration) {
+	if l.closed.Load() || d <= 0 {
+		return
+	}
+
+	domain, err := urlutil.ExtractHost(urlStr)
+	if err != nil {
+		return
+	}
+	l.setRetryAfter(domain, time.Now().Add(d))
+}
+
+// setRetryAfter holds back requests to domain until the given time, sharing it through the
+// store if one is set.
+func (l *Limiter) setRetryAfter(domain string, retryAfter time.Time) {
 	dl := l.getLimiterForDomain(domain)
 	dl.setRetryAfter(retryAfter)
+
+	if l.store != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		defer cancel()
+		_ = l.store.SetRetryAfter(ctx, domain, retryAfter)
+	}
+}
+
+// RecordUnavailable records a 503 response with Retry-After for a URL. Once enough distinct URLs
+// on the same domain report 503, the whole domain is paused until the latest Retry-After expires.
+func (l *Limiter) RecordUnavailable(urlStr string, headers http.Header) {
+	if l.closed.Load() {
+		return
+	}
+
+	if !l.config.GetRespectRetryAfter() {
+		return
+	}
+
+	domain, err := urlutil.ExtractHost(urlStr)
+	if err != nil {
+		return
+	}
+
+	retryAfterStr := headers.Get("Retry-After")
+	if retryAfterStr == "" {
+		return
+	}
+
+	retryAfter := parseRetryAfter(retryAfterStr)
+	if retryAfter.IsZero() {
+		return
+	}
+
+	dl := l.getLimiterForDomain(domain)
+	dl.recordUnavailable(urlStr, retryAfter)
+}
+
+// RecordResult records the outcome of a request for error-rate pausing. A domain whose error
+// rate exceeds the configured threshold is paused, then resumed through single probe requests.
+func (l *Limiter) RecordResult(urlStr string, statusCode int, err error) {
+	if l.closed.Load() {
+		return
+	}
+
+	cfg := l.config.ErrorPause
+	if !cfg.IsEnabled() {
+		return
+	}
+
+	domain, domainErr := urlutil.ExtractHost(urlStr)
+	if domainErr != nil {
+		return
+	}
+
+	dl := l.getLimiterForDomain(domain)
+	if dl.recordResult(cfg, cfg.IsError(statusCode, err), time.Now()) {
+		l.errorPauses.Add(1)
+	}
+}
+
+// Stats returns a snapshot of the limiter's per-domain state.
+func (l *Limiter) Stats() Stats {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	stats := Stats{
+		ActiveDomains:  len(l.limiters),
+		PausedDomains:  []DomainPause{},
+		ProbingDomains: []DomainProbe{},
+		ErrorPauses:    l.errorPauses.Load(),
+	}
+
+	now := time.Now()
+	for domain, dl := range l.limiters {
+		dl.mu.RLock()
+		if now.Before(dl.pausedUntil) {
+			stats.PausedDomains = append(stats.PausedDomains, DomainPause{
+				Domain: domain,
+				Until:  dl.pausedUntil,
+				Reason: PauseReasonUnavailable,
+				URLs:   len(dl.unavailable),
+			})
+		}
+		if now.Before(dl.errorPausedUntil) {
+			stats.PausedDomains = append(stats.PausedDomains, DomainPause{
+				Domain:    domain,
+				Until:     dl.errorPausedUntil,
+				Reason:    PauseReasonErrorRate,
+				ErrorRate: dl.errorRate,
+			})
+		} else if dl.probing {
+			stats.ProbingDomains = append(stats.ProbingDomains, DomainProbe{
+				Domain:    domain,
+				Successes: dl.probeSuccesses,
+				Required:  l.config.ErrorPause.GetProbeSuccesses(),
+			})
+		}
+		dl.mu.RUnlock()
+	}
+
+	return stats
+}
+
+// Queue returns the requests currently waiting on or in flight to each domain, ordered by
+// domain and then by how long each request has been in its current state.
+func (l *Limiter) Queue() []DomainQueue {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	queues := []DomainQueue{}
+	for domain, dl := range l.limiters {
+		dl.mu.RLock()
+		if len(dl.queue) == 0 {
+			dl.mu.RUnlock()
+			continue
+		}
+
+		dq := DomainQueue{
+			Domain:   domain,
+			Waiting:  []QueuedRequest{},
+			InFlight: []QueuedRequest{},
+		}
+		for _, q := range dl.queue {
+			req := QueuedRequest{URL: q.url, Caller: q.caller, Reason: q.reason, Since: q.since}
+			if q.inFlight {
+				dq.InFlight = append(dq.InFlight, req)
+			} else {
+				dq.Waiting = append(dq.Waiting, req)
+			}
+		}
+		dl.mu.RUnlock()
+
+		sortQueuedRequests(dq.Waiting)
+		sortQueuedRequests(dq.InFlight)
+		queues = append(queues, dq)
+	}
+
+	sort.Slice(queues, func(i, j int) bool {
+		return queues[i].Domain < queues[j].Domain
+	})
+
+	return queues
+}
+
+// sortQueuedRequests orders requests from longest to shortest in their current state.
+func sortQueuedRequests(reqs []QueuedRequest) {
+	sort.SliceStable(reqs, func(i, j int) bool {
+		return reqs[i].Since.Before(reqs[j].Since)
+	})
 }
 
 // getLimiterForDomain retrieves or creates a domain-specific limiter.
@@ -151,6 +497,21 @@ func (l *Limiter) getLimiterForDomain(domain string) *domainLimiter {
 	}
 
 	dl = newDomainLimiter(l.config)
+	if l.store != nil && dl.limiter != nil {
+		interval, burst, store := l.config.GetDelay(), dl.limiter.Burst(), l.store
+		dl.reserve = func(ctx context.Context) (time.Duration, error) {
+			return store.Reserve(ctx, domain, interval, burst)
+		}
+	}
+	if l.state != nil && dl.limiter != nil {
+		state := l.state
+		dl.loadState = func(ctx context.Context) (time.Time, error) {
+			return state.LoadState(ctx, domain)
+		}
+		dl.saveState = func(ctx context.Context, fullAt time.Time) {
+			_ = state.SaveState(ctx, domain, fullAt)
+		}
+	}
 	l.limiters[domain] = dl
 
 	return dl
@@ -190,47 +551,198 @@ func newDomainLimiter(cfg config.RateLimitConfig) *domainLimiter {
 		dl.semaphore = make(chan struct{}, maxConcurrent)
 	}
 
+	if dl.limiter != nil || dl.semaphore != nil {
+		dl.fair = newFairQueue()
+	}
+
 	return dl
 }
 
-// wait blocks until rate limiting allows the request.
-func (dl *domainLimiter) wait(ctx context.Context) error {
+// wait blocks until rate limiting allows the request, tracking it in the queue until release.
+// Requests take turns at the concurrency and rate limits in the weighted fair order of their
+// callers.
+func (dl *domainLimiter) wait(ctx context.Context, urlStr string) error {
+	caller := CallerFromContext(ctx)
+	q := &queuedRequest{url: urlStr, caller: caller.ID}
+
 	dl.mu.Lock()
 	dl.lastAccess = time.Now()
-	retryAfter := dl.retryAfter
+	dl.queue = append(dl.queue, q)
 	dl.mu.Unlock()
 
-	if !retryAfter.IsZero() && time.Now().Before(retryAfter) {
-		waitDuration := time.Until(retryAfter)
-		select {
-		case <-time.After(waitDuration):
-		case <-ctx.Done():
-			return ctx.Err()
+	if err := dl.waitRetryAfter(ctx, q); err != nil {
+		dl.dequeue(q)
+		return err
+	}
+
+	if dl.fair != nil {
+		dl.setWaitReason(q, WaitReasonQueued)
+		if err := dl.fair.acquire(ctx, caller); err != nil {
+			dl.dequeue(q)
+			return err
 		}
 	}
 
 	if dl.semaphore != nil {
+		dl.setWaitReason(q, WaitReasonConcurrency)
 		select {
 		case dl.semaphore <- struct{}{}:
 		case <-ctx.Done():
+			dl.fair.release()
+			dl.dequeue(q)
 			return ctx.Err()
 		}
 	}
 
 	if dl.limiter != nil {
-		if err := dl.limiter.Wait(ctx); err != nil {
+		dl.setWaitReason(q, WaitReasonRateLimit)
+		if err := dl.waitRate(ctx); err != nil {
 			if dl.semaphore != nil {
 				<-dl.semaphore
 			}
+			dl.fair.release()
+			dl.dequeue(q)
 			return err
 		}
 	}
 
+	if dl.fair != nil {
+		dl.fair.release()
+	}
+
+	// Another request may have hit a back-off while this one waited for its turn.
+	if err := dl.waitRetryAfter(ctx, q); err != nil {
+		if dl.semaphore != nil {
+			<-dl.semaphore
+		}
+		dl.dequeue(q)
+		return err
+	}
+
+	dl.mu.Lock()
+	q.reason = ""
+	q.since = time.Now()
+	q.inFlight = true
+	dl.mu.Unlock()
+
 	return nil
 }
 
-// release releases concurrency resources.
-func (dl *domainLimiter) release() {
+// waitRetryAfter blocks until the domain's back-off, if any, has passed, plus a random jitter of
+// up to retryAfterJitter of it. A back-off extended while waiting is waited out too.
+func (dl *domainLimiter) waitRetryAfter(ctx context.Context, q *queuedRequest) error {
+	for {
+		dl.mu.Lock()
+		retryAfter := dl.retryAfter
+		dl.mu.Unlock()
+
+		wait := time.Until(retryAfter)
+		if wait <= 0 {
+			return nil
+		}
+
+		dl.setWaitReason(q, WaitReasonRetryAfter)
+		if jitter := min(time.Duration(float64(wait)*retryAfterJitter), maxRetryAfterJitter); jitter > 0 {
+			wait += rand.N(jitter)
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// setWaitReason records what a queued request is currently waiting on.
+func (dl *domainLimiter) setWaitReason(q *queuedRequest, reason string) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+
+	q.reason = reason
+	q.since = time.Now()
+}
+
+// dequeue removes a request from the queue.
+func (dl *domainLimiter) dequeue(q *queuedRequest) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+
+	for i, queued := range dl.queue {
+		if queued == q {
+			dl.queue = append(dl.queue[:i], dl.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// waitRate blocks until the domain's request rate allows another request.
+func (dl *domainLimiter) waitRate(ctx context.Context) error {
+	if dl.reserve != nil {
+		if delay, err := dl.reserve(ctx); err == nil {
+			select {
+			case <-time.After(delay):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	if dl.loadState != nil {
+		dl.restore.Do(func() {
+			if fullAt, err := dl.loadState(ctx); err == nil {
+				dl.restoreState(fullAt, time.Now())
+			}
+		})
+	}
+
+	if err := dl.limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	if dl.saveState != nil {
+		dl.saveState(ctx, dl.fullAt(time.Now()))
+	}
+	return nil
+}
+
+// fullAt returns when the limiter will have its full burst available again.
+func (dl *domainLimiter) fullAt(now time.Time) time.Time {
+	missing := float64(dl.limiter.Burst()) - dl.limiter.TokensAt(now)
+	if missing <= 0 {
+		return now
+	}
+	return now.Add(time.Duration(missing * float64(time.Second) / float64(dl.limiter.Limit())))
+}
+
+// restoreState takes the tokens a previous limiter had used and not yet regained by fullAt,
+// rounding up so restored pacing is never looser than the saved one.
+func (dl *domainLimiter) restoreState(fullAt, now time.Time) {
+	if !fullAt.After(now) {
+		return
+	}
+
+	missing := int(math.Ceil(fullAt.Sub(now).Seconds() * float64(dl.limiter.Limit())))
+	burst := dl.limiter.Burst()
+	for missing > 0 {
+		n := min(missing, burst)
+		dl.limiter.ReserveN(now, n)
+		missing -= n
+	}
+}
+
+// release releases concurrency resources and removes the oldest in-flight request for the URL
+// from the queue.
+func (dl *domainLimiter) release(urlStr string) {
+	dl.mu.Lock()
+	for i, q := range dl.queue {
+		if q.inFlight && q.url == urlStr {
+			dl.queue = append(dl.queue[:i], dl.queue[i+1:]...)
+			break
+		}
+	}
+	dl.mu.Unlock()
+
 	if dl.semaphore != nil {
 		select {
 		case <-dl.semaphore:
@@ -249,6 +761,133 @@ func (dl *domainLimiter) setRetryAfter(retryAfter time.Time) {
 	}
 }
 
+// recordUnavailable tracks a 503 for a URL and pauses the domain once the threshold is reached.
+func (dl *domainLimiter) recordUnavailable(urlStr string, retryAfter time.Time) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+
+	now := time.Now()
+	if dl.unavailable == nil {
+		dl.unavailable = make(map[string]time.Time)
+	}
+	for u, expires := range dl.unavailable {
+		if !now.Before(expires) {
+			delete(dl.unavailable, u)
+		}
+	}
+	dl.unavailable[urlStr] = retryAfter
+
+	if len(dl.unavailable) < pauseThreshold {
+		return
+	}
+
+	for _, expires := range dl.unavailable {
+		if expires.After(dl.pausedUntil) {
+			dl.pausedUntil = expires
+		}
+	}
+}
+
+// admitErrorPause rejects requests while the domain is paused for its error rate. Once the
+// pause expires, a single probe request is let through at a time until the domain resumes.
+func (dl *domainLimiter) admitErrorPause(cfg config.ErrorPauseConfig, now time.Time) error {
+	if !cfg.IsEnabled() {
+		return nil
+	}
+
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+
+	if now.Before(dl.errorPausedUntil) {
+		return fmt.Errorf("until %s after %.0f%% of requests failed", dl.errorPausedUntil.UTC().Format(time.RFC3339), dl.errorRate*100)
+	}
+
+	if !dl.probing {
+		return nil
+	}
+
+	// A probe that never reported back (e.g. its request was canceled) is abandoned after a pause.
+	if !dl.probeStarted.IsZero() && now.Sub(dl.probeStarted) < cfg.GetPauseDuration() {
+		return fmt.Errorf("while a probe request checks whether it has recovered")
+	}
+
+	dl.probeStarted = now
+	return nil
+}
+
+// recordResult tracks a request outcome and reports whether it paused the domain.
+func (dl *domainLimiter) recordResult(cfg config.ErrorPauseConfig, failed bool, now time.Time) bool {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+
+	if dl.probing {
+		if dl.probeStarted.IsZero() {
+			// Late result from a request admitted before the pause.
+			return false
+		}
+		dl.probeStarted = time.Time{}
+
+		if failed {
+			dl.failedProbes++
+			dl.pauseForErrors(cfg, now)
+			return true
+		}
+
+		dl.probeSuccesses++
+		if dl.probeSuccesses >= cfg.GetProbeSuccesses() {
+			dl.probing = false
+			dl.failedProbes = 0
+			dl.errorRate = 0
+		}
+		return false
+	}
+
+	cutoff := now.Add(-cfg.GetWindow())
+	expired := 0
+	for expired < len(dl.outcomes) && dl.outcomes[expired].at.Before(cutoff) {
+		expired++
+	}
+	dl.outcomes = append(dl.outcomes[expired:], outcome{at: now, failed: failed})
+
+	if len(dl.outcomes) < cfg.GetMinRequests() {
+		return false
+	}
+
+	failures := 0
+	for _, o := range dl.outcomes {
+		if o.failed {
+			failures++
+		}
+	}
+
+	rate := float64(failures) / float64(len(dl.outcomes))
+	if rate < cfg.Threshold {
+		return false
+	}
+
+	dl.errorRate = rate
+	dl.pauseForErrors(cfg, now)
+	return true
+}
+
+// pauseForErrors pauses the domain and resets probing. The pause doubles with each failed
+// probe, up to maxErrorPauseMultiplier times the configured duration. Callers must hold dl.mu.
+func (dl *domainLimiter) pauseForErrors(cfg config.ErrorPauseConfig, now time.Time) {
+	multiplier := min(1<<min(dl.failedProbes, 30), maxErrorPauseMultiplier)
+	dl.errorPausedUntil = now.Add(cfg.GetPauseDuration() * time.Duration(multiplier))
+	dl.probing = true
+	dl.probeStarted = time.Time{}
+	dl.probeSuccesses = 0
+	dl.outcomes = nil
+}
+
+// getPausedUntil returns the time until which this domain is paused.
+func (dl *domainLimiter) getPausedUntil() time.Time {
+	dl.mu.RLock()
+	defer dl.mu.RUnlock()
+	return dl.pausedUntil
+}
+
 // parseRetryAfter parses a Retry-After header value.
 func parseRetryAfter(value string) time.Time {
 	if seconds, err := strconv.Atoi(value); err == nil {
@@ -276,7 +915,7 @@ func (l *Limiter) cleanupInactiveDomains() {
 			now := time.Now()
 			for domain, dl := range l.limiters {
 				dl.mu.RLock()
-				inactive := now.Sub(dl.lastAccess) > inactiveThreshold
+				inactive := now.Sub(dl.lastAccess) > inactiveThreshold && !now.Before(dl.pausedUntil) && !dl.probing && len(dl.queue) == 0
 				dl.mu.RUnlock()
 
 				if inactive {
@@ -2,6 +2,7 @@ package ratelimit
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"sync"
 	"testing"
@@ -245,6 +246,173 @@ func TestLimiterRetryAfterHTTPDate(t *testing.T) {
 	assert.Less(t, elapsed, 3*time.Second, "should not wait too long")
 }
 
+// TestLimiterBackoffHoldsQueuedRequests verifies a back-off set while a request waits for a
+// concurrency slot holds it back too, so queued requests back off together.
+func TestLimiterBackoffHoldsQueuedRequests(t *testing.T) {
+	limiter := New(config.RateLimitConfig{MaxConcurrent: 1})
+	defer limiter.Close()
+
+	url := "https://example.com/page"
+	ctx := context.Background()
+	require.NoError(t, limiter.Wait(ctx, url))
+
+	done := make(chan time.Time, 1)
+	go func() {
+		if err := limiter.Wait(ctx, "https://example.com/other"); err == nil {
+			done <- time.Now()
+		}
+	}()
+	require.Eventually(t, func() bool {
+		queue := limiter.Queue()
+		return len(queue) == 1 && len(queue[0].Waiting) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	start := time.Now()
+	limiter.Backoff(url, 300*time.Millisecond)
+	limiter.Backoff(url, 100*time.Millisecond)
+	limiter.Release(url)
+
+	require.Eventually(t, func() bool {
+		queue := limiter.Queue()
+		return len(queue) == 1 && len(queue[0].Waiting) == 1 && queue[0].Waiting[0].Reason == WaitReasonRetryAfter
+	}, time.Second, 5*time.Millisecond)
+
+	select {
+	case resumed := <-done:
+		elapsed := resumed.Sub(start)
+		assert.GreaterOrEqual(t, elapsed, 300*time.Millisecond, "a shorter back-off should not shorten the first")
+		assert.Less(t, elapsed, time.Duration(float64(300*time.Millisecond)*(1+retryAfterJitter))+100*time.Millisecond, "jitter should stay within its fraction of the back-off")
+	case <-time.After(2 * time.Second):
+		t.Fatal("queued request never resumed")
+	}
+}
+
+// TestLimiterPausesDomainAfterRepeated503 verifies 503s on multiple URLs pause the whole domain.
+func TestLimiterPausesDomainAfterRepeated503(t *testing.T) {
+	respectRetryAfter := true
+	limiter := New(config.RateLimitConfig{
+		RespectRetryAfter: &respectRetryAfter,
+	})
+	defer limiter.Close()
+
+	ctx := context.Background()
+	headers := http.Header{}
+	headers.Set("Retry-After", "60")
+
+	// A single URL returning 503 does not pause the domain.
+	limiter.RecordUnavailable("https://example.com/a", headers)
+	assert.Empty(t, limiter.Stats().PausedDomains)
+
+	// A second distinct URL does.
+	limiter.RecordUnavailable("https://example.com/b", headers)
+
+	err := limiter.Wait(ctx, "https://example.com/c")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrDomainPaused)
+	assert.Contains(t, err.Error(), "example.com")
+
+	// Other domains are unaffected.
+	assert.NoError(t, limiter.Wait(ctx, "https://other.com/a"))
+
+	stats := limiter.Stats()
+	require.Len(t, stats.PausedDomains, 1)
+	assert.Equal(t, "example.com", stats.PausedDomains[0].Domain)
+	assert.Equal(t, 2, stats.PausedDomains[0].URLs)
+	assert.WithinDuration(t, time.Now().Add(60*time.Second), stats.PausedDomains[0].Until, 2*time.Second)
+}
+
+// TestLimiterErrorRatePause verifies a high error rate pauses the domain, then probes resume it.
+func TestLimiterErrorRatePause(t *testing.T) {
+	limiter := New(config.RateLimitConfig{
+		ErrorPause: config.ErrorPauseConfig{
+			Threshold:      0.5,
+			MinRequests:    4,
+			PauseDuration:  50 * time.Millisecond,
+			ProbeSuccesses: 2,
+		},
+	})
+	defer limiter.Close()
+
+	ctx := context.Background()
+	url := "https://example.com/page"
+
+	limiter.RecordResult(url, http.StatusOK, nil)
+	limiter.RecordResult(url, http.StatusOK, nil)
+	limiter.RecordResult(url, 0, assert.AnError)
+	assert.Empty(t, limiter.Stats().PausedDomains, "should not pause below min_requests")
+
+	limiter.RecordResult(url, http.StatusBadGateway, nil)
+
+	err := limiter.Wait(ctx, url)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrDomainPaused)
+	assert.Contains(t, err.Error(), "50% of requests failed")
+
+	stats := limiter.Stats()
+	require.Len(t, stats.PausedDomains, 1)
+	assert.Equal(t, PauseReasonErrorRate, stats.PausedDomains[0].Reason)
+	assert.InDelta(t, 0.5, stats.PausedDomains[0].ErrorRate, 0.001)
+	assert.Equal(t, int64(1), stats.ErrorPauses)
+
+	time.Sleep(60 * time.Millisecond)
+
+	// Only one probe is let through at a time.
+	require.NoError(t, limiter.Wait(ctx, url))
+	assert.ErrorIs(t, limiter.Wait(ctx, url), ErrDomainPaused, "second request should wait for the probe")
+
+	limiter.RecordResult(url, http.StatusOK, nil)
+	stats = limiter.Stats()
+	require.Len(t, stats.ProbingDomains, 1)
+	assert.Equal(t, DomainProbe{Domain: "example.com", Successes: 1, Required: 2}, stats.ProbingDomains[0])
+
+	require.NoError(t, limiter.Wait(ctx, url))
+	limiter.RecordResult(url, http.StatusOK, nil)
+
+	assert.Empty(t, limiter.Stats().ProbingDomains, "domain should resume after enough probes succeed")
+	assert.NoError(t, limiter.Wait(ctx, url))
+	assert.NoError(t, limiter.Wait(ctx, url))
+}
+
+// TestLimiterErrorRateFailedProbeRepauses verifies a failed probe pauses the domain again for longer.
+func TestLimiterErrorRateFailedProbeRepauses(t *testing.T) {
+	limiter := New(config.RateLimitConfig{
+		ErrorPause: config.ErrorPauseConfig{
+			Threshold:     1,
+			MinRequests:   1,
+			PauseDuration: 50 * time.Millisecond,
+		},
+	})
+	defer limiter.Close()
+
+	ctx := context.Background()
+	url := "https://example.com/page"
+
+	limiter.RecordResult(url, http.StatusServiceUnavailable, nil)
+	time.Sleep(60 * time.Millisecond)
+
+	require.NoError(t, limiter.Wait(ctx, url))
+	limiter.RecordResult(url, 0, assert.AnError)
+
+	stats := limiter.Stats()
+	require.Len(t, stats.PausedDomains, 1)
+	assert.WithinDuration(t, time.Now().Add(100*time.Millisecond), stats.PausedDomains[0].Until, 20*time.Millisecond, "pause should double")
+	assert.Equal(t, int64(2), stats.ErrorPauses)
+}
+
+// TestLimiterErrorRateIgnoresNonErrorStatus verifies only configured status codes count as errors.
+func TestLimiterErrorRateIgnoresNonErrorStatus(t *testing.T) {
+	limiter := New(config.RateLimitConfig{
+		ErrorPause: config.ErrorPauseConfig{Threshold: 0.5, MinRequests: 2},
+	})
+	defer limiter.Close()
+
+	limiter.RecordResult("https://example.com/a", http.StatusNotFound, nil)
+	limiter.RecordResult("https://example.com/b", http.StatusNotFound, nil)
+
+	assert.NoError(t, limiter.Wait(context.Background(), "https://example.com/c"))
+	assert.Zero(t, limiter.Stats().ErrorPauses)
+}
+
 // TestLimiterRetryAfterDisabled verifies that Retry-After is ignored when disabled.
 func TestLimiterRetryAfterDisabled(t *testing.T) {
 	respectRetryAfter := false
@@ -472,3 +640,300 @@ func TestLimiterQueueTimeout(t *testing.T) {
 		t.Fatal("should have completed after release")
 	}
 }
+
+// fakeStore is an in-memory Store that records reservations.
+type fakeStore struct {
+	mu         sync.Mutex
+	reserved   []string
+	wait       time.Duration
+	err        error
+	retryAfter map[string]time.Time
+}
+
+func (s *fakeStore) Reserve(ctx context.Context, domain string, interval time.Duration, burst int) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reserved = append(s.reserved, domain)
+	return s.wait, s.err
+}
+
+func (s *fakeStore) SetRetryAfter(ctx context.Context, domain string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.retryAfter == nil {
+		s.retryAfter = make(map[string]time.Time)
+	}
+	s.retryAfter[domain] = until
+	return nil
+}
+
+func (s *fakeStore) RetryAfter(ctx context.Context, domain string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.retryAfter[domain], nil
+}
+
+// TestLimiterWithStoreReservesSharedSlots verifies pacing is delegated to the shared store.
+func TestLimiterWithStoreReservesSharedSlots(t *testing.T) {
+	store := &fakeStore{wait: 100 * time.Millisecond}
+	limiter := New(config.RateLimitConfig{RequestsPerSecond: 1000, Burst: 10}).WithStore(store)
+	defer limiter.Close()
+
+	start := time.Now()
+	require.NoError(t, limiter.Wait(context.Background(), "https://example.com/page"))
+
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond, "should wait for the shared slot")
+	assert.Equal(t, []string{"example.com"}, store.reserved)
+}
+
+// TestLimiterWithStoreFallsBackOnError verifies the local limiter is used when the store fails.
+func TestLimiterWithStoreFallsBackOnError(t *testing.T) {
+	store := &fakeStore{err: assert.AnError}
+	limiter := New(config.RateLimitConfig{RequestsPerSecond: 2, Burst: 1}).WithStore(store)
+	defer limiter.Close()
+
+	ctx := context.Background()
+	require.NoError(t, limiter.Wait(ctx, "https://example.com/page"))
+
+	start := time.Now()
+	require.NoError(t, limiter.Wait(ctx, "https://example.com/page"))
+	assert.Greater(t, time.Since(start), 400*time.Millisecond, "local limiter should still enforce the rate")
+}
+
+// TestLimiterWithStoreSharesRetryAfter verifies Retry-After is published to and read from the store.
+func TestLimiterWithStoreSharesRetryAfter(t *testing.T) {
+	store := &fakeStore{}
+	respect := true
+	cfg := config.RateLimitConfig{RespectRetryAfter: &respect}
+
+	a := New(cfg).WithStore(store)
+	defer a.Close()
+	b := New(cfg).WithStore(store)
+	defer b.Close()
+
+	a.UpdateRetryAfter("https://example.com/page", http.Header{"Retry-After": []string{"1"}})
+	assert.Contains(t, store.retryAfter, "example.com")
+
+	start := time.Now()
+	require.NoError(t, b.Wait(context.Background(), "https://example.com/other"))
+	assert.Greater(t, time.Since(start), 500*time.Millisecond, "other instance should honor the shared Retry-After")
+}
+
+// fakeStateStore is an in-memory StateStore.
+type fakeStateStore struct {
+	mu     sync.Mutex
+	fullAt map[string]time.Time
+}
+
+func (s *fakeStateStore) SaveState(ctx context.Context, domain string, fullAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fullAt == nil {
+		s.fullAt = make(map[string]time.Time)
+	}
+	s.fullAt[domain] = fullAt
+	return nil
+}
+
+func (s *fakeStateStore) LoadState(ctx context.Context, domain string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fullAt[domain], nil
+}
+
+// TestLimiterWithStateStoreResumesPacing verifies a new limiter resumes the pacing saved by a
+// previous one instead of allowing a full burst.
+func TestLimiterWithStateStoreResumesPacing(t *testing.T) {
+	state := &fakeStateStore{}
+	cfg := config.RateLimitConfig{RequestsPerSecond: 2, Burst: 2}
+	ctx := context.Background()
+
+	a := New(cfg).WithStateStore(state)
+	require.NoError(t, a.Wait(ctx, "https://example.com/a"))
+	require.NoError(t, a.Wait(ctx, "https://example.com/b"))
+	a.Close()
+
+	require.Contains(t, state.fullAt, "example.com")
+	assert.WithinDuration(t, time.Now().Add(time.Second), state.fullAt["example.com"], 100*time.Millisecond)
+
+	b := New(cfg).WithStateStore(state)
+	defer b.Close()
+
+	start := time.Now()
+	require.NoError(t, b.Wait(ctx, "https://example.com/c"))
+	assert.Greater(t, time.Since(start), 400*time.Millisecond, "restarted limiter should resume pacing")
+
+	start = time.Now()
+	require.NoError(t, b.Wait(ctx, "https://other.example.com/"))
+	assert.Less(t, time.Since(start), 100*time.Millisecond, "domains without saved state should not wait")
+}
+
+// TestLimiterQueueTracksWaitingAndInFlight verifies Queue reports in-flight requests and why others wait.
+func TestLimiterQueueTracksWaitingAndInFlight(t *testing.T) {
+	cfg := config.RateLimitConfig{
+		MaxConcurrent: 1,
+	}
+	limiter := New(cfg)
+	defer limiter.Close()
+
+	ctx := context.Background()
+	require.NoError(t, limiter.Wait(ctx, "https://example.com/first"))
+
+	waitCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		done <- limiter.Wait(waitCtx, "https://example.com/second")
+	}()
+
+	require.Eventually(t, func() bool {
+		queue := limiter.Queue()
+		return len(queue) == 1 && len(queue[0].Waiting) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	queue := limiter.Queue()
+	assert.Equal(t, "example.com", queue[0].Domain)
+	require.Len(t, queue[0].InFlight, 1)
+	assert.Equal(t, "https://example.com/first", queue[0].InFlight[0].URL)
+	assert.Empty(t, queue[0].InFlight[0].Reason)
+	assert.Equal(t, "https://example.com/second", queue[0].Waiting[0].URL)
+	assert.Equal(t, WaitReasonConcurrency, queue[0].Waiting[0].Reason)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	queue = limiter.Queue()
+	require.Len(t, queue, 1)
+	assert.Empty(t, queue[0].Waiting, "canceled waits should leave the queue")
+
+	limiter.Release("https://example.com/first")
+	assert.Empty(t, limiter.Queue())
+}
+
+// TestLimiterQueueRetryAfterReason verifies requests held by Retry-After report that reason.
+func TestLimiterQueueRetryAfterReason(t *testing.T) {
+	respectRetryAfter := true
+	cfg := config.RateLimitConfig{
+		RespectRetryAfter: &respectRetryAfter,
+	}
+	limiter := New(cfg)
+	defer limiter.Close()
+
+	url := "https://example.com/page"
+	headers := http.Header{}
+	headers.Set("Retry-After", "5")
+	limiter.UpdateRetryAfter(url, headers)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = limiter.Wait(ctx, url)
+	}()
+
+	require.Eventually(t, func() bool {
+		queue := limiter.Queue()
+		return len(queue) == 1 && len(queue[0].Waiting) == 1 && queue[0].Waiting[0].Reason == WaitReasonRetryAfter
+	}, time.Second, 10*time.Millisecond)
+}
+
+// TestLimiterFairAcrossCallers verifies a caller with many queued requests does not starve a
+// caller that queues after it: they take turns at the domain's concurrency limit.
+func TestLimiterFairAcrossCallers(t *testing.T) {
+	limiter := New(config.RateLimitConfig{MaxConcurrent: 1})
+	defer limiter.Close()
+
+	ctx := context.Background()
+	require.NoError(t, limiter.Wait(ctx, "https://example.com/first"))
+
+	admitted := make(chan string, 5)
+	queue := func(caller, url string, waiting int) {
+		go func() {
+			assert.NoError(t, limiter.Wait(WithCaller(ctx, Caller{ID: caller}), url))
+			admitted <- url
+		}()
+		require.Eventually(t, func() bool {
+			queue := limiter.Queue()
+			return len(queue) == 1 && len(queue[0].Waiting) == waiting
+		}, time.Second, time.Millisecond)
+	}
+	for i := range 4 {
+		queue("greedy", fmt.Sprintf("https://example.com/greedy/%d", i), i+1)
+	}
+	queue("other", "https://example.com/other", 5)
+
+	var callers []string
+	for _, req := range limiter.Queue()[0].Waiting {
+		callers = append(callers, req.Caller)
+	}
+	assert.ElementsMatch(t, []string{"greedy", "greedy", "greedy", "greedy", "other"}, callers)
+
+	var order []string
+	last := "https://example.com/first"
+	for range 5 {
+		limiter.Release(last)
+		last = <-admitted
+		order = append(order, last)
+	}
+	limiter.Release(last)
+
+	assert.Equal(t, []string{
+		"https://example.com/greedy/0",
+		"https://example.com/other",
+		"https://example.com/greedy/1",
+		"https://example.com/greedy/2",
+		"https://example.com/greedy/3",
+	}, order)
+}
+
+// TestFairQueueWeights verifies callers take turns in proportion to their weights, and a
+// canceled waiter leaves the queue without holding up the rest.
+func TestFairQueueWeights(t *testing.T) {
+	f := newFairQueue()
+	ctx := context.Background()
+	require.NoError(t, f.acquire(ctx, Caller{ID: "holder"}))
+
+	turns := make(chan string, 10)
+	queue := func(ctx context.Context, caller Caller, name string) {
+		go func() {
+			if f.acquire(ctx, caller) == nil {
+				turns <- name
+			}
+		}()
+		f.mu.Lock()
+		n := len(f.waiting)
+		f.mu.Unlock()
+		require.Eventually(t, func() bool {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			return len(f.waiting) > n
+		}, time.Second, time.Millisecond)
+	}
+
+	light, heavy := Caller{ID: "light", Weight: 1}, Caller{ID: "heavy", Weight: 3}
+	cancelCtx, cancel := context.WithCancel(ctx)
+	for i := range 4 {
+		if i == 1 {
+			queue(cancelCtx, light, "canceled")
+			continue
+		}
+		queue(ctx, light, fmt.Sprintf("light%d", i))
+	}
+	for i := range 4 {
+		queue(ctx, heavy, fmt.Sprintf("heavy%d", i))
+	}
+	cancel()
+	require.Eventually(t, func() bool {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return len(f.waiting) == 7
+	}, time.Second, time.Millisecond)
+
+	var order []string
+	for range 7 {
+		f.release()
+		order = append(order, <-turns)
+	}
+	f.release()
+
+	assert.Equal(t, []string{"heavy0", "heavy1", "light0", "heavy2", "heavy3", "light2", "light3"}, order)
+	assert.False(t, f.busy, "the queue should be idle once every turn is released")
+}
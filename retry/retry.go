@@ -2,14 +2,19 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand/v2"
+	"net/http"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"github.com/joeychilson/websurfer/config"
 	"github.com/joeychilson/websurfer/fetcher"
 	"github.com/joeychilson/websurfer/ratelimit"
+	"github.com/joeychilson/websurfer/tracing"
 )
 
 const (
@@ -17,13 +22,25 @@ const (
 	jitterPercent = 0.25
 )
 
-// Retrier wraps a fetcher with retry logic and exponential backoff.
+// Retrier wraps a fetcher with retry logic and exponential backoff. It is safe for concurrent
+// use.
 type Retrier struct {
 	fetcher *fetcher.Fetcher
 	limiter *ratelimit.Limiter
 	config  config.RetryConfig
 }
 
+// Attempt records the outcome of a single fetch attempt.
+type Attempt struct {
+	Time       time.Time
+	StatusCode int
+	Error      string
+	// Wait is how long the attempt waited on the rate limiter, including any Retry-After,
+	// before it was sent, and Delay is the backoff slept after it failed.
+	Wait  time.Duration
+	Delay time.Duration
+}
+
 // New creates a new Retrier with the given fetcher, rate limiter, and retry configuration.
 func New(f *fetcher.Fetcher, l *ratelimit.Limiter, cfg config.RetryConfig) *Retrier {
 	return &Retrier{
@@ -33,68 +50,152 @@ func New(f *fetcher.Fetcher, l *ratelimit.Limiter, cfg config.RetryConfig) *Retr
 	}
 }
 
-// Fetch attempts to fetch the URL with automatic retries on failure.
-func (r *Retrier) Fetch(ctx context.Context, url string) (*fetcher.Response, error) {
+// Fetch attempts to fetch the URL with automatic retries on failure, and returns the attempts
+// made.
+func (r *Retrier) Fetch(ctx context.Context, url string) (*fetcher.Response, []Attempt, error) {
 	return r.FetchWithOptions(ctx, url, nil)
 }
 
 // FetchWithOptions attempts to fetch the URL with optional fetch options and automatic retries on failure.
-func (r *Retrier) FetchWithOptions(ctx context.Context, url string, opts *fetcher.FetchOptions) (*fetcher.Response, error) {
+// Responses with a retry_on status are retried with the retry backoff, and attempts that fail
+// with a transient network error with the network error backoff. Other errors are not retried.
+// The attempts made are returned whether or not the fetch succeeded.
+func (r *Retrier) FetchWithOptions(ctx context.Context, url string, opts *fetcher.FetchOptions) (*fetcher.Response, []Attempt, error) {
 	maxRetries := r.config.GetMaxRetries()
+	maxNetworkRetries := r.config.GetMaxNetworkRetries()
 
+	var attempts []Attempt
 	var lastErr error
-	for attempt := 0; attempt <= maxRetries; attempt++ {
-		if err := r.limiter.Wait(ctx, url); err != nil {
-			return nil, fmt.Errorf("rate limit wait failed: %w", err)
+	var retries, networkRetries int
+	for attempt := 0; ; attempt++ {
+		waitStart := time.Now()
+		waitCtx, waitSpan := tracing.Start(ctx, "websurfer.ratelimit.wait", attribute.Int("websurfer.attempt", attempt+1))
+		err := r.limiter.Wait(waitCtx, url)
+		tracing.End(waitSpan, err)
+		if err != nil {
+			return nil, attempts, fmt.Errorf("rate limit wait failed: %w", err)
 		}
 
-		resp, err := r.fetcher.FetchWithOptions(ctx, url, opts)
+		attemptStart := time.Now()
+		attemptCtx, attemptSpan := tracing.Start(ctx, "websurfer.fetch.attempt", attribute.Int("websurfer.attempt", attempt+1))
+		resp, err := r.fetcher.FetchWithOptions(attemptCtx, url, opts)
+		if resp != nil {
+			attemptSpan.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		}
+		tracing.End(attemptSpan, err)
+		attempts = append(attempts, newAttempt(attemptStart, attemptStart.Sub(waitStart), resp, err))
+		r.recordResult(url, resp, err)
 
+		var backoff time.Duration
+		var retry bool
 		if resp != nil {
 			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 				r.limiter.Release(url)
-				return resp, nil
+				return resp, attempts, nil
 			}
 
 			if resp.StatusCode == 304 {
 				r.limiter.Release(url)
-				return resp, nil
+				return resp, attempts, nil
+			}
+
+			if resp.StatusCode == 503 {
+				r.limiter.RecordUnavailable(url, resp.Headers)
 			}
 
 			if !r.config.ShouldRetry(resp.StatusCode) {
 				r.limiter.Release(url)
-				return resp, nil
+				return resp, attempts, nil
 			}
 
 			r.limiter.UpdateRetryAfter(url, resp.Headers)
-			lastErr = fmt.Errorf("attempt %d: HTTP %d", attempt, resp.StatusCode)
+			lastErr = fmt.Errorf("attempt %d: %w", attempt, &fetcher.StatusError{StatusCode: resp.StatusCode})
+
+			backoff = r.calculateBackoff(retries)
+			retry = retries < maxRetries
+			retries++
 		} else {
 			lastErr = fmt.Errorf("attempt %d failed: %w", attempt, err)
+
+			// A deadline of the caller's context looks like a timeout, but retrying cannot help.
+			backoff = r.calculateNetworkBackoff(networkRetries)
+			retry = networkRetries < maxNetworkRetries && ctx.Err() == nil && fetcher.IsTransient(err)
+			networkRetries++
 		}
 
 		r.limiter.Release(url)
 
-		if attempt < maxRetries {
-			backoff := r.calculateBackoff(attempt)
-			if sleepErr := r.sleep(ctx, backoff); sleepErr != nil {
-				return nil, sleepErr
-			}
+		if resp != nil && throttled(resp.StatusCode) {
+			r.limiter.Backoff(url, backoff)
 		}
+
+		if !retry {
+			break
+		}
+		attempts[len(attempts)-1].Delay = backoff
+		if sleepErr := r.sleep(ctx, backoff); sleepErr != nil {
+			return nil, attempts, sleepErr
+		}
+	}
+
+	return nil, attempts, fmt.Errorf("failed after %d attempts: %w", len(attempts), lastErr)
+}
+
+// Waited returns how long attempts spent waiting on the rate limiter and sleeping between
+// retries, so that slow fetches can be attributed to politeness rather than the origin.
+func Waited(attempts []Attempt) (rateLimit, backoff time.Duration) {
+	for _, attempt := range attempts {
+		rateLimit += attempt.Wait
+		backoff += attempt.Delay
+	}
+	return rateLimit, backoff
+}
+
+// newAttempt records the outcome of a fetch attempt.
+func newAttempt(start time.Time, wait time.Duration, resp *fetcher.Response, err error) Attempt {
+	attempt := Attempt{Time: start, Wait: wait}
+	if resp != nil {
+		attempt.StatusCode = resp.StatusCode
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	return attempt
+}
+
+// recordResult reports the outcome of a fetch attempt to the limiter for error-rate pausing.
+// Attempts canceled by the caller say nothing about the domain and are not counted.
+func (r *Retrier) recordResult(url string, resp *fetcher.Response, err error) {
+	if err != nil && errors.Is(err, context.Canceled) {
+		return
 	}
 
-	if lastErr != nil {
-		return nil, fmt.Errorf("failed after %d attempts: %w", maxRetries+1, lastErr)
+	var statusCode int
+	if resp != nil {
+		statusCode = resp.StatusCode
 	}
+	r.limiter.RecordResult(url, statusCode, err)
+}
 
-	return nil, fmt.Errorf("failed after %d attempts", maxRetries+1)
+// throttled reports whether a status code asks clients to slow down. The retry backoff after such
+// a response applies to every request to the domain, not just the one retried.
+func throttled(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
 }
 
 // calculateBackoff computes the backoff duration for a given attempt using exponential backoff.
 func (r *Retrier) calculateBackoff(attempt int) time.Duration {
-	initialDelay := r.config.GetInitialDelay()
-	maxDelay := r.config.GetMaxDelay()
-	multiplier := r.config.GetMultiplier()
+	return r.exponentialBackoff(r.config.GetInitialDelay(), r.config.GetMaxDelay(), r.config.GetMultiplier(), attempt)
+}
+
+// calculateNetworkBackoff computes the backoff duration for a given retry of a network error.
+func (r *Retrier) calculateNetworkBackoff(attempt int) time.Duration {
+	network := r.config.RetryOnNetworkErrors
+	return r.exponentialBackoff(network.GetInitialDelay(), network.GetMaxDelay(), network.GetMultiplier(), attempt)
+}
 
+// exponentialBackoff grows initialDelay by multiplier for each attempt, capped at maxDelay, with jitter.
+func (r *Retrier) exponentialBackoff(initialDelay, maxDelay time.Duration, multiplier float64, attempt int) time.Duration {
 	cappedAttempt := min(attempt, 63)
 
 	delay := float64(initialDelay) * math.Pow(multiplier, float64(cappedAttempt))
@@ -2,6 +2,7 @@ package retry
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"sync"
@@ -318,7 +319,7 @@ func TestRetryIntegrationSuccessAfterFailures(t *testing.T) {
 	r := New(f, l, retryCfg)
 
 	// Fetch with retries
-	resp, err := r.Fetch(context.Background(), server.URL)
+	resp, _, err := r.Fetch(context.Background(), server.URL)
 
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
@@ -326,6 +327,72 @@ func TestRetryIntegrationSuccessAfterFailures(t *testing.T) {
 	assert.Equal(t, int32(3), attemptCount.Load(), "should have made 3 attempts")
 }
 
+// TestRetryAttemptHistory verifies each attempt is recorded with its status and backoff delay.
+func TestRetryAttemptHistory(t *testing.T) {
+	var attemptCount atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attemptCount.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	f, err := fetcher.New(config.FetchConfig{})
+	require.NoError(t, err)
+
+	l := ratelimit.New(config.RateLimitConfig{})
+	defer l.Close()
+
+	r := New(f, l, config.RetryConfig{
+		MaxRetries:   3,
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     100 * time.Millisecond,
+	})
+
+	_, attempts, err := r.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	require.Len(t, attempts, 3)
+
+	assert.Equal(t, http.StatusServiceUnavailable, attempts[0].StatusCode)
+	assert.NotEmpty(t, attempts[0].Error)
+	assert.Greater(t, attempts[0].Delay, time.Duration(0), "failed attempt should record backoff delay")
+
+	assert.Equal(t, http.StatusOK, attempts[2].StatusCode)
+	assert.Empty(t, attempts[2].Error)
+	assert.Zero(t, attempts[2].Delay, "final attempt should not record a delay")
+	assert.True(t, attempts[1].Time.After(attempts[0].Time))
+}
+
+// TestRetryAttemptWait verifies time spent waiting on the rate limiter is recorded per attempt.
+func TestRetryAttemptWait(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	f, err := fetcher.New(config.FetchConfig{})
+	require.NoError(t, err)
+
+	l := ratelimit.New(config.RateLimitConfig{Delay: 100 * time.Millisecond})
+	defer l.Close()
+
+	r := New(f, l, config.RetryConfig{})
+
+	_, attempts, err := r.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	first, _ := Waited(attempts)
+	assert.Less(t, first, 50*time.Millisecond, "the first request should not wait")
+
+	_, attempts, err = r.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	second, backoff := Waited(attempts)
+	assert.Greater(t, second, 50*time.Millisecond, "the second request should wait for the rate limit")
+	assert.Zero(t, backoff)
+}
+
 // TestRetryIntegrationFailureAfterMaxRetries verifies error after exhausting retries.
 // CRITICAL: LLM tools need clear error message when all retries fail.
 func TestRetryIntegrationFailureAfterMaxRetries(t *testing.T) {
@@ -352,7 +419,7 @@ func TestRetryIntegrationFailureAfterMaxRetries(t *testing.T) {
 	}
 	r := New(f, l, retryCfg)
 
-	_, err = r.Fetch(context.Background(), server.URL)
+	_, _, err = r.Fetch(context.Background(), server.URL)
 
 	assert.Error(t, err, "should fail after max retries")
 	assert.Contains(t, err.Error(), "503", "error should mention status code for LLM tools")
@@ -386,7 +453,7 @@ func TestRetryIntegrationNoRetryOn4xx(t *testing.T) {
 	}
 	r := New(f, l, retryCfg)
 
-	resp, err := r.Fetch(context.Background(), server.URL)
+	resp, _, err := r.Fetch(context.Background(), server.URL)
 
 	// Should return response without retrying (even though it's an error status)
 	assert.NoError(t, err)
@@ -432,7 +499,7 @@ func TestRetryIntegrationRetryOn429RateLimited(t *testing.T) {
 	}
 	r := New(f, l, retryCfg)
 
-	resp, err := r.Fetch(context.Background(), server.URL)
+	resp, _, err := r.Fetch(context.Background(), server.URL)
 
 	// Should eventually succeed
 	require.NoError(t, err)
@@ -444,6 +511,37 @@ func TestRetryIntegrationRetryOn429RateLimited(t *testing.T) {
 		"429 should be retried until success (3 attempts)")
 }
 
+// TestRetryThrottleBacksOffDomain verifies a 429 without Retry-After holds back other requests to
+// the domain for the retry's backoff.
+func TestRetryThrottleBacksOffDomain(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/throttled" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	f, err := fetcher.New(config.FetchConfig{})
+	require.NoError(t, err)
+
+	respectRetryAfter := true
+	l := ratelimit.New(config.RateLimitConfig{RespectRetryAfter: &respectRetryAfter})
+	defer l.Close()
+
+	r := New(f, l, config.RetryConfig{MaxRetries: 0, InitialDelay: 300 * time.Millisecond})
+	resp, _, err := r.Fetch(context.Background(), server.URL+"/throttled")
+	require.Error(t, err)
+	assert.Nil(t, resp)
+
+	start := time.Now()
+	resp, _, err = New(f, l, config.RetryConfig{}).Fetch(context.Background(), server.URL+"/ok")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Greater(t, time.Since(start), 150*time.Millisecond, "another URL on the domain should wait out the backoff")
+}
+
 // TestRetryIntegrationContextCancellation verifies retry stops on context cancel.
 // CRITICAL: LLM tool timeouts should cancel in-progress retries.
 func TestRetryIntegrationContextCancellation(t *testing.T) {
@@ -473,7 +571,7 @@ func TestRetryIntegrationContextCancellation(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
 	defer cancel()
 
-	_, err = r.Fetch(ctx, server.URL)
+	_, _, err = r.Fetch(ctx, server.URL)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "context", "should mention context cancellation")
@@ -512,7 +610,7 @@ func TestRetryIntegrationBackoffIncreases(t *testing.T) {
 	}
 	r := New(f, l, retryCfg)
 
-	_, _ = r.Fetch(context.Background(), server.URL)
+	_, _, _ = r.Fetch(context.Background(), server.URL)
 
 	// Verify delays increased
 	mu.Lock()
@@ -561,9 +659,126 @@ func TestRetryIntegrationCustomRetryOn(t *testing.T) {
 	}
 	r := New(f, l, retryCfg)
 
-	resp, err := r.Fetch(context.Background(), server.URL)
+	resp, _, err := r.Fetch(context.Background(), server.URL)
 
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 	assert.Equal(t, int32(2), attemptCount.Load(), "should have retried 502")
 }
+
+// newDroppingServer returns a server that closes the connection without a response for the
+// first drops requests, then responds 200.
+func newDroppingServer(t *testing.T, drops int32, attemptCount *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attemptCount.Add(1) <= drops {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			conn.Close()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestRetryNetworkErrors verifies dropped connections are retried with the network error backoff.
+func TestRetryNetworkErrors(t *testing.T) {
+	var attemptCount atomic.Int32
+	server := newDroppingServer(t, 2, &attemptCount)
+
+	f, err := fetcher.New(config.FetchConfig{})
+	require.NoError(t, err)
+
+	l := ratelimit.New(config.RateLimitConfig{})
+	defer l.Close()
+
+	r := New(f, l, config.RetryConfig{
+		MaxRetries:   3,
+		InitialDelay: time.Minute,
+		RetryOnNetworkErrors: config.NetworkRetryConfig{
+			InitialDelay: 10 * time.Millisecond,
+			MaxDelay:     50 * time.Millisecond,
+		},
+	})
+
+	resp, attempts, err := r.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), attemptCount.Load())
+	require.Len(t, attempts, 3)
+	assert.NotEmpty(t, attempts[0].Error)
+	assert.Less(t, attempts[0].Delay, time.Second, "network errors should use their own backoff")
+}
+
+// TestRetryNetworkErrorsMaxRetries verifies the network max_retries overrides max_retries.
+func TestRetryNetworkErrorsMaxRetries(t *testing.T) {
+	var attemptCount atomic.Int32
+	server := newDroppingServer(t, 5, &attemptCount)
+
+	f, err := fetcher.New(config.FetchConfig{})
+	require.NoError(t, err)
+
+	l := ratelimit.New(config.RateLimitConfig{})
+	defer l.Close()
+
+	r := New(f, l, config.RetryConfig{
+		MaxRetries: 5,
+		RetryOnNetworkErrors: config.NetworkRetryConfig{
+			MaxRetries:   1,
+			InitialDelay: 10 * time.Millisecond,
+		},
+	})
+
+	_, _, err = r.Fetch(context.Background(), server.URL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed after 2 attempts")
+	assert.Equal(t, int32(2), attemptCount.Load())
+}
+
+// TestRetryNetworkErrorsDisabled verifies network errors are not retried when disabled.
+func TestRetryNetworkErrorsDisabled(t *testing.T) {
+	var attemptCount atomic.Int32
+	server := newDroppingServer(t, 1, &attemptCount)
+
+	f, err := fetcher.New(config.FetchConfig{})
+	require.NoError(t, err)
+
+	l := ratelimit.New(config.RateLimitConfig{})
+	defer l.Close()
+
+	disabled := false
+	r := New(f, l, config.RetryConfig{
+		MaxRetries:           3,
+		InitialDelay:         10 * time.Millisecond,
+		RetryOnNetworkErrors: config.NetworkRetryConfig{Enabled: &disabled},
+	})
+
+	_, _, err = r.Fetch(context.Background(), server.URL)
+	require.Error(t, err)
+	assert.Equal(t, fetcher.FailureReset, fetcher.ClassifyError(err))
+	assert.Equal(t, int32(1), attemptCount.Load())
+}
+
+// TestRetryPermanentErrorNotRetried verifies errors that are not transient, such as a refused
+// connection, fail on the first attempt.
+func TestRetryPermanentErrorNotRetried(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	f, err := fetcher.New(config.FetchConfig{})
+	require.NoError(t, err)
+
+	l := ratelimit.New(config.RateLimitConfig{})
+	defer l.Close()
+
+	r := New(f, l, config.RetryConfig{MaxRetries: 3, InitialDelay: 10 * time.Millisecond})
+
+	_, attempts, err := r.Fetch(context.Background(), "http://"+addr+"/")
+	require.Error(t, err)
+	assert.Len(t, attempts, 1, "attempts are returned when the fetch fails")
+}
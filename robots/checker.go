@@ -0,0 +1,207 @@
+package robots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// maxLocalEntries caps how many hosts are kept in the in-process cache.
+	maxLocalEntries = 10000
+)
+
+// ErrDisallowed is returned when robots.txt does not allow fetching a URL.
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// FetchFunc retrieves a robots.txt file. It returns the HTTP status code and body.
+type FetchFunc func(ctx context.Context, robotsURL string) (statusCode int, body []byte, err error)
+
+// Entry is a robots.txt response as stored in a cache.
+type Entry struct {
+	StatusCode int       `json:"status_code"`
+	Body       []byte    `json:"body,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Cache stores robots.txt responses by host so they can be shared between instances.
+type Cache interface {
+	// Get returns the cached entry for host, or nil if there is none.
+	Get(ctx context.Context, host string) (*Entry, error)
+	// Set stores the entry for host until its ExpiresAt.
+	Set(ctx context.Context, host string, entry *Entry) error
+}
+
+// Config holds checker configuration.
+type Config struct {
+	// TTL is how long a fetched robots.txt is cached (default: 24h).
+	TTL time.Duration
+	// ErrorTTL is how long a robots.txt that returned a server error is cached (default: 5m).
+	ErrorTTL time.Duration
+	Logger   *slog.Logger
+}
+
+// DefaultConfig returns a checker config with sensible defaults.
+func DefaultConfig() Config {
+	return Config{
+		TTL:      24 * time.Hour,
+		ErrorTTL: 5 * time.Minute,
+		Logger:   slog.Default(),
+	}
+}
+
+// Checker checks URLs against their host's robots.txt. Parsed files are cached in process,
+// and raw responses can additionally be shared through a Cache.
+type Checker struct {
+	fetch  FetchFunc
+	config Config
+	shared Cache
+
+	mu    sync.Mutex
+	local map[string]localEntry
+}
+
+// localEntry is a parsed robots.txt cached in process.
+type localEntry struct {
+	robots    *Robots
+	expiresAt time.Time
+}
+
+// NewChecker creates a robots.txt checker that retrieves files with fetch.
+func NewChecker(fetch FetchFunc, config Config) *Checker {
+	defaults := DefaultConfig()
+	if config.TTL <= 0 {
+		config.TTL = defaults.TTL
+	}
+	if config.ErrorTTL <= 0 {
+		config.ErrorTTL = defaults.ErrorTTL
+	}
+	if config.Logger == nil {
+		config.Logger = defaults.Logger
+	}
+	return &Checker{
+		fetch:  fetch,
+		config: config,
+		local:  make(map[string]localEntry),
+	}
+}
+
+// WithCache shares fetched robots.txt files with other instances through cache.
+func (c *Checker) WithCache(cache Cache) *Checker {
+	c.shared = cache
+	return c
+}
+
+// Allowed reports whether userAgent may fetch rawURL.
+func (c *Checker) Allowed(ctx context.Context, rawURL, userAgent string) (bool, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false, fmt.Errorf("invalid url: %w", err)
+	}
+
+	r, err := c.Get(ctx, rawURL)
+	if err != nil {
+		return false, err
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	return r.Allowed(userAgent, path), nil
+}
+
+// Get returns the robots.txt rules for the host of rawURL.
+func (c *Checker) Get(ctx context.Context, rawURL string) (*Robots, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("url has no host: %s", rawURL)
+	}
+	key := u.Scheme + "://" + u.Host
+
+	now := time.Now()
+
+	c.mu.Lock()
+	cached, ok := c.local[key]
+	c.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.robots, nil
+	}
+
+	if c.shared != nil {
+		entry, err := c.shared.Get(ctx, key)
+		if err != nil {
+			c.config.Logger.Warn("failed to read shared robots.txt cache", "host", key, "error", err)
+		} else if entry != nil && now.Before(entry.ExpiresAt) {
+			r := parseEntry(entry)
+			c.storeLocal(key, r, entry.ExpiresAt)
+			return r, nil
+		}
+	}
+
+	statusCode, body, err := c.fetch(ctx, key+"/robots.txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch robots.txt: %w", err)
+	}
+
+	entry := &Entry{StatusCode: statusCode, Body: body, ExpiresAt: now.Add(c.config.TTL)}
+	if statusCode >= 500 {
+		entry.Body = nil
+		entry.ExpiresAt = now.Add(c.config.ErrorTTL)
+	}
+
+	r := parseEntry(entry)
+	c.storeLocal(key, r, entry.ExpiresAt)
+
+	if c.shared != nil {
+		if err := c.shared.Set(ctx, key, entry); err != nil {
+			c.config.Logger.Warn("failed to write shared robots.txt cache", "host", key, "error", err)
+		}
+	}
+
+	return r, nil
+}
+
+// storeLocal caches parsed rules in process, evicting expired hosts when the cache is full.
+func (c *Checker) storeLocal(key string, r *Robots, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.local) >= maxLocalEntries {
+		now := time.Now()
+		for k, e := range c.local {
+			if !now.Before(e.expiresAt) {
+				delete(c.local, k)
+			}
+		}
+		if len(c.local) >= maxLocalEntries {
+			clear(c.local)
+		}
+	}
+
+	c.local[key] = localEntry{robots: r, expiresAt: expiresAt}
+}
+
+// parseEntry interprets a robots.txt response per RFC 9309: a missing file (4xx) allows
+// everything, and a server error (5xx) disallows everything.
+func parseEntry(entry *Entry) *Robots {
+	switch {
+	case entry.StatusCode >= 200 && entry.StatusCode < 300:
+		return Parse(entry.Body)
+	case entry.StatusCode >= 500:
+		return DisallowAll()
+	default:
+		return AllowAll()
+	}
+}
@@ -0,0 +1,130 @@
+package robots
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingFetch returns a FetchFunc serving a fixed response and counting calls.
+func countingFetch(calls *int32, statusCode int, body string) FetchFunc {
+	return func(ctx context.Context, robotsURL string) (int, []byte, error) {
+		atomic.AddInt32(calls, 1)
+		return statusCode, []byte(body), nil
+	}
+}
+
+// TestCheckerAllowed verifies URLs are checked against the host's robots.txt.
+func TestCheckerAllowed(t *testing.T) {
+	var (
+		calls     int32
+		requested string
+	)
+	checker := NewChecker(func(ctx context.Context, robotsURL string) (int, []byte, error) {
+		atomic.AddInt32(&calls, 1)
+		requested = robotsURL
+		return http.StatusOK, []byte("User-agent: *\nDisallow: /private\n"), nil
+	}, Config{})
+
+	allowed, err := checker.Allowed(context.Background(), "https://example.com/page", "bot")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = checker.Allowed(context.Background(), "https://example.com/private?id=1", "bot")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	assert.Equal(t, "https://example.com/robots.txt", requested)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// TestCheckerStatusCodes verifies missing files allow everything and server errors disallow everything.
+func TestCheckerStatusCodes(t *testing.T) {
+	var calls int32
+
+	missing := NewChecker(countingFetch(&calls, http.StatusNotFound, ""), Config{})
+	allowed, err := missing.Allowed(context.Background(), "https://example.com/page", "bot")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	failing := NewChecker(countingFetch(&calls, http.StatusServiceUnavailable, ""), Config{})
+	allowed, err = failing.Allowed(context.Background(), "https://example.com/page", "bot")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+// TestCheckerFetchError verifies fetch errors are returned and not cached.
+func TestCheckerFetchError(t *testing.T) {
+	var calls int32
+	checker := NewChecker(func(ctx context.Context, robotsURL string) (int, []byte, error) {
+		atomic.AddInt32(&calls, 1)
+		return 0, nil, errors.New("connection refused")
+	}, Config{})
+
+	_, err := checker.Allowed(context.Background(), "https://example.com/page", "bot")
+	require.Error(t, err)
+	_, err = checker.Allowed(context.Background(), "https://example.com/page", "bot")
+	require.Error(t, err)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+// TestCheckerExpiry verifies cached files are refetched once their TTL passes.
+func TestCheckerExpiry(t *testing.T) {
+	var calls int32
+	checker := NewChecker(countingFetch(&calls, http.StatusOK, ""), Config{TTL: 20 * time.Millisecond})
+
+	_, err := checker.Get(context.Background(), "https://example.com/")
+	require.NoError(t, err)
+	_, err = checker.Get(context.Background(), "https://example.com/other")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	time.Sleep(30 * time.Millisecond)
+
+	_, err = checker.Get(context.Background(), "https://example.com/")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+// TestCheckerRedisCache verifies checkers sharing a Redis cache fetch robots.txt once per host.
+func TestCheckerRedisCache(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	cache := NewRedisCache(client, "")
+
+	var calls int32
+	fetch := countingFetch(&calls, http.StatusOK, "User-agent: *\nDisallow: /private\n")
+	first := NewChecker(fetch, Config{}).WithCache(cache)
+	second := NewChecker(fetch, Config{}).WithCache(cache)
+
+	allowed, err := first.Allowed(context.Background(), "https://example.com/private", "bot")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	allowed, err = second.Allowed(context.Background(), "https://example.com/private", "bot")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.True(t, mr.Exists("websurfer:robots:https://example.com"))
+	assert.InDelta(t, (24 * time.Hour).Seconds(), mr.TTL("websurfer:robots:https://example.com").Seconds(), 5)
+}
+
+// TestRedisCacheMissing verifies a missing host returns no entry.
+func TestRedisCacheMissing(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cache := NewRedisCache(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "test:")
+
+	entry, err := cache.Get(context.Background(), "https://example.com")
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+}
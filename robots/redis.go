@@ -0,0 +1,61 @@
+package robots
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCache shares robots.txt responses between instances through Redis, so each host's
+// robots.txt is fetched once per TTL across the whole deployment.
+type RedisCache struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisCache creates a Redis-backed robots.txt cache. An empty prefix uses "websurfer:robots:".
+func NewRedisCache(client *redis.Client, prefix string) *RedisCache {
+	if prefix == "" {
+		prefix = "websurfer:robots:"
+	}
+	return &RedisCache{client: client, prefix: prefix}
+}
+
+// Get returns the cached entry for host, or nil if there is none.
+func (c *RedisCache) Get(ctx context.Context, host string) (*Entry, error) {
+	data, err := c.client.Get(ctx, c.prefix+host).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis get failed: %w", err)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode robots.txt entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// Set stores the entry for host, expiring it from Redis at its ExpiresAt.
+func (c *RedisCache) Set(ctx context.Context, host string, entry *Entry) error {
+	ttl := time.Until(entry.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode robots.txt entry: %w", err)
+	}
+
+	if err := c.client.Set(ctx, c.prefix+host, data, ttl).Err(); err != nil {
+		return fmt.Errorf("redis set failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package robots
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Robots is a parsed robots.txt file.
+type Robots struct {
+	groups   []group
+	Sitemaps []string
+	// disallowAll blocks every path, used when robots.txt could not be retrieved.
+	disallowAll bool
+}
+
+// group is a set of rules that applies to one or more user agents.
+type group struct {
+	agents     []string
+	rules      []rule
+	crawlDelay time.Duration
+}
+
+// rule is a single allow or disallow path pattern.
+type rule struct {
+	pattern string
+	allow   bool
+}
+
+// AllowAll returns robots rules that allow every path.
+func AllowAll() *Robots {
+	return &Robots{}
+}
+
+// DisallowAll returns robots rules that block every path.
+func DisallowAll() *Robots {
+	return &Robots{disallowAll: true}
+}
+
+// Parse parses a robots.txt file as described in RFC 9309. Unknown lines are ignored.
+func Parse(data []byte) *Robots {
+	r := &Robots{}
+
+	var (
+		current     *group
+		lastWasRule bool
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i != -1 {
+			line = line[:i]
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || lastWasRule {
+				r.groups = append(r.groups, group{})
+				current = &r.groups[len(r.groups)-1]
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			lastWasRule = false
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+			lastWasRule = true
+			if value == "" {
+				// An empty disallow allows everything; an empty allow has no effect.
+				continue
+			}
+			current.rules = append(current.rules, rule{pattern: value, allow: key == "allow"})
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+			lastWasRule = true
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		case "sitemap":
+			if value != "" {
+				r.Sitemaps = append(r.Sitemaps, value)
+			}
+		}
+	}
+
+	return r
+}
+
+// Allowed reports whether userAgent may fetch path. path includes the query string, if any.
+func (r *Robots) Allowed(userAgent, path string) bool {
+	if r.disallowAll {
+		return false
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+
+	var (
+		best      = -1
+		bestAllow = true
+	)
+	for _, g := range r.groupsFor(userAgent) {
+		for _, rl := range g.rules {
+			n := matchLength(rl.pattern, path)
+			if n < 0 {
+				continue
+			}
+			// The longest match wins; allow wins ties.
+			if n > best || (n == best && rl.allow) {
+				best = n
+				bestAllow = rl.allow
+			}
+		}
+	}
+
+	return bestAllow
+}
+
+// CrawlDelay returns the crawl delay requested for userAgent, or zero if none.
+func (r *Robots) CrawlDelay(userAgent string) time.Duration {
+	var delay time.Duration
+	for _, g := range r.groupsFor(userAgent) {
+		delay = max(delay, g.crawlDelay)
+	}
+	return delay
+}
+
+// groupsFor returns the groups that apply to userAgent: those whose agent name appears in it,
+// such as "websurfer" in "Mozilla/5.0 (compatible; websurfer/1.0)", or the "*" groups if none do.
+func (r *Robots) groupsFor(userAgent string) []group {
+	userAgent = strings.ToLower(userAgent)
+
+	var specific, wildcard []group
+	for _, g := range r.groups {
+		for _, agent := range g.agents {
+			if agent == "*" {
+				wildcard = append(wildcard, g)
+				break
+			}
+			if agent != "" && strings.Contains(userAgent, agent) {
+				specific = append(specific, g)
+				break
+			}
+		}
+	}
+
+	if len(specific) > 0 {
+		return specific
+	}
+	return wildcard
+}
+
+// matchLength returns the length of pattern if it matches path, or -1 if it does not.
+// Patterns match path prefixes, "*" matches any sequence, and a trailing "$" anchors the end.
+func matchLength(pattern, path string) int {
+	anchored := strings.HasSuffix(pattern, "$")
+	p := strings.TrimSuffix(pattern, "$")
+
+	if matchPattern(p, path, anchored) {
+		return len(pattern)
+	}
+	return -1
+}
+
+// matchPattern reports whether pattern matches a prefix of path, or all of path if anchored.
+func matchPattern(pattern, path string, anchored bool) bool {
+	for {
+		star := strings.IndexByte(pattern, '*')
+		if star == -1 {
+			if anchored {
+				return path == pattern
+			}
+			return strings.HasPrefix(path, pattern)
+		}
+
+		if !strings.HasPrefix(path, pattern[:star]) {
+			return false
+		}
+		path = path[star:]
+		pattern = pattern[star+1:]
+
+		if pattern == "" {
+			return true
+		}
+
+		for i := 0; i <= len(path); i++ {
+			if matchPattern(pattern, path[i:], anchored) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,95 @@
+package robots
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRobots = `# example robots.txt
+User-agent: *
+Disallow: /private/
+Allow: /private/public
+Disallow: /*.pdf$
+Crawl-delay: 2
+
+User-agent: websurfer
+User-agent: otherbot
+Disallow: /no-websurfer
+Crawl-delay: 0.5
+
+Sitemap: https://example.com/sitemap.xml
+`
+
+// TestParseWildcardGroup verifies the "*" group applies to unnamed user agents.
+func TestParseWildcardGroup(t *testing.T) {
+	r := Parse([]byte(testRobots))
+	ua := "Mozilla/5.0 (compatible; SomeBot)"
+
+	assert.True(t, r.Allowed(ua, "/"))
+	assert.False(t, r.Allowed(ua, "/private/secret"))
+	assert.True(t, r.Allowed(ua, "/private/public/page"))
+	assert.False(t, r.Allowed(ua, "/docs/report.pdf"))
+	assert.True(t, r.Allowed(ua, "/docs/report.pdf?download=1"))
+	assert.True(t, r.Allowed(ua, "/no-websurfer"))
+	assert.Equal(t, 2*time.Second, r.CrawlDelay(ua))
+}
+
+// TestParseSpecificGroup verifies a group naming the user agent replaces the "*" group.
+func TestParseSpecificGroup(t *testing.T) {
+	r := Parse([]byte(testRobots))
+	ua := "Mozilla/5.0 (compatible; WebSurfer/1.0; +https://github.com/joeychilson/websurfer)"
+
+	assert.False(t, r.Allowed(ua, "/no-websurfer"))
+	assert.True(t, r.Allowed(ua, "/private/secret"))
+	assert.Equal(t, 500*time.Millisecond, r.CrawlDelay(ua))
+	assert.Equal(t, []string{"https://example.com/sitemap.xml"}, r.Sitemaps)
+}
+
+// TestAllowedLongestMatch verifies the longest matching rule wins and allow wins ties.
+func TestAllowedLongestMatch(t *testing.T) {
+	r := Parse([]byte("User-agent: *\nDisallow: /a\nAllow: /a/b\nDisallow: /a/b/c\nAllow: /x\nDisallow: /x\n"))
+
+	assert.False(t, r.Allowed("bot", "/a"))
+	assert.True(t, r.Allowed("bot", "/a/b"))
+	assert.False(t, r.Allowed("bot", "/a/b/c/d"))
+	assert.True(t, r.Allowed("bot", "/x"))
+}
+
+// TestAllowedEmptyDisallow verifies an empty Disallow allows everything.
+func TestAllowedEmptyDisallow(t *testing.T) {
+	r := Parse([]byte("User-agent: *\nDisallow:\n"))
+
+	assert.True(t, r.Allowed("bot", "/anything"))
+}
+
+// TestAllowAllDisallowAll verifies the fixed rule sets, and that /robots.txt itself is always allowed.
+func TestAllowAllDisallowAll(t *testing.T) {
+	assert.True(t, AllowAll().Allowed("bot", "/anything"))
+	assert.False(t, DisallowAll().Allowed("bot", "/anything"))
+	assert.True(t, Parse([]byte("User-agent: *\nDisallow: /\n")).Allowed("bot", "/robots.txt"))
+}
+
+// TestMatchLength verifies wildcard and end-anchor pattern matching.
+func TestMatchLength(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"/", "/anything", true},
+		{"/fish", "/fish.html", true},
+		{"/fish", "/Fish", false},
+		{"/fish*", "/fishheads", true},
+		{"/*.php", "/folder/index.php?x=1", true},
+		{"/*.php$", "/index.php", true},
+		{"/*.php$", "/index.php5", false},
+		{"/fish*.php", "/fishheads/catfish.php", true},
+		{"/fish*.php", "/Fish.PHP", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, matchLength(tt.pattern, tt.path) >= 0, "%s vs %s", tt.pattern, tt.path)
+	}
+}
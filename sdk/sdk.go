@@ -0,0 +1,1674 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultMaxRetries is the default number of retries for retryable failures.
+	defaultMaxRetries = 2
+	// defaultRetryDelay is the default initial delay between retries.
+	defaultRetryDelay = 500 * time.Millisecond
+	// defaultTimeout is the default HTTP client timeout.
+	defaultTimeout = 2 * time.Minute
+)
+
+// Client is a Go client for the websurfer HTTP API.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	maxRetries int
+	retryDelay time.Duration
+	headers    http.Header
+}
+
+// Option configures the Client.
+type Option func(*Client)
+
+// WithAPIKey sets the API key sent as a Bearer token.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for requests.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithHeader adds a header sent with every request.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		if c.headers == nil {
+			c.headers = make(http.Header)
+		}
+		c.headers.Add(key, value)
+	}
+}
+
+// WithMaxRetries sets the number of retries for transport errors and 429/5xx responses.
+// Requests that start jobs, watches, sessions, or other server-side state are never retried,
+// since a retry after a lost response would create it twice.
+func WithMaxRetries(n int) Option {
+	return func(c *Client) {
+		c.maxRetries = n
+	}
+}
+
+// WithRetryDelay sets the initial delay between retries, doubled after each attempt.
+func WithRetryDelay(d time.Duration) Option {
+	return func(c *Client) {
+		c.retryDelay = d
+	}
+}
+
+// New creates a new Client for the server at baseURL (e.g. "http://localhost:8080").
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		maxRetries: defaultMaxRetries,
+		retryDelay: defaultRetryDelay,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// FetchRequest represents a request to fetch and process a URL.
+type FetchRequest struct {
+	URL          string        `json:"url"`
+	MaxTokens    int           `json:"max_tokens,omitempty"`
+	Offset       int           `json:"offset,omitempty"`
+	BypassCache  bool          `json:"bypass_cache,omitempty"`
+	Debug        bool          `json:"debug,omitempty"`
+	ParseOptions *ParseOptions `json:"parse_options,omitempty"`
+	CallbackURL  string        `json:"callback_url,omitempty"`
+	Tokenizer    string        `json:"tokenizer,omitempty"`
+	Search       *Search       `json:"search,omitempty"`
+	// FollowPagination joins the pages of a multi-page article into one document, up to
+	// MaxPages pages (default 5).
+	FollowPagination bool `json:"follow_pagination,omitempty"`
+	MaxPages         int  `json:"max_pages,omitempty"`
+	// Deterministic omits cache state, timestamps, the region, and provenance so identical
+	// content yields identical responses.
+	Deterministic bool `json:"deterministic,omitempty"`
+	// Headers and Cookies are sent with this fetch only, such as an Accept-Language or an
+	// Authorization token. Only allowlisted headers are accepted.
+	Headers map[string]string `json:"headers,omitempty"`
+	Cookies map[string]string `json:"cookies,omitempty"`
+	// Version serves a cached version listed by History, and AsOf (RFC 3339) the version
+	// that was current at that time, without fetching.
+	Version int    `json:"version,omitempty"`
+	AsOf    string `json:"as_of,omitempty"`
+	// SessionID fetches within a session created by CreateSession, sharing its cookies,
+	// Referer, and fetch budget.
+	SessionID string `json:"session_id,omitempty"`
+	// Profile extracts records for a kind of page alongside the content. "changelog" returns
+	// the releases of a changelog or release notes page in FetchResponse.Changelog.
+	Profile string `json:"profile,omitempty"`
+	// Language returns the page's hreflang variant for this language, such as "de" or "pt-BR",
+	// instead of the page itself when the page lists one.
+	Language string `json:"language,omitempty"`
+	// TranslateTo translates the content to this language, such as "en", when the page is in
+	// another language. The page's language is then in Metadata.OriginalLanguage.
+	TranslateTo string `json:"translate_to,omitempty"`
+	// Processors replaces the site's post-processing chain for this fetch. Nil keeps the
+	// site's chain and an empty slice disables it.
+	Processors []Processor `json:"processors"`
+	// Lines returns only a range of the content's lines instead of paginating by tokens.
+	Lines *LineRange `json:"lines,omitempty"`
+	// Base64 returns binary bodies served as text base64-encoded in Content instead of a
+	// metadata-only response.
+	Base64 bool `json:"base64,omitempty"`
+	// Selectors names CSS selectors whose matches are returned in Extracted, such as
+	// {"price": ".product-price", "image": "img.hero @src"}. SelectorsOnly omits the content.
+	Selectors     map[string]string `json:"selectors,omitempty"`
+	SelectorsOnly bool              `json:"selectors_only,omitempty"`
+	// Format selects the content of HTML pages: "markdown" (default), "raw_html" for the page's
+	// HTML as fetched or rendered, or "text" for a plain-text rendering.
+	Format string `json:"format,omitempty"`
+	// IncludeRaw returns the body the content was parsed from in FetchResponse.Raw alongside
+	// the content. Such fetches skip the cache.
+	IncludeRaw bool `json:"include_raw,omitempty"`
+}
+
+// LineRange selects lines of the content, 1-based and inclusive. Long lines are soft-wrapped
+// into several, so minified documents split into lines too. An End of zero reads to the last
+// line.
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end,omitempty"`
+}
+
+// Processor is a post-processing step. Type is "normalize", "strip_boilerplate", "redact",
+// "translate", or "summarize". Redact lists the kinds a redact step replaces ("email",
+// "phone", "credit_card", "ip"; default: all), Language is a translate step's target, and
+// MaxSentences a summarize step's length (default: 3).
+type Processor struct {
+	Type         string   `json:"type"`
+	Language     string   `json:"language,omitempty"`
+	Redact       []string `json:"redact,omitempty"`
+	MaxSentences int      `json:"max_sentences,omitempty"`
+}
+
+// Search searches within the fetched document. Mode is "plain" (default), "regex",
+// or "fuzzy" (edit distance).
+type Search struct {
+	Query      string `json:"query"`
+	Mode       string `json:"mode,omitempty"`
+	MaxResults int    `json:"max_results,omitempty"`
+}
+
+// ParseOptions controls how images ("none", "inline", "appendix") and links
+// ("inline", "references") are rendered in the content.
+type ParseOptions struct {
+	Images string `json:"images,omitempty"`
+	Links  string `json:"links,omitempty"`
+	TOC    bool   `json:"toc,omitempty"`
+}
+
+// Metadata contains metadata about the fetched content.
+type Metadata struct {
+	URL               string   `json:"url"`
+	StatusCode        int      `json:"status_code"`
+	ContentType       string   `json:"content_type"`
+	Language          string   `json:"language,omitempty"`
+	OriginalLanguage  string   `json:"original_language,omitempty"`
+	Title             string   `json:"title,omitempty"`
+	Description       string   `json:"description,omitempty"`
+	FaviconURL        string   `json:"favicon_url,omitempty"`
+	ImageURL          string   `json:"image_url,omitempty"`
+	LeadImageURL      string   `json:"lead_image_url,omitempty"`
+	Excerpt           string   `json:"excerpt,omitempty"`
+	EstimatedTokens   int      `json:"estimated_tokens"`
+	LastModified      string   `json:"last_modified,omitempty"`
+	CacheState        string   `json:"cache_state,omitempty"`
+	CachedAt          string   `json:"cached_at,omitempty"`
+	RefetchSuppressed bool     `json:"refetch_suppressed,omitempty"`
+	Region            string   `json:"region,omitempty"`
+	RateLimitWaitMs   int64    `json:"rate_limit_wait_ms,omitempty"`
+	RetryWaitMs       int64    `json:"retry_wait_ms,omitempty"`
+	Version           int      `json:"version,omitempty"`
+	SeriesPages       []string `json:"series_pages,omitempty"`
+	// OriginalURL and ArchivedAt are set when the page was dead and URL is a Wayback Machine snapshot.
+	OriginalURL string `json:"original_url,omitempty"`
+	ArchivedAt  string `json:"archived_at,omitempty"`
+	// License is the declared license's name, such as "CC BY-SA 4.0", when known. Robots lists
+	// robots meta and X-Robots-Tag directives, such as "noindex" or "noai".
+	License    string   `json:"license,omitempty"`
+	LicenseURL string   `json:"license_url,omitempty"`
+	Robots     []string `json:"robots,omitempty"`
+	// AccessIssue is "paywall", "login_required", or "consent_wall" when the content is
+	// dominated by such a prompt rather than the page itself.
+	AccessIssue string `json:"access_issue,omitempty"`
+	// Alternates lists the page's language variants declared with hreflang links.
+	Alternates []Alternate `json:"alternates,omitempty"`
+	// Processors lists the post-processing steps applied to the content, in order.
+	Processors []string `json:"processors,omitempty"`
+	// Binary is set when the body is binary data rather than text, and Encoding is "base64"
+	// when Content holds it base64-encoded.
+	Binary   bool   `json:"binary,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Alternate is a language variant of a page. Lang is a BCP 47 tag or "x-default".
+type Alternate struct {
+	Lang string `json:"lang"`
+	URL  string `json:"url"`
+}
+
+// FetchResponse represents the response from a fetch request.
+type FetchResponse struct {
+	Metadata      Metadata            `json:"metadata"`
+	Content       string              `json:"content,omitempty"`
+	Outline       json.RawMessage     `json:"outline,omitempty"`
+	Summary       string              `json:"summary,omitempty"`
+	Structured    *Structured         `json:"structured,omitempty"`
+	Forms         []Form              `json:"forms,omitempty"`
+	Extracted     map[string][]string `json:"extracted,omitempty"`
+	Raw           *RawContent         `json:"raw,omitempty"`
+	Changelog     []Release           `json:"changelog,omitempty"`
+	Pagination    *Pagination         `json:"pagination,omitempty"`
+	Lines         *LineInfo           `json:"lines,omitempty"`
+	SearchResults []SearchResult      `json:"search_results,omitempty"`
+	Debug         *DebugInfo          `json:"debug,omitempty"`
+	Provenance    *Provenance         `json:"provenance,omitempty"`
+}
+
+// RawContent is the body a fetch's content was parsed from, returned with IncludeRaw. Content
+// holds the body, base64-encoded when Encoding is "base64", or its first bytes when Truncated
+// is set. Bodies over the server's limit are in Object instead when it has an object store.
+type RawContent struct {
+	ContentType string        `json:"content_type"`
+	Size        int64         `json:"size"`
+	SHA256      string        `json:"sha256"`
+	Content     string        `json:"content,omitempty"`
+	Encoding    string        `json:"encoding,omitempty"`
+	Truncated   bool          `json:"truncated,omitempty"`
+	Object      *StoredObject `json:"object,omitempty"`
+}
+
+// Structured holds the FAQs and how-tos found in a page.
+type Structured struct {
+	FAQ    []QA    `json:"faq,omitempty"`
+	HowTos []HowTo `json:"how_to,omitempty"`
+}
+
+// QA is a question and its answer.
+type QA struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// HowTo is a set of instructions.
+type HowTo struct {
+	Name        string      `json:"name,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Steps       []HowToStep `json:"steps"`
+}
+
+// HowToStep is a single step of a HowTo.
+type HowToStep struct {
+	Name string `json:"name,omitempty"`
+	Text string `json:"text"`
+}
+
+// Form is an HTML form in a page. Action is absolute, and Method is "GET", "POST", or "DIALOG".
+type Form struct {
+	ID      string      `json:"id,omitempty"`
+	Name    string      `json:"name,omitempty"`
+	Action  string      `json:"action"`
+	Method  string      `json:"method"`
+	Enctype string      `json:"enctype,omitempty"`
+	Fields  []FormField `json:"fields"`
+}
+
+// FormField is a control of a form. Radio buttons and checkboxes sharing a name are one field
+// whose Options are the choices.
+type FormField struct {
+	Name        string       `json:"name,omitempty"`
+	Type        string       `json:"type"`
+	Label       string       `json:"label,omitempty"`
+	Value       string       `json:"value,omitempty"`
+	Placeholder string       `json:"placeholder,omitempty"`
+	Required    bool         `json:"required,omitempty"`
+	Multiple    bool         `json:"multiple,omitempty"`
+	Options     []FormOption `json:"options,omitempty"`
+}
+
+// FormOption is a choice of a select, radio, or checkbox field.
+type FormOption struct {
+	Value    string `json:"value"`
+	Label    string `json:"label,omitempty"`
+	Selected bool   `json:"selected,omitempty"`
+}
+
+// Release is a release listed in a changelog. Date is YYYY-MM-DD when the changelog states one.
+type Release struct {
+	Version string          `json:"version"`
+	Date    string          `json:"date,omitempty"`
+	Title   string          `json:"title"`
+	Changes []ReleaseChange `json:"changes"`
+}
+
+// ReleaseChange is a single change in a release. Type is the heading it is listed under, such
+// as "Added" or "Fixed", if any.
+type ReleaseChange struct {
+	Type string `json:"type,omitempty"`
+	Text string `json:"text"`
+}
+
+// Provenance is a signed proof that a body hashing to OriginSHA256 was fetched from URL at
+// FetchedAt, and that Content was derived from it by Transforms. Verify it against the key
+// from ProvenanceKey.
+type Provenance struct {
+	URL           string   `json:"url"`
+	OriginSHA256  string   `json:"origin_sha256"`
+	Transforms    []string `json:"transforms"`
+	ContentSHA256 string   `json:"content_sha256"`
+	FetchedAt     string   `json:"fetched_at"`
+	Algorithm     string   `json:"algorithm"`
+	KeyID         string   `json:"key_id"`
+	Signature     string   `json:"signature"`
+}
+
+// ProvenanceKey is the public key that verifies provenance proofs.
+type ProvenanceKey struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`
+	PublicKey string `json:"public_key"`
+}
+
+// CacheExportRequest selects the cache entries to export: every cached URL on Domain, or the
+// listed URLs.
+type CacheExportRequest struct {
+	Domain string   `json:"domain,omitempty"`
+	URLs   []string `json:"urls,omitempty"`
+}
+
+// CacheImportResponse reports how many entries were imported from a snapshot.
+type CacheImportResponse struct {
+	Imported int `json:"imported"`
+}
+
+// CacheKeysResponse lists the cached URLs of a domain. Total counts them all, and Truncated
+// is set when URLs was cut to the requested limit.
+type CacheKeysResponse struct {
+	Domain    string   `json:"domain"`
+	URLs      []string `json:"urls"`
+	Total     int      `json:"total"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// CacheEntry describes how a cached entry is stored, without its body. State is "fresh",
+// "stale", or "expired".
+type CacheEntry struct {
+	URL             string `json:"url"`
+	StatusCode      int    `json:"status_code"`
+	Title           string `json:"title,omitempty"`
+	State           string `json:"state"`
+	StoredAt        string `json:"stored_at"`
+	ExpiresAt       string `json:"expires_at"`
+	TTL             string `json:"ttl"`
+	StaleTime       string `json:"stale_time"`
+	BodyBytes       int    `json:"body_bytes"`
+	StoredBytes     int    `json:"stored_bytes"`
+	Compressed      bool   `json:"compressed"`
+	BodyHash        string `json:"body_hash,omitempty"`
+	BlobKey         string `json:"blob_key,omitempty"`
+	Version         int    `json:"version,omitempty"`
+	HistoryVersions int64  `json:"history_versions"`
+}
+
+// CachePurgeResponse reports how many URLs were purged from the cache.
+type CachePurgeResponse struct {
+	Purged int `json:"purged"`
+}
+
+// CacheUsage reports the size of the cache, with the entries of each domain, most first.
+type CacheUsage struct {
+	Entries           int           `json:"entries"`
+	StoredBytes       int64         `json:"stored_bytes"`
+	UncompressedBytes int64         `json:"uncompressed_bytes"`
+	CompressedEntries int           `json:"compressed_entries"`
+	CompressionRatio  float64       `json:"compression_ratio"`
+	Domains           []DomainUsage `json:"domains"`
+	Dedup             *CacheStats   `json:"dedup,omitempty"`
+}
+
+// DomainUsage counts the cached entries of a domain.
+type DomainUsage struct {
+	Domain  string `json:"domain"`
+	Entries int    `json:"entries"`
+}
+
+// SearchResult is a passage of the document that matched the search query.
+type SearchResult struct {
+	Text      string        `json:"text"`
+	CharStart int           `json:"char_start"`
+	CharEnd   int           `json:"char_end"`
+	Score     float64       `json:"score"`
+	Matches   []SearchMatch `json:"matches"`
+	Section   string        `json:"section,omitempty"`
+	Anchor    string        `json:"anchor,omitempty"`
+	// SectionPath is the section's breadcrumb path, such as "Guide > Setup > Linux", matching
+	// the path of its heading in the outline.
+	SectionPath string `json:"section_path,omitempty"`
+	// LineStart and LineEnd are the lines the passage spans, numbered like LineRange.
+	LineStart int `json:"line_start"`
+	LineEnd   int `json:"line_end"`
+}
+
+// SearchMatch is a matched span within the document.
+type SearchMatch struct {
+	Text      string `json:"text"`
+	CharStart int    `json:"char_start"`
+	CharEnd   int    `json:"char_end"`
+}
+
+// Pagination contains pagination information for the response.
+type Pagination struct {
+	Offset              int  `json:"offset"`
+	Limit               int  `json:"limit"`
+	TotalTokens         int  `json:"total_tokens"`
+	HasMore             bool `json:"has_more"`
+	SuggestedNextOffset int  `json:"suggested_next_offset,omitempty"`
+}
+
+// LineInfo locates the content returned for a LineRange among the document's lines.
+// CharStart and CharEnd are the byte offsets of the returned lines in the document.
+type LineInfo struct {
+	Start      int `json:"start"`
+	End        int `json:"end"`
+	TotalLines int `json:"total_lines"`
+	CharStart  int `json:"char_start"`
+	CharEnd    int `json:"char_end"`
+}
+
+// DebugInfo contains diagnostic details included when debug is requested.
+type DebugInfo struct {
+	Attempts []Attempt `json:"attempts"`
+	Transfer *Transfer `json:"transfer,omitempty"`
+}
+
+// Attempt describes a single origin fetch attempt.
+type Attempt struct {
+	Timestamp  string `json:"timestamp"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	WaitMs     int64  `json:"wait_ms,omitempty"`
+	DelayMs    int64  `json:"delay_ms,omitempty"`
+}
+
+// Transfer describes how a response was transferred from origin.
+type Transfer struct {
+	Protocol         string `json:"protocol"`
+	TLSVersion       string `json:"tls_version,omitempty"`
+	RemoteIP         string `json:"remote_ip,omitempty"`
+	ContentEncoding  string `json:"content_encoding,omitempty"`
+	CompressedSize   int64  `json:"compressed_size"`
+	DecompressedSize int64  `json:"decompressed_size"`
+}
+
+// StatsResponse represents the response from a stats request.
+type StatsResponse struct {
+	ActiveDomains  int           `json:"active_domains"`
+	PausedDomains  []DomainPause `json:"paused_domains"`
+	ProbingDomains []DomainProbe `json:"probing_domains"`
+	ErrorPauses    int64         `json:"error_pauses_total"`
+	Cache          *CacheStats   `json:"cache,omitempty"`
+	Headless       HeadlessStats `json:"headless"`
+}
+
+// HeadlessStats describes the pool of browser contexts headless renders run in.
+type HeadlessStats struct {
+	PoolSize int   `json:"pool_size"`
+	Warm     int   `json:"warm"`
+	Active   int   `json:"active"`
+	Waiting  int   `json:"waiting"`
+	Renders  int64 `json:"renders_total"`
+	Failures int64 `json:"failures_total"`
+	Recycled int64 `json:"recycled_total"`
+}
+
+// CacheStats describes how much Redis memory body deduplication saves.
+type CacheStats struct {
+	DedupBodies      int   `json:"dedup_bodies"`
+	DedupReferences  int64 `json:"dedup_references"`
+	DedupStoredBytes int64 `json:"dedup_stored_bytes"`
+	DedupSavedBytes  int64 `json:"dedup_saved_bytes"`
+}
+
+// DomainPause describes a domain whose traffic is temporarily paused.
+type DomainPause struct {
+	Domain    string  `json:"domain"`
+	Until     string  `json:"until"`
+	Reason    string  `json:"reason"`
+	URLs      int     `json:"urls,omitempty"`
+	ErrorRate float64 `json:"error_rate,omitempty"`
+}
+
+// DomainProbe describes a domain resuming after an error pause, one probe request at a time.
+type DomainProbe struct {
+	Domain    string `json:"domain"`
+	Successes int    `json:"successes"`
+	Required  int    `json:"required"`
+}
+
+// QueueResponse represents the response from a queue request.
+type QueueResponse struct {
+	Waiting  int           `json:"waiting"`
+	InFlight int           `json:"in_flight"`
+	Domains  []DomainQueue `json:"domains"`
+}
+
+// DomainQueue lists the fetches waiting on and in flight to a domain.
+type DomainQueue struct {
+	Domain   string        `json:"domain"`
+	Waiting  []QueuedFetch `json:"waiting"`
+	InFlight []QueuedFetch `json:"in_flight"`
+}
+
+// QueuedFetch describes a fetch waiting on a domain's rate limits or in flight to it.
+// Reason is "rate_limit", "concurrency", "queued", or "retry_after" while waiting, and empty in
+// flight. Caller is who the fetch is made for.
+type QueuedFetch struct {
+	URL        string `json:"url"`
+	Caller     string `json:"caller,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	Since      string `json:"since"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// AsyncResponse is returned when a fetch is submitted with a CallbackURL.
+type AsyncResponse struct {
+	JobID  string `json:"job_id"`
+	Status string `json:"status"`
+}
+
+// WebhookPayload is the body POSTed to the callback URL when an async fetch or crawl finishes.
+// Use webhook.Verify to check its signature.
+type WebhookPayload struct {
+	JobID       string         `json:"job_id"`
+	Status      string         `json:"status"`
+	URL         string         `json:"url"`
+	CompletedAt string         `json:"completed_at"`
+	Result      *FetchResponse `json:"result,omitempty"`
+	// Crawl is the result of a crawl job, in place of Result.
+	Crawl *CrawlResult `json:"crawl,omitempty"`
+	Error *APIError    `json:"error,omitempty"`
+}
+
+// JobResponse describes a queued fetch or crawl job and, once finished, its outcome.
+type JobResponse struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	Status    string         `json:"status"`
+	Attempts  int            `json:"attempts"`
+	CreatedAt string         `json:"created_at"`
+	UpdatedAt string         `json:"updated_at"`
+	Result    *FetchResponse `json:"result,omitempty"`
+	Crawl     *CrawlResult   `json:"crawl,omitempty"`
+	Progress  *CrawlProgress `json:"progress,omitempty"`
+	Preload   *PreloadResult `json:"preload,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// PreloadRequest fetches pages into the server's cache as a job. Pages are listed in URLs,
+// read from the sitemap at SitemapURL, or both. Rate is how many pages are fetched per second
+// (default: 1, max: 10).
+type PreloadRequest struct {
+	URLs        []string `json:"urls,omitempty"`
+	SitemapURL  string   `json:"sitemap_url,omitempty"`
+	Rate        float64  `json:"rate,omitempty"`
+	BypassCache bool     `json:"bypass_cache,omitempty"`
+}
+
+// PreloadResult is the outcome of a preload job. Fetched counts pages fetched from origin,
+// and Cached those already in the cache.
+type PreloadResult struct {
+	URLs     int              `json:"urls"`
+	Fetched  int              `json:"fetched"`
+	Cached   int              `json:"cached"`
+	Failed   int              `json:"failed"`
+	Failures []PreloadFailure `json:"failures,omitempty"`
+	// LimitReached names the server safety limit that cut the preload short, if any.
+	LimitReached string `json:"limit_reached,omitempty"`
+}
+
+// PreloadFailure is a page that could not be preloaded.
+type PreloadFailure struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	ErrorCode  string `json:"error_code,omitempty"`
+}
+
+// CrawlRequest crawls the same-host pages reachable from URL as a job.
+type CrawlRequest struct {
+	URL         string `json:"url"`
+	MaxPages    int    `json:"max_pages,omitempty"`
+	MaxDepth    int    `json:"max_depth,omitempty"`
+	BypassCache bool   `json:"bypass_cache,omitempty"`
+	// RespectNofollow skips nofollow links and the links of pages marked nofollow.
+	RespectNofollow bool `json:"respect_nofollow,omitempty"`
+	// Canonical is "ignore" (default), "skip", or "remap" for pages whose canonical URL is
+	// another page.
+	Canonical string `json:"canonical,omitempty"`
+	// CallbackURL receives a WebhookPayload with the crawl result once the crawl finishes.
+	CallbackURL string `json:"callback_url,omitempty"`
+}
+
+// CrawlProgress reports how far a crawl job has come. PagesPerSecond is the rate over the last
+// minute, and ETASeconds, when known, the estimated time left to visit the pages discovered,
+// up to max_pages.
+type CrawlProgress struct {
+	Discovered     int     `json:"discovered"`
+	Fetched        int     `json:"fetched"`
+	Failed         int     `json:"failed"`
+	Bytes          int64   `json:"bytes"`
+	PagesPerSecond float64 `json:"pages_per_second"`
+	ETASeconds     int     `json:"eta_seconds,omitempty"`
+	UpdatedAt      string  `json:"updated_at"`
+}
+
+// CrawlResult is the outcome of a crawl job.
+type CrawlResult struct {
+	URL     string       `json:"url"`
+	Pages   []CrawlPage  `json:"pages"`
+	Outline *SiteOutline `json:"outline"`
+	// LimitReached names the server safety limit that cut the crawl short, if any.
+	LimitReached string `json:"limit_reached,omitempty"`
+}
+
+// CrawlPage is a page visited during a crawl.
+type CrawlPage struct {
+	URL        string `json:"url"`
+	Depth      int    `json:"depth"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Title      string `json:"title,omitempty"`
+	Tokens     int    `json:"tokens,omitempty"`
+	Error      string `json:"error,omitempty"`
+	ErrorCode  string `json:"error_code,omitempty"`
+	// CanonicalURL is the page's declared canonical URL, and RemappedFrom the URL it was
+	// reached by when reported under its canonical URL.
+	CanonicalURL string      `json:"canonical_url,omitempty"`
+	RemappedFrom string      `json:"remapped_from,omitempty"`
+	Alternates   []Alternate `json:"alternates,omitempty"`
+}
+
+// SiteOutline is a table of contents for a crawled site, with pages arranged by URL path.
+type SiteOutline struct {
+	URL         string       `json:"url"`
+	Pages       int          `json:"pages"`
+	TotalTokens int          `json:"total_tokens"`
+	Root        *OutlineNode `json:"root"`
+}
+
+// OutlineNode is a path segment in a site outline.
+type OutlineNode struct {
+	Path        string         `json:"path"`
+	URL         string         `json:"url,omitempty"`
+	Title       string         `json:"title,omitempty"`
+	Tokens      int            `json:"tokens,omitempty"`
+	TotalTokens int            `json:"total_tokens"`
+	Children    []*OutlineNode `json:"children,omitempty"`
+}
+
+// MapRequest discovers the pages of a site. Set GroupBy to "section" to group them by
+// the first segment of their path.
+type MapRequest struct {
+	URL         string `json:"url"`
+	Limit       int    `json:"limit,omitempty"`
+	GroupBy     string `json:"group_by,omitempty"`
+	BypassCache bool   `json:"bypass_cache,omitempty"`
+	// IncludeMetadata fetches page titles and descriptions and flags duplicated ones.
+	IncludeMetadata bool `json:"include_metadata,omitempty"`
+	// MetadataMode is "full" (default) to fetch each page, or "head" to send HEAD requests and
+	// read titles and descriptions only from cached pages.
+	MetadataMode string `json:"metadata_mode,omitempty"`
+	// IncludeExternal reports off-site links along with the pages that link to them.
+	IncludeExternal bool `json:"include_external,omitempty"`
+	// RespectNofollow skips nofollow links when a site is mapped from the page's links.
+	RespectNofollow bool `json:"respect_nofollow,omitempty"`
+}
+
+// MapResponse lists the pages discovered on a site. URLs is set for flat results and Groups
+// for grouped results.
+type MapResponse struct {
+	URL       string         `json:"url"`
+	Source    string         `json:"source"`
+	Total     int            `json:"total"`
+	Truncated bool           `json:"truncated,omitempty"`
+	URLs      []MapURL       `json:"urls,omitempty"`
+	Groups    []MapGroup     `json:"groups,omitempty"`
+	External  []ExternalLink `json:"external,omitempty"`
+	// LimitReached names the server safety limit that cut the map short, if any.
+	LimitReached string `json:"limit_reached,omitempty"`
+	// CacheState reports whether the discovered URLs came from the map cache: "hit",
+	// "revalidated", or "miss". CachedAt is when they were discovered.
+	CacheState string `json:"cache_state,omitempty"`
+	CachedAt   string `json:"cached_at,omitempty"`
+}
+
+// MapURL is a discovered page. DuplicateTitle and DuplicateDescription flag template
+// placeholders shared by several pages.
+type MapURL struct {
+	URL                  string `json:"url"`
+	LastModified         string `json:"last_modified,omitempty"`
+	Title                string `json:"title,omitempty"`
+	Description          string `json:"description,omitempty"`
+	CanonicalURL         string `json:"canonical_url,omitempty"`
+	DuplicateTitle       bool   `json:"duplicate_title,omitempty"`
+	DuplicateDescription bool   `json:"duplicate_description,omitempty"`
+	// StatusCode and ContentType are reported for pages read with metadata_mode "head".
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// MapGroup is a set of discovered pages sharing a section of the site.
+type MapGroup struct {
+	Section string   `json:"section"`
+	Count   int      `json:"count"`
+	URLs    []MapURL `json:"urls"`
+}
+
+// ExternalLink is an off-site link found while mapping. Count is the number of referring pages,
+// of which up to 20 are listed.
+type ExternalLink struct {
+	URL       string   `json:"url"`
+	Count     int      `json:"count"`
+	Referrers []string `json:"referrers"`
+}
+
+// SitemapsResponse lists the sitemaps a site declares in its robots.txt.
+type SitemapsResponse struct {
+	URL      string        `json:"url"`
+	Sitemaps []SitemapInfo `json:"sitemaps"`
+}
+
+// SitemapGenerateRequest crawls a site and generates its sitemap. Format is "xml" (default)
+// or "json"; GenerateSitemap and GenerateSitemapXML set it.
+type SitemapGenerateRequest struct {
+	CrawlRequest
+	Format string `json:"format,omitempty"`
+}
+
+// SitemapGenerateResponse lists the pages of a generated sitemap.
+type SitemapGenerateResponse struct {
+	URL   string   `json:"url"`
+	Total int      `json:"total"`
+	URLs  []MapURL `json:"urls"`
+	// LimitReached names the server safety limit that cut the crawl short, if any.
+	LimitReached string `json:"limit_reached,omitempty"`
+}
+
+// SitemapInfo describes a sitemap. Type ("index" or "urlset"), URLCount, and Error are only
+// set when sitemaps are expanded.
+type SitemapInfo struct {
+	URL      string `json:"url"`
+	Parent   string `json:"parent,omitempty"`
+	Type     string `json:"type,omitempty"`
+	URLCount int    `json:"url_count,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// DiffResponse compares the cached version of a page with a fresh fetch. Previous is nil if
+// the page had not been cached.
+type DiffResponse struct {
+	URL               string       `json:"url"`
+	Previous          *DiffVersion `json:"previous,omitempty"`
+	Current           DiffVersion  `json:"current"`
+	Changed           bool         `json:"changed"`
+	Diff              string       `json:"diff,omitempty"`
+	Summary           DiffSummary  `json:"summary"`
+	RefetchSuppressed bool         `json:"refetch_suppressed,omitempty"`
+}
+
+// DiffVersion describes one version of a page.
+type DiffVersion struct {
+	StatusCode int    `json:"status_code"`
+	FetchedAt  string `json:"fetched_at"`
+}
+
+// HistoryResponse lists the cached versions of a page, newest first.
+type HistoryResponse struct {
+	URL      string           `json:"url"`
+	Versions []HistoryVersion `json:"versions"`
+}
+
+// HistoryVersion describes one cached version of a page. ChangedAt is when the version was
+// first fetched, and FetchedAt when it was last confirmed unchanged.
+type HistoryVersion struct {
+	Version    int    `json:"version"`
+	StatusCode int    `json:"status_code"`
+	Title      string `json:"title,omitempty"`
+	ChangedAt  string `json:"changed_at"`
+	FetchedAt  string `json:"fetched_at"`
+	Bytes      int    `json:"bytes"`
+}
+
+// DiffSummary counts changed lines and lists the outline sections added or removed.
+type DiffSummary struct {
+	LinesAdded      int      `json:"lines_added"`
+	LinesRemoved    int      `json:"lines_removed"`
+	SectionsAdded   []string `json:"sections_added,omitempty"`
+	SectionsRemoved []string `json:"sections_removed,omitempty"`
+}
+
+// ValidateResponse holds a verdict for each URL passed to Validate, in order. Valid counts the
+// URLs that may be fetched.
+type ValidateResponse struct {
+	Results []URLVerdict `json:"results"`
+	Valid   int          `json:"valid"`
+}
+
+// URLVerdict is the verdict for one URL: "valid", "invalid", "ssrf_blocked", "blocked_domain",
+// or "robots_disallowed". Reason explains verdicts other than valid.
+type URLVerdict struct {
+	URL     string `json:"url"`
+	Verdict string `json:"verdict"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// ProbeResponse reports whether a URL responds, from a HEAD request or a GET whose body is not
+// read. Reachable is false when no response was received, with ErrorCode and FailureType
+// describing the connection failure. LatencyMs is the time to the first byte of the response.
+type ProbeResponse struct {
+	URL         string            `json:"url"`
+	FinalURL    string            `json:"final_url,omitempty"`
+	Reachable   bool              `json:"reachable"`
+	Method      string            `json:"method,omitempty"`
+	StatusCode  int               `json:"status_code,omitempty"`
+	LatencyMs   int64             `json:"latency_ms"`
+	Protocol    string            `json:"protocol,omitempty"`
+	TLSVersion  string            `json:"tls_version,omitempty"`
+	RemoteIP    string            `json:"remote_ip,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	ErrorCode   string            `json:"error_code,omitempty"`
+	FailureType string            `json:"failure_type,omitempty"`
+}
+
+// HeadResponse holds the response headers of each URL passed to Head, in order. Existing counts
+// the URLs that exist.
+type HeadResponse struct {
+	Results  []URLHead `json:"results"`
+	Existing int       `json:"existing"`
+}
+
+// URLHead describes one URL from its response headers. Exists is set for a status below 400,
+// and ContentLength is nil when the server declared no length. Error and ErrorCode are set
+// instead when the URL could not be checked.
+type URLHead struct {
+	URL           string            `json:"url"`
+	FinalURL      string            `json:"final_url,omitempty"`
+	Exists        bool              `json:"exists"`
+	StatusCode    int               `json:"status_code,omitempty"`
+	ContentType   string            `json:"content_type,omitempty"`
+	ContentLength *int64            `json:"content_length,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	ErrorCode     string            `json:"error_code,omitempty"`
+}
+
+// LinkcheckRequest asks for the links of a page to be verified.
+type LinkcheckRequest struct {
+	URL         string `json:"url"`
+	BypassCache bool   `json:"bypass_cache,omitempty"`
+}
+
+// LinkcheckResponse classifies each distinct link of a page, in the order they appear.
+// Truncated is set when only the page's first 200 links were checked.
+type LinkcheckResponse struct {
+	URL          string       `json:"url"`
+	Total        int          `json:"total"`
+	Truncated    bool         `json:"truncated,omitempty"`
+	OK           int          `json:"ok"`
+	Redirected   int          `json:"redirected"`
+	Broken       int          `json:"broken"`
+	Skipped      int          `json:"skipped"`
+	Links        []LinkResult `json:"links"`
+	LimitReached string       `json:"limit_reached,omitempty"`
+}
+
+// LinkResult is the classification of one link: "ok", "redirected", "broken", or "skipped".
+// Location is where a redirected link leads.
+type LinkResult struct {
+	URL        string `json:"url"`
+	Status     string `json:"status"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Location   string `json:"location,omitempty"`
+	External   bool   `json:"external,omitempty"`
+	Error      string `json:"error,omitempty"`
+	ErrorCode  string `json:"error_code,omitempty"`
+}
+
+// WatchRequest registers a URL to be refetched every Interval, a Go duration such as "30m".
+// CallbackURL is notified when at least Threshold, a fraction from 0 to 1, of the page's lines
+// change between versions.
+type WatchRequest struct {
+	URL         string  `json:"url"`
+	Interval    string  `json:"interval,omitempty"`
+	Threshold   float64 `json:"threshold,omitempty"`
+	CallbackURL string  `json:"callback_url,omitempty"`
+}
+
+// WatchResponse describes a watch and the stored versions of its page, newest first.
+type WatchResponse struct {
+	ID          string  `json:"id"`
+	URL         string  `json:"url"`
+	Interval    string  `json:"interval"`
+	Threshold   float64 `json:"threshold"`
+	CallbackURL string  `json:"callback_url,omitempty"`
+	// Caller is who created the watch: a configured caller's name, an API key's hash prefix
+	// ("key:..."), or a client IP ("ip:..."). Its checks are rate limited as made by them.
+	Caller      string `json:"caller,omitempty"`
+	CreatedAt   string `json:"created_at"`
+	CheckedAt   string `json:"checked_at,omitempty"`
+	NextCheckAt string `json:"next_check_at"`
+	LastError   string `json:"last_error,omitempty"`
+	// RefetchSuppressed is set when the last check reused a fetch made within the site's
+	// min_refetch_interval instead of fetching the page.
+	RefetchSuppressed bool           `json:"refetch_suppressed,omitempty"`
+	Versions          []WatchVersion `json:"versions,omitempty"`
+}
+
+// WatchVersion describes one version of a watched page. Change is the fraction of lines that
+// changed from the previous version.
+type WatchVersion struct {
+	FetchedAt  string  `json:"fetched_at"`
+	StatusCode int     `json:"status_code"`
+	Hash       string  `json:"hash"`
+	Change     float64 `json:"change"`
+}
+
+// SessionRequest starts a browsing session that may make up to MaxFetches fetches (default
+// 100) and lives for IdleTimeout, a Go duration such as "10m", after its last fetch (default "30m").
+type SessionRequest struct {
+	MaxFetches  int    `json:"max_fetches,omitempty"`
+	IdleTimeout string `json:"idle_timeout,omitempty"`
+}
+
+// SessionResponse describes a session. Referer is the last page fetched in it.
+type SessionResponse struct {
+	ID          string          `json:"id"`
+	MaxFetches  int             `json:"max_fetches"`
+	Fetches     int             `json:"fetches"`
+	IdleTimeout string          `json:"idle_timeout"`
+	Referer     string          `json:"referer,omitempty"`
+	Cookies     []SessionCookie `json:"cookies,omitempty"`
+	CreatedAt   string          `json:"created_at"`
+	ExpiresAt   string          `json:"expires_at"`
+}
+
+// SessionCookie describes a cookie held by a session, without its value.
+type SessionCookie struct {
+	Name    string `json:"name"`
+	Domain  string `json:"domain"`
+	Path    string `json:"path"`
+	Expires string `json:"expires,omitempty"`
+}
+
+// Image is an image returned by the favicon and preview image endpoints. URL is where the
+// image was fetched from.
+type Image struct {
+	Data        []byte
+	ContentType string
+	URL         string
+}
+
+// DownloadRequest downloads a binary asset, such as a PDF, image, or archive, without parsing
+// it. Accept lists the media types it may have, such as "image/*" (default: anything but HTML),
+// and MaxSize caps its size in bytes.
+type DownloadRequest struct {
+	URL     string   `json:"url"`
+	Accept  []string `json:"accept,omitempty"`
+	MaxSize int64    `json:"max_size,omitempty"`
+	// Store is set by StoreDownload.
+	Store bool `json:"store,omitempty"`
+}
+
+// Download is an asset returned by Download. URL is where it was fetched from, after redirects.
+// SHA256 is read from the trailer that follows the streamed asset.
+type Download struct {
+	Data        []byte
+	ContentType string
+	URL         string
+	SHA256      string
+}
+
+// DownloadResponse describes an asset uploaded to the server's object store.
+type DownloadResponse struct {
+	URL         string        `json:"url"`
+	ContentType string        `json:"content_type"`
+	Size        int64         `json:"size"`
+	SHA256      string        `json:"sha256"`
+	Object      *StoredObject `json:"object"`
+}
+
+// StoredObject is an object in the server's object store. Location is its s3:// or gs:// URI.
+type StoredObject struct {
+	Bucket   string `json:"bucket"`
+	Key      string `json:"key"`
+	Location string `json:"location"`
+	URL      string `json:"url"`
+	Size     int64  `json:"size"`
+}
+
+// SemanticSearchRequest searches a URL's content by meaning rather than keywords.
+type SemanticSearchRequest struct {
+	URL          string        `json:"url"`
+	Query        string        `json:"query"`
+	TopK         int           `json:"top_k,omitempty"`
+	BypassCache  bool          `json:"bypass_cache,omitempty"`
+	ParseOptions *ParseOptions `json:"parse_options,omitempty"`
+}
+
+// SemanticSearchResponse lists the chunks of a document most similar to the query.
+type SemanticSearchResponse struct {
+	URL     string                 `json:"url"`
+	Results []SemanticSearchResult `json:"results"`
+}
+
+// SemanticSearchResult is a document chunk ranked by similarity to the query.
+type SemanticSearchResult struct {
+	Text        string  `json:"text"`
+	CharStart   int     `json:"char_start"`
+	CharEnd     int     `json:"char_end"`
+	Section     string  `json:"section,omitempty"`
+	Anchor      string  `json:"anchor,omitempty"`
+	SectionPath string  `json:"section_path,omitempty"`
+	Score       float64 `json:"score"`
+}
+
+// APIError is returned when the server responds with a non-2xx status.
+type APIError struct {
+	StatusCode int    `json:"status_code"`
+	Message    string `json:"error"`
+	// ErrorCode is a stable, machine-readable code for the cause, such as ROBOTS_BLOCKED or
+	// UPSTREAM_429. ErrorCodes lists them all.
+	ErrorCode string `json:"error_code,omitempty"`
+	// FailureType classifies connection failures: dns_error, tls_error, conn_refused, reset, or timeout.
+	FailureType string `json:"failure_type,omitempty"`
+	// Debug lists the origin fetch attempts made before a fetch failed, when debug was requested.
+	Debug *DebugInfo `json:"debug,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("websurfer: HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// Fetch fetches and processes a single URL. It is retried on transient failures unless
+// req.CallbackURL is set, which makes the server start a background fetch instead.
+func (c *Client) Fetch(ctx context.Context, req FetchRequest) (*FetchResponse, error) {
+	var resp FetchResponse
+	if err := c.send(ctx, http.MethodPost, "/v1/fetch", req, &resp, req.CallbackURL == ""); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// FetchAsync submits a fetch that runs in the background. The result is POSTed to
+// req.CallbackURL, which is required.
+func (c *Client) FetchAsync(ctx context.Context, req FetchRequest) (*AsyncResponse, error) {
+	if req.CallbackURL == "" {
+		return nil, fmt.Errorf("callback url is required for async fetch")
+	}
+
+	var resp AsyncResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/fetch", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreateJob queues a fetch on the server's job queue and returns immediately.
+func (c *Client) CreateJob(ctx context.Context, req FetchRequest) (*JobResponse, error) {
+	var resp JobResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/jobs", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetJob returns the current status of a job, including its result once completed.
+func (c *Client) GetJob(ctx context.Context, id string) (*JobResponse, error) {
+	var resp JobResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/jobs/"+url.PathEscape(id), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CancelJob cancels a queued or running job.
+func (c *Client) CancelJob(ctx context.Context, id string) (*JobResponse, error) {
+	var resp JobResponse
+	if err := c.do(ctx, http.MethodDelete, "/v1/jobs/"+url.PathEscape(id), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Crawl queues a crawl of a site on the server's job queue and returns immediately.
+func (c *Client) Crawl(ctx context.Context, req CrawlRequest) (*JobResponse, error) {
+	var resp JobResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/crawl", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Preload queues fetching pages into the server's cache on its job queue and returns
+// immediately.
+func (c *Client) Preload(ctx context.Context, req PreloadRequest) (*JobResponse, error) {
+	var resp JobResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/preload", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// JobOutline returns the site outline of a completed crawl job.
+func (c *Client) JobOutline(ctx context.Context, id string) (*SiteOutline, error) {
+	var resp SiteOutline
+	if err := c.do(ctx, http.MethodGet, "/v1/jobs/"+url.PathEscape(id)+"/outline", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// FetchPaged iterates over a document page by page, following suggested_next_offset
+// until the server reports no more content. MaxTokens defaults to 4000 if unset.
+// Iteration stops after the first error.
+func (c *Client) FetchPaged(ctx context.Context, req FetchRequest) iter.Seq2[*FetchResponse, error] {
+	if req.MaxTokens == 0 {
+		req.MaxTokens = 4000
+	}
+
+	return func(yield func(*FetchResponse, error) bool) {
+		for {
+			resp, err := c.Fetch(ctx, req)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if !yield(resp, nil) {
+				return
+			}
+
+			if resp.Pagination == nil || !resp.Pagination.HasMore || resp.Pagination.SuggestedNextOffset <= req.Offset {
+				return
+			}
+			req.Offset = resp.Pagination.SuggestedNextOffset
+		}
+	}
+}
+
+// ExportCache returns a snapshot archive of the selected cache entries. Like every admin
+// endpoint, it requires a Client created with the server's admin key.
+func (c *Client) ExportCache(ctx context.Context, req CacheExportRequest) ([]byte, error) {
+	var archive []byte
+	if err := c.query(ctx, "/v1/admin/cache/export", req, &archive); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// ImportCache loads a snapshot archive from ExportCache into the server's cache. A positive
+// ttl sets how long imported entries stay fresh; otherwise each keeps its exported TTL. It
+// requires the admin key.
+func (c *Client) ImportCache(ctx context.Context, archive []byte, ttl time.Duration) (*CacheImportResponse, error) {
+	path := "/v1/admin/cache/import"
+	if ttl > 0 {
+		path += "?" + url.Values{"ttl": {ttl.String()}}.Encode()
+	}
+
+	var resp CacheImportResponse
+	if err := c.do(ctx, http.MethodPost, path, archive, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CacheKeys lists up to limit cached URLs of domain. A limit of 0 uses the server default.
+// It and the other cache administration methods require the admin key.
+func (c *Client) CacheKeys(ctx context.Context, domain string, limit int) (*CacheKeysResponse, error) {
+	query := url.Values{"domain": {domain}}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var resp CacheKeysResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/admin/cache/keys?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CacheEntry describes the cached entry of pageURL.
+func (c *Client) CacheEntry(ctx context.Context, pageURL string) (*CacheEntry, error) {
+	var resp CacheEntry
+	if err := c.do(ctx, http.MethodGet, "/v1/admin/cache/entry?"+url.Values{"url": {pageURL}}.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PurgeCache removes pageURL and its history from the cache.
+func (c *Client) PurgeCache(ctx context.Context, pageURL string) (*CachePurgeResponse, error) {
+	var resp CachePurgeResponse
+	if err := c.do(ctx, http.MethodDelete, "/v1/admin/cache/entry?"+url.Values{"url": {pageURL}}.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PurgeCacheDomain removes every cached URL of domain from the cache.
+func (c *Client) PurgeCacheDomain(ctx context.Context, domain string) (*CachePurgeResponse, error) {
+	var resp CachePurgeResponse
+	if err := c.do(ctx, http.MethodDelete, "/v1/admin/cache/domain?"+url.Values{"domain": {domain}}.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CacheUsage reports the size and compression of the cache.
+func (c *Client) CacheUsage(ctx context.Context) (*CacheUsage, error) {
+	var resp CacheUsage
+	if err := c.do(ctx, http.MethodGet, "/v1/admin/cache/stats", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ProvenanceKey returns the public key the server signs fetch responses with.
+func (c *Client) ProvenanceKey(ctx context.Context) (*ProvenanceKey, error) {
+	var resp ProvenanceKey
+	if err := c.do(ctx, http.MethodGet, "/v1/provenance/key", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ErrorCodeInfo documents an error code returned by the server.
+type ErrorCodeInfo struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	// Retryable reports whether the same request may succeed if retried later.
+	Retryable bool `json:"retryable"`
+}
+
+// ErrorCodes returns the error codes the server may return.
+func (c *Client) ErrorCodes(ctx context.Context) ([]ErrorCodeInfo, error) {
+	var resp struct {
+		Errors []ErrorCodeInfo `json:"errors"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/errors", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Errors, nil
+}
+
+// Stats returns the server's limiter state.
+func (c *Client) Stats(ctx context.Context) (*StatsResponse, error) {
+	var resp StatsResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/stats", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Map discovers the pages of a site from its sitemap, or from the page's links if it has none.
+func (c *Client) Map(ctx context.Context, req MapRequest) (*MapResponse, error) {
+	var resp MapResponse
+	if err := c.query(ctx, "/v1/map", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Diff fetches pageURL fresh, replacing its cached version, and reports what changed since
+// the cached version was fetched.
+func (c *Client) Diff(ctx context.Context, pageURL string) (*DiffResponse, error) {
+	var resp DiffResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/diff?"+url.Values{"url": {pageURL}}.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// History lists the cached versions of pageURL. Sites keep previous versions when their
+// cache sets max_versions.
+func (c *Client) History(ctx context.Context, pageURL string) (*HistoryResponse, error) {
+	var resp HistoryResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/history?"+url.Values{"url": {pageURL}}.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Validate checks whether urls may be fetched, without fetching them.
+func (c *Client) Validate(ctx context.Context, urls []string) (*ValidateResponse, error) {
+	var resp ValidateResponse
+	if err := c.query(ctx, "/v1/validate", map[string][]string{"urls": urls}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Probe checks whether pageURL responds within timeout without fetching its body. A zero
+// timeout uses the server's default.
+func (c *Client) Probe(ctx context.Context, pageURL string, timeout time.Duration) (*ProbeResponse, error) {
+	query := url.Values{"url": {pageURL}}
+	if timeout > 0 {
+		query.Set("timeout", timeout.String())
+	}
+
+	var resp ProbeResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/probe?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Head requests the headers of up to 100 URLs without downloading their bodies, to check that
+// they exist and read their type and size.
+func (c *Client) Head(ctx context.Context, urls []string) (*HeadResponse, error) {
+	var resp HeadResponse
+	if err := c.query(ctx, "/v1/head", map[string][]string{"urls": urls}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Linkcheck fetches a page and verifies each of its links, classifying them as ok,
+// redirected, broken, or skipped.
+func (c *Client) Linkcheck(ctx context.Context, req LinkcheckRequest) (*LinkcheckResponse, error) {
+	var resp LinkcheckResponse
+	if err := c.query(ctx, "/v1/linkcheck", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Watch registers a URL to be refetched on a schedule. Requires the server's job queue.
+func (c *Client) Watch(ctx context.Context, req WatchRequest) (*WatchResponse, error) {
+	var resp WatchResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/watch", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetWatch returns a watch and the stored versions of its page.
+func (c *Client) GetWatch(ctx context.Context, id string) (*WatchResponse, error) {
+	var resp WatchResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/watch/"+url.PathEscape(id), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteWatch stops watching a page and discards its versions.
+func (c *Client) DeleteWatch(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/v1/watch/"+url.PathEscape(id), nil, nil)
+}
+
+// CreateSession starts a browsing session. Pass its ID as FetchRequest.SessionID to fetch
+// within it. Requires the server's job queue.
+func (c *Client) CreateSession(ctx context.Context, req SessionRequest) (*SessionResponse, error) {
+	var resp SessionResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/sessions", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetSession returns a session and the cookies it holds.
+func (c *Client) GetSession(ctx context.Context, id string) (*SessionResponse, error) {
+	var resp SessionResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/sessions/"+url.PathEscape(id), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteSession ends a session and discards its cookies.
+func (c *Client) DeleteSession(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/v1/sessions/"+url.PathEscape(id), nil, nil)
+}
+
+// Sitemaps returns the sitemaps declared in a site's robots.txt. With expand, same-host
+// sitemaps are fetched to report their type and URL count, and sitemap indexes are followed.
+func (c *Client) Sitemaps(ctx context.Context, siteURL string, expand bool) (*SitemapsResponse, error) {
+	query := url.Values{"url": {siteURL}}
+	if expand {
+		query.Set("expand", "true")
+	}
+
+	var resp SitemapsResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/sitemaps?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GenerateSitemap crawls a site and returns the pages of its generated sitemap.
+func (c *Client) GenerateSitemap(ctx context.Context, req SitemapGenerateRequest) (*SitemapGenerateResponse, error) {
+	req.Format = "json"
+
+	var resp SitemapGenerateResponse
+	if err := c.query(ctx, "/v1/sitemap/generate", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GenerateSitemapXML crawls a site and returns its generated sitemap.xml document.
+func (c *Client) GenerateSitemapXML(ctx context.Context, req SitemapGenerateRequest) ([]byte, error) {
+	req.Format = "xml"
+
+	var data []byte
+	if err := c.query(ctx, "/v1/sitemap/generate", req, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// SemanticSearch returns the chunks of a URL's content most similar in meaning to the query.
+func (c *Client) SemanticSearch(ctx context.Context, req SemanticSearchRequest) (*SemanticSearchResponse, error) {
+	var resp SemanticSearchResponse
+	if err := c.query(ctx, "/v1/search/semantic", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Queue returns the fetches currently waiting on rate limits or in flight, grouped by domain.
+func (c *Client) Queue(ctx context.Context) (*QueueResponse, error) {
+	var resp QueueResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/queue", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Favicon returns a page's favicon. A positive size scales it down to fit within size pixels.
+func (c *Client) Favicon(ctx context.Context, pageURL string, size int) (*Image, error) {
+	return c.image(ctx, "/v1/favicon", pageURL, size)
+}
+
+// PreviewImage returns a page's og:image or twitter:image. A positive size scales it down to
+// fit within size pixels.
+func (c *Client) PreviewImage(ctx context.Context, pageURL string, size int) (*Image, error) {
+	return c.image(ctx, "/v1/preview-image", pageURL, size)
+}
+
+// image requests one of the image endpoints for pageURL.
+func (c *Client) image(ctx context.Context, path, pageURL string, size int) (*Image, error) {
+	query := url.Values{"url": {pageURL}}
+	if size > 0 {
+		query.Set("size", strconv.Itoa(size))
+	}
+
+	var img Image
+	if err := c.do(ctx, http.MethodGet, path+"?"+query.Encode(), nil, &img); err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+// Download fetches a binary asset and returns its bytes unparsed.
+func (c *Client) Download(ctx context.Context, req DownloadRequest) (*Download, error) {
+	req.Store = false
+
+	var download Download
+	if err := c.query(ctx, "/v1/download", req, &download); err != nil {
+		return nil, err
+	}
+	return &download, nil
+}
+
+// StoreDownload fetches a binary asset into the server's object store and returns a reference
+// to the stored object.
+func (c *Client) StoreDownload(ctx context.Context, req DownloadRequest) (*DownloadResponse, error) {
+	req.Store = true
+
+	var resp DownloadResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/download", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Health checks that the server is reachable and healthy.
+func (c *Client) Health(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/health", nil, nil)
+}
+
+// do performs a JSON request and decodes the response into out. Only idempotent methods are
+// retried.
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	return c.send(ctx, method, path, in, out, isIdempotent(method))
+}
+
+// query POSTs a request that only reads, such as a fetch, and decodes the response into out.
+// It is retried like a GET, since repeating it changes nothing on the server.
+func (c *Client) query(ctx context.Context, path string, in, out any) error {
+	return c.send(ctx, http.MethodPost, path, in, out, true)
+}
+
+// send performs a JSON request, retrying transient failures when retry is set, and decodes the
+// response into out.
+func (c *Client) send(ctx context.Context, method, path string, in, out any, retry bool) error {
+	var body []byte
+	contentType := "application/json"
+	if raw, ok := in.([]byte); ok {
+		body = raw
+		contentType = "application/octet-stream"
+	} else if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = encoded
+	}
+
+	delay := c.retryDelay
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			delay *= 2
+		}
+
+		retryable, err := c.doOnce(ctx, method, path, body, contentType, out)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		if !retry || !retryable || ctx.Err() != nil {
+			return err
+		}
+	}
+
+	return lastErr
+}
+
+// doOnce performs a single request, reporting whether a failure is worth retrying.
+func (c *Client) doOnce(ctx context.Context, method, path string, body []byte, contentType string, out any) (bool, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for key, values := range c.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return true, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		if json.Unmarshal(data, apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		apiErr.StatusCode = resp.StatusCode
+		return isRetryableStatus(resp.StatusCode), apiErr
+	}
+
+	if out == nil {
+		return false, nil
+	}
+
+	if raw, ok := out.(*[]byte); ok {
+		*raw = data
+		return false, nil
+	}
+
+	if img, ok := out.(*Image); ok {
+		img.Data = data
+		img.ContentType = resp.Header.Get("Content-Type")
+		img.URL = resp.Header.Get("X-Image-URL")
+		return false, nil
+	}
+
+	if download, ok := out.(*Download); ok {
+		download.Data = data
+		download.ContentType = resp.Header.Get("Content-Type")
+		download.URL = resp.Header.Get("X-Download-URL")
+		download.SHA256 = resp.Trailer.Get("X-Content-SHA256")
+		return false, nil
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return false, nil
+}
+
+// isIdempotent reports whether repeating a request with method has the same effect as sending
+// it once.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isRetryableStatus returns true for status codes that indicate a transient failure.
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,408 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClientFetch verifies a fetch request is sent with auth and decoded.
+func TestClientFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/fetch", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		var req FetchRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "https://example.com", req.URL)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"metadata":{"url":"https://example.com","status_code":200,"content_type":"text/html","estimated_tokens":3},"content":"Hello"}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithAPIKey("secret"))
+
+	resp, err := c.Fetch(context.Background(), FetchRequest{URL: "https://example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.Metadata.StatusCode)
+	assert.Equal(t, "Hello", resp.Content)
+}
+
+// TestClientWithHeader verifies custom headers are sent with every request.
+func TestClientWithHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "us-east", r.Header.Get("X-Websurfer-Delegated"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"metadata":{"url":"https://example.com","status_code":200,"region":"eu-west"},"content":"Hello"}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithHeader("X-Websurfer-Delegated", "us-east"))
+
+	resp, err := c.Fetch(context.Background(), FetchRequest{URL: "https://example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "eu-west", resp.Metadata.Region)
+}
+
+// TestClientFetchPaged verifies the iterator follows suggested offsets until has_more is false.
+func TestClientFetchPaged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req FetchRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		resp := FetchResponse{
+			Content: "page",
+			Pagination: &Pagination{
+				Offset:      req.Offset,
+				Limit:       req.MaxTokens,
+				TotalTokens: 30,
+				HasMore:     req.Offset+10 < 30,
+			},
+		}
+		if resp.Pagination.HasMore {
+			resp.Pagination.SuggestedNextOffset = req.Offset + 10
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+
+	var offsets []int
+	for page, err := range c.FetchPaged(context.Background(), FetchRequest{URL: "https://example.com", MaxTokens: 10}) {
+		require.NoError(t, err)
+		offsets = append(offsets, page.Pagination.Offset)
+	}
+
+	assert.Equal(t, []int{0, 10, 20}, offsets)
+}
+
+// TestClientRetriesTransientErrors verifies 503 responses are retried.
+func TestClientRetriesTransientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"unavailable","status_code":503}`))
+			return
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithMaxRetries(3), WithRetryDelay(time.Millisecond))
+
+	require.NoError(t, c.Health(context.Background()))
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+// TestClientDoesNotRetryNonIdempotent verifies requests that create server-side state are not
+// retried, while read-only POSTs such as fetches are.
+func TestClientDoesNotRetryNonIdempotent(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":"unavailable","status_code":503}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithMaxRetries(2), WithRetryDelay(time.Millisecond))
+	ctx := context.Background()
+
+	_, err := c.Crawl(ctx, CrawlRequest{URL: "https://example.com"})
+	require.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load(), "crawls should not be retried")
+
+	calls.Store(0)
+	_, err = c.Fetch(ctx, FetchRequest{URL: "https://example.com", CallbackURL: "https://example.com/hook"})
+	require.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load(), "async fetches should not be retried")
+
+	calls.Store(0)
+	_, err = c.Fetch(ctx, FetchRequest{URL: "https://example.com"})
+	require.Error(t, err)
+	assert.Equal(t, int32(3), calls.Load(), "fetches should be retried")
+}
+
+// TestClientAPIError verifies non-retryable errors are returned as APIError without retrying.
+func TestClientAPIError(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"url cannot be empty","status_code":400,"error_code":"INVALID_REQUEST"}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithRetryDelay(time.Millisecond))
+
+	_, err := c.Fetch(context.Background(), FetchRequest{})
+	require.Error(t, err)
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal(t, "url cannot be empty", apiErr.Message)
+	assert.Equal(t, "INVALID_REQUEST", apiErr.ErrorCode)
+	assert.Equal(t, int32(1), calls.Load(), "4xx errors should not be retried")
+}
+
+// TestClientFetchAsync verifies async submissions send the callback URL and decode the job ID.
+func TestClientFetchAsync(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req FetchRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "https://hooks.example.com/done", req.CallbackURL)
+
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"job_id":"abc123","status":"accepted"}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+
+	resp, err := c.FetchAsync(context.Background(), FetchRequest{URL: "https://example.com", CallbackURL: "https://hooks.example.com/done"})
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", resp.JobID)
+
+	_, err = c.FetchAsync(context.Background(), FetchRequest{URL: "https://example.com"})
+	assert.Error(t, err, "callback url should be required")
+}
+
+// TestClientJobs verifies job create, get, and cancel requests use the jobs endpoints.
+func TestClientJobs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/jobs":
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"id":"job1","status":"queued"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/jobs/job1":
+			w.Write([]byte(`{"id":"job1","status":"completed","attempts":1,"result":{"metadata":{"url":"https://example.com","status_code":200,"content_type":"text/html","estimated_tokens":1},"content":"Hi"}}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/jobs/job1":
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error":"job already finished","status_code":409}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	ctx := context.Background()
+
+	created, err := c.CreateJob(ctx, FetchRequest{URL: "https://example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "queued", created.Status)
+
+	job, err := c.GetJob(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", job.Status)
+	require.NotNil(t, job.Result)
+	assert.Equal(t, "Hi", job.Result.Content)
+
+	_, err = c.CancelJob(ctx, created.ID)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+}
+
+// TestClientValidate verifies URLs are posted to the validate endpoint.
+func TestClientValidate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/validate", r.URL.Path)
+		var req struct {
+			URLs []string `json:"urls"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, []string{"https://example.com", "http://10.0.0.1"}, req.URLs)
+
+		w.Write([]byte(`{"results":[{"url":"https://example.com","verdict":"valid"},{"url":"http://10.0.0.1","verdict":"ssrf_blocked","reason":"blocked"}],"valid":1}`))
+	}))
+	defer server.Close()
+
+	resp, err := New(server.URL).Validate(context.Background(), []string{"https://example.com", "http://10.0.0.1"})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 2)
+	assert.Equal(t, "ssrf_blocked", resp.Results[1].Verdict)
+	assert.Equal(t, 1, resp.Valid)
+}
+
+// TestClientHistory verifies history requests pass the page URL as a query parameter.
+func TestClientHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/history", r.URL.Path)
+		assert.Equal(t, "https://example.com/pricing", r.URL.Query().Get("url"))
+		w.Write([]byte(`{"url":"https://example.com/pricing","versions":[{"version":2,"status_code":200},{"version":1,"status_code":200}]}`))
+	}))
+	defer server.Close()
+
+	resp, err := New(server.URL).History(context.Background(), "https://example.com/pricing")
+	require.NoError(t, err)
+	require.Len(t, resp.Versions, 2)
+	assert.Equal(t, 2, resp.Versions[0].Version)
+}
+
+// TestClientGenerateSitemap verifies sitemap generation requests the JSON or XML format.
+func TestClientGenerateSitemap(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/sitemap/generate", r.URL.Path)
+		var req SitemapGenerateRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "https://example.com", req.URL)
+		assert.Equal(t, 50, req.MaxPages)
+
+		if req.Format == "xml" {
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte(`<urlset></urlset>`))
+			return
+		}
+		w.Write([]byte(`{"url":"https://example.com","total":1,"urls":[{"url":"https://example.com/","last_modified":"2025-01-01T00:00:00Z"}]}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	req := SitemapGenerateRequest{CrawlRequest: CrawlRequest{URL: "https://example.com", MaxPages: 50}}
+
+	resp, err := c.GenerateSitemap(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, resp.URLs, 1)
+	assert.Equal(t, "2025-01-01T00:00:00Z", resp.URLs[0].LastModified)
+
+	data, err := c.GenerateSitemapXML(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "<urlset></urlset>", string(data))
+}
+
+// TestClientDownload verifies downloads return the raw bytes or a stored object reference.
+func TestClientDownload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/download", r.URL.Path)
+		var req DownloadRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, []string{"application/pdf"}, req.Accept)
+
+		if req.Store {
+			w.Write([]byte(`{"url":"https://example.com/a.pdf","content_type":"application/pdf","size":8,"sha256":"abc","object":{"bucket":"assets","key":"abc.pdf","location":"s3://assets/abc.pdf"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("X-Download-URL", "https://example.com/a.pdf")
+		w.Header().Set("Trailer", "X-Content-SHA256")
+		w.Write([]byte("%PDF-1.7"))
+		w.Header().Set("X-Content-SHA256", "abc")
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	req := DownloadRequest{URL: "https://example.com/a.pdf", Accept: []string{"application/pdf"}}
+
+	download, err := c.Download(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("%PDF-1.7"), download.Data)
+	assert.Equal(t, "application/pdf", download.ContentType)
+	assert.Equal(t, "https://example.com/a.pdf", download.URL)
+	assert.Equal(t, "abc", download.SHA256)
+
+	stored, err := c.StoreDownload(context.Background(), req)
+	require.NoError(t, err)
+	require.NotNil(t, stored.Object)
+	assert.Equal(t, "s3://assets/abc.pdf", stored.Object.Location)
+}
+
+// TestClientWatch verifies watch create, get, and delete requests use the watch endpoints.
+func TestClientWatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/watch":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"w1","url":"https://example.com","interval":"1h0m0s","threshold":0.2}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/watch/w1":
+			w.Write([]byte(`{"id":"w1","url":"https://example.com","versions":[{"hash":"b","change":0.5},{"hash":"a"}]}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/watch/w1":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	ctx := context.Background()
+
+	created, err := c.Watch(ctx, WatchRequest{URL: "https://example.com", Threshold: 0.2})
+	require.NoError(t, err)
+	assert.Equal(t, "w1", created.ID)
+	assert.Equal(t, 0.2, created.Threshold)
+
+	watch, err := c.GetWatch(ctx, created.ID)
+	require.NoError(t, err)
+	require.Len(t, watch.Versions, 2)
+	assert.Equal(t, 0.5, watch.Versions[0].Change)
+
+	require.NoError(t, c.DeleteWatch(ctx, created.ID))
+}
+
+// TestClientSession verifies session create, get, and delete requests use the session endpoints.
+func TestClientSession(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/sessions":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"s1","max_fetches":20,"fetches":0,"idle_timeout":"30m0s"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/sessions/s1":
+			w.Write([]byte(`{"id":"s1","max_fetches":20,"fetches":2,"referer":"https://example.com/login","cookies":[{"name":"sid","domain":"example.com","path":"/"}]}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/sessions/s1":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	ctx := context.Background()
+
+	created, err := c.CreateSession(ctx, SessionRequest{MaxFetches: 20})
+	require.NoError(t, err)
+	assert.Equal(t, "s1", created.ID)
+
+	sess, err := c.GetSession(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sess.Fetches)
+	require.Len(t, sess.Cookies, 1)
+	assert.Equal(t, "sid", sess.Cookies[0].Name)
+
+	require.NoError(t, c.DeleteSession(ctx, created.ID))
+}
+
+// TestClientFavicon verifies image endpoints send the page URL and size and return raw bytes.
+func TestClientFavicon(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/favicon", r.URL.Path)
+		assert.Equal(t, "https://example.com/a?b=c", r.URL.Query().Get("url"))
+		assert.Equal(t, "32", r.URL.Query().Get("size"))
+
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("X-Image-URL", "https://example.com/favicon.png")
+		w.Write([]byte("\x89PNG"))
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+
+	img, err := c.Favicon(context.Background(), "https://example.com/a?b=c", 32)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("\x89PNG"), img.Data)
+	assert.Equal(t, "image/png", img.ContentType)
+	assert.Equal(t, "https://example.com/favicon.png", img.URL)
+}
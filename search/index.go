@@ -0,0 +1,125 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Index is an inverted index of a document's passages by the words in them. Searching through
+// an index skips splitting the document and only scores the passages that can match, so
+// repeated searches of a large document are cheap. Indexes are serialized with Marshal to be
+// cached alongside the document.
+type Index struct {
+	// Size is the length of the indexed document, to detect an index used with another one.
+	Size int `json:"size"`
+	// Passages holds the start and end offsets of each passage.
+	Passages [][2]int `json:"passages"`
+	// Words maps each lowercase word to the passages containing it, in order.
+	Words map[string][]int `json:"words"`
+}
+
+// NewIndex indexes the passages of content.
+func NewIndex(content []byte) *Index {
+	passages := splitPassages(string(content))
+	idx := &Index{
+		Size:     len(content),
+		Passages: make([][2]int, len(passages)),
+		Words:    make(map[string][]int),
+	}
+	for i, p := range passages {
+		idx.Passages[i] = [2]int{p.start, p.start + len(p.text)}
+		for _, word := range wordRegex.FindAllString(p.text, -1) {
+			word = strings.ToLower(word)
+			postings := idx.Words[word]
+			if len(postings) == 0 || postings[len(postings)-1] != i {
+				idx.Words[word] = append(postings, i)
+			}
+		}
+	}
+	return idx
+}
+
+// UnmarshalIndex decodes an index serialized with Marshal.
+func UnmarshalIndex(data []byte) (*Index, error) {
+	var idx Index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("invalid search index: %w", err)
+	}
+	return &idx, nil
+}
+
+// Marshal serializes the index.
+func (idx *Index) Marshal() ([]byte, error) {
+	return json.Marshal(idx)
+}
+
+// Search finds the passages of content that best match the query, like Search, scoring only
+// the passages the index shows may match. content must be the indexed document; if its size
+// differs, it is searched without the index.
+func (idx *Index) Search(content []byte, opts Options) ([]Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if len(content) != idx.Size {
+		return Search(content, opts)
+	}
+
+	candidates, all := idx.candidates(opts)
+	if all {
+		candidates = make([]int, len(idx.Passages))
+		for i := range candidates {
+			candidates[i] = i
+		}
+	}
+
+	text := string(content)
+	passages := make([]passage, 0, len(candidates))
+	for _, i := range candidates {
+		start, end := idx.Passages[i][0], idx.Passages[i][1]
+		passages = append(passages, passage{text: text[start:end], start: start})
+	}
+	return rank(passages, opts), nil
+}
+
+// candidates returns the passages that may match the query, in order, or all when the index
+// cannot narrow them down.
+func (idx *Index) candidates(opts Options) ([]int, bool) {
+	terms := queryTerms(opts.Query)
+	var matches func(term, word string) bool
+	switch opts.Mode {
+	case ModeRegex:
+		return nil, true
+	case ModeFuzzy:
+		matches = func(term, word string) bool {
+			limit := fuzzyLimit(term, opts.MaxDistance)
+			return levenshtein(term, word, limit) <= limit
+		}
+	default:
+		// A plain term made only of word characters can only occur within a word, so the
+		// passages with a word containing it are the only ones it can match.
+		for _, term := range terms {
+			if wordRegex.FindString(term) != term {
+				return nil, true
+			}
+		}
+		matches = func(term, word string) bool { return strings.Contains(word, term) }
+	}
+
+	seen := make(map[int]bool)
+	var candidates []int
+	for word, postings := range idx.Words {
+		if !slices.ContainsFunc(terms, func(term string) bool { return matches(term, word) }) {
+			continue
+		}
+		for _, i := range postings {
+			if !seen[i] {
+				seen[i] = true
+				candidates = append(candidates, i)
+			}
+		}
+	}
+	slices.Sort(candidates)
+	return candidates, false
+}
@@ -0,0 +1,61 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIndexSearchMatchesSearch verifies searching through an index, including one restored
+// from its serialized form, returns the same results as searching the document.
+func TestIndexSearchMatchesSearch(t *testing.T) {
+	content := []byte(testDocument)
+
+	data, err := NewIndex(content).Marshal()
+	require.NoError(t, err)
+	idx, err := UnmarshalIndex(data)
+	require.NoError(t, err)
+
+	for _, opts := range []Options{
+		{Query: "retry-after header"},
+		{Query: "cached memcached"},
+		{Query: "RETR"},
+		{Query: "are", MaxResults: 2},
+		{Query: "nothing matches this"},
+		{Query: `(?i)retr(y|ies|ied)`, Mode: ModeRegex},
+		{Query: "exponental", Mode: ModeFuzzy},
+		{Query: "kashink", Mode: ModeFuzzy, MaxDistance: 3},
+	} {
+		t.Run(opts.Query, func(t *testing.T) {
+			want, err := Search(content, opts)
+			require.NoError(t, err)
+			got, err := idx.Search(content, opts)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+// TestIndexCandidates verifies word queries only score the passages containing them.
+func TestIndexCandidates(t *testing.T) {
+	idx := NewIndex([]byte(testDocument))
+
+	candidates, all := idx.candidates(Options{Query: "redis"})
+	assert.False(t, all)
+	assert.Equal(t, []int{5}, candidates)
+
+	_, all = idx.candidates(Options{Query: "retry-after"})
+	assert.True(t, all, "terms with punctuation cannot be looked up by word")
+}
+
+// TestIndexSearchOtherContent verifies an index used with different content falls back to a
+// direct search.
+func TestIndexSearchOtherContent(t *testing.T) {
+	idx := NewIndex([]byte(testDocument))
+
+	results, err := idx.Search([]byte("A short note about redis."), Options{Query: "redis"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "A short note about redis.", results[0].Text)
+}
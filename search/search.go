@@ -0,0 +1,347 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// ModePlain matches query terms case-insensitively, ranking passages by term coverage.
+	ModePlain = "plain"
+	// ModeRegex matches a regular expression, ranking passages by match count.
+	ModeRegex = "regex"
+	// ModeFuzzy matches query terms within an edit distance, ranking passages by similarity.
+	ModeFuzzy = "fuzzy"
+)
+
+const (
+	// defaultMaxResults is how many results are returned when MaxResults is unset.
+	defaultMaxResults = 10
+	// maxSnippetChars caps the passage text returned with each result.
+	maxSnippetChars = 500
+	// phraseBonus is added to a plain passage's coverage score when it contains the whole query.
+	phraseBonus = 0.2
+)
+
+var (
+	// passageBreakRegex splits documents into passages at blank lines.
+	passageBreakRegex = regexp.MustCompile(`\n[ \t]*\n`)
+	// wordRegex matches words for fuzzy comparison.
+	wordRegex = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+)
+
+// Options controls a search.
+type Options struct {
+	Query string
+	// Mode is ModePlain (default), ModeRegex, or ModeFuzzy.
+	Mode       string
+	MaxResults int
+	// MaxDistance is the largest edit distance a fuzzy term may match at. Defaults to
+	// a quarter of the term's length, at least 1.
+	MaxDistance int
+}
+
+// Validate checks that the options describe a valid search.
+func (o Options) Validate() error {
+	if strings.TrimSpace(o.Query) == "" {
+		return fmt.Errorf("query is required")
+	}
+
+	switch o.Mode {
+	case "", ModePlain, ModeFuzzy:
+	case ModeRegex:
+		if _, err := regexp.Compile(o.Query); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	default:
+		return fmt.Errorf("mode must be one of %q, %q, %q", ModePlain, ModeRegex, ModeFuzzy)
+	}
+
+	if o.MaxResults < 0 {
+		return fmt.Errorf("max_results must be non-negative")
+	}
+
+	if o.MaxDistance < 0 {
+		return fmt.Errorf("max_distance must be non-negative")
+	}
+
+	return nil
+}
+
+// Result is a passage that matched the query.
+type Result struct {
+	Text      string  `json:"text"`
+	CharStart int     `json:"char_start"`
+	CharEnd   int     `json:"char_end"`
+	Score     float64 `json:"score"`
+	Matches   []Match `json:"matches"`
+}
+
+// Match is a matched span within the document.
+type Match struct {
+	Text      string `json:"text"`
+	CharStart int    `json:"char_start"`
+	CharEnd   int    `json:"char_end"`
+}
+
+// passage is a block of the document between blank lines.
+type passage struct {
+	text  string
+	start int
+}
+
+// Search finds the passages of content that best match the query, highest score first.
+// Offsets are byte offsets into content.
+func Search(content []byte, opts Options) ([]Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return rank(splitPassages(string(content)), opts), nil
+}
+
+// rank scores passages against the query and returns the best matches, highest score first.
+// Passages of equal score keep their order.
+func rank(passages []passage, opts Options) []Result {
+	var scorer func(p passage) (float64, []Match)
+	switch opts.Mode {
+	case ModeRegex:
+		re := regexp.MustCompile(opts.Query)
+		scorer = func(p passage) (float64, []Match) { return scoreRegex(p, re) }
+	case ModeFuzzy:
+		terms := queryTerms(opts.Query)
+		scorer = func(p passage) (float64, []Match) { return scoreFuzzy(p, terms, opts.MaxDistance) }
+	default:
+		terms := queryTerms(opts.Query)
+		phrase := strings.ToLower(strings.TrimSpace(opts.Query))
+		scorer = func(p passage) (float64, []Match) { return scorePlain(p, terms, phrase) }
+	}
+
+	var results []Result
+	for _, p := range passages {
+		score, matches := scorer(p)
+		if score <= 0 {
+			continue
+		}
+		results = append(results, Result{
+			Text:      snippet(p, matches[0].CharStart),
+			CharStart: p.start,
+			CharEnd:   p.start + len(p.text),
+			Score:     score,
+			Matches:   matches,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	maxResults := opts.MaxResults
+	if maxResults == 0 {
+		maxResults = defaultMaxResults
+	}
+	if len(results) > maxResults {
+		results = results[:maxResults]
+	}
+
+	return results
+}
+
+// splitPassages splits content into non-empty passages with their offsets.
+func splitPassages(content string) []passage {
+	var passages []passage
+
+	start := 0
+	for _, loc := range append(passageBreakRegex.FindAllStringIndex(content, -1), []int{len(content), len(content)}) {
+		text := content[start:loc[0]]
+		trimmedLeft := strings.TrimLeft(text, " \t\n\r")
+		offset := start + len(text) - len(trimmedLeft)
+		trimmed := strings.TrimRight(trimmedLeft, " \t\n\r")
+		if trimmed != "" {
+			passages = append(passages, passage{text: trimmed, start: offset})
+		}
+		start = loc[1]
+	}
+
+	return passages
+}
+
+// queryTerms splits a query into distinct lowercase terms.
+func queryTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// scorePlain scores a passage by the fraction of query terms it contains, with a bonus
+// when it contains the whole query as a phrase.
+func scorePlain(p passage, terms []string, phrase string) (float64, []Match) {
+	lower := strings.ToLower(p.text)
+
+	var matches []Match
+	matched := 0
+	for _, term := range terms {
+		found := false
+		for i := 0; ; {
+			idx := strings.Index(lower[i:], term)
+			if idx == -1 {
+				break
+			}
+			start := i + idx
+			matches = append(matches, newMatch(p, start, start+len(term)))
+			found = true
+			i = start + len(term)
+		}
+		if found {
+			matched++
+		}
+	}
+
+	if matched == 0 {
+		return 0, nil
+	}
+
+	score := (1 - phraseBonus) * float64(matched) / float64(len(terms))
+	if strings.Contains(lower, phrase) {
+		score += phraseBonus
+	}
+
+	sortMatches(matches)
+	return score, matches
+}
+
+// scoreRegex scores a passage by its number of matches, approaching 1 as matches increase.
+func scoreRegex(p passage, re *regexp.Regexp) (float64, []Match) {
+	locs := re.FindAllStringIndex(p.text, -1)
+
+	var matches []Match
+	for _, loc := range locs {
+		if loc[0] == loc[1] {
+			continue
+		}
+		matches = append(matches, newMatch(p, loc[0], loc[1]))
+	}
+
+	if len(matches) == 0 {
+		return 0, nil
+	}
+
+	n := float64(len(matches))
+	return n / (n + 1), matches
+}
+
+// scoreFuzzy scores a passage by how closely its words match each query term. A term's
+// similarity is 1 minus its best edit distance over the term length, and the score is the
+// mean similarity across terms.
+func scoreFuzzy(p passage, terms []string, maxDistance int) (float64, []Match) {
+	words := wordRegex.FindAllStringIndex(p.text, -1)
+	lowerWords := make([]string, len(words))
+	for i, loc := range words {
+		lowerWords[i] = strings.ToLower(p.text[loc[0]:loc[1]])
+	}
+
+	var (
+		matches []Match
+		total   float64
+	)
+	for _, term := range terms {
+		limit := fuzzyLimit(term, maxDistance)
+
+		best := -1
+		var bestWords []int
+		for i, word := range lowerWords {
+			d := levenshtein(term, word, limit)
+			if d > limit {
+				continue
+			}
+			switch {
+			case best == -1 || d < best:
+				best = d
+				bestWords = []int{i}
+			case d == best:
+				bestWords = append(bestWords, i)
+			}
+		}
+
+		if best == -1 {
+			continue
+		}
+
+		total += 1 - float64(best)/float64(max(len([]rune(term)), 1))
+		for _, i := range bestWords {
+			matches = append(matches, newMatch(p, words[i][0], words[i][1]))
+		}
+	}
+
+	if len(matches) == 0 {
+		return 0, nil
+	}
+
+	sortMatches(matches)
+	return total / float64(len(terms)), matches
+}
+
+// fuzzyLimit returns the largest edit distance term may match at: maxDistance, or a quarter
+// of the term's length, at least 1, when unset.
+func fuzzyLimit(term string, maxDistance int) int {
+	if maxDistance > 0 {
+		return maxDistance
+	}
+	return max(1, len([]rune(term))/4)
+}
+
+// newMatch creates a match from offsets within a passage.
+func newMatch(p passage, start, end int) Match {
+	return Match{
+		Text:      p.text[start:end],
+		CharStart: p.start + start,
+		CharEnd:   p.start + end,
+	}
+}
+
+// sortMatches orders matches by position.
+func sortMatches(matches []Match) {
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].CharStart < matches[j].CharStart
+	})
+}
+
+// snippet returns the passage text, trimmed to a window around the first match if it is long.
+func snippet(p passage, firstMatch int) string {
+	if len(p.text) <= maxSnippetChars {
+		return p.text
+	}
+
+	rel := firstMatch - p.start
+	start := max(0, rel-maxSnippetChars/4)
+	end := min(len(p.text), start+maxSnippetChars)
+	start = max(0, end-maxSnippetChars)
+
+	for start > 0 && !isRuneStart(p.text[start]) {
+		start--
+	}
+	for end < len(p.text) && !isRuneStart(p.text[end]) {
+		end++
+	}
+
+	text := p.text[start:end]
+	if start > 0 {
+		text = "…" + text
+	}
+	if end < len(p.text) {
+		text += "…"
+	}
+	return text
+}
+
+// isRuneStart reports whether b begins a UTF-8 encoded rune.
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,141 @@
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDocument = `# Rate Limiting
+
+Requests are rate limited per domain.
+
+## Retries
+
+Failed requests are retried with exponential backoff.
+Retries respect the Retry-After header.
+
+## Caching
+
+Responses are cached in Redis.`
+
+// TestValidate verifies invalid search options are rejected.
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr string
+	}{
+		{"empty query", Options{Query: "  "}, "query is required"},
+		{"unknown mode", Options{Query: "x", Mode: "semantic"}, "mode must be one of"},
+		{"bad regex", Options{Query: "(", Mode: ModeRegex}, "invalid regex"},
+		{"negative max results", Options{Query: "x", MaxResults: -1}, "max_results"},
+		{"valid fuzzy", Options{Query: "x", Mode: ModeFuzzy}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestSearchPlain verifies plain search ranks passages containing the whole phrase first.
+func TestSearchPlain(t *testing.T) {
+	results, err := Search([]byte(testDocument), Options{Query: "retry-after header"})
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+
+	assert.Contains(t, results[0].Text, "Retry-After header")
+	assert.InDelta(t, 1.0, results[0].Score, 0.001)
+	for _, m := range results[0].Matches {
+		assert.Equal(t, m.Text, testDocument[m.CharStart:m.CharEnd])
+	}
+}
+
+// TestSearchPlainPartialCoverage verifies passages matching only some terms score lower.
+func TestSearchPlainPartialCoverage(t *testing.T) {
+	results, err := Search([]byte(testDocument), Options{Query: "cached memcached"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	assert.Contains(t, results[0].Text, "cached in Redis")
+	assert.InDelta(t, 0.4, results[0].Score, 0.001)
+}
+
+// TestSearchRegex verifies regex search returns match offsets and ranks by match count.
+func TestSearchRegex(t *testing.T) {
+	results, err := Search([]byte(testDocument), Options{Query: `(?i)retr(y|ies|ied)`, Mode: ModeRegex})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	assert.Contains(t, results[0].Text, "exponential backoff")
+	assert.Len(t, results[0].Matches, 3)
+	assert.Greater(t, results[0].Score, results[1].Score)
+	for _, m := range results[0].Matches {
+		assert.Equal(t, m.Text, testDocument[m.CharStart:m.CharEnd])
+	}
+}
+
+// TestSearchFuzzy verifies fuzzy search tolerates typos and scores closer matches higher.
+func TestSearchFuzzy(t *testing.T) {
+	results, err := Search([]byte(testDocument), Options{Query: "exponental", Mode: ModeFuzzy})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	assert.Equal(t, "exponential", results[0].Matches[0].Text)
+	assert.InDelta(t, 0.9, results[0].Score, 0.001)
+
+	exact, err := Search([]byte(testDocument), Options{Query: "exponential", Mode: ModeFuzzy})
+	require.NoError(t, err)
+	require.Len(t, exact, 1)
+	assert.Greater(t, exact[0].Score, results[0].Score)
+}
+
+// TestSearchFuzzyMaxDistance verifies matches beyond the maximum edit distance are excluded.
+func TestSearchFuzzyMaxDistance(t *testing.T) {
+	results, err := Search([]byte(testDocument), Options{Query: "cashing", Mode: ModeFuzzy})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	results, err = Search([]byte(testDocument), Options{Query: "kashink", Mode: ModeFuzzy, MaxDistance: 1})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+// TestSearchMaxResults verifies results are capped at MaxResults.
+func TestSearchMaxResults(t *testing.T) {
+	results, err := Search([]byte(testDocument), Options{Query: "are", MaxResults: 2})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+}
+
+// TestSearchSnippet verifies long passages are trimmed around the first match.
+func TestSearchSnippet(t *testing.T) {
+	doc := strings.Repeat("filler ", 200) + "needle " + strings.Repeat("filler ", 200)
+
+	results, err := Search([]byte(doc), Options{Query: "needle"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	assert.Contains(t, results[0].Text, "needle")
+	assert.True(t, strings.HasPrefix(results[0].Text, "…"))
+	assert.True(t, strings.HasSuffix(results[0].Text, "…"))
+	assert.Equal(t, 0, results[0].CharStart)
+	assert.Equal(t, len(strings.TrimSpace(doc)), results[0].CharEnd)
+}
+
+// TestLevenshtein verifies edit distances and the early exit past the limit.
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("kitten", "kitten", 3))
+	assert.Equal(t, 3, levenshtein("kitten", "sitting", 3))
+	assert.Equal(t, 2, levenshtein("kitten", "sitting", 1))
+	assert.Equal(t, 1, levenshtein("café", "cafe", 2))
+}
@@ -0,0 +1,132 @@
+package semantic
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/joeychilson/websurfer/outline"
+)
+
+const (
+	// defaultChunkChars is the target chunk size when none is configured.
+	defaultChunkChars = 1000
+)
+
+var (
+	// blockBreakRegex splits documents into blocks at blank lines.
+	blockBreakRegex = regexp.MustCompile(`\n[ \t]*\n`)
+)
+
+// Chunk is a contiguous span of a document that is embedded as a unit.
+type Chunk struct {
+	Text      string `json:"text"`
+	CharStart int    `json:"char_start"`
+	CharEnd   int    `json:"char_end"`
+	Section   string `json:"section,omitempty"`
+	Anchor    string `json:"anchor,omitempty"`
+	// SectionPath is the section's breadcrumb path, in the format of outline heading paths.
+	SectionPath string `json:"section_path,omitempty"`
+}
+
+// block is a run of text between blank lines.
+type block struct {
+	start, end int
+}
+
+// ChunkMarkdown splits content into chunks of up to maxChars, breaking at blank lines and
+// starting a new chunk at each heading so chunks stay within one section. Blocks longer than
+// maxChars are split at whitespace. Offsets are byte offsets into content.
+func ChunkMarkdown(content []byte, contentType string, maxChars int) []Chunk {
+	if maxChars <= 0 {
+		maxChars = defaultChunkChars
+	}
+
+	text := string(content)
+
+	var headings []outline.Heading
+	if strings.Contains(contentType, "markdown") {
+		headings = outline.ExtractBytes(content, contentType).Headings
+	}
+
+	var (
+		chunks  []Chunk
+		current *block
+	)
+	flush := func() {
+		if current == nil {
+			return
+		}
+		chunks = append(chunks, newChunk(text, current.start, current.end, headings))
+		current = nil
+	}
+
+	for _, b := range splitBlocks(text, maxChars) {
+		isHeading := strings.HasPrefix(text[b.start:b.end], "#")
+		if current != nil && (isHeading || b.end-current.start > maxChars) {
+			flush()
+		}
+		if current == nil {
+			current = &block{start: b.start, end: b.end}
+		} else {
+			current.end = b.end
+		}
+	}
+	flush()
+
+	return chunks
+}
+
+// newChunk creates a chunk for text[start:end], labeled with the heading it falls under.
+func newChunk(text string, start, end int, headings []outline.Heading) Chunk {
+	chunk := Chunk{
+		Text:      text[start:end],
+		CharStart: start,
+		CharEnd:   end,
+	}
+	for _, h := range headings {
+		if h.CharStart > start {
+			break
+		}
+		chunk.Section = h.Text
+		chunk.Anchor = h.Anchor
+		chunk.SectionPath = h.Path
+	}
+	return chunk
+}
+
+// splitBlocks returns the trimmed, non-empty blocks of text, splitting any longer than maxChars.
+func splitBlocks(text string, maxChars int) []block {
+	var blocks []block
+
+	start := 0
+	for _, loc := range append(blockBreakRegex.FindAllStringIndex(text, -1), []int{len(text), len(text)}) {
+		segment := text[start:loc[0]]
+		trimmedLeft := strings.TrimLeft(segment, " \t\n\r")
+		bStart := start + len(segment) - len(trimmedLeft)
+		bEnd := bStart + len(strings.TrimRight(trimmedLeft, " \t\n\r"))
+		start = loc[1]
+
+		for bEnd-bStart > maxChars {
+			cut := splitPoint(text, bStart, bStart+maxChars)
+			blocks = append(blocks, block{start: bStart, end: cut})
+			bStart = cut + len(text[cut:bEnd]) - len(strings.TrimLeft(text[cut:bEnd], " \t\n\r"))
+		}
+		if bEnd > bStart {
+			blocks = append(blocks, block{start: bStart, end: bEnd})
+		}
+	}
+
+	return blocks
+}
+
+// splitPoint returns where to cut text before limit: the last whitespace after start if there
+// is one, otherwise the last rune boundary.
+func splitPoint(text string, start, limit int) int {
+	if i := strings.LastIndexAny(text[start:limit], " \t\n"); i > 0 {
+		return start + i
+	}
+	for limit > start && text[limit]&0xC0 == 0x80 {
+		limit--
+	}
+	return limit
+}
@@ -0,0 +1,204 @@
+package semantic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// ProviderOpenAI embeds with the OpenAI embeddings API.
+	ProviderOpenAI = "openai"
+	// ProviderHTTP embeds with a self-hosted endpoint, such as a local ONNX model served by
+	// text-embeddings-inference.
+	ProviderHTTP = "http"
+)
+
+const (
+	// defaultOpenAIURL is the base URL of the OpenAI API.
+	defaultOpenAIURL = "https://api.openai.com/v1"
+	// defaultOpenAIModel is the OpenAI embedding model used when none is configured.
+	defaultOpenAIModel = "text-embedding-3-small"
+	// embedTimeout bounds a single embedding request.
+	embedTimeout = 30 * time.Second
+	// maxErrorBody caps how much of an error response is included in errors.
+	maxErrorBody = 512
+)
+
+// Embedder computes vector embeddings for text.
+type Embedder interface {
+	// Name identifies the embedder and model, so vectors from different models are never mixed.
+	Name() string
+	// Embed returns one vector per text, in the same order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EmbedderConfig selects and configures an embedder.
+type EmbedderConfig struct {
+	// Provider is ProviderOpenAI or ProviderHTTP.
+	Provider string
+	// URL is the endpoint for ProviderHTTP, or overrides the OpenAI base URL.
+	URL    string
+	Model  string
+	APIKey string
+}
+
+// NewEmbedder creates an embedder from the configuration.
+func NewEmbedder(cfg EmbedderConfig) (Embedder, error) {
+	switch cfg.Provider {
+	case ProviderOpenAI:
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("openai embedder requires an api key")
+		}
+		return NewOpenAIEmbedder(cfg.APIKey, cfg.Model, cfg.URL), nil
+	case ProviderHTTP:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("http embedder requires a url")
+		}
+		return NewHTTPEmbedder(cfg.URL, cfg.Model, cfg.APIKey), nil
+	default:
+		return nil, fmt.Errorf("unknown embedder provider %q (supported: %s, %s)", cfg.Provider, ProviderOpenAI, ProviderHTTP)
+	}
+}
+
+// OpenAIEmbedder embeds text with the OpenAI embeddings API.
+type OpenAIEmbedder struct {
+	client  *http.Client
+	baseURL string
+	model   string
+	apiKey  string
+}
+
+// NewOpenAIEmbedder creates an OpenAI embedder. Empty model and baseURL use the defaults.
+func NewOpenAIEmbedder(apiKey, model, baseURL string) *OpenAIEmbedder {
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	if baseURL == "" {
+		baseURL = defaultOpenAIURL
+	}
+	return &OpenAIEmbedder{
+		client:  &http.Client{Timeout: embedTimeout},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		model:   model,
+		apiKey:  apiKey,
+	}
+}
+
+// Name returns the provider and model.
+func (e *OpenAIEmbedder) Name() string {
+	return ProviderOpenAI + ":" + e.model
+}
+
+// openAIEmbeddingResponse is the response body of the OpenAI embeddings API.
+type openAIEmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed returns embeddings for texts.
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body := map[string]any{"model": e.model, "input": texts}
+
+	var resp openAIEmbeddingResponse
+	if err := postJSON(ctx, e.client, e.baseURL+"/embeddings", e.apiKey, body, &resp); err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("missing embedding for input %d", i)
+		}
+	}
+
+	return vectors, nil
+}
+
+// HTTPEmbedder embeds text with a self-hosted endpoint that accepts {"inputs": [...]} and
+// returns a JSON array of vectors, as text-embeddings-inference's /embed does.
+type HTTPEmbedder struct {
+	client *http.Client
+	url    string
+	model  string
+	apiKey string
+}
+
+// NewHTTPEmbedder creates an HTTP embedder. The model is only used to name the embedder.
+func NewHTTPEmbedder(url, model, apiKey string) *HTTPEmbedder {
+	return &HTTPEmbedder{
+		client: &http.Client{Timeout: embedTimeout},
+		url:    url,
+		model:  model,
+		apiKey: apiKey,
+	}
+}
+
+// Name returns the provider and model, or the endpoint URL if no model is set.
+func (e *HTTPEmbedder) Name() string {
+	if e.model != "" {
+		return ProviderHTTP + ":" + e.model
+	}
+	return ProviderHTTP + ":" + e.url
+}
+
+// Embed returns embeddings for texts.
+func (e *HTTPEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var vectors [][]float32
+	if err := postJSON(ctx, e.client, e.url, e.apiKey, map[string]any{"inputs": texts}, &vectors); err != nil {
+		return nil, err
+	}
+
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(vectors))
+	}
+
+	return vectors, nil
+}
+
+// postJSON posts body as JSON and decodes the response into out.
+func postJSON(ctx context.Context, client *http.Client, url, apiKey string, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode embedding request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("embedding request failed: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode embedding response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package semantic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewEmbedder verifies provider selection and required settings.
+func TestNewEmbedder(t *testing.T) {
+	_, err := NewEmbedder(EmbedderConfig{Provider: ProviderOpenAI})
+	assert.ErrorContains(t, err, "api key")
+
+	_, err = NewEmbedder(EmbedderConfig{Provider: ProviderHTTP})
+	assert.ErrorContains(t, err, "url")
+
+	_, err = NewEmbedder(EmbedderConfig{Provider: "onnx"})
+	assert.ErrorContains(t, err, "unknown embedder provider")
+
+	e, err := NewEmbedder(EmbedderConfig{Provider: ProviderOpenAI, APIKey: "key"})
+	require.NoError(t, err)
+	assert.Equal(t, "openai:text-embedding-3-small", e.Name())
+}
+
+// TestOpenAIEmbedder verifies requests to the embeddings API and reordering by index.
+func TestOpenAIEmbedder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+
+		var body struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "custom-model", body.Model)
+		assert.Equal(t, []string{"a", "b"}, body.Input)
+
+		w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer srv.Close()
+
+	e := NewOpenAIEmbedder("key", "custom-model", srv.URL+"/v1/")
+	vectors, err := e.Embed(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1, 0}, {0, 1}}, vectors)
+}
+
+// TestHTTPEmbedder verifies requests to a self-hosted endpoint.
+func TestHTTPEmbedder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Inputs []string `json:"inputs"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, []string{"hello"}, body.Inputs)
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		w.Write([]byte(`[[0.5,0.5]]`))
+	}))
+	defer srv.Close()
+
+	e := NewHTTPEmbedder(srv.URL, "bge-small", "")
+	assert.Equal(t, "http:bge-small", e.Name())
+
+	vectors, err := e.Embed(context.Background(), []string{"hello"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.5, 0.5}}, vectors)
+}
+
+// TestHTTPEmbedderError verifies error responses and mismatched counts are reported.
+func TestHTTPEmbedderError(t *testing.T) {
+	status := http.StatusInternalServerError
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`[[1]]`))
+	}))
+	defer srv.Close()
+
+	e := NewHTTPEmbedder(srv.URL, "", "")
+
+	_, err := e.Embed(context.Background(), []string{"a"})
+	assert.ErrorContains(t, err, "HTTP 500")
+
+	status = http.StatusOK
+	_, err = e.Embed(context.Background(), []string{"a", "b"})
+	assert.ErrorContains(t, err, "expected 2 embeddings, got 1")
+}
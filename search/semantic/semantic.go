@@ -0,0 +1,158 @@
+package semantic
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	// defaultTopK is how many results are returned when TopK is unset.
+	defaultTopK = 5
+	// embedBatchSize is the most texts sent in one embedding request.
+	embedBatchSize = 64
+)
+
+// Config holds semantic searcher configuration.
+type Config struct {
+	// Store caches chunk vectors. Documents are re-embedded on every search if nil.
+	Store *Store
+	// ChunkChars is the target chunk size in bytes (default: 1000).
+	ChunkChars int
+	Logger     *slog.Logger
+}
+
+// Searcher ranks document chunks by embedding similarity to a query.
+type Searcher struct {
+	embedder   Embedder
+	store      *Store
+	chunkChars int
+	logger     *slog.Logger
+}
+
+// NewSearcher creates a semantic searcher using the given embedder.
+func NewSearcher(embedder Embedder, config Config) *Searcher {
+	if config.ChunkChars <= 0 {
+		config.ChunkChars = defaultChunkChars
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+	return &Searcher{
+		embedder:   embedder,
+		store:      config.Store,
+		chunkChars: config.ChunkChars,
+		logger:     config.Logger,
+	}
+}
+
+// Result is a chunk ranked by similarity to the query.
+type Result struct {
+	Chunk
+	Score float64 `json:"score"`
+}
+
+// Search returns the topK chunks of content most similar to query, highest score first.
+// A topK of 0 uses the default.
+func (s *Searcher) Search(ctx context.Context, content []byte, contentType, query string, topK int) ([]Result, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	if topK <= 0 {
+		topK = defaultTopK
+	}
+
+	doc, err := s.document(ctx, content, contentType)
+	if err != nil {
+		return nil, err
+	}
+	if len(doc.Chunks) == 0 {
+		return []Result{}, nil
+	}
+
+	queryVectors, err := s.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(queryVectors) != 1 {
+		return nil, fmt.Errorf("expected 1 query embedding, got %d", len(queryVectors))
+	}
+
+	results := make([]Result, len(doc.Chunks))
+	for i, chunk := range doc.Chunks {
+		results[i] = Result{Chunk: chunk, Score: cosine(queryVectors[0], doc.Vectors[i])}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > topK {
+		results = results[:topK]
+	}
+
+	return results, nil
+}
+
+// document returns the chunked and embedded content, from the store if it has been seen before.
+func (s *Searcher) document(ctx context.Context, content []byte, contentType string) (*Document, error) {
+	key := documentKey(s.embedder.Name(), s.chunkChars, content)
+
+	if s.store != nil {
+		doc, err := s.store.Get(ctx, key)
+		if err != nil {
+			s.logger.Warn("failed to load document vectors", "error", err)
+		} else if doc != nil {
+			return doc, nil
+		}
+	}
+
+	chunks := ChunkMarkdown(content, contentType, s.chunkChars)
+	doc := &Document{Chunks: chunks, Vectors: make([][]float32, 0, len(chunks))}
+
+	for start := 0; start < len(chunks); start += embedBatchSize {
+		end := min(start+embedBatchSize, len(chunks))
+		texts := make([]string, 0, end-start)
+		for _, chunk := range chunks[start:end] {
+			texts = append(texts, chunk.Text)
+		}
+
+		vectors, err := s.embedder.Embed(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed document: %w", err)
+		}
+		if len(vectors) != len(texts) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(vectors))
+		}
+		doc.Vectors = append(doc.Vectors, vectors...)
+	}
+
+	if s.store != nil {
+		if err := s.store.Set(ctx, key, doc); err != nil {
+			s.logger.Warn("failed to store document vectors", "error", err)
+		}
+	}
+
+	return doc, nil
+}
+
+// cosine returns the cosine similarity of a and b, or 0 if they differ in length or either is zero.
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,124 @@
+package semantic
+
+import (
+	"context"
+	"hash/fnv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDocument = `# Guide
+
+Install the package with go get.
+
+## Rate Limiting
+
+Requests to each domain are throttled so servers are not overloaded.
+
+## Caching
+
+Responses are stored in Redis and served until they expire.`
+
+// wordEmbedder embeds text as a bag of hashed words, so texts sharing words are similar.
+type wordEmbedder struct {
+	calls atomic.Int32
+}
+
+func (e *wordEmbedder) Name() string { return "test:words" }
+
+func (e *wordEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	e.calls.Add(1)
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, 64)
+		for _, word := range strings.Fields(strings.ToLower(text)) {
+			h := fnv.New32a()
+			h.Write([]byte(strings.Trim(word, ".,#")))
+			v[h.Sum32()%64]++
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+// TestChunkMarkdownSections verifies chunks break at headings and carry their section.
+func TestChunkMarkdownSections(t *testing.T) {
+	chunks := ChunkMarkdown([]byte(testDocument), "text/markdown", 1000)
+	require.Len(t, chunks, 3)
+
+	assert.Equal(t, "Rate Limiting", chunks[1].Section)
+	assert.Equal(t, "rate-limiting", chunks[1].Anchor)
+	assert.Equal(t, "Guide > Rate Limiting", chunks[1].SectionPath)
+	for _, chunk := range chunks {
+		assert.Equal(t, chunk.Text, testDocument[chunk.CharStart:chunk.CharEnd])
+		assert.True(t, strings.HasPrefix(chunk.Text, "#"))
+	}
+}
+
+// TestChunkMarkdownSplitsLongBlocks verifies blocks longer than the chunk size are split at whitespace.
+func TestChunkMarkdownSplitsLongBlocks(t *testing.T) {
+	content := strings.Repeat("word ", 100)
+	chunks := ChunkMarkdown([]byte(content), "text/plain", 50)
+
+	require.Greater(t, len(chunks), 1)
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk.Text), 50)
+		assert.Equal(t, chunk.Text, content[chunk.CharStart:chunk.CharEnd])
+		assert.False(t, strings.HasPrefix(chunk.Text, " "))
+	}
+}
+
+// TestSearcherRanksBySimilarity verifies the chunk most similar to the query ranks first.
+func TestSearcherRanksBySimilarity(t *testing.T) {
+	searcher := NewSearcher(&wordEmbedder{}, Config{})
+
+	results, err := searcher.Search(context.Background(), []byte(testDocument), "text/markdown", "stored in redis until expire", 2)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "Caching", results[0].Section)
+	assert.Greater(t, results[0].Score, results[1].Score)
+}
+
+// TestSearcherEmptyQuery verifies an empty query is rejected.
+func TestSearcherEmptyQuery(t *testing.T) {
+	searcher := NewSearcher(&wordEmbedder{}, Config{})
+
+	_, err := searcher.Search(context.Background(), []byte(testDocument), "text/markdown", " ", 0)
+	assert.ErrorContains(t, err, "query is required")
+}
+
+// TestSearcherReusesStoredVectors verifies documents are embedded once and then served from Redis.
+func TestSearcherReusesStoredVectors(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := NewStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), StoreConfig{})
+	embedder := &wordEmbedder{}
+	searcher := NewSearcher(embedder, Config{Store: store})
+
+	ctx := context.Background()
+	_, err := searcher.Search(ctx, []byte(testDocument), "text/markdown", "caching", 0)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), embedder.calls.Load(), "document and query should be embedded")
+
+	results, err := searcher.Search(ctx, []byte(testDocument), "text/markdown", "rate limiting", 0)
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, int32(3), embedder.calls.Load(), "only the query should be embedded again")
+
+	assert.Len(t, mr.Keys(), 1)
+	assert.True(t, strings.HasPrefix(mr.Keys()[0], "websurfer:semantic:doc:test:words:"))
+}
+
+// TestCosine verifies cosine similarity edge cases.
+func TestCosine(t *testing.T) {
+	assert.InDelta(t, 1.0, cosine([]float32{1, 2}, []float32{2, 4}), 0.0001)
+	assert.InDelta(t, 0.0, cosine([]float32{1, 0}, []float32{0, 1}), 0.0001)
+	assert.Zero(t, cosine([]float32{0, 0}, []float32{1, 1}))
+	assert.Zero(t, cosine([]float32{1}, []float32{1, 1}))
+}
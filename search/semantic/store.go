@@ -0,0 +1,89 @@
+package semantic
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// StoreConfig holds vector store configuration.
+type StoreConfig struct {
+	Prefix string
+	// TTL is how long a document's vectors are kept after they are computed.
+	TTL time.Duration
+}
+
+// DefaultStoreConfig returns a store config with sensible defaults.
+func DefaultStoreConfig() StoreConfig {
+	return StoreConfig{
+		Prefix: "websurfer:semantic:",
+		TTL:    24 * time.Hour,
+	}
+}
+
+// Store keeps document chunk embeddings in Redis, keyed by embedder and content hash, so a
+// document is only embedded once per model until its content changes.
+type Store struct {
+	client *redis.Client
+	config StoreConfig
+}
+
+// NewStore creates a vector store with the provided client and configuration.
+func NewStore(client *redis.Client, config StoreConfig) *Store {
+	defaults := DefaultStoreConfig()
+	if config.Prefix == "" {
+		config.Prefix = defaults.Prefix
+	}
+	if config.TTL <= 0 {
+		config.TTL = defaults.TTL
+	}
+	return &Store{client: client, config: config}
+}
+
+// Document is a chunked document and the embedding of each chunk.
+type Document struct {
+	Chunks  []Chunk     `json:"chunks"`
+	Vectors [][]float32 `json:"vectors"`
+}
+
+// Get returns the stored document for the key, or nil if there is none.
+func (s *Store) Get(ctx context.Context, key string) (*Document, error) {
+	data, err := s.client.Get(ctx, s.config.Prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vectors: %w", err)
+	}
+
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode vectors: %w", err)
+	}
+	return &doc, nil
+}
+
+// Set stores the document under the key.
+func (s *Store) Set(ctx context.Context, key string, doc *Document) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode vectors: %w", err)
+	}
+
+	if err := s.client.Set(ctx, s.config.Prefix+key, data, s.config.TTL).Err(); err != nil {
+		return fmt.Errorf("failed to set vectors: %w", err)
+	}
+	return nil
+}
+
+// documentKey identifies a document's vectors by embedder, chunk size, and content.
+func documentKey(embedder string, chunkChars int, content []byte) string {
+	sum := sha256.Sum256(content)
+	return fmt.Sprintf("doc:%s:%d:%s", embedder, chunkChars, hex.EncodeToString(sum[:]))
+}
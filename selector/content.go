@@ -0,0 +1,111 @@
+package selector
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// xpathCallRegex matches an expression starting with a function call, such as "count(//li)".
+var xpathCallRegex = regexp.MustCompile(`^[A-Za-z][-A-Za-z]*\s*\(`)
+
+// Matcher finds elements in a document. Selector and XPath are matchers.
+type Matcher interface {
+	// MatchAll returns the matching elements below root, in document order.
+	MatchAll(root *html.Node) []*html.Node
+}
+
+// IsXPath reports whether an expression is XPath rather than CSS: XPath expressions start with
+// /, ./, ../, (, or a function call, which no selector can.
+func IsXPath(expr string) bool {
+	expr = strings.TrimSpace(expr)
+	for _, prefix := range []string{"/", "./", "../", "("} {
+		if strings.HasPrefix(expr, prefix) {
+			return true
+		}
+	}
+	return xpathCallRegex.MatchString(expr)
+}
+
+// CompileMatcher compiles a selector list, or an XPath expression that selects elements.
+func CompileMatcher(expr string) (Matcher, error) {
+	if !IsXPath(expr) {
+		return Compile(strings.TrimSpace(expr))
+	}
+
+	x, err := CompileXPath(strings.TrimSpace(expr))
+	if err != nil {
+		return nil, err
+	}
+	if !x.Nodes() {
+		return nil, fmt.Errorf("invalid xpath %q: expression must select elements", expr)
+	}
+	return x, nil
+}
+
+// MainContent narrows an HTML document to the elements m matches, keeping the document's head
+// so its title and metadata survive. Matches nested in an earlier match are kept with it. The
+// document is returned unchanged, with false, if nothing matches or the match is the whole
+// body.
+func MainContent(doc []byte, m Matcher) ([]byte, bool, error) {
+	root, err := html.Parse(bytes.NewReader(doc))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse html: %w", err)
+	}
+
+	var body *html.Node
+	var regions []*html.Node
+	for _, n := range m.MatchAll(root) {
+		if n.DataAtom == atom.Html || n.DataAtom == atom.Body {
+			return doc, false, nil
+		}
+		if !containedIn(n, regions) {
+			regions = append(regions, n)
+		}
+	}
+	var find func(*html.Node)
+	find = func(n *html.Node) {
+		for c := n.FirstChild; c != nil && body == nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.DataAtom == atom.Body {
+				body = c
+				return
+			}
+			find(c)
+		}
+	}
+	find(root)
+	if len(regions) == 0 || body == nil {
+		return doc, false, nil
+	}
+
+	for _, n := range regions {
+		n.Parent.RemoveChild(n)
+	}
+	for body.FirstChild != nil {
+		body.RemoveChild(body.FirstChild)
+	}
+	for _, n := range regions {
+		body.AppendChild(n)
+	}
+
+	var b bytes.Buffer
+	if err := html.Render(&b, root); err != nil {
+		return nil, false, fmt.Errorf("failed to render html: %w", err)
+	}
+	return b.Bytes(), true, nil
+}
+
+// containedIn reports whether n is below any of the nodes.
+func containedIn(n *html.Node, nodes []*html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if slices.Contains(nodes, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package selector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMainContent verifies documents are narrowed to the matched regions, keeping the head.
+func TestMainContent(t *testing.T) {
+	doc := []byte(`<html><head><title>Widget</title></head><body>
+<nav>Home</nav>
+<article><h1>Widget</h1><div class="body"><p>Details</p></div></article>
+<aside class="related">Related</aside>
+<footer>About</footer>
+</body></html>`)
+
+	for _, expr := range []string{"article, .related", "//article | //aside[@class='related']", "//article | //div[@class='body'] | //aside"} {
+		t.Run(expr, func(t *testing.T) {
+			m, err := CompileMatcher(expr)
+			require.NoError(t, err)
+
+			narrowed, ok, err := MainContent(doc, m)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, `<html><head><title>Widget</title></head><body><article><h1>Widget</h1><div class="body"><p>Details</p></div></article><aside class="related">Related</aside></body></html>`, string(narrowed))
+		})
+	}
+
+	for _, expr := range []string{"table", "//body", "/html"} {
+		narrowed, ok, err := MainContent(doc, mustMatcher(t, expr))
+		require.NoError(t, err)
+		assert.False(t, ok, expr)
+		assert.Equal(t, doc, narrowed, expr)
+	}
+}
+
+// TestCompileMatcher verifies expressions compile as CSS or XPath.
+func TestCompileMatcher(t *testing.T) {
+	assert.IsType(t, &Selector{}, mustMatcher(t, "main .content"))
+	assert.IsType(t, &XPath{}, mustMatcher(t, "//main"))
+	assert.IsType(t, &XPath{}, mustMatcher(t, "(//article)[1]"))
+	assert.IsType(t, &XPath{}, mustMatcher(t, "./main"))
+
+	for _, expr := range []string{"count(//p)", "//p[", "main >"} {
+		_, err := CompileMatcher(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func mustMatcher(t *testing.T, expr string) Matcher {
+	t.Helper()
+	m, err := CompileMatcher(expr)
+	require.NoError(t, err)
+	return m
+}
@@ -0,0 +1,199 @@
+package selector
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+)
+
+// maxValues is the most values extracted for a single query.
+const maxValues = 100
+
+// queryAttrRegex matches a query ending in the attribute to extract, such as "a.next @href".
+var queryAttrRegex = regexp.MustCompile(`^(.*?)\s*@([A-Za-z_:][-A-Za-z0-9_:.]*)$`)
+
+var (
+	// urlAttrs are attributes holding URLs, which are resolved against the page's URL.
+	urlAttrs = []string{"href", "src", "action", "poster", "cite", "data"}
+	// skippedElements hold no text content.
+	skippedElements = []string{"script", "style", "template", "noscript"}
+	// blockElements are separated from the text around them.
+	blockElements = []string{
+		"address", "article", "aside", "blockquote", "br", "dd", "div", "dl", "dt", "figcaption",
+		"figure", "footer", "h1", "h2", "h3", "h4", "h5", "h6", "header", "hr", "li", "main", "nav",
+		"ol", "p", "pre", "section", "table", "td", "th", "tr", "ul",
+	}
+)
+
+// Query selects elements and what to extract from them: their text, or the value of Attr.
+// Queries written in XPath set XPath instead of Selector, and extract the string-value of each
+// node they select, or the value they compute.
+type Query struct {
+	Selector *Selector
+	XPath    *XPath
+	Attr     string
+}
+
+// ParseQuery parses a query: a selector list, optionally followed by @ and the name of the
+// attribute to extract, such as "h1", ".price", or "img.hero @src", or an XPath expression,
+// such as "//h1" or "//img[@class='hero']/@src".
+func ParseQuery(s string) (*Query, error) {
+	if IsXPath(s) {
+		x, err := CompileXPath(strings.TrimSpace(s))
+		if err != nil {
+			return nil, err
+		}
+		return &Query{XPath: x}, nil
+	}
+
+	q := &Query{}
+	selector := strings.TrimSpace(s)
+	if m := queryAttrRegex.FindStringSubmatch(selector); m != nil && strings.Count(m[1], "[") == strings.Count(m[1], "]") {
+		selector, q.Attr = m[1], strings.ToLower(m[2])
+	}
+	if selector == "" {
+		return nil, fmt.Errorf("invalid selector %q: selector cannot be empty", s)
+	}
+
+	sel, err := Compile(selector)
+	if err != nil {
+		return nil, err
+	}
+	q.Selector = sel
+	return q, nil
+}
+
+// Extract evaluates each named query against an HTML document and returns the values of the
+// matching elements, in document order, under the query's name: their whitespace-collapsed
+// text, or the value of the query's attribute, with URL attributes such as href and src
+// resolved against baseURL. Elements with empty text or without the attribute are skipped, and
+// at most maxValues values are returned per query. Names whose query matches nothing map to an
+// empty list.
+func Extract(doc []byte, baseURL string, queries map[string]string) (map[string][]string, error) {
+	parsed := make(map[string]*Query, len(queries))
+	for name, query := range queries {
+		q, err := ParseQuery(query)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		parsed[name] = q
+	}
+
+	root, err := html.Parse(bytes.NewReader(doc))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse html: %w", err)
+	}
+	base, _ := url.Parse(baseURL)
+
+	results := make(map[string][]string, len(parsed))
+	for name, q := range parsed {
+		if q.XPath != nil {
+			results[name] = q.xpathValues(root, base)
+			continue
+		}
+
+		values := []string{}
+		for _, n := range q.Selector.MatchAll(root) {
+			value, ok := q.value(n, base)
+			if !ok {
+				continue
+			}
+			values = append(values, value)
+			if len(values) == maxValues {
+				break
+			}
+		}
+		results[name] = values
+	}
+	return results, nil
+}
+
+// value returns what the query extracts from the element n.
+func (q *Query) value(n *html.Node, base *url.URL) (string, bool) {
+	if q.Attr == "" {
+		text := textContent(n)
+		return text, text != ""
+	}
+
+	for _, attr := range n.Attr {
+		if attr.Namespace == "" && attr.Key == q.Attr {
+			return resolveAttr(attr, base), true
+		}
+	}
+	return "", false
+}
+
+// resolveAttr returns the trimmed value of an attribute, resolving URL attributes against base.
+func resolveAttr(attr html.Attribute, base *url.URL) string {
+	value := strings.TrimSpace(attr.Val)
+	if base != nil && slices.Contains(urlAttrs, strings.ToLower(attr.Key)) {
+		if ref, err := url.Parse(value); err == nil {
+			value = base.ResolveReference(ref).String()
+		}
+	}
+	return value
+}
+
+// xpathValues returns what an XPath query extracts from root: the whitespace-collapsed text of
+// each element or text node it selects and the value of each attribute, or the string, number,
+// or boolean it computes.
+func (q *Query) xpathValues(root *html.Node, base *url.URL) []string {
+	values := []string{}
+	result := q.XPath.evaluate(root)
+	iter, ok := result.(*xpath.NodeIterator)
+	if !ok {
+		if value := strings.TrimSpace(toString(result)); value != "" {
+			values = append(values, value)
+		}
+		return values
+	}
+
+	for _, it := range selectedItems(root, iter) {
+		value := textContent(it.n)
+		if it.attr != nil {
+			value = resolveAttr(*it.attr, base)
+		}
+		if value == "" {
+			continue
+		}
+		values = append(values, value)
+		if len(values) == maxValues {
+			break
+		}
+	}
+	return values
+}
+
+// textContent returns the whitespace-collapsed text below n, skipping scripts and styles.
+// Block elements are separated by a space, so the text of adjacent paragraphs or cells does not
+// run together.
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+		case n.Type == html.ElementNode && slices.Contains(skippedElements, n.Data):
+			return
+		}
+		block := n.Type == html.ElementNode && slices.Contains(blockElements, n.Data)
+		if block {
+			b.WriteByte(' ')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if block {
+			b.WriteByte(' ')
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
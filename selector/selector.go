@@ -0,0 +1,44 @@
+// Package selector matches CSS selectors and XPath expressions against HTML documents and
+// extracts the text or attributes of the matching elements. Selectors are matched with
+// cascadia and XPath expressions evaluated with antchfx/xpath.
+package selector
+
+import (
+	"fmt"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// Selector is a compiled selector list. An element matches if any selector in the list does.
+type Selector struct {
+	group cascadia.SelectorGroup
+}
+
+// Compile parses a selector list.
+func Compile(s string) (*Selector, error) {
+	group, err := cascadia.ParseGroup(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %w", s, err)
+	}
+	return &Selector{group: group}, nil
+}
+
+// MustCompile is like Compile but panics if the selector cannot be parsed.
+func MustCompile(s string) *Selector {
+	sel, err := Compile(s)
+	if err != nil {
+		panic(err)
+	}
+	return sel
+}
+
+// Match reports whether the element n matches the selector.
+func (s *Selector) Match(n *html.Node) bool {
+	return n != nil && s.group.Match(n)
+}
+
+// MatchAll returns the elements below root that match the selector, in document order.
+func (s *Selector) MatchAll(root *html.Node) []*html.Node {
+	return cascadia.QueryAll(root, s.group)
+}
@@ -0,0 +1,126 @@
+package selector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+const testPage = `<html><body>
+<main id="content">
+  <h1 class="title main">Widget <em>Pro</em></h1>
+  <ul class="specs">
+    <li>Steel</li>
+    <li data-unit="kg">2 kg</li>
+    <li lang="en-US">Blue</li>
+    <li>Boxed</li>
+  </ul>
+  <div class="product"><span class="product-price">$19.99</span><span class="sale">$14.99</span></div>
+  <p>First<br>line</p>
+  <a href="/next" rel="next">Next</a>
+  <a href="https://cdn.example.com/manual.pdf" type="application/PDF">Manual</a>
+  <img class="hero" src="img/hero.png" alt="Hero">
+  <p></p>
+</main>
+<footer><a href="/about">About</a></footer>
+</body></html>`
+
+// TestSelectorMatchAll verifies supported selectors match the expected elements in document
+// order.
+func TestSelectorMatchAll(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(testPage))
+	require.NoError(t, err)
+
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{"h1", []string{"Widget Pro"}},
+		{".title.main", []string{"Widget Pro"}},
+		{"#content > h1", []string{"Widget Pro"}},
+		{"main a", []string{"Next", "Manual"}},
+		{"body > a", nil},
+		{"a, h1", []string{"Widget Pro", "Next", "Manual", "About"}},
+		{"li:first-child", []string{"Steel"}},
+		{"li:last-child", []string{"Boxed"}},
+		{"li:nth-child(2n)", []string{"2 kg", "Boxed"}},
+		{"li:nth-child(odd)", []string{"Steel", "Blue"}},
+		{"li:nth-last-child(2)", []string{"Blue"}},
+		{"li:nth-child(-n+2)", []string{"Steel", "2 kg"}},
+		{"li[data-unit]", []string{"2 kg"}},
+		{"li[lang|=en]", []string{"Blue"}},
+		{`a[href^="https://"]`, []string{"Manual"}},
+		{`a[href$=".pdf"]`, []string{"Manual"}},
+		{`a[type="application/pdf" i]`, []string{"Manual"}},
+		{`a[rel~=next]`, []string{"Next"}},
+		{`a[href*=about]`, []string{"About"}},
+		{".product-price + .sale", []string{"$14.99"}},
+		{"h1 ~ p", []string{"First line", ""}},
+		{"p:empty", []string{""}},
+		{"footer *", []string{"About"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			sel, err := Compile(tt.selector)
+			require.NoError(t, err)
+
+			var got []string
+			for _, n := range sel.MatchAll(root) {
+				got = append(got, textContent(n))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestCompileInvalid verifies malformed and unsupported selectors are rejected.
+func TestCompileInvalid(t *testing.T) {
+	for _, selector := range []string{"", "h1,", "> p", "p >", "#", ".", "a[href", `a[href="x]`, "a[href=x", "li:nth-child(x)", "li:nth-child", "p!"} {
+		_, err := Compile(selector)
+		assert.Error(t, err, selector)
+	}
+}
+
+// TestExtract verifies CSS and XPath queries extract text or attributes, resolving URLs against
+// the page.
+func TestExtract(t *testing.T) {
+	values, err := Extract([]byte(testPage), "https://shop.example.com/widgets/pro", map[string]string{
+		"title":   "h1",
+		"price":   ".product-price",
+		"specs":   "ul.specs li",
+		"next":    `a[rel="next"] @href`,
+		"image":   "img.hero@src",
+		"alt":     "img.hero @alt",
+		"missing": ".reviews",
+		"links":   "//main/a/@href",
+		"count":   "count(//li)",
+		"unit":    "//li[@data-unit]/text()",
+		"none":    "//table",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{
+		"title":   {"Widget Pro"},
+		"price":   {"$19.99"},
+		"specs":   {"Steel", "2 kg", "Blue", "Boxed"},
+		"next":    {"https://shop.example.com/next"},
+		"image":   {"https://shop.example.com/widgets/img/hero.png"},
+		"alt":     {"Hero"},
+		"missing": {},
+		"links":   {"https://shop.example.com/next", "https://cdn.example.com/manual.pdf"},
+		"count":   {"4"},
+		"unit":    {"2 kg"},
+		"none":    {},
+	}, values)
+
+	_, err = Extract([]byte(testPage), "", map[string]string{"bad": "li:nth-child(x)"})
+	assert.Error(t, err)
+	_, err = Extract([]byte(testPage), "", map[string]string{"bad": "@href"})
+	assert.Error(t, err)
+	_, err = Extract([]byte(testPage), "", map[string]string{"bad": "//li["})
+	assert.Error(t, err)
+}
@@ -0,0 +1,140 @@
+package selector
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+)
+
+// XPath is a compiled XPath 1.0 expression. Along with the core function library, it supports
+// functions common in scraping configurations, such as ends-with and lower-case.
+type XPath struct {
+	expr  *xpath.Expr
+	nodes bool
+}
+
+// CompileXPath parses an XPath expression.
+func CompileXPath(s string) (x *XPath, err error) {
+	expr, err := xpath.Compile(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xpath %q: %w", s, err)
+	}
+
+	// Whether an expression selects nodes depends only on its form, so evaluating it against an
+	// empty document tells. Errors the compiler leaves to evaluation panic.
+	defer func() {
+		if r := recover(); r != nil {
+			x, err = nil, fmt.Errorf("invalid xpath %q: %v", s, r)
+		}
+	}()
+	_, nodes := expr.Evaluate(htmlquery.CreateXPathNavigator(&html.Node{Type: html.DocumentNode})).(*xpath.NodeIterator)
+	return &XPath{expr: expr, nodes: nodes}, nil
+}
+
+// MustCompileXPath is like CompileXPath but panics if the expression cannot be parsed.
+func MustCompileXPath(s string) *XPath {
+	x, err := CompileXPath(s)
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
+
+// Nodes reports whether the expression selects nodes rather than computing a value.
+func (x *XPath) Nodes() bool {
+	return x.nodes
+}
+
+// MatchAll returns the elements the expression selects from root, in document order.
+func (x *XPath) MatchAll(root *html.Node) []*html.Node {
+	iter, ok := x.evaluate(root).(*xpath.NodeIterator)
+	if !ok {
+		return nil
+	}
+	var matches []*html.Node
+	for _, it := range selectedItems(root, iter) {
+		if it.attr == nil && it.n.Type == html.ElementNode {
+			matches = append(matches, it.n)
+		}
+	}
+	return matches
+}
+
+// evaluate evaluates the expression with root as the context node. The result is a node-set
+// (*xpath.NodeIterator), string, number (float64), or boolean.
+func (x *XPath) evaluate(root *html.Node) any {
+	return x.expr.Evaluate(htmlquery.CreateXPathNavigator(root))
+}
+
+// xpathItem is a node selected by an expression: an element or text node, or the attribute
+// attr of the element n.
+type xpathItem struct {
+	n    *html.Node
+	attr *html.Attribute
+}
+
+// selectedItems returns the nodes of a node-set result in document order, which antchfx/xpath
+// doesn't keep for unions. Attributes of one element keep their order.
+func selectedItems(root *html.Node, iter *xpath.NodeIterator) []xpathItem {
+	var items []xpathItem
+	for iter.MoveNext() {
+		nav := iter.Current().(*htmlquery.NodeNavigator)
+		it := xpathItem{n: nav.Current()}
+		if nav.NodeType() == xpath.AttributeNode {
+			it.attr = &html.Attribute{Key: nav.LocalName(), Val: nav.Value()}
+		}
+		items = append(items, it)
+	}
+	if len(items) < 2 {
+		return items
+	}
+
+	top := root
+	for top.Parent != nil {
+		top = top.Parent
+	}
+	order := make(map[*html.Node]int)
+	var index func(*html.Node)
+	index = func(n *html.Node) {
+		order[n] = len(order)
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			index(c)
+		}
+	}
+	index(top)
+
+	slices.SortStableFunc(items, func(a, b xpathItem) int {
+		return cmp.Compare(order[a.n], order[b.n])
+	})
+	return items
+}
+
+// toString converts a string, number, or boolean result to a string, formatting numbers as
+// XPath does, without an exponent.
+func toString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "NaN"
+		case math.IsInf(v, 1):
+			return "Infinity"
+		case math.IsInf(v, -1):
+			return "-Infinity"
+		case v == 0:
+			return "0"
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
@@ -0,0 +1,103 @@
+package selector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+// TestXPathMatchAll verifies supported expressions select the expected elements in document
+// order.
+func TestXPathMatchAll(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(testPage))
+	require.NoError(t, err)
+
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"//h1", []string{"Widget Pro"}},
+		{"/html/body/main/h1", []string{"Widget Pro"}},
+		{"//*[@id='content']/h1", []string{"Widget Pro"}},
+		{"//main//a", []string{"Next", "Manual"}},
+		{"/html/body/a", nil},
+		{"//a | //h1", []string{"Widget Pro", "Next", "Manual", "About"}},
+		{"//li[1]", []string{"Steel"}},
+		{"//li[last()]", []string{"Boxed"}},
+		{"//li[position() mod 2 = 0]", []string{"2 kg", "Boxed"}},
+		{"//li[position() < 3]", []string{"Steel", "2 kg"}},
+		{"(//li)[3]", []string{"Blue"}},
+		{"//li[@data-unit]", []string{"2 kg"}},
+		{"//li[not(@*)]", []string{"Steel", "Boxed"}},
+		{"//li[starts-with(@lang, 'en')]", []string{"Blue"}},
+		{"//a[ends-with(@href, '.pdf')]", []string{"Manual"}},
+		{"//a[lower-case(@type) = 'application/pdf']", []string{"Manual"}},
+		{"//a[contains(., 'Man')]", []string{"Manual"}},
+		{"//li[text() = 'Blue']", []string{"Blue"}},
+		{"//h1[contains(concat(' ', normalize-space(@class), ' '), ' main ')]", []string{"Widget Pro"}},
+		{"//span[@class='product-price']/following-sibling::span", []string{"$14.99"}},
+		{"//span[@class='sale']/preceding-sibling::*[1]", []string{"$19.99"}},
+		{"//em/ancestor::*[1]", []string{"Widget Pro"}},
+		{"//em/..", []string{"Widget Pro"}},
+		{"//ul[count(li) = 4]/li[2]", []string{"2 kg"}},
+		{"//p[not(node())]", []string{""}},
+		{"//footer/descendant::*", []string{"About"}},
+		{"//img/@src", nil},
+		{"count(//li)", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			x, err := CompileXPath(tt.expr)
+			require.NoError(t, err)
+
+			var got []string
+			for _, n := range x.MatchAll(root) {
+				got = append(got, textContent(n))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestXPathEvaluate verifies expressions computing strings, numbers, and booleans.
+func TestXPathEvaluate(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(testPage))
+	require.NoError(t, err)
+
+	tests := []struct {
+		expr string
+		want any
+	}{
+		{"count(//li)", float64(4)},
+		{"string(//li[2]/@data-unit)", "kg"},
+		{"normalize-space(//h1)", "Widget Pro"},
+		{"substring-after(//span[@class='sale'], '$')", "14.99"},
+		{"number(substring(//span[@class='sale'], 2)) * 2", 29.98},
+		{"string(number(//li[@data-unit]/@data-unit))", "NaN"},
+		{"translate('ABC', 'ABC', 'ab')", "ab"},
+		{"round(2.5) + floor(-1.5) + ceiling(1.2)", float64(3)},
+		{"//li = 'Blue'", true},
+		{"//li != 'Blue'", true},
+		{"not(//table)", true},
+		{"string-length(name(//main))", float64(4)},
+		{"-3 div 2", -1.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			assert.Equal(t, tt.want, MustCompileXPath(tt.expr).evaluate(root))
+		})
+	}
+}
+
+// TestCompileXPathInvalid verifies malformed and unsupported expressions are rejected.
+func TestCompileXPathInvalid(t *testing.T) {
+	for _, expr := range []string{"", "//", "//li[", "//li[@", "//a[@href='x]", "foo::li", "unknown()", "contains('a')", "$x"} {
+		_, err := CompileXPath(expr)
+		assert.Error(t, err, expr)
+	}
+}
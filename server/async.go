@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/joeychilson/websurfer/fetcher"
+	"github.com/joeychilson/websurfer/ratelimit"
+)
+
+const (
+	// asyncJobTimeout bounds how long an async fetch may run before it is reported as failed.
+	asyncJobTimeout = 10 * time.Minute
+	// webhookSendTimeout bounds delivery of a job's callback, including retries.
+	webhookSendTimeout = 2 * time.Minute
+)
+
+// AsyncResponse is returned immediately when a fetch is requested with a callback_url.
+type AsyncResponse struct {
+	JobID  string `json:"job_id"`
+	Status string `json:"status"`
+}
+
+// WebhookPayload is POSTed to the callback_url when an async job finishes.
+type WebhookPayload struct {
+	JobID       string         `json:"job_id"`
+	Status      string         `json:"status"`
+	URL         string         `json:"url"`
+	CompletedAt string         `json:"completed_at"`
+	Result      *FetchResponse `json:"result,omitempty"`
+	// Crawl is the result of a crawl job, in place of Result.
+	Crawl *CrawlResult   `json:"crawl,omitempty"`
+	Error *ErrorResponse `json:"error,omitempty"`
+}
+
+// startAsyncFetch accepts the request, runs the fetch in the background as the request's
+// caller, and reports the result to the request's callback_url.
+func (s *Server) startAsyncFetch(w http.ResponseWriter, r *http.Request, req *FetchRequest) {
+	jobID, err := newJobID()
+	if err != nil {
+		s.logger.Error("failed to create job id", "error", err)
+		s.sendError(w, "failed to create job", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Info("async fetch accepted", "job_id", jobID, "url", req.URL)
+
+	caller := ratelimit.CallerFromContext(r.Context())
+	s.asyncJobs.Add(1)
+	go func() {
+		defer s.asyncJobs.Done()
+		s.runAsyncFetch(jobID, caller, req)
+	}()
+
+	s.sendJSON(w, AsyncResponse{JobID: jobID, Status: "accepted"}, http.StatusAccepted)
+}
+
+// runAsyncFetch performs the fetch for caller and delivers the outcome to the callback URL. A
+// panic in the fetch is delivered as a failure, since no handler is left to recover it.
+func (s *Server) runAsyncFetch(jobID string, caller ratelimit.Caller, req *FetchRequest) {
+	ctx, cancel := context.WithTimeout(ratelimit.WithCaller(s.jobsCtx, caller), asyncJobTimeout)
+	defer cancel()
+
+	resp, err := func() (resp *FetchResponse, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				s.logger.Error("async fetch panicked", "job_id", jobID, "url", req.URL, "panic", r)
+				resp, err = nil, fmt.Errorf("fetch panicked: %v", r)
+			}
+		}()
+		return s.processFetch(ctx, req)
+	}()
+	if err != nil {
+		s.logger.Error("async fetch failed", "job_id", jobID, "url", req.URL, "error", err, "failure_type", fetcher.ClassifyError(err))
+	}
+
+	s.deliverWebhook(jobID, req, resp, err)
+}
+
+// deliverWebhook POSTs a finished job's outcome to the request's callback URL.
+func (s *Server) deliverWebhook(jobID string, req *FetchRequest, resp *FetchResponse, fetchErr error) {
+	payload := WebhookPayload{
+		JobID:       jobID,
+		URL:         req.URL,
+		CompletedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if fetchErr != nil {
+		payload.Status = "failed"
+		payload.Error = buildFetchError(req.URL, fetchErr)
+	} else {
+		payload.Status = "completed"
+		payload.Result = resp
+	}
+
+	s.sendWebhook(req.CallbackURL, payload)
+}
+
+// deliverCrawlWebhook POSTs a finished crawl job's outcome to the request's callback URL.
+func (s *Server) deliverCrawlWebhook(jobID string, req *CrawlRequest, result *CrawlResult, crawlErr error) {
+	payload := WebhookPayload{
+		JobID:       jobID,
+		URL:         req.URL,
+		CompletedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if crawlErr != nil {
+		payload.Status = "failed"
+		payload.Error = buildFetchError(req.URL, crawlErr)
+	} else {
+		payload.Status = "completed"
+		payload.Crawl = result
+	}
+
+	s.sendWebhook(req.CallbackURL, payload)
+}
+
+// sendWebhook POSTs a job's webhook payload to callbackURL.
+func (s *Server) sendWebhook(callbackURL string, payload WebhookPayload) {
+	sendCtx, sendCancel := context.WithTimeout(context.Background(), webhookSendTimeout)
+	defer sendCancel()
+
+	if err := s.webhook.Send(sendCtx, callbackURL, payload); err != nil {
+		s.logger.Error("webhook delivery failed", "job_id", payload.JobID, "callback_url", callbackURL, "error", err)
+		return
+	}
+
+	s.logger.Info("webhook delivered", "job_id", payload.JobID, "url", payload.URL, "status", payload.Status)
+}
+
+// newJobID returns a random identifier for an async job.
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,276 @@
+package server
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joeychilson/websurfer/cache"
+)
+
+// defaultCacheKeysLimit is how many URLs the cache keys endpoint lists when no limit is set.
+const defaultCacheKeysLimit = 1000
+
+// CacheKeysResponse lists the cached URLs of a domain. Total counts them all, and Truncated
+// is set when URLs was cut to the requested limit.
+type CacheKeysResponse struct {
+	Domain    string   `json:"domain"`
+	URLs      []string `json:"urls"`
+	Total     int      `json:"total"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// CacheEntryResponse describes how a cached entry is stored, without its body.
+type CacheEntryResponse struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Title      string `json:"title,omitempty"`
+	// State is "fresh" within the TTL, "stale" within the stale window, and "expired" after it.
+	State     string `json:"state"`
+	StoredAt  string `json:"stored_at"`
+	ExpiresAt string `json:"expires_at"`
+	TTL       string `json:"ttl"`
+	StaleTime string `json:"stale_time"`
+	// BodyBytes is the size of the body, and StoredBytes the size of the Redis value, which
+	// excludes a deduplicated body or one in the blob store.
+	BodyBytes       int    `json:"body_bytes"`
+	StoredBytes     int    `json:"stored_bytes"`
+	Compressed      bool   `json:"compressed"`
+	BodyHash        string `json:"body_hash,omitempty"`
+	BlobKey         string `json:"blob_key,omitempty"`
+	Version         int    `json:"version,omitempty"`
+	HistoryVersions int64  `json:"history_versions"`
+}
+
+// CachePurgeResponse reports how many URLs were purged from the cache.
+type CachePurgeResponse struct {
+	Purged int `json:"purged"`
+}
+
+// CacheUsageResponse reports the size of the cache. CompressionRatio is the uncompressed size
+// of the entries over their stored size, and Dedup reports the deduplicated bodies stored
+// alongside them.
+type CacheUsageResponse struct {
+	Entries           int           `json:"entries"`
+	StoredBytes       int64         `json:"stored_bytes"`
+	UncompressedBytes int64         `json:"uncompressed_bytes"`
+	CompressedEntries int           `json:"compressed_entries"`
+	CompressionRatio  float64       `json:"compression_ratio"`
+	Domains           []DomainUsage `json:"domains"`
+	Dedup             *CacheStats   `json:"dedup,omitempty"`
+}
+
+// DomainUsage counts the cached entries of a domain.
+type DomainUsage struct {
+	Domain  string `json:"domain"`
+	Entries int    `json:"entries"`
+}
+
+// handleCacheKeys handles GET /v1/admin/cache/keys requests, listing the cached URLs of the
+// domain query parameter, up to limit.
+func (s *Server) handleCacheKeys(w http.ResponseWriter, r *http.Request) {
+	responseCache := s.client.Cache()
+	if responseCache == nil {
+		s.sendError(w, "cache is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	domain := strings.TrimSpace(r.URL.Query().Get("domain"))
+	if domain == "" {
+		s.sendError(w, "domain is required", http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultCacheKeysLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			s.sendError(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	urls, err := responseCache.ListURLs(r.Context(), domain)
+	if err != nil {
+		s.logger.Error("cache key listing failed", "domain", domain, "error", err)
+		s.sendError(w, "failed to list cache keys", http.StatusInternalServerError)
+		return
+	}
+
+	resp := CacheKeysResponse{Domain: domain, URLs: urls, Total: len(urls)}
+	if len(urls) > limit {
+		resp.URLs = urls[:limit]
+		resp.Truncated = true
+	}
+	if resp.URLs == nil {
+		resp.URLs = []string{}
+	}
+	s.sendJSON(w, resp, http.StatusOK)
+}
+
+// handleCacheEntry handles GET /v1/admin/cache/entry requests, describing the cached entry of
+// the url query parameter.
+func (s *Server) handleCacheEntry(w http.ResponseWriter, r *http.Request) {
+	responseCache := s.client.Cache()
+	if responseCache == nil {
+		s.sendError(w, "cache is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	pageURL := r.URL.Query().Get("url")
+	if pageURL == "" {
+		s.sendError(w, "url is required", http.StatusBadRequest)
+		return
+	}
+
+	info, err := responseCache.Inspect(r.Context(), s.client.CacheKey(pageURL))
+	if err != nil {
+		s.logger.Error("cache inspection failed", "url", pageURL, "error", err)
+		s.sendError(w, "failed to read cache entry", http.StatusInternalServerError)
+		return
+	}
+	if info == nil {
+		s.sendError(w, "page is not cached", http.StatusNotFound)
+		return
+	}
+
+	s.sendJSON(w, CacheEntryResponse{
+		URL:             info.URL,
+		StatusCode:      info.StatusCode,
+		Title:           info.Title,
+		State:           cacheStateName(info.State),
+		StoredAt:        info.StoredAt.UTC().Format(time.RFC3339),
+		ExpiresAt:       info.ExpiresAt.UTC().Format(time.RFC3339),
+		TTL:             info.TTL.String(),
+		StaleTime:       info.StaleTime.String(),
+		BodyBytes:       info.BodySize,
+		StoredBytes:     info.StoredSize,
+		Compressed:      info.Compressed,
+		BodyHash:        info.BodyHash,
+		BlobKey:         info.BlobKey,
+		Version:         info.Version,
+		HistoryVersions: info.HistoryVersions,
+	}, http.StatusOK)
+}
+
+// handleCachePurge handles DELETE /v1/admin/cache/entry requests, purging the cached entry and
+// history of the url query parameter.
+func (s *Server) handleCachePurge(w http.ResponseWriter, r *http.Request) {
+	responseCache := s.client.Cache()
+	if responseCache == nil {
+		s.sendError(w, "cache is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	pageURL := r.URL.Query().Get("url")
+	if pageURL == "" {
+		s.sendError(w, "url is required", http.StatusBadRequest)
+		return
+	}
+
+	key := s.client.CacheKey(pageURL)
+	info, err := responseCache.Inspect(r.Context(), key)
+	if err == nil {
+		err = responseCache.Delete(r.Context(), key)
+	}
+	if err != nil {
+		s.logger.Error("cache purge failed", "url", pageURL, "error", err)
+		s.sendError(w, "failed to purge cache entry", http.StatusInternalServerError)
+		return
+	}
+
+	resp := CachePurgeResponse{}
+	if info != nil {
+		resp.Purged = 1
+	}
+	s.logger.Info("cache entry purged", "url", key, "purged", resp.Purged)
+	s.sendJSON(w, resp, http.StatusOK)
+}
+
+// handleCachePurgeDomain handles DELETE /v1/admin/cache/domain requests, purging every cached
+// URL of the domain query parameter.
+func (s *Server) handleCachePurgeDomain(w http.ResponseWriter, r *http.Request) {
+	responseCache := s.client.Cache()
+	if responseCache == nil {
+		s.sendError(w, "cache is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	domain := strings.TrimSpace(r.URL.Query().Get("domain"))
+	if domain == "" {
+		s.sendError(w, "domain is required", http.StatusBadRequest)
+		return
+	}
+
+	purged, err := responseCache.DeleteDomain(r.Context(), domain)
+	if err != nil {
+		s.logger.Error("cache domain purge failed", "domain", domain, "purged", purged, "error", err)
+		s.sendError(w, "failed to purge domain", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Info("cache domain purged", "domain", domain, "purged", purged)
+	s.sendJSON(w, CachePurgeResponse{Purged: purged}, http.StatusOK)
+}
+
+// handleCacheUsage handles GET /v1/admin/cache/stats requests.
+func (s *Server) handleCacheUsage(w http.ResponseWriter, r *http.Request) {
+	responseCache := s.client.Cache()
+	if responseCache == nil {
+		s.sendError(w, "cache is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	usage, err := responseCache.Usage(r.Context())
+	if err != nil {
+		s.logger.Error("cache usage scan failed", "error", err)
+		s.sendError(w, "failed to compute cache stats", http.StatusInternalServerError)
+		return
+	}
+
+	resp := CacheUsageResponse{
+		Entries:           usage.Entries,
+		StoredBytes:       usage.StoredBytes,
+		UncompressedBytes: usage.UncompressedBytes,
+		CompressedEntries: usage.CompressedEntries,
+		Domains:           make([]DomainUsage, 0, len(usage.Domains)),
+	}
+	if usage.StoredBytes > 0 {
+		resp.CompressionRatio = float64(usage.UncompressedBytes) / float64(usage.StoredBytes)
+	}
+	for domain, entries := range usage.Domains {
+		resp.Domains = append(resp.Domains, DomainUsage{Domain: domain, Entries: entries})
+	}
+	slices.SortFunc(resp.Domains, func(a, b DomainUsage) int {
+		return cmp.Or(cmp.Compare(b.Entries, a.Entries), cmp.Compare(a.Domain, b.Domain))
+	})
+
+	if dedup, err := responseCache.DedupStats(r.Context()); err == nil {
+		resp.Dedup = &CacheStats{
+			DedupBodies:      dedup.Bodies,
+			DedupReferences:  dedup.References,
+			DedupStoredBytes: dedup.StoredBytes,
+			DedupSavedBytes:  dedup.SavedBytes,
+		}
+	} else {
+		s.logger.Warn("failed to compute cache dedup stats", "error", err)
+	}
+
+	s.sendJSON(w, resp, http.StatusOK)
+}
+
+// cacheStateName returns the name of a cache entry state reported by the admin API.
+func cacheStateName(state cache.State) string {
+	switch state {
+	case cache.StateFresh:
+		return "fresh"
+	case cache.StateStale:
+		return "stale"
+	default:
+		return "expired"
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joeychilson/websurfer/cache"
+)
+
+// TestCacheAdmin verifies cache keys are listed, inspected, and purged by URL and domain.
+func TestCacheAdmin(t *testing.T) {
+	ctx := context.Background()
+	s, responseCache := newCacheTestServer(t)
+	router := s.Router()
+	for _, u := range []string{"https://example.com/", "https://example.com/docs", "https://other.com/"} {
+		require.NoError(t, responseCache.Set(ctx, &cache.Entry{URL: u, StatusCode: 200, Title: "Page", Body: []byte("body of " + u), StoredAt: time.Now()}))
+	}
+
+	request := func(method, target string, out any) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, newAdminRequest(method, target, nil))
+		if out != nil && w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		}
+		return w.Code
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/v1/admin/cache/domain?domain=example.com", nil))
+	require.Equal(t, http.StatusUnauthorized, w.Code, "cache administration should require the admin key")
+
+	var keys CacheKeysResponse
+	require.Equal(t, http.StatusOK, request("GET", "/v1/admin/cache/keys?domain=example.com&limit=1", &keys))
+	assert.Equal(t, []string{"https://example.com/"}, keys.URLs)
+	assert.Equal(t, 2, keys.Total)
+	assert.True(t, keys.Truncated)
+	assert.Equal(t, http.StatusBadRequest, request("GET", "/v1/admin/cache/keys", nil))
+
+	var entry CacheEntryResponse
+	require.Equal(t, http.StatusOK, request("GET", "/v1/admin/cache/entry?url=HTTPS://Example.com/docs%3Futm_source=x", &entry))
+	assert.Equal(t, "https://example.com/docs", entry.URL)
+	assert.Equal(t, "fresh", entry.State)
+	assert.Equal(t, len("body of https://example.com/docs"), entry.BodyBytes)
+
+	var usage CacheUsageResponse
+	require.Equal(t, http.StatusOK, request("GET", "/v1/admin/cache/stats", &usage))
+	assert.Equal(t, 3, usage.Entries)
+	assert.Equal(t, []DomainUsage{{Domain: "example.com", Entries: 2}, {Domain: "other.com", Entries: 1}}, usage.Domains)
+
+	var purge CachePurgeResponse
+	require.Equal(t, http.StatusOK, request("DELETE", "/v1/admin/cache/entry?url=https://example.com/docs", &purge))
+	assert.Equal(t, 1, purge.Purged)
+	assert.Equal(t, http.StatusNotFound, request("GET", "/v1/admin/cache/entry?url=https://example.com/docs", nil))
+
+	require.Equal(t, http.StatusOK, request("DELETE", "/v1/admin/cache/domain?domain=example.com", &purge))
+	assert.Equal(t, 1, purge.Purged)
+	require.Equal(t, http.StatusOK, request("GET", "/v1/admin/cache/keys?domain=example.com", &keys))
+	assert.Empty(t, keys.URLs)
+	assert.Equal(t, 0, keys.Total)
+
+	remaining, err := responseCache.Get(ctx, "https://other.com/")
+	require.NoError(t, err)
+	assert.NotNil(t, remaining)
+}
@@ -0,0 +1,448 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/crawler"
+	"github.com/joeychilson/websurfer/hreflang"
+	"github.com/joeychilson/websurfer/jobs"
+	urlpkg "github.com/joeychilson/websurfer/url"
+)
+
+const (
+	// jobTypeFetch is the type of jobs whose payload is a fetch request, which has no type field.
+	jobTypeFetch = "fetch"
+	// jobTypeCrawl marks job payloads that hold a crawl request.
+	jobTypeCrawl = "crawl"
+	// maxCrawlDepth caps the max_depth of a crawl request.
+	maxCrawlDepth = 10
+)
+
+// CrawlRequest represents a request to crawl the same-host pages reachable from a URL.
+type CrawlRequest struct {
+	URL string `json:"url"`
+	// MaxPages caps how many pages are fetched (default: 100, max: the server's max_pages limit).
+	MaxPages int `json:"max_pages,omitempty"`
+	// MaxDepth caps how many links away from URL the crawl goes (default: 3, max: 10).
+	MaxDepth    int  `json:"max_depth,omitempty"`
+	BypassCache bool `json:"bypass_cache,omitempty"`
+	// RespectNofollow skips links marked rel="nofollow", "ugc", or "sponsored", and all links of
+	// pages whose robots directives include nofollow.
+	RespectNofollow bool `json:"respect_nofollow,omitempty"`
+	// Canonical handles pages whose rel="canonical" URL is another page on the site: "ignore"
+	// (default) crawls them as is, "skip" drops them and crawls the canonical URL instead, and
+	// "remap" reports them under their canonical URL, once.
+	Canonical string `json:"canonical,omitempty"`
+	// CallbackURL receives the crawl result, signed like async fetch callbacks, once the crawl
+	// finishes.
+	CallbackURL string `json:"callback_url,omitempty"`
+}
+
+// CrawlResult is the outcome of a crawl job.
+type CrawlResult struct {
+	URL     string       `json:"url"`
+	Pages   []CrawlPage  `json:"pages"`
+	Outline *SiteOutline `json:"outline"`
+	// LimitReached names the server safety limit that cut the crawl short, if any.
+	LimitReached string `json:"limit_reached,omitempty"`
+}
+
+// CrawlPage is a page visited during a crawl.
+type CrawlPage struct {
+	URL        string `json:"url"`
+	Depth      int    `json:"depth"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Title      string `json:"title,omitempty"`
+	Tokens     int    `json:"tokens,omitempty"`
+	Error      string `json:"error,omitempty"`
+	ErrorCode  string `json:"error_code,omitempty"`
+	// CanonicalURL is the page's declared canonical URL, and RemappedFrom the URL it was reached
+	// by when the crawl reported it under its canonical URL.
+	CanonicalURL string `json:"canonical_url,omitempty"`
+	RemappedFrom string `json:"remapped_from,omitempty"`
+	// Alternates lists the page's language variants declared with hreflang links.
+	Alternates []hreflang.Alternate `json:"alternates,omitempty"`
+}
+
+// SiteOutline is a table of contents for a crawled site, with pages arranged by URL path.
+type SiteOutline struct {
+	URL         string       `json:"url"`
+	Pages       int          `json:"pages"`
+	TotalTokens int          `json:"total_tokens"`
+	Root        *OutlineNode `json:"root"`
+}
+
+// OutlineNode is a path segment in a site outline. URL, Title, and Tokens are only set when a
+// page was crawled at that path, and TotalTokens includes every page beneath it.
+type OutlineNode struct {
+	Path        string         `json:"path"`
+	URL         string         `json:"url,omitempty"`
+	Title       string         `json:"title,omitempty"`
+	Tokens      int            `json:"tokens,omitempty"`
+	TotalTokens int            `json:"total_tokens"`
+	Children    []*OutlineNode `json:"children,omitempty"`
+}
+
+// crawlJobPayload is the job payload of a crawl job.
+type crawlJobPayload struct {
+	Type  string       `json:"type"`
+	Crawl CrawlRequest `json:"crawl"`
+}
+
+// handleCrawl handles POST /v1/crawl requests.
+func (s *Server) handleCrawl(w http.ResponseWriter, r *http.Request) {
+	if s.queue == nil {
+		s.sendError(w, "job queue is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req CrawlRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("failed to decode request", "error", err)
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.validateCrawlRequest(&req); err != nil {
+		s.logger.Error("invalid request", "error", err)
+		s.sendValidationError(w, err)
+		return
+	}
+
+	payload, err := json.Marshal(crawlJobPayload{Type: jobTypeCrawl, Crawl: req})
+	if err != nil {
+		s.sendError(w, "failed to encode job", http.StatusInternalServerError)
+		return
+	}
+
+	job, err := s.queue.Enqueue(r.Context(), payload)
+	if err != nil {
+		s.logger.Error("failed to enqueue job", "url", req.URL, "error", err)
+		s.sendError(w, "failed to enqueue job", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Info("crawl enqueued", "job_id", job.ID, "url", req.URL, "max_pages", req.MaxPages)
+	s.sendJSON(w, buildJobResponse(job), http.StatusAccepted)
+}
+
+// handleJobOutline handles GET /v1/jobs/{id}/outline requests. The outline is returned as JSON,
+// or as a markdown list with format=markdown.
+func (s *Server) handleJobOutline(w http.ResponseWriter, r *http.Request) {
+	if s.queue == nil {
+		s.sendError(w, "job queue is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "markdown" {
+		s.sendError(w, "format must be json or markdown", http.StatusBadRequest)
+		return
+	}
+
+	job, err := s.queue.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		s.sendJobError(w, err)
+		return
+	}
+
+	if jobType(job.Payload) != jobTypeCrawl {
+		s.sendError(w, "job is not a crawl", http.StatusBadRequest)
+		return
+	}
+	if job.Status != jobs.StatusCompleted {
+		s.sendError(w, fmt.Sprintf("crawl is %s", job.Status), http.StatusConflict)
+		return
+	}
+
+	var result CrawlResult
+	if err := json.Unmarshal(job.Result, &result); err != nil || result.Outline == nil {
+		s.sendError(w, "crawl result is unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	if format == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(result.Outline.Markdown())); err != nil {
+			s.logger.Error("failed to write outline", "error", err)
+		}
+		return
+	}
+
+	s.sendJSON(w, result.Outline, http.StatusOK)
+}
+
+// validateCrawlRequest validates a crawl request and fills in defaults.
+func (s *Server) validateCrawlRequest(req *CrawlRequest) error {
+	if _, err := urlpkg.ValidateExternal(req.URL); err != nil {
+		return err
+	}
+
+	if err := s.policy.Check(req.URL); err != nil {
+		return err
+	}
+
+	if req.CallbackURL != "" {
+		if _, err := urlpkg.ValidateExternal(req.CallbackURL); err != nil {
+			return fmt.Errorf("invalid callback_url: %w", err)
+		}
+	}
+
+	defaults := crawler.DefaultConfig()
+	switch {
+	case req.MaxPages < 0 || req.MaxPages > s.limits.GetMaxPages():
+		return fmt.Errorf("max_pages must be between 1 and %d", s.limits.GetMaxPages())
+	case req.MaxPages == 0:
+		req.MaxPages = min(defaults.MaxPages, s.limits.GetMaxPages())
+	}
+	switch {
+	case req.MaxDepth < 0 || req.MaxDepth > maxCrawlDepth:
+		return fmt.Errorf("max_depth must be between 1 and %d", maxCrawlDepth)
+	case req.MaxDepth == 0:
+		req.MaxDepth = defaults.MaxDepth
+	}
+	switch req.Canonical {
+	case "", crawler.CanonicalIgnore, crawler.CanonicalSkip, crawler.CanonicalRemap:
+	default:
+		return fmt.Errorf("canonical must be %q, %q, or %q", crawler.CanonicalIgnore, crawler.CanonicalSkip, crawler.CanonicalRemap)
+	}
+	return nil
+}
+
+// jobType returns the type recorded in a job payload, defaulting to jobTypeFetch.
+func jobType(payload json.RawMessage) string {
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(payload, &header); err != nil || header.Type == "" {
+		return jobTypeFetch
+	}
+	return header.Type
+}
+
+// runCrawlJob processes a crawl job claimed from the queue, and delivers its webhook if requested.
+func (s *Server) runCrawlJob(ctx context.Context, job *jobs.Job) (json.RawMessage, error) {
+	var payload crawlJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("invalid job payload: %w", err)
+	}
+
+	progress := newCrawlProgress(payload.Crawl.MaxPages)
+	s.crawls.add(job.ID, progress)
+	defer s.crawls.remove(job.ID)
+
+	stopProgress := s.publishProgress(ctx, job, progress)
+	result, err := s.processCrawl(ctx, &payload.Crawl, progress)
+	stopProgress()
+
+	// Jobs that were canceled, reassigned, or interrupted by shutdown have no final outcome to report.
+	if payload.Crawl.CallbackURL != "" && !errors.Is(ctx.Err(), context.Canceled) {
+		s.deliverCrawlWebhook(job.ID, &payload.Crawl, result, err)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to crawl %s: %w", payload.Crawl.URL, err)
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job result: %w", err)
+	}
+	return encoded, nil
+}
+
+// processCrawl crawls the site and builds its outline, recording its progress in progress
+// if it is not nil. When a server safety limit stops the crawl, the pages visited so far are
+// returned.
+func (s *Server) processCrawl(ctx context.Context, req *CrawlRequest, progress *crawlProgress) (*CrawlResult, error) {
+	guard, ctx, cancel := s.newLimitGuard(ctx)
+	defer cancel()
+
+	c := crawler.New(s.crawlFetch(guard, req.BypassCache, progress), crawler.Config{
+		MaxPages:        req.MaxPages,
+		MaxDepth:        req.MaxDepth,
+		RespectNofollow: req.RespectNofollow,
+		Canonical:       req.Canonical,
+		NormalizeURL:    s.client.NormalizeURL,
+		Discovered:      progress.discover,
+		Logger:          s.logger,
+	})
+
+	result := &CrawlResult{URL: req.URL, Pages: []CrawlPage{}}
+	err := c.Crawl(ctx, req.URL, func(page *crawler.Page) {
+		if errors.Is(page.Err, errLimitReached) {
+			return
+		}
+		cp := CrawlPage{URL: page.URL, Depth: page.Depth, RemappedFrom: page.RemappedFrom}
+		if page.Err != nil {
+			cp.Error = page.Err.Error()
+			cp.ErrorCode = fetchErrorCode(page.Err)
+		} else {
+			cp.StatusCode = page.Result.StatusCode
+			cp.Title = strings.TrimSpace(page.Result.Title)
+			cp.CanonicalURL = page.Result.CanonicalURL
+			cp.Alternates = page.Result.Alternates
+			if cp.StatusCode < http.StatusBadRequest {
+				cp.Tokens = s.tokenizer.Count(page.Result.Body, page.Result.ContentType)
+			}
+		}
+		result.Pages = append(result.Pages, cp)
+		progress.visit(cp.Error != "" || cp.StatusCode >= http.StatusBadRequest)
+	})
+	result.LimitReached = guard.limitReached(ctx)
+	if err != nil && result.LimitReached == "" {
+		return nil, err
+	}
+
+	result.Outline = buildSiteOutline(req.URL, result.Pages)
+	return result, nil
+}
+
+// crawlFetch returns a crawler fetch function backed by the client, bounded by guard. The bytes
+// downloaded from origin are recorded in progress if it is not nil.
+func (s *Server) crawlFetch(guard *limitGuard, bypassCache bool, progress *crawlProgress) crawler.FetchFunc {
+	return func(ctx context.Context, pageURL string) (*crawler.Result, error) {
+		if err := s.policy.Check(pageURL); err != nil {
+			return nil, err
+		}
+
+		fetched, err := guard.fetch(ctx, s.client, pageURL, &client.FetchOptions{BypassCache: bypassCache})
+		if err != nil {
+			return nil, err
+		}
+		if fetched.Transfer != nil {
+			progress.download(fetched.Transfer.CompressedSize)
+		}
+
+		result := &crawler.Result{
+			URL:           fetched.URL,
+			StatusCode:    fetched.StatusCode,
+			Title:         fetched.Title,
+			Body:          fetched.Body,
+			CanonicalURL:  fetched.CanonicalURL,
+			Nofollow:      slices.Contains(fetched.Robots, "nofollow") || slices.Contains(fetched.Robots, "none"),
+			NofollowLinks: fetched.NofollowLinks,
+			Alternates:    fetched.Alternates,
+		}
+		if values := fetched.Headers["Content-Type"]; len(values) > 0 {
+			result.ContentType = values[0]
+		}
+		if values := fetched.Headers["Last-Modified"]; len(values) > 0 {
+			result.LastModified = values[0]
+		}
+		return result, nil
+	}
+}
+
+// buildSiteOutline arranges the successfully crawled pages into a tree by URL path.
+func buildSiteOutline(siteURL string, pages []CrawlPage) *SiteOutline {
+	outline := &SiteOutline{URL: siteURL, Root: &OutlineNode{Path: "/"}}
+
+	for _, page := range pages {
+		if page.Error != "" || page.StatusCode >= http.StatusBadRequest {
+			continue
+		}
+		u, err := url.Parse(page.URL)
+		if err != nil {
+			continue
+		}
+
+		node := outline.Root
+		var segments []string
+		if trimmed := strings.Trim(u.EscapedPath(), "/"); trimmed != "" {
+			segments = strings.Split(trimmed, "/")
+		}
+		if u.RawQuery != "" {
+			if len(segments) == 0 {
+				segments = []string{""}
+			}
+			segments[len(segments)-1] += "?" + u.RawQuery
+		}
+
+		for i := range segments {
+			node = node.child("/" + strings.Join(segments[:i+1], "/"))
+		}
+
+		if node.URL != "" {
+			continue
+		}
+		node.URL = page.URL
+		node.Title = page.Title
+		node.Tokens = page.Tokens
+		outline.Pages++
+		outline.TotalTokens += page.Tokens
+	}
+
+	outline.Root.sortAndTotal()
+	return outline
+}
+
+// child returns the child node at path, creating it if needed.
+func (n *OutlineNode) child(path string) *OutlineNode {
+	for _, c := range n.Children {
+		if c.Path == path {
+			return c
+		}
+	}
+	c := &OutlineNode{Path: path}
+	n.Children = append(n.Children, c)
+	return c
+}
+
+// sortAndTotal orders children by path and computes TotalTokens for the subtree.
+func (n *OutlineNode) sortAndTotal() int {
+	sort.Slice(n.Children, func(i, j int) bool {
+		return n.Children[i].Path < n.Children[j].Path
+	})
+
+	n.TotalTokens = n.Tokens
+	for _, c := range n.Children {
+		n.TotalTokens += c.sortAndTotal()
+	}
+	return n.TotalTokens
+}
+
+// Markdown renders the outline as a nested markdown list.
+func (o *SiteOutline) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Site outline: %s\n\n%d pages, %d tokens\n\n", o.URL, o.Pages, o.TotalTokens)
+	o.Root.writeMarkdown(&b, 0)
+	return b.String()
+}
+
+// writeMarkdown writes the node and its children as list items indented by depth.
+func (n *OutlineNode) writeMarkdown(b *strings.Builder, depth int) {
+	b.WriteString(strings.Repeat("  ", depth))
+	b.WriteString("- ")
+
+	switch {
+	case n.URL == "":
+		fmt.Fprintf(b, "`%s`", n.Path)
+	case n.Title != "":
+		fmt.Fprintf(b, "[%s](%s)", strings.ReplaceAll(n.Title, "]", `\]`), n.URL)
+	default:
+		fmt.Fprintf(b, "[%s](%s)", n.Path, n.URL)
+	}
+
+	if len(n.Children) > 0 {
+		fmt.Fprintf(b, " (%d tokens, %d in section)\n", n.Tokens, n.TotalTokens)
+	} else {
+		fmt.Fprintf(b, " (%d tokens)\n", n.Tokens)
+	}
+
+	for _, c := range n.Children {
+		c.writeMarkdown(b, depth+1)
+	}
+}
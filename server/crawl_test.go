@@ -0,0 +1,237 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joeychilson/websurfer/jobs"
+	"github.com/joeychilson/websurfer/webhook"
+)
+
+// TestBuildSiteOutline verifies pages are arranged by path with section token totals.
+func TestBuildSiteOutline(t *testing.T) {
+	outline := buildSiteOutline("https://example.com", []CrawlPage{
+		{URL: "https://example.com/", Title: "Home", Tokens: 10},
+		{URL: "https://example.com/docs/b", Title: "B", Tokens: 5},
+		{URL: "https://example.com/docs/a", Title: "A", Tokens: 7},
+		{URL: "https://example.com/broken", StatusCode: 404},
+		{URL: "https://example.com/failed", Error: "timeout"},
+	})
+
+	assert.Equal(t, 3, outline.Pages)
+	assert.Equal(t, 22, outline.TotalTokens)
+	assert.Equal(t, "Home", outline.Root.Title)
+	assert.Equal(t, 22, outline.Root.TotalTokens)
+
+	require.Len(t, outline.Root.Children, 1, "failed pages should be left out")
+	docs := outline.Root.Children[0]
+	assert.Equal(t, "/docs", docs.Path)
+	assert.Empty(t, docs.URL, "sections without a page have no URL")
+	assert.Equal(t, 12, docs.TotalTokens)
+	require.Len(t, docs.Children, 2)
+	assert.Equal(t, "/docs/a", docs.Children[0].Path)
+	assert.Equal(t, "/docs/b", docs.Children[1].Path)
+
+	md := outline.Markdown()
+	assert.Contains(t, md, "3 pages, 22 tokens")
+	assert.Contains(t, md, "- [Home](https://example.com/) (10 tokens, 22 in section)")
+	assert.Contains(t, md, "  - `/docs` (0 tokens, 12 in section)")
+	assert.Contains(t, md, "    - [A](https://example.com/docs/a) (7 tokens)")
+}
+
+// TestValidateCrawlRequest verifies defaults and limits for crawl requests.
+func TestValidateCrawlRequest(t *testing.T) {
+	req := CrawlRequest{URL: "https://example.com"}
+	require.NoError(t, (&Server{}).validateCrawlRequest(&req))
+	assert.Equal(t, 100, req.MaxPages)
+	assert.Equal(t, 3, req.MaxDepth)
+
+	for _, req := range []CrawlRequest{
+		{URL: "http://localhost"},
+		{URL: "https://example.com", MaxPages: 1001},
+		{URL: "https://example.com", MaxDepth: -1},
+		{URL: "https://example.com", Canonical: "merge"},
+		{URL: "https://example.com", CallbackURL: "http://169.254.169.254/hook"},
+	} {
+		assert.Error(t, (&Server{}).validateCrawlRequest(&req), "%+v", req)
+	}
+}
+
+// TestCrawlJob verifies crawl jobs run through the queue and expose their outline.
+func TestCrawlJob(t *testing.T) {
+	var origin *httptest.Server
+	origin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprintf(w, `<html><head><title>Home</title></head><body><p>Welcome</p><a href="%s/guide">Guide</a></body></html>`, origin.URL)
+		case "/guide":
+			w.Write([]byte(`<html><head><title>Guide</title></head><body><p>Read the guide</p></body></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer origin.Close()
+
+	s := newJobTestServer(t)
+	router := s.Router()
+
+	body, _ := json.Marshal(CrawlRequest{URL: "https://example.com", MaxPages: -1})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/v1/crawl", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code, "crawl requests should be validated")
+
+	// The test origin is on loopback, which request validation rejects, so enqueue directly.
+	payload, _ := json.Marshal(crawlJobPayload{Type: jobTypeCrawl, Crawl: CrawlRequest{URL: origin.URL + "/", MaxPages: 10, MaxDepth: 2}})
+	created, err := s.queue.Enqueue(context.Background(), payload)
+	require.NoError(t, err)
+
+	var job JobResponse
+	require.Eventually(t, func() bool {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/v1/jobs/"+created.ID, nil))
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &job) != nil {
+			return false
+		}
+		return job.Status == "completed"
+	}, 5*time.Second, 20*time.Millisecond)
+
+	assert.Equal(t, jobTypeCrawl, job.Type)
+	require.NotNil(t, job.Crawl)
+	assert.Nil(t, job.Result)
+	require.Len(t, job.Crawl.Pages, 2)
+	assert.Equal(t, "Guide", job.Crawl.Pages[1].Title)
+	assert.Positive(t, job.Crawl.Pages[1].Tokens)
+	require.NotNil(t, job.Progress, "finished crawls should keep their last progress")
+	assert.Equal(t, 2, job.Progress.Discovered)
+	assert.Equal(t, 2, job.Progress.Fetched)
+	assert.Zero(t, job.Progress.Failed)
+	assert.Positive(t, job.Progress.Bytes)
+	assert.Zero(t, job.Progress.ETASeconds)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/v1/jobs/"+created.ID+"/outline", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var outline SiteOutline
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &outline))
+	assert.Equal(t, 2, outline.Pages)
+	require.Len(t, outline.Root.Children, 1)
+	assert.Equal(t, "/guide", outline.Root.Children[0].Path)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/v1/jobs/"+created.ID+"/outline?format=markdown", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/markdown")
+	assert.Contains(t, w.Body.String(), "[Guide]("+origin.URL+"/guide)")
+}
+
+// TestCrawlJobDeliversWebhook verifies a crawl with a callback_url POSTs its result there.
+func TestCrawlJobDeliversWebhook(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Home</title></head><body><p>Welcome</p></body></html>`))
+	}))
+	defer origin.Close()
+
+	delivered := make(chan WebhookPayload, 1)
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload WebhookPayload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		delivered <- payload
+	}))
+	defer callback.Close()
+
+	// The test origin and callback are on loopback, which request validation and the default
+	// webhook client reject, so run the job directly.
+	s := newMapTestServer(t)
+	s.queue = jobs.New(redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()}), jobs.Config{})
+	s.webhook = webhook.New("", webhook.WithHTTPClient(callback.Client()))
+
+	ctx := context.Background()
+	payload, _ := json.Marshal(crawlJobPayload{Type: jobTypeCrawl, Crawl: CrawlRequest{URL: origin.URL + "/", MaxPages: 10, MaxDepth: 1, CallbackURL: callback.URL}})
+	created, err := s.queue.Enqueue(ctx, payload)
+	require.NoError(t, err)
+	job, err := s.queue.Claim(ctx)
+	require.NoError(t, err)
+
+	_, err = s.runCrawlJob(ctx, job)
+	require.NoError(t, err)
+
+	d := <-delivered
+	assert.Equal(t, created.ID, d.JobID)
+	assert.Equal(t, "completed", d.Status)
+	assert.Nil(t, d.Result)
+	require.NotNil(t, d.Crawl)
+	require.Len(t, d.Crawl.Pages, 1)
+	assert.Equal(t, "Home", d.Crawl.Pages[0].Title)
+}
+
+// TestProcessCrawlCanonicalNofollow verifies query-string variants are reported once under their
+// canonical URL and nofollow links are not crawled.
+func TestProcessCrawlCanonicalNofollow(t *testing.T) {
+	var origin *httptest.Server
+	origin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><body><p>Home</p><a href="/docs?ref=nav">Docs</a> <a href="/docs?ref=footer">Docs</a> <a rel="nofollow" href="/login">Log in</a></body></html>`))
+		case "/docs":
+			fmt.Fprintf(w, `<html><head><title>Docs</title><link rel="canonical" href="%s/docs"><link rel="alternate" hreflang="de" href="/de/docs"></head><body><p>Docs</p></body></html>`, origin.URL)
+		default:
+			w.Write([]byte(`<html><body><p>Other</p></body></html>`))
+		}
+	}))
+	defer origin.Close()
+
+	s := newMapTestServer(t)
+
+	result, err := s.processCrawl(context.Background(), &CrawlRequest{URL: origin.URL + "/", MaxPages: 10, MaxDepth: 2, RespectNofollow: true, Canonical: "remap"}, nil)
+	require.NoError(t, err)
+
+	require.Len(t, result.Pages, 2)
+	docs := result.Pages[1]
+	assert.Equal(t, origin.URL+"/docs", docs.URL)
+	assert.Equal(t, origin.URL+"/docs?ref=nav", docs.RemappedFrom)
+	assert.Equal(t, origin.URL+"/docs", docs.CanonicalURL)
+	require.Len(t, docs.Alternates, 1)
+	assert.Equal(t, origin.URL+"/de/docs", docs.Alternates[0].URL)
+}
+
+// TestJobOutlineErrors verifies the outline endpoint rejects fetch jobs and unfinished crawls.
+func TestJobOutlineErrors(t *testing.T) {
+	s := newJobTestServer(t)
+	router := s.Router()
+
+	fetchJob, err := s.queue.Enqueue(context.Background(), json.RawMessage(`{"url":"https://websurfer-test.invalid"}`))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/v1/jobs/"+fetchJob.ID+"/outline", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	body, _ := json.Marshal(CrawlRequest{URL: "https://websurfer-test.invalid"})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/v1/crawl", bytes.NewReader(body)))
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var queued JobResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &queued))
+	assert.Equal(t, jobTypeCrawl, queued.Type)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/v1/jobs/"+queued.ID+"/outline?format=xml", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/v1/jobs/missing/outline", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/lines"
+	"github.com/joeychilson/websurfer/outline"
+	urlpkg "github.com/joeychilson/websurfer/url"
+)
+
+// diffContextLines is how many unchanged lines surround each hunk of a diff.
+const diffContextLines = 3
+
+// DiffResponse compares the cached version of a page with a fresh fetch.
+type DiffResponse struct {
+	URL string `json:"url"`
+	// Previous is the version that was cached, or nil if the page had not been cached.
+	Previous *DiffVersion `json:"previous,omitempty"`
+	Current  DiffVersion  `json:"current"`
+	Changed  bool         `json:"changed"`
+	// Diff is a unified diff of the previous content against the current content.
+	Diff    string      `json:"diff,omitempty"`
+	Summary DiffSummary `json:"summary"`
+	// RefetchSuppressed is set when the page was fetched within the site's
+	// min_refetch_interval, so the cached version was reused.
+	RefetchSuppressed bool `json:"refetch_suppressed,omitempty"`
+}
+
+// DiffVersion describes one version of a page.
+type DiffVersion struct {
+	StatusCode int    `json:"status_code"`
+	FetchedAt  string `json:"fetched_at"`
+}
+
+// DiffSummary summarizes the changes between two versions of a page. Sections are the
+// headings of the page outline.
+type DiffSummary struct {
+	LinesAdded      int      `json:"lines_added"`
+	LinesRemoved    int      `json:"lines_removed"`
+	SectionsAdded   []string `json:"sections_added,omitempty"`
+	SectionsRemoved []string `json:"sections_removed,omitempty"`
+}
+
+// handleDiff handles GET /v1/diff requests.
+func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
+	if s.client.Cache() == nil {
+		s.sendError(w, "cache is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	pageURL := r.URL.Query().Get("url")
+	if _, err := urlpkg.ValidateExternal(pageURL); err != nil {
+		s.sendValidationError(w, err)
+		return
+	}
+
+	if err := s.policy.Check(pageURL); err != nil {
+		s.sendValidationError(w, err)
+		return
+	}
+
+	resp, err := s.processDiff(r.Context(), pageURL)
+	if err != nil {
+		errResp := buildFetchError(pageURL, err)
+		s.logger.Error("diff failed", "url", pageURL, "error", err, "failure_type", errResp.FailureType)
+		s.sendJSON(w, errResp, errResp.StatusCode)
+		return
+	}
+
+	s.sendJSON(w, resp, http.StatusOK)
+}
+
+// processDiff fetches pageURL from origin, replacing its cached version, and compares the two.
+func (s *Server) processDiff(ctx context.Context, pageURL string) (*DiffResponse, error) {
+	previous := s.client.Cached(ctx, pageURL)
+
+	current, err := s.client.FetchWithOptions(ctx, pageURL, &client.FetchOptions{BypassCache: true})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &DiffResponse{
+		URL:               pageURL,
+		Current:           diffVersion(current),
+		RefetchSuppressed: current.RefetchSuppressed,
+	}
+	if previous == nil {
+		return resp, nil
+	}
+
+	version := diffVersion(previous)
+	resp.Previous = &version
+
+	resp.Changed = string(previous.Body) != string(current.Body)
+	if !resp.Changed {
+		return resp, nil
+	}
+
+	// Long lines are soft-wrapped so a change to a minified page does not replace the whole page.
+	before := lines.Split(string(previous.Body), lines.DefaultWidth).Texts(string(previous.Body))
+	after := lines.Split(string(current.Body), lines.DefaultWidth).Texts(string(current.Body))
+	resp.Diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        before,
+		B:        after,
+		FromFile: "previous",
+		ToFile:   "current",
+		FromDate: resp.Previous.FetchedAt,
+		ToDate:   resp.Current.FetchedAt,
+		Context:  diffContextLines,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff content: %w", err)
+	}
+
+	for _, op := range difflib.NewMatcher(before, after).GetOpCodes() {
+		if op.Tag == 'e' {
+			continue
+		}
+		resp.Summary.LinesRemoved += op.I2 - op.I1
+		resp.Summary.LinesAdded += op.J2 - op.J1
+	}
+	resp.Summary.SectionsAdded, resp.Summary.SectionsRemoved = diffSections(sections(previous), sections(current))
+
+	return resp, nil
+}
+
+// diffVersion describes the version of a page held by resp.
+func diffVersion(resp *client.Response) DiffVersion {
+	fetchedAt := resp.CachedAt
+	if fetchedAt.IsZero() {
+		fetchedAt = time.Now()
+	}
+	return DiffVersion{
+		StatusCode: resp.StatusCode,
+		FetchedAt:  fetchedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// sections returns the outline headings of a page, or nil if its content is not markdown.
+// HTML pages are stored as markdown once parsed.
+func sections(resp *client.Response) []string {
+	var contentType string
+	if values := resp.Headers["Content-Type"]; len(values) > 0 {
+		contentType = strings.ToLower(values[0])
+	}
+	if !strings.Contains(contentType, "html") && !strings.Contains(contentType, "markdown") {
+		return nil
+	}
+
+	headings := outline.ExtractBytes(resp.Body, "text/markdown").Headings
+	titles := make([]string, 0, len(headings))
+	for _, heading := range headings {
+		titles = append(titles, strings.Repeat("#", heading.Level)+" "+heading.Text)
+	}
+	return titles
+}
+
+// diffSections returns the sections in after but not before, and those in before but not
+// after. Repeated headings are matched by count.
+func diffSections(before, after []string) (added, removed []string) {
+	counts := make(map[string]int)
+	for _, section := range before {
+		counts[section]++
+	}
+	for _, section := range after {
+		if counts[section] > 0 {
+			counts[section]--
+			continue
+		}
+		added = append(added, section)
+	}
+
+	for _, section := range before {
+		if counts[section] > 0 {
+			counts[section]--
+			removed = append(removed, section)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProcessDiff verifies a fresh fetch is compared against the cached version and replaces it.
+func TestProcessDiff(t *testing.T) {
+	pages := []string{
+		`<html><body><h2>Pricing</h2><p>Basic plan costs $10.</p><h2>Support</h2><p>Email us.</p></body></html>`,
+		`<html><body><h2>Pricing</h2><p>Basic plan costs $12.</p><h2>FAQ</h2><p>Ask away.</p></body></html>`,
+	}
+	var version atomic.Int32
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(pages[version.Load()]))
+	}))
+	defer origin.Close()
+
+	s, _ := newCacheTestServer(t)
+	ctx := context.Background()
+
+	first, err := s.processDiff(ctx, origin.URL)
+	require.NoError(t, err)
+	assert.Nil(t, first.Previous, "an uncached page has no previous version")
+	assert.False(t, first.Changed)
+
+	unchanged, err := s.processDiff(ctx, origin.URL)
+	require.NoError(t, err)
+	require.NotNil(t, unchanged.Previous)
+	assert.False(t, unchanged.Changed)
+	assert.Empty(t, unchanged.Diff)
+
+	version.Store(1)
+	changed, err := s.processDiff(ctx, origin.URL)
+	require.NoError(t, err)
+	assert.True(t, changed.Changed)
+	assert.Contains(t, changed.Diff, "--- previous")
+	assert.Contains(t, changed.Diff, "-Basic plan costs $10.")
+	assert.Contains(t, changed.Diff, "+Basic plan costs $12.")
+	assert.Equal(t, []string{"## FAQ"}, changed.Summary.SectionsAdded)
+	assert.Equal(t, []string{"## Support"}, changed.Summary.SectionsRemoved)
+	assert.Positive(t, changed.Summary.LinesAdded)
+	assert.Positive(t, changed.Summary.LinesRemoved)
+
+	cached := s.client.Cached(ctx, origin.URL)
+	require.NotNil(t, cached)
+	assert.Contains(t, string(cached.Body), "$12")
+}
+
+// TestDiffSections verifies repeated headings are matched by count.
+func TestDiffSections(t *testing.T) {
+	added, removed := diffSections(
+		[]string{"# Intro", "## Notes", "## Notes", "## Old"},
+		[]string{"# Intro", "## Notes", "## New"},
+	)
+	assert.Equal(t, []string{"## New"}, added)
+	assert.Equal(t, []string{"## Notes", "## Old"}, removed)
+}
+
+// TestHandleDiffRequiresCache verifies /v1/diff is unavailable without a cache.
+func TestHandleDiffRequiresCache(t *testing.T) {
+	s := newMapTestServer(t)
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest("GET", "/v1/diff?url=https://example.com", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
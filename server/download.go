@@ -0,0 +1,262 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/fetcher"
+	"github.com/joeychilson/websurfer/objectstore"
+	urlpkg "github.com/joeychilson/websurfer/url"
+)
+
+const (
+	// maxDownloadAccept caps how many media types a download may accept.
+	maxDownloadAccept = 20
+)
+
+// errUnsupportedType is returned when a downloaded asset's content type is not accepted.
+var errUnsupportedType = errors.New("content type not accepted")
+
+// DownloadRequest represents a request to download a binary asset, such as a PDF, image, or
+// archive, without parsing it.
+type DownloadRequest struct {
+	URL string `json:"url"`
+	// Accept lists the media types the asset may have, such as "application/pdf" or "image/*"
+	// (default: any type except HTML, which /v1/fetch handles).
+	Accept []string `json:"accept,omitempty"`
+	// MaxSize caps the asset's size in bytes. The site's max_body_size always applies.
+	MaxSize int64 `json:"max_size,omitempty"`
+	// Store uploads the asset to the server's object store and returns a reference to it
+	// instead of its bytes.
+	Store bool `json:"store,omitempty"`
+}
+
+// DownloadResponse describes a downloaded asset uploaded to the object store.
+type DownloadResponse struct {
+	URL         string              `json:"url"`
+	ContentType string              `json:"content_type"`
+	Size        int64               `json:"size"`
+	SHA256      string              `json:"sha256"`
+	Object      *objectstore.Object `json:"object"`
+}
+
+// handleDownload handles POST /v1/download requests.
+func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	var req DownloadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("failed to decode request", "error", err)
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.validateDownloadRequest(&req); err != nil {
+		s.logger.Error("invalid request", "error", err)
+		s.sendValidationError(w, err)
+		return
+	}
+
+	if req.Store && s.objectStore == nil {
+		s.sendError(w, "object store is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	s.logger.Info("download request", "url", req.URL, "max_size", req.MaxSize, "store", req.Store)
+
+	download, err := s.processDownload(r.Context(), &req)
+	var statusErr *fetcher.StatusError
+	if errors.As(err, &statusErr) {
+		s.logger.Error("download failed", "url", req.URL, "error", err)
+		s.sendErrorCode(w, fmt.Sprintf("failed to download %s: %v", req.URL, err), http.StatusBadGateway, fetchErrorCode(err))
+		return
+	}
+	if err != nil {
+		errResp := buildFetchError(req.URL, err)
+		s.logger.Error("download failed", "url", req.URL, "error", err, "failure_type", errResp.FailureType)
+		s.sendJSON(w, errResp, errResp.StatusCode)
+		return
+	}
+
+	defer download.Body.Close()
+
+	if req.Store {
+		// Objects are named by their SHA-256, so the asset is read whole before it is uploaded.
+		body, err := io.ReadAll(download.Body)
+		if err != nil {
+			errResp := buildFetchError(req.URL, err)
+			s.logger.Error("download failed", "url", req.URL, "error", err, "failure_type", errResp.FailureType)
+			s.sendJSON(w, errResp, errResp.StatusCode)
+			return
+		}
+		hash := sha256.Sum256(body)
+		digest := hex.EncodeToString(hash[:])
+
+		object, err := s.objectStore.Put(r.Context(), digest+downloadExtension(download), download.ContentType, body)
+		if err != nil {
+			s.logger.Error("failed to store download", "url", req.URL, "error", err)
+			s.sendError(w, fmt.Sprintf("failed to store download: %v", err), http.StatusBadGateway)
+			return
+		}
+
+		s.logger.Info("download stored", "url", req.URL, "location", object.Location, "size", object.Size)
+		s.sendJSON(w, DownloadResponse{
+			URL:         download.URL,
+			ContentType: download.ContentType,
+			Size:        int64(len(body)),
+			SHA256:      digest,
+			Object:      object,
+		}, http.StatusOK)
+		return
+	}
+
+	// The asset is streamed as it arrives, so its SHA-256 is only known once it was sent and
+	// follows it in a trailer.
+	w.Header().Set("Content-Type", download.ContentType)
+	if length := http.Header(download.Headers).Get("Content-Length"); length != "" {
+		w.Header().Set("Content-Length", length)
+	}
+	if name := downloadFilename(download.URL); name != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	}
+	w.Header().Set("X-Download-URL", download.URL)
+	w.Header().Set("Trailer", "X-Content-SHA256")
+	w.WriteHeader(http.StatusOK)
+
+	hash := sha256.New()
+	size, err := io.Copy(w, io.TeeReader(download.Body, hash))
+	if err != nil {
+		// The status was sent, so the response is cut off to show the asset is incomplete.
+		s.logger.Error("failed to stream download", "url", req.URL, "size", size, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+	w.Header().Set("X-Content-SHA256", hex.EncodeToString(hash.Sum(nil)))
+	s.logger.Info("download streamed", "url", req.URL, "size", size)
+}
+
+// validateDownloadRequest validates a download request.
+func (s *Server) validateDownloadRequest(req *DownloadRequest) error {
+	if _, err := urlpkg.ValidateExternal(req.URL); err != nil {
+		return err
+	}
+
+	if err := s.policy.Check(req.URL); err != nil {
+		return err
+	}
+
+	if req.MaxSize < 0 {
+		return fmt.Errorf("max_size must not be negative")
+	}
+
+	if len(req.Accept) > maxDownloadAccept {
+		return fmt.Errorf("accept may list at most %d media types", maxDownloadAccept)
+	}
+	for i, accept := range req.Accept {
+		mediaType, _, err := mime.ParseMediaType(accept)
+		if err != nil || !strings.Contains(mediaType, "/") {
+			return fmt.Errorf("invalid media type in accept: %q", accept)
+		}
+		req.Accept[i] = mediaType
+	}
+	return nil
+}
+
+// processDownload downloads the asset and checks its status, declared size, and content type.
+// Its body is capped at the request's max_size, failing with fetcher.ErrBodyTooLarge once read
+// past it. The caller must close it.
+func (s *Server) processDownload(ctx context.Context, req *DownloadRequest) (*client.Download, error) {
+	download, err := s.client.Download(ctx, req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkDownload(req, download); err != nil {
+		download.Body.Close()
+		return nil, err
+	}
+
+	if req.MaxSize > 0 {
+		download.Body = fetcher.LimitBody(download.Body, req.MaxSize)
+	}
+	return download, nil
+}
+
+// checkDownload checks a download's status, its size if the origin declared one, and its
+// content type against the request.
+func checkDownload(req *DownloadRequest, download *client.Download) error {
+	if download.StatusCode < http.StatusOK || download.StatusCode >= http.StatusMultipleChoices {
+		return &fetcher.StatusError{StatusCode: download.StatusCode}
+	}
+
+	length, err := strconv.ParseInt(http.Header(download.Headers).Get("Content-Length"), 10, 64)
+	if req.MaxSize > 0 && err == nil && length > req.MaxSize {
+		return fmt.Errorf("%w: %d bytes exceeds max_size of %d bytes", fetcher.ErrBodyTooLarge, length, req.MaxSize)
+	}
+
+	if download.ContentType == "" {
+		download.ContentType = "application/octet-stream"
+	}
+	if !acceptsContentType(req.Accept, download.ContentType) {
+		return fmt.Errorf("%w: %s", errUnsupportedType, download.ContentType)
+	}
+	return nil
+}
+
+// acceptsContentType reports whether contentType matches one of the accepted media types,
+// which may be wildcards such as "image/*". With no accepted types, anything but HTML matches.
+func acceptsContentType(accept []string, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+
+	if len(accept) == 0 {
+		return mediaType != "text/html" && mediaType != "application/xhtml+xml"
+	}
+
+	for _, accepted := range accept {
+		if accepted == "*/*" || accepted == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(accepted, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// downloadFilename returns the last path segment of the downloaded URL, if it has one.
+func downloadFilename(downloadURL string) string {
+	u, err := url.Parse(downloadURL)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		return ""
+	}
+	return name
+}
+
+// downloadExtension returns the file extension of a download, from its URL or else its content
+// type, for naming stored objects.
+func downloadExtension(download *client.Download) string {
+	if ext := path.Ext(downloadFilename(download.URL)); ext != "" && len(ext) <= 10 {
+		return strings.ToLower(ext)
+	}
+	mediaType, _, _ := mime.ParseMediaType(download.ContentType)
+	if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/fetcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProcessDownload verifies downloads return raw bytes and enforce status, size, and type.
+func TestProcessDownload(t *testing.T) {
+	pdf := []byte("%PDF-1.7\n%%EOF")
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/report.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write(pdf)
+		case "/streamed.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			w.Write(pdf)
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<html><body>Hello</body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer origin.Close()
+
+	s := newMapTestServer(t)
+	ctx := context.Background()
+
+	download, err := s.processDownload(ctx, &DownloadRequest{URL: origin.URL + "/report.pdf", Accept: []string{"application/pdf"}})
+	require.NoError(t, err)
+	body, err := io.ReadAll(download.Body)
+	require.NoError(t, err)
+	download.Body.Close()
+	assert.Equal(t, pdf, body)
+	assert.Equal(t, "application/pdf", download.ContentType)
+
+	_, err = s.processDownload(ctx, &DownloadRequest{URL: origin.URL + "/report.pdf", MaxSize: 4})
+	assert.ErrorIs(t, err, fetcher.ErrBodyTooLarge)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, fetchErrorStatus(err))
+
+	download, err = s.processDownload(ctx, &DownloadRequest{URL: origin.URL + "/streamed.pdf", MaxSize: 4})
+	require.NoError(t, err, "an undeclared size is only checked while streaming")
+	_, err = io.ReadAll(download.Body)
+	download.Body.Close()
+	assert.ErrorIs(t, err, fetcher.ErrBodyTooLarge)
+
+	_, err = s.processDownload(ctx, &DownloadRequest{URL: origin.URL + "/report.pdf", Accept: []string{"image/*"}})
+	assert.ErrorIs(t, err, errUnsupportedType)
+	assert.Equal(t, http.StatusUnsupportedMediaType, fetchErrorStatus(err))
+
+	_, err = s.processDownload(ctx, &DownloadRequest{URL: origin.URL + "/page"})
+	assert.ErrorIs(t, err, errUnsupportedType, "HTML is not downloaded unless accepted")
+
+	_, err = s.processDownload(ctx, &DownloadRequest{URL: origin.URL + "/missing.zip"})
+	var statusErr *fetcher.StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+}
+
+// TestAcceptsContentType verifies exact, wildcard, and default content type matching.
+func TestAcceptsContentType(t *testing.T) {
+	assert.True(t, acceptsContentType(nil, "application/pdf"))
+	assert.False(t, acceptsContentType(nil, "text/html; charset=utf-8"))
+	assert.False(t, acceptsContentType(nil, "application/xhtml+xml"))
+	assert.True(t, acceptsContentType([]string{"text/html"}, "Text/HTML; charset=utf-8"))
+	assert.True(t, acceptsContentType([]string{"image/*"}, "image/png"))
+	assert.False(t, acceptsContentType([]string{"image/*"}, "application/zip"))
+	assert.True(t, acceptsContentType([]string{"*/*"}, "application/zip"))
+}
+
+// TestValidateDownloadRequest verifies download requests are checked before fetching.
+func TestValidateDownloadRequest(t *testing.T) {
+	s := newMapTestServer(t)
+
+	req := DownloadRequest{URL: "https://example.com/a.pdf", Accept: []string{"Application/PDF", "image/*"}}
+	require.NoError(t, s.validateDownloadRequest(&req))
+	assert.Equal(t, []string{"application/pdf", "image/*"}, req.Accept)
+
+	for _, req := range []DownloadRequest{
+		{URL: "http://localhost/a.pdf"},
+		{URL: "https://example.com/a.pdf", MaxSize: -1},
+		{URL: "https://example.com/a.pdf", Accept: []string{"pdf"}},
+		{URL: "https://example.com/a.pdf", Accept: make([]string, maxDownloadAccept+1)},
+	} {
+		assert.Error(t, s.validateDownloadRequest(&req), "%+v", req)
+	}
+}
+
+// TestDownloadExtension verifies stored objects are named from the URL or content type.
+func TestDownloadExtension(t *testing.T) {
+	assert.Equal(t, "report.pdf", downloadFilename("https://example.com/files/report.pdf?v=2"))
+	assert.Empty(t, downloadFilename("https://example.com/"))
+
+	assert.Equal(t, ".pdf", downloadExtension(&client.Download{URL: "https://example.com/Report.PDF"}))
+	assert.Equal(t, ".zip", downloadExtension(&client.Download{URL: "https://example.com/get", ContentType: "application/zip"}))
+	assert.Empty(t, downloadExtension(&client.Download{URL: "https://example.com/get", ContentType: "application/x-unknown"}))
+}
+
+// TestHandleDownloadStoreNotConfigured verifies storing downloads requires an object store.
+func TestHandleDownloadStoreNotConfigured(t *testing.T) {
+	s := newMapTestServer(t)
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest("POST", "/v1/download", strings.NewReader(`{"url":"https://example.com/a.pdf","store":true}`)))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/joeychilson/websurfer/budget"
+	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/fetcher"
+	"github.com/joeychilson/websurfer/policy"
+	"github.com/joeychilson/websurfer/ratelimit"
+	"github.com/joeychilson/websurfer/robots"
+	"github.com/joeychilson/websurfer/session"
+	urlpkg "github.com/joeychilson/websurfer/url"
+)
+
+// Error codes identify the cause of an error response. They are stable: new codes may be
+// added, but existing codes keep their meaning.
+const (
+	ErrorCodeInvalidRequest    = "INVALID_REQUEST"
+	ErrorCodeUnauthorized      = "UNAUTHORIZED"
+	ErrorCodePolicyBlocked     = "POLICY_BLOCKED"
+	ErrorCodeSSRFBlocked       = "SSRF_BLOCKED"
+	ErrorCodeRobotsBlocked     = "ROBOTS_BLOCKED"
+	ErrorCodeNotFound          = "NOT_FOUND"
+	ErrorCodeConflict          = "CONFLICT"
+	ErrorCodeRateLimited       = "RATE_LIMITED"
+	ErrorCodeBudgetExhausted   = "BUDGET_EXHAUSTED"
+	ErrorCodeSessionExhausted  = "SESSION_EXHAUSTED"
+	ErrorCodeDomainPaused      = "DOMAIN_PAUSED"
+	ErrorCodeUpstream429       = "UPSTREAM_429"
+	ErrorCodeUpstream5xx       = "UPSTREAM_5XX"
+	ErrorCodeUpstreamError     = "UPSTREAM_ERROR"
+	ErrorCodeUpstreamTimeout   = "UPSTREAM_TIMEOUT"
+	ErrorCodeDNSFailed         = "DNS_FAILED"
+	ErrorCodeTLSFailed         = "TLS_FAILED"
+	ErrorCodeConnectionRefused = "CONNECTION_REFUSED"
+	ErrorCodeConnectionReset   = "CONNECTION_RESET"
+	ErrorCodeParseFailed       = "PARSE_FAILED"
+	ErrorCodeContentTooLarge   = "CONTENT_TOO_LARGE"
+	ErrorCodeUnsupportedType   = "UNSUPPORTED_CONTENT_TYPE"
+	ErrorCodeFetchFailed       = "FETCH_FAILED"
+	ErrorCodeNotConfigured     = "NOT_CONFIGURED"
+	ErrorCodeInternal          = "INTERNAL_ERROR"
+)
+
+// ErrorCodeInfo documents an error code.
+type ErrorCodeInfo struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	// Retryable reports whether the same request may succeed if retried later.
+	Retryable bool `json:"retryable"`
+}
+
+// ErrorsResponse lists the error codes the server returns.
+type ErrorsResponse struct {
+	Errors []ErrorCodeInfo `json:"errors"`
+}
+
+// errorCatalog documents every error code, in the order they are listed by GET /v1/errors.
+var errorCatalog = []ErrorCodeInfo{
+	{ErrorCodeInvalidRequest, "The request is malformed or has invalid parameters.", false},
+	{ErrorCodeUnauthorized, "The API key is missing or invalid.", false},
+	{ErrorCodePolicyBlocked, "The URL is not permitted by the server's domain policy.", false},
+	{ErrorCodeSSRFBlocked, "The URL or a redirect targets a private, loopback, or link-local address.", false},
+	{ErrorCodeRobotsBlocked, "The site's robots.txt disallows fetching the URL.", false},
+	{ErrorCodeNotFound, "The requested resource, such as a job, session, preview image, or page version, does not exist.", false},
+	{ErrorCodeConflict, "The resource is in a state that does not allow the request, such as a finished job.", false},
+	{ErrorCodeRateLimited, "The client has exceeded the server's request rate limit.", true},
+	{ErrorCodeBudgetExhausted, "The site's fetch budget is used up until its window resets.", true},
+	{ErrorCodeSessionExhausted, "The session has made all the fetches it was created with.", false},
+	{ErrorCodeDomainPaused, "Fetching from the domain is paused after it reported being unavailable.", true},
+	{ErrorCodeUpstream429, "The site kept responding 429 Too Many Requests.", true},
+	{ErrorCodeUpstream5xx, "The site kept responding with a server error.", true},
+	{ErrorCodeUpstreamError, "A request to the site or another upstream service failed.", true},
+	{ErrorCodeUpstreamTimeout, "The connection or request to the site timed out.", true},
+	{ErrorCodeDNSFailed, "The site's hostname could not be resolved.", false},
+	{ErrorCodeTLSFailed, "The TLS handshake or certificate verification failed.", false},
+	{ErrorCodeConnectionRefused, "The site refused the connection.", true},
+	{ErrorCodeConnectionReset, "The connection was reset or closed mid-request.", true},
+	{ErrorCodeParseFailed, "The fetched content could not be parsed.", false},
+	{ErrorCodeContentTooLarge, "The response body exceeds the site's max_body_size or the download's max_size, or the requested lines the server's max_tokens.", false},
+	{ErrorCodeUnsupportedType, "The downloaded content's type is not one the download accepts.", false},
+	{ErrorCodeFetchFailed, "The fetch failed for another reason.", true},
+	{ErrorCodeNotConfigured, "The feature needed for the request, such as the job queue or cache, is not configured.", false},
+	{ErrorCodeInternal, "The server failed to process the request.", true},
+}
+
+// handleErrors handles GET /v1/errors requests.
+func (s *Server) handleErrors(w http.ResponseWriter, r *http.Request) {
+	s.sendJSON(w, ErrorsResponse{Errors: errorCatalog}, http.StatusOK)
+}
+
+// fetchErrorCode returns the error code for a failed fetch.
+func fetchErrorCode(err error) string {
+	switch {
+	case errors.Is(err, policy.ErrViolation):
+		return ErrorCodePolicyBlocked
+	case errors.Is(err, urlpkg.ErrBlockedAddress):
+		return ErrorCodeSSRFBlocked
+	case errors.Is(err, robots.ErrDisallowed):
+		return ErrorCodeRobotsBlocked
+	case errors.Is(err, budget.ErrBudgetExhausted):
+		return ErrorCodeBudgetExhausted
+	case errors.Is(err, ratelimit.ErrDomainPaused):
+		return ErrorCodeDomainPaused
+	case errors.Is(err, client.ErrParse):
+		return ErrorCodeParseFailed
+	case errors.Is(err, fetcher.ErrBodyTooLarge), errors.Is(err, errTokenLimit):
+		return ErrorCodeContentTooLarge
+	case errors.Is(err, errUnsupportedType):
+		return ErrorCodeUnsupportedType
+	case errors.Is(err, client.ErrVersionNotFound), errors.Is(err, session.ErrNotFound):
+		return ErrorCodeNotFound
+	case errors.Is(err, session.ErrExhausted):
+		return ErrorCodeSessionExhausted
+	case errors.Is(err, errTranslationNotConfigured):
+		return ErrorCodeNotConfigured
+	}
+
+	var statusErr *fetcher.StatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == http.StatusTooManyRequests:
+			return ErrorCodeUpstream429
+		case statusErr.StatusCode >= http.StatusInternalServerError:
+			return ErrorCodeUpstream5xx
+		default:
+			return ErrorCodeUpstreamError
+		}
+	}
+
+	switch fetcher.ClassifyError(err) {
+	case fetcher.FailureDNS:
+		return ErrorCodeDNSFailed
+	case fetcher.FailureTLS:
+		return ErrorCodeTLSFailed
+	case fetcher.FailureConnRefused:
+		return ErrorCodeConnectionRefused
+	case fetcher.FailureReset:
+		return ErrorCodeConnectionReset
+	case fetcher.FailureTimeout:
+		return ErrorCodeUpstreamTimeout
+	}
+	return ErrorCodeFetchFailed
+}
+
+// validationErrorCode returns the error code for a request validation error.
+func validationErrorCode(err error) string {
+	switch {
+	case errors.Is(err, policy.ErrViolation):
+		return ErrorCodePolicyBlocked
+	case errors.Is(err, urlpkg.ErrBlockedAddress):
+		return ErrorCodeSSRFBlocked
+	default:
+		return ErrorCodeInvalidRequest
+	}
+}
+
+// statusErrorCode returns the error code for an error response with no more specific cause.
+func statusErrorCode(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return ErrorCodeInvalidRequest
+	case http.StatusUnauthorized:
+		return ErrorCodeUnauthorized
+	case http.StatusForbidden:
+		return ErrorCodePolicyBlocked
+	case http.StatusNotFound:
+		return ErrorCodeNotFound
+	case http.StatusConflict:
+		return ErrorCodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return ErrorCodeContentTooLarge
+	case http.StatusUnsupportedMediaType:
+		return ErrorCodeUnsupportedType
+	case http.StatusTooManyRequests:
+		return ErrorCodeRateLimited
+	case http.StatusBadGateway:
+		return ErrorCodeUpstreamError
+	case http.StatusServiceUnavailable:
+		return ErrorCodeNotConfigured
+	default:
+		return ErrorCodeInternal
+	}
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/joeychilson/websurfer/budget"
+	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/fetcher"
+	"github.com/joeychilson/websurfer/ratelimit"
+	"github.com/joeychilson/websurfer/robots"
+	"github.com/joeychilson/websurfer/session"
+	urlpkg "github.com/joeychilson/websurfer/url"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFetchErrorCode verifies fetch failures map to stable error codes.
+func TestFetchErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("%w: https://example.com", robots.ErrDisallowed), ErrorCodeRobotsBlocked},
+		{urlpkg.ValidateIP([]byte{10, 0, 0, 1}), ErrorCodeSSRFBlocked},
+		{fmt.Errorf("wrapped: %w", budget.ErrBudgetExhausted), ErrorCodeBudgetExhausted},
+		{ratelimit.ErrDomainPaused, ErrorCodeDomainPaused},
+		{fmt.Errorf("%w: bad input", client.ErrParse), ErrorCodeParseFailed},
+		{session.ErrExhausted, ErrorCodeSessionExhausted},
+		{fmt.Errorf("failed to fetch: %w: exceeds maximum size of 1024 bytes", fetcher.ErrBodyTooLarge), ErrorCodeContentTooLarge},
+		{fmt.Errorf("%w: text/html", errUnsupportedType), ErrorCodeUnsupportedType},
+		{fmt.Errorf("failed after 3 attempts: %w", &fetcher.StatusError{StatusCode: 429}), ErrorCodeUpstream429},
+		{fmt.Errorf("failed after 3 attempts: %w", &fetcher.StatusError{StatusCode: 503}), ErrorCodeUpstream5xx},
+		{fmt.Errorf("failed: %w", context.DeadlineExceeded), ErrorCodeUpstreamTimeout},
+		{fmt.Errorf("something else"), ErrorCodeFetchFailed},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, fetchErrorCode(tt.err), "%v", tt.err)
+	}
+}
+
+// TestHandleErrorsEndpoint verifies /v1/errors lists every error code once.
+func TestHandleErrorsEndpoint(t *testing.T) {
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest("GET", "/v1/errors", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp ErrorsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+	codes := make(map[string]bool)
+	for _, info := range resp.Errors {
+		assert.False(t, codes[info.Code], "duplicate code %s", info.Code)
+		assert.NotEmpty(t, info.Description)
+		codes[info.Code] = true
+	}
+	for _, code := range []string{ErrorCodeInvalidRequest, ErrorCodeRobotsBlocked, ErrorCodeSSRFBlocked, ErrorCodeUpstream429, ErrorCodeParseFailed} {
+		assert.True(t, codes[code], "missing code %s", code)
+	}
+	for _, status := range []int{400, 401, 403, 404, 409, 413, 415, 429, 500, 502, 503} {
+		assert.True(t, codes[statusErrorCode(status)], "status %d maps to an unlisted code", status)
+	}
+}
+
+// TestErrorCodeInResponses verifies error responses carry the error code for their cause.
+func TestErrorCodeInResponses(t *testing.T) {
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		url    string
+		status int
+		code   string
+	}{
+		{"not-a-valid-url", http.StatusBadRequest, ErrorCodeInvalidRequest},
+		{"http://10.0.0.1/admin", http.StatusBadRequest, ErrorCodeSSRFBlocked},
+	}
+
+	for _, tt := range tests {
+		body, _ := json.Marshal(FetchRequest{URL: tt.url})
+		w := httptest.NewRecorder()
+		s.Router().ServeHTTP(w, httptest.NewRequest("POST", "/v1/fetch", bytes.NewReader(body)))
+		assert.Equal(t, tt.status, w.Code, tt.url)
+
+		var errResp ErrorResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&errResp))
+		assert.Equal(t, tt.code, errResp.ErrorCode, tt.url)
+	}
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest("GET", "/v1/jobs/missing", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), `"error_code":"NOT_CONFIGURED"`)
+}
@@ -2,52 +2,273 @@ package server
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
 	"strings"
 	"time"
 
+	"golang.org/x/text/language"
+
+	"github.com/joeychilson/websurfer/access"
+	"github.com/joeychilson/websurfer/budget"
+	"github.com/joeychilson/websurfer/changelog"
 	"github.com/joeychilson/websurfer/client"
 	"github.com/joeychilson/websurfer/content"
+	"github.com/joeychilson/websurfer/fetcher"
+	"github.com/joeychilson/websurfer/forms"
+	"github.com/joeychilson/websurfer/hreflang"
+	"github.com/joeychilson/websurfer/langdetect"
+	"github.com/joeychilson/websurfer/lines"
 	"github.com/joeychilson/websurfer/outline"
+	"github.com/joeychilson/websurfer/parser"
+	"github.com/joeychilson/websurfer/policy"
+	"github.com/joeychilson/websurfer/postprocess"
+	"github.com/joeychilson/websurfer/provenance"
+	"github.com/joeychilson/websurfer/ratelimit"
+	"github.com/joeychilson/websurfer/retry"
+	"github.com/joeychilson/websurfer/robots"
+	"github.com/joeychilson/websurfer/search"
+	"github.com/joeychilson/websurfer/selector"
+	"github.com/joeychilson/websurfer/session"
+	"github.com/joeychilson/websurfer/structured"
 	urlpkg "github.com/joeychilson/websurfer/url"
 )
 
+const (
+	// ProfileChangelog extracts the releases of a changelog or release notes page.
+	ProfileChangelog = "changelog"
+	// minIndexedSearchSize is the smallest document whose search index is cached. Smaller
+	// documents are searched directly, which is cheaper than loading an index.
+	minIndexedSearchSize = 64 << 10
+	// maxSelectors caps how many selectors a fetch may evaluate.
+	maxSelectors = 50
+)
+
 var (
 	// langRegex extracts the language code from HTML lang attribute
 	langRegex = regexp.MustCompile(`(?i)<html[^>]+lang=["']([^"']+)["']`)
+	// errTokenLimit is returned when the lines a fetch asks for exceed the server's max_tokens.
+	errTokenLimit = errors.New("exceeds the server's max_tokens limit")
 )
 
 // FetchRequest represents a request to fetch and process a URL.
 type FetchRequest struct {
-	URL       string `json:"url"`
-	MaxTokens int    `json:"max_tokens,omitempty"`
-	Offset    int    `json:"offset,omitempty"`
+	URL          string         `json:"url"`
+	MaxTokens    int            `json:"max_tokens,omitempty"`
+	Offset       int            `json:"offset,omitempty"`
+	BypassCache  bool           `json:"bypass_cache,omitempty"`
+	Debug        bool           `json:"debug,omitempty"`
+	ParseOptions *ParseOptions  `json:"parse_options,omitempty"`
+	CallbackURL  string         `json:"callback_url,omitempty"`
+	Tokenizer    string         `json:"tokenizer,omitempty"`
+	Search       *SearchRequest `json:"search,omitempty"`
+	// FollowPagination follows rel="next" and "next page" links and joins the pages of a
+	// multi-page article into one document, up to MaxPages pages (default: 5, max: 20).
+	FollowPagination bool `json:"follow_pagination,omitempty"`
+	MaxPages         int  `json:"max_pages,omitempty"`
+	// Deterministic omits fields that vary between fetches of unchanged content, such as cache
+	// state, timestamps, and the serving region, so responses can be golden-file tested.
+	Deterministic bool `json:"deterministic,omitempty"`
+	// Headers and Cookies are sent with this fetch only, over the site's configured headers.
+	// Only allowlisted headers may be set. Requests with either skip the cache.
+	Headers map[string]string `json:"headers,omitempty"`
+	Cookies map[string]string `json:"cookies,omitempty"`
+	// Version serves that version of the page from the cache history, and AsOf (RFC 3339)
+	// serves the version that was current at that time. Neither fetches from origin.
+	Version int    `json:"version,omitempty"`
+	AsOf    string `json:"as_of,omitempty"`
+	// SessionID fetches within a session created by POST /v1/sessions, sharing its cookies,
+	// Referer, and fetch budget.
+	SessionID string `json:"session_id,omitempty"`
+	// Profile extracts records for a kind of page alongside the content. "changelog" returns
+	// the releases of a changelog or release notes page.
+	Profile string `json:"profile,omitempty"`
+	// Language returns the page's hreflang variant for this language, a BCP 47 tag such as
+	// "de" or "pt-BR", instead of the page itself when the page lists one.
+	Language string `json:"language,omitempty"`
+	// TranslateTo translates the content to this language, such as "en", when the page is in
+	// another language. The page's language is then recorded in Metadata.OriginalLanguage.
+	TranslateTo string `json:"translate_to,omitempty"`
+	// Processors replaces the site's post-processing chain for this fetch, such as
+	// [{"type": "redact"}, {"type": "summarize"}]; an empty list disables it.
+	Processors []postprocess.Spec `json:"processors,omitempty"`
+	// Lines returns only a range of the content's lines instead of paginating by tokens.
+	Lines *LineRange `json:"lines,omitempty"`
+	// Base64 returns binary bodies served as text, such as an image labeled text/html,
+	// base64-encoded in Content. Without it, they get a metadata-only response.
+	Base64 bool `json:"base64,omitempty"`
+	// Selectors names CSS selectors whose matches are returned in Extracted, such as
+	// {"price": ".product-price", "image": "img.hero @src"}. A trailing @name extracts that
+	// attribute instead of the text. SelectorsOnly omits the content.
+	Selectors     map[string]string `json:"selectors,omitempty"`
+	SelectorsOnly bool              `json:"selectors_only,omitempty"`
+	// Format selects the content of HTML pages: "markdown" (default), "raw_html" for the page's
+	// HTML as fetched or rendered, or "text" for a plain-text rendering.
+	Format string `json:"format,omitempty"`
+	// IncludeRaw returns the body the content was parsed from in Raw alongside the content, so
+	// callers needing both fetch once. Such fetches skip the cache.
+	IncludeRaw bool `json:"include_raw,omitempty"`
+
+	// delegated is set when the request was forwarded by a peer in another region.
+	delegated bool
+	// session is the state of SessionID for this fetch, set once a fetch is reserved.
+	session *session.Session
+	// jar holds the session's cookies for this fetch, including those set during it.
+	jar *session.Jar
+}
+
+// ParseOptions controls how images and links are rendered in the content.
+type ParseOptions struct {
+	Images string `json:"images,omitempty"`
+	Links  string `json:"links,omitempty"`
+	TOC    bool   `json:"toc,omitempty"`
+}
+
+// LineRange selects lines of the content, 1-based and inclusive. Lines longer than
+// lines.DefaultWidth bytes are soft-wrapped into several, so minified documents without
+// newlines are split into lines too. An End of zero reads to the last line.
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end,omitempty"`
+}
+
+// SearchRequest controls searching within the fetched document.
+type SearchRequest struct {
+	Query      string `json:"query"`
+	Mode       string `json:"mode,omitempty"`
+	MaxResults int    `json:"max_results,omitempty"`
+}
+
+// toSearchOptions converts the search request to search options.
+func (r *SearchRequest) toSearchOptions() search.Options {
+	return search.Options{Query: r.Query, Mode: r.Mode, MaxResults: r.MaxResults}
+}
+
+// toParserOptions converts the request parse options to parser options.
+func (o *ParseOptions) toParserOptions() parser.Options {
+	if o == nil {
+		return parser.Options{}
+	}
+	return parser.Options{Images: o.Images, Links: o.Links, TOC: o.TOC}
 }
 
 // Metadata contains metadata about the fetched content.
 type Metadata struct {
-	URL             string `json:"url"`
-	StatusCode      int    `json:"status_code"`
-	ContentType     string `json:"content_type"`
-	Language        string `json:"language,omitempty"`
-	Title           string `json:"title,omitempty"`
-	Description     string `json:"description,omitempty"`
-	FaviconURL      string `json:"favicon_url,omitempty"`
-	EstimatedTokens int    `json:"estimated_tokens"`
-	LastModified    string `json:"last_modified,omitempty"`
-	CacheState      string `json:"cache_state,omitempty"`
-	CachedAt        string `json:"cached_at,omitempty"`
+	URL               string `json:"url"`
+	StatusCode        int    `json:"status_code"`
+	ContentType       string `json:"content_type"`
+	Language          string `json:"language,omitempty"`
+	OriginalLanguage  string `json:"original_language,omitempty"`
+	Title             string `json:"title,omitempty"`
+	Description       string `json:"description,omitempty"`
+	FaviconURL        string `json:"favicon_url,omitempty"`
+	ImageURL          string `json:"image_url,omitempty"`
+	EstimatedTokens   int    `json:"estimated_tokens"`
+	LastModified      string `json:"last_modified,omitempty"`
+	CacheState        string `json:"cache_state,omitempty"`
+	CachedAt          string `json:"cached_at,omitempty"`
+	RefetchSuppressed bool   `json:"refetch_suppressed,omitempty"`
+	Region            string `json:"region,omitempty"`
+	// RateLimitWaitMs is how long the fetch waited on the site's rate limit, concurrency limit,
+	// or Retry-After, and RetryWaitMs how long it slept between retries, so slow fetches can be
+	// told apart from slow origins.
+	RateLimitWaitMs int64 `json:"rate_limit_wait_ms,omitempty"`
+	RetryWaitMs     int64 `json:"retry_wait_ms,omitempty"`
+	// Version numbers the page's distinct cached contents when the site keeps cache history.
+	Version int `json:"version,omitempty"`
+	// SeriesPages lists the pages joined into the content when pagination was followed.
+	SeriesPages []string `json:"series_pages,omitempty"`
+	// OriginalURL and ArchivedAt are set when the page was dead and URL is a Wayback Machine snapshot.
+	OriginalURL string `json:"original_url,omitempty"`
+	ArchivedAt  string `json:"archived_at,omitempty"`
+	// License is the declared license's name, such as "CC BY-SA 4.0", when known.
+	License    string   `json:"license,omitempty"`
+	LicenseURL string   `json:"license_url,omitempty"`
+	Robots     []string `json:"robots,omitempty"`
+	// AccessIssue is "paywall", "login_required", or "consent_wall" when the content is
+	// dominated by such a prompt rather than the page itself.
+	AccessIssue string `json:"access_issue,omitempty"`
+	// LeadImageURL and Excerpt are estimated from the article body, like a reader mode, so they
+	// reflect the content rather than social preview tags.
+	LeadImageURL string `json:"lead_image_url,omitempty"`
+	Excerpt      string `json:"excerpt,omitempty"`
+	// Alternates lists the page's language variants declared with hreflang links.
+	Alternates []hreflang.Alternate `json:"alternates,omitempty"`
+	// Processors lists the post-processing steps applied to the content, in order.
+	Processors []string `json:"processors,omitempty"`
+	// Binary is set when the body is binary data rather than text, and Encoding is "base64"
+	// when Content holds it base64-encoded.
+	Binary   bool   `json:"binary,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
 }
 
 // FetchResponse represents the response from a fetch request.
 type FetchResponse struct {
-	Metadata   Metadata         `json:"metadata"`
-	Content    string           `json:"content,omitempty"`
-	Outline    *outline.Outline `json:"outline,omitempty"`
-	Pagination *Pagination      `json:"pagination,omitempty"`
+	Metadata Metadata         `json:"metadata"`
+	Content  string           `json:"content,omitempty"`
+	Outline  *outline.Outline `json:"outline,omitempty"`
+	// Summary is an extractive summary of the content, set by the summarize processor.
+	Summary string `json:"summary,omitempty"`
+	// Structured holds FAQs and how-tos found in the page, as question/answer and step arrays.
+	Structured *structured.Data `json:"structured,omitempty"`
+	// Forms lists the HTML forms in the page, with their actions, methods, and fields.
+	Forms []forms.Form `json:"forms,omitempty"`
+	// Extracted holds the values matched by each of the request's selectors, in page order.
+	Extracted map[string][]string `json:"extracted,omitempty"`
+	// Raw is the body the content was parsed from, when include_raw is requested.
+	Raw *RawContent `json:"raw,omitempty"`
+	// Changelog holds the releases found in the page when the changelog profile is requested.
+	Changelog     []changelog.Entry `json:"changelog,omitempty"`
+	Pagination    *Pagination       `json:"pagination,omitempty"`
+	Lines         *LineInfo         `json:"lines,omitempty"`
+	SearchResults []SearchResult    `json:"search_results,omitempty"`
+	Debug         *DebugInfo        `json:"debug,omitempty"`
+	// Provenance signs the URL, content hash, and fetch time when a signing key is configured.
+	Provenance *provenance.Proof `json:"provenance,omitempty"`
+}
+
+// SearchResult is a passage of the document that matched the search query.
+type SearchResult struct {
+	search.Result
+	Section string `json:"section,omitempty"`
+	Anchor  string `json:"anchor,omitempty"`
+	// SectionPath is the section's breadcrumb path, such as "Guide > Setup > Linux", matching
+	// the path of its heading in the outline.
+	SectionPath string `json:"section_path,omitempty"`
+	// LineStart and LineEnd are the lines the passage spans, numbered like LineRange.
+	LineStart int `json:"line_start"`
+	LineEnd   int `json:"line_end"`
+}
+
+// DebugInfo contains diagnostic details included when debug is requested.
+type DebugInfo struct {
+	Attempts []Attempt `json:"attempts"`
+	Transfer *Transfer `json:"transfer,omitempty"`
+}
+
+// Attempt describes a single origin fetch attempt.
+type Attempt struct {
+	Timestamp  string `json:"timestamp"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	WaitMs     int64  `json:"wait_ms,omitempty"`
+	DelayMs    int64  `json:"delay_ms,omitempty"`
+}
+
+// Transfer describes how a response was transferred from origin.
+type Transfer struct {
+	Protocol         string `json:"protocol"`
+	TLSVersion       string `json:"tls_version,omitempty"`
+	RemoteIP         string `json:"remote_ip,omitempty"`
+	ContentEncoding  string `json:"content_encoding,omitempty"`
+	CompressedSize   int64  `json:"compressed_size"`
+	DecompressedSize int64  `json:"decompressed_size"`
 }
 
 // Pagination contains pagination information for the response.
@@ -59,11 +280,25 @@ type Pagination struct {
 	SuggestedNextOffset int  `json:"suggested_next_offset,omitempty"`
 }
 
+// LineInfo locates the content returned for a LineRange among the document's lines.
+type LineInfo struct {
+	Start      int `json:"start"`
+	End        int `json:"end"`
+	TotalLines int `json:"total_lines"`
+	// CharStart and CharEnd are the byte offsets of the returned lines in the document.
+	CharStart int `json:"char_start"`
+	CharEnd   int `json:"char_end"`
+}
+
 // ErrorResponse represents an error.
 type ErrorResponse struct {
-	Error      string            `json:"error"`
-	StatusCode int               `json:"status_code"`
-	Details    map[string]string `json:"details,omitempty"`
+	Error       string            `json:"error"`
+	StatusCode  int               `json:"status_code"`
+	ErrorCode   string            `json:"error_code"`
+	FailureType string            `json:"failure_type,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+	// Debug lists the origin fetch attempts made before a fetch failed, when debug was requested.
+	Debug *DebugInfo `json:"debug,omitempty"`
 }
 
 // handleFetch handles POST /v1/fetch requests.
@@ -79,7 +314,23 @@ func (s *Server) handleFetch(w http.ResponseWriter, r *http.Request) {
 
 	if err := s.validateRequest(&req); err != nil {
 		s.logger.Error("invalid request", "error", err)
-		s.sendError(w, err.Error(), http.StatusBadRequest)
+		s.sendValidationError(w, err)
+		return
+	}
+	req.delegated = r.Header.Get(delegatedHeader) != ""
+
+	if req.SessionID != "" && s.sessions == nil {
+		s.sendError(w, "sessions are not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	if req.TranslateTo != "" && s.translator == nil {
+		s.sendError(w, "translation is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	if req.CallbackURL != "" {
+		s.startAsyncFetch(w, r, &req)
 		return
 	}
 
@@ -87,8 +338,12 @@ func (s *Server) handleFetch(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := s.processFetch(ctx, &req)
 	if err != nil {
-		s.logger.Error("fetch failed", "url", req.URL, "error", err)
-		s.sendError(w, fmt.Sprintf("failed to fetch %s: %v", req.URL, err), http.StatusInternalServerError)
+		errResp := buildFetchError(req.URL, err)
+		if attempts := client.Attempts(err); req.Debug && len(attempts) > 0 {
+			errResp.Debug = &DebugInfo{Attempts: buildAttempts(attempts)}
+		}
+		s.logger.Error("fetch failed", "url", req.URL, "error", err, "failure_type", errResp.FailureType)
+		s.sendJSON(w, errResp, errResp.StatusCode)
 		return
 	}
 
@@ -99,12 +354,18 @@ func (s *Server) handleFetch(w http.ResponseWriter, r *http.Request) {
 	s.sendJSON(w, resp, http.StatusOK)
 }
 
-// processFetch handles the fetch request processing logic.
-func (s *Server) processFetch(ctx context.Context, req *FetchRequest) (*FetchResponse, error) {
-	fetched, err := s.client.Fetch(ctx, req.URL)
+// fetchLocal fetches the request from this instance and builds the response.
+func (s *Server) fetchLocal(ctx context.Context, req *FetchRequest) (*FetchResponse, error) {
+	fetched, err := s.client.FetchWithOptions(ctx, req.URL, req.fetchOptions())
 	if err != nil {
 		return nil, err
 	}
+	if req.session != nil {
+		s.recordSessionFetch(ctx, req, fetched)
+	}
+	if req.Language != "" {
+		fetched = s.selectLanguage(ctx, fetched, req)
+	}
 
 	var (
 		contentType  string
@@ -117,23 +378,288 @@ func (s *Server) processFetch(ctx context.Context, req *FetchRequest) (*FetchRes
 		lastModified = values[0]
 	}
 
+	// transforms describes, in order, how the returned content was derived from the origin
+	// body, for the provenance proof.
+	var transforms []string
+	if fetched.Rendered {
+		transforms = append(transforms, "render")
+	}
+
+	if fetched.Binary {
+		resp := buildBinaryResponse(fetched, contentType, lastModified, req.Base64)
+		if req.Base64 {
+			transforms = append(transforms, "base64")
+		}
+		s.finishResponse(resp, fetched, transforms)
+		return resp, nil
+	}
+
+	if req.SelectorsOnly {
+		resp := &FetchResponse{
+			Metadata:  buildFetchMetadata(fetched, contentType, "", lastModified, 0),
+			Extracted: fetched.Extracted,
+		}
+		s.finishResponse(resp, fetched, append(transforms, "selectors_only"))
+		return resp, nil
+	}
+
+	format := req.Format
+	if format == "" {
+		format = parser.FormatMarkdown
+	}
+	transforms = append(transforms, "parse:"+format)
+
 	var language string
 	if strings.Contains(strings.ToLower(contentType), "html") {
 		language = extractLanguage(fetched.Body)
 	}
 
 	workingBytes := fetched.Body
+	var seriesPages []string
+	if req.FollowPagination {
+		workingBytes, seriesPages = s.followPagination(ctx, fetched, req)
+		if len(seriesPages) > 0 {
+			transforms = append(transforms, fmt.Sprintf("join_pages:%d", len(seriesPages)))
+		}
+	}
 
-	if req.MaxTokens > 0 || req.Offset > 0 {
-		return s.buildPaginatedResponse(fetched, workingBytes, contentType, language, lastModified, req)
+	chain, err := s.contentProcessors(req)
+	if err != nil {
+		return nil, err
+	}
+	var processed *postprocess.Document
+	if len(chain) > 0 {
+		processed = &postprocess.Document{URL: fetched.URL, ContentType: contentType, Content: string(workingBytes), Language: language}
+		if err := chain.Run(ctx, processed); err != nil {
+			return nil, fmt.Errorf("failed to process content: %w", err)
+		}
+		workingBytes, language = []byte(processed.Content), processed.Language
+		for _, name := range chain.Names() {
+			transforms = append(transforms, "process:"+name)
+		}
+	}
+
+	tokenizer, err := s.tokenizerFor(req)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.buildFullResponse(fetched, workingBytes, contentType, language, lastModified)
+	var resp *FetchResponse
+	switch {
+	case req.Lines != nil:
+		resp, err = s.buildLinesResponse(fetched, workingBytes, contentType, language, lastModified, tokenizer, req.Lines)
+		transforms = append(transforms, fmt.Sprintf("lines:%d-%d", req.Lines.Start, req.Lines.End))
+	case req.MaxTokens > 0 || req.Offset > 0:
+		resp, err = s.buildPaginatedResponse(fetched, workingBytes, contentType, language, lastModified, tokenizer, req)
+		transforms = append(transforms, fmt.Sprintf("paginate:offset=%d,max_tokens=%d", req.Offset, req.MaxTokens))
+	default:
+		resp, err = s.buildFullResponse(fetched, workingBytes, contentType, language, lastModified, tokenizer)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Search != nil {
+		resp.SearchResults, err = buildSearchResults(workingBytes, contentType, req.Search, s.searchIndex(ctx, req.URL, workingBytes))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Debug {
+		resp.Debug = buildDebugInfo(fetched)
+	}
+	resp.Structured = fetched.Structured
+	resp.Forms = fetched.Forms
+	resp.Extracted = fetched.Extracted
+	if req.IncludeRaw && fetched.Raw != nil {
+		resp.Raw = s.buildRawContent(ctx, fetched, contentType)
+	}
+	if strings.Contains(strings.ToLower(contentType), "html") {
+		resp.Metadata.AccessIssue = access.Detect(fetched.Body)
+	}
+	if req.Profile == ProfileChangelog && strings.Contains(contentType, "markdown") {
+		resp.Changelog = changelog.Extract(workingBytes)
+	}
+	resp.Metadata.SeriesPages = seriesPages
+	if resp.Metadata.Language == "" {
+		resp.Metadata.Language = langdetect.Detect(resp.Content)
+	}
+	if processed != nil {
+		resp.Summary = processed.Summary
+		resp.Metadata.OriginalLanguage = processed.OriginalLanguage
+		resp.Metadata.Processors = chain.Names()
+	}
+
+	if req.TranslateTo != "" {
+		original := resp.Content
+		if err := s.translateResponse(ctx, resp, req.TranslateTo); err != nil {
+			return nil, err
+		}
+		if resp.Content != original {
+			transforms = append(transforms, "translate:"+req.TranslateTo)
+		}
+	}
+
+	s.finishResponse(resp, fetched, transforms)
+	return resp, nil
+}
+
+// finishResponse sets the serving region and fetch timings on a response, and signs its
+// content when a signing key is configured. Only content fetched from the origin is signed,
+// with transforms describing how it was derived from the origin body; imported entries and
+// Wayback Machine snapshots are not.
+func (s *Server) finishResponse(resp *FetchResponse, fetched *client.Response, transforms []string) {
+	resp.Metadata.Region = s.region
+	rateLimitWait, retryWait := retry.Waited(fetched.Attempts)
+	resp.Metadata.RateLimitWaitMs = rateLimitWait.Milliseconds()
+	resp.Metadata.RetryWaitMs = retryWait.Milliseconds()
+
+	if s.signer != nil && fetched.OriginSHA256 != "" && fetched.OriginalURL == "" {
+		fetchedAt := fetched.CachedAt
+		if fetchedAt.IsZero() {
+			fetchedAt = time.Now()
+		}
+		resp.Provenance = s.signer.Sign(fetched.URL, fetched.OriginSHA256, transforms, []byte(resp.Content), fetchedAt)
+	}
+}
+
+// buildBinaryResponse builds the response for a binary body, which is never parsed, paginated,
+// or processed: metadata only, or the body base64-encoded in Content when encode is set.
+func buildBinaryResponse(fetched *client.Response, contentType, lastModified string, encode bool) *FetchResponse {
+	resp := &FetchResponse{Metadata: buildFetchMetadata(fetched, contentType, "", lastModified, 0)}
+	resp.Metadata.Binary = true
+	if encode {
+		resp.Content = base64.StdEncoding.EncodeToString(fetched.Body)
+		resp.Metadata.Encoding = "base64"
+	}
+	return resp
+}
+
+// makeDeterministic clears the fields that vary between fetches of unchanged content: cache
+// state and timestamps, the serving region, rate limit and retry timings, and the provenance proof, which
+// signs the fetch time. Content, pagination, and outlines are already a pure function of the
+// document and tokenizer, and JSON keys are emitted in a fixed order.
+func (r *FetchResponse) makeDeterministic() {
+	r.Metadata.CacheState = ""
+	r.Metadata.CachedAt = ""
+	r.Metadata.RefetchSuppressed = false
+	r.Metadata.Region = ""
+	r.Metadata.RateLimitWaitMs = 0
+	r.Metadata.RetryWaitMs = 0
+	r.Provenance = nil
+	if r.Debug != nil {
+		for i := range r.Debug.Attempts {
+			r.Debug.Attempts[i].Timestamp = ""
+			r.Debug.Attempts[i].WaitMs = 0
+			r.Debug.Attempts[i].DelayMs = 0
+		}
+		r.Debug.Transfer = nil
+	}
+}
+
+// buildSearchResults searches the full document, through idx when it is not nil, and labels
+// each result with the markdown section it falls in, so results are located even when the
+// content is paginated.
+func buildSearchResults(workingBytes []byte, contentType string, req *SearchRequest, idx *search.Index) ([]SearchResult, error) {
+	var (
+		results []search.Result
+		err     error
+	)
+	if idx != nil {
+		results, err = idx.Search(workingBytes, req.toSearchOptions())
+	} else {
+		results, err = search.Search(workingBytes, req.toSearchOptions())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+
+	var headings []outline.Heading
+	if strings.Contains(contentType, "markdown") {
+		headings = outline.ExtractBytes(workingBytes, contentType).Headings
+	}
+	documentLines := lines.Split(string(workingBytes), lines.DefaultWidth)
+
+	searchResults := make([]SearchResult, 0, len(results))
+	for _, result := range results {
+		sr := SearchResult{
+			Result:    result,
+			LineStart: documentLines.Locate(result.CharStart),
+			LineEnd:   documentLines.Locate(max(result.CharEnd-1, result.CharStart)),
+		}
+		for _, h := range headings {
+			if h.CharStart > result.CharStart {
+				break
+			}
+			sr.Section = h.Text
+			sr.Anchor = h.Anchor
+			sr.SectionPath = h.Path
+		}
+		searchResults = append(searchResults, sr)
+	}
+	return searchResults, nil
+}
+
+// searchIndex returns the cached search index of a large document, building and caching it on
+// first use, or nil for small documents and when the cache is not configured. Indexes are keyed
+// by a hash of the document, so they are reused until the content changes.
+func (s *Server) searchIndex(ctx context.Context, pageURL string, content []byte) *search.Index {
+	responseCache := s.client.Cache()
+	if responseCache == nil || len(content) < minIndexedSearchSize {
+		return nil
+	}
+
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	cacheConfig := s.client.Config().GetConfigForURL(pageURL).Cache
+	expiration := cacheConfig.TTL + cacheConfig.StaleTime
+
+	data, err := responseCache.GetSearchIndex(ctx, hash, expiration)
+	if err != nil {
+		s.logger.Warn("failed to read search index", "url", pageURL, "error", err)
+	}
+	if data != nil {
+		if idx, err := search.UnmarshalIndex(data); err == nil {
+			return idx
+		}
+	}
+
+	idx := search.NewIndex(content)
+	if data, err = idx.Marshal(); err == nil {
+		err = responseCache.SetSearchIndex(ctx, hash, data, expiration)
+	}
+	if err != nil {
+		s.logger.Warn("failed to cache search index", "url", pageURL, "error", err)
+	}
+	return idx
+}
+
+// tokenizerFor returns the tokenizer requested by req, or the server's configured tokenizer.
+func (s *Server) tokenizerFor(req *FetchRequest) (content.Tokenizer, error) {
+	if req.Tokenizer == "" {
+		return s.tokenizer, nil
+	}
+	return content.NewTokenizer(req.Tokenizer)
+}
+
+// contentProcessors returns the post-processing chain of the request, or of the site when the
+// request does not set one.
+func (s *Server) contentProcessors(req *FetchRequest) (postprocess.Chain, error) {
+	specs := req.Processors
+	if specs == nil {
+		specs = s.client.Config().GetConfigForURL(req.URL).Processors
+	}
+	chain, err := postprocess.New(specs, s.translator)
+	if errors.Is(err, postprocess.ErrNoTranslator) {
+		return nil, errTranslationNotConfigured
+	}
+	return chain, err
 }
 
 // buildPaginatedResponse builds a response with pagination for offset/max_tokens requests.
-func (s *Server) buildPaginatedResponse(fetched *client.Response, workingBytes []byte, contentType, language, lastModified string, req *FetchRequest) (*FetchResponse, error) {
-	totalTokens := content.EstimateTokens(workingBytes, contentType)
+func (s *Server) buildPaginatedResponse(fetched *client.Response, workingBytes []byte, contentType, language, lastModified string, tokenizer content.Tokenizer, req *FetchRequest) (*FetchResponse, error) {
+	totalTokens := tokenizer.Count(workingBytes, contentType)
 
 	maxTokens := req.MaxTokens
 	if maxTokens == 0 {
@@ -154,18 +680,14 @@ func (s *Server) buildPaginatedResponse(fetched *client.Response, workingBytes [
 		}, nil
 	}
 
-	charsPerToken := float64(len(workingBytes)) / float64(totalTokens)
-	charOffsetFloat := float64(req.Offset) * charsPerToken
-
-	if charOffsetFloat >= float64(len(workingBytes)) || charOffsetFloat < 0 {
+	charOffset := tokenizer.BytesForTokens(workingBytes, contentType, req.Offset)
+	if req.Offset >= totalTokens || charOffset >= len(workingBytes) {
 		return nil, fmt.Errorf("offset %d exceeds content length (total tokens: %d)", req.Offset, totalTokens)
 	}
 
-	charOffset := int(charOffsetFloat)
-
 	contentFromOffset := workingBytes[charOffset:]
 
-	truncation := content.Truncate(contentFromOffset, contentType, maxTokens)
+	truncation := content.TruncateWith(tokenizer, contentFromOffset, contentType, maxTokens)
 
 	metadata := buildFetchMetadata(fetched, contentType, language, lastModified, truncation.ReturnedTokens)
 
@@ -196,9 +718,48 @@ func (s *Server) buildPaginatedResponse(fetched *client.Response, workingBytes [
 	}, nil
 }
 
+// buildLinesResponse builds a response with the lines of the content selected by lineRange.
+func (s *Server) buildLinesResponse(fetched *client.Response, workingBytes []byte, contentType, language, lastModified string, tokenizer content.Tokenizer, lineRange *LineRange) (*FetchResponse, error) {
+	documentLines := lines.Split(string(workingBytes), lines.DefaultWidth)
+
+	last := lineRange.End
+	if last == 0 {
+		last = len(documentLines)
+	}
+	start, end, ok := documentLines.Range(lineRange.Start, last)
+	if !ok {
+		return nil, fmt.Errorf("lines %d-%d are out of range (total lines: %d)", lineRange.Start, last, len(documentLines))
+	}
+
+	selected := workingBytes[start:end]
+	tokens := tokenizer.Count(selected, contentType)
+	if limit := s.limits.MaxTokens; limit > 0 && tokens > limit {
+		return nil, fmt.Errorf("%w: lines %d-%d are %d tokens, over %d", errTokenLimit, lineRange.Start, last, tokens, limit)
+	}
+	metadata := buildFetchMetadata(fetched, contentType, language, lastModified, tokens)
+
+	var documentOutline *outline.Outline
+	if lineRange.Start == 1 && strings.Contains(contentType, "markdown") {
+		documentOutline = outline.ExtractBytes(workingBytes, contentType)
+	}
+
+	return &FetchResponse{
+		Metadata: metadata,
+		Content:  string(selected),
+		Outline:  documentOutline,
+		Lines: &LineInfo{
+			Start:      lineRange.Start,
+			End:        min(last, len(documentLines)),
+			TotalLines: len(documentLines),
+			CharStart:  start,
+			CharEnd:    end,
+		},
+	}, nil
+}
+
 // buildFullResponse builds a response with full content (no pagination).
-func (s *Server) buildFullResponse(fetched *client.Response, workingBytes []byte, contentType, language, lastModified string) (*FetchResponse, error) {
-	estimatedTokens := content.EstimateTokens(workingBytes, contentType)
+func (s *Server) buildFullResponse(fetched *client.Response, workingBytes []byte, contentType, language, lastModified string, tokenizer content.Tokenizer) (*FetchResponse, error) {
+	estimatedTokens := tokenizer.Count(workingBytes, contentType)
 	metadata := buildFetchMetadata(fetched, contentType, language, lastModified, estimatedTokens)
 
 	var documentOutline *outline.Outline
@@ -213,6 +774,15 @@ func (s *Server) buildFullResponse(fetched *client.Response, workingBytes []byte
 	}, nil
 }
 
+// validationStatus returns the status code for a request validation error: 403 when the
+// domain policy forbids the URL, 400 otherwise.
+func validationStatus(err error) int {
+	if errors.Is(err, policy.ErrViolation) {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
+
 // validateRequest validates the fetch request.
 func (s *Server) validateRequest(req *FetchRequest) error {
 	if req == nil {
@@ -223,17 +793,321 @@ func (s *Server) validateRequest(req *FetchRequest) error {
 		return err
 	}
 
+	if err := s.policy.Check(req.URL); err != nil {
+		return err
+	}
+
 	if req.MaxTokens < 0 {
 		return fmt.Errorf("max_tokens must be non-negative")
 	}
 
+	if limit := s.limits.MaxTokens; limit > 0 && req.MaxTokens > limit {
+		return fmt.Errorf("max_tokens must be at most %d", limit)
+	}
+
 	if req.Offset < 0 {
 		return fmt.Errorf("offset must be non-negative")
 	}
 
+	if req.Lines != nil {
+		switch {
+		case req.Lines.Start < 1:
+			return fmt.Errorf("lines.start must be at least 1")
+		case req.Lines.End != 0 && req.Lines.End < req.Lines.Start:
+			return fmt.Errorf("lines.end must not be before lines.start")
+		case req.MaxTokens > 0 || req.Offset > 0:
+			return fmt.Errorf("lines cannot be combined with max_tokens or offset")
+		}
+	}
+
+	if req.MaxTokens == 0 && req.Lines == nil {
+		req.MaxTokens = s.limits.GetDefaultMaxTokens()
+	}
+
+	if err := req.ParseOptions.toParserOptions().Validate(); err != nil {
+		return fmt.Errorf("invalid parse_options: %w", err)
+	}
+
+	switch req.Format {
+	case "", parser.FormatMarkdown, parser.FormatRawHTML, parser.FormatText:
+	default:
+		return fmt.Errorf("format must be one of %q, %q, %q", parser.FormatMarkdown, parser.FormatRawHTML, parser.FormatText)
+	}
+
+	if req.Tokenizer != "" {
+		if _, err := content.NewTokenizer(req.Tokenizer); err != nil {
+			return err
+		}
+	}
+
+	if req.Search != nil {
+		if err := req.Search.toSearchOptions().Validate(); err != nil {
+			return fmt.Errorf("invalid search: %w", err)
+		}
+	}
+
+	if err := validateRequestOverrides(req.Headers, req.Cookies); err != nil {
+		return err
+	}
+
+	if req.MaxPages < 0 || req.MaxPages > maxSeriesPages {
+		return fmt.Errorf("max_pages must be between 1 and %d", maxSeriesPages)
+	}
+
+	if req.CallbackURL != "" {
+		if _, err := urlpkg.ValidateExternal(req.CallbackURL); err != nil {
+			return fmt.Errorf("invalid callback_url: %w", err)
+		}
+	}
+
+	if err := req.validateVersion(); err != nil {
+		return err
+	}
+
+	if req.Profile != "" && req.Profile != ProfileChangelog {
+		return fmt.Errorf("profile must be %q", ProfileChangelog)
+	}
+
+	if req.Language != "" {
+		if _, err := language.Parse(req.Language); err != nil {
+			return fmt.Errorf("invalid language: must be a BCP 47 tag such as \"de\" or \"pt-BR\"")
+		}
+	}
+
+	if req.TranslateTo != "" {
+		if _, err := language.Parse(req.TranslateTo); err != nil {
+			return fmt.Errorf("invalid translate_to: must be a language code such as \"en\"")
+		}
+	}
+
+	if err := postprocess.Validate(req.Processors); err != nil {
+		return err
+	}
+
+	if err := req.validateSelectors(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateSelectors validates the selectors and selectors_only fields.
+func (r *FetchRequest) validateSelectors() error {
+	if len(r.Selectors) > maxSelectors {
+		return fmt.Errorf("selectors must have at most %d entries", maxSelectors)
+	}
+	for name, query := range r.Selectors {
+		if name == "" {
+			return fmt.Errorf("selector names cannot be empty")
+		}
+		if _, err := selector.ParseQuery(query); err != nil {
+			return fmt.Errorf("invalid selectors.%s: %w", name, err)
+		}
+	}
+	if r.SelectorsOnly && len(r.Selectors) == 0 {
+		return fmt.Errorf("selectors_only requires selectors")
+	}
+	return nil
+}
+
+// validateVersion validates the version and as_of fields, which read the cache history and
+// so cannot be combined with options that fetch from origin.
+func (r *FetchRequest) validateVersion() error {
+	if r.Version < 0 {
+		return fmt.Errorf("version must be non-negative")
+	}
+
+	if r.AsOf != "" {
+		if _, err := time.Parse(time.RFC3339, r.AsOf); err != nil {
+			return fmt.Errorf("invalid as_of: must be an RFC 3339 time")
+		}
+	}
+
+	if r.Version == 0 && r.AsOf == "" {
+		return nil
+	}
+	if r.Version > 0 && r.AsOf != "" {
+		return fmt.Errorf("version and as_of cannot be combined")
+	}
+	if r.BypassCache || r.FollowPagination || len(r.Headers) > 0 || len(r.Cookies) > 0 || r.SessionID != "" || len(r.Selectors) > 0 {
+		return fmt.Errorf("version and as_of cannot be combined with bypass_cache, follow_pagination, headers, cookies, session_id, or selectors")
+	}
 	return nil
 }
 
+// StatsResponse represents the response from a stats request.
+type StatsResponse struct {
+	ActiveDomains  int           `json:"active_domains"`
+	PausedDomains  []DomainPause `json:"paused_domains"`
+	ProbingDomains []DomainProbe `json:"probing_domains"`
+	ErrorPauses    int64         `json:"error_pauses_total"`
+	Cluster        *ClusterStats `json:"cluster,omitempty"`
+	Cache          *CacheStats   `json:"cache,omitempty"`
+	Headless       HeadlessStats `json:"headless"`
+}
+
+// HeadlessStats describes the pool of browser contexts headless renders run in.
+type HeadlessStats struct {
+	PoolSize int   `json:"pool_size"`
+	Warm     int   `json:"warm"`
+	Active   int   `json:"active"`
+	Waiting  int   `json:"waiting"`
+	Renders  int64 `json:"renders_total"`
+	Failures int64 `json:"failures_total"`
+	Recycled int64 `json:"recycled_total"`
+}
+
+// CacheStats describes how much Redis memory body deduplication saves.
+type CacheStats struct {
+	DedupBodies      int   `json:"dedup_bodies"`
+	DedupReferences  int64 `json:"dedup_references"`
+	DedupStoredBytes int64 `json:"dedup_stored_bytes"`
+	DedupSavedBytes  int64 `json:"dedup_saved_bytes"`
+}
+
+// ClusterStats describes this instance's role in the cluster.
+type ClusterStats struct {
+	InstanceID string `json:"instance_id"`
+	Leader     bool   `json:"leader"`
+	LeaderID   string `json:"leader_id,omitempty"`
+}
+
+// DomainPause describes a domain whose traffic is temporarily paused.
+type DomainPause struct {
+	Domain    string  `json:"domain"`
+	Until     string  `json:"until"`
+	Reason    string  `json:"reason"`
+	URLs      int     `json:"urls,omitempty"`
+	ErrorRate float64 `json:"error_rate,omitempty"`
+}
+
+// DomainProbe describes a domain resuming after an error pause, one probe request at a time.
+type DomainProbe struct {
+	Domain    string `json:"domain"`
+	Successes int    `json:"successes"`
+	Required  int    `json:"required"`
+}
+
+// handleStats handles GET /v1/stats requests.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	stats := s.client.Stats()
+
+	resp := StatsResponse{
+		ActiveDomains:  stats.RateLimit.ActiveDomains,
+		PausedDomains:  make([]DomainPause, 0, len(stats.RateLimit.PausedDomains)),
+		ProbingDomains: make([]DomainProbe, 0, len(stats.RateLimit.ProbingDomains)),
+		ErrorPauses:    stats.RateLimit.ErrorPauses,
+		Headless: HeadlessStats{
+			PoolSize: stats.Headless.Size,
+			Warm:     stats.Headless.Warm,
+			Active:   stats.Headless.Active,
+			Waiting:  stats.Headless.Waiting,
+			Renders:  stats.Headless.Renders,
+			Failures: stats.Headless.Failures,
+			Recycled: stats.Headless.Recycled,
+		},
+	}
+	for _, pause := range stats.RateLimit.PausedDomains {
+		resp.PausedDomains = append(resp.PausedDomains, DomainPause{
+			Domain:    pause.Domain,
+			Until:     pause.Until.UTC().Format(time.RFC3339),
+			Reason:    pause.Reason,
+			URLs:      pause.URLs,
+			ErrorRate: pause.ErrorRate,
+		})
+	}
+	for _, probe := range stats.RateLimit.ProbingDomains {
+		resp.ProbingDomains = append(resp.ProbingDomains, DomainProbe(probe))
+	}
+
+	if s.elector != nil {
+		resp.Cluster = &ClusterStats{
+			InstanceID: s.elector.InstanceID(),
+			Leader:     s.elector.IsLeader(),
+		}
+		if leaderID, err := s.elector.Leader(r.Context()); err == nil {
+			resp.Cluster.LeaderID = leaderID
+		} else {
+			s.logger.Warn("failed to look up cluster leader", "error", err)
+		}
+	}
+
+	if responseCache := s.client.Cache(); responseCache != nil {
+		if dedup, err := responseCache.DedupStats(r.Context()); err == nil {
+			resp.Cache = &CacheStats{
+				DedupBodies:      dedup.Bodies,
+				DedupReferences:  dedup.References,
+				DedupStoredBytes: dedup.StoredBytes,
+				DedupSavedBytes:  dedup.SavedBytes,
+			}
+		} else {
+			s.logger.Warn("failed to compute cache dedup stats", "error", err)
+		}
+	}
+
+	s.sendJSON(w, resp, http.StatusOK)
+}
+
+// QueueResponse represents the response from a queue request.
+type QueueResponse struct {
+	Waiting  int           `json:"waiting"`
+	InFlight int           `json:"in_flight"`
+	Domains  []DomainQueue `json:"domains"`
+}
+
+// DomainQueue lists the fetches waiting on and in flight to a domain.
+type DomainQueue struct {
+	Domain   string        `json:"domain"`
+	Waiting  []QueuedFetch `json:"waiting"`
+	InFlight []QueuedFetch `json:"in_flight"`
+}
+
+// QueuedFetch describes a fetch waiting on a domain's rate limits or in flight to it.
+type QueuedFetch struct {
+	URL string `json:"url"`
+	// Caller is who the fetch is made for: a configured caller's name, an API key's hash
+	// prefix ("key:..."), or a client IP ("ip:...").
+	Caller     string `json:"caller,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	Since      string `json:"since"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// handleQueue handles GET /v1/queue requests.
+func (s *Server) handleQueue(w http.ResponseWriter, r *http.Request) {
+	queues := s.client.Queue()
+	now := time.Now()
+
+	resp := QueueResponse{Domains: make([]DomainQueue, 0, len(queues))}
+	for _, q := range queues {
+		dq := DomainQueue{
+			Domain:   q.Domain,
+			Waiting:  buildQueuedFetches(q.Waiting, now),
+			InFlight: buildQueuedFetches(q.InFlight, now),
+		}
+		resp.Waiting += len(dq.Waiting)
+		resp.InFlight += len(dq.InFlight)
+		resp.Domains = append(resp.Domains, dq)
+	}
+
+	s.sendJSON(w, resp, http.StatusOK)
+}
+
+// buildQueuedFetches converts limiter queue entries to their response form.
+func buildQueuedFetches(reqs []ratelimit.QueuedRequest, now time.Time) []QueuedFetch {
+	fetches := make([]QueuedFetch, 0, len(reqs))
+	for _, req := range reqs {
+		fetches = append(fetches, QueuedFetch{
+			URL:        req.URL,
+			Caller:     req.Caller,
+			Reason:     req.Reason,
+			Since:      req.Since.UTC().Format(time.RFC3339),
+			DurationMs: now.Sub(req.Since).Milliseconds(),
+		})
+	}
+	return fetches
+}
+
 // handleHealth handles GET /health requests.
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	health := map[string]string{
@@ -243,6 +1117,27 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	s.sendJSON(w, health, http.StatusOK)
 }
 
+// ProvenanceKeyResponse describes the public key that verifies provenance proofs.
+type ProvenanceKeyResponse struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`
+	PublicKey string `json:"public_key"`
+}
+
+// handleProvenanceKey handles GET /v1/provenance/key requests.
+func (s *Server) handleProvenanceKey(w http.ResponseWriter, r *http.Request) {
+	if s.signer == nil {
+		s.sendError(w, "response signing is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	s.sendJSON(w, ProvenanceKeyResponse{
+		Algorithm: provenance.Algorithm,
+		KeyID:     s.signer.KeyID(),
+		PublicKey: s.signer.PublicKey(),
+	}, http.StatusOK)
+}
+
 // sendJSON sends a JSON response.
 func (s *Server) sendJSON(w http.ResponseWriter, data interface{}, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
@@ -255,15 +1150,91 @@ func (s *Server) sendJSON(w http.ResponseWriter, data interface{}, statusCode in
 	}
 }
 
-// sendError sends an error response.
+// sendError sends an error response, with the error code for its status code.
 func (s *Server) sendError(w http.ResponseWriter, message string, statusCode int) {
+	s.sendErrorCode(w, message, statusCode, statusErrorCode(statusCode))
+}
+
+// sendErrorCode sends an error response with the given error code.
+func (s *Server) sendErrorCode(w http.ResponseWriter, message string, statusCode int, code string) {
 	errResp := ErrorResponse{
 		Error:      message,
 		StatusCode: statusCode,
+		ErrorCode:  code,
 	}
 	s.sendJSON(w, errResp, statusCode)
 }
 
+// sendValidationError sends the error response for a request validation error.
+func (s *Server) sendValidationError(w http.ResponseWriter, err error) {
+	s.sendErrorCode(w, err.Error(), validationStatus(err), validationErrorCode(err))
+}
+
+// buildDebugInfo builds the debug details for a fetched response.
+func buildDebugInfo(resp *client.Response) *DebugInfo {
+	info := &DebugInfo{Attempts: buildAttempts(resp.Attempts)}
+	if t := resp.Transfer; t != nil {
+		info.Transfer = &Transfer{
+			Protocol:         t.Protocol,
+			TLSVersion:       t.TLSVersion,
+			RemoteIP:         t.RemoteIP,
+			ContentEncoding:  t.ContentEncoding,
+			CompressedSize:   t.CompressedSize,
+			DecompressedSize: t.DecompressedSize,
+		}
+	}
+	return info
+}
+
+// buildAttempts converts origin fetch attempts to their API representation.
+func buildAttempts(attempts []retry.Attempt) []Attempt {
+	result := make([]Attempt, 0, len(attempts))
+	for _, attempt := range attempts {
+		result = append(result, Attempt{
+			Timestamp:  attempt.Time.UTC().Format(time.RFC3339Nano),
+			StatusCode: attempt.StatusCode,
+			Error:      attempt.Error,
+			WaitMs:     attempt.Wait.Milliseconds(),
+			DelayMs:    attempt.Delay.Milliseconds(),
+		})
+	}
+	return result
+}
+
+// buildFetchError builds the error response for a failed fetch, classifying connection failures.
+func buildFetchError(urlStr string, err error) *ErrorResponse {
+	return &ErrorResponse{
+		Error:       fmt.Sprintf("failed to fetch %s: %v", urlStr, err),
+		StatusCode:  fetchErrorStatus(err),
+		ErrorCode:   fetchErrorCode(err),
+		FailureType: fetcher.ClassifyError(err),
+	}
+}
+
+// fetchErrorStatus maps a fetch error to the HTTP status code returned to the caller.
+func fetchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, budget.ErrBudgetExhausted):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ratelimit.ErrDomainPaused):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, robots.ErrDisallowed):
+		return http.StatusForbidden
+	case errors.Is(err, client.ErrVersionNotFound), errors.Is(err, session.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, session.ErrExhausted):
+		return http.StatusTooManyRequests
+	case errors.Is(err, fetcher.ErrBodyTooLarge), errors.Is(err, errTokenLimit):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, errUnsupportedType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, errTranslationNotConfigured):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // extractLanguage extracts the language from the HTML content.
 func extractLanguage(htmlContent []byte) string {
 	matches := langRegex.FindSubmatch(htmlContent)
@@ -280,21 +1251,35 @@ func extractLanguage(htmlContent []byte) string {
 // buildFetchMetadata builds the fetch metadata.
 func buildFetchMetadata(resp *client.Response, contentType, language, lastModified string, tokens int) Metadata {
 	metadata := Metadata{
-		URL:             resp.URL,
-		StatusCode:      resp.StatusCode,
-		ContentType:     contentType,
-		Language:        language,
-		Title:           resp.Title,
-		Description:     resp.Description,
-		FaviconURL:      resp.FaviconURL,
-		EstimatedTokens: tokens,
-		LastModified:    lastModified,
-		CacheState:      resp.CacheState,
+		URL:               resp.URL,
+		StatusCode:        resp.StatusCode,
+		ContentType:       contentType,
+		Language:          language,
+		Title:             resp.Title,
+		Description:       resp.Description,
+		FaviconURL:        resp.FaviconURL,
+		ImageURL:          resp.ImageURL,
+		LeadImageURL:      resp.LeadImageURL,
+		Excerpt:           resp.Excerpt,
+		License:           resp.License,
+		LicenseURL:        resp.LicenseURL,
+		Robots:            resp.Robots,
+		Alternates:        resp.Alternates,
+		EstimatedTokens:   tokens,
+		LastModified:      lastModified,
+		CacheState:        resp.CacheState,
+		RefetchSuppressed: resp.RefetchSuppressed,
+		Version:           resp.Version,
 	}
 
 	if !resp.CachedAt.IsZero() {
 		metadata.CachedAt = resp.CachedAt.Format(time.RFC3339Nano)
 	}
 
+	if resp.OriginalURL != "" {
+		metadata.OriginalURL = resp.OriginalURL
+		metadata.ArchivedAt = resp.ArchivedAt.UTC().Format(time.RFC3339)
+	}
+
 	return metadata
 }
@@ -2,12 +2,39 @@ package server
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/joeychilson/websurfer/access"
+	"github.com/joeychilson/websurfer/budget"
+	"github.com/joeychilson/websurfer/cache"
 	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/config"
+	"github.com/joeychilson/websurfer/fetcher"
+	"github.com/joeychilson/websurfer/headless"
+	"github.com/joeychilson/websurfer/hreflang"
+	"github.com/joeychilson/websurfer/lines"
+	"github.com/joeychilson/websurfer/policy"
+	"github.com/joeychilson/websurfer/postprocess"
+	"github.com/joeychilson/websurfer/provenance"
+	"github.com/joeychilson/websurfer/ratelimit"
+	"github.com/joeychilson/websurfer/retry"
+	"github.com/joeychilson/websurfer/robots"
+	"github.com/joeychilson/websurfer/webhook"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -91,6 +118,138 @@ func TestValidateRequestNegativeOffset(t *testing.T) {
 	assert.Contains(t, err.Error(), "offset")
 }
 
+// TestValidateRequestInvalidParseOptions verifies unknown parse option values are rejected.
+func TestValidateRequestInvalidParseOptions(t *testing.T) {
+	c, _ := client.New(nil)
+	defer c.Close()
+	s, _ := New(c, nil, nil)
+
+	req := &FetchRequest{
+		URL:          "https://example.com",
+		ParseOptions: &ParseOptions{Images: "embedded"},
+	}
+
+	err := s.validateRequest(req)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "parse_options")
+
+	req.ParseOptions = &ParseOptions{Images: "appendix", Links: "references"}
+	assert.NoError(t, s.validateRequest(req))
+}
+
+// TestValidateRequestInvalidFormat verifies unknown output formats are rejected.
+func TestValidateRequestInvalidFormat(t *testing.T) {
+	c, _ := client.New(nil)
+	defer c.Close()
+	s, _ := New(c, nil, nil)
+
+	req := &FetchRequest{URL: "https://example.com", Format: "pdf"}
+	err := s.validateRequest(req)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "format")
+
+	for _, format := range []string{"markdown", "raw_html", "text"} {
+		req.Format = format
+		assert.NoError(t, s.validateRequest(req), format)
+	}
+}
+
+// TestValidateRequestInvalidTokenizer verifies unknown tokenizers are rejected.
+func TestValidateRequestInvalidTokenizer(t *testing.T) {
+	c, _ := client.New(nil)
+	defer c.Close()
+	s, _ := New(c, nil, nil)
+
+	req := &FetchRequest{URL: "https://example.com", Tokenizer: "gpt2"}
+
+	err := s.validateRequest(req)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown tokenizer")
+
+	req.Tokenizer = "o200k_base"
+	assert.NoError(t, s.validateRequest(req))
+}
+
+// TestValidateRequestInvalidSearch verifies invalid search options are rejected.
+func TestValidateRequestInvalidSearch(t *testing.T) {
+	c, _ := client.New(nil)
+	defer c.Close()
+	s, _ := New(c, nil, nil)
+
+	req := &FetchRequest{URL: "https://example.com", Search: &SearchRequest{Query: "(", Mode: "regex"}}
+
+	err := s.validateRequest(req)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid search")
+
+	req.Search.Mode = "semantic"
+	assert.Error(t, s.validateRequest(req))
+
+	req.Search = &SearchRequest{Query: "retry", Mode: "fuzzy"}
+	assert.NoError(t, s.validateRequest(req))
+}
+
+// TestValidateRequestInvalidProfile verifies unknown profiles are rejected.
+func TestValidateRequestInvalidProfile(t *testing.T) {
+	c, _ := client.New(nil)
+	defer c.Close()
+	s, _ := New(c, nil, nil)
+
+	req := &FetchRequest{URL: "https://example.com", Profile: "recipe"}
+
+	err := s.validateRequest(req)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "profile")
+
+	req.Profile = ProfileChangelog
+	assert.NoError(t, s.validateRequest(req))
+}
+
+// TestBuildSearchResultsSections verifies search results are labeled with their markdown section.
+func TestBuildSearchResultsSections(t *testing.T) {
+	doc := []byte("# Intro\n\nWelcome.\n\n## Retry Policy\n\nRequests are retried with backoff.\n")
+
+	results, err := buildSearchResults(doc, "text/markdown", &SearchRequest{Query: "backoff"}, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	assert.Equal(t, "Retry Policy", results[0].Section)
+	assert.Equal(t, "retry-policy", results[0].Anchor)
+	assert.Equal(t, "Intro > Retry Policy", results[0].SectionPath)
+	assert.Equal(t, "backoff", results[0].Matches[0].Text)
+
+	results, err = buildSearchResults(doc, "text/plain", &SearchRequest{Query: "backoff"}, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Empty(t, results[0].Section)
+}
+
+// TestSearchIndexCached verifies large documents are searched through an index cached by
+// content, and small ones directly.
+func TestSearchIndexCached(t *testing.T) {
+	ctx := context.Background()
+	s, responseCache := newCacheTestServer(t)
+
+	assert.Nil(t, s.searchIndex(ctx, "https://example.com/", []byte("small document")))
+
+	doc := []byte(strings.Repeat("Filler paragraph about nothing in particular.\n\n", 2000) + "## Retry Policy\n\nRequests are retried with backoff.\n")
+	idx := s.searchIndex(ctx, "https://example.com/", doc)
+	require.NotNil(t, idx)
+
+	sum := sha256.Sum256(doc)
+	data, err := responseCache.GetSearchIndex(ctx, hex.EncodeToString(sum[:]), 0)
+	require.NoError(t, err)
+	require.NotNil(t, data, "the index is cached by content hash")
+
+	cached := s.searchIndex(ctx, "https://example.com/other", doc)
+	assert.Equal(t, idx, cached)
+
+	results, err := buildSearchResults(doc, "text/markdown", &SearchRequest{Query: "backoff"}, cached)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Retry Policy", results[0].Section)
+}
+
 // TestHandleHealthEndpoint verifies /health endpoint works.
 func TestHandleHealthEndpoint(t *testing.T) {
 	c, err := client.New(nil)
@@ -117,6 +276,146 @@ func TestHandleHealthEndpoint(t *testing.T) {
 	assert.NotEmpty(t, health["time"])
 }
 
+// TestHandleStatsEndpoint verifies /v1/stats reports limiter and headless pool state.
+func TestHandleStatsEndpoint(t *testing.T) {
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/v1/stats", nil)
+	w := httptest.NewRecorder()
+
+	s.Router().ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var stats StatsResponse
+	err = json.NewDecoder(w.Body).Decode(&stats)
+	require.NoError(t, err)
+	assert.NotNil(t, stats.PausedDomains)
+	assert.Empty(t, stats.PausedDomains)
+	assert.Equal(t, headless.DefaultPoolSize, stats.Headless.PoolSize)
+	assert.Zero(t, stats.Headless.Active)
+}
+
+// TestHandleQueueEndpoint verifies /v1/queue lists fetches in flight to each domain.
+func TestHandleQueueEndpoint(t *testing.T) {
+	unblock := make(chan struct{})
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+		w.Write([]byte("ok"))
+	}))
+	defer origin.Close()
+
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.processFetch(context.Background(), &FetchRequest{URL: origin.URL + "/slow", BypassCache: true})
+	}()
+
+	var queue QueueResponse
+	require.Eventually(t, func() bool {
+		w := httptest.NewRecorder()
+		s.Router().ServeHTTP(w, httptest.NewRequest("GET", "/v1/queue", nil))
+		if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&queue) != nil {
+			return false
+		}
+		return queue.InFlight == 1
+	}, time.Second, 10*time.Millisecond)
+
+	require.Len(t, queue.Domains, 1)
+	assert.Equal(t, origin.URL+"/slow", queue.Domains[0].InFlight[0].URL)
+	assert.Empty(t, queue.Domains[0].Waiting)
+
+	close(unblock)
+	<-done
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest("GET", "/v1/queue", nil))
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&queue))
+	assert.Zero(t, queue.InFlight)
+	assert.NotNil(t, queue.Domains)
+	assert.Empty(t, queue.Domains)
+}
+
+// TestHandleStatsClusterMode verifies /v1/stats reports the instance's cluster role.
+func TestHandleStatsClusterMode(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := New(c, nil, &ServerConfig{RedisClient: redisClient, ClusterMode: true, InstanceID: "node-1"})
+	require.NoError(t, err)
+	defer s.Close()
+
+	assert.Eventually(t, s.elector.IsLeader, time.Second, 10*time.Millisecond)
+
+	req := httptest.NewRequest("GET", "/v1/stats", nil)
+	w := httptest.NewRecorder()
+
+	s.Router().ServeHTTP(w, req)
+
+	var stats StatsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&stats))
+	require.NotNil(t, stats.Cluster)
+	assert.Equal(t, "node-1", stats.Cluster.InstanceID)
+	assert.True(t, stats.Cluster.Leader)
+	assert.Equal(t, "node-1", stats.Cluster.LeaderID)
+}
+
+// TestHandleStatsCacheDedup verifies /v1/stats reports the memory saved by shared cached bodies.
+func TestHandleStatsCacheDedup(t *testing.T) {
+	mr := miniredis.RunT(t)
+	responseCache := cache.New(redis.NewClient(&redis.Options{Addr: mr.Addr()}), cache.Config{EnableDeduplication: true})
+
+	ctx := context.Background()
+	body := []byte("<html><body>Mirrored docs</body></html>")
+	for _, u := range []string{"https://docs.example.com/intro", "https://mirror.example.org/intro"} {
+		require.NoError(t, responseCache.Set(ctx, &cache.Entry{URL: u, StatusCode: 200, Body: body, StoredAt: time.Now()}))
+	}
+
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+	c.WithCache(responseCache)
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest("GET", "/v1/stats", nil))
+
+	var stats StatsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&stats))
+	require.NotNil(t, stats.Cache)
+	assert.Equal(t, 1, stats.Cache.DedupBodies)
+	assert.Equal(t, int64(2), stats.Cache.DedupReferences)
+	assert.Equal(t, int64(len(body)), stats.Cache.DedupSavedBytes)
+}
+
+// TestServerClusterModeRequiresRedis verifies cluster mode is rejected without Redis.
+func TestServerClusterModeRequiresRedis(t *testing.T) {
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = New(c, nil, &ServerConfig{ClusterMode: true})
+	assert.ErrorContains(t, err, "requires a redis client")
+}
+
 // TestHandleFetchInvalidJSON verifies invalid JSON is rejected.
 func TestHandleFetchInvalidJSON(t *testing.T) {
 	c, err := client.New(nil)
@@ -167,6 +466,32 @@ func TestHandleFetchInvalidURL(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+// TestDomainPolicy verifies fetch, map, and crawl requests for forbidden domains are rejected with 403.
+func TestDomainPolicy(t *testing.T) {
+	cfg := config.New()
+	cfg.Policy = config.PolicyConfig{AllowedDomains: []string{"example.com"}, BlockedTLDs: []string{"zip"}}
+
+	c, err := client.New(cfg)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+
+	for _, path := range []string{"/v1/fetch", "/v1/map"} {
+		for _, target := range []string{"https://other.org", "https://files.zip"} {
+			body, _ := json.Marshal(map[string]string{"url": target})
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest("POST", path, bytes.NewReader(body)))
+			assert.Equal(t, http.StatusForbidden, w.Code, "%s %s", path, target)
+			assert.Contains(t, w.Body.String(), "blocked by domain policy")
+		}
+	}
+
+	assert.NoError(t, s.validateRequest(&FetchRequest{URL: "https://docs.example.com/page"}))
+	assert.ErrorIs(t, s.validateCrawlRequest(&CrawlRequest{URL: "https://other.org"}), policy.ErrViolation)
+}
+
 // TestSendJSON verifies JSON response formatting.
 func TestSendJSON(t *testing.T) {
 	c, err := client.New(nil)
@@ -238,6 +563,71 @@ func TestBuildFetchMetadata(t *testing.T) {
 	assert.Equal(t, "hit", metadata.CacheState)
 }
 
+// TestBuildDebugInfo verifies attempt history is converted for debug responses.
+func TestBuildDebugInfo(t *testing.T) {
+	now := time.Now()
+	resp := &client.Response{
+		Attempts: []retry.Attempt{
+			{Time: now, StatusCode: 503, Error: "HTTP 503", Delay: 2 * time.Second},
+			{Time: now.Add(2 * time.Second), StatusCode: 200},
+		},
+	}
+
+	info := buildDebugInfo(resp)
+	require.Len(t, info.Attempts, 2)
+	assert.Equal(t, 503, info.Attempts[0].StatusCode)
+	assert.Equal(t, "HTTP 503", info.Attempts[0].Error)
+	assert.Equal(t, int64(2000), info.Attempts[0].DelayMs)
+	assert.Equal(t, 200, info.Attempts[1].StatusCode)
+	assert.Zero(t, info.Attempts[1].DelayMs)
+	assert.Nil(t, info.Transfer, "cached responses have no transfer details")
+}
+
+// TestBuildDebugInfoTransfer verifies transfer details are included for origin fetches.
+func TestBuildDebugInfoTransfer(t *testing.T) {
+	resp := &client.Response{
+		Transfer: &fetcher.Transfer{
+			Protocol:         "HTTP/2.0",
+			TLSVersion:       "TLS 1.3",
+			RemoteIP:         "93.184.216.34",
+			ContentEncoding:  "gzip",
+			CompressedSize:   1200,
+			DecompressedSize: 4800,
+		},
+	}
+
+	info := buildDebugInfo(resp)
+	require.NotNil(t, info.Transfer)
+	assert.Equal(t, "HTTP/2.0", info.Transfer.Protocol)
+	assert.Equal(t, "TLS 1.3", info.Transfer.TLSVersion)
+	assert.Equal(t, "93.184.216.34", info.Transfer.RemoteIP)
+	assert.Equal(t, "gzip", info.Transfer.ContentEncoding)
+	assert.Equal(t, int64(1200), info.Transfer.CompressedSize)
+	assert.Equal(t, int64(4800), info.Transfer.DecompressedSize)
+}
+
+// TestFetchErrorStatus verifies fetch errors map to appropriate HTTP status codes.
+func TestFetchErrorStatus(t *testing.T) {
+	assert.Equal(t, http.StatusTooManyRequests, fetchErrorStatus(fmt.Errorf("wrapped: %w", budget.ErrBudgetExhausted)))
+	assert.Equal(t, http.StatusServiceUnavailable, fetchErrorStatus(fmt.Errorf("wrapped: %w", ratelimit.ErrDomainPaused)))
+	assert.Equal(t, http.StatusForbidden, fetchErrorStatus(fmt.Errorf("wrapped: %w", robots.ErrDisallowed)))
+	assert.Equal(t, http.StatusInternalServerError, fetchErrorStatus(fmt.Errorf("connection refused")))
+}
+
+// TestBuildFetchError verifies fetch error responses carry status and failure type.
+func TestBuildFetchError(t *testing.T) {
+	dnsErr := &net.DNSError{Err: "no such host", Name: "missing.example", IsNotFound: true}
+
+	resp := buildFetchError("https://missing.example", fmt.Errorf("attempt 1 failed: %w", dnsErr))
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, "dns_error", resp.FailureType)
+	assert.Contains(t, resp.Error, "https://missing.example")
+
+	resp = buildFetchError("https://example.com", fmt.Errorf("wrapped: %w", budget.ErrBudgetExhausted))
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Empty(t, resp.FailureType)
+}
+
 // TestExtractLanguage verifies language extraction from HTML.
 func TestExtractLanguage(t *testing.T) {
 	tests := []struct {
@@ -311,6 +701,10 @@ func TestServerRouter(t *testing.T) {
 	}{
 		{"GET", "/health"},
 		{"POST", "/v1/fetch"},
+		{"GET", "/v1/stats"},
+		{"POST", "/v1/jobs"},
+		{"GET", "/v1/jobs/abc"},
+		{"DELETE", "/v1/jobs/abc"},
 	}
 
 	for _, route := range routes {
@@ -324,3 +718,551 @@ func TestServerRouter(t *testing.T) {
 		assert.NotEqual(t, http.StatusNotFound, w.Code, "route %s %s should exist", route.method, route.path)
 	}
 }
+
+// TestValidateRequestPrivateCallbackURL verifies callbacks to internal addresses are rejected.
+func TestValidateRequestPrivateCallbackURL(t *testing.T) {
+	c, _ := client.New(nil)
+	defer c.Close()
+	s, _ := New(c, nil, nil)
+
+	req := &FetchRequest{
+		URL:         "https://example.com",
+		CallbackURL: "http://169.254.169.254/hook",
+	}
+
+	err := s.validateRequest(req)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "callback_url")
+}
+
+// TestRunAsyncFetchDeliversWebhook verifies async jobs POST a signed result to the callback URL.
+func TestRunAsyncFetchDeliversWebhook(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body><p>Async content</p></body></html>"))
+	}))
+	defer origin.Close()
+
+	type delivery struct {
+		payload   WebhookPayload
+		signature string
+		timestamp string
+		body      []byte
+	}
+	delivered := make(chan delivery, 1)
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload WebhookPayload
+		assert.NoError(t, json.Unmarshal(body, &payload))
+		delivered <- delivery{
+			payload:   payload,
+			signature: r.Header.Get(webhook.SignatureHeader),
+			timestamp: r.Header.Get(webhook.TimestampHeader),
+			body:      body,
+		}
+	}))
+	defer callback.Close()
+
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := New(c, nil, &ServerConfig{WebhookSecret: "s3cret"})
+	require.NoError(t, err)
+	// The callback is on loopback, which the default webhook client refuses.
+	s.webhook = webhook.New("s3cret", webhook.WithHTTPClient(callback.Client()))
+
+	s.runAsyncFetch("job-1", ratelimit.Caller{}, &FetchRequest{URL: origin.URL, CallbackURL: callback.URL})
+
+	d := <-delivered
+	assert.Equal(t, "job-1", d.payload.JobID)
+	assert.Equal(t, "completed", d.payload.Status)
+	require.NotNil(t, d.payload.Result)
+	assert.Contains(t, d.payload.Result.Content, "Async content")
+	assert.NoError(t, webhook.Verify([]byte("s3cret"), d.timestamp, d.body, d.signature, time.Minute))
+}
+
+// TestHandleFetchAsyncAccepted verifies a request with callback_url returns a job ID immediately.
+func TestHandleFetchAsyncAccepted(t *testing.T) {
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+	defer s.Close()
+
+	body, _ := json.Marshal(FetchRequest{
+		URL:         "https://websurfer-test.invalid",
+		CallbackURL: "https://websurfer-test.invalid/webhook",
+	})
+	req := httptest.NewRequest("POST", "/v1/fetch", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	s.Router().ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	var resp AsyncResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.JobID, 32)
+	assert.Equal(t, "accepted", resp.Status)
+}
+
+// newJobTestServer creates a server with a Redis-backed job queue.
+func newJobTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+
+	s, err := New(c, nil, &ServerConfig{RedisClient: redisClient})
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// TestJobEndpoints verifies jobs can be created, polled until completed, and report their result.
+func TestJobEndpoints(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body><p>Queued content</p></body></html>"))
+	}))
+	defer origin.Close()
+
+	s := newJobTestServer(t)
+	router := s.Router()
+
+	body, _ := json.Marshal(FetchRequest{URL: "https://example.com", MaxTokens: -1})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/v1/jobs", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code, "job requests should be validated")
+
+	body, _ = json.Marshal(FetchRequest{URL: "https://websurfer-test.invalid"})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/v1/jobs", bytes.NewReader(body)))
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var queued JobResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &queued))
+	assert.Equal(t, "queued", queued.Status)
+	assert.NotEmpty(t, queued.ID)
+
+	// The test origin is on loopback, which request validation rejects, so enqueue directly.
+	payload, _ := json.Marshal(FetchRequest{URL: origin.URL})
+	created, err := s.queue.Enqueue(context.Background(), payload)
+	require.NoError(t, err)
+
+	var job JobResponse
+	require.Eventually(t, func() bool {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/v1/jobs/"+created.ID, nil))
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &job) != nil {
+			return false
+		}
+		return job.Status == "completed"
+	}, 5*time.Second, 20*time.Millisecond)
+
+	require.NotNil(t, job.Result)
+	assert.Contains(t, job.Result.Content, "Queued content")
+	assert.Equal(t, 1, job.Attempts)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/v1/jobs/"+created.ID, nil))
+	assert.Equal(t, http.StatusConflict, w.Code, "finished jobs cannot be canceled")
+}
+
+// TestJobEndpointsNotFound verifies unknown job IDs return 404.
+func TestJobEndpointsNotFound(t *testing.T) {
+	router := newJobTestServer(t).Router()
+
+	for _, method := range []string{"GET", "DELETE"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, "/v1/jobs/missing", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code, method)
+	}
+}
+
+// TestJobEndpointsWithoutRedis verifies job endpoints report the queue as unavailable without Redis.
+func TestJobEndpointsWithoutRedis(t *testing.T) {
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest("GET", "/v1/jobs/abc", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+// TestFetchProvenance verifies responses carry a verifiable proof when a signing key is configured.
+func TestFetchProvenance(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body><p>Signed content</p></body></html>"))
+	}))
+	defer origin.Close()
+
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = New(c, nil, &ServerConfig{SigningKey: "not-a-key"})
+	assert.Error(t, err, "invalid signing keys should be rejected")
+
+	s, err := New(c, nil, &ServerConfig{SigningKey: base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("k"), 32))})
+	require.NoError(t, err)
+
+	resp, err := s.processFetch(context.Background(), &FetchRequest{URL: origin.URL, MaxTokens: 100})
+	require.NoError(t, err)
+	require.NotNil(t, resp.Provenance)
+	assert.Equal(t, resp.Metadata.URL, resp.Provenance.URL)
+	originHash := sha256.Sum256([]byte("<html><body><p>Signed content</p></body></html>"))
+	assert.Equal(t, hex.EncodeToString(originHash[:]), resp.Provenance.OriginSHA256, "the origin body is signed")
+	assert.Equal(t, []string{"parse:markdown", "paginate:offset=0,max_tokens=100"}, resp.Provenance.Transforms)
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest("GET", "/v1/provenance/key", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var key ProvenanceKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &key))
+	assert.Equal(t, resp.Provenance.KeyID, key.KeyID)
+
+	require.NoError(t, provenance.Verify(key.PublicKey, resp.Provenance, []byte(resp.Content)))
+	assert.Error(t, provenance.Verify(key.PublicKey, resp.Provenance, []byte(resp.Content+"edited")))
+
+	unsigned := &FetchResponse{Content: "archived"}
+	s.finishResponse(unsigned, &client.Response{URL: origin.URL, OriginalURL: origin.URL, OriginSHA256: resp.Provenance.OriginSHA256}, nil)
+	assert.Nil(t, unsigned.Provenance, "wayback snapshots are not signed as fetched")
+
+	s.finishResponse(unsigned, &client.Response{URL: origin.URL}, nil)
+	assert.Nil(t, unsigned.Provenance, "content not fetched from the origin, such as imports, is not signed")
+}
+
+// TestFetchRateLimitWait verifies time spent waiting on the site's rate limit is reported.
+func TestFetchRateLimitWait(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body><p>Polite</p></body></html>"))
+	}))
+	defer origin.Close()
+
+	cfg := config.New()
+	cfg.Default.RateLimit = config.RateLimitConfig{Delay: 200 * time.Millisecond}
+
+	c, err := client.New(cfg)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+
+	req := FetchRequest{URL: origin.URL, BypassCache: true, Debug: true}
+	_, err = s.processFetch(context.Background(), &req)
+	require.NoError(t, err)
+
+	resp, err := s.processFetch(context.Background(), &req)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, resp.Metadata.RateLimitWaitMs, int64(100))
+	assert.Zero(t, resp.Metadata.RetryWaitMs)
+	require.Len(t, resp.Debug.Attempts, 1)
+	assert.Equal(t, resp.Metadata.RateLimitWaitMs, resp.Debug.Attempts[0].WaitMs)
+}
+
+// TestFetchDeterministic verifies deterministic fetches of unchanged content serialize identically.
+func TestFetchDeterministic(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body><h1>Golden</h1><p>Stable content</p></body></html>"))
+	}))
+	defer origin.Close()
+
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := New(c, nil, &ServerConfig{SigningKey: base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("k"), 32))})
+	require.NoError(t, err)
+	s.region = "us-east"
+
+	req := FetchRequest{URL: origin.URL, Debug: true, MaxTokens: 5}
+	resp, err := s.processFetch(context.Background(), &req)
+	require.NoError(t, err)
+	assert.NotNil(t, resp.Provenance)
+	assert.Equal(t, "us-east", resp.Metadata.Region)
+
+	req.Deterministic = true
+	var outputs [][]byte
+	for range 2 {
+		resp, err := s.processFetch(context.Background(), &req)
+		require.NoError(t, err)
+		assert.Nil(t, resp.Provenance)
+		assert.Empty(t, resp.Metadata.Region)
+		assert.Empty(t, resp.Metadata.CachedAt)
+		require.NotNil(t, resp.Debug)
+		assert.Empty(t, resp.Debug.Attempts[0].Timestamp)
+
+		out, err := json.Marshal(resp)
+		require.NoError(t, err)
+		outputs = append(outputs, out)
+	}
+	assert.Equal(t, string(outputs[0]), string(outputs[1]))
+}
+
+// TestProvenanceKeyNotConfigured verifies the key endpoint is unavailable without a signing key.
+func TestProvenanceKeyNotConfigured(t *testing.T) {
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest("GET", "/v1/provenance/key", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+// TestFetchFollowPagination verifies the pages of a series are joined with source markers.
+func TestFetchFollowPagination(t *testing.T) {
+	var origin *httptest.Server
+	origin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Write([]byte(`<html><head><link rel="next" href="?page=2"></head><body><p>Part one</p></body></html>`))
+		case "2":
+			w.Write([]byte(`<html><body><p>Part two</p><a href="?page=3">Next</a></body></html>`))
+		case "3":
+			fmt.Fprintf(w, `<html><body><p>Part three</p><a rel="next" href="https://elsewhere.example/">Next</a><a href="%s/">Home</a></body></html>`, origin.URL)
+		}
+	}))
+	defer origin.Close()
+
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+
+	resp, err := s.processFetch(context.Background(), &FetchRequest{URL: origin.URL + "/", FollowPagination: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{origin.URL + "/", origin.URL + "/?page=2", origin.URL + "/?page=3"}, resp.Metadata.SeriesPages)
+	assert.Contains(t, resp.Content, "<!-- page 2 of 3: "+origin.URL+"/?page=2 -->")
+	assert.Less(t, strings.Index(resp.Content, "Part one"), strings.Index(resp.Content, "Part two"))
+	assert.Less(t, strings.Index(resp.Content, "Part two"), strings.Index(resp.Content, "Part three"))
+
+	resp, err = s.processFetch(context.Background(), &FetchRequest{URL: origin.URL + "/", FollowPagination: true, MaxPages: 2})
+	require.NoError(t, err)
+	assert.Len(t, resp.Metadata.SeriesPages, 2)
+	assert.NotContains(t, resp.Content, "Part three")
+
+	resp, err = s.processFetch(context.Background(), &FetchRequest{URL: origin.URL + "/"})
+	require.NoError(t, err)
+	assert.Nil(t, resp.Metadata.SeriesPages)
+	assert.NotContains(t, resp.Content, "Part two")
+
+	assert.Error(t, s.validateRequest(&FetchRequest{URL: "https://example.com", MaxPages: maxSeriesPages + 1}))
+}
+
+// TestFetchLanguage verifies the hreflang variant matching the requested language is returned.
+func TestFetchLanguage(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		head := `<head><link rel="alternate" hreflang="en" href="/"><link rel="alternate" hreflang="de" href="/de/"></head>`
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html lang="en">` + head + `<body><p>Hello</p></body></html>`))
+		case "/de/":
+			w.Write([]byte(`<html lang="de">` + head + `<body><p>Hallo</p></body></html>`))
+		}
+	}))
+	defer origin.Close()
+
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+
+	resp, err := s.processFetch(context.Background(), &FetchRequest{URL: origin.URL + "/"})
+	require.NoError(t, err)
+	assert.Contains(t, resp.Content, "Hello")
+	assert.Equal(t, []hreflang.Alternate{{Lang: "en", URL: origin.URL + "/"}, {Lang: "de", URL: origin.URL + "/de/"}}, resp.Metadata.Alternates)
+
+	resp, err = s.processFetch(context.Background(), &FetchRequest{URL: origin.URL + "/", Language: "de-AT"})
+	require.NoError(t, err)
+	assert.Equal(t, origin.URL+"/de/", resp.Metadata.URL)
+	assert.Contains(t, resp.Content, "Hallo")
+
+	resp, err = s.processFetch(context.Background(), &FetchRequest{URL: origin.URL + "/", Language: "fr"})
+	require.NoError(t, err)
+	assert.Equal(t, origin.URL+"/", resp.Metadata.URL)
+	assert.Contains(t, resp.Content, "Hello")
+
+	assert.Error(t, s.validateRequest(&FetchRequest{URL: "https://example.com", Language: "not a language"}))
+}
+
+// TestFetchProcessors verifies the site's processor chain is applied and can be replaced per request.
+func TestFetchProcessors(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><p>Questions about the release go to support@example.com today.</p><p>We use cookies to improve your experience.</p></body></html>`))
+	}))
+	defer origin.Close()
+
+	cfg := config.New()
+	cfg.Default.Processors = []postprocess.Spec{{Type: postprocess.StepStripBoilerplate}, {Type: postprocess.StepRedact}}
+	c, err := client.New(cfg)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+
+	resp, err := s.processFetch(context.Background(), &FetchRequest{URL: origin.URL})
+	require.NoError(t, err)
+	assert.Contains(t, resp.Content, "go to [REDACTED:email] today")
+	assert.NotContains(t, resp.Content, "cookies")
+	assert.Equal(t, []string{postprocess.StepStripBoilerplate, postprocess.StepRedact}, resp.Metadata.Processors)
+	assert.Equal(t, resp.Metadata.EstimatedTokens, s.tokenizer.Count([]byte(resp.Content), resp.Metadata.ContentType), "tokens are counted after processing")
+
+	resp, err = s.processFetch(context.Background(), &FetchRequest{URL: origin.URL, Processors: []postprocess.Spec{{Type: postprocess.StepSummarize, MaxSentences: 1}}})
+	require.NoError(t, err)
+	assert.Contains(t, resp.Content, "support@example.com")
+	assert.Equal(t, "Questions about the release go to support@example.com today.", resp.Summary)
+	assert.Equal(t, []string{postprocess.StepSummarize}, resp.Metadata.Processors)
+
+	resp, err = s.processFetch(context.Background(), &FetchRequest{URL: origin.URL, Processors: []postprocess.Spec{}})
+	require.NoError(t, err)
+	assert.Contains(t, resp.Content, "support@example.com")
+	assert.Empty(t, resp.Metadata.Processors)
+
+	_, err = s.processFetch(context.Background(), &FetchRequest{URL: origin.URL, Processors: []postprocess.Spec{{Type: postprocess.StepTranslate, Language: "de"}}})
+	assert.ErrorIs(t, err, errTranslationNotConfigured)
+
+	assert.Error(t, s.validateRequest(&FetchRequest{URL: "https://example.com", Processors: []postprocess.Spec{{Type: "shout"}}}))
+}
+
+// TestFetchLines verifies a minified document without newlines is served by soft-wrapped
+// lines, and search results report the lines they span.
+func TestFetchLines(t *testing.T) {
+	minified := strings.Repeat(`{"name":"widget","tags":["a","b"]},`, 40) + `{"name":"needle"}`
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(minified))
+	}))
+	defer origin.Close()
+
+	s := newMapTestServer(t)
+	ctx := context.Background()
+
+	resp, err := s.processFetch(ctx, &FetchRequest{URL: origin.URL, Lines: &LineRange{Start: 2, End: 3}, Search: &SearchRequest{Query: "needle"}})
+	require.NoError(t, err)
+	require.NotNil(t, resp.Lines)
+	assert.Greater(t, resp.Lines.TotalLines, 5)
+	assert.Equal(t, 2, resp.Lines.Start)
+	assert.Equal(t, 3, resp.Lines.End)
+	assert.Equal(t, minified[resp.Lines.CharStart:resp.Lines.CharEnd], resp.Content)
+	assert.LessOrEqual(t, len(resp.Content), 2*lines.DefaultWidth)
+
+	require.Len(t, resp.SearchResults, 1)
+	assert.Equal(t, resp.Lines.TotalLines, resp.SearchResults[0].LineEnd)
+
+	_, err = s.processFetch(ctx, &FetchRequest{URL: origin.URL, Lines: &LineRange{Start: 1000}})
+	assert.ErrorContains(t, err, "out of range")
+
+	assert.Error(t, s.validateRequest(&FetchRequest{URL: "https://example.com", Lines: &LineRange{Start: 1}, MaxTokens: 100}))
+	assert.Error(t, s.validateRequest(&FetchRequest{URL: "https://example.com", Lines: &LineRange{Start: 3, End: 2}}))
+}
+
+// TestFetchBinary verifies binary bodies served as text get a metadata-only response, or their
+// bytes base64-encoded when requested, instead of being parsed.
+func TestFetchBinary(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01")
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write(png)
+	}))
+	defer origin.Close()
+
+	s := newMapTestServer(t)
+	ctx := context.Background()
+
+	resp, err := s.processFetch(ctx, &FetchRequest{URL: origin.URL})
+	require.NoError(t, err)
+	assert.True(t, resp.Metadata.Binary)
+	assert.Equal(t, "text/html", resp.Metadata.ContentType)
+	assert.Empty(t, resp.Content)
+	assert.Empty(t, resp.Metadata.Encoding)
+
+	resp, err = s.processFetch(ctx, &FetchRequest{URL: origin.URL, Base64: true, Search: &SearchRequest{Query: "PNG"}})
+	require.NoError(t, err)
+	assert.Equal(t, "base64", resp.Metadata.Encoding)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(png), resp.Content)
+	assert.Empty(t, resp.SearchResults)
+}
+
+// TestFetchAccessIssue verifies pages showing only a paywall are flagged in the metadata.
+func TestFetchAccessIssue(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/premium":
+			w.Write([]byte(`<html><body><h1>Markets rally</h1><p>Subscribe to continue reading. Already a subscriber? Sign in.</p></body></html>`))
+		default:
+			w.Write([]byte(`<html><body><h1>Markets rally</h1><p>Stocks rose on Tuesday as rates held steady.</p></body></html>`))
+		}
+	}))
+	defer origin.Close()
+
+	s := newMapTestServer(t)
+
+	resp, err := s.processFetch(context.Background(), &FetchRequest{URL: origin.URL + "/premium"})
+	require.NoError(t, err)
+	assert.Equal(t, access.Paywall, resp.Metadata.AccessIssue)
+
+	resp, err = s.processFetch(context.Background(), &FetchRequest{URL: origin.URL + "/free"})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Metadata.AccessIssue)
+}
+
+// TestFetchSelectors verifies selector values are returned alongside or instead of the content.
+func TestFetchSelectors(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><h1>Widget</h1><p>A sturdy widget.</p><span class="product-price">$19.99</span><img class="hero" src="/hero.png"></body></html>`))
+	}))
+	defer origin.Close()
+
+	s := newMapTestServer(t)
+	selectors := map[string]string{"title": "h1", "price": ".product-price", "image": "img.hero @src"}
+	want := map[string][]string{"title": {"Widget"}, "price": {"$19.99"}, "image": {origin.URL + "/hero.png"}}
+
+	resp, err := s.processFetch(context.Background(), &FetchRequest{URL: origin.URL, Selectors: selectors})
+	require.NoError(t, err)
+	assert.Equal(t, want, resp.Extracted)
+	assert.Contains(t, resp.Content, "A sturdy widget.")
+
+	resp, err = s.processFetch(context.Background(), &FetchRequest{URL: origin.URL, Selectors: selectors, SelectorsOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, want, resp.Extracted)
+	assert.Empty(t, resp.Content)
+	assert.Equal(t, http.StatusOK, resp.Metadata.StatusCode)
+}
+
+// TestValidateRequestSelectors verifies malformed selectors are rejected.
+func TestValidateRequestSelectors(t *testing.T) {
+	s := newMapTestServer(t)
+
+	assert.NoError(t, s.validateRequest(&FetchRequest{URL: "https://example.com", Selectors: map[string]string{"links": "a[href^=http] @href"}}))
+	assert.Error(t, s.validateRequest(&FetchRequest{URL: "https://example.com", Selectors: map[string]string{"price": "span:nth-child(x)"}}))
+	assert.Error(t, s.validateRequest(&FetchRequest{URL: "https://example.com", Selectors: map[string]string{"": "h1"}}))
+	assert.Error(t, s.validateRequest(&FetchRequest{URL: "https://example.com", SelectorsOnly: true}))
+	assert.Error(t, s.validateRequest(&FetchRequest{URL: "https://example.com", Selectors: map[string]string{"title": "h1"}, Version: 2}))
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	urlpkg "github.com/joeychilson/websurfer/url"
+)
+
+const (
+	// maxHeadURLs is the most URLs a single head request may check.
+	maxHeadURLs = 100
+	// headWorkers is how many URLs are checked at once.
+	headWorkers = 8
+)
+
+// HeadRequest lists URLs whose headers are requested.
+type HeadRequest struct {
+	URLs []string `json:"urls"`
+}
+
+// HeadResponse holds the headers of each requested URL, in request order.
+type HeadResponse struct {
+	Results []URLHead `json:"results"`
+	// Existing is how many of the URLs exist.
+	Existing int `json:"existing"`
+}
+
+// URLHead describes one URL from its response headers. Exists is set when it responded with
+// a status below 400. ContentLength is omitted when the server declared no length. Error and
+// ErrorCode are set instead when the URL could not be checked.
+type URLHead struct {
+	URL           string            `json:"url"`
+	FinalURL      string            `json:"final_url,omitempty"`
+	Exists        bool              `json:"exists"`
+	StatusCode    int               `json:"status_code,omitempty"`
+	ContentType   string            `json:"content_type,omitempty"`
+	ContentLength *int64            `json:"content_length,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	ErrorCode     string            `json:"error_code,omitempty"`
+}
+
+// handleHead handles POST /v1/head requests.
+func (s *Server) handleHead(w http.ResponseWriter, r *http.Request) {
+	var req HeadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("failed to decode request", "error", err)
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.URLs) == 0 || len(req.URLs) > maxHeadURLs {
+		s.sendError(w, fmt.Sprintf("urls must contain between 1 and %d URLs", maxHeadURLs), http.StatusBadRequest)
+		return
+	}
+
+	s.sendJSON(w, s.processHead(r.Context(), req.URLs), http.StatusOK)
+}
+
+// processHead requests the headers of each URL, without their bodies.
+func (s *Server) processHead(ctx context.Context, urls []string) *HeadResponse {
+	resp := &HeadResponse{Results: make([]URLHead, len(urls))}
+
+	sem := make(chan struct{}, headWorkers)
+	var wg sync.WaitGroup
+	for i, pageURL := range urls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resp.Results[i] = s.headURL(ctx, pageURL)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range resp.Results {
+		if result.Exists {
+			resp.Existing++
+		}
+	}
+	return resp
+}
+
+// headURL validates a single URL and requests its headers.
+func (s *Server) headURL(ctx context.Context, pageURL string) URLHead {
+	head := URLHead{URL: pageURL}
+
+	if _, err := urlpkg.ValidateExternal(pageURL); err != nil {
+		head.Error = err.Error()
+		head.ErrorCode = validationErrorCode(err)
+		return head
+	}
+	if err := s.policy.Check(pageURL); err != nil {
+		head.Error = err.Error()
+		head.ErrorCode = validationErrorCode(err)
+		return head
+	}
+	return s.fetchHead(ctx, pageURL)
+}
+
+// fetchHead requests the headers of a validated URL.
+func (s *Server) fetchHead(ctx context.Context, pageURL string) URLHead {
+	head := URLHead{URL: pageURL}
+
+	result, err := s.client.Head(ctx, pageURL)
+	if err != nil {
+		s.logger.Debug("head request failed", "url", pageURL, "error", err)
+		head.Error = err.Error()
+		head.ErrorCode = fetchErrorCode(err)
+		return head
+	}
+
+	head.FinalURL = result.URL
+	head.Exists = result.StatusCode < http.StatusBadRequest
+	head.StatusCode = result.StatusCode
+	head.ContentType = result.ContentType
+	if result.ContentLength >= 0 {
+		head.ContentLength = &result.ContentLength
+	}
+	head.Headers = make(map[string]string, len(result.Headers))
+	for name := range result.Headers {
+		head.Headers[name] = result.Headers.Get(name)
+	}
+	return head
+}
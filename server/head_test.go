@@ -0,0 +1,74 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFetchHead verifies head requests report the status, content type, and length of a URL
+// without downloading it.
+func TestFetchHead(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Length", "48213")
+		w.Header().Set("ETag", `"v1"`)
+	}))
+	defer origin.Close()
+
+	s := newMapTestServer(t)
+	ctx := context.Background()
+
+	head := s.fetchHead(ctx, origin.URL+"/report.pdf")
+	assert.True(t, head.Exists)
+	assert.Equal(t, http.StatusOK, head.StatusCode)
+	assert.Equal(t, "application/pdf", head.ContentType)
+	require.NotNil(t, head.ContentLength)
+	assert.Equal(t, int64(48213), *head.ContentLength)
+	assert.Equal(t, `"v1"`, head.Headers["Etag"])
+
+	head = s.fetchHead(ctx, origin.URL+"/missing")
+	assert.False(t, head.Exists)
+	assert.Equal(t, http.StatusNotFound, head.StatusCode)
+	assert.Empty(t, head.Error)
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	head = s.fetchHead(ctx, closed.URL)
+	assert.False(t, head.Exists)
+	assert.Equal(t, ErrorCodeConnectionRefused, head.ErrorCode)
+}
+
+// TestProcessHeadInvalidURLs verifies URLs that may not be fetched are reported without being
+// requested.
+func TestProcessHeadInvalidURLs(t *testing.T) {
+	s := newMapTestServer(t)
+
+	resp := s.processHead(context.Background(), []string{"http://127.0.0.1/admin", "ftp://example.com/file"})
+	require.Len(t, resp.Results, 2)
+	assert.Equal(t, ErrorCodeSSRFBlocked, resp.Results[0].ErrorCode)
+	assert.Equal(t, ErrorCodeInvalidRequest, resp.Results[1].ErrorCode)
+	assert.Zero(t, resp.Existing)
+}
+
+// TestHandleHeadValidation verifies head requests must list between 1 and maxHeadURLs URLs.
+func TestHandleHeadValidation(t *testing.T) {
+	router := newMapTestServer(t).Router()
+
+	for _, body := range []string{`{"urls": []}`, `not json`, `{"urls": [` + string(bytes.Repeat([]byte(`"https://example.com",`), maxHeadURLs)) + `"https://example.com"]}`} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/head", bytes.NewBufferString(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
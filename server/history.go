@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	urlpkg "github.com/joeychilson/websurfer/url"
+)
+
+// HistoryResponse lists the cached versions of a page, newest first.
+type HistoryResponse struct {
+	URL      string           `json:"url"`
+	Versions []HistoryVersion `json:"versions"`
+}
+
+// HistoryVersion describes one cached version of a page. Pass Version to /v1/fetch to read it.
+type HistoryVersion struct {
+	Version    int    `json:"version"`
+	StatusCode int    `json:"status_code"`
+	Title      string `json:"title,omitempty"`
+	// ChangedAt is when this version was first fetched, and FetchedAt when it was last
+	// confirmed unchanged.
+	ChangedAt string `json:"changed_at"`
+	FetchedAt string `json:"fetched_at"`
+	Bytes     int    `json:"bytes"`
+}
+
+// handleHistory handles GET /v1/history requests.
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if s.client.Cache() == nil {
+		s.sendError(w, "cache is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	pageURL := r.URL.Query().Get("url")
+	if _, err := urlpkg.ValidateExternal(pageURL); err != nil {
+		s.sendValidationError(w, err)
+		return
+	}
+
+	if err := s.policy.Check(pageURL); err != nil {
+		s.sendValidationError(w, err)
+		return
+	}
+
+	resp, err := s.processHistory(r.Context(), pageURL)
+	if err != nil {
+		s.logger.Error("history failed", "url", pageURL, "error", err)
+		s.sendError(w, "failed to read cache history", http.StatusInternalServerError)
+		return
+	}
+	if len(resp.Versions) == 0 {
+		s.sendError(w, "page is not cached", http.StatusNotFound)
+		return
+	}
+
+	s.sendJSON(w, resp, http.StatusOK)
+}
+
+// processHistory lists the cached versions of pageURL.
+func (s *Server) processHistory(ctx context.Context, pageURL string) (*HistoryResponse, error) {
+	versions, err := s.client.History(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &HistoryResponse{URL: pageURL, Versions: make([]HistoryVersion, 0, len(versions))}
+	for _, version := range versions {
+		changedAt := version.ChangedAt
+		if changedAt.IsZero() {
+			changedAt = version.CachedAt
+		}
+		resp.Versions = append(resp.Versions, HistoryVersion{
+			Version:    version.Version,
+			StatusCode: version.StatusCode,
+			Title:      version.Title,
+			ChangedAt:  changedAt.UTC().Format(time.RFC3339),
+			FetchedAt:  version.CachedAt.UTC().Format(time.RFC3339),
+			Bytes:      len(version.Body),
+		})
+	}
+	return resp, nil
+}
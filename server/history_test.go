@@ -0,0 +1,91 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/joeychilson/websurfer/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHistoryEndpoint verifies cached versions are listed and can be fetched by version or time.
+func TestHistoryEndpoint(t *testing.T) {
+	s, responseCache := newCacheTestServer(t)
+	router := s.Router()
+	ctx := context.Background()
+
+	pageURL := "https://example.com/pricing"
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i, body := range []string{"Basic plan costs $10.", "Basic plan costs $12."} {
+		require.NoError(t, responseCache.Set(ctx, &cache.Entry{
+			URL:         pageURL,
+			StatusCode:  200,
+			Headers:     map[string][]string{"Content-Type": {"text/plain"}},
+			Body:        []byte(body),
+			StoredAt:    start.Add(time.Duration(i) * 10 * time.Minute),
+			TTL:         time.Hour,
+			StaleTime:   time.Hour,
+			MaxVersions: 5,
+		}))
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/v1/history?url="+pageURL, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var history HistoryResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	require.Len(t, history.Versions, 2)
+	assert.Equal(t, 2, history.Versions[0].Version)
+	assert.Equal(t, 1, history.Versions[1].Version)
+	assert.Equal(t, start.UTC().Format(time.RFC3339), history.Versions[1].ChangedAt)
+
+	requests := []FetchRequest{
+		{URL: pageURL, Version: 1},
+		{URL: pageURL, AsOf: start.Add(5 * time.Minute).Format(time.RFC3339)},
+	}
+	for _, req := range requests {
+		body, _ := json.Marshal(req)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/v1/fetch", bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp FetchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, 1, resp.Metadata.Version)
+		assert.Contains(t, resp.Content, "$10")
+	}
+
+	body, _ := json.Marshal(FetchRequest{URL: pageURL, Version: 9})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/v1/fetch", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/v1/history?url=https://example.com/other", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// TestFetchVersionValidation verifies version and as_of reject invalid values and combinations.
+func TestFetchVersionValidation(t *testing.T) {
+	s := newMapTestServer(t)
+
+	invalid := []FetchRequest{
+		{URL: "https://example.com", Version: -1},
+		{URL: "https://example.com", AsOf: "yesterday"},
+		{URL: "https://example.com", Version: 1, AsOf: "2024-01-01T00:00:00Z"},
+		{URL: "https://example.com", Version: 1, BypassCache: true},
+		{URL: "https://example.com", AsOf: "2024-01-01T00:00:00Z", Headers: map[string]string{"Accept-Language": "fr"}},
+	}
+	for _, req := range invalid {
+		assert.Error(t, s.validateRequest(&req), "%+v", req)
+	}
+
+	assert.NoError(t, s.validateRequest(&FetchRequest{URL: "https://example.com", AsOf: "2024-01-01T00:00:00Z"}))
+}
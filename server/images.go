@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/joeychilson/websurfer/thumbnail"
+	urlpkg "github.com/joeychilson/websurfer/url"
+)
+
+const (
+	// maxImageSize caps the size parameter of image requests, in pixels.
+	maxImageSize = 512
+	// imageCacheControl lets browsers and proxies reuse returned images for a day.
+	imageCacheControl = "public, max-age=86400"
+)
+
+const (
+	// imageKindFavicon is a page's favicon, falling back to /favicon.ico.
+	imageKindFavicon = "favicon"
+	// imageKindPreview is a page's og:image or twitter:image.
+	imageKindPreview = "preview"
+)
+
+// errNoImage is returned when a page declares no image of the requested kind.
+var errNoImage = errors.New("page has no preview image")
+
+// handleFavicon handles GET /v1/favicon requests.
+func (s *Server) handleFavicon(w http.ResponseWriter, r *http.Request) {
+	s.serveImage(w, r, imageKindFavicon)
+}
+
+// handlePreviewImage handles GET /v1/preview-image requests.
+func (s *Server) handlePreviewImage(w http.ResponseWriter, r *http.Request) {
+	s.serveImage(w, r, imageKindPreview)
+}
+
+// serveImage resolves a page's favicon or preview image and writes its bytes, optionally
+// scaled down to fit within the size query parameter.
+func (s *Server) serveImage(w http.ResponseWriter, r *http.Request, kind string) {
+	pageURL := r.URL.Query().Get("url")
+	if _, err := urlpkg.ValidateExternal(pageURL); err != nil {
+		s.sendValidationError(w, err)
+		return
+	}
+
+	if err := s.policy.Check(pageURL); err != nil {
+		s.sendValidationError(w, err)
+		return
+	}
+
+	size, err := parseImageSize(r.URL.Query().Get("size"))
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	imageURL, err := s.resolveImageURL(r.Context(), pageURL, kind)
+	if errors.Is(err, errNoImage) {
+		s.sendError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		errResp := buildFetchError(pageURL, err)
+		s.logger.Error("fetch failed", "url", pageURL, "error", err, "failure_type", errResp.FailureType)
+		s.sendJSON(w, errResp, errResp.StatusCode)
+		return
+	}
+
+	if _, err := urlpkg.ValidateExternal(imageURL); err != nil {
+		s.sendErrorCode(w, fmt.Sprintf("image url is not allowed: %v", err), http.StatusBadGateway, validationErrorCode(err))
+		return
+	}
+
+	if err := s.policy.Check(imageURL); err != nil {
+		s.sendErrorCode(w, fmt.Sprintf("image url is not allowed: %v", err), http.StatusForbidden, ErrorCodePolicyBlocked)
+		return
+	}
+
+	data, contentType, err := s.fetchImage(r.Context(), imageURL, size)
+	if err != nil {
+		s.logger.Error("image fetch failed", "url", imageURL, "error", err)
+		s.sendError(w, fmt.Sprintf("failed to fetch image %s: %v", imageURL, err), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", imageCacheControl)
+	w.Header().Set("X-Image-URL", imageURL)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		s.logger.Error("failed to write image", "error", err)
+	}
+}
+
+// resolveImageURL fetches a page and returns the URL of its favicon or preview image.
+func (s *Server) resolveImageURL(ctx context.Context, pageURL, kind string) (string, error) {
+	resp, err := s.client.Fetch(ctx, pageURL)
+	if err != nil {
+		return "", err
+	}
+
+	switch kind {
+	case imageKindFavicon:
+		if resp.FaviconURL != "" {
+			return resp.FaviconURL, nil
+		}
+		u, err := url.Parse(resp.URL)
+		if err != nil {
+			return "", fmt.Errorf("invalid url: %w", err)
+		}
+		return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/favicon.ico"}).String(), nil
+	default:
+		if resp.ImageURL == "" {
+			return "", errNoImage
+		}
+		return resp.ImageURL, nil
+	}
+}
+
+// fetchImage fetches an image through the client cache and scales it down to fit within
+// size pixels if size is set. Formats that cannot be decoded, such as ICO and SVG, are
+// returned unscaled.
+func (s *Server) fetchImage(ctx context.Context, imageURL string, size int) ([]byte, string, error) {
+	resp, err := s.client.Fetch(ctx, imageURL)
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	var contentType string
+	if values := resp.Headers["Content-Type"]; len(values) > 0 {
+		contentType = values[0]
+	}
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = http.DetectContentType(resp.Body)
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, "", fmt.Errorf("not an image: %s", contentType)
+	}
+
+	if size == 0 {
+		return resp.Body, contentType, nil
+	}
+
+	resized, resizedType, err := thumbnail.Resize(resp.Body, size)
+	if errors.Is(err, thumbnail.ErrUnsupportedFormat) {
+		return resp.Body, contentType, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return resized, resizedType, nil
+}
+
+// parseImageSize parses the size query parameter, where empty means the original size.
+func parseImageSize(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 1 || size > maxImageSize {
+		return 0, fmt.Errorf("size must be between 1 and %d", maxImageSize)
+	}
+	return size, nil
+}
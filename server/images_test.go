@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newImageOrigin starts a site with a page declaring an icon and og:image, and a bare page.
+func newImageOrigin(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var icon bytes.Buffer
+	require.NoError(t, png.Encode(&icon, image.NewRGBA(image.Rect(0, 0, 128, 64))))
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head><link rel="icon" href="/icon.png"><meta property="og:image" content="/icon.png"></head><body><p>Home</p></body></html>`))
+		case "/bare":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head><title>Bare</title></head><body><p>Bare</p></body></html>`))
+		case "/icon.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(icon.Bytes())
+		case "/not-image":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("hello"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(origin.Close)
+
+	return origin
+}
+
+// TestResolveImageURL verifies favicons and preview images are resolved from page metadata.
+func TestResolveImageURL(t *testing.T) {
+	origin := newImageOrigin(t)
+	s := newMapTestServer(t)
+	ctx := context.Background()
+
+	imageURL, err := s.resolveImageURL(ctx, origin.URL+"/", imageKindFavicon)
+	require.NoError(t, err)
+	assert.Equal(t, origin.URL+"/icon.png", imageURL)
+
+	imageURL, err = s.resolveImageURL(ctx, origin.URL+"/", imageKindPreview)
+	require.NoError(t, err)
+	assert.Equal(t, origin.URL+"/icon.png", imageURL)
+
+	imageURL, err = s.resolveImageURL(ctx, origin.URL+"/bare", imageKindFavicon)
+	require.NoError(t, err)
+	assert.Equal(t, origin.URL+"/favicon.ico", imageURL)
+
+	_, err = s.resolveImageURL(ctx, origin.URL+"/bare", imageKindPreview)
+	assert.ErrorIs(t, err, errNoImage)
+}
+
+// TestFetchImage verifies images are returned as-is or scaled down to the requested size.
+func TestFetchImage(t *testing.T) {
+	origin := newImageOrigin(t)
+	s := newMapTestServer(t)
+	ctx := context.Background()
+
+	data, contentType, err := s.fetchImage(ctx, origin.URL+"/icon.png", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 128, cfg.Width)
+
+	data, contentType, err = s.fetchImage(ctx, origin.URL+"/icon.png", 32)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	cfg, err = png.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 32, cfg.Width)
+	assert.Equal(t, 16, cfg.Height)
+
+	_, _, err = s.fetchImage(ctx, origin.URL+"/not-image", 0)
+	assert.Error(t, err)
+
+	_, _, err = s.fetchImage(ctx, origin.URL+"/missing.png", 0)
+	assert.Error(t, err)
+}
+
+// TestHandleImageInvalidRequest verifies invalid image requests are rejected.
+func TestHandleImageInvalidRequest(t *testing.T) {
+	s := newMapTestServer(t)
+
+	tests := []string{
+		"/v1/favicon",
+		"/v1/favicon?url=http://127.0.0.1",
+		"/v1/preview-image?url=https://example.com&size=0",
+		"/v1/preview-image?url=https://example.com&size=1024",
+		"/v1/preview-image?url=https://example.com&size=big",
+	}
+
+	for _, target := range tests {
+		w := httptest.NewRecorder()
+		s.Router().ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+	}
+}
@@ -0,0 +1,200 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/joeychilson/websurfer/jobs"
+	"github.com/joeychilson/websurfer/ratelimit"
+)
+
+// JobResponse describes a queued fetch, crawl, or preload job and, once finished, its outcome.
+type JobResponse struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	Status    string         `json:"status"`
+	Attempts  int            `json:"attempts"`
+	CreatedAt string         `json:"created_at"`
+	UpdatedAt string         `json:"updated_at"`
+	Result    *FetchResponse `json:"result,omitempty"`
+	Crawl     *CrawlResult   `json:"crawl,omitempty"`
+	Progress  *CrawlProgress `json:"progress,omitempty"`
+	Preload   *PreloadResult `json:"preload,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// handleCreateJob handles POST /v1/jobs requests.
+func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
+	if s.queue == nil {
+		s.sendError(w, "job queue is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req FetchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("failed to decode request", "error", err)
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.validateRequest(&req); err != nil {
+		s.logger.Error("invalid request", "error", err)
+		s.sendValidationError(w, err)
+		return
+	}
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		s.sendError(w, "failed to encode job", http.StatusInternalServerError)
+		return
+	}
+
+	job, err := s.queue.Enqueue(r.Context(), payload)
+	if err != nil {
+		s.logger.Error("failed to enqueue job", "url", req.URL, "error", err)
+		s.sendError(w, "failed to enqueue job", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Info("job enqueued", "job_id", job.ID, "url", req.URL)
+	s.sendJSON(w, buildJobResponse(job), http.StatusAccepted)
+}
+
+// handleGetJob handles GET /v1/jobs/{id} requests.
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	if s.queue == nil {
+		s.sendError(w, "job queue is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	job, err := s.queue.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		s.sendJobError(w, err)
+		return
+	}
+
+	s.sendJSON(w, buildJobResponse(job), http.StatusOK)
+}
+
+// handleCancelJob handles DELETE /v1/jobs/{id} requests.
+func (s *Server) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	if s.queue == nil {
+		s.sendError(w, "job queue is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	job, err := s.queue.Cancel(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		s.sendJobError(w, err)
+		return
+	}
+
+	s.logger.Info("job canceled", "job_id", job.ID)
+	s.sendJSON(w, buildJobResponse(job), http.StatusOK)
+}
+
+// sendJobError maps a job queue error to an error response.
+func (s *Server) sendJobError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		s.sendError(w, "job not found", http.StatusNotFound)
+	case errors.Is(err, jobs.ErrFinished):
+		s.sendError(w, "job already finished", http.StatusConflict)
+	default:
+		s.logger.Error("job queue error", "error", err)
+		s.sendError(w, "job queue error", http.StatusInternalServerError)
+	}
+}
+
+// runJob processes a job claimed from the queue, as the caller that enqueued it. Fetch jobs
+// deliver their webhook if requested.
+func (s *Server) runJob(ctx context.Context, job *jobs.Job) (json.RawMessage, error) {
+	ctx = ratelimit.WithCaller(ctx, job.Caller)
+
+	switch jobType(job.Payload) {
+	case jobTypeCrawl:
+		return s.runCrawlJob(ctx, job)
+	case jobTypePreload:
+		return s.runPreloadJob(ctx, job)
+	}
+
+	var req FetchRequest
+	if err := json.Unmarshal(job.Payload, &req); err != nil {
+		return nil, fmt.Errorf("invalid job payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, asyncJobTimeout)
+	defer cancel()
+
+	resp, fetchErr := s.processFetch(ctx, &req)
+
+	// Jobs that were canceled, reassigned, or interrupted by shutdown have no final outcome to report.
+	if req.CallbackURL != "" && !errors.Is(ctx.Err(), context.Canceled) {
+		s.deliverWebhook(job.ID, &req, resp, fetchErr)
+	}
+
+	if fetchErr != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", req.URL, fetchErr)
+	}
+
+	result, err := json.Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job result: %w", err)
+	}
+	return result, nil
+}
+
+// buildJobResponse converts a job to its API representation.
+func buildJobResponse(job *jobs.Job) JobResponse {
+	resp := JobResponse{
+		ID:        job.ID,
+		Type:      jobTypeFetch,
+		Status:    job.Status,
+		Attempts:  job.Attempts,
+		CreatedAt: job.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: job.UpdatedAt.UTC().Format(time.RFC3339),
+		Error:     job.Error,
+	}
+
+	switch jobType(job.Payload) {
+	case jobTypeCrawl:
+		resp.Type = jobTypeCrawl
+		if len(job.Progress) > 0 {
+			var progress CrawlProgress
+			if err := json.Unmarshal(job.Progress, &progress); err == nil {
+				resp.Progress = &progress
+			}
+		}
+		if len(job.Result) > 0 {
+			var result CrawlResult
+			if err := json.Unmarshal(job.Result, &result); err == nil {
+				resp.Crawl = &result
+			}
+		}
+		return resp
+	case jobTypePreload:
+		resp.Type = jobTypePreload
+		if len(job.Result) > 0 {
+			var result PreloadResult
+			if err := json.Unmarshal(job.Result, &result); err == nil {
+				resp.Preload = &result
+			}
+		}
+		return resp
+	}
+
+	if len(job.Result) > 0 {
+		var result FetchResponse
+		if err := json.Unmarshal(job.Result, &result); err == nil {
+			resp.Result = &result
+		}
+	}
+
+	return resp
+}
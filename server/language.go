@@ -0,0 +1,31 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/hreflang"
+)
+
+// selectLanguage returns the hreflang variant of page that matches the requested language,
+// fetched with the request's options. page itself is returned when it lists no variant in that
+// language, is already that variant, or the variant fails to fetch.
+func (s *Server) selectLanguage(ctx context.Context, page *client.Response, req *FetchRequest) *client.Response {
+	alt, ok := hreflang.Select(page.Alternates, req.Language)
+	if !ok || alt.URL == page.URL || alt.URL == req.URL {
+		return page
+	}
+
+	variant, err := s.client.FetchWithOptions(ctx, alt.URL, req.fetchOptions())
+	if err != nil || variant.StatusCode != http.StatusOK {
+		s.logger.Debug("failed to fetch language variant", "url", alt.URL, "language", alt.Lang, "error", err)
+		return page
+	}
+	if req.session != nil {
+		s.recordSessionFetch(ctx, req, variant)
+	}
+
+	s.logger.Debug("selected language variant", "url", req.URL, "variant", alt.URL, "language", alt.Lang)
+	return variant
+}
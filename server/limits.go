@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/config"
+	"github.com/joeychilson/websurfer/fetcher"
+)
+
+const (
+	// LimitMaxDuration means a map or crawl ran for the server's max_duration.
+	LimitMaxDuration = "max_duration"
+	// LimitMaxPages means a map or crawl fetched the server's max_pages.
+	LimitMaxPages = "max_pages"
+	// LimitMaxBytes means a map or crawl fetched the server's max_bytes.
+	LimitMaxBytes = "max_bytes"
+	// LimitMaxExternalFetches means a map or crawl made the server's max_external_fetches.
+	LimitMaxExternalFetches = "max_external_fetches"
+	// LimitMaxURLs means a map discovered the server's max_urls.
+	LimitMaxURLs = "max_urls"
+)
+
+var (
+	// errLimitReached is returned for fetches refused after a safety limit was reached.
+	errLimitReached = errors.New("safety limit reached")
+	// errDurationLimit is the cause of a map or crawl context that ran out of time.
+	errDurationLimit = errors.New("max_duration reached")
+)
+
+// limitGuard enforces the server's safety limits across the fetches of one map or crawl. A nil
+// limitGuard fetches without limits.
+type limitGuard struct {
+	limits   config.LimitsConfig
+	mu       sync.Mutex
+	pages    int
+	bytes    int64
+	external int
+	reached  string
+	// urlsCapped is set when a map stopped collecting URLs at max_urls.
+	urlsCapped bool
+}
+
+// newLimitGuard returns a guard for one map or crawl and a context bounded by max_duration.
+func (s *Server) newLimitGuard(ctx context.Context) (*limitGuard, context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeoutCause(ctx, s.limits.GetMaxDuration(), errDurationLimit)
+	return &limitGuard{limits: s.limits}, ctx, cancel
+}
+
+// fetch fetches pageURL with c, refusing with errLimitReached once a limit has been reached.
+// The fetch that crosses the byte or origin fetch limit still succeeds; later ones are refused.
+func (g *limitGuard) fetch(ctx context.Context, c *client.Client, pageURL string, opts *client.FetchOptions) (*client.Response, error) {
+	if err := g.reserve(); err != nil {
+		return nil, err
+	}
+	return g.fetchReserved(ctx, c, pageURL, opts)
+}
+
+// reserve counts a fetch against the limits before it is made, refusing with errLimitReached
+// once a limit has been reached. Reserving in order and fetching concurrently keeps which
+// fetches a limit cuts independent of which finish first.
+func (g *limitGuard) reserve() error {
+	if g == nil {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch {
+	case g.reached != "":
+	case g.pages >= g.limits.GetMaxPages():
+		g.reached = LimitMaxPages
+	case g.bytes >= g.limits.GetMaxBytes():
+		g.reached = LimitMaxBytes
+	case g.external >= g.limits.GetMaxExternalFetches():
+		g.reached = LimitMaxExternalFetches
+	}
+	if g.reached != "" {
+		return fmt.Errorf("%w: %s", errLimitReached, g.reached)
+	}
+	g.pages++
+	return nil
+}
+
+// fetchReserved fetches pageURL with c for a fetch already reserved, counting its bytes and
+// whether it went to the origin.
+func (g *limitGuard) fetchReserved(ctx context.Context, c *client.Client, pageURL string, opts *client.FetchOptions) (*client.Response, error) {
+	resp, err := c.FetchWithOptions(ctx, pageURL, opts)
+	if err != nil || g == nil {
+		return resp, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.bytes += int64(len(resp.Body))
+	if resp.CacheState != client.CacheStateHit && resp.CacheState != client.CacheStateStale {
+		g.external++
+	}
+	return resp, nil
+}
+
+// probe sends pageURL a HEAD request with c, counted against the limits as an origin fetch
+// without a body.
+func (g *limitGuard) probe(ctx context.Context, c *client.Client, pageURL string) (*fetcher.ProbeResult, error) {
+	if err := g.reserve(); err != nil {
+		return nil, err
+	}
+
+	result, err := c.Probe(ctx, pageURL)
+	if err != nil || g == nil {
+		return result, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.external++
+	return result, nil
+}
+
+// capURLs records that a map stopped collecting URLs at the server's max_urls. Unlike the
+// other limits, it does not refuse later fetches, such as those for page metadata.
+func (g *limitGuard) capURLs() {
+	if g == nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.urlsCapped = true
+}
+
+// limitReached returns the limit that cut the map or crawl short, or an empty string. ctx is
+// the context returned by newLimitGuard.
+func (g *limitGuard) limitReached(ctx context.Context) string {
+	if g == nil {
+		return ""
+	}
+	if errors.Is(context.Cause(ctx), errDurationLimit) {
+		return LimitMaxDuration
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.reached == "" && g.urlsCapped {
+		return LimitMaxURLs
+	}
+	return g.reached
+}
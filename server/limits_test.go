@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joeychilson/websurfer/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProcessMapLimits verifies the server's page limit cuts a map short whatever it requests.
+func TestProcessMapLimits(t *testing.T) {
+	origin := newSitemapOrigin(t)
+	s := newMapTestServer(t)
+	s.limits = config.LimitsConfig{MaxPages: 2}
+
+	resp, err := s.processMap(context.Background(), &MapRequest{URL: origin.URL})
+	require.NoError(t, err)
+
+	assert.Equal(t, LimitMaxPages, resp.LimitReached)
+	assert.Equal(t, 4, resp.Total, "only the index and the first child sitemap should be fetched")
+}
+
+// TestProcessMapURLLimit verifies the server's URL limit caps a map without refusing metadata fetches.
+func TestProcessMapURLLimit(t *testing.T) {
+	origin := newSitemapOrigin(t)
+	s := newMapTestServer(t)
+	s.limits = config.LimitsConfig{MaxURLs: 3}
+
+	resp, err := s.processMap(context.Background(), &MapRequest{URL: origin.URL, IncludeMetadata: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, LimitMaxURLs, resp.LimitReached)
+	assert.Equal(t, 3, resp.Total)
+	assert.True(t, resp.Truncated)
+
+	resp, err = s.processMap(context.Background(), &MapRequest{URL: origin.URL, Limit: 2})
+	require.NoError(t, err)
+	assert.Empty(t, resp.LimitReached, "a request limit below max_urls is not a safety limit")
+}
+
+// TestProcessCrawlLimits verifies byte and duration limits stop a crawl and keep the pages visited so far.
+func TestProcessCrawlLimits(t *testing.T) {
+	var origin *httptest.Server
+	origin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><head><title>%[2]s</title></head><body><p>Page %[2]s</p><a href="%[1]s%[2]sa">Next A</a><a href="%[1]s%[2]sb">Next B</a></body></html>`, origin.URL, r.URL.Path)
+	}))
+	defer origin.Close()
+
+	s := newMapTestServer(t)
+
+	s.limits = config.LimitsConfig{MaxBytes: 1}
+	result, err := s.processCrawl(context.Background(), &CrawlRequest{URL: origin.URL + "/", MaxPages: 10, MaxDepth: 3}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, LimitMaxBytes, result.LimitReached)
+	require.Len(t, result.Pages, 1, "pages refused by the limit should not be reported")
+	assert.Equal(t, 1, result.Outline.Pages)
+
+	s.limits = config.LimitsConfig{MaxDuration: time.Nanosecond}
+	result, err = s.processCrawl(context.Background(), &CrawlRequest{URL: origin.URL + "/", MaxPages: 10, MaxDepth: 3, BypassCache: true}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, LimitMaxDuration, result.LimitReached)
+	assert.Empty(t, result.Pages)
+}
+
+// TestValidateCrawlRequestLimits verifies crawl requests may not exceed the server's page limit.
+func TestValidateCrawlRequestLimits(t *testing.T) {
+	s := &Server{limits: config.LimitsConfig{MaxPages: 50}}
+
+	req := CrawlRequest{URL: "https://example.com"}
+	require.NoError(t, s.validateCrawlRequest(&req))
+	assert.Equal(t, 50, req.MaxPages)
+
+	assert.Error(t, s.validateCrawlRequest(&CrawlRequest{URL: "https://example.com", MaxPages: 51}))
+}
+
+// TestValidateRequestTokenLimits verifies fetches get the server's default max_tokens and may
+// not exceed its ceiling.
+func TestValidateRequestTokenLimits(t *testing.T) {
+	s := newMapTestServer(t)
+	s.limits = config.LimitsConfig{DefaultMaxTokens: 2000, MaxTokens: 8000}
+
+	req := FetchRequest{URL: "https://example.com"}
+	require.NoError(t, s.validateRequest(&req))
+	assert.Equal(t, 2000, req.MaxTokens)
+
+	req = FetchRequest{URL: "https://example.com", MaxTokens: 8000}
+	require.NoError(t, s.validateRequest(&req))
+	assert.Equal(t, 8000, req.MaxTokens)
+
+	assert.ErrorContains(t, s.validateRequest(&FetchRequest{URL: "https://example.com", MaxTokens: 8001}), "at most 8000")
+
+	req = FetchRequest{URL: "https://example.com", Lines: &LineRange{Start: 1}}
+	require.NoError(t, s.validateRequest(&req))
+	assert.Zero(t, req.MaxTokens, "line ranges are not paginated")
+
+	s.limits = config.LimitsConfig{MaxTokens: 500}
+	req = FetchRequest{URL: "https://example.com"}
+	require.NoError(t, s.validateRequest(&req))
+	assert.Equal(t, 500, req.MaxTokens, "the ceiling is the default when none is set")
+}
+
+// TestFetchTokenLimit verifies line ranges over the server's max_tokens are refused.
+func TestFetchTokenLimit(t *testing.T) {
+	body := strings.Repeat("word ", 2000)
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(body))
+	}))
+	defer origin.Close()
+
+	s := newMapTestServer(t)
+	s.limits = config.LimitsConfig{MaxTokens: 200}
+	ctx := context.Background()
+
+	_, err := s.processFetch(ctx, &FetchRequest{URL: origin.URL, Lines: &LineRange{Start: 1}})
+	require.ErrorIs(t, err, errTokenLimit)
+	errResp := buildFetchError(origin.URL, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, errResp.StatusCode)
+	assert.Equal(t, ErrorCodeContentTooLarge, errResp.ErrorCode)
+
+	_, err = s.processFetch(ctx, &FetchRequest{URL: origin.URL, Lines: &LineRange{Start: 1, End: 1}})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/crawler"
+	urlpkg "github.com/joeychilson/websurfer/url"
+)
+
+const (
+	// maxLinkcheckLinks caps how many of a page's links a single link check verifies.
+	maxLinkcheckLinks = 200
+	// linkcheckWorkers is how many links are verified at once.
+	linkcheckWorkers = 8
+)
+
+const (
+	// LinkOK means the link responded with a 2xx status.
+	LinkOK = "ok"
+	// LinkRedirected means the link responded with a redirect, or was redirected to another URL.
+	LinkRedirected = "redirected"
+	// LinkBroken means the link responded with a 4xx or 5xx status, or did not respond.
+	LinkBroken = "broken"
+	// LinkSkipped means the link was not checked: the domain policy or robots.txt disallows
+	// it, or a safety limit was reached first.
+	LinkSkipped = "skipped"
+)
+
+// LinkcheckRequest asks for the links of a page to be verified.
+type LinkcheckRequest struct {
+	URL         string `json:"url"`
+	BypassCache bool   `json:"bypass_cache,omitempty"`
+}
+
+// LinkcheckResponse classifies each link of a page, in the order they appear. Truncated is set
+// when the page had more than maxLinkcheckLinks distinct links and only the first were checked.
+type LinkcheckResponse struct {
+	URL        string       `json:"url"`
+	Total      int          `json:"total"`
+	Truncated  bool         `json:"truncated,omitempty"`
+	OK         int          `json:"ok"`
+	Redirected int          `json:"redirected"`
+	Broken     int          `json:"broken"`
+	Skipped    int          `json:"skipped"`
+	Links      []LinkResult `json:"links"`
+	// LimitReached names the server safety limit that cut the check short, if any.
+	LimitReached string `json:"limit_reached,omitempty"`
+}
+
+// LinkResult is the classification of one link. Location is where a redirected link leads.
+// Error and ErrorCode explain broken links that did not respond and skipped links.
+type LinkResult struct {
+	URL        string `json:"url"`
+	Status     string `json:"status"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Location   string `json:"location,omitempty"`
+	External   bool   `json:"external,omitempty"`
+	Error      string `json:"error,omitempty"`
+	ErrorCode  string `json:"error_code,omitempty"`
+}
+
+// handleLinkcheck handles POST /v1/linkcheck requests.
+func (s *Server) handleLinkcheck(w http.ResponseWriter, r *http.Request) {
+	var req LinkcheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("failed to decode request", "error", err)
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.validateLinkcheckRequest(&req); err != nil {
+		s.logger.Error("invalid request", "error", err)
+		s.sendValidationError(w, err)
+		return
+	}
+
+	resp, err := s.processLinkcheck(r.Context(), &req)
+	if err != nil {
+		errResp := buildFetchError(req.URL, err)
+		s.logger.Error("link check failed", "url", req.URL, "error", err, "failure_type", errResp.FailureType)
+		s.sendJSON(w, errResp, errResp.StatusCode)
+		return
+	}
+
+	s.logger.Info("link check completed", "url", req.URL, "total", resp.Total, "broken", resp.Broken)
+	s.sendJSON(w, resp, http.StatusOK)
+}
+
+// validateLinkcheckRequest validates the link check request.
+func (s *Server) validateLinkcheckRequest(req *LinkcheckRequest) error {
+	if _, err := urlpkg.ValidateExternal(req.URL); err != nil {
+		return err
+	}
+	return s.policy.Check(req.URL)
+}
+
+// processLinkcheck fetches the page and verifies its distinct links concurrently, with a HEAD
+// request or a GET whose body is not read. The fetches are bounded by the server's limits.
+func (s *Server) processLinkcheck(ctx context.Context, req *LinkcheckRequest) (*LinkcheckResponse, error) {
+	guard, ctx, cancel := s.newLimitGuard(ctx)
+	defer cancel()
+
+	fetched, err := guard.fetch(ctx, s.client, req.URL, &client.FetchOptions{BypassCache: req.BypassCache})
+	if err != nil {
+		return nil, err
+	}
+	pageURL, err := url.Parse(fetched.URL)
+	if err != nil || pageURL.Host == "" {
+		if pageURL, err = url.Parse(req.URL); err != nil {
+			return nil, fmt.Errorf("invalid url: %w", err)
+		}
+	}
+
+	resp := &LinkcheckResponse{URL: req.URL, Links: []LinkResult{}}
+	seen := make(map[string]bool)
+	for _, link := range crawler.ExtractLinks(fetched.Body, pageURL, s.client.NormalizeURL) {
+		linkURL := link.String()
+		if seen[linkURL] {
+			continue
+		}
+		seen[linkURL] = true
+		if len(resp.Links) >= maxLinkcheckLinks {
+			resp.Truncated = true
+			break
+		}
+		resp.Links = append(resp.Links, LinkResult{URL: linkURL, External: link.Host != pageURL.Host})
+	}
+
+	sem := make(chan struct{}, linkcheckWorkers)
+	var wg sync.WaitGroup
+	for i := range resp.Links {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			s.checkLink(ctx, guard, &resp.Links[i])
+		}()
+	}
+	wg.Wait()
+
+	resp.Total = len(resp.Links)
+	for _, link := range resp.Links {
+		switch link.Status {
+		case LinkOK:
+			resp.OK++
+		case LinkRedirected:
+			resp.Redirected++
+		case LinkBroken:
+			resp.Broken++
+		case LinkSkipped:
+			resp.Skipped++
+		}
+	}
+	resp.LimitReached = guard.limitReached(ctx)
+	return resp, nil
+}
+
+// checkLink verifies a link and records its classification. Like the links a crawl follows,
+// links to private addresses are refused by the fetcher's SSRF protection.
+func (s *Server) checkLink(ctx context.Context, guard *limitGuard, link *LinkResult) {
+	skip := func(err error, code string) {
+		link.Status = LinkSkipped
+		link.Error = err.Error()
+		link.ErrorCode = code
+	}
+
+	if err := s.policy.Check(link.URL); err != nil {
+		skip(err, validationErrorCode(err))
+		return
+	}
+
+	result, err := guard.probe(ctx, s.client, link.URL)
+	switch {
+	case errors.Is(err, errLimitReached):
+		skip(err, ErrorCodeFetchFailed)
+		return
+	case err != nil && fetchErrorCode(err) == ErrorCodeRobotsBlocked:
+		skip(err, ErrorCodeRobotsBlocked)
+		return
+	case err != nil:
+		link.Status = LinkBroken
+		link.Error = err.Error()
+		link.ErrorCode = fetchErrorCode(err)
+		return
+	}
+
+	link.StatusCode = result.StatusCode
+	switch {
+	case result.StatusCode >= http.StatusBadRequest:
+		link.Status = LinkBroken
+	case result.StatusCode >= http.StatusMultipleChoices:
+		link.Status = LinkRedirected
+		if location := result.Headers.Get("Location"); location != "" {
+			link.Location = resolveLocation(link.URL, location)
+		}
+	case result.URL != "" && result.URL != link.URL:
+		link.Status = LinkRedirected
+		link.Location = result.URL
+	default:
+		link.Status = LinkOK
+	}
+}
+
+// resolveLocation resolves a Location header against the URL that sent it.
+func resolveLocation(base, location string) string {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return location
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+	return baseURL.ResolveReference(ref).String()
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProcessLinkcheck verifies each distinct link of a page is classified as ok, redirected,
+// broken, or skipped.
+func TestProcessLinkcheck(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><body><main>
+<p><a href="/docs">Docs</a> and <a href="/docs">the docs again</a></p>
+<p><a href="/old">Old page</a></p>
+<p><a href="/missing">Missing page</a></p>
+<p><a href="/private/admin">Admin</a></p>
+</main></body></html>`)
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private/\n")
+		case "/docs", "/new":
+			w.Header().Set("Content-Type", "text/html")
+		case "/old":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer origin.Close()
+
+	cfg := config.New()
+	respectRobots := true
+	cfg.Default.Fetch.RespectRobots = &respectRobots
+
+	c, err := client.New(cfg)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+	defer s.Close()
+
+	resp, err := s.processLinkcheck(context.Background(), &LinkcheckRequest{URL: origin.URL + "/"})
+	require.NoError(t, err)
+
+	links := make(map[string]LinkResult)
+	for _, link := range resp.Links {
+		links[link.URL] = link
+	}
+	require.Len(t, links, 4)
+	assert.Equal(t, 4, resp.Total)
+
+	assert.Equal(t, LinkOK, links[origin.URL+"/docs"].Status)
+	assert.Equal(t, http.StatusOK, links[origin.URL+"/docs"].StatusCode)
+
+	assert.Equal(t, LinkRedirected, links[origin.URL+"/old"].Status)
+	assert.Equal(t, origin.URL+"/new", links[origin.URL+"/old"].Location)
+
+	assert.Equal(t, LinkBroken, links[origin.URL+"/missing"].Status)
+	assert.Equal(t, http.StatusNotFound, links[origin.URL+"/missing"].StatusCode)
+
+	admin := links[origin.URL+"/private/admin"]
+	assert.Equal(t, LinkSkipped, admin.Status)
+	assert.Equal(t, ErrorCodeRobotsBlocked, admin.ErrorCode)
+
+	assert.Equal(t, 1, resp.OK)
+	assert.Equal(t, 1, resp.Redirected)
+	assert.Equal(t, 1, resp.Broken)
+	assert.Equal(t, 1, resp.Skipped)
+	assert.False(t, resp.Truncated)
+}
+
+// TestHandleLinkcheckValidation verifies link checks of invalid or private URLs are rejected.
+func TestHandleLinkcheckValidation(t *testing.T) {
+	router := newMapTestServer(t).Router()
+
+	for _, body := range []string{`not json`, `{"url": ""}`, `{"url": "http://127.0.0.1/"}`} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/linkcheck", bytes.NewBufferString(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
@@ -0,0 +1,537 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/crawler"
+	"github.com/joeychilson/websurfer/sitemap"
+	urlpkg "github.com/joeychilson/websurfer/url"
+)
+
+const (
+	// defaultMapLimit is how many URLs are returned when the request sets no limit.
+	defaultMapLimit = 1000
+	// maxMapLimit caps how many URLs a single map request may return.
+	maxMapLimit = 10000
+	// maxSitemaps caps how many sitemap files are fetched for one map request.
+	maxSitemaps = 50
+	// mapSitemapWorkers is how many sitemap files are fetched at once.
+	mapSitemapWorkers = 4
+	// maxMapMetadata caps how many pages are fetched for titles and descriptions.
+	maxMapMetadata = 200
+	// mapMetadataWorkers is how many pages are fetched for metadata at once.
+	mapMetadataWorkers = 8
+	// duplicateThreshold is how many pages must share a title or description for it to be
+	// flagged as a template placeholder.
+	duplicateThreshold = 3
+)
+
+const (
+	// MapSourceSitemap means URLs were discovered from the site's sitemap.
+	MapSourceSitemap = "sitemap"
+	// MapSourceLinks means the site had no sitemap and URLs were discovered from the page's links.
+	MapSourceLinks = "links"
+
+	// GroupBySection groups URLs by the first segment of their path.
+	GroupBySection = "section"
+
+	// MapMetadataFull reads page metadata by fetching and parsing each page.
+	MapMetadataFull = "full"
+	// MapMetadataHead reads page metadata from the cache, or else from a HEAD request.
+	MapMetadataHead = "head"
+)
+
+// MapRequest represents a request to discover the pages of a site.
+type MapRequest struct {
+	URL         string `json:"url"`
+	Limit       int    `json:"limit,omitempty"`
+	GroupBy     string `json:"group_by,omitempty"`
+	BypassCache bool   `json:"bypass_cache,omitempty"`
+	// IncludeMetadata fetches the title and description of up to 200 pages and flags values
+	// shared by many of them.
+	IncludeMetadata bool `json:"include_metadata,omitempty"`
+	// MetadataMode selects how metadata is read: "full" (default) fetches and parses each
+	// page, and "head" sends a HEAD request for its status, content type, and last
+	// modification time, taking titles and descriptions only from pages already cached.
+	MetadataMode string `json:"metadata_mode,omitempty"`
+	// IncludeExternal reports the off-site links found on the requested page and on up to 200
+	// discovered pages, along with the pages that link to them.
+	IncludeExternal bool `json:"include_external,omitempty"`
+	// RespectNofollow skips nofollow links, or all links of a page marked nofollow, when a site
+	// without a sitemap is mapped from the requested page's links.
+	RespectNofollow bool `json:"respect_nofollow,omitempty"`
+}
+
+// MapResponse lists the pages discovered on a site, either flat or grouped.
+type MapResponse struct {
+	URL       string         `json:"url"`
+	Source    string         `json:"source"`
+	Total     int            `json:"total"`
+	Truncated bool           `json:"truncated,omitempty"`
+	URLs      []MapURL       `json:"urls,omitempty"`
+	Groups    []MapGroup     `json:"groups,omitempty"`
+	External  []ExternalLink `json:"external,omitempty"`
+	// LimitReached names the server safety limit that cut the map short, if any.
+	LimitReached string `json:"limit_reached,omitempty"`
+	// CacheState reports whether the discovered URLs came from the map cache: "hit",
+	// "revalidated" when the site's sitemaps were checked and unchanged, or "miss". CachedAt is
+	// when they were discovered.
+	CacheState string `json:"cache_state,omitempty"`
+	CachedAt   string `json:"cached_at,omitempty"`
+}
+
+// MapURL is a discovered page. DuplicateTitle and DuplicateDescription are set when the value
+// is shared by several other pages, which usually means a template placeholder.
+type MapURL struct {
+	URL                  string `json:"url"`
+	LastModified         string `json:"last_modified,omitempty"`
+	Title                string `json:"title,omitempty"`
+	Description          string `json:"description,omitempty"`
+	CanonicalURL         string `json:"canonical_url,omitempty"`
+	DuplicateTitle       bool   `json:"duplicate_title,omitempty"`
+	DuplicateDescription bool   `json:"duplicate_description,omitempty"`
+	// StatusCode and ContentType are reported for pages read with metadata_mode "head".
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// MapGroup is a set of discovered pages sharing a section of the site.
+type MapGroup struct {
+	Section string   `json:"section"`
+	Count   int      `json:"count"`
+	URLs    []MapURL `json:"urls"`
+}
+
+// handleMap handles POST /v1/map requests.
+func (s *Server) handleMap(w http.ResponseWriter, r *http.Request) {
+	var req MapRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("failed to decode request", "error", err)
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.validateMapRequest(&req); err != nil {
+		s.logger.Error("invalid request", "error", err)
+		s.sendValidationError(w, err)
+		return
+	}
+
+	s.logger.Info("map request", "url", req.URL, "limit", req.Limit, "group_by", req.GroupBy)
+
+	resp, err := s.processMap(r.Context(), &req)
+	if err != nil {
+		errResp := buildFetchError(req.URL, err)
+		s.logger.Error("map failed", "url", req.URL, "error", err, "failure_type", errResp.FailureType)
+		s.sendJSON(w, errResp, errResp.StatusCode)
+		return
+	}
+
+	s.logger.Info("map completed", "url", req.URL, "source", resp.Source, "total", resp.Total)
+
+	s.sendJSON(w, resp, http.StatusOK)
+}
+
+// processMap discovers a site's pages from its sitemap, falling back to the links on the
+// requested page when the site has no sitemap. The fetches are bounded by the server's limits.
+func (s *Server) processMap(ctx context.Context, req *MapRequest) (*MapResponse, error) {
+	base, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+
+	guard, ctx, cancel := s.newLimitGuard(ctx)
+	defer cancel()
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultMapLimit
+	}
+
+	var external *externalLinks
+	if req.IncludeExternal {
+		external = newExternalLinks()
+	}
+
+	discovery, err := s.discoverMap(ctx, guard, base, limit, req, external)
+	if err != nil {
+		return nil, err
+	}
+	urls := discovery.URLs
+
+	switch {
+	case req.IncludeMetadata && req.MetadataMode == MapMetadataHead:
+		s.probeMapPages(ctx, guard, urls)
+	case req.IncludeMetadata || req.IncludeExternal:
+		s.fetchMapPages(ctx, guard, urls, req, external)
+	}
+	if req.IncludeMetadata {
+		flagDuplicates(urls)
+	}
+
+	resp := &MapResponse{
+		URL:          req.URL,
+		Source:       discovery.Source,
+		Total:        len(urls),
+		Truncated:    discovery.Truncated,
+		LimitReached: guard.limitReached(ctx),
+		CacheState:   discovery.cacheState,
+	}
+	if discovery.cacheState == MapCacheHit || discovery.cacheState == MapCacheRevalidated {
+		resp.CachedAt = discovery.StoredAt.UTC().Format(time.RFC3339)
+	}
+	if external != nil {
+		resp.External = external.list()
+	}
+	if req.GroupBy == GroupBySection {
+		resp.Groups = groupBySection(urls)
+	} else {
+		resp.URLs = urls
+	}
+
+	return resp, nil
+}
+
+// sitemapRoots returns the same-host sitemaps declared in base's robots.txt, and the sitemaps
+// discovery starts from: the declared ones, or /sitemap.xml if there are none.
+func (s *Server) sitemapRoots(ctx context.Context, base *url.URL) (declared, roots []string) {
+	all, err := s.client.GetSitemapsFromRobotsTxt(ctx, base.String())
+	if err != nil {
+		s.logger.Debug("failed to read robots.txt sitemaps", "url", base.String(), "error", err)
+	}
+	for _, sitemapURL := range all {
+		if u, err := url.Parse(sitemapURL); err == nil && u.Host == base.Host {
+			declared = append(declared, sitemapURL)
+		}
+	}
+	if len(declared) == 0 {
+		return nil, []string{(&url.URL{Scheme: base.Scheme, Host: base.Host, Path: "/sitemap.xml"}).String()}
+	}
+	return declared, declared
+}
+
+// sitemapResult is a fetched sitemap, or the error that kept it from being fetched.
+type sitemapResult struct {
+	url     string
+	sitemap *sitemap.Sitemap
+	err     error
+}
+
+// discoverFromSitemaps collects page URLs from the root sitemaps and any sitemap indexes they
+// link to. Only sitemaps on the requested host are followed. Sitemaps are fetched in waves of
+// up to mapSitemapWorkers, and their URLs are merged in queue order so the result does not
+// depend on which fetch finishes first. Once limit URLs are collected, fetches in flight are
+// canceled and no further sitemaps are fetched. It reports whether limit was reached.
+func (s *Server) discoverFromSitemaps(ctx context.Context, guard *limitGuard, base *url.URL, roots []string, limit int, bypassCache bool) ([]MapURL, bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	queue := slices.Clone(roots)
+	visited := make(map[string]bool)
+	seen := make(map[string]bool)
+
+	var urls []MapURL
+	for len(queue) > 0 && len(visited) < maxSitemaps {
+		var wave []chan sitemapResult
+		for len(queue) > 0 && len(wave) < mapSitemapWorkers && len(visited) < maxSitemaps {
+			sitemapURL := queue[0]
+			queue = queue[1:]
+			if visited[sitemapURL] {
+				continue
+			}
+			visited[sitemapURL] = true
+
+			result := make(chan sitemapResult, 1)
+			wave = append(wave, result)
+			if err := guard.reserve(); err != nil {
+				result <- sitemapResult{url: sitemapURL, err: err}
+				continue
+			}
+			go func() {
+				sm, err := s.fetchReservedSitemap(ctx, guard, sitemapURL, bypassCache)
+				result <- sitemapResult{url: sitemapURL, sitemap: sm, err: err}
+			}()
+		}
+
+		for _, result := range wave {
+			res := <-result
+			if res.err != nil {
+				s.logger.Debug("skipping sitemap", "url", res.url, "error", res.err)
+				continue
+			}
+
+			for _, child := range res.sitemap.Sitemaps {
+				if u, err := url.Parse(child); err == nil && u.Host == base.Host && !visited[child] {
+					queue = append(queue, child)
+				}
+			}
+
+			for _, u := range res.sitemap.URLs {
+				key := s.client.NormalizeURL(u.Loc)
+				if seen[key] {
+					continue
+				}
+				if len(urls) >= limit {
+					s.logger.Info("map url limit reached", "url", base.String(), "sitemaps", len(visited), "urls", len(urls))
+					return urls, true
+				}
+				seen[key] = true
+				urls = append(urls, MapURL{URL: u.Loc, LastModified: u.LastMod})
+			}
+		}
+
+		s.logger.Info("map progress", "url", base.String(), "sitemaps", len(visited), "queued", len(queue), "urls", len(urls))
+	}
+
+	return urls, false
+}
+
+// fetchSitemap fetches and parses a single sitemap, counting the fetch against guard if set.
+func (s *Server) fetchSitemap(ctx context.Context, guard *limitGuard, sitemapURL string, bypassCache bool) (*sitemap.Sitemap, error) {
+	if err := guard.reserve(); err != nil {
+		return nil, err
+	}
+	return s.fetchReservedSitemap(ctx, guard, sitemapURL, bypassCache)
+}
+
+// fetchReservedSitemap fetches and parses a single sitemap whose fetch was reserved with guard.
+func (s *Server) fetchReservedSitemap(ctx context.Context, guard *limitGuard, sitemapURL string, bypassCache bool) (*sitemap.Sitemap, error) {
+	fetched, err := guard.fetchReserved(ctx, s.client, sitemapURL, &client.FetchOptions{BypassCache: bypassCache})
+	if err != nil {
+		return nil, err
+	}
+	if fetched.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", fetched.StatusCode)
+	}
+
+	return sitemap.Parse(fetched.Body)
+}
+
+// discoverFromLinks collects the same-host links on the requested page, passing off-site
+// links to external if it is set. It reports whether limit was reached.
+func (s *Server) discoverFromLinks(ctx context.Context, guard *limitGuard, base *url.URL, limit int, req *MapRequest, external *externalLinks) ([]MapURL, bool, error) {
+	fetched, err := guard.fetch(ctx, s.client, base.String(), &client.FetchOptions{BypassCache: req.BypassCache})
+	if err != nil {
+		return nil, false, err
+	}
+
+	pageURL := base
+	if u, err := url.Parse(fetched.URL); err == nil && u.Host != "" {
+		pageURL = u
+	}
+
+	links := crawler.ExtractLinks(fetched.Body, pageURL, s.client.NormalizeURL)
+	if external != nil {
+		external.addFrom(pageURL, links)
+	}
+
+	if req.RespectNofollow && (slices.Contains(fetched.Robots, "nofollow") || slices.Contains(fetched.Robots, "none")) {
+		return nil, false, nil
+	}
+	nofollow := make(map[string]bool)
+	if req.RespectNofollow {
+		for _, link := range fetched.NofollowLinks {
+			nofollow[s.client.NormalizeURL(link)] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	var urls []MapURL
+	for _, u := range links {
+		if u.Host != pageURL.Host || nofollow[u.String()] {
+			continue
+		}
+
+		link := u.String()
+		if seen[link] {
+			continue
+		}
+		if len(urls) >= limit {
+			return urls, true, nil
+		}
+		seen[link] = true
+		urls = append(urls, MapURL{URL: link})
+	}
+
+	return urls, false, nil
+}
+
+// fetchMapPages fetches up to maxMapMetadata discovered pages, filling in their title and
+// description if requested and passing their off-site links to external if it is set. Pages
+// that fail to fetch are skipped.
+func (s *Server) fetchMapPages(ctx context.Context, guard *limitGuard, urls []MapURL, req *MapRequest, external *externalLinks) {
+	sem := make(chan struct{}, mapMetadataWorkers)
+	var wg sync.WaitGroup
+	for i := range urls[:min(len(urls), maxMapMetadata)] {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			fetched, err := guard.fetch(ctx, s.client, urls[i].URL, &client.FetchOptions{BypassCache: req.BypassCache})
+			if err != nil {
+				s.logger.Debug("skipping map page", "url", urls[i].URL, "error", err)
+				return
+			}
+
+			if req.IncludeMetadata {
+				urls[i].Title = strings.TrimSpace(fetched.Title)
+				urls[i].Description = strings.TrimSpace(fetched.Description)
+				if fetched.CanonicalURL != urls[i].URL {
+					urls[i].CanonicalURL = fetched.CanonicalURL
+				}
+			}
+
+			if external != nil {
+				if pageURL, err := url.Parse(urls[i].URL); err == nil {
+					external.addFrom(pageURL, crawler.ExtractLinks(fetched.Body, pageURL, s.client.NormalizeURL))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// probeMapPages reads the metadata of up to maxMapMetadata discovered pages without fetching
+// their bodies. Cached pages report their cached metadata, and the others are sent a HEAD
+// request. Pages that fail to respond are skipped.
+func (s *Server) probeMapPages(ctx context.Context, guard *limitGuard, urls []MapURL) {
+	sem := make(chan struct{}, mapMetadataWorkers)
+	var wg sync.WaitGroup
+	for i := range urls[:min(len(urls), maxMapMetadata)] {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if cached := s.client.Cached(ctx, urls[i].URL); cached != nil {
+				urls[i].StatusCode = cached.StatusCode
+				if values := cached.Headers["Content-Type"]; len(values) > 0 {
+					urls[i].ContentType = values[0]
+				}
+				urls[i].Title = strings.TrimSpace(cached.Title)
+				urls[i].Description = strings.TrimSpace(cached.Description)
+				if cached.CanonicalURL != urls[i].URL {
+					urls[i].CanonicalURL = cached.CanonicalURL
+				}
+				return
+			}
+
+			probed, err := guard.probe(ctx, s.client, urls[i].URL)
+			if err != nil {
+				s.logger.Debug("skipping map page", "url", urls[i].URL, "error", err)
+				return
+			}
+			urls[i].StatusCode = probed.StatusCode
+			urls[i].ContentType = probed.Headers.Get("Content-Type")
+			if modified, err := http.ParseTime(probed.Headers.Get("Last-Modified")); err == nil && urls[i].LastModified == "" {
+				urls[i].LastModified = modified.UTC().Format(time.RFC3339)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// flagDuplicates marks titles and descriptions shared by at least duplicateThreshold pages.
+func flagDuplicates(urls []MapURL) {
+	titles := make(map[string]int)
+	descriptions := make(map[string]int)
+	for _, u := range urls {
+		if u.Title != "" {
+			titles[u.Title]++
+		}
+		if u.Description != "" {
+			descriptions[u.Description]++
+		}
+	}
+
+	for i := range urls {
+		urls[i].DuplicateTitle = urls[i].Title != "" && titles[urls[i].Title] >= duplicateThreshold
+		urls[i].DuplicateDescription = urls[i].Description != "" && descriptions[urls[i].Description] >= duplicateThreshold
+	}
+}
+
+// groupBySection groups URLs by the first segment of their path, largest groups first.
+func groupBySection(urls []MapURL) []MapGroup {
+	index := make(map[string]int)
+	var groups []MapGroup
+	for _, u := range urls {
+		section := sectionOf(u.URL)
+		i, ok := index[section]
+		if !ok {
+			i = len(groups)
+			index[section] = i
+			groups = append(groups, MapGroup{Section: section})
+		}
+		groups[i].Count++
+		groups[i].URLs = append(groups[i].URLs, u)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Section < groups[j].Section
+	})
+
+	return groups
+}
+
+// sectionOf returns "/" plus the first path segment of a URL, or "/" for the site root.
+func sectionOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "/"
+	}
+
+	segment, _, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	return "/" + segment
+}
+
+// validateMapRequest validates the map request.
+func (s *Server) validateMapRequest(req *MapRequest) error {
+	if _, err := urlpkg.ValidateExternal(req.URL); err != nil {
+		return err
+	}
+
+	if err := s.policy.Check(req.URL); err != nil {
+		return err
+	}
+
+	if req.Limit < 0 || req.Limit > maxMapLimit {
+		return fmt.Errorf("limit must be between 0 and %d", maxMapLimit)
+	}
+
+	switch req.GroupBy {
+	case "", GroupBySection:
+	default:
+		return fmt.Errorf("group_by must be %q", GroupBySection)
+	}
+
+	switch req.MetadataMode {
+	case "", MapMetadataFull:
+	case MapMetadataHead:
+		if !req.IncludeMetadata {
+			return fmt.Errorf("metadata_mode requires include_metadata")
+		}
+		if req.IncludeExternal {
+			return fmt.Errorf("metadata_mode %q cannot be combined with include_external, which reads each page's links", MapMetadataHead)
+		}
+	default:
+		return fmt.Errorf("metadata_mode must be %q or %q", MapMetadataFull, MapMetadataHead)
+	}
+
+	return nil
+}
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// mapCachePrefix prefixes the Redis keys of cached map results.
+	mapCachePrefix = "websurfer:map:"
+	// mapCacheRetention is how long map results are kept for revalidation after their TTL.
+	mapCacheRetention = 24 * time.Hour
+)
+
+// Map cache states reported in MapResponse.CacheState.
+const (
+	MapCacheHit         = "hit"
+	MapCacheRevalidated = "revalidated"
+	MapCacheMiss        = "miss"
+)
+
+// mapDiscovery is the set of pages discovered on a site, before any are fetched for metadata
+// or external links. It is what the map cache stores.
+type mapDiscovery struct {
+	// URL is the requested page, which link discovery depends on, and Nofollow is set when
+	// link discovery skipped nofollow links.
+	URL       string   `json:"url"`
+	Nofollow  bool     `json:"nofollow,omitempty"`
+	Source    string   `json:"source"`
+	URLs      []MapURL `json:"urls"`
+	Truncated bool     `json:"truncated,omitempty"`
+	// Sitemaps are the same-host sitemaps declared in robots.txt, and Fingerprint digests the
+	// root sitemaps' entries and lastmods, so changes to either invalidate the result.
+	Sitemaps    []string  `json:"sitemaps,omitempty"`
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	StoredAt    time.Time `json:"stored_at"`
+
+	cacheState string
+}
+
+// mapCache stores map results per site in Redis.
+type mapCache struct {
+	client *redis.Client
+}
+
+// makeKey creates the Redis key holding the map result of base's site.
+func (m *mapCache) makeKey(base *url.URL) string {
+	return mapCachePrefix + base.Scheme + "://" + base.Host
+}
+
+// get returns the cached map result of base's site, or nil if there is none.
+func (m *mapCache) get(ctx context.Context, base *url.URL) (*mapDiscovery, error) {
+	data, err := m.client.Get(ctx, m.makeKey(base)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis get failed: %w", err)
+	}
+
+	var discovery mapDiscovery
+	if err := json.Unmarshal(data, &discovery); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal map result: %w", err)
+	}
+	return &discovery, nil
+}
+
+// set stores the map result of base's site.
+func (m *mapCache) set(ctx context.Context, base *url.URL, discovery *mapDiscovery) error {
+	data, err := json.Marshal(discovery)
+	if err != nil {
+		return fmt.Errorf("failed to marshal map result: %w", err)
+	}
+	if err := m.client.Set(ctx, m.makeKey(base), data, mapCacheRetention).Err(); err != nil {
+		return fmt.Errorf("redis set failed: %w", err)
+	}
+	return nil
+}
+
+// limited returns the discovered URLs cut to limit and whether more exist, or false if the
+// discovery was itself cut short below limit.
+func (d *mapDiscovery) limited(limit int) ([]MapURL, bool, bool) {
+	if len(d.URLs) > limit {
+		return d.URLs[:limit], true, true
+	}
+	if d.Truncated && len(d.URLs) < limit {
+		return nil, false, false
+	}
+	return d.URLs, d.Truncated, true
+}
+
+// discoverMap discovers a site's pages, serving them from the map cache when it holds a result
+// that is within the site's map TTL or whose sitemaps are unchanged. Fresh results are cached
+// unless a safety limit cut them short.
+func (s *Server) discoverMap(ctx context.Context, guard *limitGuard, base *url.URL, limit int, req *MapRequest, external *externalLinks) (*mapDiscovery, error) {
+	cacheConfig := s.client.Config().GetConfigForURL(base.String()).Cache
+	cacheable := s.mapCache != nil && cacheConfig.IsEnabled()
+
+	if cacheable && !req.BypassCache {
+		if cached := s.cachedMap(ctx, guard, base, limit, req, cacheConfig.GetMapTTL()); cached != nil {
+			return cached, nil
+		}
+	}
+
+	// The server's max_urls caps discovery whatever limit the request asks for.
+	budget := min(limit, s.limits.GetMaxURLs())
+
+	discovery := &mapDiscovery{URL: base.String(), Source: MapSourceSitemap, StoredAt: time.Now()}
+	var roots []string
+	discovery.Sitemaps, roots = s.sitemapRoots(ctx, base)
+	discovery.URLs, discovery.Truncated = s.discoverFromSitemaps(ctx, guard, base, roots, budget, req.BypassCache)
+	if len(discovery.URLs) == 0 {
+		var err error
+		discovery.Source = MapSourceLinks
+		discovery.Nofollow = req.RespectNofollow
+		discovery.URLs, discovery.Truncated, err = s.discoverFromLinks(ctx, guard, base, budget, req, external)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if discovery.Truncated && budget < limit {
+		guard.capURLs()
+	}
+
+	if !cacheable {
+		return discovery, nil
+	}
+	discovery.cacheState = MapCacheMiss
+
+	if guard.limitReached(ctx) != "" {
+		return discovery, nil
+	}
+	if discovery.Source == MapSourceSitemap {
+		discovery.Fingerprint = s.sitemapFingerprint(ctx, guard, roots, false)
+	}
+	if err := s.mapCache.set(ctx, base, discovery); err != nil {
+		s.logger.Warn("failed to cache map result", "url", base.String(), "error", err)
+	}
+	return discovery, nil
+}
+
+// cachedMap returns the cached map result of base's site cut to limit, or nil if it cannot be
+// served. Results past ttl are served only if they came from sitemaps that are unchanged.
+func (s *Server) cachedMap(ctx context.Context, guard *limitGuard, base *url.URL, limit int, req *MapRequest, ttl time.Duration) *mapDiscovery {
+	cached, err := s.mapCache.get(ctx, base)
+	if err != nil {
+		s.logger.Warn("failed to read cached map result", "url", base.String(), "error", err)
+		return nil
+	}
+	if cached == nil {
+		return nil
+	}
+
+	// Link discovery depends on the requested page and whether nofollow links are skipped, and
+	// reports its off-site links, which are not cached.
+	if cached.Source == MapSourceLinks && (cached.URL != base.String() || cached.Nofollow != req.RespectNofollow || req.IncludeExternal) {
+		return nil
+	}
+
+	urls, truncated, ok := cached.limited(limit)
+	if !ok {
+		return nil
+	}
+
+	cached.cacheState = MapCacheHit
+	if time.Since(cached.StoredAt) >= ttl {
+		if cached.Source != MapSourceSitemap || cached.Fingerprint == "" {
+			return nil
+		}
+
+		declared, roots := s.sitemapRoots(ctx, base)
+		if !slices.Equal(declared, cached.Sitemaps) {
+			s.logger.Debug("map cache invalidated (robots.txt sitemaps changed)", "url", base.String())
+			return nil
+		}
+		if s.sitemapFingerprint(ctx, guard, roots, true) != cached.Fingerprint {
+			s.logger.Debug("map cache invalidated (sitemaps changed)", "url", base.String())
+			return nil
+		}
+
+		cached.StoredAt = time.Now()
+		if err := s.mapCache.set(ctx, base, cached); err != nil {
+			s.logger.Warn("failed to cache map result", "url", base.String(), "error", err)
+		}
+		cached.cacheState = MapCacheRevalidated
+	}
+
+	cached.URLs, cached.Truncated = urls, truncated
+	return cached
+}
+
+// sitemapFingerprint digests the entries and lastmods of the root sitemaps, or returns "" if
+// any cannot be fetched.
+func (s *Server) sitemapFingerprint(ctx context.Context, guard *limitGuard, roots []string, bypassCache bool) string {
+	hash := sha256.New()
+	for _, root := range roots {
+		sm, err := s.fetchSitemap(ctx, guard, root, bypassCache)
+		if err != nil {
+			s.logger.Debug("failed to fingerprint sitemap", "url", root, "error", err)
+			return ""
+		}
+
+		fmt.Fprintf(hash, "%s\n", root)
+		for _, child := range sm.Children {
+			fmt.Fprintf(hash, "sitemap\t%s\t%s\n", child.Loc, child.LastMod)
+		}
+		for _, u := range sm.URLs {
+			fmt.Fprintf(hash, "url\t%s\t%s\n", u.Loc, u.LastMod)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProcessMapCache verifies repeated maps are served from the map cache, revalidated
+// against the sitemap index once stale, and rediscovered when its lastmods change.
+func TestProcessMapCache(t *testing.T) {
+	var lastmod atomic.Value
+	lastmod.Store("2025-01-01")
+	var childFetches atomic.Int32
+
+	var origin *httptest.Server
+	origin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<sitemapindex><sitemap><loc>%s/sitemap-docs.xml</loc><lastmod>%s</lastmod></sitemap></sitemapindex>`, origin.URL, lastmod.Load())
+		case "/sitemap-docs.xml":
+			childFetches.Add(1)
+			fmt.Fprintf(w, `<urlset><url><loc>%[1]s/a</loc></url><url><loc>%[1]s/b</loc></url><url><loc>%[1]s/c</loc></url></urlset>`, origin.URL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer origin.Close()
+
+	s := newJobTestServer(t)
+	ctx := context.Background()
+	base, err := url.Parse(origin.URL)
+	require.NoError(t, err)
+
+	resp, err := s.processMap(ctx, &MapRequest{URL: origin.URL})
+	require.NoError(t, err)
+	assert.Equal(t, MapCacheMiss, resp.CacheState)
+	assert.Equal(t, 3, resp.Total)
+	assert.Equal(t, int32(1), childFetches.Load())
+
+	resp, err = s.processMap(ctx, &MapRequest{URL: origin.URL, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, MapCacheHit, resp.CacheState)
+	assert.NotEmpty(t, resp.CachedAt)
+	assert.Equal(t, 2, resp.Total)
+	assert.True(t, resp.Truncated)
+	assert.Equal(t, int32(1), childFetches.Load(), "a cached map should not refetch sitemaps")
+
+	expire := func() {
+		cached, err := s.mapCache.get(ctx, base)
+		require.NoError(t, err)
+		cached.StoredAt = time.Now().Add(-time.Hour)
+		require.NoError(t, s.mapCache.set(ctx, base, cached))
+	}
+
+	expire()
+	resp, err = s.processMap(ctx, &MapRequest{URL: origin.URL})
+	require.NoError(t, err)
+	assert.Equal(t, MapCacheRevalidated, resp.CacheState)
+	assert.Equal(t, 3, resp.Total)
+	assert.Equal(t, int32(1), childFetches.Load(), "revalidation should only fetch the root sitemaps")
+
+	expire()
+	lastmod.Store("2025-02-01")
+	resp, err = s.processMap(ctx, &MapRequest{URL: origin.URL})
+	require.NoError(t, err)
+	assert.Equal(t, MapCacheMiss, resp.CacheState)
+	assert.Equal(t, int32(2), childFetches.Load())
+
+	resp, err = s.processMap(ctx, &MapRequest{URL: origin.URL, BypassCache: true})
+	require.NoError(t, err)
+	assert.Equal(t, MapCacheMiss, resp.CacheState)
+}
+
+// TestMapDiscoveryLimited verifies cached results serve smaller limits, and larger ones only
+// when the cached discovery was complete.
+func TestMapDiscoveryLimited(t *testing.T) {
+	discovery := &mapDiscovery{URLs: []MapURL{{URL: "a"}, {URL: "b"}, {URL: "c"}}}
+
+	urls, truncated, ok := discovery.limited(2)
+	assert.True(t, ok)
+	assert.True(t, truncated)
+	assert.Len(t, urls, 2)
+
+	urls, truncated, ok = discovery.limited(10)
+	assert.True(t, ok)
+	assert.False(t, truncated)
+	assert.Len(t, urls, 3)
+
+	discovery.Truncated = true
+	_, _, ok = discovery.limited(10)
+	assert.False(t, ok)
+
+	_, truncated, ok = discovery.limited(3)
+	assert.True(t, ok)
+	assert.True(t, truncated)
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"net/url"
+	"sort"
+	"sync"
+)
+
+const (
+	// maxExternalLinks caps how many distinct off-site links a map request reports.
+	maxExternalLinks = 1000
+	// maxExternalReferrers caps how many referring pages are listed per off-site link.
+	maxExternalReferrers = 20
+)
+
+// ExternalLink is an off-site link found while mapping, with the pages that link to it.
+// Count is the number of distinct referring pages, which may exceed len(Referrers).
+type ExternalLink struct {
+	URL       string   `json:"url"`
+	Count     int      `json:"count"`
+	Referrers []string `json:"referrers"`
+}
+
+// externalLinks collects off-site links from concurrently fetched pages.
+type externalLinks struct {
+	mu        sync.Mutex
+	links     map[string]*ExternalLink
+	referrers map[string]map[string]bool
+}
+
+// newExternalLinks creates an empty external link collector.
+func newExternalLinks() *externalLinks {
+	return &externalLinks{
+		links:     make(map[string]*ExternalLink),
+		referrers: make(map[string]map[string]bool),
+	}
+}
+
+// addFrom records the links on pageURL that point to another host.
+func (e *externalLinks) addFrom(pageURL *url.URL, links []*url.URL) {
+	referrer := pageURL.String()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, u := range links {
+		if u.Host == pageURL.Host {
+			continue
+		}
+
+		link := u.String()
+		entry, ok := e.links[link]
+		if !ok {
+			if len(e.links) >= maxExternalLinks {
+				continue
+			}
+			entry = &ExternalLink{URL: link}
+			e.links[link] = entry
+			e.referrers[link] = make(map[string]bool)
+		}
+
+		if e.referrers[link][referrer] {
+			continue
+		}
+		e.referrers[link][referrer] = true
+		entry.Count++
+		if len(entry.Referrers) < maxExternalReferrers {
+			entry.Referrers = append(entry.Referrers, referrer)
+		}
+	}
+}
+
+// list returns the collected links, most referenced first.
+func (e *externalLinks) list() []ExternalLink {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	links := make([]ExternalLink, 0, len(e.links))
+	for _, link := range e.links {
+		sort.Strings(link.Referrers)
+		links = append(links, *link)
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].Count != links[j].Count {
+			return links[i].Count > links[j].Count
+		}
+		return links[i].URL < links[j].URL
+	})
+
+	return links
+}
@@ -0,0 +1,283 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/joeychilson/websurfer/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMapTestServer creates a server for map tests.
+func newMapTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// newSitemapOrigin starts a site whose sitemap index lists a docs sitemap and a blog sitemap.
+func newSitemapOrigin(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var origin *httptest.Server
+	origin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<sitemapindex>
+  <sitemap><loc>%[1]s/sitemap-docs.xml</loc></sitemap>
+  <sitemap><loc>%[1]s/sitemap-blog.xml</loc></sitemap>
+  <sitemap><loc>https://other.example.com/sitemap.xml</loc></sitemap>
+</sitemapindex>`, origin.URL)
+		case "/sitemap-docs.xml":
+			fmt.Fprintf(w, `<urlset>
+  <url><loc>%[1]s/</loc></url>
+  <url><loc>%[1]s/docs/intro</loc><lastmod>2024-01-02</lastmod></url>
+  <url><loc>%[1]s/docs/install</loc></url>
+  <url><loc>%[1]s/docs/api/fetch</loc></url>
+</urlset>`, origin.URL)
+		case "/sitemap-blog.xml":
+			fmt.Fprintf(w, `<urlset>
+  <url><loc>%[1]s/blog/launch</loc></url>
+  <url><loc>%[1]s/docs/intro</loc></url>
+</urlset>`, origin.URL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(origin.Close)
+
+	return origin
+}
+
+// TestProcessMapSitemap verifies URLs are collected from nested sitemaps and deduplicated.
+func TestProcessMapSitemap(t *testing.T) {
+	origin := newSitemapOrigin(t)
+	s := newMapTestServer(t)
+
+	resp, err := s.processMap(context.Background(), &MapRequest{URL: origin.URL})
+	require.NoError(t, err)
+
+	assert.Equal(t, MapSourceSitemap, resp.Source)
+	assert.Equal(t, 5, resp.Total)
+	assert.False(t, resp.Truncated)
+	assert.Empty(t, resp.Groups)
+	require.Len(t, resp.URLs, 5)
+	assert.Equal(t, MapURL{URL: origin.URL + "/docs/intro", LastModified: "2024-01-02"}, resp.URLs[1])
+}
+
+// TestProcessMapGroupBySection verifies URLs are grouped by first path segment with counts.
+func TestProcessMapGroupBySection(t *testing.T) {
+	origin := newSitemapOrigin(t)
+	s := newMapTestServer(t)
+
+	resp, err := s.processMap(context.Background(), &MapRequest{URL: origin.URL, GroupBy: GroupBySection})
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, resp.Total)
+	assert.Empty(t, resp.URLs)
+	require.Len(t, resp.Groups, 3)
+
+	assert.Equal(t, "/docs", resp.Groups[0].Section)
+	assert.Equal(t, 3, resp.Groups[0].Count)
+	assert.Len(t, resp.Groups[0].URLs, 3)
+	assert.Equal(t, "/", resp.Groups[1].Section)
+	assert.Equal(t, "/blog", resp.Groups[2].Section)
+}
+
+// TestProcessMapLimit verifies results are capped and marked truncated.
+func TestProcessMapLimit(t *testing.T) {
+	origin := newSitemapOrigin(t)
+	s := newMapTestServer(t)
+
+	resp, err := s.processMap(context.Background(), &MapRequest{URL: origin.URL, Limit: 2})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, resp.Total)
+	assert.True(t, resp.Truncated)
+}
+
+// TestProcessMapLinksFallback verifies same-host page links are used when there is no sitemap.
+func TestProcessMapLinksFallback(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><main>
+<p><a href="/docs/intro">Intro</a> <a href="/docs/intro#setup">Setup</a></p>
+<p><a href="https://other.example.com/page">Elsewhere</a> <a href="mailto:hi@example.com">Mail</a></p>
+<p><a href="/pricing">Pricing</a></p>
+</main></body></html>`))
+	}))
+	defer origin.Close()
+
+	s := newMapTestServer(t)
+
+	resp, err := s.processMap(context.Background(), &MapRequest{URL: origin.URL + "/"})
+	require.NoError(t, err)
+
+	assert.Equal(t, MapSourceLinks, resp.Source)
+	assert.Equal(t, []MapURL{{URL: origin.URL + "/docs/intro"}, {URL: origin.URL + "/pricing"}}, resp.URLs)
+}
+
+// TestProcessMapDuplicateMetadata verifies titles and descriptions shared by many pages are flagged.
+func TestProcessMapDuplicateMetadata(t *testing.T) {
+	var origin *httptest.Server
+	origin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprintf(w, `<urlset>
+  <url><loc>%[1]s/a</loc></url>
+  <url><loc>%[1]s/b</loc></url>
+  <url><loc>%[1]s/c</loc></url>
+  <url><loc>%[1]s/about</loc></url>
+</urlset>`, origin.URL)
+		case "/about":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head><title>About Us</title><meta name="description" content="Site description"></head><body><p>About</p></body></html>`))
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head><title>Untitled</title><meta name="description" content="Site description"></head><body><p>Page</p></body></html>`))
+		}
+	}))
+	defer origin.Close()
+
+	s := newMapTestServer(t)
+
+	resp, err := s.processMap(context.Background(), &MapRequest{URL: origin.URL, IncludeMetadata: true})
+	require.NoError(t, err)
+	require.Len(t, resp.URLs, 4)
+
+	for _, u := range resp.URLs[:3] {
+		assert.Equal(t, "Untitled", u.Title)
+		assert.True(t, u.DuplicateTitle, u.URL)
+		assert.True(t, u.DuplicateDescription, u.URL)
+	}
+
+	about := resp.URLs[3]
+	assert.Equal(t, "About Us", about.Title)
+	assert.False(t, about.DuplicateTitle)
+	assert.True(t, about.DuplicateDescription)
+}
+
+// TestProcessMapHeadMetadata verifies metadata_mode "head" reads pages with HEAD requests
+// instead of fetching them.
+func TestProcessMapHeadMetadata(t *testing.T) {
+	var gets atomic.Int32
+	var origin *httptest.Server
+	origin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprintf(w, `<urlset>
+  <url><loc>%[1]s/guide</loc><lastmod>2026-01-02</lastmod></url>
+  <url><loc>%[1]s/report.pdf</loc></url>
+  <url><loc>%[1]s/gone</loc></url>
+</urlset>`, origin.URL)
+			return
+		case "/gone", "/robots.txt":
+			http.NotFound(w, r)
+			return
+		case "/report.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Header().Set("Last-Modified", "Tue, 03 Mar 2026 10:00:00 GMT")
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Last-Modified", "Wed, 04 Mar 2026 10:00:00 GMT")
+		}
+		if r.Method == http.MethodGet {
+			gets.Add(1)
+		}
+	}))
+	defer origin.Close()
+
+	s := newMapTestServer(t)
+
+	resp, err := s.processMap(context.Background(), &MapRequest{URL: origin.URL, IncludeMetadata: true, MetadataMode: MapMetadataHead})
+	require.NoError(t, err)
+
+	assert.Equal(t, []MapURL{
+		{URL: origin.URL + "/guide", LastModified: "2026-01-02", StatusCode: http.StatusOK, ContentType: "text/html"},
+		{URL: origin.URL + "/report.pdf", LastModified: "2026-03-03T10:00:00Z", StatusCode: http.StatusOK, ContentType: "application/pdf"},
+		{URL: origin.URL + "/gone", StatusCode: http.StatusNotFound, ContentType: "text/plain; charset=utf-8"},
+	}, resp.URLs)
+	assert.Zero(t, gets.Load(), "pages should not be fetched")
+}
+
+// TestProcessMapExternalLinks verifies off-site links are reported with their referring pages.
+func TestProcessMapExternalLinks(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><body><main>
+<p><a href="/docs">Docs</a> <a href="https://go.dev/ref/spec">Spec</a></p>
+<p><a href="https://pkg.go.dev/net/http#Client">Client</a></p>
+</main></body></html>`))
+		case "/docs":
+			w.Write([]byte(`<html><body><main>
+<p><a href="https://go.dev/ref/spec">Spec</a> <a href="https://go.dev/ref/spec">Spec again</a></p>
+</main></body></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer origin.Close()
+
+	s := newMapTestServer(t)
+
+	resp, err := s.processMap(context.Background(), &MapRequest{URL: origin.URL + "/", IncludeExternal: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, []MapURL{{URL: origin.URL + "/docs"}}, resp.URLs)
+	assert.Equal(t, []ExternalLink{
+		{URL: "https://go.dev/ref/spec", Count: 2, Referrers: []string{origin.URL + "/", origin.URL + "/docs"}},
+		{URL: "https://pkg.go.dev/net/http", Count: 1, Referrers: []string{origin.URL + "/"}},
+	}, resp.External)
+}
+
+// TestHandleMapInvalidRequest verifies invalid map requests are rejected.
+func TestHandleMapInvalidRequest(t *testing.T) {
+	s := newMapTestServer(t)
+
+	tests := []string{
+		`{"url": "https://example.com", "group_by": "domain"}`,
+		`{"url": "https://example.com", "limit": -1}`,
+		`{"url": "https://example.com", "limit": 100000}`,
+		`{"url": "http://127.0.0.1"}`,
+		`{"url": "https://example.com", "metadata_mode": "body"}`,
+		`{"url": "https://example.com", "metadata_mode": "head"}`,
+		`{"url": "https://example.com", "include_metadata": true, "include_external": true, "metadata_mode": "head"}`,
+	}
+
+	for _, body := range tests {
+		w := httptest.NewRecorder()
+		s.Router().ServeHTTP(w, httptest.NewRequest("POST", "/v1/map", bytes.NewBufferString(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
+
+// TestSectionOf verifies sections are the first path segment.
+func TestSectionOf(t *testing.T) {
+	assert.Equal(t, "/", sectionOf("https://example.com"))
+	assert.Equal(t, "/", sectionOf("https://example.com/"))
+	assert.Equal(t, "/docs", sectionOf("https://example.com/docs"))
+	assert.Equal(t, "/docs", sectionOf("https://example.com/docs/api/fetch?x=1"))
+}
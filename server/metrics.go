@@ -0,0 +1,80 @@
+package server
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// crawlMetric is a per-job metric of the crawls running on this instance.
+type crawlMetric struct {
+	name   string
+	kind   string
+	help   string
+	value  func(CrawlProgress) float64
+	always bool
+}
+
+// crawlMetrics are the per-job crawl metrics exposed by GET /metrics.
+var crawlMetrics = []crawlMetric{
+	{"websurfer_crawl_pages_discovered", "gauge", "Distinct pages the crawl has found.", func(p CrawlProgress) float64 { return float64(p.Discovered) }, true},
+	{"websurfer_crawl_pages_fetched_total", "counter", "Pages the crawl has fetched.", func(p CrawlProgress) float64 { return float64(p.Fetched) }, true},
+	{"websurfer_crawl_pages_failed_total", "counter", "Pages the crawl failed to fetch.", func(p CrawlProgress) float64 { return float64(p.Failed) }, true},
+	{"websurfer_crawl_bytes_downloaded_total", "counter", "Bytes the crawl has downloaded from origin.", func(p CrawlProgress) float64 { return float64(p.Bytes) }, true},
+	{"websurfer_crawl_pages_per_second", "gauge", "Pages the crawl visited per second over the last minute.", func(p CrawlProgress) float64 { return p.PagesPerSecond }, true},
+	{"websurfer_crawl_eta_seconds", "gauge", "Estimated seconds until the crawl has visited the pages it discovered.", func(p CrawlProgress) float64 { return float64(p.ETASeconds) }, false},
+}
+
+// handleMetrics handles GET /metrics requests, exposing the progress of the crawl jobs running
+// on this instance, and the outcomes of its background cache refreshes, in the Prometheus text
+// format. Each job's series are labeled with its job_id and dropped once it finishes.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	crawls := s.crawls.snapshot()
+	ids := slices.Sorted(maps.Keys(crawls))
+
+	var b strings.Builder
+	writeMetricHeader(&b, "websurfer_crawl_jobs_running", "gauge", "Crawl jobs running on this instance.")
+	fmt.Fprintf(&b, "websurfer_crawl_jobs_running %d\n", len(ids))
+
+	for _, metric := range crawlMetrics {
+		writeMetricHeader(&b, metric.name, metric.kind, metric.help)
+		for _, id := range ids {
+			progress := crawls[id]
+			if !metric.always && metric.value(progress) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "%s{job_id=%q} %s\n", metric.name, id, strconv.FormatFloat(metric.value(progress), 'g', -1, 64))
+		}
+	}
+
+	refresh := s.client.Stats().Refresh
+	writeMetricHeader(&b, "websurfer_cache_refreshes_total", "counter", "Background cache refreshes by result.")
+	for _, result := range []struct {
+		name  string
+		count int64
+	}{
+		{"updated", refresh.Updated},
+		{"skipped", refresh.Skipped},
+		{"not_modified", refresh.NotModified},
+		{"failed", refresh.Failed},
+	} {
+		fmt.Fprintf(&b, "websurfer_cache_refreshes_total{result=%q} %d\n", result.name, result.count)
+	}
+	writeMetricHeader(&b, "websurfer_cache_refresh_change_ratio", "summary", "Fraction of lines changed by background refreshes that fetched new content.")
+	fmt.Fprintf(&b, "websurfer_cache_refresh_change_ratio_sum %s\n", strconv.FormatFloat(refresh.ChangeSum, 'g', -1, 64))
+	fmt.Fprintf(&b, "websurfer_cache_refresh_change_ratio_count %d\n", refresh.Updated+refresh.Skipped)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(b.String())); err != nil {
+		s.logger.Error("failed to write metrics", "error", err)
+	}
+}
+
+// writeMetricHeader writes the HELP and TYPE lines of a metric.
+func writeMetricHeader(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
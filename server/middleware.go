@@ -1,7 +1,10 @@
 package server
 
 import (
+	"crypto/sha256"
 	"crypto/subtle"
+	"encoding/hex"
+	"net"
 	"net/http"
 	"os"
 	"strings"
@@ -10,6 +13,9 @@ import (
 	"github.com/go-chi/httprate"
 	httprateredis "github.com/go-chi/httprate-redis"
 	"github.com/redis/go-redis/v9"
+
+	"github.com/joeychilson/websurfer/config"
+	"github.com/joeychilson/websurfer/ratelimit"
 )
 
 // RateLimitConfig holds configuration for the rate limiter.
@@ -36,7 +42,7 @@ func RateLimit(config RateLimitConfig) func(next http.Handler) http.Handler {
 	limitHandler := func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusTooManyRequests)
-		w.Write([]byte(`{"error":"rate limit exceeded","status_code":429}`))
+		w.Write([]byte(`{"error":"rate limit exceeded","status_code":429,"error_code":"RATE_LIMITED"}`))
 	}
 
 	baseOptions := []httprate.Option{
@@ -60,12 +66,32 @@ func RateLimit(config RateLimitConfig) func(next http.Handler) http.Handler {
 }
 
 // AuthMiddleware returns a middleware that validates API key from Authorization header or X-API-Key header.
-// The API key is loaded from the API_KEY environment variable.
-// If API_KEY is not set, the middleware is disabled and all requests are allowed.
-func AuthMiddleware() func(next http.Handler) http.Handler {
-	apiKey := os.Getenv("API_KEY")
+// The API key is loaded from the API_KEY environment variable, and each caller's and peer's key
+// from its api_key_env variable; a request sending any of them is allowed.
+// If no key is set, the middleware is disabled and all requests are allowed.
+func AuthMiddleware(callers []config.CallerConfig, peers []config.PeerConfig) func(next http.Handler) http.Handler {
+	var apiKeys [][]byte
+	if apiKey := os.Getenv("API_KEY"); apiKey != "" {
+		apiKeys = append(apiKeys, []byte(apiKey))
+	}
+	for _, caller := range callers {
+		if caller.APIKeyEnv == "" {
+			continue
+		}
+		if key := os.Getenv(caller.APIKeyEnv); key != "" {
+			apiKeys = append(apiKeys, []byte(key))
+		}
+	}
+	for _, peer := range peers {
+		if peer.APIKeyEnv == "" {
+			continue
+		}
+		if key := os.Getenv(peer.APIKeyEnv); key != "" {
+			apiKeys = append(apiKeys, []byte(key))
+		}
+	}
 
-	if apiKey == "" {
+	if len(apiKeys) == 0 {
 		return func(next http.Handler) http.Handler {
 			return next
 		}
@@ -73,26 +99,51 @@ func AuthMiddleware() func(next http.Handler) http.Handler {
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			key := r.Header.Get("X-API-Key")
+			key := requestAPIKey(r)
 
 			if key == "" {
-				authHeader := r.Header.Get("Authorization")
-				if strings.HasPrefix(authHeader, "Bearer ") {
-					key = strings.TrimPrefix(authHeader, "Bearer ")
-				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"missing API key","status_code":401,"error_code":"UNAUTHORIZED","message":"Provide API key via X-API-Key header or Authorization: Bearer <key>"}`))
+				return
 			}
 
-			if key == "" {
+			if !validAPIKey([]byte(key), apiKeys) {
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusUnauthorized)
-				w.Write([]byte(`{"error":"missing API key","status_code":401,"message":"Provide API key via X-API-Key header or Authorization: Bearer <key>"}`))
+				w.Write([]byte(`{"error":"invalid API key","status_code":401,"error_code":"UNAUTHORIZED"}`))
 				return
 			}
 
-			if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) != 1 {
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AdminMiddleware returns a middleware that guards admin endpoints with a separate key, loaded
+// from the ADMIN_API_KEY environment variable and sent like an API key. API keys and caller keys
+// are not accepted. If ADMIN_API_KEY is not set, admin endpoints are disabled.
+func AdminMiddleware() func(next http.Handler) http.Handler {
+	adminKey := os.Getenv("ADMIN_API_KEY")
+
+	if adminKey == "" {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(`{"error":"admin API is not configured","status_code":503,"error_code":"NOT_CONFIGURED","message":"Set ADMIN_API_KEY to enable admin endpoints"}`))
+			})
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := requestAPIKey(r)
+
+			if key == "" || subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) != 1 {
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusUnauthorized)
-				w.Write([]byte(`{"error":"invalid API key","status_code":401}`))
+				w.Write([]byte(`{"error":"invalid admin API key","status_code":401,"error_code":"UNAUTHORIZED","message":"Provide ADMIN_API_KEY via X-API-Key header or Authorization: Bearer <key>"}`))
 				return
 			}
 
@@ -100,3 +151,89 @@ func AuthMiddleware() func(next http.Handler) http.Handler {
 		})
 	}
 }
+
+// PeerMiddleware strips the delegated header from requests not made by a peer, so clients
+// cannot keep their fetches from being routed to another region. A request is made by a peer
+// when the header names a peer's region and the request sends the key in that peer's
+// api_key_env, so each pair of peers shares a key. Keys are read once, when it is created.
+func PeerMiddleware(peers []config.PeerConfig) func(next http.Handler) http.Handler {
+	byRegion := make(map[string][]byte, len(peers))
+	for _, peer := range peers {
+		if peer.APIKeyEnv == "" {
+			continue
+		}
+		if key := os.Getenv(peer.APIKeyEnv); key != "" {
+			byRegion[peer.Region] = []byte(key)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if region := r.Header.Get(delegatedHeader); region != "" {
+				key, ok := byRegion[region]
+				if !ok || subtle.ConstantTimeCompare([]byte(requestAPIKey(r)), key) != 1 {
+					r.Header.Del(delegatedHeader)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// validAPIKey reports whether key matches one of apiKeys. Every key is compared in constant time.
+func validAPIKey(key []byte, apiKeys [][]byte) bool {
+	valid := 0
+	for _, apiKey := range apiKeys {
+		valid |= subtle.ConstantTimeCompare(key, apiKey)
+	}
+	return valid == 1
+}
+
+// requestAPIKey returns the API key of a request, from the X-API-Key header or else a bearer
+// token in the Authorization header.
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if authHeader := r.Header.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		return strings.TrimPrefix(authHeader, "Bearer ")
+	}
+	return ""
+}
+
+// CallerMiddleware tags each request with the caller it is made for, so fetches from different
+// API clients take fair turns at a busy domain's limits. A request is made for the configured
+// caller whose key it sends, else for its API key, identified by a hash prefix, or else for its
+// client IP. Keys are read from the callers' environment variables once, when it is created.
+func CallerMiddleware(callers []config.CallerConfig) func(next http.Handler) http.Handler {
+	byKey := make(map[string]ratelimit.Caller, len(callers))
+	for _, caller := range callers {
+		if key := os.Getenv(caller.APIKeyEnv); key != "" {
+			byKey[key] = ratelimit.Caller{ID: caller.Name, Weight: caller.GetWeight()}
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(ratelimit.WithCaller(r.Context(), requestCaller(r, byKey))))
+		})
+	}
+}
+
+// requestCaller identifies the caller of a request from its API key or client IP.
+func requestCaller(r *http.Request, byKey map[string]ratelimit.Caller) ratelimit.Caller {
+	key := requestAPIKey(r)
+	if caller, ok := byKey[key]; ok {
+		return caller
+	}
+	if key != "" {
+		hash := sha256.Sum256([]byte(key))
+		return ratelimit.Caller{ID: "key:" + hex.EncodeToString(hash[:6])}
+	}
+
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return ratelimit.Caller{ID: "ip:" + ip}
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joeychilson/websurfer/config"
+	"github.com/joeychilson/websurfer/ratelimit"
+)
+
+// TestCallerMiddleware verifies requests are tagged with the configured caller of their API key,
+// else a caller for the key itself, else one for the client IP.
+func TestCallerMiddleware(t *testing.T) {
+	t.Setenv("TEST_CRAWLER_KEY", "crawler-secret")
+
+	var caller ratelimit.Caller
+	handler := CallerMiddleware([]config.CallerConfig{
+		{Name: "crawler", APIKeyEnv: "TEST_CRAWLER_KEY", Weight: 3},
+		{Name: "unset", APIKeyEnv: "TEST_UNSET_KEY"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller = ratelimit.CallerFromContext(r.Context())
+	}))
+
+	serve := func(header, value string) ratelimit.Caller {
+		req := httptest.NewRequest(http.MethodGet, "/v1/fetch", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		return caller
+	}
+
+	assert.Equal(t, ratelimit.Caller{ID: "crawler", Weight: 3}, serve("Authorization", "Bearer crawler-secret"))
+	assert.Equal(t, ratelimit.Caller{ID: "crawler", Weight: 3}, serve("X-API-Key", "crawler-secret"))
+
+	other := serve("X-API-Key", "other-secret")
+	require.True(t, strings.HasPrefix(other.ID, "key:"), "unlisted keys should be identified by a hash")
+	assert.NotContains(t, other.ID, "other-secret")
+	assert.Equal(t, other, serve("Authorization", "Bearer other-secret"))
+
+	assert.Equal(t, ratelimit.Caller{ID: "ip:192.0.2.1"}, serve("", ""))
+}
+
+// TestAuthCallerRateLimitChain verifies a configured caller's key passes authentication alongside
+// API_KEY and reaches the handler tagged with its caller, while unknown keys are rejected.
+func TestAuthCallerRateLimitChain(t *testing.T) {
+	t.Setenv("API_KEY", "admin-secret")
+	t.Setenv("TEST_CRAWLER_KEY", "crawler-secret")
+
+	callers := []config.CallerConfig{{Name: "crawler", APIKeyEnv: "TEST_CRAWLER_KEY", Weight: 3}}
+
+	var caller ratelimit.Caller
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller = ratelimit.CallerFromContext(r.Context())
+	})
+	handler = RateLimit(RateLimitConfig{RequestLimit: 100, WindowDuration: time.Minute})(handler)
+	handler = CallerMiddleware(callers)(handler)
+	handler = AuthMiddleware(callers, nil)(handler)
+
+	serve := func(key string) int {
+		caller = ratelimit.Caller{}
+		req := httptest.NewRequest(http.MethodGet, "/v1/fetch", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("crawler-secret"))
+	assert.Equal(t, ratelimit.Caller{ID: "crawler", Weight: 3}, caller)
+
+	assert.Equal(t, http.StatusOK, serve("admin-secret"))
+	assert.True(t, strings.HasPrefix(caller.ID, "key:"))
+
+	assert.Equal(t, http.StatusUnauthorized, serve("other-secret"))
+	assert.Equal(t, ratelimit.Caller{}, caller)
+
+	assert.Equal(t, http.StatusUnauthorized, serve(""))
+}
+
+// TestPeerMiddleware verifies the delegated header is kept only on requests sending the key of
+// the peer region it names, and peer keys pass authentication.
+func TestPeerMiddleware(t *testing.T) {
+	t.Setenv("API_KEY", "api-secret")
+	t.Setenv("TEST_EU_KEY", "eu-secret")
+
+	peers := []config.PeerConfig{
+		{Region: "eu", URL: "https://eu.example.com", APIKeyEnv: "TEST_EU_KEY"},
+		{Region: "ap", URL: "https://ap.example.com"},
+	}
+
+	var delegated string
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delegated = r.Header.Get(delegatedHeader)
+	})
+	handler = PeerMiddleware(peers)(handler)
+	handler = AuthMiddleware(nil, peers)(handler)
+
+	serve := func(key, region string) int {
+		delegated = ""
+		req := httptest.NewRequest(http.MethodPost, "/v1/fetch", nil)
+		req.Header.Set("X-API-Key", key)
+		req.Header.Set(delegatedHeader, region)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("eu-secret", "eu"))
+	assert.Equal(t, "eu", delegated)
+
+	assert.Equal(t, http.StatusOK, serve("api-secret", "eu"))
+	assert.Empty(t, delegated, "a client key should not mark a fetch as delegated")
+
+	assert.Equal(t, http.StatusOK, serve("eu-secret", "ap"))
+	assert.Empty(t, delegated, "a peer's key should only vouch for its own region")
+
+	assert.Equal(t, http.StatusUnauthorized, serve("other-secret", "eu"))
+}
+
+// TestAdminMiddleware verifies admin endpoints accept only the admin key, and are disabled
+// without one.
+func TestAdminMiddleware(t *testing.T) {
+	t.Setenv("API_KEY", "api-secret")
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func(handler http.Handler, key string) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/cache/import", nil)
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	disabled := AdminMiddleware()(next)
+	assert.Equal(t, http.StatusServiceUnavailable, serve(disabled, "api-secret"))
+
+	t.Setenv("ADMIN_API_KEY", "admin-secret")
+	handler := AdminMiddleware()(next)
+	assert.Equal(t, http.StatusOK, serve(handler, "admin-secret"))
+	assert.Equal(t, http.StatusUnauthorized, serve(handler, "api-secret"))
+	assert.Equal(t, http.StatusUnauthorized, serve(handler, ""))
+}
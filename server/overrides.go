@@ -0,0 +1,120 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/joeychilson/websurfer/client"
+)
+
+const (
+	// maxRequestHeaders caps the headers a fetch request may override.
+	maxRequestHeaders = 20
+	// maxRequestCookies caps the cookies a fetch request may send.
+	maxRequestCookies = 50
+)
+
+// allowedRequestHeaders are the headers a fetch request may set. Hop-by-hop, framing, and
+// routing headers such as Host, Content-Length, Transfer-Encoding, and X-Forwarded-For are
+// excluded so requests can't smuggle or spoof, and conditional headers such as If-None-Match
+// so they can't turn a fetch into a 304 with no content; cookies are set through the cookies map.
+var allowedRequestHeaders = map[string]bool{
+	"Accept":           true,
+	"Accept-Language":  true,
+	"Authorization":    true,
+	"Cache-Control":    true,
+	"Dnt":              true,
+	"Pragma":           true,
+	"Referer":          true,
+	"User-Agent":       true,
+	"X-Api-Key":        true,
+	"X-Requested-With": true,
+}
+
+// validateRequestOverrides checks that headers are allowlisted and well formed, and that
+// cookies have valid names and values.
+func validateRequestOverrides(headers, cookies map[string]string) error {
+	if len(headers) > maxRequestHeaders {
+		return fmt.Errorf("headers: at most %d headers are allowed", maxRequestHeaders)
+	}
+	for name, value := range headers {
+		if !allowedRequestHeaders[http.CanonicalHeaderKey(name)] {
+			return fmt.Errorf("headers: %q is not allowed", name)
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("headers: invalid value for %q", name)
+		}
+	}
+
+	if len(cookies) > maxRequestCookies {
+		return fmt.Errorf("cookies: at most %d cookies are allowed", maxRequestCookies)
+	}
+	for name, value := range cookies {
+		cookie := &http.Cookie{Name: name, Value: value}
+		if err := cookie.Valid(); err != nil || strings.ContainsAny(value, ";\"") {
+			return fmt.Errorf("cookies: invalid cookie %q", name)
+		}
+	}
+
+	return nil
+}
+
+// canonicalHeaders returns headers with canonical names, so overrides replace configured
+// headers regardless of case.
+func canonicalHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	canonical := make(map[string]string, len(headers))
+	for name, value := range headers {
+		canonical[http.CanonicalHeaderKey(name)] = value
+	}
+	return canonical
+}
+
+// fetchOptions returns the client options for the request. AsOf is parsed by validateRequest,
+// so it is valid here.
+func (r *FetchRequest) fetchOptions() *client.FetchOptions {
+	opts := &client.FetchOptions{
+		BypassCache:  r.BypassCache,
+		ParseOptions: r.ParseOptions.toParserOptions(),
+		Headers:      canonicalHeaders(r.Headers),
+		Cookies:      r.Cookies,
+		Version:      r.Version,
+		Selectors:    r.Selectors,
+		IncludeRaw:   r.IncludeRaw,
+	}
+	opts.ParseOptions.Format = r.Format
+	if r.AsOf != "" {
+		opts.AsOf, _ = time.Parse(time.RFC3339, r.AsOf)
+	}
+	if r.session != nil {
+		r.applySession(opts)
+	}
+	return opts
+}
+
+// applySession adds the session's cookie jar and Referer to opts, under the request's own
+// cookies and headers. Session fetches skip the cache, since their responses depend on the
+// session's cookies.
+func (r *FetchRequest) applySession(opts *client.FetchOptions) {
+	opts.SkipCache = true
+
+	if r.jar != nil {
+		opts.CookieJar = r.jar
+	}
+
+	if r.session.Referer == "" {
+		return
+	}
+	if opts.Headers == nil {
+		opts.Headers = make(map[string]string)
+	}
+	if _, ok := opts.Headers["Referer"]; !ok {
+		opts.Headers["Referer"] = r.session.Referer
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joeychilson/websurfer/client"
+)
+
+// TestValidateRequestOverrides verifies only allowlisted, well-formed headers and valid cookies are accepted.
+func TestValidateRequestOverrides(t *testing.T) {
+	assert.NoError(t, validateRequestOverrides(map[string]string{"accept-language": "de-DE", "Authorization": "Bearer token"}, map[string]string{"session": "abc123"}))
+
+	assert.Error(t, validateRequestOverrides(map[string]string{"Host": "internal"}, nil))
+	assert.Error(t, validateRequestOverrides(map[string]string{"X-Forwarded-For": "10.0.0.1"}, nil))
+	assert.Error(t, validateRequestOverrides(map[string]string{"Cookie": "a=b"}, nil), "cookies go through the cookies map")
+	assert.Error(t, validateRequestOverrides(map[string]string{"If-None-Match": "*"}, nil), "conditional headers could force a 304")
+	assert.Error(t, validateRequestOverrides(map[string]string{"Accept": "text/html\r\nHost: evil"}, nil))
+	assert.Error(t, validateRequestOverrides(nil, map[string]string{"bad name": "x"}))
+	assert.Error(t, validateRequestOverrides(nil, map[string]string{"session": "a; admin=true"}))
+}
+
+// TestFetchRequestOverrides verifies request headers and cookies reach the origin over the configured headers.
+func TestFetchRequestOverrides(t *testing.T) {
+	var language, cookie, userAgent string
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		language = r.Header.Get("Accept-Language")
+		cookie = r.Header.Get("Cookie")
+		userAgent = r.Header.Get("User-Agent")
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("hallo"))
+	}))
+	defer origin.Close()
+
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+
+	_, err = s.processFetch(context.Background(), &FetchRequest{
+		URL:     origin.URL,
+		Headers: map[string]string{"accept-language": "de-DE", "user-agent": "AgentBot/1.0"},
+		Cookies: map[string]string{"theme": "dark", "session": "abc"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "de-DE", language)
+	assert.Equal(t, "AgentBot/1.0", userAgent)
+	assert.Equal(t, "session=abc; theme=dark", cookie)
+}
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/jobs"
+	urlpkg "github.com/joeychilson/websurfer/url"
+)
+
+const (
+	// jobTypePreload marks job payloads that hold a preload request.
+	jobTypePreload = "preload"
+	// defaultPreloadRate is how many pages a preload fetches per second when no rate is given.
+	defaultPreloadRate = 1.0
+	// maxPreloadRate caps the rate of a preload request.
+	maxPreloadRate = 10.0
+	// preloadWorkers is how many pages a preload fetches at once.
+	preloadWorkers = 4
+)
+
+// PreloadRequest represents a request to fetch pages into the cache ahead of use. Pages are
+// listed in URLs, read from the sitemap at SitemapURL, or both.
+type PreloadRequest struct {
+	URLs       []string `json:"urls,omitempty"`
+	SitemapURL string   `json:"sitemap_url,omitempty"`
+	// Rate is how many pages are fetched per second (default: 1, max: 10). Each site's own
+	// rate limit still applies.
+	Rate float64 `json:"rate,omitempty"`
+	// BypassCache refetches pages that are already cached.
+	BypassCache bool `json:"bypass_cache,omitempty"`
+}
+
+// PreloadResult is the outcome of a preload job. Fetched counts pages fetched from origin,
+// and Cached those already in the cache.
+type PreloadResult struct {
+	URLs     int              `json:"urls"`
+	Fetched  int              `json:"fetched"`
+	Cached   int              `json:"cached"`
+	Failed   int              `json:"failed"`
+	Failures []PreloadFailure `json:"failures,omitempty"`
+	// LimitReached names the server safety limit that cut the preload short, if any.
+	LimitReached string `json:"limit_reached,omitempty"`
+}
+
+// PreloadFailure is a page that could not be preloaded.
+type PreloadFailure struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	ErrorCode  string `json:"error_code,omitempty"`
+}
+
+// preloadJobPayload is the job payload of a preload job.
+type preloadJobPayload struct {
+	Type    string         `json:"type"`
+	Preload PreloadRequest `json:"preload"`
+}
+
+// handlePreload handles POST /v1/preload requests.
+func (s *Server) handlePreload(w http.ResponseWriter, r *http.Request) {
+	if s.queue == nil {
+		s.sendError(w, "job queue is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req PreloadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("failed to decode request", "error", err)
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.validatePreloadRequest(&req); err != nil {
+		s.logger.Error("invalid request", "error", err)
+		s.sendValidationError(w, err)
+		return
+	}
+
+	payload, err := json.Marshal(preloadJobPayload{Type: jobTypePreload, Preload: req})
+	if err != nil {
+		s.sendError(w, "failed to encode job", http.StatusInternalServerError)
+		return
+	}
+
+	job, err := s.queue.Enqueue(r.Context(), payload)
+	if err != nil {
+		s.logger.Error("failed to enqueue job", "urls", len(req.URLs), "sitemap_url", req.SitemapURL, "error", err)
+		s.sendError(w, "failed to enqueue job", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Info("preload enqueued", "job_id", job.ID, "urls", len(req.URLs), "sitemap_url", req.SitemapURL, "rate", req.Rate)
+	s.sendJSON(w, buildJobResponse(job), http.StatusAccepted)
+}
+
+// validatePreloadRequest validates a preload request and fills in defaults.
+func (s *Server) validatePreloadRequest(req *PreloadRequest) error {
+	if len(req.URLs) == 0 && req.SitemapURL == "" {
+		return fmt.Errorf("urls or sitemap_url is required")
+	}
+	if len(req.URLs) > s.limits.GetMaxPages() {
+		return fmt.Errorf("urls must contain at most %d URLs", s.limits.GetMaxPages())
+	}
+
+	for _, pageURL := range req.URLs {
+		if _, err := urlpkg.ValidateExternal(pageURL); err != nil {
+			return err
+		}
+		if err := s.policy.Check(pageURL); err != nil {
+			return err
+		}
+	}
+	if req.SitemapURL != "" {
+		if _, err := urlpkg.ValidateExternal(req.SitemapURL); err != nil {
+			return err
+		}
+		if err := s.policy.Check(req.SitemapURL); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case req.Rate < 0 || req.Rate > maxPreloadRate:
+		return fmt.Errorf("rate must be between 0 and %g pages per second", maxPreloadRate)
+	case req.Rate == 0:
+		req.Rate = defaultPreloadRate
+	}
+	return nil
+}
+
+// runPreloadJob processes a preload job claimed from the queue.
+func (s *Server) runPreloadJob(ctx context.Context, job *jobs.Job) (json.RawMessage, error) {
+	var payload preloadJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("invalid job payload: %w", err)
+	}
+
+	result, err := s.processPreload(ctx, &payload.Preload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to preload: %w", err)
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job result: %w", err)
+	}
+	return encoded, nil
+}
+
+// processPreload fetches the requested pages into the cache, starting one fetch every 1/rate
+// seconds with at most preloadWorkers in flight. Sitemap pages on the sitemap's host follow
+// the listed URLs, and duplicates are fetched once. When a server safety limit stops the
+// preload, the pages fetched so far are reported.
+func (s *Server) processPreload(ctx context.Context, req *PreloadRequest) (*PreloadResult, error) {
+	guard, ctx, cancel := s.newLimitGuard(ctx)
+	defer cancel()
+
+	urls := req.URLs
+	if req.SitemapURL != "" {
+		base, err := url.Parse(req.SitemapURL)
+		if err != nil {
+			return nil, err
+		}
+		found, capped := s.discoverFromSitemaps(ctx, guard, base, []string{req.SitemapURL}, s.limits.GetMaxURLs(), req.BypassCache)
+		if capped {
+			guard.capURLs()
+		}
+		for _, u := range found {
+			urls = append(urls, u.URL)
+		}
+	}
+
+	seen := make(map[string]bool)
+	pages := make([]string, 0, len(urls))
+	for _, pageURL := range urls {
+		if key := s.client.NormalizeURL(pageURL); !seen[key] {
+			seen[key] = true
+			pages = append(pages, pageURL)
+		}
+	}
+	result := &PreloadResult{URLs: len(pages)}
+
+	rate := req.Rate
+	if rate <= 0 {
+		rate = defaultPreloadRate
+	}
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+	defer ticker.Stop()
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, preloadWorkers)
+	)
+	record := func(pageURL string, resp *client.Response, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case errors.Is(err, errLimitReached):
+			return
+		case err != nil:
+			result.Failed++
+			result.Failures = append(result.Failures, PreloadFailure{URL: pageURL, Error: err.Error(), ErrorCode: fetchErrorCode(err)})
+		case resp.StatusCode >= http.StatusBadRequest:
+			result.Failed++
+			result.Failures = append(result.Failures, PreloadFailure{URL: pageURL, StatusCode: resp.StatusCode})
+		case resp.CacheState == client.CacheStateHit || resp.CacheState == client.CacheStateStale:
+			result.Cached++
+		default:
+			result.Fetched++
+		}
+	}
+
+loop:
+	for i, pageURL := range pages {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				break loop
+			case <-ticker.C:
+			}
+		}
+		if err := s.policy.Check(pageURL); err != nil {
+			record(pageURL, nil, err)
+			continue
+		}
+		if err := guard.reserve(); err != nil {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			break loop
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resp, err := guard.fetchReserved(ctx, s.client, pageURL, &client.FetchOptions{BypassCache: req.BypassCache})
+			record(pageURL, resp, err)
+		}()
+	}
+	wg.Wait()
+
+	result.LimitReached = guard.limitReached(ctx)
+	if err := ctx.Err(); err != nil && result.LimitReached == "" {
+		return nil, err
+	}
+
+	s.logger.Info("preload completed", "urls", result.URLs, "fetched", result.Fetched, "cached", result.Cached, "failed", result.Failed, "limit_reached", result.LimitReached)
+	return result, nil
+}
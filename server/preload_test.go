@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidatePreloadRequest verifies defaults and limits for preload requests.
+func TestValidatePreloadRequest(t *testing.T) {
+	req := PreloadRequest{URLs: []string{"https://example.com/docs"}}
+	require.NoError(t, (&Server{}).validatePreloadRequest(&req))
+	assert.Equal(t, defaultPreloadRate, req.Rate)
+
+	for _, req := range []PreloadRequest{
+		{},
+		{URLs: []string{"https://example.com", "http://localhost"}},
+		{SitemapURL: "http://127.0.0.1/sitemap.xml"},
+		{URLs: []string{"https://example.com"}, Rate: 20},
+		{URLs: make([]string, 1001)},
+	} {
+		assert.Error(t, (&Server{}).validatePreloadRequest(&req), "%+v", req)
+	}
+}
+
+// TestProcessPreload verifies listed and sitemap pages are fetched into the cache once, and
+// pages already cached are not fetched again.
+func TestProcessPreload(t *testing.T) {
+	var origin *httptest.Server
+	origin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprintf(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>%[1]s/a</loc></url><url><loc>%[1]s/b</loc></url></urlset>`, origin.URL)
+		case "/a", "/b":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><body><p>Page " + r.URL.Path + "</p></body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer origin.Close()
+
+	s, responseCache := newCacheTestServer(t)
+	ctx := context.Background()
+
+	result, err := s.processPreload(ctx, &PreloadRequest{
+		URLs:       []string{origin.URL + "/a", origin.URL + "/missing"},
+		SitemapURL: origin.URL + "/sitemap.xml",
+		Rate:       maxPreloadRate,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.URLs)
+	assert.Equal(t, 2, result.Fetched)
+	assert.Equal(t, 1, result.Failed)
+	require.Len(t, result.Failures, 1)
+	assert.Equal(t, PreloadFailure{URL: origin.URL + "/missing", StatusCode: http.StatusNotFound}, result.Failures[0])
+
+	entry, err := responseCache.Get(ctx, origin.URL+"/b")
+	require.NoError(t, err)
+	assert.NotNil(t, entry)
+
+	result, err = s.processPreload(ctx, &PreloadRequest{URLs: []string{origin.URL + "/a", origin.URL + "/b"}, Rate: maxPreloadRate})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Cached)
+	assert.Zero(t, result.Fetched)
+}
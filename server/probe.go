@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/joeychilson/websurfer/fetcher"
+	urlpkg "github.com/joeychilson/websurfer/url"
+)
+
+const (
+	// defaultProbeTimeout bounds a probe when the request sets no timeout.
+	defaultProbeTimeout = 5 * time.Second
+	// maxProbeTimeout is the longest timeout a probe may request.
+	maxProbeTimeout = 30 * time.Second
+)
+
+// probeHeaders are the response headers a probe reports, describing the server and the
+// resource without its body.
+var probeHeaders = []string{
+	"Server",
+	"Content-Type",
+	"Content-Length",
+	"Last-Modified",
+	"ETag",
+	"Cache-Control",
+	"Age",
+	"Retry-After",
+	"Via",
+	"X-Powered-By",
+}
+
+// ProbeResponse reports whether a URL responds. Reachable is false when no response was
+// received, with ErrorCode and FailureType describing the connection failure; any HTTP
+// response, including an error status, counts as reachable.
+type ProbeResponse struct {
+	URL       string `json:"url"`
+	FinalURL  string `json:"final_url,omitempty"`
+	Reachable bool   `json:"reachable"`
+	// Method is the request method that produced the response: HEAD, or GET when the site
+	// does not support HEAD.
+	Method     string `json:"method,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	// LatencyMs is the time to the first byte of the response, or until the probe failed.
+	LatencyMs   int64             `json:"latency_ms"`
+	Protocol    string            `json:"protocol,omitempty"`
+	TLSVersion  string            `json:"tls_version,omitempty"`
+	RemoteIP    string            `json:"remote_ip,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	ErrorCode   string            `json:"error_code,omitempty"`
+	FailureType string            `json:"failure_type,omitempty"`
+}
+
+// handleProbe handles GET /v1/probe requests, checking whether the url query parameter
+// responds within the timeout query parameter without fetching its body.
+func (s *Server) handleProbe(w http.ResponseWriter, r *http.Request) {
+	pageURL := r.URL.Query().Get("url")
+	if _, err := urlpkg.ValidateExternal(pageURL); err != nil {
+		s.sendValidationError(w, err)
+		return
+	}
+
+	if err := s.policy.Check(pageURL); err != nil {
+		s.sendValidationError(w, err)
+		return
+	}
+
+	timeout := defaultProbeTimeout
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 || parsed > maxProbeTimeout {
+			s.sendError(w, fmt.Sprintf("timeout must be a positive duration of at most %s, such as 2s", maxProbeTimeout), http.StatusBadRequest)
+			return
+		}
+		timeout = parsed
+	}
+
+	resp, err := s.processProbe(r.Context(), pageURL, timeout)
+	if err != nil {
+		errResp := buildFetchError(pageURL, err)
+		s.logger.Error("probe failed", "url", pageURL, "error", err)
+		s.sendJSON(w, errResp, errResp.StatusCode)
+		return
+	}
+	s.sendJSON(w, resp, http.StatusOK)
+}
+
+// processProbe probes pageURL within timeout. Connection failures are reported in the
+// response as unreachable; an error is only returned when the probe was not attempted, such
+// as when robots.txt disallows the URL.
+func (s *Server) processProbe(ctx context.Context, pageURL string, timeout time.Duration) (*ProbeResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	result, err := s.client.Probe(ctx, pageURL)
+	if err != nil {
+		failureType := fetcher.ClassifyError(err)
+		if failureType == "" {
+			return nil, err
+		}
+		return &ProbeResponse{
+			URL:         pageURL,
+			LatencyMs:   time.Since(start).Milliseconds(),
+			Error:       err.Error(),
+			ErrorCode:   fetchErrorCode(err),
+			FailureType: failureType,
+		}, nil
+	}
+
+	resp := &ProbeResponse{
+		URL:        pageURL,
+		FinalURL:   result.URL,
+		Reachable:  true,
+		Method:     result.Method,
+		StatusCode: result.StatusCode,
+		LatencyMs:  result.FirstByte.Milliseconds(),
+		Protocol:   result.Transfer.Protocol,
+		TLSVersion: result.Transfer.TLSVersion,
+		RemoteIP:   result.Transfer.RemoteIP,
+		Headers:    make(map[string]string),
+	}
+	for _, name := range probeHeaders {
+		if value := result.Headers.Get(name); value != "" {
+			resp.Headers[name] = value
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/joeychilson/websurfer/fetcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProcessProbe verifies probes report the response status, latency, and server headers,
+// and report connection failures as unreachable.
+func TestProcessProbe(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		w.Header().Set("Server", "nginx")
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer origin.Close()
+
+	s := newMapTestServer(t)
+	ctx := context.Background()
+
+	resp, err := s.processProbe(ctx, origin.URL+"/page", time.Second)
+	require.NoError(t, err)
+	assert.True(t, resp.Reachable)
+	assert.Equal(t, http.MethodHead, resp.Method)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, origin.URL+"/page", resp.FinalURL)
+	assert.Equal(t, map[string]string{"Server": "nginx", "Content-Type": "text/html"}, resp.Headers)
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	resp, err = s.processProbe(ctx, closed.URL, time.Second)
+	require.NoError(t, err)
+	assert.False(t, resp.Reachable)
+	assert.Equal(t, fetcher.FailureConnRefused, resp.FailureType)
+	assert.Equal(t, ErrorCodeConnectionRefused, resp.ErrorCode)
+}
+
+// TestHandleProbeValidation verifies probes reject private URLs and out of range timeouts.
+func TestHandleProbeValidation(t *testing.T) {
+	router := newMapTestServer(t).Router()
+
+	for target, status := range map[string]int{
+		"/v1/probe?url=http://127.0.0.1/":                    http.StatusBadRequest,
+		"/v1/probe?url=https://example.com/&timeout=1m":      http.StatusBadRequest,
+		"/v1/probe?url=https://example.com/&timeout=forever": http.StatusBadRequest,
+		"/v1/probe?url=not%20a%20url":                        http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, status, w.Code, target)
+	}
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/joeychilson/websurfer/jobs"
+)
+
+const (
+	// progressInterval is how often a running crawl records its progress on its job.
+	progressInterval = 2 * time.Second
+	// rateWindow is how far back the current rate of a crawl is measured.
+	rateWindow = time.Minute
+)
+
+// CrawlProgress reports how far a running crawl job has come. Fetched and Failed count the
+// pages visited so far, Bytes what was downloaded from origin for them, and PagesPerSecond
+// the rate over the last minute. ETASeconds estimates the time left to visit the pages
+// discovered, up to max_pages, at that rate.
+type CrawlProgress struct {
+	Discovered     int     `json:"discovered"`
+	Fetched        int     `json:"fetched"`
+	Failed         int     `json:"failed"`
+	Bytes          int64   `json:"bytes"`
+	PagesPerSecond float64 `json:"pages_per_second"`
+	ETASeconds     int     `json:"eta_seconds,omitempty"`
+	UpdatedAt      string  `json:"updated_at"`
+}
+
+// crawlProgress tracks the progress of a running crawl. A nil *crawlProgress tracks nothing.
+type crawlProgress struct {
+	mu         sync.Mutex
+	maxPages   int
+	started    time.Time
+	discovered int
+	fetched    int
+	failed     int
+	bytes      int64
+	// visits holds when the pages of the last rateWindow were visited.
+	visits []time.Time
+	now    func() time.Time
+}
+
+// newCrawlProgress creates a tracker for a crawl of at most maxPages pages, which has
+// discovered its start page.
+func newCrawlProgress(maxPages int) *crawlProgress {
+	return &crawlProgress{maxPages: maxPages, started: time.Now(), discovered: 1, now: time.Now}
+}
+
+// discover records that the crawl has found n distinct pages.
+func (p *crawlProgress) discover(n int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.discovered = max(p.discovered, n)
+}
+
+// download records n bytes downloaded from origin.
+func (p *crawlProgress) download(n int64) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bytes += n
+}
+
+// visit records a visited page, and whether it failed.
+func (p *crawlProgress) visit(failed bool) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if failed {
+		p.failed++
+	} else {
+		p.fetched++
+	}
+	now := p.now()
+	p.visits = append(p.trimVisits(now), now)
+}
+
+// trimVisits drops the visits older than rateWindow.
+func (p *crawlProgress) trimVisits(now time.Time) []time.Time {
+	i := 0
+	for i < len(p.visits) && now.Sub(p.visits[i]) > rateWindow {
+		i++
+	}
+	return p.visits[i:]
+}
+
+// snapshot returns the crawl's progress so far.
+func (p *crawlProgress) snapshot() CrawlProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	p.visits = p.trimVisits(now)
+	progress := CrawlProgress{
+		Discovered: p.discovered,
+		Fetched:    p.fetched,
+		Failed:     p.failed,
+		Bytes:      p.bytes,
+		UpdatedAt:  now.UTC().Format(time.RFC3339),
+	}
+
+	if elapsed := min(now.Sub(p.started), rateWindow).Seconds(); elapsed > 0 {
+		progress.PagesPerSecond = math.Round(float64(len(p.visits))/elapsed*100) / 100
+	}
+	remaining := min(p.discovered, p.maxPages) - p.fetched - p.failed
+	if remaining > 0 && progress.PagesPerSecond > 0 {
+		progress.ETASeconds = int(math.Ceil(float64(remaining) / progress.PagesPerSecond))
+	}
+	return progress
+}
+
+// crawlRegistry holds the progress of the crawl jobs running on this instance, by job ID.
+type crawlRegistry struct {
+	mu     sync.Mutex
+	crawls map[string]*crawlProgress
+}
+
+// add registers the progress of a running crawl job.
+func (r *crawlRegistry) add(jobID string, progress *crawlProgress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.crawls == nil {
+		r.crawls = make(map[string]*crawlProgress)
+	}
+	r.crawls[jobID] = progress
+}
+
+// remove unregisters a crawl job once it finishes.
+func (r *crawlRegistry) remove(jobID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.crawls, jobID)
+}
+
+// snapshot returns the progress of each running crawl job.
+func (r *crawlRegistry) snapshot() map[string]CrawlProgress {
+	r.mu.Lock()
+	crawls := maps.Clone(r.crawls)
+	r.mu.Unlock()
+
+	snapshots := make(map[string]CrawlProgress, len(crawls))
+	for id, progress := range crawls {
+		snapshots[id] = progress.snapshot()
+	}
+	return snapshots
+}
+
+// publishProgress records the crawl's progress on its job every progressInterval until the
+// returned function is called, which records it a final time.
+func (s *Server) publishProgress(ctx context.Context, job *jobs.Job, progress *crawlProgress) func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.recordProgress(ctx, job, progress)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+		if ctx.Err() == nil {
+			s.recordProgress(ctx, job, progress)
+		}
+	}
+}
+
+// recordProgress records a snapshot of the crawl's progress on its job.
+func (s *Server) recordProgress(ctx context.Context, job *jobs.Job, progress *crawlProgress) {
+	encoded, err := json.Marshal(progress.snapshot())
+	if err != nil {
+		s.logger.Error("failed to encode crawl progress", "job_id", job.ID, "error", err)
+		return
+	}
+
+	err = s.queue.SetProgress(ctx, job, encoded)
+	switch {
+	case errors.Is(err, jobs.ErrLeaseLost):
+		s.logger.Debug("crawl job lease lost, not recording progress", "job_id", job.ID)
+	case err != nil:
+		s.logger.Warn("failed to record crawl progress", "job_id", job.ID, "error", err)
+	}
+}
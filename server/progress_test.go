@@ -0,0 +1,81 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCrawlProgress verifies counts, the rate over the last minute, and the ETA to visit the
+// pages discovered up to max_pages.
+func TestCrawlProgress(t *testing.T) {
+	now := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	progress := &crawlProgress{maxPages: 10, started: now, discovered: 1, now: func() time.Time { return now }}
+
+	progress.discover(5)
+	progress.discover(3)
+	for i := range 4 {
+		now = now.Add(10 * time.Second)
+		progress.visit(i == 3)
+		progress.download(1000)
+	}
+
+	snapshot := progress.snapshot()
+	assert.Equal(t, 5, snapshot.Discovered)
+	assert.Equal(t, 3, snapshot.Fetched)
+	assert.Equal(t, 1, snapshot.Failed)
+	assert.Equal(t, int64(4000), snapshot.Bytes)
+	assert.Equal(t, 0.1, snapshot.PagesPerSecond)
+	assert.Equal(t, 10, snapshot.ETASeconds, "one page is left at 0.1 pages per second")
+
+	// Visits older than a minute no longer count towards the rate.
+	now = now.Add(45 * time.Second)
+	snapshot = progress.snapshot()
+	assert.Equal(t, 0.03, snapshot.PagesPerSecond)
+
+	progress.discover(50)
+	assert.Equal(t, 200, progress.snapshot().ETASeconds, "pages past max_pages should not be waited for")
+
+	var untracked *crawlProgress
+	untracked.discover(1)
+	untracked.visit(false)
+	untracked.download(1)
+}
+
+// TestHandleMetrics verifies running crawl jobs and background refresh counters are exposed in
+// the Prometheus text format.
+func TestHandleMetrics(t *testing.T) {
+	s := newMapTestServer(t)
+
+	now := time.Now()
+	progress := &crawlProgress{maxPages: 10, started: now.Add(-time.Second), discovered: 4, now: func() time.Time { return now }}
+	progress.visit(false)
+	progress.download(2048)
+	s.crawls.add("abc123", progress)
+	s.crawls.add("def456", newCrawlProgress(10))
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+
+	body := w.Body.String()
+	assert.Contains(t, body, "# TYPE websurfer_crawl_pages_fetched_total counter\n")
+	assert.Contains(t, body, "websurfer_crawl_jobs_running 2\n")
+	assert.Contains(t, body, `websurfer_crawl_pages_discovered{job_id="abc123"} 4`+"\n")
+	assert.Contains(t, body, `websurfer_crawl_pages_fetched_total{job_id="abc123"} 1`+"\n")
+	assert.Contains(t, body, `websurfer_crawl_bytes_downloaded_total{job_id="abc123"} 2048`+"\n")
+	assert.Contains(t, body, `websurfer_crawl_pages_per_second{job_id="abc123"} 1`+"\n")
+	assert.Contains(t, body, `websurfer_crawl_eta_seconds{job_id="abc123"} 3`+"\n")
+	assert.NotContains(t, body, `websurfer_crawl_eta_seconds{job_id="def456"}`, "unknown ETAs should be left out")
+	assert.Contains(t, body, `websurfer_cache_refreshes_total{result="updated"} 0`+"\n")
+	assert.Contains(t, body, "websurfer_cache_refresh_change_ratio_count 0\n")
+
+	s.crawls.remove("abc123")
+	w = httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.NotContains(t, w.Body.String(), "abc123")
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"unicode/utf8"
+
+	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/objectstore"
+)
+
+// RawContent is the body a fetch's content was parsed from, returned with include_raw.
+type RawContent struct {
+	ContentType string `json:"content_type"`
+	// Size is the size of the whole body in bytes, and SHA256 its hash, even when Content holds
+	// only part of it or the body was stored instead.
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// Content is the body, or its first bytes when Truncated is set. Bodies that are not valid
+	// UTF-8 are base64-encoded, with Encoding "base64".
+	Content   string `json:"content,omitempty"`
+	Encoding  string `json:"encoding,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+	// Object references the body in the object store, set instead of Content when the body is
+	// over the server's max_raw_bytes limit.
+	Object *objectstore.Object `json:"object,omitempty"`
+}
+
+// buildRawContent describes the raw body of a fetch. Bodies over the max_raw_bytes limit are
+// uploaded to the object store when one is configured, and otherwise truncated to the limit.
+func (s *Server) buildRawContent(ctx context.Context, fetched *client.Response, contentType string) *RawContent {
+	body := fetched.Raw
+	hash := sha256.Sum256(body)
+	raw := &RawContent{
+		ContentType: contentType,
+		Size:        int64(len(body)),
+		SHA256:      hex.EncodeToString(hash[:]),
+	}
+
+	limit := s.limits.GetMaxRawBytes()
+	if int64(len(body)) > limit {
+		if s.objectStore != nil {
+			key := "raw/" + raw.SHA256 + downloadExtension(&client.Download{URL: fetched.URL, ContentType: contentType})
+			object, err := s.objectStore.Put(ctx, key, contentType, body)
+			if err == nil {
+				raw.Object = object
+				return raw
+			}
+			s.logger.Warn("failed to store raw body, truncating it", "url", fetched.URL, "error", err)
+		}
+
+		body = body[:limit]
+		if utf8.Valid(fetched.Raw) {
+			// Cut at a character boundary, so the truncated body is still valid UTF-8.
+			for len(body) > 0 && !utf8.Valid(body) {
+				body = body[:len(body)-1]
+			}
+		}
+		raw.Truncated = true
+	}
+
+	if utf8.Valid(body) {
+		raw.Content = string(body)
+	} else {
+		raw.Content = base64.StdEncoding.EncodeToString(body)
+		raw.Encoding = "base64"
+	}
+	return raw
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/objectstore"
+)
+
+// TestBuildRawContent verifies raw bodies are returned whole under the limit, truncated at a
+// character boundary over it, and base64-encoded when they are not UTF-8.
+func TestBuildRawContent(t *testing.T) {
+	s := newMapTestServer(t)
+	s.limits.MaxRawBytes = 10
+	ctx := context.Background()
+
+	raw := s.buildRawContent(ctx, &client.Response{URL: "https://example.com/", Raw: []byte("<p>Hi</p>")}, "text/html")
+	assert.Equal(t, "<p>Hi</p>", raw.Content)
+	assert.Equal(t, int64(9), raw.Size)
+	assert.Len(t, raw.SHA256, 64)
+	assert.False(t, raw.Truncated)
+	assert.Empty(t, raw.Encoding)
+
+	raw = s.buildRawContent(ctx, &client.Response{URL: "https://example.com/", Raw: []byte("<p>Ünïcödé</p>")}, "text/html")
+	assert.Equal(t, "<p>Ünïc", raw.Content, "truncation should not split a character")
+	assert.Equal(t, int64(18), raw.Size)
+	assert.True(t, raw.Truncated)
+
+	binary := []byte{0xff, 0xfe, 0x00, 0x01}
+	raw = s.buildRawContent(ctx, &client.Response{URL: "https://example.com/", Raw: binary}, "application/octet-stream")
+	assert.Equal(t, "base64", raw.Encoding)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(binary), raw.Content)
+}
+
+// TestBuildRawContentObjectStore verifies raw bodies over the limit are uploaded to the object
+// store when one is configured.
+func TestBuildRawContentObjectStore(t *testing.T) {
+	objects := map[string]string{}
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		objects[r.URL.Path] = string(data)
+	}))
+	defer origin.Close()
+
+	store, err := objectstore.New(objectstore.Config{Provider: objectstore.ProviderS3, Bucket: "pages", Endpoint: origin.URL, AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	require.NoError(t, err)
+
+	s := newMapTestServer(t)
+	s.limits.MaxRawBytes = 10
+	s.objectStore = store
+
+	body := strings.Repeat("<p>Hello</p>", 10)
+	raw := s.buildRawContent(context.Background(), &client.Response{URL: "https://example.com/page.html", Raw: []byte(body)}, "text/html")
+
+	require.NotNil(t, raw.Object)
+	assert.Empty(t, raw.Content)
+	assert.False(t, raw.Truncated)
+	assert.Equal(t, int64(len(body)), raw.Size)
+	assert.Equal(t, "raw/"+raw.SHA256+".html", raw.Object.Key)
+	assert.Equal(t, body, objects["/pages/raw/"+raw.SHA256+".html"])
+}
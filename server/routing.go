@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/joeychilson/websurfer/config"
+	"github.com/joeychilson/websurfer/sdk"
+)
+
+// delegatedHeader marks a fetch forwarded from a peer so it is never delegated again.
+const delegatedHeader = "X-Websurfer-Delegated"
+
+// newPeerClients creates an API client for each configured peer region.
+func newPeerClients(peers []config.PeerConfig, localRegion string) map[string]*sdk.Client {
+	clients := make(map[string]*sdk.Client, len(peers))
+	for _, peer := range peers {
+		opts := []sdk.Option{sdk.WithHeader(delegatedHeader, localRegion)}
+		if peer.APIKeyEnv != "" {
+			opts = append(opts, sdk.WithAPIKey(os.Getenv(peer.APIKeyEnv)))
+		}
+		clients[peer.Region] = sdk.New(peer.URL, opts...)
+	}
+	return clients
+}
+
+// processFetch fetches the request and, in deterministic mode, strips the fields that vary
+// between identical fetches.
+func (s *Server) processFetch(ctx context.Context, req *FetchRequest) (*FetchResponse, error) {
+	resp, err := s.routeFetch(ctx, req)
+	if err == nil && req.Deterministic {
+		resp.makeDeterministic()
+	}
+	return resp, err
+}
+
+// routeFetch fetches locally or through peers in other regions, depending on the site's routing.
+// Sites with routing.regions are always delegated; sites with routing.fallback_regions are
+// delegated only when the local fetch returns a geo-block status code.
+func (s *Server) routeFetch(ctx context.Context, req *FetchRequest) (*FetchResponse, error) {
+	if req.SessionID != "" {
+		return s.fetchInSession(ctx, req)
+	}
+
+	if req.delegated || len(s.peers) == 0 {
+		return s.fetchLocal(ctx, req)
+	}
+
+	routing := s.client.Config().GetConfigForURL(req.URL).Routing
+	if len(routing.Regions) > 0 {
+		return s.fetchViaPeers(ctx, req, routing.Regions)
+	}
+
+	resp, err := s.fetchLocal(ctx, req)
+	if err != nil || len(routing.FallbackRegions) == 0 || !routing.IsGeoBlocked(resp.Metadata.StatusCode) {
+		return resp, err
+	}
+
+	s.logger.Info("fetch geo-blocked locally, delegating to peers",
+		"url", req.URL,
+		"status_code", resp.Metadata.StatusCode,
+		"regions", routing.FallbackRegions)
+
+	peerResp, err := s.fetchViaPeers(ctx, req, routing.FallbackRegions)
+	if err != nil {
+		s.logger.Warn("peer fallback failed, returning local response", "url", req.URL, "error", err)
+		return resp, nil
+	}
+	return peerResp, nil
+}
+
+// fetchViaPeers delegates the fetch to peers in the given regions, in order, until one succeeds.
+func (s *Server) fetchViaPeers(ctx context.Context, req *FetchRequest, regions []string) (*FetchResponse, error) {
+	peerReq := *req
+	peerReq.CallbackURL = ""
+
+	var sdkReq sdk.FetchRequest
+	if err := convertJSON(peerReq, &sdkReq); err != nil {
+		return nil, fmt.Errorf("failed to build peer request: %w", err)
+	}
+
+	var lastErr error
+	for _, region := range regions {
+		peer, ok := s.peers[region]
+		if !ok {
+			lastErr = fmt.Errorf("no peer configured for region %s", region)
+			continue
+		}
+
+		sdkResp, err := peer.Fetch(ctx, sdkReq)
+		if err != nil {
+			s.logger.Warn("peer fetch failed", "url", req.URL, "region", region, "error", err)
+			lastErr = fmt.Errorf("region %s: %w", region, err)
+			continue
+		}
+
+		var resp FetchResponse
+		if err := convertJSON(sdkResp, &resp); err != nil {
+			lastErr = fmt.Errorf("region %s: invalid response: %w", region, err)
+			continue
+		}
+		if resp.Metadata.Region == "" {
+			resp.Metadata.Region = region
+		}
+
+		s.logger.Info("fetch delegated to peer", "url", req.URL, "region", region)
+		return &resp, nil
+	}
+
+	return nil, fmt.Errorf("all peer regions failed: %w", lastErr)
+}
+
+// convertJSON copies between the server and SDK representations of the same JSON shape.
+func convertJSON(in, out any) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakePeer starts a peer that answers every fetch with the given content and records the delegated header.
+func newFakePeer(t *testing.T, content string, delegated chan<- string) *httptest.Server {
+	t.Helper()
+
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req FetchRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Empty(t, req.CallbackURL, "callback should not be forwarded")
+		delegated <- r.Header.Get(delegatedHeader)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(FetchResponse{
+			Metadata: Metadata{StatusCode: http.StatusOK},
+			Content:  content,
+		})
+	}))
+	t.Cleanup(peer.Close)
+
+	return peer
+}
+
+// newRoutingTestServer creates a server in region "us" with a single "eu" peer and the given routing.
+func newRoutingTestServer(t *testing.T, peerURL string, routing config.RoutingConfig) *Server {
+	t.Helper()
+
+	cfg := config.New()
+	cfg.Region = "us"
+	cfg.Peers = []config.PeerConfig{{Region: "eu", URL: peerURL}}
+	cfg.Default.Routing = routing
+
+	c, err := client.New(cfg)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// TestProcessFetchGeoBlockFallback verifies geo-blocked fetches are retried through a fallback peer.
+func TestProcessFetchGeoBlockFallback(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnavailableForLegalReasons)
+	}))
+	defer origin.Close()
+
+	delegated := make(chan string, 1)
+	peer := newFakePeer(t, "Content from eu", delegated)
+	s := newRoutingTestServer(t, peer.URL, config.RoutingConfig{FallbackRegions: []string{"eu"}})
+
+	resp, err := s.processFetch(context.Background(), &FetchRequest{URL: origin.URL, CallbackURL: "https://example.com/hook"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "us", <-delegated)
+	assert.Equal(t, "eu", resp.Metadata.Region)
+	assert.Equal(t, "Content from eu", resp.Content)
+}
+
+// TestProcessFetchLocalRegion verifies successful local fetches are not delegated and report the local region.
+func TestProcessFetchLocalRegion(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body><p>Local content</p></body></html>"))
+	}))
+	defer origin.Close()
+
+	delegated := make(chan string, 1)
+	peer := newFakePeer(t, "Content from eu", delegated)
+	s := newRoutingTestServer(t, peer.URL, config.RoutingConfig{FallbackRegions: []string{"eu"}})
+
+	resp, err := s.processFetch(context.Background(), &FetchRequest{URL: origin.URL})
+	require.NoError(t, err)
+
+	assert.Equal(t, "us", resp.Metadata.Region)
+	assert.Contains(t, resp.Content, "Local content")
+	assert.Empty(t, delegated, "peer should not be called")
+}
+
+// TestProcessFetchAlwaysRouted verifies sites with routing regions are fetched by the peer without a local attempt.
+func TestProcessFetchAlwaysRouted(t *testing.T) {
+	delegated := make(chan string, 1)
+	peer := newFakePeer(t, "Content from eu", delegated)
+	s := newRoutingTestServer(t, peer.URL, config.RoutingConfig{Regions: []string{"eu"}})
+
+	resp, err := s.processFetch(context.Background(), &FetchRequest{URL: "https://websurfer-test.invalid"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "us", <-delegated)
+	assert.Equal(t, "eu", resp.Metadata.Region)
+}
+
+// TestProcessFetchPeerFailureReturnsLocal verifies the local response is kept when every fallback peer fails.
+func TestProcessFetchPeerFailureReturnsLocal(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnavailableForLegalReasons)
+	}))
+	defer origin.Close()
+
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error":"forbidden"}`))
+	}))
+	defer peer.Close()
+
+	s := newRoutingTestServer(t, peer.URL, config.RoutingConfig{FallbackRegions: []string{"eu"}})
+
+	resp, err := s.processFetch(context.Background(), &FetchRequest{URL: origin.URL})
+	require.NoError(t, err)
+
+	assert.Equal(t, "us", resp.Metadata.Region)
+	assert.Equal(t, http.StatusUnavailableForLegalReasons, resp.Metadata.StatusCode)
+}
+
+// TestProcessFetchDelegatedNotRerouted verifies requests forwarded by a peer are fetched locally to avoid loops.
+func TestProcessFetchDelegatedNotRerouted(t *testing.T) {
+	delegated := make(chan string, 1)
+	peer := newFakePeer(t, "Content from eu", delegated)
+	s := newRoutingTestServer(t, peer.URL, config.RoutingConfig{Regions: []string{"eu"}})
+
+	_, err := s.processFetch(context.Background(), &FetchRequest{URL: "https://websurfer-test.invalid", delegated: true})
+	assert.Error(t, err, "delegated request should be fetched locally")
+	assert.Empty(t, delegated, "peer should not be called")
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/search/semantic"
+	urlpkg "github.com/joeychilson/websurfer/url"
+)
+
+// SemanticSearchRequest represents a request to search a URL's content by meaning.
+type SemanticSearchRequest struct {
+	URL          string        `json:"url"`
+	Query        string        `json:"query"`
+	TopK         int           `json:"top_k,omitempty"`
+	BypassCache  bool          `json:"bypass_cache,omitempty"`
+	ParseOptions *ParseOptions `json:"parse_options,omitempty"`
+}
+
+// SemanticSearchResponse represents the response from a semantic search request.
+type SemanticSearchResponse struct {
+	URL     string            `json:"url"`
+	Results []semantic.Result `json:"results"`
+}
+
+// handleSemanticSearch handles POST /v1/search/semantic requests.
+func (s *Server) handleSemanticSearch(w http.ResponseWriter, r *http.Request) {
+	if s.semantic == nil {
+		s.sendError(w, "semantic search is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req SemanticSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("failed to decode request", "error", err)
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateSemanticSearchRequest(&req); err != nil {
+		s.logger.Error("invalid request", "error", err)
+		s.sendValidationError(w, err)
+		return
+	}
+
+	if err := s.policy.Check(req.URL); err != nil {
+		s.sendValidationError(w, err)
+		return
+	}
+
+	fetched, err := s.client.FetchWithOptions(r.Context(), req.URL, &client.FetchOptions{
+		BypassCache:  req.BypassCache,
+		ParseOptions: req.ParseOptions.toParserOptions(),
+	})
+	if err != nil {
+		errResp := buildFetchError(req.URL, err)
+		s.logger.Error("fetch failed", "url", req.URL, "error", err, "failure_type", errResp.FailureType)
+		s.sendJSON(w, errResp, errResp.StatusCode)
+		return
+	}
+
+	resp, err := s.searchFetched(r.Context(), fetched, &req)
+	if err != nil {
+		s.logger.Error("semantic search failed", "url", req.URL, "error", err)
+		s.sendError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	s.sendJSON(w, resp, http.StatusOK)
+}
+
+// searchFetched ranks the fetched content's chunks against the request query.
+func (s *Server) searchFetched(ctx context.Context, fetched *client.Response, req *SemanticSearchRequest) (*SemanticSearchResponse, error) {
+	var contentType string
+	if values, ok := fetched.Headers["Content-Type"]; ok && len(values) > 0 {
+		contentType = values[0]
+	}
+
+	results, err := s.semantic.Search(ctx, fetched.Body, contentType, req.Query, req.TopK)
+	if err != nil {
+		return nil, fmt.Errorf("semantic search failed: %w", err)
+	}
+
+	return &SemanticSearchResponse{URL: fetched.URL, Results: results}, nil
+}
+
+// validateSemanticSearchRequest validates the semantic search request.
+func validateSemanticSearchRequest(req *SemanticSearchRequest) error {
+	if _, err := urlpkg.ValidateExternal(req.URL); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(req.Query) == "" {
+		return fmt.Errorf("query is required")
+	}
+
+	if req.TopK < 0 {
+		return fmt.Errorf("top_k must be non-negative")
+	}
+
+	if err := req.ParseOptions.toParserOptions().Validate(); err != nil {
+		return fmt.Errorf("invalid parse_options: %w", err)
+	}
+
+	return nil
+}
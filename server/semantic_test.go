@@ -0,0 +1,105 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joeychilson/websurfer/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keywordEmbedder embeds text as a one-hot vector of whether it mentions each keyword.
+type keywordEmbedder struct {
+	keywords []string
+}
+
+func (e keywordEmbedder) Name() string { return "test:keywords" }
+
+func (e keywordEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, len(e.keywords)+1)
+		v[len(e.keywords)] = 0.1
+		for j, keyword := range e.keywords {
+			if strings.Contains(strings.ToLower(text), keyword) {
+				v[j] = 1
+			}
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+// newSemanticTestServer creates a server with semantic search backed by a keyword embedder.
+func newSemanticTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+
+	s, err := New(c, nil, &ServerConfig{Embedder: keywordEmbedder{keywords: []string{"cache", "retry"}}})
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// TestHandleSemanticSearchNotConfigured verifies semantic search is unavailable without an embedder.
+func TestHandleSemanticSearchNotConfigured(t *testing.T) {
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+
+	body := `{"url": "https://example.com", "query": "pricing"}`
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest("POST", "/v1/search/semantic", bytes.NewBufferString(body)))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+// TestHandleSemanticSearchInvalidRequest verifies invalid requests are rejected.
+func TestHandleSemanticSearchInvalidRequest(t *testing.T) {
+	s := newSemanticTestServer(t)
+
+	tests := []string{
+		`{"url": "https://example.com"}`,
+		`{"url": "https://example.com", "query": "x", "top_k": -1}`,
+		`{"url": "http://127.0.0.1", "query": "x"}`,
+		`not json`,
+	}
+
+	for _, body := range tests {
+		w := httptest.NewRecorder()
+		s.Router().ServeHTTP(w, httptest.NewRequest("POST", "/v1/search/semantic", bytes.NewBufferString(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
+
+// TestSearchFetchedRanksChunks verifies fetched content is chunked and ranked against the query.
+func TestSearchFetchedRanksChunks(t *testing.T) {
+	s := newSemanticTestServer(t)
+
+	fetched := &client.Response{
+		URL:     "https://example.com/docs",
+		Headers: map[string][]string{"Content-Type": {"text/markdown"}},
+		Body:    []byte("# Docs\n\n## Retries\n\nFailed requests retry with backoff.\n\n## Caching\n\nResponses cache in Redis."),
+	}
+
+	resp, err := s.searchFetched(context.Background(), fetched, &SemanticSearchRequest{Query: "how long is the cache kept", TopK: 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://example.com/docs", resp.URL)
+	require.Len(t, resp.Results, 1)
+	assert.Equal(t, "Caching", resp.Results[0].Section)
+	assert.Equal(t, "caching", resp.Results[0].Anchor)
+	assert.Equal(t, string(fetched.Body[resp.Results[0].CharStart:resp.Results[0].CharEnd]), resp.Results[0].Text)
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/joeychilson/websurfer/client"
+)
+
+const (
+	// defaultSeriesPages is how many pages of a series are fetched when max_pages is unset.
+	defaultSeriesPages = 5
+	// maxSeriesPages caps the max_pages of a fetch request.
+	maxSeriesPages = 20
+)
+
+// followPagination fetches the pages that follow first in a paginated series and joins their
+// content, each page preceded by a source marker. It stops after maxPages pages, or at the
+// first page that repeats, is on another host, or fails to fetch. The URLs of the joined pages
+// are returned only when more than one page was found.
+func (s *Server) followPagination(ctx context.Context, first *client.Response, req *FetchRequest) ([]byte, []string) {
+	maxPages := req.MaxPages
+	if maxPages == 0 {
+		maxPages = defaultSeriesPages
+	}
+
+	firstURL, err := url.Parse(first.URL)
+	if err != nil {
+		return first.Body, nil
+	}
+
+	pages := []*client.Response{first}
+	seen := map[string]bool{first.URL: true, req.URL: true}
+	for next := first.NextURL; next != "" && len(pages) < maxPages; {
+		nextURL, err := url.Parse(next)
+		if err != nil || nextURL.Host != firstURL.Host || seen[next] {
+			break
+		}
+		seen[next] = true
+
+		fetched, err := s.client.FetchWithOptions(ctx, next, req.fetchOptions())
+		if err != nil || fetched.StatusCode != http.StatusOK {
+			s.logger.Debug("stopped following pagination", "url", next, "error", err)
+			break
+		}
+		seen[fetched.URL] = true
+
+		pages = append(pages, fetched)
+		next = fetched.NextURL
+	}
+
+	if len(pages) == 1 {
+		return first.Body, nil
+	}
+
+	var buf bytes.Buffer
+	urls := make([]string, len(pages))
+	for i, page := range pages {
+		urls[i] = page.URL
+		if i > 0 {
+			buf.WriteString("\n\n")
+		}
+		fmt.Fprintf(&buf, "<!-- page %d of %d: %s -->\n\n", i+1, len(pages), page.URL)
+		buf.Write(bytes.TrimSpace(page.Body))
+	}
+	buf.WriteString("\n")
+
+	return buf.Bytes(), urls
+}
@@ -1,9 +1,11 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -12,13 +14,50 @@ import (
 	"github.com/redis/go-redis/v9"
 
 	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/cluster"
+	"github.com/joeychilson/websurfer/config"
+	"github.com/joeychilson/websurfer/content"
+	"github.com/joeychilson/websurfer/jobs"
+	"github.com/joeychilson/websurfer/objectstore"
+	"github.com/joeychilson/websurfer/policy"
+	"github.com/joeychilson/websurfer/provenance"
+	"github.com/joeychilson/websurfer/robots"
+	"github.com/joeychilson/websurfer/sdk"
+	"github.com/joeychilson/websurfer/search/semantic"
+	"github.com/joeychilson/websurfer/session"
+	"github.com/joeychilson/websurfer/tracing"
+	"github.com/joeychilson/websurfer/translate"
+	"github.com/joeychilson/websurfer/watcher"
+	"github.com/joeychilson/websurfer/webhook"
 )
 
 // ServerConfig holds configuration for the API server.
 type ServerConfig struct {
+	// RedisClient enables the job queue, page watches, sessions, and the map cache, and shares API rate limits and
+	// robots.txt files across instances.
 	RedisClient       *redis.Client
 	RateLimitRequests int
 	RateLimitWindow   time.Duration
+	// WebhookSecret signs async job callbacks with HMAC-SHA256. Callbacks are unsigned if empty.
+	WebhookSecret string
+	// SigningKey is a base64-encoded Ed25519 seed used to attach provenance proofs to fetch
+	// responses. Responses are unsigned if empty.
+	SigningKey string
+	// JobWorkers is the number of workers processing the Redis job queue (default: 4).
+	// The job queue is only enabled when RedisClient is set.
+	JobWorkers int
+	// ClusterMode elects a leader among instances sharing RedisClient, so scheduled sweeps
+	// such as requeueing expired job leases run once per cluster. Requires RedisClient.
+	ClusterMode bool
+	// InstanceID identifies this instance in the cluster. Defaults to the hostname plus a random suffix.
+	InstanceID string
+	// Embedder enables POST /v1/search/semantic. Chunk vectors are cached in RedisClient when set.
+	Embedder semantic.Embedder
+	// ObjectStore lets POST /v1/download upload assets and return a reference instead of their bytes,
+	// and holds the raw bodies of fetches over the max_raw_bytes limit.
+	ObjectStore *objectstore.Store
+	// Translator enables translate_to on fetch requests.
+	Translator translate.Translator
 }
 
 // Server represents the API server.
@@ -26,6 +65,25 @@ type Server struct {
 	client      *client.Client
 	logger      *slog.Logger
 	rateLimiter func(next http.Handler) http.Handler
+	webhook     *webhook.Sender
+	jobsCtx     context.Context
+	cancelJobs  context.CancelFunc
+	asyncJobs   sync.WaitGroup
+	queue       *jobs.Queue
+	watcher     *watcher.Watcher
+	sessions    *session.Store
+	mapCache    *mapCache
+	elector     *cluster.Elector
+	tokenizer   content.Tokenizer
+	region      string
+	peers       map[string]*sdk.Client
+	semantic    *semantic.Searcher
+	objectStore *objectstore.Store
+	signer      *provenance.Signer
+	policy      *policy.Policy
+	limits      config.LimitsConfig
+	translator  translate.Translator
+	crawls      crawlRegistry
 }
 
 // New creates a new API server instance.
@@ -48,6 +106,31 @@ func New(c *client.Client, log *slog.Logger, cfg *ServerConfig) (*Server, error)
 	if cfg.RateLimitWindow == 0 {
 		cfg.RateLimitWindow = time.Minute
 	}
+	if cfg.JobWorkers == 0 {
+		cfg.JobWorkers = 4
+	}
+
+	if cfg.ClusterMode && cfg.RedisClient == nil {
+		return nil, fmt.Errorf("cluster mode requires a redis client")
+	}
+
+	tokenizer, err := content.NewTokenizer(c.Config().Tokenizer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tokenizer: %w", err)
+	}
+
+	domainPolicy, err := policy.New(c.Config().Policy)
+	if err != nil {
+		return nil, err
+	}
+
+	var signer *provenance.Signer
+	if cfg.SigningKey != "" {
+		signer, err = provenance.NewSigner(cfg.SigningKey)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	rateLimitConfig := RateLimitConfig{
 		RequestLimit:   cfg.RateLimitRequests,
@@ -56,11 +139,83 @@ func New(c *client.Client, log *slog.Logger, cfg *ServerConfig) (*Server, error)
 	}
 	rateLimiter := RateLimit(rateLimitConfig)
 
-	return &Server{
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+
+	s := &Server{
 		client:      c,
 		logger:      log,
 		rateLimiter: rateLimiter,
-	}, nil
+		webhook:     webhook.New(cfg.WebhookSecret),
+		jobsCtx:     jobsCtx,
+		cancelJobs:  cancelJobs,
+		tokenizer:   tokenizer,
+		region:      c.Config().Region,
+		peers:       newPeerClients(c.Config().Peers, c.Config().Region),
+		objectStore: cfg.ObjectStore,
+		signer:      signer,
+		policy:      domainPolicy,
+		limits:      c.Config().Limits,
+		translator:  cfg.Translator,
+	}
+
+	if cfg.RedisClient != nil {
+		c.WithRobotsCache(robots.NewRedisCache(cfg.RedisClient, ""))
+	}
+
+	if cfg.Embedder != nil {
+		semanticConfig := semantic.Config{Logger: log}
+		if cfg.RedisClient != nil {
+			semanticConfig.Store = semantic.NewStore(cfg.RedisClient, semantic.StoreConfig{})
+		}
+		s.semantic = semantic.NewSearcher(cfg.Embedder, semanticConfig)
+	}
+
+	if cfg.ClusterMode {
+		s.elector = cluster.NewElector(cfg.RedisClient, "scheduler", cluster.Config{
+			InstanceID: cfg.InstanceID,
+			Logger:     log,
+		})
+		s.asyncJobs.Add(1)
+		go func() {
+			defer s.asyncJobs.Done()
+			s.elector.Run(jobsCtx)
+		}()
+	}
+
+	if cfg.RedisClient != nil {
+		jobsConfig := jobs.Config{Logger: log}
+		if s.elector != nil {
+			jobsConfig.IsLeader = s.elector.IsLeader
+		}
+		s.queue = jobs.New(cfg.RedisClient, jobsConfig)
+		for range cfg.JobWorkers {
+			s.asyncJobs.Add(1)
+			go func() {
+				defer s.asyncJobs.Done()
+				s.queue.Work(jobsCtx, s.runJob)
+			}()
+		}
+
+		s.sessions = session.New(cfg.RedisClient, session.Config{})
+		s.mapCache = &mapCache{client: cfg.RedisClient}
+
+		s.watcher = watcher.New(cfg.RedisClient, watcher.Config{Logger: log})
+		s.asyncJobs.Add(1)
+		go func() {
+			defer s.asyncJobs.Done()
+			s.watcher.Run(jobsCtx, s.fetchWatched, s.notifyWatch)
+		}()
+	}
+
+	return s, nil
+}
+
+// Close cancels running async jobs, stops job queue workers and the watch scheduler, and waits
+// for callbacks to be sent.
+// Queued jobs interrupted here are picked up again once their leases expire.
+func (s *Server) Close() {
+	s.cancelJobs()
+	s.asyncJobs.Wait()
 }
 
 // Router returns a configured chi.Mux with all routes and middleware.
@@ -69,17 +224,62 @@ func (s *Server) Router() chi.Router {
 
 	r.Use(chimiddleware.RequestID)
 	r.Use(chimiddleware.RealIP)
+	r.Use(tracing.Middleware())
 	r.Use(httplog.RequestLogger(s.logger, &httplog.Options{
 		Level:         slog.LevelInfo,
 		RecoverPanics: true,
 	}))
 
 	r.Get("/health", s.handleHealth)
+	r.Get("/v1/provenance/key", s.handleProvenanceKey)
+	r.Get("/v1/errors", s.handleErrors)
 
 	r.Group(func(r chi.Router) {
-		r.Use(AuthMiddleware())
+		r.Use(AuthMiddleware(s.client.Config().Callers, s.client.Config().Peers))
+		r.Use(PeerMiddleware(s.client.Config().Peers))
+		r.Use(CallerMiddleware(s.client.Config().Callers))
 		r.Use(s.rateLimiter)
 		r.Post("/v1/fetch", s.handleFetch)
+		r.Post("/v1/download", s.handleDownload)
+		r.Get("/v1/stats", s.handleStats)
+		r.Get("/metrics", s.handleMetrics)
+		r.Get("/v1/queue", s.handleQueue)
+		r.Post("/v1/search/semantic", s.handleSemanticSearch)
+		r.Post("/v1/map", s.handleMap)
+		r.Get("/v1/sitemaps", s.handleSitemaps)
+		r.Post("/v1/sitemap/generate", s.handleSitemapGenerate)
+		r.Post("/v1/validate", s.handleValidate)
+		r.Get("/v1/probe", s.handleProbe)
+		r.Post("/v1/head", s.handleHead)
+		r.Post("/v1/linkcheck", s.handleLinkcheck)
+		r.Get("/v1/diff", s.handleDiff)
+		r.Get("/v1/history", s.handleHistory)
+		r.Get("/v1/favicon", s.handleFavicon)
+		r.Get("/v1/preview-image", s.handlePreviewImage)
+		r.Post("/v1/jobs", s.handleCreateJob)
+		r.Get("/v1/jobs/{id}", s.handleGetJob)
+		r.Delete("/v1/jobs/{id}", s.handleCancelJob)
+		r.Get("/v1/jobs/{id}/outline", s.handleJobOutline)
+		r.Post("/v1/crawl", s.handleCrawl)
+		r.Post("/v1/preload", s.handlePreload)
+		r.Post("/v1/watch", s.handleCreateWatch)
+		r.Get("/v1/watch/{id}", s.handleGetWatch)
+		r.Delete("/v1/watch/{id}", s.handleDeleteWatch)
+		r.Post("/v1/sessions", s.handleCreateSession)
+		r.Get("/v1/sessions/{id}", s.handleGetSession)
+		r.Delete("/v1/sessions/{id}", s.handleDeleteSession)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(AdminMiddleware())
+		r.Use(s.rateLimiter)
+		r.Post("/v1/admin/cache/export", s.handleCacheExport)
+		r.Post("/v1/admin/cache/import", s.handleCacheImport)
+		r.Get("/v1/admin/cache/keys", s.handleCacheKeys)
+		r.Get("/v1/admin/cache/entry", s.handleCacheEntry)
+		r.Delete("/v1/admin/cache/entry", s.handleCachePurge)
+		r.Delete("/v1/admin/cache/domain", s.handleCachePurgeDomain)
+		r.Get("/v1/admin/cache/stats", s.handleCacheUsage)
 	})
 
 	return r
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/session"
+)
+
+const (
+	// defaultSessionMaxFetches is how many fetches a session may make when no limit is given.
+	defaultSessionMaxFetches = 100
+	// maxSessionMaxFetches is the most fetches a session may be allowed.
+	maxSessionMaxFetches = 1000
+	// defaultSessionIdleTimeout is how long an idle session lives when no timeout is given.
+	defaultSessionIdleTimeout = 30 * time.Minute
+	// maxSessionIdleTimeout is the longest idle timeout a session may have.
+	maxSessionIdleTimeout = 24 * time.Hour
+)
+
+// SessionRequest starts a browsing session. Fetches with its session_id share a cookie jar and
+// send the previous page as their Referer.
+type SessionRequest struct {
+	// MaxFetches is how many fetches the session may make (default: 100, max: 1000).
+	MaxFetches int `json:"max_fetches,omitempty"`
+	// IdleTimeout is how long the session lives after its last fetch, as a Go duration such as
+	// "10m" (default: "30m", max: "24h").
+	IdleTimeout string `json:"idle_timeout,omitempty"`
+}
+
+// SessionResponse describes a session.
+type SessionResponse struct {
+	ID          string `json:"id"`
+	MaxFetches  int    `json:"max_fetches"`
+	Fetches     int    `json:"fetches"`
+	IdleTimeout string `json:"idle_timeout"`
+	// Referer is the last page fetched in the session.
+	Referer   string          `json:"referer,omitempty"`
+	Cookies   []SessionCookie `json:"cookies,omitempty"`
+	CreatedAt string          `json:"created_at"`
+	ExpiresAt string          `json:"expires_at"`
+}
+
+// SessionCookie describes a cookie held by a session. Values are not returned.
+type SessionCookie struct {
+	Name    string `json:"name"`
+	Domain  string `json:"domain"`
+	Path    string `json:"path"`
+	Expires string `json:"expires,omitempty"`
+}
+
+// handleCreateSession handles POST /v1/sessions requests.
+func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	if s.sessions == nil {
+		s.sendError(w, "sessions are not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req SessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("failed to decode request", "error", err)
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	maxFetches, idleTimeout, err := validateSessionRequest(&req)
+	if err != nil {
+		s.logger.Error("invalid request", "error", err)
+		s.sendValidationError(w, err)
+		return
+	}
+
+	sess, err := s.sessions.Create(r.Context(), maxFetches, idleTimeout)
+	if err != nil {
+		s.sendSessionError(w, err)
+		return
+	}
+
+	s.logger.Info("session created", "session_id", sess.ID, "max_fetches", maxFetches, "idle_timeout", idleTimeout)
+	s.sendJSON(w, buildSessionResponse(sess), http.StatusCreated)
+}
+
+// handleGetSession handles GET /v1/sessions/{id} requests.
+func (s *Server) handleGetSession(w http.ResponseWriter, r *http.Request) {
+	if s.sessions == nil {
+		s.sendError(w, "sessions are not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	sess, err := s.sessions.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		s.sendSessionError(w, err)
+		return
+	}
+
+	s.sendJSON(w, buildSessionResponse(sess), http.StatusOK)
+}
+
+// handleDeleteSession handles DELETE /v1/sessions/{id} requests.
+func (s *Server) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
+	if s.sessions == nil {
+		s.sendError(w, "sessions are not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if err := s.sessions.Delete(r.Context(), id); err != nil {
+		s.sendSessionError(w, err)
+		return
+	}
+
+	s.logger.Info("session deleted", "session_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateSessionRequest validates a session request and returns its fetch limit and idle timeout.
+func validateSessionRequest(req *SessionRequest) (int, time.Duration, error) {
+	maxFetches := req.MaxFetches
+	if maxFetches == 0 {
+		maxFetches = defaultSessionMaxFetches
+	}
+	if maxFetches < 0 || maxFetches > maxSessionMaxFetches {
+		return 0, 0, fmt.Errorf("max_fetches must be between 1 and %d", maxSessionMaxFetches)
+	}
+
+	if req.IdleTimeout == "" {
+		return maxFetches, defaultSessionIdleTimeout, nil
+	}
+	idleTimeout, err := time.ParseDuration(req.IdleTimeout)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid idle_timeout: %w", err)
+	}
+	if idleTimeout < time.Minute || idleTimeout > maxSessionIdleTimeout {
+		return 0, 0, fmt.Errorf("idle_timeout must be between 1m and %s", maxSessionIdleTimeout)
+	}
+	return maxFetches, idleTimeout, nil
+}
+
+// sendSessionError maps a session store error to an error response.
+func (s *Server) sendSessionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, session.ErrNotFound) {
+		s.sendError(w, "session not found", http.StatusNotFound)
+		return
+	}
+	s.logger.Error("session error", "error", err)
+	s.sendError(w, "session error", http.StatusInternalServerError)
+}
+
+// fetchInSession fetches the request locally within its session. Sessions hold cookies set by
+// the sites fetched, so their fetches are never delegated to peers.
+func (s *Server) fetchInSession(ctx context.Context, req *FetchRequest) (*FetchResponse, error) {
+	sess, err := s.sessions.Begin(ctx, req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionReq := *req
+	sessionReq.session = sess
+	sessionReq.jar = sess.Jar(slices.Collect(maps.Keys(req.Cookies))...)
+	return s.fetchLocal(ctx, &sessionReq)
+}
+
+// recordSessionFetch stores the cookies the request's fetches received so far, including during
+// redirects and headless renders, and the fetched URL as the session's next Referer. Failures
+// are logged, since the fetch itself succeeded.
+func (s *Server) recordSessionFetch(ctx context.Context, req *FetchRequest, fetched *client.Response) {
+	if fetched.OriginalURL != "" {
+		// Archived snapshots are fetched without the session's cookies and are not the site's
+		// page, so they shouldn't become its Referer.
+		return
+	}
+
+	if err := s.sessions.Record(ctx, req.session, fetched.URL, req.jar); err != nil {
+		s.logger.Warn("failed to record session fetch", "session_id", req.session.ID, "url", fetched.URL, "error", err)
+	}
+}
+
+// buildSessionResponse converts a session to its API representation.
+func buildSessionResponse(sess *session.Session) SessionResponse {
+	resp := SessionResponse{
+		ID:          sess.ID,
+		MaxFetches:  sess.MaxFetches,
+		Fetches:     sess.Fetches,
+		IdleTimeout: sess.IdleTimeout.String(),
+		Referer:     sess.Referer,
+		CreatedAt:   sess.CreatedAt.UTC().Format(time.RFC3339),
+		ExpiresAt:   sess.ExpiresAt.UTC().Format(time.RFC3339),
+	}
+	for _, cookie := range sess.Cookies {
+		sessionCookie := SessionCookie{Name: cookie.Name, Domain: cookie.Domain, Path: cookie.Path}
+		if !cookie.Expires.IsZero() {
+			sessionCookie.Expires = cookie.Expires.UTC().Format(time.RFC3339)
+		}
+		resp.Cookies = append(resp.Cookies, sessionCookie)
+	}
+	return resp
+}
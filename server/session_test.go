@@ -0,0 +1,128 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSessionEndpoints verifies sessions can be created, inspected, and deleted.
+func TestSessionEndpoints(t *testing.T) {
+	router := newJobTestServer(t).Router()
+
+	invalid := []SessionRequest{
+		{MaxFetches: -1},
+		{MaxFetches: maxSessionMaxFetches + 1},
+		{IdleTimeout: "10s"},
+		{IdleTimeout: "48h"},
+		{IdleTimeout: "soon"},
+	}
+	for _, req := range invalid {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/v1/sessions", bytes.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, "%+v", req)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/v1/sessions", bytes.NewReader([]byte(`{}`))))
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created SessionResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.NotEmpty(t, created.ID)
+	assert.Equal(t, defaultSessionMaxFetches, created.MaxFetches)
+	assert.Equal(t, "30m0s", created.IdleTimeout)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/v1/sessions/"+created.ID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/v1/sessions/"+created.ID, nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	for _, method := range []string{"GET", "DELETE"} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, "/v1/sessions/"+created.ID, nil))
+		assert.Equal(t, http.StatusNotFound, w.Code, method)
+	}
+}
+
+// TestSessionRequiresRedis verifies sessions are unavailable without the job queue's Redis.
+func TestSessionRequiresRedis(t *testing.T) {
+	router := newMapTestServer(t).Router()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/v1/sessions", bytes.NewReader([]byte(`{}`))))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	body, _ := json.Marshal(FetchRequest{URL: "https://example.com", SessionID: "abc"})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/v1/fetch", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+// TestFetchInSession verifies fetches in a session share cookies, including those set during
+// redirects, send the previous page as the Referer, skip the cache, and stop once the session's
+// budget is used.
+func TestFetchInSession(t *testing.T) {
+	var origin *httptest.Server
+	origin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "abc", Path: "/"})
+			http.Redirect(w, r, "/home", http.StatusFound)
+		case "/home":
+			if cookie, err := r.Cookie("sid"); err != nil || cookie.Value != "abc" {
+				w.Write([]byte("anonymous"))
+				return
+			}
+			w.Write([]byte("logged in"))
+		case "/account":
+			cookie, err := r.Cookie("sid")
+			if err != nil || cookie.Value != "abc" || r.Referer() != origin.URL+"/home" {
+				w.Write([]byte("anonymous"))
+				return
+			}
+			w.Write([]byte("welcome back"))
+		}
+	}))
+	defer origin.Close()
+
+	s := newJobTestServer(t)
+	ctx := context.Background()
+
+	sess, err := s.sessions.Create(ctx, 2, defaultSessionIdleTimeout)
+	require.NoError(t, err)
+
+	// The test origin is on loopback, which request validation rejects, so fetch directly.
+	resp, err := s.processFetch(ctx, &FetchRequest{URL: origin.URL + "/login", SessionID: sess.ID})
+	require.NoError(t, err)
+	assert.Equal(t, "logged in", resp.Content, "a cookie set by a redirect should be sent to its target")
+
+	resp, err = s.processFetch(ctx, &FetchRequest{URL: origin.URL + "/account", SessionID: sess.ID})
+	require.NoError(t, err)
+	assert.Equal(t, "welcome back", resp.Content)
+	assert.Equal(t, "miss", resp.Metadata.CacheState)
+
+	_, err = s.processFetch(ctx, &FetchRequest{URL: origin.URL + "/account", SessionID: sess.ID})
+	require.Error(t, err)
+	errResp := buildFetchError(origin.URL, err)
+	assert.Equal(t, http.StatusTooManyRequests, errResp.StatusCode)
+	assert.Equal(t, ErrorCodeSessionExhausted, errResp.ErrorCode)
+
+	resp, err = s.processFetch(ctx, &FetchRequest{URL: origin.URL + "/account"})
+	require.NoError(t, err)
+	assert.Equal(t, "anonymous", resp.Content, "fetches outside the session should not share its cookies")
+
+	_, err = s.processFetch(ctx, &FetchRequest{URL: origin.URL + "/account", SessionID: "missing"})
+	assert.Equal(t, http.StatusNotFound, buildFetchError(origin.URL, err).StatusCode)
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/joeychilson/websurfer/crawler"
+	"github.com/joeychilson/websurfer/sitemap"
+)
+
+const (
+	// SitemapFormatXML returns a generated sitemap as a sitemap.xml document.
+	SitemapFormatXML = "xml"
+	// SitemapFormatJSON returns a generated sitemap as a SitemapGenerateResponse.
+	SitemapFormatJSON = "json"
+)
+
+// SitemapGenerateRequest represents a request to crawl a site and generate its sitemap.
+type SitemapGenerateRequest struct {
+	CrawlRequest
+	// Format is "xml" (default) or "json".
+	Format string `json:"format,omitempty"`
+}
+
+// SitemapGenerateResponse lists the pages of a generated sitemap.
+type SitemapGenerateResponse struct {
+	URL   string   `json:"url"`
+	Total int      `json:"total"`
+	URLs  []MapURL `json:"urls"`
+	// LimitReached names the server safety limit that cut the crawl short, if any.
+	LimitReached string `json:"limit_reached,omitempty"`
+}
+
+// handleSitemapGenerate handles POST /v1/sitemap/generate requests.
+func (s *Server) handleSitemapGenerate(w http.ResponseWriter, r *http.Request) {
+	var req SitemapGenerateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("failed to decode request", "error", err)
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.validateSitemapGenerateRequest(&req); err != nil {
+		s.logger.Error("invalid request", "error", err)
+		s.sendValidationError(w, err)
+		return
+	}
+
+	s.logger.Info("sitemap generate request", "url", req.URL, "max_pages", req.MaxPages, "max_depth", req.MaxDepth)
+
+	resp, err := s.processSitemapGenerate(r.Context(), &req)
+	if err != nil {
+		errResp := buildFetchError(req.URL, err)
+		s.logger.Error("sitemap generate failed", "url", req.URL, "error", err, "failure_type", errResp.FailureType)
+		s.sendJSON(w, errResp, errResp.StatusCode)
+		return
+	}
+
+	s.logger.Info("sitemap generated", "url", req.URL, "total", resp.Total, "limit_reached", resp.LimitReached)
+
+	if req.Format == SitemapFormatJSON {
+		s.sendJSON(w, resp, http.StatusOK)
+		return
+	}
+
+	urls := make([]sitemap.URL, 0, len(resp.URLs))
+	for _, u := range resp.URLs {
+		urls = append(urls, sitemap.URL{Loc: u.URL, LastMod: u.LastModified})
+	}
+	data, err := sitemap.Marshal(urls)
+	if err != nil {
+		s.logger.Error("failed to write sitemap", "url", req.URL, "error", err)
+		s.sendError(w, "failed to write sitemap", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	if resp.LimitReached != "" {
+		w.Header().Set("X-Limit-Reached", resp.LimitReached)
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		s.logger.Error("failed to write sitemap", "error", err)
+	}
+}
+
+// validateSitemapGenerateRequest validates a sitemap generation request and fills in defaults.
+func (s *Server) validateSitemapGenerateRequest(req *SitemapGenerateRequest) error {
+	if err := s.validateCrawlRequest(&req.CrawlRequest); err != nil {
+		return err
+	}
+
+	switch req.Format {
+	case "":
+		req.Format = SitemapFormatXML
+	case SitemapFormatXML, SitemapFormatJSON:
+	default:
+		return fmt.Errorf("format must be %q or %q", SitemapFormatXML, SitemapFormatJSON)
+	}
+	return nil
+}
+
+// processSitemapGenerate crawls the site and lists the same-host pages that were fetched
+// successfully, in crawl order, with their Last-Modified dates. Redirected pages are listed
+// at the URL they redirected to, once. When a server safety limit stops the crawl, the pages
+// visited so far are returned.
+func (s *Server) processSitemapGenerate(ctx context.Context, req *SitemapGenerateRequest) (*SitemapGenerateResponse, error) {
+	start, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+
+	guard, ctx, cancel := s.newLimitGuard(ctx)
+	defer cancel()
+
+	c := crawler.New(s.crawlFetch(guard, req.BypassCache, nil), crawler.Config{
+		MaxPages:        req.MaxPages,
+		MaxDepth:        req.MaxDepth,
+		RespectNofollow: req.RespectNofollow,
+		Canonical:       req.Canonical,
+		NormalizeURL:    s.client.NormalizeURL,
+		Logger:          s.logger,
+	})
+
+	resp := &SitemapGenerateResponse{URL: req.URL, URLs: []MapURL{}}
+	seen := make(map[string]bool)
+	err = c.Crawl(ctx, req.URL, func(page *crawler.Page) {
+		if page.Err != nil || page.Result.StatusCode < http.StatusOK || page.Result.StatusCode >= http.StatusMultipleChoices {
+			return
+		}
+
+		// Pages remapped to their canonical URL are listed under it rather than where they were found.
+		listed := page.Result.URL
+		if page.RemappedFrom != "" {
+			listed = page.URL
+		}
+		pageURL, err := url.Parse(s.client.NormalizeURL(listed))
+		if err != nil || pageURL.Host == "" {
+			pageURL, _ = url.Parse(page.URL)
+		}
+		pageURL.Fragment = ""
+		if pageURL.Host != start.Host || seen[pageURL.String()] {
+			return
+		}
+		seen[pageURL.String()] = true
+
+		resp.URLs = append(resp.URLs, MapURL{URL: pageURL.String(), LastModified: sitemapLastMod(page.Result.LastModified)})
+	})
+	resp.LimitReached = guard.limitReached(ctx)
+	if err != nil && resp.LimitReached == "" {
+		return nil, err
+	}
+
+	resp.Total = len(resp.URLs)
+	return resp, nil
+}
+
+// sitemapLastMod converts a Last-Modified header to the W3C datetime used by sitemaps, or
+// returns "" if it is missing or invalid.
+func sitemapLastMod(lastModified string) string {
+	if lastModified == "" {
+		return ""
+	}
+	t, err := http.ParseTime(lastModified)
+	if err != nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
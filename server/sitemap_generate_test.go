@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProcessSitemapGenerate verifies generated sitemaps list successful same-host pages once,
+// with lastmod taken from their Last-Modified headers.
+func TestProcessSitemapGenerate(t *testing.T) {
+	var origin *httptest.Server
+	origin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Last-Modified", "Wed, 01 Jan 2025 10:00:00 GMT")
+			fmt.Fprintf(w, `<html><body><a href="%[1]s/guide">Guide</a><a href="%[1]s/old">Old</a><a href="%[1]s/missing">Missing</a><a href="https://other.example/">Other</a></body></html>`, origin.URL)
+		case "/guide":
+			fmt.Fprintf(w, `<html><body><p>Guide</p><a href="%s/">Home</a></body></html>`, origin.URL)
+		case "/old":
+			http.Redirect(w, r, "/guide", http.StatusMovedPermanently)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer origin.Close()
+
+	s := newMapTestServer(t)
+	req := &SitemapGenerateRequest{CrawlRequest: CrawlRequest{URL: origin.URL + "/", MaxPages: 10, MaxDepth: 2}}
+
+	resp, err := s.processSitemapGenerate(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []MapURL{
+		{URL: origin.URL + "/", LastModified: "2025-01-01T10:00:00Z"},
+		{URL: origin.URL + "/guide"},
+	}, resp.URLs)
+	assert.Equal(t, 2, resp.Total)
+	assert.Empty(t, resp.LimitReached)
+}
+
+// TestValidateSitemapGenerateRequest verifies crawl defaults and the format option.
+func TestValidateSitemapGenerateRequest(t *testing.T) {
+	req := SitemapGenerateRequest{CrawlRequest: CrawlRequest{URL: "https://example.com"}}
+	require.NoError(t, (&Server{}).validateSitemapGenerateRequest(&req))
+	assert.Equal(t, SitemapFormatXML, req.Format)
+	assert.Equal(t, 100, req.MaxPages)
+
+	req = SitemapGenerateRequest{CrawlRequest: CrawlRequest{URL: "https://example.com"}, Format: "csv"}
+	assert.Error(t, (&Server{}).validateSitemapGenerateRequest(&req))
+
+	req = SitemapGenerateRequest{CrawlRequest: CrawlRequest{URL: "http://localhost"}, Format: SitemapFormatJSON}
+	assert.Error(t, (&Server{}).validateSitemapGenerateRequest(&req))
+}
+
+// TestSitemapLastMod verifies Last-Modified headers are converted to W3C datetimes.
+func TestSitemapLastMod(t *testing.T) {
+	assert.Equal(t, "2025-03-04T05:06:07Z", sitemapLastMod("Tue, 04 Mar 2025 05:06:07 GMT"))
+	assert.Empty(t, sitemapLastMod(""))
+	assert.Empty(t, sitemapLastMod("yesterday"))
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	urlpkg "github.com/joeychilson/websurfer/url"
+)
+
+const (
+	// SitemapTypeIndex is a sitemap that lists other sitemaps.
+	SitemapTypeIndex = "index"
+	// SitemapTypeURLSet is a sitemap that lists pages.
+	SitemapTypeURLSet = "urlset"
+)
+
+// SitemapsResponse lists the sitemaps a site declares in its robots.txt.
+type SitemapsResponse struct {
+	URL      string        `json:"url"`
+	Sitemaps []SitemapInfo `json:"sitemaps"`
+}
+
+// SitemapInfo describes a sitemap. Type, URLCount, and Error are only set when sitemaps are
+// expanded, and Parent is set for sitemaps found inside a sitemap index.
+type SitemapInfo struct {
+	URL      string `json:"url"`
+	Parent   string `json:"parent,omitempty"`
+	Type     string `json:"type,omitempty"`
+	URLCount int    `json:"url_count,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// handleSitemaps handles GET /v1/sitemaps requests.
+func (s *Server) handleSitemaps(w http.ResponseWriter, r *http.Request) {
+	pageURL := r.URL.Query().Get("url")
+	if _, err := urlpkg.ValidateExternal(pageURL); err != nil {
+		s.sendValidationError(w, err)
+		return
+	}
+
+	if err := s.policy.Check(pageURL); err != nil {
+		s.sendValidationError(w, err)
+		return
+	}
+
+	expand, err := parseBoolParam(r.URL.Query().Get("expand"))
+	if err != nil {
+		s.sendError(w, "expand must be true or false", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := s.processSitemaps(r.Context(), pageURL, expand)
+	if err != nil {
+		errResp := buildFetchError(pageURL, err)
+		s.logger.Error("sitemaps failed", "url", pageURL, "error", err, "failure_type", errResp.FailureType)
+		s.sendJSON(w, errResp, errResp.StatusCode)
+		return
+	}
+
+	s.sendJSON(w, resp, http.StatusOK)
+}
+
+// processSitemaps lists the sitemaps declared in robots.txt. With expand, each sitemap on the
+// same host is fetched to report its type and URL count, and sitemap indexes are followed.
+func (s *Server) processSitemaps(ctx context.Context, pageURL string, expand bool) (*SitemapsResponse, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	declared, err := s.client.GetSitemapsFromRobotsTxt(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &SitemapsResponse{URL: pageURL, Sitemaps: make([]SitemapInfo, 0, len(declared))}
+	seen := make(map[string]bool)
+	for _, sitemapURL := range declared {
+		if !seen[sitemapURL] {
+			seen[sitemapURL] = true
+			resp.Sitemaps = append(resp.Sitemaps, SitemapInfo{URL: sitemapURL})
+		}
+	}
+
+	if !expand {
+		return resp, nil
+	}
+
+	fetched := 0
+	for i := 0; i < len(resp.Sitemaps) && fetched < maxSitemaps; i++ {
+		info := &resp.Sitemaps[i]
+		if u, err := url.Parse(info.URL); err != nil || u.Host != base.Host {
+			info.Error = "not expanded: sitemap is on another host"
+			continue
+		}
+
+		fetched++
+		sm, err := s.fetchSitemap(ctx, nil, info.URL, false)
+		if err != nil {
+			info.Error = err.Error()
+			continue
+		}
+
+		if len(sm.Sitemaps) > 0 {
+			info.Type = SitemapTypeIndex
+		} else {
+			info.Type = SitemapTypeURLSet
+		}
+		info.URLCount = len(sm.URLs)
+
+		parent := info.URL
+		for _, child := range sm.Sitemaps {
+			if !seen[child] {
+				seen[child] = true
+				resp.Sitemaps = append(resp.Sitemaps, SitemapInfo{URL: child, Parent: parent})
+			}
+		}
+	}
+
+	return resp, nil
+}
+
+// parseBoolParam parses an optional boolean query parameter, where empty means false.
+func parseBoolParam(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRobotsSitemapOrigin starts a site whose robots.txt declares a sitemap index at a
+// non-standard path and a sitemap on another host.
+func newRobotsSitemapOrigin(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var origin *httptest.Server
+	origin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprintf(w, "User-agent: *\nDisallow:\n\nSitemap: %[1]s/maps/index.xml\nSitemap: https://cdn.example.com/sitemap.xml\nSitemap: %[1]s/maps/index.xml\n", origin.URL)
+		case "/maps/index.xml":
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprintf(w, `<sitemapindex><sitemap><loc>%[1]s/maps/pages.xml</loc></sitemap><sitemap><loc>%[1]s/maps/missing.xml</loc></sitemap></sitemapindex>`, origin.URL)
+		case "/maps/pages.xml":
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprintf(w, `<urlset><url><loc>%[1]s/a</loc></url><url><loc>%[1]s/b</loc></url></urlset>`, origin.URL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(origin.Close)
+
+	return origin
+}
+
+// TestProcessSitemapsDeclared verifies the sitemaps declared in robots.txt are listed once each.
+func TestProcessSitemapsDeclared(t *testing.T) {
+	origin := newRobotsSitemapOrigin(t)
+	s := newMapTestServer(t)
+
+	resp, err := s.processSitemaps(context.Background(), origin.URL, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, []SitemapInfo{
+		{URL: origin.URL + "/maps/index.xml"},
+		{URL: "https://cdn.example.com/sitemap.xml"},
+	}, resp.Sitemaps)
+}
+
+// TestProcessSitemapsExpand verifies same-host sitemaps are fetched and indexes are followed.
+func TestProcessSitemapsExpand(t *testing.T) {
+	origin := newRobotsSitemapOrigin(t)
+	s := newMapTestServer(t)
+
+	resp, err := s.processSitemaps(context.Background(), origin.URL, true)
+	require.NoError(t, err)
+	require.Len(t, resp.Sitemaps, 4)
+
+	index := origin.URL + "/maps/index.xml"
+	assert.Equal(t, SitemapInfo{URL: index, Type: SitemapTypeIndex}, resp.Sitemaps[0])
+	assert.Equal(t, "https://cdn.example.com/sitemap.xml", resp.Sitemaps[1].URL)
+	assert.NotEmpty(t, resp.Sitemaps[1].Error)
+	assert.Equal(t, SitemapInfo{URL: origin.URL + "/maps/pages.xml", Parent: index, Type: SitemapTypeURLSet, URLCount: 2}, resp.Sitemaps[2])
+	assert.Equal(t, origin.URL+"/maps/missing.xml", resp.Sitemaps[3].URL)
+	assert.NotEmpty(t, resp.Sitemaps[3].Error)
+}
+
+// TestProcessMapRobotsSitemaps verifies map uses the sitemaps declared in robots.txt.
+func TestProcessMapRobotsSitemaps(t *testing.T) {
+	origin := newRobotsSitemapOrigin(t)
+	s := newMapTestServer(t)
+
+	resp, err := s.processMap(context.Background(), &MapRequest{URL: origin.URL})
+	require.NoError(t, err)
+
+	assert.Equal(t, MapSourceSitemap, resp.Source)
+	assert.Equal(t, []MapURL{{URL: origin.URL + "/a"}, {URL: origin.URL + "/b"}}, resp.URLs)
+}
+
+// TestHandleSitemapsInvalidRequest verifies invalid sitemaps requests are rejected.
+func TestHandleSitemapsInvalidRequest(t *testing.T) {
+	s := newMapTestServer(t)
+
+	tests := []string{
+		"/v1/sitemaps",
+		"/v1/sitemaps?url=http://127.0.0.1",
+		"/v1/sitemaps?url=https://example.com&expand=maybe",
+	}
+
+	for _, target := range tests {
+		w := httptest.NewRecorder()
+		s.Router().ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/joeychilson/websurfer/cache"
+)
+
+const (
+	// maxSnapshotSize caps the size of an uploaded cache snapshot.
+	maxSnapshotSize = 1 << 30
+	// snapshotFilename is the suggested filename for exported snapshots.
+	snapshotFilename = "websurfer-cache.jsonl.gz"
+)
+
+// CacheExportRequest selects the cache entries to export: every cached URL on Domain, or the
+// listed URLs.
+type CacheExportRequest struct {
+	Domain string   `json:"domain,omitempty"`
+	URLs   []string `json:"urls,omitempty"`
+}
+
+// CacheImportResponse reports how many entries were imported from a snapshot.
+type CacheImportResponse struct {
+	Imported int `json:"imported"`
+}
+
+// handleCacheExport handles POST /v1/admin/cache/export requests.
+func (s *Server) handleCacheExport(w http.ResponseWriter, r *http.Request) {
+	responseCache := s.client.Cache()
+	if responseCache == nil {
+		s.sendError(w, "cache is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req CacheExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("failed to decode request", "error", err)
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if (req.Domain == "") == (len(req.URLs) == 0) {
+		s.sendError(w, "exactly one of domain or urls is required", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+snapshotFilename+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	// Entries are streamed, so a failure midway truncates the archive, which Import rejects.
+	count, err := responseCache.Export(r.Context(), w, cache.ExportFilter{Domain: req.Domain, URLs: req.URLs})
+	if err != nil {
+		s.logger.Error("cache export failed", "domain", req.Domain, "exported", count, "error", err)
+		return
+	}
+
+	s.logger.Info("cache exported", "domain", req.Domain, "urls", len(req.URLs), "exported", count)
+}
+
+// handleCacheImport handles POST /v1/admin/cache/import requests. The body is a snapshot from
+// the export endpoint, and the optional ttl query parameter sets how long imported entries
+// stay fresh.
+func (s *Server) handleCacheImport(w http.ResponseWriter, r *http.Request) {
+	responseCache := s.client.Cache()
+	if responseCache == nil {
+		s.sendError(w, "cache is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var opts cache.ImportOptions
+	if value := r.URL.Query().Get("ttl"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			s.sendError(w, "ttl must be a positive duration, such as 24h", http.StatusBadRequest)
+			return
+		}
+		opts.TTL = ttl
+	}
+
+	count, err := responseCache.Import(r.Context(), http.MaxBytesReader(w, r.Body, maxSnapshotSize), opts)
+	if err != nil {
+		s.logger.Error("cache import failed", "imported", count, "error", err)
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.logger.Info("cache imported", "imported", count, "ttl", opts.TTL)
+	s.sendJSON(w, CacheImportResponse{Imported: count}, http.StatusOK)
+}
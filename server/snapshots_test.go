@@ -0,0 +1,124 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joeychilson/websurfer/cache"
+	"github.com/joeychilson/websurfer/client"
+)
+
+// testAdminKey is the ADMIN_API_KEY of test servers with a cache.
+const testAdminKey = "admin-secret"
+
+// newCacheTestServer creates a server whose client caches responses in a fresh miniredis, with
+// admin endpoints enabled under testAdminKey.
+func newCacheTestServer(t *testing.T) (*Server, *cache.Cache) {
+	t.Helper()
+	t.Setenv("ADMIN_API_KEY", testAdminKey)
+
+	mr := miniredis.RunT(t)
+	responseCache := cache.New(redis.NewClient(&redis.Options{Addr: mr.Addr()}), cache.Config{})
+
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+	c.WithCache(responseCache)
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+	return s, responseCache
+}
+
+// newAdminRequest creates a request to an admin endpoint, authenticated with testAdminKey.
+func newAdminRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	req.Header.Set("X-API-Key", testAdminKey)
+	return req
+}
+
+// TestCacheExportImport verifies a snapshot exported from one server imports into another.
+func TestCacheExportImport(t *testing.T) {
+	ctx := context.Background()
+	source, sourceCache := newCacheTestServer(t)
+	require.NoError(t, sourceCache.Set(ctx, &cache.Entry{URL: "https://example.com/docs", StatusCode: 200, Body: []byte("Docs"), StoredAt: time.Now()}))
+
+	body, _ := json.Marshal(CacheExportRequest{Domain: "example.com"})
+	w := httptest.NewRecorder()
+	source.Router().ServeHTTP(w, newAdminRequest("POST", "/v1/admin/cache/export", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/gzip", w.Header().Get("Content-Type"))
+	archive := w.Body.Bytes()
+
+	target, targetCache := newCacheTestServer(t)
+	w = httptest.NewRecorder()
+	target.Router().ServeHTTP(w, newAdminRequest("POST", "/v1/admin/cache/import?ttl=48h", bytes.NewReader(archive)))
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp CacheImportResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 1, resp.Imported)
+
+	entry, err := targetCache.Get(ctx, "https://example.com/docs")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, "Docs", string(entry.Body))
+	assert.Equal(t, 48*time.Hour, entry.TTL)
+}
+
+// TestCacheSnapshotValidation verifies malformed export and import requests are rejected.
+func TestCacheSnapshotValidation(t *testing.T) {
+	s, _ := newCacheTestServer(t)
+	router := s.Router()
+
+	for _, req := range []CacheExportRequest{{}, {Domain: "example.com", URLs: []string{"https://example.com"}}} {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, newAdminRequest("POST", "/v1/admin/cache/export", bytes.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, "%+v", req)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newAdminRequest("POST", "/v1/admin/cache/import?ttl=soon", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, newAdminRequest("POST", "/v1/admin/cache/import", bytes.NewReader([]byte("not a snapshot"))))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestCacheSnapshotRequiresAdminKey verifies snapshot endpoints reject API keys.
+func TestCacheSnapshotRequiresAdminKey(t *testing.T) {
+	t.Setenv("API_KEY", "api-secret")
+	s, _ := newCacheTestServer(t)
+
+	req := httptest.NewRequest("POST", "/v1/admin/cache/import", nil)
+	req.Header.Set("X-API-Key", "api-secret")
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestCacheSnapshotWithoutCache verifies snapshot endpoints are unavailable without a cache.
+func TestCacheSnapshotWithoutCache(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", testAdminKey)
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, newAdminRequest("POST", "/v1/admin/cache/import", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/joeychilson/websurfer/translate"
+)
+
+// errTranslationNotConfigured is returned when a fetch asks for translation without a translator.
+var errTranslationNotConfigured = errors.New("translation is not configured")
+
+// translateResponse translates the response content to target, unless the page is already in
+// that language, and records the page's language as the original language.
+func (s *Server) translateResponse(ctx context.Context, resp *FetchResponse, target string) error {
+	if s.translator == nil {
+		return errTranslationNotConfigured
+	}
+
+	source := resp.Metadata.Language
+	if baseLanguage(source) == baseLanguage(target) || strings.TrimSpace(resp.Content) == "" {
+		return nil
+	}
+
+	translated, err := translate.Markdown(ctx, s.translator, resp.Content, baseLanguage(source), target)
+	if err != nil {
+		return fmt.Errorf("failed to translate content: %w", err)
+	}
+
+	resp.Content = translated
+	resp.Metadata.OriginalLanguage = source
+	resp.Metadata.Language = target
+	return nil
+}
+
+// baseLanguage returns the lowercase primary subtag of a language tag, such as "pt" for "pt-BR".
+func baseLanguage(tag string) string {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	return base
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joeychilson/websurfer/client"
+)
+
+// fakeTranslator translates by prefixing each text with the target language.
+type fakeTranslator struct {
+	sources []string
+}
+
+// Translate implements translate.Translator.
+func (f *fakeTranslator) Translate(ctx context.Context, texts []string, source, target string) ([]string, error) {
+	f.sources = append(f.sources, source)
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		translated[i] = "[" + target + "] " + text
+	}
+	return translated, nil
+}
+
+// TestFetchTranslate verifies content is translated and the page's detected language is recorded.
+func TestFetchTranslate(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><p>Die Katze sitzt auf dem Tisch und es ist nicht das erste Mal, dass sie sich dort ausruht.</p></body></html>`))
+	}))
+	defer origin.Close()
+
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	translator := &fakeTranslator{}
+	s, err := New(c, nil, &ServerConfig{Translator: translator})
+	require.NoError(t, err)
+
+	resp, err := s.processFetch(context.Background(), &FetchRequest{URL: origin.URL})
+	require.NoError(t, err)
+	assert.Equal(t, "de", resp.Metadata.Language, "language is detected from the text")
+	assert.Empty(t, resp.Metadata.OriginalLanguage)
+
+	resp, err = s.processFetch(context.Background(), &FetchRequest{URL: origin.URL, TranslateTo: "en"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(resp.Content, "[en] Die Katze"), resp.Content)
+	assert.Equal(t, "en", resp.Metadata.Language)
+	assert.Equal(t, "de", resp.Metadata.OriginalLanguage)
+	assert.Equal(t, []string{"de"}, translator.sources)
+
+	resp, err = s.processFetch(context.Background(), &FetchRequest{URL: origin.URL, TranslateTo: "de-AT"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(resp.Content, "Die Katze"), "content already in the target language is not translated")
+	assert.Empty(t, resp.Metadata.OriginalLanguage)
+
+	assert.Error(t, s.validateRequest(&FetchRequest{URL: "https://example.com", TranslateTo: "not a language"}))
+}
+
+// TestFetchTranslateNotConfigured verifies translation requests fail without a translator.
+func TestFetchTranslateNotConfigured(t *testing.T) {
+	c, err := client.New(nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest("POST", "/v1/fetch", strings.NewReader(`{"url":"https://example.com","translate_to":"en"}`)))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), ErrorCodeNotConfigured)
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/joeychilson/websurfer/policy"
+	urlpkg "github.com/joeychilson/websurfer/url"
+)
+
+const (
+	// maxValidateURLs is the most URLs a single validate request may check.
+	maxValidateURLs = 500
+	// validateWorkers is how many URLs are checked at once.
+	validateWorkers = 16
+)
+
+const (
+	// VerdictValid means the URL may be fetched.
+	VerdictValid = "valid"
+	// VerdictInvalid means the URL is malformed or not http or https.
+	VerdictInvalid = "invalid"
+	// VerdictSSRFBlocked means the URL points to a private, loopback, or link-local address.
+	VerdictSSRFBlocked = "ssrf_blocked"
+	// VerdictBlockedDomain means the server's domain policy blocks the URL.
+	VerdictBlockedDomain = "blocked_domain"
+	// VerdictRobotsDisallowed means the site's robots.txt disallows fetching the URL.
+	VerdictRobotsDisallowed = "robots_disallowed"
+)
+
+// ValidateRequest lists candidate URLs to check before fetching.
+type ValidateRequest struct {
+	URLs []string `json:"urls"`
+}
+
+// ValidateResponse holds a verdict for each requested URL, in request order.
+type ValidateResponse struct {
+	Results []URLVerdict `json:"results"`
+	// Valid is how many of the URLs may be fetched.
+	Valid int `json:"valid"`
+}
+
+// URLVerdict is the verdict for one URL. Reason explains verdicts other than valid.
+type URLVerdict struct {
+	URL     string `json:"url"`
+	Verdict string `json:"verdict"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// handleValidate handles POST /v1/validate requests.
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	var req ValidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("failed to decode request", "error", err)
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.URLs) == 0 || len(req.URLs) > maxValidateURLs {
+		s.sendError(w, fmt.Sprintf("urls must contain between 1 and %d URLs", maxValidateURLs), http.StatusBadRequest)
+		return
+	}
+
+	s.sendJSON(w, s.processValidate(r.Context(), req.URLs), http.StatusOK)
+}
+
+// processValidate checks each URL without fetching it. Only robots.txt is fetched, once per host.
+func (s *Server) processValidate(ctx context.Context, urls []string) *ValidateResponse {
+	resp := &ValidateResponse{Results: make([]URLVerdict, len(urls))}
+
+	sem := make(chan struct{}, validateWorkers)
+	var wg sync.WaitGroup
+	for i, pageURL := range urls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resp.Results[i] = s.validateURL(ctx, pageURL)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range resp.Results {
+		if result.Verdict == VerdictValid {
+			resp.Valid++
+		}
+	}
+	return resp
+}
+
+// validateURL returns the verdict for a single URL.
+func (s *Server) validateURL(ctx context.Context, pageURL string) URLVerdict {
+	verdict := URLVerdict{URL: pageURL, Verdict: VerdictValid}
+
+	if _, err := urlpkg.ValidateExternal(pageURL); err != nil {
+		verdict.Verdict = VerdictInvalid
+		if errors.Is(err, urlpkg.ErrBlockedAddress) {
+			verdict.Verdict = VerdictSSRFBlocked
+		}
+		verdict.Reason = err.Error()
+		return verdict
+	}
+
+	if err := s.policy.Check(pageURL); err != nil {
+		verdict.Verdict = VerdictBlockedDomain
+		if !errors.Is(err, policy.ErrViolation) {
+			verdict.Verdict = VerdictInvalid
+		}
+		verdict.Reason = err.Error()
+		return verdict
+	}
+
+	allowed, err := s.client.RobotsAllowed(ctx, pageURL)
+	if err != nil {
+		// The fetch itself decides once robots.txt can be read.
+		s.logger.Debug("robots.txt check failed during validation", "url", pageURL, "error", err)
+		return verdict
+	}
+	if !allowed {
+		verdict.Verdict = VerdictRobotsDisallowed
+		verdict.Reason = "disallowed by robots.txt"
+	}
+	return verdict
+}
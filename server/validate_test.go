@@ -0,0 +1,66 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joeychilson/websurfer/client"
+	"github.com/joeychilson/websurfer/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHandleValidate verifies each URL gets a verdict, in request order, without being fetched.
+func TestHandleValidate(t *testing.T) {
+	cfg := config.New()
+	cfg.Policy = config.PolicyConfig{BlockedTLDs: []string{"zip"}}
+
+	c, err := client.New(cfg)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := New(c, nil, nil)
+	require.NoError(t, err)
+
+	urls := []string{
+		"https://websurfer-test.invalid/page",
+		"ftp://example.com/file",
+		"http://169.254.169.254/latest/meta-data",
+		"https://files.zip/download",
+		"http://10.0.0.1/admin",
+	}
+	body, _ := json.Marshal(ValidateRequest{URLs: urls})
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest("POST", "/v1/validate", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp ValidateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Results, len(urls))
+
+	want := []string{VerdictValid, VerdictInvalid, VerdictSSRFBlocked, VerdictBlockedDomain, VerdictSSRFBlocked}
+	for i, result := range resp.Results {
+		assert.Equal(t, urls[i], result.URL)
+		assert.Equal(t, want[i], result.Verdict, urls[i])
+		if result.Verdict != VerdictValid {
+			assert.NotEmpty(t, result.Reason, urls[i])
+		}
+	}
+	assert.Equal(t, 1, resp.Valid)
+}
+
+// TestHandleValidateLimits verifies requests must list between 1 and maxValidateURLs URLs.
+func TestHandleValidateLimits(t *testing.T) {
+	s := newMapTestServer(t)
+
+	for _, count := range []int{0, maxValidateURLs + 1} {
+		body, _ := json.Marshal(ValidateRequest{URLs: strings.Split(strings.Repeat("https://example.com,", count), ",")[:count]})
+		w := httptest.NewRecorder()
+		s.Router().ServeHTTP(w, httptest.NewRequest("POST", "/v1/validate", bytes.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, count)
+	}
+}
@@ -0,0 +1,261 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/joeychilson/websurfer/client"
+	urlpkg "github.com/joeychilson/websurfer/url"
+	"github.com/joeychilson/websurfer/watcher"
+)
+
+const (
+	// defaultWatchInterval is how often a watched page is refetched when no interval is given.
+	defaultWatchInterval = time.Hour
+	// minWatchInterval is the shortest interval a page may be watched at.
+	minWatchInterval = time.Minute
+)
+
+// WatchRequest registers a URL to be refetched on a schedule.
+type WatchRequest struct {
+	URL string `json:"url"`
+	// Interval is how often the page is refetched, as a Go duration such as "30m" (default:
+	// "1h", min: "1m").
+	Interval string `json:"interval,omitempty"`
+	// Threshold is the fraction of lines, from 0 to 1, that must change between versions for
+	// CallbackURL to be notified. 0 notifies on any change.
+	Threshold   float64 `json:"threshold,omitempty"`
+	CallbackURL string  `json:"callback_url,omitempty"`
+}
+
+// WatchResponse describes a watch and the stored versions of its page, newest first.
+type WatchResponse struct {
+	ID          string  `json:"id"`
+	URL         string  `json:"url"`
+	Interval    string  `json:"interval"`
+	Threshold   float64 `json:"threshold"`
+	CallbackURL string  `json:"callback_url,omitempty"`
+	// Caller is who created the watch: a configured caller's name, an API key's hash prefix
+	// ("key:..."), or a client IP ("ip:..."). Its checks are rate limited as made by them.
+	Caller      string `json:"caller,omitempty"`
+	CreatedAt   string `json:"created_at"`
+	CheckedAt   string `json:"checked_at,omitempty"`
+	NextCheckAt string `json:"next_check_at"`
+	LastError   string `json:"last_error,omitempty"`
+	// RefetchSuppressed is set when the last check reused a fetch made within the site's
+	// min_refetch_interval instead of fetching the page.
+	RefetchSuppressed bool           `json:"refetch_suppressed,omitempty"`
+	Versions          []WatchVersion `json:"versions,omitempty"`
+}
+
+// WatchVersion describes one version of a watched page.
+type WatchVersion struct {
+	FetchedAt  string `json:"fetched_at"`
+	StatusCode int    `json:"status_code"`
+	Hash       string `json:"hash"`
+	// Change is the fraction of lines that changed from the previous version.
+	Change float64 `json:"change"`
+}
+
+// WatchWebhookPayload is POSTed to a watch's callback_url when its page changes by at least
+// the watch's threshold.
+type WatchWebhookPayload struct {
+	WatchID  string       `json:"watch_id"`
+	URL      string       `json:"url"`
+	Change   float64      `json:"change"`
+	Previous WatchVersion `json:"previous"`
+	Current  WatchVersion `json:"current"`
+	// Diff is a unified diff of the previous content against the current content.
+	Diff string `json:"diff"`
+}
+
+// handleCreateWatch handles POST /v1/watch requests.
+func (s *Server) handleCreateWatch(w http.ResponseWriter, r *http.Request) {
+	if s.watcher == nil {
+		s.sendError(w, "watcher is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req WatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("failed to decode request", "error", err)
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	interval, err := s.validateWatchRequest(&req)
+	if err != nil {
+		s.logger.Error("invalid request", "error", err)
+		s.sendValidationError(w, err)
+		return
+	}
+
+	watch, err := s.watcher.Add(r.Context(), req.URL, interval, req.Threshold, req.CallbackURL)
+	if err != nil {
+		s.sendWatchError(w, err)
+		return
+	}
+
+	s.logger.Info("watch created", "watch_id", watch.ID, "url", watch.URL, "interval", interval, "caller", watch.Caller.ID)
+	s.sendJSON(w, buildWatchResponse(watch, nil), http.StatusCreated)
+}
+
+// handleGetWatch handles GET /v1/watch/{id} requests.
+func (s *Server) handleGetWatch(w http.ResponseWriter, r *http.Request) {
+	if s.watcher == nil {
+		s.sendError(w, "watcher is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	watch, err := s.watcher.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		s.sendWatchError(w, err)
+		return
+	}
+
+	versions, err := s.watcher.Versions(r.Context(), watch.ID)
+	if err != nil {
+		s.sendWatchError(w, err)
+		return
+	}
+
+	s.sendJSON(w, buildWatchResponse(watch, versions), http.StatusOK)
+}
+
+// handleDeleteWatch handles DELETE /v1/watch/{id} requests.
+func (s *Server) handleDeleteWatch(w http.ResponseWriter, r *http.Request) {
+	if s.watcher == nil {
+		s.sendError(w, "watcher is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if err := s.watcher.Delete(r.Context(), id); err != nil {
+		s.sendWatchError(w, err)
+		return
+	}
+
+	s.logger.Info("watch deleted", "watch_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateWatchRequest validates a watch request and returns its interval.
+func (s *Server) validateWatchRequest(req *WatchRequest) (time.Duration, error) {
+	if _, err := urlpkg.ValidateExternal(req.URL); err != nil {
+		return 0, err
+	}
+
+	if err := s.policy.Check(req.URL); err != nil {
+		return 0, err
+	}
+
+	if req.CallbackURL != "" {
+		if _, err := urlpkg.ValidateExternal(req.CallbackURL); err != nil {
+			return 0, fmt.Errorf("invalid callback_url: %w", err)
+		}
+	}
+
+	if req.Threshold < 0 || req.Threshold > 1 {
+		return 0, fmt.Errorf("threshold must be between 0 and 1")
+	}
+
+	if req.Interval == "" {
+		return defaultWatchInterval, nil
+	}
+	interval, err := time.ParseDuration(req.Interval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval: %w", err)
+	}
+	if interval < minWatchInterval {
+		return 0, fmt.Errorf("interval must be at least %s", minWatchInterval)
+	}
+	return interval, nil
+}
+
+// sendWatchError maps a watcher error to an error response.
+func (s *Server) sendWatchError(w http.ResponseWriter, err error) {
+	if errors.Is(err, watcher.ErrNotFound) {
+		s.sendError(w, "watch not found", http.StatusNotFound)
+		return
+	}
+	s.logger.Error("watcher error", "error", err)
+	s.sendError(w, "watcher error", http.StatusInternalServerError)
+}
+
+// fetchWatched fetches the current content of a watched page from origin.
+func (s *Server) fetchWatched(ctx context.Context, pageURL string) (*watcher.Snapshot, error) {
+	if err := s.policy.Check(pageURL); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.FetchWithOptions(ctx, pageURL, &client.FetchOptions{BypassCache: true})
+	if err != nil {
+		return nil, err
+	}
+	return &watcher.Snapshot{StatusCode: resp.StatusCode, Body: resp.Body, RefetchSuppressed: resp.RefetchSuppressed}, nil
+}
+
+// notifyWatch POSTs a change to the watch's callback URL, if it has one.
+func (s *Server) notifyWatch(ctx context.Context, event *watcher.Event) {
+	if event.Watch.CallbackURL == "" {
+		return
+	}
+
+	payload := WatchWebhookPayload{
+		WatchID:  event.Watch.ID,
+		URL:      event.Watch.URL,
+		Change:   event.Current.Change,
+		Previous: buildWatchVersion(event.Previous),
+		Current:  buildWatchVersion(event.Current),
+		Diff:     event.Diff,
+	}
+
+	sendCtx, sendCancel := context.WithTimeout(context.WithoutCancel(ctx), webhookSendTimeout)
+	defer sendCancel()
+
+	if err := s.webhook.Send(sendCtx, event.Watch.CallbackURL, payload); err != nil {
+		s.logger.Error("watch webhook delivery failed", "watch_id", event.Watch.ID, "callback_url", event.Watch.CallbackURL, "error", err)
+		return
+	}
+
+	s.logger.Info("watch webhook delivered", "watch_id", event.Watch.ID, "url", event.Watch.URL, "change", event.Current.Change)
+}
+
+// buildWatchResponse converts a watch and its versions to their API representation.
+func buildWatchResponse(watch *watcher.Watch, versions []watcher.Version) WatchResponse {
+	resp := WatchResponse{
+		ID:                watch.ID,
+		URL:               watch.URL,
+		Interval:          watch.Interval.String(),
+		Threshold:         watch.Threshold,
+		CallbackURL:       watch.CallbackURL,
+		Caller:            watch.Caller.ID,
+		CreatedAt:         watch.CreatedAt.UTC().Format(time.RFC3339),
+		NextCheckAt:       watch.NextCheckAt.UTC().Format(time.RFC3339),
+		LastError:         watch.LastError,
+		RefetchSuppressed: watch.RefetchSuppressed,
+	}
+	if !watch.CheckedAt.IsZero() {
+		resp.CheckedAt = watch.CheckedAt.UTC().Format(time.RFC3339)
+	}
+	for i := range versions {
+		resp.Versions = append(resp.Versions, buildWatchVersion(&versions[i]))
+	}
+	return resp
+}
+
+// buildWatchVersion converts a page version to its API representation.
+func buildWatchVersion(version *watcher.Version) WatchVersion {
+	return WatchVersion{
+		FetchedAt:  version.FetchedAt.UTC().Format(time.RFC3339),
+		StatusCode: version.StatusCode,
+		Hash:       version.Hash,
+		Change:     version.Change,
+	}
+}
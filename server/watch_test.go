@@ -0,0 +1,140 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/joeychilson/websurfer/watcher"
+	"github.com/joeychilson/websurfer/webhook"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWatchEndpoints verifies watches can be created, inspected, and deleted.
+func TestWatchEndpoints(t *testing.T) {
+	router := newJobTestServer(t).Router()
+
+	invalid := []WatchRequest{
+		{URL: "https://example.com", Interval: "10s"},
+		{URL: "https://example.com", Interval: "hourly"},
+		{URL: "https://example.com", Threshold: 1.5},
+		{URL: "https://example.com", CallbackURL: "http://127.0.0.1/hook"},
+		{URL: "http://10.0.0.1/admin"},
+	}
+	for _, req := range invalid {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/v1/watch", bytes.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, "%+v", req)
+	}
+
+	body, _ := json.Marshal(WatchRequest{URL: "https://websurfer-test.invalid", Interval: "30m", Threshold: 0.1})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/v1/watch", bytes.NewReader(body)))
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created WatchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.NotEmpty(t, created.ID)
+	assert.Equal(t, "30m0s", created.Interval)
+	assert.Equal(t, 0.1, created.Threshold)
+	assert.Equal(t, "ip:192.0.2.1", created.Caller, "the watch should record who created it")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/v1/watch/"+created.ID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/v1/watch/"+created.ID, nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	for _, method := range []string{"GET", "DELETE"} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, "/v1/watch/"+created.ID, nil))
+		assert.Equal(t, http.StatusNotFound, w.Code, method)
+	}
+}
+
+// TestWatchRequiresRedis verifies /v1/watch is unavailable without the job queue's Redis.
+func TestWatchRequiresRedis(t *testing.T) {
+	router := newMapTestServer(t).Router()
+
+	body, _ := json.Marshal(WatchRequest{URL: "https://example.com"})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/v1/watch", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+// TestWatchNotifiesChanges verifies checks of a watched page deliver a webhook with the diff.
+func TestWatchNotifiesChanges(t *testing.T) {
+	var version atomic.Int32
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		if version.Load() == 0 {
+			w.Write([]byte("Status: operational\n"))
+			return
+		}
+		w.Write([]byte("Status: degraded\n"))
+	}))
+	defer origin.Close()
+
+	deliveries := make(chan WatchWebhookPayload, 1)
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload WatchWebhookPayload
+		assert.NoError(t, json.Unmarshal(body, &payload))
+		deliveries <- payload
+	}))
+	defer callback.Close()
+
+	// The test origins are on loopback, which request validation rejects, so add the watch
+	// directly and check it without the scheduler.
+	s := newMapTestServer(t)
+	mr := miniredis.RunT(t)
+	s.watcher = watcher.New(redis.NewClient(&redis.Options{Addr: mr.Addr()}), watcher.Config{})
+	s.webhook = webhook.New("", webhook.WithHTTPClient(callback.Client()))
+
+	ctx := context.Background()
+	watch, err := s.watcher.Add(ctx, origin.URL, time.Hour, 0, callback.URL)
+	require.NoError(t, err)
+
+	event, err := s.watcher.Check(ctx, watch, s.fetchWatched)
+	require.NoError(t, err)
+	assert.Nil(t, event)
+
+	version.Store(1)
+	event, err = s.watcher.Check(ctx, watch, s.fetchWatched)
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	s.notifyWatch(ctx, event)
+
+	select {
+	case payload := <-deliveries:
+		assert.Equal(t, watch.ID, payload.WatchID)
+		assert.Equal(t, 1.0, payload.Change)
+		assert.Contains(t, payload.Diff, "-Status: operational")
+		assert.Contains(t, payload.Diff, "+Status: degraded")
+		assert.NotEqual(t, payload.Previous.Hash, payload.Current.Hash)
+	default:
+		t.Fatal("expected a webhook delivery")
+	}
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest("GET", "/v1/watch/"+watch.ID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp WatchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Versions, 2)
+	assert.Equal(t, 1.0, resp.Versions[0].Change)
+	assert.NotEmpty(t, resp.CheckedAt)
+}
@@ -0,0 +1,252 @@
+package session
+
+import (
+	"cmp"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cookie is a cookie set by a site during a session.
+type Cookie struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Domain string `json:"domain"`
+	Path   string `json:"path"`
+	// HostOnly cookies are sent only to Domain itself, not its subdomains.
+	HostOnly bool      `json:"host_only,omitempty"`
+	Secure   bool      `json:"secure,omitempty"`
+	Expires  time.Time `json:"expires,omitzero"`
+
+	// deleted marks a Set-Cookie that removes the cookie.
+	deleted bool
+}
+
+// key identifies the cookie within a session. A later cookie with the same key replaces it.
+func (c *Cookie) key() string {
+	return c.Domain + ";" + c.Path + ";" + c.Name
+}
+
+// expired reports whether the cookie has expired at now. Session cookies never expire.
+func (c *Cookie) expired(now time.Time) bool {
+	return !c.Expires.IsZero() && !c.Expires.After(now)
+}
+
+// matches reports whether the cookie is sent with a request to u.
+func (c *Cookie) matches(u *url.URL) bool {
+	if c.Secure && u.Scheme != "https" {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if c.HostOnly {
+		if host != c.Domain {
+			return false
+		}
+	} else if !domainMatch(host, c.Domain) {
+		return false
+	}
+
+	return pathMatch(requestPath(u), c.Path)
+}
+
+// CookiesFor returns the session's cookies sent with a request to pageURL, by name.
+func (s *Session) CookiesFor(pageURL string) map[string]string {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+
+	matched := matching(s.Cookies, u)
+	if len(matched) == 0 {
+		return nil
+	}
+
+	cookies := make(map[string]string, len(matched))
+	for _, cookie := range matched {
+		cookies[cookie.Name] = cookie.Value
+	}
+	return cookies
+}
+
+// Jar is a cookie jar for one fetch in a session. It sends the session's cookies with every
+// request of the fetch, including redirects, and keeps the cookies each response sets, so a
+// cookie set by a redirect is sent to its target. Store.Record saves the cookies it received.
+type Jar struct {
+	mu      sync.Mutex
+	cookies map[string]Cookie
+	// changes lists the cookies set or deleted by the fetch's responses, in order.
+	changes []Cookie
+	// overridden names the cookies the request sets itself, which the session's don't replace.
+	overridden map[string]bool
+	now        func() time.Time
+}
+
+// Jar returns a cookie jar for a fetch in the session. Cookies named in overridden are not
+// sent, since the request sets them itself.
+func (s *Session) Jar(overridden ...string) *Jar {
+	jar := &Jar{
+		cookies:    make(map[string]Cookie, len(s.Cookies)),
+		overridden: make(map[string]bool, len(overridden)),
+		now:        time.Now,
+	}
+	for _, cookie := range s.Cookies {
+		jar.cookies[cookie.key()] = cookie
+	}
+	for _, name := range overridden {
+		jar.overridden[name] = true
+	}
+	return jar
+}
+
+// SetCookies implements http.CookieJar, keeping the cookies of a response from u.
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, cookie := range newCookies(u, cookies, j.now()) {
+		if cookie.deleted {
+			delete(j.cookies, cookie.key())
+		} else {
+			j.cookies[cookie.key()] = cookie
+		}
+		j.changes = append(j.changes, cookie)
+	}
+}
+
+// Cookies implements http.CookieJar, returning the cookies to send with a request to u.
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := j.now()
+	held := make([]Cookie, 0, len(j.cookies))
+	for _, cookie := range j.cookies {
+		if !cookie.expired(now) && !j.overridden[cookie.Name] {
+			held = append(held, cookie)
+		}
+	}
+
+	matched := matching(held, u)
+	cookies := make([]*http.Cookie, 0, len(matched))
+	for _, cookie := range matched {
+		cookies = append(cookies, &http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+	return cookies
+}
+
+// received returns the cookies set or deleted by the fetch's responses, in order.
+func (j *Jar) received() []Cookie {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return slices.Clone(j.changes)
+}
+
+// matching returns the cookies sent with a request to u. When several cookies share a name,
+// only the one with the most specific path is sent.
+func matching(cookies []Cookie, u *url.URL) []Cookie {
+	matched := make([]Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		if cookie.matches(u) {
+			matched = append(matched, cookie)
+		}
+	}
+
+	slices.SortStableFunc(matched, func(a, b Cookie) int {
+		if c := cmp.Compare(len(b.Path), len(a.Path)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	seen := make(map[string]bool, len(matched))
+	return slices.DeleteFunc(matched, func(cookie Cookie) bool {
+		if seen[cookie.Name] {
+			return true
+		}
+		seen[cookie.Name] = true
+		return false
+	})
+}
+
+// newCookies converts the cookies of a response from u, following RFC 6265. Cookies for a
+// domain u cannot set are ignored.
+func newCookies(u *url.URL, received []*http.Cookie, now time.Time) []Cookie {
+	host := strings.ToLower(u.Hostname())
+
+	cookies := make([]Cookie, 0, len(received))
+	for _, parsed := range received {
+		cookie := Cookie{
+			Name:   parsed.Name,
+			Value:  parsed.Value,
+			Domain: host,
+			Path:   parsed.Path,
+			Secure: parsed.Secure,
+		}
+
+		if domain := strings.ToLower(strings.TrimPrefix(parsed.Domain, ".")); domain != "" {
+			if net.ParseIP(host) != nil || !domainMatch(host, domain) {
+				continue
+			}
+			cookie.Domain = domain
+		} else {
+			cookie.HostOnly = true
+		}
+
+		if !strings.HasPrefix(cookie.Path, "/") {
+			cookie.Path = defaultPath(u)
+		}
+
+		switch {
+		case parsed.MaxAge < 0:
+			cookie.deleted = true
+		case parsed.MaxAge > 0:
+			cookie.Expires = now.Add(time.Duration(parsed.MaxAge) * time.Second)
+		case !parsed.Expires.IsZero():
+			cookie.Expires = parsed.Expires
+			cookie.deleted = cookie.expired(now)
+		}
+
+		cookies = append(cookies, cookie)
+	}
+	return cookies
+}
+
+// domainMatch reports whether host is domain or one of its subdomains.
+func domainMatch(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// pathMatch reports whether a request to path sends a cookie scoped to cookiePath.
+func pathMatch(path, cookiePath string) bool {
+	if !strings.HasPrefix(path, cookiePath) {
+		return false
+	}
+	return len(path) == len(cookiePath) || strings.HasSuffix(cookiePath, "/") || path[len(cookiePath)] == '/'
+}
+
+// requestPath returns the path of u, "/" when empty.
+func requestPath(u *url.URL) string {
+	if u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
+
+// defaultPath returns the path of a cookie set by a response from u without a Path attribute:
+// the directory of u's path.
+func defaultPath(u *url.URL) string {
+	path := requestPath(u)
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return path[:i]
+}
@@ -0,0 +1,270 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrNotFound is returned when a session does not exist or has expired.
+	ErrNotFound = errors.New("session not found")
+	// ErrExhausted is returned when a session has used all of its fetches.
+	ErrExhausted = errors.New("session fetch budget exhausted")
+)
+
+var (
+	// beginScript reserves a fetch from a session's budget and extends the session's idle
+	// timeout. It returns the new fetch count, -1 if the session does not exist, or -2 if its
+	// budget is used up.
+	beginScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  return -1
+end
+local max = tonumber(redis.call('HGET', KEYS[1], 'max_fetches'))
+local fetches = tonumber(redis.call('HGET', KEYS[1], 'fetches'))
+if fetches >= max then
+  return -2
+end
+local ttl = redis.call('HGET', KEYS[1], 'idle_timeout')
+redis.call('PEXPIRE', KEYS[1], ttl)
+redis.call('PEXPIRE', KEYS[2], ttl)
+return redis.call('HINCRBY', KEYS[1], 'fetches', 1)
+`)
+
+	// recordScript stores a fetch's referer and cookies unless the session was deleted meanwhile.
+	// ARGV[1] is the referer, ARGV[2] the idle timeout, then cookie keys and values follow in
+	// pairs, where an empty value deletes the cookie.
+	recordScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  return 0
+end
+redis.call('HSET', KEYS[1], 'referer', ARGV[1])
+for i = 3, #ARGV, 2 do
+  if ARGV[i + 1] == '' then
+    redis.call('HDEL', KEYS[2], ARGV[i])
+  else
+    redis.call('HSET', KEYS[2], ARGV[i], ARGV[i + 1])
+  end
+end
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+redis.call('PEXPIRE', KEYS[2], ARGV[2])
+return 1
+`)
+)
+
+// Session is browsing state shared by the fetches made in it.
+type Session struct {
+	ID string
+	// MaxFetches is how many fetches the session may make in total.
+	MaxFetches int
+	Fetches    int
+	// IdleTimeout is how long the session lives after its last fetch.
+	IdleTimeout time.Duration
+	// Referer is the URL of the session's last fetched page, sent as the next fetch's Referer.
+	Referer   string
+	Cookies   []Cookie
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Config holds session store configuration.
+type Config struct {
+	Prefix string
+}
+
+// DefaultConfig returns a session store config with sensible defaults.
+func DefaultConfig() Config {
+	return Config{
+		Prefix: "websurfer:session:",
+	}
+}
+
+// applyDefaults fills in zero values with defaults.
+func applyDefaults(config Config) Config {
+	defaults := DefaultConfig()
+	if config.Prefix == "" {
+		config.Prefix = defaults.Prefix
+	}
+	return config
+}
+
+// Store keeps sessions in Redis, so fetches in a session may be served by any instance.
+type Store struct {
+	client *redis.Client
+	config Config
+	now    func() time.Time
+}
+
+// New creates a new session store with the provided client and configuration.
+func New(client *redis.Client, config Config) *Store {
+	return &Store{
+		client: client,
+		config: applyDefaults(config),
+		now:    time.Now,
+	}
+}
+
+// makeSessionKey creates the Redis key holding a session's fields.
+func (s *Store) makeSessionKey(id string) string {
+	return s.config.Prefix + id
+}
+
+// makeCookiesKey creates the Redis key holding a session's cookies, by Cookie.key.
+func (s *Store) makeCookiesKey(id string) string {
+	return s.config.Prefix + id + ":cookies"
+}
+
+// Create starts a new session that may make up to maxFetches fetches and expires after being
+// idle for idleTimeout.
+func (s *Store) Create(ctx context.Context, maxFetches int, idleTimeout time.Duration) (*Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session id: %w", err)
+	}
+
+	now := s.now()
+	key := s.makeSessionKey(id)
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key,
+			"max_fetches", maxFetches,
+			"fetches", 0,
+			"idle_timeout", idleTimeout.Milliseconds(),
+			"referer", "",
+			"created_at", now.UnixMilli(),
+		)
+		pipe.PExpire(ctx, key, idleTimeout)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("redis create failed: %w", err)
+	}
+
+	return &Session{
+		ID:          id,
+		MaxFetches:  maxFetches,
+		IdleTimeout: idleTimeout,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(idleTimeout),
+	}, nil
+}
+
+// Get returns the session with the given ID, including its cookies.
+func (s *Store) Get(ctx context.Context, id string) (*Session, error) {
+	key := s.makeSessionKey(id)
+
+	pipe := s.client.Pipeline()
+	fieldsCmd := pipe.HGetAll(ctx, key)
+	ttlCmd := pipe.PTTL(ctx, key)
+	cookiesCmd := pipe.HVals(ctx, s.makeCookiesKey(id))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("redis get failed: %w", err)
+	}
+
+	fields := fieldsCmd.Val()
+	if len(fields) == 0 {
+		return nil, ErrNotFound
+	}
+
+	now := s.now()
+	sess := &Session{
+		ID:          id,
+		MaxFetches:  atoi(fields["max_fetches"]),
+		Fetches:     atoi(fields["fetches"]),
+		IdleTimeout: time.Duration(atoi(fields["idle_timeout"])) * time.Millisecond,
+		Referer:     fields["referer"],
+		CreatedAt:   time.UnixMilli(int64(atoi(fields["created_at"]))),
+		ExpiresAt:   now.Add(ttlCmd.Val()),
+	}
+
+	for _, value := range cookiesCmd.Val() {
+		var cookie Cookie
+		if err := json.Unmarshal([]byte(value), &cookie); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal cookie: %w", err)
+		}
+		if cookie.expired(now) {
+			continue
+		}
+		sess.Cookies = append(sess.Cookies, cookie)
+	}
+
+	return sess, nil
+}
+
+// Delete removes the session and its cookies.
+func (s *Store) Delete(ctx context.Context, id string) error {
+	deleted, err := s.client.Del(ctx, s.makeSessionKey(id), s.makeCookiesKey(id)).Result()
+	if err != nil {
+		return fmt.Errorf("redis del failed: %w", err)
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Begin reserves one fetch from the session's budget and returns the session's state for the
+// fetch. Concurrent fetches in one session each reserve their own fetch.
+func (s *Store) Begin(ctx context.Context, id string) (*Session, error) {
+	fetches, err := beginScript.Run(ctx, s.client, []string{s.makeSessionKey(id), s.makeCookiesKey(id)}).Int()
+	if err != nil {
+		return nil, fmt.Errorf("redis begin failed: %w", err)
+	}
+	switch fetches {
+	case -1:
+		return nil, ErrNotFound
+	case -2:
+		return nil, ErrExhausted
+	}
+
+	return s.Get(ctx, id)
+}
+
+// Record stores the outcome of a fetch of pageURL in the session: pageURL becomes the next
+// fetch's Referer, and the cookies set by the responses the fetch received through jar,
+// including redirects, update the session's cookies. jar may be nil.
+func (s *Store) Record(ctx context.Context, sess *Session, pageURL string, jar *Jar) error {
+	args := []any{pageURL, sess.IdleTimeout.Milliseconds()}
+	for _, cookie := range jar.received() {
+		if cookie.deleted {
+			args = append(args, cookie.key(), "")
+			continue
+		}
+		data, err := json.Marshal(cookie)
+		if err != nil {
+			return fmt.Errorf("failed to marshal cookie: %w", err)
+		}
+		args = append(args, cookie.key(), data)
+	}
+
+	recorded, err := recordScript.Run(ctx, s.client, []string{s.makeSessionKey(sess.ID), s.makeCookiesKey(sess.ID)}, args...).Int()
+	if err != nil {
+		return fmt.Errorf("redis record failed: %w", err)
+	}
+	if recorded == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// atoi parses a Redis integer field, treating a missing field as 0.
+func atoi(value string) int {
+	n, _ := strconv.Atoi(value)
+	return n
+}
+
+// newID returns a random session ID.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
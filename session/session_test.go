@@ -0,0 +1,156 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	})
+
+	return New(client, Config{}), mr
+}
+
+// receive returns a jar for sess that received the Set-Cookie headers of a response from pageURL.
+func receive(t *testing.T, sess *Session, pageURL string, setCookies ...string) *Jar {
+	t.Helper()
+
+	u, err := url.Parse(pageURL)
+	require.NoError(t, err)
+
+	jar := sess.Jar()
+	jar.SetCookies(u, (&http.Response{Header: http.Header{"Set-Cookie": setCookies}}).Cookies())
+	return jar
+}
+
+// TestStoreBeginEnforcesBudget verifies each fetch reserves one of the session's fetches.
+func TestStoreBeginEnforcesBudget(t *testing.T) {
+	store, _ := setupTestStore(t)
+	ctx := context.Background()
+
+	sess, err := store.Create(ctx, 2, time.Hour)
+	require.NoError(t, err)
+
+	for want := 1; want <= 2; want++ {
+		begun, err := store.Begin(ctx, sess.ID)
+		require.NoError(t, err)
+		assert.Equal(t, want, begun.Fetches)
+	}
+
+	_, err = store.Begin(ctx, sess.ID)
+	assert.ErrorIs(t, err, ErrExhausted)
+
+	_, err = store.Begin(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestStoreRecordCookiesAndReferer verifies fetches update the session's cookies and referer.
+func TestStoreRecordCookiesAndReferer(t *testing.T) {
+	store, _ := setupTestStore(t)
+	ctx := context.Background()
+
+	sess, err := store.Create(ctx, 10, time.Hour)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Record(ctx, sess, "https://shop.example.com/login", receive(t, sess, "https://shop.example.com/login",
+		"session=abc; Path=/; Secure; HttpOnly",
+		"region=eu; Domain=example.com; Path=/",
+		"cart=1; Path=/cart",
+		"tracker=x; Domain=other.com",
+	)))
+
+	got, err := store.Get(ctx, sess.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://shop.example.com/login", got.Referer)
+	assert.Len(t, got.Cookies, 3, "cookies for another domain should be ignored")
+
+	assert.Equal(t, map[string]string{"session": "abc", "region": "eu"}, got.CookiesFor("https://shop.example.com/products"))
+	assert.Equal(t, map[string]string{"session": "abc", "region": "eu", "cart": "1"}, got.CookiesFor("https://shop.example.com/cart/items"))
+	assert.Equal(t, map[string]string{"region": "eu"}, got.CookiesFor("https://www.example.com/"), "host-only cookies should not be sent to other hosts")
+	assert.Equal(t, map[string]string{"region": "eu"}, got.CookiesFor("http://shop.example.com/"), "secure cookies should not be sent over http")
+
+	require.NoError(t, store.Record(ctx, got, "https://shop.example.com/logout", receive(t, got, "https://shop.example.com/logout", "session=; Path=/; Max-Age=0")))
+	got, err = store.Get(ctx, sess.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"region": "eu"}, got.CookiesFor("https://shop.example.com/"))
+}
+
+// TestJarKeepsCookiesAcrossRedirects verifies a jar sends the cookies set earlier in a fetch,
+// so a cookie set by a redirect reaches its target and is recorded.
+func TestJarKeepsCookiesAcrossRedirects(t *testing.T) {
+	store, _ := setupTestStore(t)
+	ctx := context.Background()
+
+	sess, err := store.Create(ctx, 10, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, store.Record(ctx, sess, "https://example.com/", receive(t, sess, "https://example.com/", "theme=dark", "lang=en")))
+	sess, err = store.Get(ctx, sess.ID)
+	require.NoError(t, err)
+
+	jar := sess.Jar("lang")
+	login, _ := url.Parse("https://example.com/login")
+	account, _ := url.Parse("https://example.com/account")
+
+	jar.SetCookies(login, []*http.Cookie{{Name: "session", Value: "abc", Path: "/"}})
+	var sent []string
+	for _, cookie := range jar.Cookies(account) {
+		sent = append(sent, cookie.Name+"="+cookie.Value)
+	}
+	assert.ElementsMatch(t, []string{"session=abc", "theme=dark"}, sent, "overridden cookies should not be sent")
+
+	require.NoError(t, store.Record(ctx, sess, account.String(), jar))
+	got, err := store.Get(ctx, sess.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"session": "abc", "theme": "dark", "lang": "en"}, got.CookiesFor("https://example.com/"))
+}
+
+// TestStoreExpiresIdleSessions verifies sessions expire after their idle timeout.
+func TestStoreExpiresIdleSessions(t *testing.T) {
+	store, mr := setupTestStore(t)
+	ctx := context.Background()
+
+	sess, err := store.Create(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, store.Record(ctx, sess, "https://example.com/", receive(t, sess, "https://example.com/", "a=1")))
+
+	mr.FastForward(30 * time.Second)
+	_, err = store.Begin(ctx, sess.ID)
+	require.NoError(t, err)
+
+	mr.FastForward(45 * time.Second)
+	_, err = store.Get(ctx, sess.ID)
+	require.NoError(t, err, "a fetch should extend the idle timeout")
+
+	mr.FastForward(time.Minute)
+	_, err = store.Get(ctx, sess.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Empty(t, mr.Keys())
+}
+
+// TestStoreDelete verifies deleting a session removes it and its cookies.
+func TestStoreDelete(t *testing.T) {
+	store, mr := setupTestStore(t)
+	ctx := context.Background()
+
+	sess, err := store.Create(ctx, 10, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, store.Record(ctx, sess, "https://example.com/", receive(t, sess, "https://example.com/", "a=1")))
+
+	require.NoError(t, store.Delete(ctx, sess.ID))
+	assert.ErrorIs(t, store.Delete(ctx, sess.ID), ErrNotFound)
+	assert.ErrorIs(t, store.Record(ctx, sess, "https://example.com/", nil), ErrNotFound)
+	assert.Empty(t, mr.Keys())
+}
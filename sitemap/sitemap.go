@@ -0,0 +1,133 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// maxDecompressedSize caps how large a gzipped sitemap may expand. The sitemap protocol
+	// limits uncompressed sitemaps to 50MB.
+	maxDecompressedSize = 50 << 20
+	// MaxURLs is the most URLs the sitemap protocol allows in one sitemap.
+	MaxURLs = 50000
+	// Namespace is the XML namespace of sitemaps.
+	Namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+)
+
+// gzipMagic is the header that starts every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Sitemap is a parsed sitemap: either a list of page URLs or, for a sitemap index, a list of
+// child sitemaps.
+type Sitemap struct {
+	URLs     []URL
+	Sitemaps []string
+	// Children lists the child sitemaps of a sitemap index with their lastmod, in the same
+	// order as Sitemaps.
+	Children []URL
+}
+
+// URL is a page or child sitemap listed in a sitemap.
+type URL struct {
+	Loc     string
+	LastMod string
+}
+
+// xmlSitemap matches both <urlset> and <sitemapindex> documents.
+type xmlSitemap struct {
+	URLs []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"sitemap"`
+}
+
+// xmlURLSet is a <urlset> document as written by Marshal.
+type xmlURLSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	URLs    []xmlURL `xml:"url"`
+}
+
+// xmlURL is a page entry of a written <urlset>.
+type xmlURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// Parse parses an XML sitemap or sitemap index, gzipped or not.
+func Parse(data []byte) (*Sitemap, error) {
+	if bytes.HasPrefix(data, gzipMagic) {
+		decompressed, err := gunzip(data)
+		if err != nil {
+			return nil, err
+		}
+		data = decompressed
+	}
+
+	var doc xmlSitemap
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse sitemap: %w", err)
+	}
+
+	sm := &Sitemap{}
+	for _, u := range doc.URLs {
+		if loc := strings.TrimSpace(u.Loc); loc != "" {
+			sm.URLs = append(sm.URLs, URL{Loc: loc, LastMod: strings.TrimSpace(u.LastMod)})
+		}
+	}
+	for _, s := range doc.Sitemaps {
+		if loc := strings.TrimSpace(s.Loc); loc != "" {
+			sm.Sitemaps = append(sm.Sitemaps, loc)
+			sm.Children = append(sm.Children, URL{Loc: loc, LastMod: strings.TrimSpace(s.LastMod)})
+		}
+	}
+
+	return sm, nil
+}
+
+// Marshal writes urls as a sitemap <urlset> document, with an XML declaration. LastMod values
+// should be W3C datetimes. It fails if there are more than MaxURLs.
+func Marshal(urls []URL) ([]byte, error) {
+	if len(urls) > MaxURLs {
+		return nil, fmt.Errorf("sitemap has %d urls, more than the %d allowed", len(urls), MaxURLs)
+	}
+
+	doc := xmlURLSet{Xmlns: Namespace, URLs: make([]xmlURL, 0, len(urls))}
+	for _, u := range urls {
+		doc.URLs = append(doc.URLs, xmlURL{Loc: u.Loc, LastMod: u.LastMod})
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write sitemap: %w", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// gunzip decompresses a gzipped sitemap, refusing ones that expand past maxDecompressedSize.
+func gunzip(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress sitemap: %w", err)
+	}
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(io.LimitReader(reader, maxDecompressedSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress sitemap: %w", err)
+	}
+	if len(decompressed) > maxDecompressedSize {
+		return nil, fmt.Errorf("decompressed sitemap exceeds %d bytes", maxDecompressedSize)
+	}
+
+	return decompressed, nil
+}
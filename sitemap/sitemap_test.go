@@ -0,0 +1,86 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseURLSet verifies page URLs and last-modified dates are extracted.
+func TestParseURLSet(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc> https://example.com/ </loc><lastmod>2024-01-02</lastmod></url>
+  <url><loc>https://example.com/docs/intro</loc></url>
+  <url><loc></loc></url>
+</urlset>`)
+
+	sm, err := Parse(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, []URL{
+		{Loc: "https://example.com/", LastMod: "2024-01-02"},
+		{Loc: "https://example.com/docs/intro"},
+	}, sm.URLs)
+	assert.Empty(t, sm.Sitemaps)
+}
+
+// TestParseSitemapIndex verifies child sitemaps are extracted from a sitemap index.
+func TestParseSitemapIndex(t *testing.T) {
+	data := []byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/sitemap-docs.xml</loc><lastmod>2025-01-15</lastmod></sitemap>
+  <sitemap><loc>https://example.com/sitemap-blog.xml.gz</loc></sitemap>
+</sitemapindex>`)
+
+	sm, err := Parse(data)
+	require.NoError(t, err)
+
+	assert.Empty(t, sm.URLs)
+	assert.Equal(t, []string{"https://example.com/sitemap-docs.xml", "https://example.com/sitemap-blog.xml.gz"}, sm.Sitemaps)
+	assert.Equal(t, URL{Loc: "https://example.com/sitemap-docs.xml", LastMod: "2025-01-15"}, sm.Children[0])
+	assert.Empty(t, sm.Children[1].LastMod)
+}
+
+// TestParseGzipped verifies gzipped sitemaps are decompressed.
+func TestParseGzipped(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(`<urlset><url><loc>https://example.com/a</loc></url></urlset>`))
+	require.NoError(t, w.Close())
+
+	sm, err := Parse(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, []URL{{Loc: "https://example.com/a"}}, sm.URLs)
+}
+
+// TestParseInvalid verifies non-XML content is rejected.
+func TestParseInvalid(t *testing.T) {
+	_, err := Parse([]byte("<html><body>Not found"))
+	assert.Error(t, err)
+}
+
+// TestMarshal verifies written sitemaps are escaped, namespaced, and parse back unchanged.
+func TestMarshal(t *testing.T) {
+	urls := []URL{
+		{Loc: "https://example.com/", LastMod: "2025-01-02T03:04:05Z"},
+		{Loc: "https://example.com/search?q=a&page=2"},
+	}
+
+	data, err := Marshal(urls)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), `<?xml version="1.0" encoding="UTF-8"?>`))
+	assert.Contains(t, string(data), `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	assert.Contains(t, string(data), "q=a&amp;page=2")
+	assert.NotContains(t, string(data), "<lastmod></lastmod>")
+
+	sm, err := Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, urls, sm.URLs)
+
+	_, err = Marshal(make([]URL, MaxURLs+1))
+	assert.Error(t, err)
+}
@@ -0,0 +1,337 @@
+package structured
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Data holds the structured content extracted from a page.
+type Data struct {
+	FAQ    []QA    `json:"faq,omitempty"`
+	HowTos []HowTo `json:"how_to,omitempty"`
+}
+
+// QA is a question and its answer.
+type QA struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// HowTo is a set of instructions.
+type HowTo struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Steps       []Step `json:"steps"`
+}
+
+// Step is a single step of a HowTo.
+type Step struct {
+	Name string `json:"name,omitempty"`
+	Text string `json:"text"`
+}
+
+// Extract returns the FAQs and how-tos declared in an HTML document, or nil if there are none.
+// schema.org FAQPage and HowTo blocks in JSON-LD are preferred. Without them, questions are
+// read from Question microdata and from <details>/<summary> and <dt>/<dd> pairs whose summary
+// or term is phrased as a question.
+func Extract(htmlContent []byte) *Data {
+	doc, err := html.Parse(bytes.NewReader(htmlContent))
+	if err != nil {
+		return nil
+	}
+
+	var data Data
+	var scripts []string
+	walk(doc, func(n *html.Node) bool {
+		if n.Data == "script" && strings.EqualFold(strings.TrimSpace(attr(n, "type")), "application/ld+json") {
+			scripts = append(scripts, text(n))
+			return false
+		}
+		return true
+	})
+	for _, script := range scripts {
+		fromJSONLD(script, &data)
+	}
+
+	if len(data.FAQ) == 0 {
+		data.FAQ = fromMarkup(doc)
+	}
+
+	if len(data.FAQ) == 0 && len(data.HowTos) == 0 {
+		return nil
+	}
+	return &data
+}
+
+// fromJSONLD adds the FAQPage and HowTo items of a JSON-LD document to data.
+func fromJSONLD(script string, data *Data) {
+	var doc any
+	if err := json.Unmarshal([]byte(script), &doc); err != nil {
+		return
+	}
+
+	var visit func(v any, depth int)
+	visit = func(v any, depth int) {
+		if depth > 3 {
+			return
+		}
+		switch node := v.(type) {
+		case []any:
+			for _, item := range node {
+				visit(item, depth+1)
+			}
+		case map[string]any:
+			switch {
+			case hasType(node, "FAQPage"):
+				data.FAQ = append(data.FAQ, questions(node["mainEntity"])...)
+			case hasType(node, "Question"):
+				data.FAQ = append(data.FAQ, questions(node)...)
+			case hasType(node, "HowTo"):
+				howTo := HowTo{
+					Name:        plain(stringValue(node["name"])),
+					Description: plain(stringValue(node["description"])),
+					Steps:       steps(node["step"]),
+				}
+				if len(howTo.Steps) > 0 {
+					data.HowTos = append(data.HowTos, howTo)
+				}
+			}
+			visit(node["@graph"], depth+1)
+		}
+	}
+
+	visit(doc, 0)
+}
+
+// questions returns the answered Question items in v, a Question or a list of them.
+func questions(v any) []QA {
+	var qas []QA
+	for _, item := range list(v) {
+		node, ok := item.(map[string]any)
+		if !ok || !hasType(node, "Question") {
+			continue
+		}
+
+		answer := node["acceptedAnswer"]
+		if answer == nil {
+			answer = node["suggestedAnswer"]
+		}
+		var answerText string
+		for _, a := range list(answer) {
+			if m, ok := a.(map[string]any); ok {
+				answerText = plain(stringValue(m["text"]))
+			} else {
+				answerText = plain(stringValue(a))
+			}
+			if answerText != "" {
+				break
+			}
+		}
+
+		question := plain(stringValue(node["name"]))
+		if question != "" && answerText != "" {
+			qas = append(qas, QA{Question: question, Answer: answerText})
+		}
+	}
+	return qas
+}
+
+// steps returns the steps of a HowTo step property, flattening HowToSection items.
+func steps(v any) []Step {
+	var result []Step
+	for _, item := range list(v) {
+		switch node := item.(type) {
+		case string:
+			if s := plain(node); s != "" {
+				result = append(result, Step{Text: s})
+			}
+		case map[string]any:
+			if hasType(node, "HowToSection") {
+				result = append(result, steps(node["itemListElement"])...)
+				continue
+			}
+			step := Step{Name: plain(stringValue(node["name"])), Text: plain(stringValue(node["text"]))}
+			if step.Text == "" {
+				step.Text, step.Name = step.Name, ""
+			}
+			if step.Text == "" {
+				if texts := steps(node["itemListElement"]); len(texts) > 0 {
+					parts := make([]string, len(texts))
+					for i, t := range texts {
+						parts[i] = t.Text
+					}
+					step.Text = strings.Join(parts, " ")
+				}
+			}
+			if step.Text != "" {
+				result = append(result, step)
+			}
+		}
+	}
+	return result
+}
+
+// fromMarkup returns the questions found in Question microdata, or failing that in
+// details/summary and dt/dd pairs phrased as questions.
+func fromMarkup(doc *html.Node) []QA {
+	var microdata, patterns []QA
+	seen := make(map[string]bool)
+
+	add := func(qas *[]QA, question, answer string) {
+		question, answer = collapse(question), collapse(answer)
+		if question == "" || answer == "" || seen[question] {
+			return
+		}
+		seen[question] = true
+		*qas = append(*qas, QA{Question: question, Answer: answer})
+	}
+
+	walk(doc, func(n *html.Node) bool {
+		switch {
+		case strings.HasSuffix(attr(n, "itemtype"), "schema.org/Question"):
+			var question, answer string
+			walk(n, func(c *html.Node) bool {
+				switch attr(c, "itemprop") {
+				case "name":
+					if question == "" {
+						question = text(c)
+					}
+				case "text":
+					if answer == "" {
+						answer = text(c)
+					}
+				}
+				return true
+			})
+			add(&microdata, question, answer)
+			return false
+		case n.Data == "details":
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode && c.Data == "summary" {
+					question := text(c)
+					if isQuestion(question) {
+						add(&patterns, question, strings.TrimPrefix(collapse(text(n)), collapse(question)))
+					}
+					break
+				}
+			}
+		case n.Data == "dt":
+			question := text(n)
+			if !isQuestion(question) {
+				break
+			}
+			for c := n.NextSibling; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode {
+					if c.Data == "dd" {
+						add(&patterns, question, text(c))
+					}
+					break
+				}
+			}
+		}
+		return true
+	})
+
+	if len(microdata) > 0 {
+		return microdata
+	}
+	return patterns
+}
+
+// isQuestion reports whether s is phrased as a question.
+func isQuestion(s string) bool {
+	return strings.HasSuffix(strings.TrimSpace(s), "?")
+}
+
+// walk calls visit for each element under n, descending into an element's children only when
+// visit returns true.
+func walk(n *html.Node, visit func(*html.Node) bool) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && !visit(c) {
+			continue
+		}
+		walk(c, visit)
+	}
+}
+
+// text returns the text content of n and its children, separating block elements with spaces.
+func text(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(text(c))
+		if c.Type == html.ElementNode {
+			switch c.Data {
+			case "br", "p", "div", "li", "ol", "ul", "tr", "td", "th", "h1", "h2", "h3", "h4", "h5", "h6":
+				b.WriteByte(' ')
+			}
+		}
+	}
+	return b.String()
+}
+
+// attr returns the value of an attribute of n.
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// plain converts a JSON-LD text value, which may contain HTML, to collapsed plain text.
+func plain(s string) string {
+	if !strings.Contains(s, "<") {
+		return collapse(s)
+	}
+	nodes, err := html.ParseFragment(strings.NewReader(s), &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div})
+	if err != nil {
+		return collapse(s)
+	}
+	var b strings.Builder
+	for _, n := range nodes {
+		b.WriteString(text(n))
+		b.WriteByte(' ')
+	}
+	return collapse(b.String())
+}
+
+// collapse trims s and collapses runs of whitespace to single spaces.
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// hasType reports whether a JSON-LD node has the schema.org type name.
+func hasType(node map[string]any, name string) bool {
+	for _, t := range list(node["@type"]) {
+		if s, ok := t.(string); ok && strings.TrimPrefix(strings.TrimPrefix(s, "https://schema.org/"), "http://schema.org/") == name {
+			return true
+		}
+	}
+	return false
+}
+
+// list returns v as a list, wrapping a single value.
+func list(v any) []any {
+	switch value := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		return value
+	default:
+		return []any{value}
+	}
+}
+
+// stringValue returns v if it is a string.
+func stringValue(v any) string {
+	s, _ := v.(string)
+	return s
+}
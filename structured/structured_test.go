@@ -0,0 +1,87 @@
+package structured
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExtractFAQPage verifies FAQPage JSON-LD questions are extracted with HTML stripped from answers.
+func TestExtractFAQPage(t *testing.T) {
+	html := `<html><head><script type="application/ld+json">
+	{"@context":"https://schema.org","@type":"FAQPage","mainEntity":[
+		{"@type":"Question","name":"What is websurfer?","acceptedAnswer":{"@type":"Answer","text":"<p>A <b>web</b> retriever.</p><p>Written in Go.</p>"}},
+		{"@type":"Question","name":"Unanswered?"}
+	]}
+	</script></head><body><details><summary>Ignored?</summary>Markup is a fallback.</details></body></html>`
+
+	data := Extract([]byte(html))
+	require.NotNil(t, data)
+	assert.Equal(t, []QA{{Question: "What is websurfer?", Answer: "A web retriever. Written in Go."}}, data.FAQ)
+	assert.Empty(t, data.HowTos)
+}
+
+// TestExtractHowTo verifies HowTo steps are extracted from strings, steps, and sections inside @graph.
+func TestExtractHowTo(t *testing.T) {
+	html := `<script type="application/ld+json">
+	{"@graph":[{"@type":"WebPage"},{"@type":"HowTo","name":"Make tea","description":"A cup of tea.","step":[
+		{"@type":"HowToStep","name":"Boil","text":"Boil the water."},
+		{"@type":"HowToSection","name":"Brew","itemListElement":[
+			{"@type":"HowToStep","text":"Add the tea bag."},
+			"Wait three minutes."
+		]}
+	]}]}
+	</script>`
+
+	data := Extract([]byte(html))
+	require.NotNil(t, data)
+	require.Len(t, data.HowTos, 1)
+	assert.Equal(t, "Make tea", data.HowTos[0].Name)
+	assert.Equal(t, "A cup of tea.", data.HowTos[0].Description)
+	assert.Equal(t, []Step{
+		{Name: "Boil", Text: "Boil the water."},
+		{Text: "Add the tea bag."},
+		{Text: "Wait three minutes."},
+	}, data.HowTos[0].Steps)
+}
+
+// TestExtractMicrodata verifies Question microdata is extracted.
+func TestExtractMicrodata(t *testing.T) {
+	html := `<div itemscope itemtype="https://schema.org/FAQPage">
+		<div itemscope itemprop="mainEntity" itemtype="https://schema.org/Question">
+			<h3 itemprop="name">Is it free?</h3>
+			<div itemscope itemprop="acceptedAnswer" itemtype="https://schema.org/Answer">
+				<p itemprop="text">Yes, it is   open source.</p>
+			</div>
+		</div>
+	</div>`
+
+	data := Extract([]byte(html))
+	require.NotNil(t, data)
+	assert.Equal(t, []QA{{Question: "Is it free?", Answer: "Yes, it is open source."}}, data.FAQ)
+}
+
+// TestExtractPatterns verifies details/summary and dt/dd pairs phrased as questions are extracted.
+func TestExtractPatterns(t *testing.T) {
+	html := `<body>
+		<details><summary>How do I install it?</summary><p>Run go install.</p></details>
+		<details><summary>Show more</summary><p>Not a question.</p></details>
+		<dl>
+			<dt>Does it cache?</dt><dd>Yes, in Redis.</dd>
+			<dt>Timeout</dt><dd>30s</dd>
+		</dl>
+	</body>`
+
+	data := Extract([]byte(html))
+	require.NotNil(t, data)
+	assert.Equal(t, []QA{
+		{Question: "How do I install it?", Answer: "Run go install."},
+		{Question: "Does it cache?", Answer: "Yes, in Redis."},
+	}, data.FAQ)
+}
+
+// TestExtractNone verifies pages without FAQs or how-tos return nil.
+func TestExtractNone(t *testing.T) {
+	assert.Nil(t, Extract([]byte(`<html><body><p>Plain article.</p><script type="application/ld+json">{"@type":"Article"}</script></body></html>`)))
+}
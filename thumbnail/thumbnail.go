@@ -0,0 +1,110 @@
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+const (
+	// maxPixels caps the decoded size of a source image to bound memory use.
+	maxPixels = 40 * 1000 * 1000
+	// jpegQuality is the quality used when re-encoding JPEG images.
+	jpegQuality = 85
+)
+
+// ErrUnsupportedFormat is returned when an image cannot be decoded, such as ICO or SVG.
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+// Resize scales an image down to fit within size x size pixels, preserving its aspect ratio.
+// JPEG images are re-encoded as JPEG and everything else as PNG. Images that already fit are
+// returned unchanged. It returns the encoded image and its content type.
+func Resize(data []byte, size int) ([]byte, string, error) {
+	if size <= 0 {
+		return nil, "", fmt.Errorf("size must be positive")
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, "", fmt.Errorf("image is too large: %dx%d", cfg.Width, cfg.Height)
+	}
+
+	if cfg.Width <= size && cfg.Height <= size {
+		return data, "image/" + format, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	width, height := fit(cfg.Width, cfg.Height, size)
+	dst := scale(src, width, height)
+
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, "", fmt.Errorf("failed to encode image: %w", err)
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, "", fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), "image/png", nil
+}
+
+// fit returns the dimensions of a width x height image scaled to fit within size x size.
+func fit(width, height, size int) (int, int) {
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
+
+// scale resizes src to width x height by averaging the source pixels covered by each
+// destination pixel, which avoids the aliasing of nearest-neighbor sampling when shrinking.
+func scale(src image.Image, width, height int) *image.RGBA64 {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA64(image.Rect(0, 0, width, height))
+
+	for y := range height {
+		y0 := bounds.Min.Y + y*srcH/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*srcH/height)
+		for x := range width {
+			x0 := bounds.Min.X + x*srcW/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*srcW/width)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	return dst
+}
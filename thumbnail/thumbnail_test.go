@@ -0,0 +1,76 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodePNG returns a solid-colored PNG of the given size.
+func encodePNG(t *testing.T, width, height int, c color.Color) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// TestResizeScalesDown verifies large images are scaled to fit, preserving aspect ratio and color.
+func TestResizeScalesDown(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	data, contentType, err := Resize(encodePNG(t, 200, 100, red), 50)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 50, img.Bounds().Dx())
+	assert.Equal(t, 25, img.Bounds().Dy())
+
+	r, g, b, a := img.At(10, 10).RGBA()
+	assert.Equal(t, []uint32{0xffff, 0, 0, 0xffff}, []uint32{r, g, b, a})
+}
+
+// TestResizeSmallImageUnchanged verifies images that already fit are returned as-is.
+func TestResizeSmallImageUnchanged(t *testing.T) {
+	original := encodePNG(t, 16, 16, color.White)
+
+	data, contentType, err := Resize(original, 64)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	assert.Equal(t, original, data)
+}
+
+// TestResizeJPEG verifies JPEG images stay JPEG.
+func TestResizeJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 64, 128)), nil))
+
+	data, contentType, err := Resize(buf.Bytes(), 32)
+	require.NoError(t, err)
+	assert.Equal(t, "image/jpeg", contentType)
+
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 16, cfg.Width)
+	assert.Equal(t, 32, cfg.Height)
+}
+
+// TestResizeUnsupportedFormat verifies undecodable data returns ErrUnsupportedFormat.
+func TestResizeUnsupportedFormat(t *testing.T) {
+	_, _, err := Resize([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"), 32)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
@@ -0,0 +1,163 @@
+// Package transform rewrites parsed page content with site-specific rules, such as removing a
+// site's navigation sections or dropping lines of chrome, so deployments can clean up noisy
+// sites in configuration instead of in the parsers.
+package transform
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rule types.
+const (
+	// StripSection removes each markdown section whose heading matches the pattern, from the
+	// heading down to the next heading of the same or a higher level.
+	StripSection = "strip_section"
+	// DropLines removes lines matching the pattern.
+	DropLines = "drop_lines"
+	// DropHeadings removes heading lines matching the pattern, keeping the content beneath them.
+	DropHeadings = "drop_headings"
+	// Replace replaces matches of the pattern with the replacement, which may refer to
+	// submatches as $1 or ${name}.
+	Replace = "replace"
+)
+
+// headingRegex matches a markdown ATX heading, capturing its markers and text.
+var headingRegex = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+
+// Rule configures a rewrite of the content.
+type Rule struct {
+	// Type is the rule type, such as StripSection.
+	Type string `json:"type" yaml:"type"`
+	// Pattern is a regular expression matched against heading text for StripSection and
+	// DropHeadings, against each line for DropLines, and against the whole content for Replace.
+	Pattern string `json:"pattern" yaml:"pattern"`
+	// Replacement is what Replace rules substitute for each match.
+	Replacement string `json:"replacement,omitempty" yaml:"replacement,omitempty"`
+}
+
+// Pipeline applies compiled rules in order.
+type Pipeline []compiledRule
+
+// compiledRule is a rule with its pattern compiled.
+type compiledRule struct {
+	Rule
+	pattern *regexp.Regexp
+}
+
+// New compiles rules into a pipeline.
+func New(rules []Rule) (Pipeline, error) {
+	pipeline := make(Pipeline, 0, len(rules))
+	for i, rule := range rules {
+		switch rule.Type {
+		case StripSection, DropLines, DropHeadings, Replace:
+		case "":
+			return nil, fmt.Errorf("transforms[%d]: type cannot be empty", i)
+		default:
+			return nil, fmt.Errorf("transforms[%d]: unknown type %q (supported: %s, %s, %s, %s)", i, rule.Type, StripSection, DropLines, DropHeadings, Replace)
+		}
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("transforms[%d]: pattern cannot be empty", i)
+		}
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("transforms[%d]: invalid pattern: %w", i, err)
+		}
+		pipeline = append(pipeline, compiledRule{Rule: rule, pattern: pattern})
+	}
+	return pipeline, nil
+}
+
+// Validate reports whether rules are valid, without keeping the compiled pipeline.
+func Validate(rules []Rule) error {
+	_, err := New(rules)
+	return err
+}
+
+// Apply rewrites content with each rule in order. Rules other than Replace leave code blocks
+// untouched, and blank lines left around removed lines are dropped.
+func (p Pipeline) Apply(content []byte) []byte {
+	if len(p) == 0 || len(content) == 0 {
+		return content
+	}
+
+	text := string(content)
+	for _, rule := range p {
+		if rule.Type == Replace {
+			text = rule.pattern.ReplaceAllString(text, rule.Replacement)
+			continue
+		}
+
+		lines := strings.Split(text, "\n")
+		drop := make([]bool, len(lines))
+		inFence := false
+		// sectionLevel is the heading level of the section being stripped, or 0.
+		sectionLevel := 0
+		for i, line := range lines {
+			if strings.HasPrefix(strings.TrimSpace(line), "```") {
+				inFence = !inFence
+				drop[i] = sectionLevel > 0
+				continue
+			}
+			if inFence {
+				drop[i] = sectionLevel > 0
+				continue
+			}
+
+			level, heading := parseHeading(line)
+			switch rule.Type {
+			case StripSection:
+				if level > 0 && sectionLevel > 0 && level <= sectionLevel {
+					sectionLevel = 0
+				}
+				if level > 0 && sectionLevel == 0 && rule.pattern.MatchString(heading) {
+					sectionLevel = level
+				}
+				drop[i] = sectionLevel > 0
+			case DropLines:
+				drop[i] = rule.pattern.MatchString(line)
+			case DropHeadings:
+				drop[i] = level > 0 && rule.pattern.MatchString(heading)
+			}
+		}
+		trailingNewline := strings.HasSuffix(text, "\n")
+		text = removeLines(lines, drop)
+		if trailingNewline && text != "" && !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+	}
+	return []byte(text)
+}
+
+// parseHeading returns the level and text of a markdown heading line, or 0 if the line is not a
+// heading.
+func parseHeading(line string) (int, string) {
+	m := headingRegex.FindStringSubmatch(line)
+	if m == nil {
+		return 0, ""
+	}
+	return len(m[1]), m[2]
+}
+
+// removeLines joins the lines not dropped. Blank lines next to a removed line are dropped with
+// it, so no gaps are left behind.
+func removeLines(lines []string, drop []bool) string {
+	kept := make([]string, 0, len(lines))
+	removed := false
+	for i, line := range lines {
+		if drop[i] {
+			removed = true
+			continue
+		}
+		if removed && strings.TrimSpace(line) == "" && (len(kept) == 0 || strings.TrimSpace(kept[len(kept)-1]) == "") {
+			continue
+		}
+		removed = false
+		kept = append(kept, line)
+	}
+	for len(kept) > 0 && removed && strings.TrimSpace(kept[len(kept)-1]) == "" {
+		kept = kept[:len(kept)-1]
+	}
+	return strings.Join(kept, "\n")
+}
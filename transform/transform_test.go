@@ -0,0 +1,58 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPipelineApply verifies each rule type rewrites the content and leaves code blocks alone.
+func TestPipelineApply(t *testing.T) {
+	content := "# Guide\n\nIntro text.\n\n## Navigation\n\n- [Home](/)\n- [Docs](/docs)\n\n### Popular\n\n- [FAQ](/faq)\n\n## Install\n\nRun the installer.\n\n```sh\n# Navigation\nShare this page\n```\n\nShare this page on X\n\n## Related posts\n\nMore reading.\n"
+
+	tests := []struct {
+		name  string
+		rules []Rule
+		want  string
+	}{
+		{
+			name:  "strip section",
+			rules: []Rule{{Type: StripSection, Pattern: `^(Navigation|Related posts)$`}},
+			want:  "# Guide\n\nIntro text.\n\n## Install\n\nRun the installer.\n\n```sh\n# Navigation\nShare this page\n```\n\nShare this page on X\n",
+		},
+		{
+			name:  "drop lines",
+			rules: []Rule{{Type: DropLines, Pattern: `^Share this page`}},
+			want:  "# Guide\n\nIntro text.\n\n## Navigation\n\n- [Home](/)\n- [Docs](/docs)\n\n### Popular\n\n- [FAQ](/faq)\n\n## Install\n\nRun the installer.\n\n```sh\n# Navigation\nShare this page\n```\n\n## Related posts\n\nMore reading.\n",
+		},
+		{
+			name:  "drop headings",
+			rules: []Rule{{Type: DropHeadings, Pattern: `^Popular$`}},
+			want:  "# Guide\n\nIntro text.\n\n## Navigation\n\n- [Home](/)\n- [Docs](/docs)\n\n- [FAQ](/faq)\n\n## Install\n\nRun the installer.\n\n```sh\n# Navigation\nShare this page\n```\n\nShare this page on X\n\n## Related posts\n\nMore reading.\n",
+		},
+		{
+			name:  "replace",
+			rules: []Rule{{Type: Replace, Pattern: `\]\(/(\w*)\)`, Replacement: "](https://example.com/$1)"}},
+			want:  "# Guide\n\nIntro text.\n\n## Navigation\n\n- [Home](https://example.com/)\n- [Docs](https://example.com/docs)\n\n### Popular\n\n- [FAQ](https://example.com/faq)\n\n## Install\n\nRun the installer.\n\n```sh\n# Navigation\nShare this page\n```\n\nShare this page on X\n\n## Related posts\n\nMore reading.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline, err := New(tt.rules)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(pipeline.Apply([]byte(content))))
+		})
+	}
+}
+
+// TestValidate verifies rules with unknown types or invalid patterns are rejected.
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(nil))
+	assert.NoError(t, Validate([]Rule{{Type: DropLines, Pattern: `^Advertisement$`}}))
+	assert.Error(t, Validate([]Rule{{Pattern: `x`}}))
+	assert.Error(t, Validate([]Rule{{Type: "strip", Pattern: `x`}}))
+	assert.Error(t, Validate([]Rule{{Type: DropLines}}))
+	assert.Error(t, Validate([]Rule{{Type: Replace, Pattern: `(`}}))
+}
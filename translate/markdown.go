@@ -0,0 +1,116 @@
+package translate
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+const (
+	// maxBatchChars and maxBatchTexts cap the size of a single translation request.
+	maxBatchChars = 20000
+	maxBatchTexts = 50
+)
+
+// segment is a piece of a markdown document, translated unless it is code or whitespace.
+type segment struct {
+	text      string
+	translate bool
+}
+
+// Markdown translates a markdown document to target, paragraph by paragraph. Fenced code
+// blocks and paragraphs without letters are kept as is, and paragraphs are sent in batches to
+// keep requests small.
+func Markdown(ctx context.Context, t Translator, markdown, source, target string) (string, error) {
+	segments := splitMarkdown(markdown)
+
+	var pending []int
+	var pendingChars int
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		texts := make([]string, len(pending))
+		for i, idx := range pending {
+			texts[i] = segments[idx].text
+		}
+		translated, err := t.Translate(ctx, texts, source, target)
+		if err != nil {
+			return err
+		}
+		for i, idx := range pending {
+			segments[idx].text = translated[i]
+		}
+		pending, pendingChars = pending[:0], 0
+		return nil
+	}
+
+	for i, seg := range segments {
+		if !seg.translate {
+			continue
+		}
+		if len(pending) > 0 && (len(pending) == maxBatchTexts || pendingChars+len(seg.text) > maxBatchChars) {
+			if err := flush(); err != nil {
+				return "", err
+			}
+		}
+		pending = append(pending, i)
+		pendingChars += len(seg.text)
+	}
+	if err := flush(); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, seg := range segments {
+		b.WriteString(seg.text)
+	}
+	return b.String(), nil
+}
+
+// splitMarkdown splits a markdown document into paragraphs, fenced code blocks, and the
+// whitespace between them, so that joining the segments restores the document.
+func splitMarkdown(markdown string) []segment {
+	var (
+		segments  []segment
+		paragraph strings.Builder
+		fence     string
+	)
+	flushParagraph := func() {
+		if paragraph.Len() == 0 {
+			return
+		}
+		text := paragraph.String()
+		body := strings.TrimRight(text, "\n")
+		segments = append(segments, segment{text: body, translate: hasLetters(body)}, segment{text: text[len(body):]})
+		paragraph.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			segments = append(segments, segment{text: line})
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flushParagraph()
+			fence = trimmed[:3]
+			segments = append(segments, segment{text: line})
+		case trimmed == "":
+			flushParagraph()
+			segments = append(segments, segment{text: line})
+		default:
+			paragraph.WriteString(line)
+		}
+	}
+	flushParagraph()
+
+	return segments
+}
+
+// hasLetters reports whether text contains any letters worth translating.
+func hasLetters(text string) bool {
+	return strings.IndexFunc(text, unicode.IsLetter) >= 0
+}
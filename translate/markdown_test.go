@@ -0,0 +1,51 @@
+package translate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// upperTranslator translates text by upper-casing it, recording each batch.
+type upperTranslator struct {
+	batches [][]string
+}
+
+// Translate implements Translator.
+func (u *upperTranslator) Translate(ctx context.Context, texts []string, source, target string) ([]string, error) {
+	u.batches = append(u.batches, texts)
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		translated[i] = strings.ToUpper(text)
+	}
+	return translated, nil
+}
+
+// TestMarkdown verifies paragraphs are translated while code blocks and layout are kept.
+func TestMarkdown(t *testing.T) {
+	markdown := "# Titel\n\nErster Absatz\nmit zwei Zeilen.\n\n```go\nfmt.Println(\"hallo\")\n```\n\n---\n\n- Punkt\n"
+
+	tr := &upperTranslator{}
+	translated, err := Markdown(context.Background(), tr, markdown, "de", "en")
+	require.NoError(t, err)
+	assert.Equal(t, "# TITEL\n\nERSTER ABSATZ\nMIT ZWEI ZEILEN.\n\n```go\nfmt.Println(\"hallo\")\n```\n\n---\n\n- PUNKT\n", translated)
+	assert.Equal(t, [][]string{{"# Titel", "Erster Absatz\nmit zwei Zeilen.", "- Punkt"}}, tr.batches)
+}
+
+// TestMarkdownBatches verifies long documents are split across requests.
+func TestMarkdownBatches(t *testing.T) {
+	paragraphs := make([]string, maxBatchTexts+1)
+	for i := range paragraphs {
+		paragraphs[i] = "Absatz"
+	}
+
+	tr := &upperTranslator{}
+	_, err := Markdown(context.Background(), tr, strings.Join(paragraphs, "\n\n"), "de", "en")
+	require.NoError(t, err)
+	require.Len(t, tr.batches, 2)
+	assert.Len(t, tr.batches[0], maxBatchTexts)
+	assert.Len(t, tr.batches[1], 1)
+}
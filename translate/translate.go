@@ -0,0 +1,231 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// ProviderLibreTranslate translates with a LibreTranslate server.
+	ProviderLibreTranslate = "libretranslate"
+	// ProviderDeepL translates with the DeepL API.
+	ProviderDeepL = "deepl"
+	// ProviderHTTP translates with a custom endpoint.
+	ProviderHTTP = "http"
+)
+
+const (
+	// defaultLibreTranslateURL is the base URL of the public LibreTranslate server.
+	defaultLibreTranslateURL = "https://libretranslate.com"
+	// deepLURL and deepLFreeURL are the base URLs of the DeepL Pro and Free APIs.
+	deepLURL     = "https://api.deepl.com"
+	deepLFreeURL = "https://api-free.deepl.com"
+	// translateTimeout bounds a single translation request.
+	translateTimeout = 60 * time.Second
+	// maxErrorBody caps how much of an error response is included in errors.
+	maxErrorBody = 512
+)
+
+// Translator translates text between languages.
+type Translator interface {
+	// Translate returns texts translated to target, one per text, in the same order. Languages
+	// are ISO 639-1 codes such as "en"; an empty source asks the translator to detect it.
+	Translate(ctx context.Context, texts []string, source, target string) ([]string, error)
+}
+
+// Config selects and configures a translator.
+type Config struct {
+	// Provider is ProviderLibreTranslate, ProviderDeepL, or ProviderHTTP.
+	Provider string
+	// URL is the endpoint for ProviderHTTP, or overrides the base URL of the other providers.
+	URL    string
+	APIKey string
+}
+
+// New creates a translator from the configuration.
+func New(cfg Config) (Translator, error) {
+	switch cfg.Provider {
+	case ProviderLibreTranslate:
+		return NewLibreTranslate(cfg.URL, cfg.APIKey), nil
+	case ProviderDeepL:
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("deepl translator requires an api key")
+		}
+		return NewDeepL(cfg.APIKey, cfg.URL), nil
+	case ProviderHTTP:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("http translator requires a url")
+		}
+		return NewHTTP(cfg.URL, cfg.APIKey), nil
+	default:
+		return nil, fmt.Errorf("unknown translator provider %q (supported: %s, %s, %s)", cfg.Provider, ProviderLibreTranslate, ProviderDeepL, ProviderHTTP)
+	}
+}
+
+// LibreTranslate translates with a LibreTranslate server.
+type LibreTranslate struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// NewLibreTranslate creates a LibreTranslate translator. An empty baseURL uses the public
+// server, which requires an API key.
+func NewLibreTranslate(baseURL, apiKey string) *LibreTranslate {
+	if baseURL == "" {
+		baseURL = defaultLibreTranslateURL
+	}
+	return &LibreTranslate{
+		client:  &http.Client{Timeout: translateTimeout},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+	}
+}
+
+// Translate translates texts to target.
+func (t *LibreTranslate) Translate(ctx context.Context, texts []string, source, target string) ([]string, error) {
+	if source == "" {
+		source = "auto"
+	}
+	body := map[string]any{"q": texts, "source": source, "target": target, "format": "text"}
+	if t.apiKey != "" {
+		body["api_key"] = t.apiKey
+	}
+
+	var resp struct {
+		TranslatedText []string `json:"translatedText"`
+	}
+	if err := postJSON(ctx, t.client, t.baseURL+"/translate", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return checkCount(texts, resp.TranslatedText)
+}
+
+// DeepL translates with the DeepL API.
+type DeepL struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// NewDeepL creates a DeepL translator. An empty baseURL uses the Free API for free keys, which
+// end in ":fx", and the Pro API otherwise.
+func NewDeepL(apiKey, baseURL string) *DeepL {
+	if baseURL == "" {
+		baseURL = deepLURL
+		if strings.HasSuffix(apiKey, ":fx") {
+			baseURL = deepLFreeURL
+		}
+	}
+	return &DeepL{
+		client:  &http.Client{Timeout: translateTimeout},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+	}
+}
+
+// Translate translates texts to target.
+func (t *DeepL) Translate(ctx context.Context, texts []string, source, target string) ([]string, error) {
+	body := map[string]any{"text": texts, "target_lang": strings.ToUpper(target)}
+	if source != "" {
+		body["source_lang"] = strings.ToUpper(source)
+	}
+
+	var resp struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	header := http.Header{"Authorization": {"DeepL-Auth-Key " + t.apiKey}}
+	if err := postJSON(ctx, t.client, t.baseURL+"/v2/translate", header, body, &resp); err != nil {
+		return nil, err
+	}
+
+	translated := make([]string, len(resp.Translations))
+	for i, tr := range resp.Translations {
+		translated[i] = tr.Text
+	}
+	return checkCount(texts, translated)
+}
+
+// HTTP translates with a custom endpoint that accepts {"texts": [...], "source": "de",
+// "target": "en"} and returns {"texts": [...]}.
+type HTTP struct {
+	client *http.Client
+	url    string
+	apiKey string
+}
+
+// NewHTTP creates a translator for a custom endpoint. The API key, if any, is sent as a bearer token.
+func NewHTTP(url, apiKey string) *HTTP {
+	return &HTTP{
+		client: &http.Client{Timeout: translateTimeout},
+		url:    url,
+		apiKey: apiKey,
+	}
+}
+
+// Translate translates texts to target.
+func (t *HTTP) Translate(ctx context.Context, texts []string, source, target string) ([]string, error) {
+	var header http.Header
+	if t.apiKey != "" {
+		header = http.Header{"Authorization": {"Bearer " + t.apiKey}}
+	}
+
+	var resp struct {
+		Texts []string `json:"texts"`
+	}
+	body := map[string]any{"texts": texts, "source": source, "target": target}
+	if err := postJSON(ctx, t.client, t.url, header, body, &resp); err != nil {
+		return nil, err
+	}
+	return checkCount(texts, resp.Texts)
+}
+
+// checkCount returns translated if it has one translation per text.
+func checkCount(texts, translated []string) ([]string, error) {
+	if len(translated) != len(texts) {
+		return nil, fmt.Errorf("expected %d translations, got %d", len(texts), len(translated))
+	}
+	return translated, nil
+}
+
+// postJSON posts body as JSON with the given headers and decodes the response into out.
+func postJSON(ctx context.Context, client *http.Client, url string, header http.Header, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode translation request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create translation request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("translation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("translation request failed: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode translation response: %w", err)
+	}
+
+	return nil
+}
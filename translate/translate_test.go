@@ -0,0 +1,118 @@
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNew verifies provider selection and required settings.
+func TestNew(t *testing.T) {
+	_, err := New(Config{Provider: ProviderDeepL})
+	assert.ErrorContains(t, err, "api key")
+
+	_, err = New(Config{Provider: ProviderHTTP})
+	assert.ErrorContains(t, err, "url")
+
+	_, err = New(Config{Provider: "google"})
+	assert.ErrorContains(t, err, "unknown translator provider")
+
+	tr, err := New(Config{Provider: ProviderDeepL, APIKey: "key:fx"})
+	require.NoError(t, err)
+	assert.Equal(t, deepLFreeURL, tr.(*DeepL).baseURL)
+
+	tr, err = New(Config{Provider: ProviderLibreTranslate})
+	require.NoError(t, err)
+	assert.Equal(t, defaultLibreTranslateURL, tr.(*LibreTranslate).baseURL)
+}
+
+// TestLibreTranslate verifies requests to a LibreTranslate server.
+func TestLibreTranslate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/translate", r.URL.Path)
+
+		var body struct {
+			Q      []string `json:"q"`
+			Source string   `json:"source"`
+			Target string   `json:"target"`
+			APIKey string   `json:"api_key"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, []string{"Hallo", "Welt"}, body.Q)
+		assert.Equal(t, "auto", body.Source)
+		assert.Equal(t, "en", body.Target)
+		assert.Equal(t, "key", body.APIKey)
+
+		w.Write([]byte(`{"translatedText":["Hello","World"]}`))
+	}))
+	defer srv.Close()
+
+	translated, err := NewLibreTranslate(srv.URL+"/", "key").Translate(context.Background(), []string{"Hallo", "Welt"}, "", "en")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Hello", "World"}, translated)
+}
+
+// TestDeepL verifies requests to the DeepL API.
+func TestDeepL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/translate", r.URL.Path)
+		assert.Equal(t, "DeepL-Auth-Key key", r.Header.Get("Authorization"))
+
+		var body struct {
+			Text       []string `json:"text"`
+			SourceLang string   `json:"source_lang"`
+			TargetLang string   `json:"target_lang"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, []string{"Hallo"}, body.Text)
+		assert.Equal(t, "DE", body.SourceLang)
+		assert.Equal(t, "EN", body.TargetLang)
+
+		w.Write([]byte(`{"translations":[{"detected_source_language":"DE","text":"Hello"}]}`))
+	}))
+	defer srv.Close()
+
+	translated, err := NewDeepL("key", srv.URL).Translate(context.Background(), []string{"Hallo"}, "de", "en")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Hello"}, translated)
+}
+
+// TestHTTP verifies requests to a custom endpoint and error handling.
+func TestHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+
+		var body struct {
+			Texts  []string `json:"texts"`
+			Source string   `json:"source"`
+			Target string   `json:"target"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body.Target == "xx" {
+			http.Error(w, "unsupported language", http.StatusBadRequest)
+			return
+		}
+		if len(body.Texts) > 1 {
+			w.Write([]byte(`{"texts":["one"]}`))
+			return
+		}
+		w.Write([]byte(`{"texts":["Hello"]}`))
+	}))
+	defer srv.Close()
+
+	tr := NewHTTP(srv.URL, "key")
+	translated, err := tr.Translate(context.Background(), []string{"Hallo"}, "de", "en")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Hello"}, translated)
+
+	_, err = tr.Translate(context.Background(), []string{"Hallo"}, "de", "xx")
+	assert.ErrorContains(t, err, "unsupported language")
+
+	_, err = tr.Translate(context.Background(), []string{"a", "b"}, "de", "en")
+	assert.ErrorContains(t, err, "expected 2 translations, got 1")
+}
@@ -2,9 +2,23 @@ package url
 
 import (
 	"net/url"
+	"slices"
 	"strings"
 )
 
+// readmeNames are the README files tried, in order, for repository and directory URLs.
+var readmeNames = []string{"README.md", "README.rst", "README", "readme.md"}
+
+// githubReserved are first path segments of github.com that are not repository owners.
+var githubReserved = []string{
+	"about", "apps", "collections", "enterprise", "explore", "features", "login", "marketplace",
+	"new", "notifications", "orgs", "pricing", "search", "settings", "sponsors", "topics",
+	"trending", "users",
+}
+
+// gitlabReserved are first path segments of gitlab.com that are not groups or users.
+var gitlabReserved = []string{"admin", "dashboard", "explore", "groups", "help", "projects", "search", "users"}
+
 // Transform converts URLs to their optimal fetch format.
 // For example, GitHub blob URLs are converted to raw URLs for direct content access.
 func Transform(rawURL string) string {
@@ -16,6 +30,8 @@ func Transform(rawURL string) string {
 	switch u.Host {
 	case "github.com", "www.github.com":
 		return transformGitHub(u)
+	case "gitlab.com", "www.gitlab.com":
+		return transformGitLab(u)
 	case "arxiv.org", "www.arxiv.org":
 		return transformArXiv(u)
 	}
@@ -23,6 +39,28 @@ func Transform(rawURL string) string {
 	return rawURL
 }
 
+// Readmes returns the raw URLs of the README files that may describe a GitHub or GitLab
+// repository or directory URL, to try in order before the page itself, or nil for any other URL.
+// github.com/owner/repo → raw.githubusercontent.com/owner/repo/HEAD/README.md, ...
+// github.com/owner/repo/tree/branch/dir → raw.githubusercontent.com/owner/repo/branch/dir/README.md, ...
+// gitlab.com/group/project → gitlab.com/group/project/-/raw/HEAD/README.md, ...
+func Readmes(rawURL string) []string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+
+	segments := strings.FieldsFunc(u.EscapedPath(), func(r rune) bool { return r == '/' })
+	switch u.Host {
+	case "github.com", "www.github.com":
+		return githubReadmes(segments)
+	case "gitlab.com", "www.gitlab.com":
+		return gitlabReadmes(segments)
+	}
+
+	return nil
+}
+
 // transformGitHub converts GitHub blob URLs to raw.githubusercontent.com URLs.
 // github.com/owner/repo/blob/branch/path → raw.githubusercontent.com/owner/repo/branch/path
 func transformGitHub(u *url.URL) string {
@@ -35,6 +73,66 @@ func transformGitHub(u *url.URL) string {
 	return u.String()
 }
 
+// transformGitLab converts GitLab blob URLs to raw URLs.
+// gitlab.com/group/project/-/blob/branch/path → gitlab.com/group/project/-/raw/branch/path
+func transformGitLab(u *url.URL) string {
+	if !strings.Contains(u.Path, "/-/blob/") {
+		return u.String()
+	}
+
+	u.Path = strings.Replace(u.Path, "/-/blob/", "/-/raw/", 1)
+	return u.String()
+}
+
+// githubReadmes returns the README URLs of a github.com repository root or tree path. Branch
+// names may contain slashes, but raw.githubusercontent.com resolves them itself, so the path
+// after /tree/ maps over unchanged.
+func githubReadmes(segments []string) []string {
+	if len(segments) < 2 || slices.Contains(githubReserved, segments[0]) {
+		return nil
+	}
+
+	base := "https://raw.githubusercontent.com/" + segments[0] + "/" + strings.TrimSuffix(segments[1], ".git")
+	switch {
+	case len(segments) == 2:
+		return readmeURLs(base + "/HEAD")
+	case len(segments) > 3 && segments[2] == "tree":
+		return readmeURLs(base + "/" + strings.Join(segments[3:], "/"))
+	}
+
+	return nil
+}
+
+// gitlabReadmes returns the README URLs of a gitlab.com project root or tree path. Projects
+// may be nested in subgroups, so the project is the path before the "/-/" separator, or the
+// whole path when there is none.
+func gitlabReadmes(segments []string) []string {
+	if len(segments) < 2 || slices.Contains(gitlabReserved, segments[0]) {
+		return nil
+	}
+
+	sep := slices.Index(segments, "-")
+	if sep == -1 {
+		last := len(segments) - 1
+		project := strings.Join(segments[:last], "/") + "/" + strings.TrimSuffix(segments[last], ".git")
+		return readmeURLs("https://gitlab.com/" + project + "/-/raw/HEAD")
+	}
+	if sep >= 2 && len(segments) > sep+2 && segments[sep+1] == "tree" {
+		return readmeURLs("https://gitlab.com/" + strings.Join(segments[:sep], "/") + "/-/raw/" + strings.Join(segments[sep+2:], "/"))
+	}
+
+	return nil
+}
+
+// readmeURLs returns the URLs of the README files in a raw directory URL.
+func readmeURLs(dir string) []string {
+	urls := make([]string, len(readmeNames))
+	for i, name := range readmeNames {
+		urls[i] = dir + "/" + name
+	}
+	return urls
+}
+
 // transformArXiv converts arXiv abstract URLs to HTML URLs for cleaner parsing.
 // arxiv.org/abs/2301.00001 → arxiv.org/html/2301.00001
 func transformArXiv(u *url.URL) string {
@@ -33,6 +33,21 @@ func TestTransform(t *testing.T) {
 			input: "https://raw.githubusercontent.com/golang/go/master/src/fmt/print.go",
 			want:  "https://raw.githubusercontent.com/golang/go/master/src/fmt/print.go",
 		},
+		{
+			name:  "gitlab blob to raw",
+			input: "https://gitlab.com/gitlab-org/cli/-/blob/main/README.md",
+			want:  "https://gitlab.com/gitlab-org/cli/-/raw/main/README.md",
+		},
+		{
+			name:  "gitlab subgroup blob to raw",
+			input: "https://gitlab.com/gitlab-org/security/gitlab/-/blob/master/doc/index.md",
+			want:  "https://gitlab.com/gitlab-org/security/gitlab/-/raw/master/doc/index.md",
+		},
+		{
+			name:  "gitlab non-blob unchanged",
+			input: "https://gitlab.com/gitlab-org/cli/-/issues/1",
+			want:  "https://gitlab.com/gitlab-org/cli/-/issues/1",
+		},
 		{
 			name:  "arxiv abs to html",
 			input: "https://arxiv.org/abs/2301.00001",
@@ -64,3 +79,72 @@ func TestTransform(t *testing.T) {
 		})
 	}
 }
+
+func TestReadmes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "github repository",
+			input: "https://github.com/golang/go",
+			want:  "https://raw.githubusercontent.com/golang/go/HEAD/README.md",
+		},
+		{
+			name:  "github repository with .git suffix",
+			input: "https://github.com/golang/go.git",
+			want:  "https://raw.githubusercontent.com/golang/go/HEAD/README.md",
+		},
+		{
+			name:  "github tree",
+			input: "https://github.com/golang/go/tree/master/src/fmt",
+			want:  "https://raw.githubusercontent.com/golang/go/master/src/fmt/README.md",
+		},
+		{
+			name:  "gitlab project",
+			input: "https://gitlab.com/gitlab-org/cli",
+			want:  "https://gitlab.com/gitlab-org/cli/-/raw/HEAD/README.md",
+		},
+		{
+			name:  "gitlab subgroup tree",
+			input: "https://gitlab.com/gitlab-org/security/gitlab/-/tree/master/doc",
+			want:  "https://gitlab.com/gitlab-org/security/gitlab/-/raw/master/doc/README.md",
+		},
+		{
+			name:  "github owner",
+			input: "https://github.com/golang",
+		},
+		{
+			name:  "github reserved path",
+			input: "https://github.com/orgs/golang",
+		},
+		{
+			name:  "github file",
+			input: "https://github.com/golang/go/blob/master/README.md",
+		},
+		{
+			name:  "gitlab issues",
+			input: "https://gitlab.com/gitlab-org/cli/-/issues",
+		},
+		{
+			name:  "other host",
+			input: "https://example.com/owner/repo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Readmes(tt.input)
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("Readmes(%q) = %q, want nil", tt.input, got)
+				}
+				return
+			}
+			if len(got) != len(readmeNames) || got[0] != tt.want {
+				t.Errorf("Readmes(%q) = %q, want %d URLs starting with %q", tt.input, got, len(readmeNames), tt.want)
+			}
+		})
+	}
+}
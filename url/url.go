@@ -1,12 +1,17 @@
 package url
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"net/url"
 	"strings"
 )
 
+// ErrBlockedAddress is returned when a URL or connection targets a private, loopback, or
+// link-local address.
+var ErrBlockedAddress = errors.New("blocked by ssrf protection")
+
 // ParseAndValidate parses a URL string and validates it has a scheme and host.
 func ParseAndValidate(rawURL string) (*url.URL, error) {
 	if strings.TrimSpace(rawURL) == "" {
@@ -56,10 +61,10 @@ func ValidateNotPrivate(host string) error {
 
 	if ip := net.ParseIP(hostname); ip != nil {
 		if ip.IsLoopback() || ip.IsPrivate() {
-			return fmt.Errorf("requests to private IP addresses are not allowed: %s", hostname)
+			return fmt.Errorf("%w: requests to private IP addresses are not allowed: %s", ErrBlockedAddress, hostname)
 		}
 		if isLinkLocal(ip) {
-			return fmt.Errorf("requests to link-local addresses are not allowed: %s", hostname)
+			return fmt.Errorf("%w: requests to link-local addresses are not allowed: %s", ErrBlockedAddress, hostname)
 		}
 		return nil
 	}
@@ -71,16 +76,31 @@ func ValidateNotPrivate(host string) error {
 
 	for _, resolvedIP := range ips {
 		if resolvedIP.IsLoopback() || resolvedIP.IsPrivate() {
-			return fmt.Errorf("url resolves to private IP address: %s -> %s", hostname, resolvedIP.String())
+			return fmt.Errorf("%w: url resolves to private IP address: %s -> %s", ErrBlockedAddress, hostname, resolvedIP.String())
 		}
 		if isLinkLocal(resolvedIP) {
-			return fmt.Errorf("url resolves to link-local address: %s -> %s", hostname, resolvedIP.String())
+			return fmt.Errorf("%w: url resolves to link-local address: %s -> %s", ErrBlockedAddress, hostname, resolvedIP.String())
 		}
 	}
 
 	return nil
 }
 
+// ValidateIP checks that an already-resolved IP address is not private, loopback, or link-local.
+// It is used at dial time so the address actually connected to is the one that was validated.
+func ValidateIP(ip net.IP) error {
+	if ip.IsLoopback() || ip.IsPrivate() {
+		return fmt.Errorf("%w: connections to private IP addresses are not allowed: %s", ErrBlockedAddress, ip.String())
+	}
+	if isLinkLocal(ip) {
+		return fmt.Errorf("%w: connections to link-local addresses are not allowed: %s", ErrBlockedAddress, ip.String())
+	}
+	if ip.IsUnspecified() {
+		return fmt.Errorf("%w: connections to unspecified addresses are not allowed: %s", ErrBlockedAddress, ip.String())
+	}
+	return nil
+}
+
 // isLinkLocal checks if an IP address is in the link-local range.
 // This blocks:
 // - 169.254.0.0/16 (IPv4 link-local, used by AWS/GCP/Azure metadata endpoints)
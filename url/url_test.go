@@ -348,6 +348,35 @@ func TestIsLinkLocal(t *testing.T) {
 	}
 }
 
+// TestValidateIP verifies resolved IPs are checked against private, loopback, and link-local ranges.
+func TestValidateIP(t *testing.T) {
+	tests := []struct {
+		ip          string
+		shouldError bool
+	}{
+		{"8.8.8.8", false},
+		{"2001:4860:4860::8888", false},
+		{"127.0.0.1", true},
+		{"10.0.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"::1", true},
+		{"fe80::1", true},
+		{"0.0.0.0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			err := ValidateIP(net.ParseIP(tt.ip))
+			if tt.shouldError {
+				assert.Error(t, err, "should reject %s", tt.ip)
+			} else {
+				assert.NoError(t, err, "should accept %s", tt.ip)
+			}
+		})
+	}
+}
+
 // TestParseAndValidatePreservesURL verifies URL parsing preserves all components.
 func TestParseAndValidatePreservesURL(t *testing.T) {
 	// Note: ParseRequestURI (used in ParseAndValidate) treats # as part of RawQuery
@@ -0,0 +1,161 @@
+// Package urlnorm normalizes URLs so that trivially different spellings of the same page, such
+// as ones differing in host case, default port, tracking parameters, or query order, compare
+// equal.
+package urlnorm
+
+import (
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DefaultTrackingParams are the query parameters removed from every URL unless kept by
+// Rules.KeepParams. A trailing "*" matches any parameter with that prefix.
+var DefaultTrackingParams = []string{
+	"utm_*", "gclid", "gclsrc", "dclid", "gbraid", "wbraid", "fbclid", "msclkid", "yclid",
+	"mc_cid", "mc_eid", "_ga", "_gl", "igshid", "twclid", "li_fat_id", "_hsenc", "_hsmi",
+	"mkt_tok", "oly_anon_id", "oly_enc_id", "vero_id", "ref_src",
+}
+
+// Rules configures normalization. The zero value applies the default normalization: the scheme
+// and host are lowercased, default ports, dot segments, fragments, and tracking parameters are
+// removed, an empty path becomes "/", and query parameters are sorted.
+type Rules struct {
+	// Disabled leaves URLs unchanged.
+	Disabled bool
+	// StripParams are removed in addition to DefaultTrackingParams, and KeepParams are never
+	// removed. A trailing "*" matches any parameter with that prefix.
+	StripParams []string
+	KeepParams  []string
+	// KeepQueryOrder leaves query parameters in their original order, for sites where it matters.
+	KeepQueryOrder bool
+	// StripTrailingSlash removes a trailing slash from paths other than "/".
+	StripTrailingSlash bool
+	// KeepFragment keeps the fragment, for single-page apps that route on it.
+	KeepFragment bool
+}
+
+// Normalize returns rawURL normalized with rules, or rawURL unchanged if it cannot be parsed
+// or is not an absolute URL.
+func Normalize(rawURL string, rules Rules) string {
+	if rules.Disabled {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return rawURL
+	}
+	return NormalizeURL(u, rules).String()
+}
+
+// NormalizeURL returns a normalized copy of u.
+func NormalizeURL(u *url.URL, rules Rules) *url.URL {
+	n := *u
+	if rules.Disabled {
+		return &n
+	}
+
+	n.Scheme = strings.ToLower(n.Scheme)
+	n.Host = normalizeHost(n.Scheme, n.Host)
+	if !rules.KeepFragment {
+		n.Fragment, n.RawFragment = "", ""
+	}
+
+	// Paths with escaped slashes or other non-default encodings are left as they are.
+	if n.Opaque == "" && n.RawPath == "" {
+		n.Path = cleanPath(n.Path, rules.StripTrailingSlash)
+	}
+
+	n.RawQuery = normalizeQuery(n.RawQuery, rules)
+	n.ForceQuery = false
+	return &n
+}
+
+// normalizeHost lowercases host and removes the scheme's default port.
+func normalizeHost(scheme, host string) string {
+	host = strings.ToLower(host)
+	switch {
+	case scheme == "http" && strings.HasSuffix(host, ":80"):
+		host = strings.TrimSuffix(host, ":80")
+	case scheme == "https" && strings.HasSuffix(host, ":443"):
+		host = strings.TrimSuffix(host, ":443")
+	}
+	return host
+}
+
+// cleanPath resolves dot segments and duplicate slashes, keeping a trailing slash unless
+// stripTrailingSlash is set. An empty path becomes "/".
+func cleanPath(p string, stripTrailingSlash bool) string {
+	if p == "" {
+		return "/"
+	}
+	trailing := strings.HasSuffix(p, "/") || strings.HasSuffix(p, "/.") || strings.HasSuffix(p, "/..")
+	cleaned := path.Clean("/" + p)
+	if trailing && cleaned != "/" && !stripTrailingSlash {
+		cleaned += "/"
+	}
+	if stripTrailingSlash && len(cleaned) > 1 {
+		cleaned = strings.TrimSuffix(cleaned, "/")
+	}
+	return cleaned
+}
+
+// normalizeQuery removes stripped parameters and sorts the rest by name, keeping the order of
+// repeated parameters. The raw encoding of each parameter is kept.
+func normalizeQuery(rawQuery string, rules Rules) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	type param struct {
+		name string
+		raw  string
+	}
+	var params []param
+	for _, raw := range strings.Split(rawQuery, "&") {
+		if raw == "" {
+			continue
+		}
+		rawName, _, _ := strings.Cut(raw, "=")
+		name, err := url.QueryUnescape(rawName)
+		if err != nil {
+			name = rawName
+		}
+		if stripped(name, rules) {
+			continue
+		}
+		params = append(params, param{name: name, raw: raw})
+	}
+
+	if !rules.KeepQueryOrder {
+		sort.SliceStable(params, func(i, j int) bool { return params[i].name < params[j].name })
+	}
+
+	raws := make([]string, len(params))
+	for i, p := range params {
+		raws[i] = p.raw
+	}
+	return strings.Join(raws, "&")
+}
+
+// stripped reports whether the parameter name is removed under rules.
+func stripped(name string, rules Rules) bool {
+	lower := strings.ToLower(name)
+	if slices.ContainsFunc(rules.KeepParams, func(pattern string) bool { return matchParam(lower, pattern) }) {
+		return false
+	}
+	return slices.ContainsFunc(DefaultTrackingParams, func(pattern string) bool { return matchParam(lower, pattern) }) ||
+		slices.ContainsFunc(rules.StripParams, func(pattern string) bool { return matchParam(lower, pattern) })
+}
+
+// matchParam reports whether a lowercase parameter name matches pattern, which may end in "*"
+// to match a prefix.
+func matchParam(name, pattern string) bool {
+	pattern = strings.ToLower(pattern)
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(name, prefix)
+	}
+	return name == pattern
+}
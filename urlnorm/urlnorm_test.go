@@ -0,0 +1,44 @@
+package urlnorm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNormalize verifies trivially different URLs normalize to the same URL.
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		rules Rules
+		want  string
+	}{
+		{"lowercases scheme and host", "HTTPS://Example.COM/Docs", Rules{}, "https://example.com/Docs"},
+		{"strips default https port", "https://example.com:443/a", Rules{}, "https://example.com/a"},
+		{"strips default http port", "http://example.com:80/a", Rules{}, "http://example.com/a"},
+		{"keeps other ports", "https://example.com:8443/a", Rules{}, "https://example.com:8443/a"},
+		{"adds root path", "https://example.com", Rules{}, "https://example.com/"},
+		{"resolves dot segments", "https://example.com/a/./b/../c", Rules{}, "https://example.com/a/c"},
+		{"keeps trailing slash", "https://example.com/a/b/../", Rules{}, "https://example.com/a/"},
+		{"removes fragment", "https://example.com/a#section", Rules{}, "https://example.com/a"},
+		{"removes tracking params", "https://example.com/a?utm_source=x&id=1&fbclid=abc&UTM_Medium=y", Rules{}, "https://example.com/a?id=1"},
+		{"sorts query params", "https://example.com/a?b=2&a=1&b=1", Rules{}, "https://example.com/a?a=1&b=2&b=1"},
+		{"drops empty query", "https://example.com/a?utm_source=x", Rules{}, "https://example.com/a"},
+		{"keeps encoded values", "https://example.com/a?q=a%20b%26c", Rules{}, "https://example.com/a?q=a%20b%26c"},
+		{"keeps escaped slashes", "https://example.com/a%2Fb/../c", Rules{}, "https://example.com/a%2Fb/../c"},
+		{"strips configured params", "https://example.com/a?sid=1&session_x=2&id=3", Rules{StripParams: []string{"sid", "session_*"}}, "https://example.com/a?id=3"},
+		{"keeps configured params", "https://example.com/a?utm_campaign=launch&utm_source=x", Rules{KeepParams: []string{"utm_campaign"}}, "https://example.com/a?utm_campaign=launch"},
+		{"keeps query order", "https://example.com/a?b=2&a=1", Rules{KeepQueryOrder: true}, "https://example.com/a?b=2&a=1"},
+		{"strips trailing slash", "https://example.com/a/", Rules{StripTrailingSlash: true}, "https://example.com/a"},
+		{"keeps root slash", "https://example.com/", Rules{StripTrailingSlash: true}, "https://example.com/"},
+		{"keeps fragment", "https://example.com/#/route", Rules{KeepFragment: true}, "https://example.com/#/route"},
+		{"disabled", "HTTPS://Example.com:443/a?utm_source=x", Rules{Disabled: true}, "HTTPS://Example.com:443/a?utm_source=x"},
+		{"relative urls unchanged", "/a/../b", Rules{}, "/a/../b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Normalize(tt.input, tt.rules))
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// FetchFunc fetches the current content of a watched URL.
+type FetchFunc func(ctx context.Context, url string) (*Snapshot, error)
+
+// NotifyFunc is called for each change that reaches its watch's threshold.
+type NotifyFunc func(ctx context.Context, event *Event)
+
+// Run checks due watches until ctx is canceled, calling notify for changes that reach their
+// thresholds. Several schedulers may run concurrently, in this process or others sharing the
+// same Redis; each due watch is claimed by one of them.
+func (w *Watcher) Run(ctx context.Context, fetch FetchFunc, notify NotifyFunc) {
+	logger := w.config.Logger
+
+	for ctx.Err() == nil {
+		ids, err := w.claimDue(ctx)
+		if err != nil {
+			logger.Error("failed to claim due watches", "error", err)
+		}
+
+		for _, id := range ids {
+			if ctx.Err() != nil {
+				return
+			}
+			w.runCheck(ctx, id, fetch, notify)
+		}
+
+		if len(ids) == w.config.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(w.config.PollInterval):
+		}
+	}
+}
+
+// runCheck checks a single claimed watch and reports a qualifying change to notify. A panic in
+// the check is logged, so it does not stop the scheduler.
+func (w *Watcher) runCheck(ctx context.Context, id string, fetch FetchFunc, notify NotifyFunc) {
+	logger := w.config.Logger.With("watch_id", id)
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("watch check panicked", "panic", r)
+		}
+	}()
+
+	checkCtx, cancel := context.WithTimeout(ctx, w.config.CheckTimeout)
+	defer cancel()
+
+	watch, err := w.Get(checkCtx, id)
+	if errors.Is(err, ErrNotFound) {
+		return
+	}
+	if err != nil {
+		logger.Error("failed to load watch", "error", err)
+		return
+	}
+
+	event, err := w.Check(checkCtx, watch, fetch)
+	if err != nil {
+		if ctx.Err() != nil {
+			logger.Info("watch check interrupted by shutdown")
+			return
+		}
+		logger.Warn("watch check failed", "url", watch.URL, "error", err)
+		return
+	}
+	if watch.RefetchSuppressed {
+		logger.Info("watch check reused a recent fetch (within min refetch interval)", "url", watch.URL)
+	}
+
+	if event != nil {
+		logger.Info("watched page changed", "url", watch.URL, "change", event.Current.Change)
+		notify(ctx, event)
+	}
+}
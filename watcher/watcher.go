@@ -0,0 +1,413 @@
+package watcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/joeychilson/websurfer/cache"
+	"github.com/joeychilson/websurfer/lines"
+	"github.com/joeychilson/websurfer/ratelimit"
+)
+
+// ErrNotFound is returned when a watch does not exist.
+var ErrNotFound = errors.New("watch not found")
+
+// versionTTL is how long a watch's versions are kept without the page being checked or, for
+// versions before the newest, without its content changing.
+const versionTTL = 365 * 24 * time.Hour
+
+// diffContextLines is how many unchanged lines surround each hunk of an event's diff.
+const diffContextLines = 3
+
+// claimScript returns the watches due for a check and pushes their next check back by the
+// check timeout, so no other scheduler claims them while they are being checked.
+var claimScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, id in ipairs(ids) do
+  redis.call('ZADD', KEYS[1], ARGV[3], id)
+end
+return ids
+`)
+
+// Watch is a URL refetched on a schedule.
+type Watch struct {
+	ID       string        `json:"id"`
+	URL      string        `json:"url"`
+	Interval time.Duration `json:"interval"`
+	// Threshold is the fraction of lines, from 0 to 1, that must change between versions for
+	// CallbackURL to be notified. 0 notifies on any change.
+	Threshold   float64   `json:"threshold"`
+	CallbackURL string    `json:"callback_url,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	CheckedAt   time.Time `json:"checked_at,omitzero"`
+	NextCheckAt time.Time `json:"next_check_at"`
+	// LastError is the error of the most recent check, empty if it succeeded.
+	LastError string `json:"last_error,omitempty"`
+	// RefetchSuppressed is set when the most recent check reused a fetch made within the
+	// site's min_refetch_interval instead of fetching the page.
+	RefetchSuppressed bool `json:"refetch_suppressed,omitempty"`
+	// Caller is who created the watch. Its checks are rate limited as made by them.
+	Caller ratelimit.Caller `json:"caller,omitzero"`
+}
+
+// Version is a distinct version of a watched page.
+type Version struct {
+	FetchedAt  time.Time `json:"fetched_at"`
+	StatusCode int       `json:"status_code"`
+	Hash       string    `json:"hash"`
+	Body       []byte    `json:"body"`
+	// Change is the fraction of lines that changed from the previous version, 0 for the first.
+	Change float64 `json:"change"`
+}
+
+// Snapshot is the content of a watched page at one check.
+type Snapshot struct {
+	StatusCode int
+	Body       []byte
+	// RefetchSuppressed is set when the page was fetched within its site's
+	// min_refetch_interval, so the most recent fetch was reused.
+	RefetchSuppressed bool
+}
+
+// Event describes a change found by a check.
+type Event struct {
+	Watch    *Watch
+	Previous *Version
+	Current  *Version
+	// Diff is a unified diff of the previous version against the current one.
+	Diff string
+}
+
+// Config holds watcher configuration.
+type Config struct {
+	Prefix string
+	// MaxVersions is how many versions of each watched page are kept (default: 10).
+	MaxVersions int
+	// PollInterval is how long the scheduler waits between looking for due watches.
+	PollInterval time.Duration
+	// CheckTimeout bounds a single check. A watch whose check did not finish, because its
+	// scheduler stopped, is checked again once it has passed.
+	CheckTimeout time.Duration
+	// BatchSize is how many due watches are claimed at a time.
+	BatchSize int
+	Logger    *slog.Logger
+}
+
+// DefaultConfig returns a watcher config with sensible defaults.
+func DefaultConfig() Config {
+	return Config{
+		Prefix:       "websurfer:watch:",
+		MaxVersions:  10,
+		PollInterval: 5 * time.Second,
+		CheckTimeout: 2 * time.Minute,
+		BatchSize:    10,
+		Logger:       slog.Default(),
+	}
+}
+
+// applyDefaults fills in zero values with defaults.
+func applyDefaults(config Config) Config {
+	defaults := DefaultConfig()
+	if config.Prefix == "" {
+		config.Prefix = defaults.Prefix
+	}
+	if config.MaxVersions <= 0 {
+		config.MaxVersions = defaults.MaxVersions
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.CheckTimeout <= 0 {
+		config.CheckTimeout = defaults.CheckTimeout
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.Logger == nil {
+		config.Logger = defaults.Logger
+	}
+	return config
+}
+
+// Watcher stores watches in Redis, and the versions of their pages in a cache history under
+// each watch's ID. Because the schedule lives in Redis, any number of instances may run
+// schedulers against it and each check runs once.
+type Watcher struct {
+	client   *redis.Client
+	versions *cache.Cache
+	config   Config
+	now      func() time.Time
+}
+
+// New creates a new watcher with the provided client and configuration.
+func New(client *redis.Client, config Config) *Watcher {
+	config = applyDefaults(config)
+	return &Watcher{
+		client: client,
+		versions: cache.New(client, cache.Config{
+			Prefix:            config.Prefix + "versions:",
+			TTL:               versionTTL,
+			EnableCompression: true,
+			HistoryTTL:        versionTTL,
+			Logger:            config.Logger,
+		}),
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// makeWatchKey creates the Redis key holding a watch.
+func (w *Watcher) makeWatchKey(id string) string {
+	return w.config.Prefix + "watch:" + id
+}
+
+// makeScheduleKey creates the Redis key of the sorted set of watches by next check time.
+func (w *Watcher) makeScheduleKey() string {
+	return w.config.Prefix + "schedule"
+}
+
+// Add registers a new watch, checked for the first time on the scheduler's next poll. The
+// caller of ctx, set with ratelimit.WithCaller, is recorded as the watch's creator.
+func (w *Watcher) Add(ctx context.Context, url string, interval time.Duration, threshold float64, callbackURL string) (*Watch, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watch id: %w", err)
+	}
+
+	now := w.now()
+	watch := &Watch{
+		ID:          id,
+		URL:         url,
+		Interval:    interval,
+		Threshold:   threshold,
+		CallbackURL: callbackURL,
+		CreatedAt:   now,
+		NextCheckAt: now,
+		Caller:      ratelimit.CallerFromContext(ctx),
+	}
+
+	data, err := json.Marshal(watch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal watch: %w", err)
+	}
+
+	_, err = w.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, w.makeWatchKey(id), data, 0)
+		pipe.ZAdd(ctx, w.makeScheduleKey(), redis.Z{Score: float64(now.UnixMilli()), Member: id})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("redis add failed: %w", err)
+	}
+
+	return watch, nil
+}
+
+// Get returns the watch with the given ID.
+func (w *Watcher) Get(ctx context.Context, id string) (*Watch, error) {
+	data, err := w.client.Get(ctx, w.makeWatchKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis get failed: %w", err)
+	}
+
+	var watch Watch
+	if err := json.Unmarshal(data, &watch); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal watch: %w", err)
+	}
+	return &watch, nil
+}
+
+// Delete removes a watch and its version history.
+func (w *Watcher) Delete(ctx context.Context, id string) error {
+	var deleted *redis.IntCmd
+	_, err := w.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		deleted = pipe.Del(ctx, w.makeWatchKey(id))
+		pipe.ZRem(ctx, w.makeScheduleKey(), id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("redis delete failed: %w", err)
+	}
+	if deleted.Val() == 0 {
+		return ErrNotFound
+	}
+	return w.versions.Delete(ctx, id)
+}
+
+// Versions returns the stored versions of a watched page, newest first.
+func (w *Watcher) Versions(ctx context.Context, id string) ([]Version, error) {
+	current, err := w.versions.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, nil
+	}
+
+	previous, err := w.versions.History(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	entries := append([]*cache.Entry{current}, previous...)
+
+	// One more version than is returned is kept, so the change of the oldest one is known.
+	versions := make([]Version, 0, min(len(entries), w.config.MaxVersions))
+	for i, entry := range entries[:min(len(entries), w.config.MaxVersions)] {
+		var before *cache.Entry
+		if i+1 < len(entries) {
+			before = entries[i+1]
+		}
+		versions = append(versions, *newVersion(entry, before))
+	}
+	return versions, nil
+}
+
+// Check fetches a watched page and stores it as a new version if its content changed. It
+// returns an Event when the change reaches the watch's threshold, or nil otherwise. The
+// outcome of the check schedules the next one.
+func (w *Watcher) Check(ctx context.Context, watch *Watch, fetch FetchFunc) (*Event, error) {
+	snapshot, fetchErr := fetch(ratelimit.WithCaller(ctx, watch.Caller), watch.URL)
+
+	var event *Event
+	var err error
+	if fetchErr == nil {
+		event, err = w.record(ctx, watch, snapshot)
+	}
+
+	now := w.now()
+	watch.CheckedAt = now
+	watch.NextCheckAt = now.Add(watch.Interval)
+	watch.LastError = ""
+	watch.RefetchSuppressed = false
+	if fetchErr != nil {
+		watch.LastError = fetchErr.Error()
+	} else {
+		watch.RefetchSuppressed = snapshot.RefetchSuppressed
+	}
+	if scheduleErr := w.reschedule(ctx, watch); scheduleErr != nil && err == nil {
+		err = scheduleErr
+	}
+
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	return event, err
+}
+
+// record stores snapshot as the newest version of watch if it differs from the previous one.
+func (w *Watcher) record(ctx context.Context, watch *Watch, snapshot *Snapshot) (*Event, error) {
+	previous, err := w.versions.Get(ctx, watch.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &cache.Entry{
+		URL:         watch.ID,
+		StatusCode:  snapshot.StatusCode,
+		Body:        snapshot.Body,
+		StoredAt:    w.now(),
+		TTL:         versionTTL,
+		MaxVersions: w.config.MaxVersions + 1,
+	}
+	if err := w.versions.Set(ctx, entry); err != nil {
+		return nil, err
+	}
+	if previous == nil || bytes.Equal(previous.Body, snapshot.Body) {
+		return nil, nil
+	}
+
+	current := newVersion(entry, previous)
+	if current.Change == 0 || current.Change < watch.Threshold {
+		return nil, nil
+	}
+
+	before := newVersion(previous, nil)
+	diff, err := lines.Unified(string(previous.Body), string(entry.Body), before.FetchedAt.UTC().Format(time.RFC3339), current.FetchedAt.UTC().Format(time.RFC3339), diffContextLines)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff content: %w", err)
+	}
+	return &Event{Watch: watch, Previous: before, Current: current, Diff: diff}, nil
+}
+
+// newVersion describes the version of a watched page held by entry, as changed from the
+// version before it, if known.
+func newVersion(entry, before *cache.Entry) *Version {
+	version := &Version{
+		FetchedAt:  entry.ChangedAt,
+		StatusCode: entry.StatusCode,
+		Hash:       hashBody(entry.Body),
+		Body:       entry.Body,
+	}
+	if version.FetchedAt.IsZero() {
+		version.FetchedAt = entry.StoredAt
+	}
+	if before != nil {
+		version.Change = lines.Change(string(before.Body), string(entry.Body))
+	}
+	return version
+}
+
+// reschedule saves a checked watch and its next check time, unless it was deleted meanwhile.
+func (w *Watcher) reschedule(ctx context.Context, watch *Watch) error {
+	data, err := json.Marshal(watch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal watch: %w", err)
+	}
+
+	var saved *redis.BoolCmd
+	_, err = w.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		saved = pipe.SetXX(ctx, w.makeWatchKey(watch.ID), data, redis.KeepTTL)
+		pipe.ZAddXX(ctx, w.makeScheduleKey(), redis.Z{Score: float64(watch.NextCheckAt.UnixMilli()), Member: watch.ID})
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("redis reschedule failed: %w", err)
+	}
+
+	if !saved.Val() {
+		// The watch was deleted during the check, so drop any version the check stored.
+		return w.versions.Delete(ctx, watch.ID)
+	}
+	return nil
+}
+
+// claimDue returns the watches due for a check, leasing them to the caller for the check timeout.
+func (w *Watcher) claimDue(ctx context.Context) ([]string, error) {
+	now := w.now()
+	keys := []string{w.makeScheduleKey()}
+	args := []any{now.UnixMilli(), w.config.BatchSize, now.Add(w.config.CheckTimeout).UnixMilli()}
+
+	ids, err := claimScript.Run(ctx, w.client, keys, args...).StringSlice()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("redis claim failed: %w", err)
+	}
+	return ids, nil
+}
+
+// hashBody returns the hex SHA-256 of body.
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// newID returns a random watch identifier.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
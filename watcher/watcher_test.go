@@ -0,0 +1,279 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joeychilson/websurfer/ratelimit"
+)
+
+func setupTestWatcher(t *testing.T, config Config) *Watcher {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	})
+
+	return New(client, config)
+}
+
+// staticFetch returns a FetchFunc that serves *body.
+func staticFetch(body *string) FetchFunc {
+	return func(ctx context.Context, url string) (*Snapshot, error) {
+		return &Snapshot{StatusCode: 200, Body: []byte(*body)}, nil
+	}
+}
+
+// TestWatcherCheckStoresVersions verifies checks store distinct versions and report changes.
+func TestWatcherCheckStoresVersions(t *testing.T) {
+	w := setupTestWatcher(t, Config{})
+	ctx := context.Background()
+
+	watch, err := w.Add(ctx, "https://example.com", time.Hour, 0, "")
+	require.NoError(t, err)
+
+	body := "line one\nline two\n"
+	fetch := staticFetch(&body)
+
+	event, err := w.Check(ctx, watch, fetch)
+	require.NoError(t, err)
+	assert.Nil(t, event, "the first version has nothing to compare against")
+
+	event, err = w.Check(ctx, watch, fetch)
+	require.NoError(t, err)
+	assert.Nil(t, event, "unchanged content should not be reported")
+
+	body = "line one\nline 2\n"
+	event, err = w.Check(ctx, watch, fetch)
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.Equal(t, 0.5, event.Current.Change)
+	assert.Contains(t, event.Diff, "-line two")
+	assert.Contains(t, event.Diff, "+line 2")
+
+	versions, err := w.Versions(ctx, watch.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 2, "unchanged checks should not add versions")
+	assert.Equal(t, "line one\nline 2\n", string(versions[0].Body))
+
+	stored, err := w.Get(ctx, watch.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.CheckedAt.IsZero())
+	assert.Equal(t, stored.CheckedAt.Add(time.Hour), stored.NextCheckAt)
+}
+
+// TestWatcherThreshold verifies changes below the watch's threshold are stored but not reported.
+func TestWatcherThreshold(t *testing.T) {
+	w := setupTestWatcher(t, Config{})
+	ctx := context.Background()
+
+	watch, err := w.Add(ctx, "https://example.com", time.Hour, 0.5, "")
+	require.NoError(t, err)
+
+	body := "a\nb\nc\nd\n"
+	fetch := staticFetch(&body)
+	_, err = w.Check(ctx, watch, fetch)
+	require.NoError(t, err)
+
+	body = "a\nb\nc\nD\n"
+	event, err := w.Check(ctx, watch, fetch)
+	require.NoError(t, err)
+	assert.Nil(t, event)
+
+	body = "w\nx\ny\nD\n"
+	event, err = w.Check(ctx, watch, fetch)
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.Equal(t, 0.75, event.Current.Change)
+
+	versions, err := w.Versions(ctx, watch.ID)
+	require.NoError(t, err)
+	assert.Len(t, versions, 3)
+}
+
+// TestWatcherMaxVersions verifies only the newest versions are kept.
+func TestWatcherMaxVersions(t *testing.T) {
+	w := setupTestWatcher(t, Config{MaxVersions: 2})
+	ctx := context.Background()
+
+	watch, err := w.Add(ctx, "https://example.com", time.Hour, 0, "")
+	require.NoError(t, err)
+
+	var body string
+	for i := range 4 {
+		body = fmt.Sprintf("version %d\n", i)
+		_, err := w.Check(ctx, watch, staticFetch(&body))
+		require.NoError(t, err)
+	}
+
+	versions, err := w.Versions(ctx, watch.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, "version 3\n", string(versions[0].Body))
+	assert.Equal(t, "version 2\n", string(versions[1].Body))
+	assert.Equal(t, 1.0, versions[1].Change, "the oldest kept version should keep its change")
+}
+
+// TestWatcherChecksAsCreator verifies a watch records the caller that created it and its
+// checks are made as that caller.
+func TestWatcherChecksAsCreator(t *testing.T) {
+	w := setupTestWatcher(t, Config{})
+	ctx := context.Background()
+
+	creator := ratelimit.Caller{ID: "reports", Weight: 2}
+	watch, err := w.Add(ratelimit.WithCaller(ctx, creator), "https://example.com", time.Hour, 0, "")
+	require.NoError(t, err)
+
+	stored, err := w.Get(ctx, watch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, creator, stored.Caller)
+
+	var checkedAs ratelimit.Caller
+	_, err = w.Check(ctx, stored, func(ctx context.Context, url string) (*Snapshot, error) {
+		checkedAs = ratelimit.CallerFromContext(ctx)
+		return &Snapshot{StatusCode: 200, Body: []byte("page")}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, creator, checkedAs)
+}
+
+// TestWatcherCheckFailure verifies a failed fetch is recorded and the watch stays scheduled.
+func TestWatcherCheckFailure(t *testing.T) {
+	w := setupTestWatcher(t, Config{})
+	ctx := context.Background()
+
+	watch, err := w.Add(ctx, "https://example.com", time.Minute, 0, "")
+	require.NoError(t, err)
+
+	_, err = w.Check(ctx, watch, func(ctx context.Context, url string) (*Snapshot, error) {
+		return nil, errors.New("connection refused")
+	})
+	require.Error(t, err)
+
+	stored, err := w.Get(ctx, watch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "connection refused", stored.LastError)
+	assert.True(t, stored.NextCheckAt.After(stored.CreatedAt))
+}
+
+// TestWatcherCheckRefetchSuppressed verifies a check that reused a recent fetch is flagged on
+// the watch until a check fetches the page.
+func TestWatcherCheckRefetchSuppressed(t *testing.T) {
+	w := setupTestWatcher(t, Config{})
+	ctx := context.Background()
+
+	watch, err := w.Add(ctx, "https://example.com", time.Minute, 0, "")
+	require.NoError(t, err)
+
+	suppressed := true
+	fetch := func(ctx context.Context, url string) (*Snapshot, error) {
+		return &Snapshot{StatusCode: 200, Body: []byte("page"), RefetchSuppressed: suppressed}, nil
+	}
+
+	_, err = w.Check(ctx, watch, fetch)
+	require.NoError(t, err)
+	stored, err := w.Get(ctx, watch.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.RefetchSuppressed)
+
+	suppressed = false
+	_, err = w.Check(ctx, stored, fetch)
+	require.NoError(t, err)
+	stored, err = w.Get(ctx, watch.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.RefetchSuppressed)
+}
+
+// TestWatcherClaimDue verifies due watches are claimed once until their check timeout passes.
+func TestWatcherClaimDue(t *testing.T) {
+	w := setupTestWatcher(t, Config{CheckTimeout: time.Minute})
+	ctx := context.Background()
+
+	now := time.Now()
+	w.now = func() time.Time { return now }
+
+	watch, err := w.Add(ctx, "https://example.com", time.Hour, 0, "")
+	require.NoError(t, err)
+
+	ids, err := w.claimDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{watch.ID}, ids)
+
+	ids, err = w.claimDue(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, ids, "a claimed watch should not be claimed again while it is checked")
+
+	now = now.Add(2 * time.Minute)
+	ids, err = w.claimDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{watch.ID}, ids, "an unfinished check should be retried after the timeout")
+}
+
+// TestWatcherDelete verifies deleting a watch removes it, its versions, and its schedule.
+func TestWatcherDelete(t *testing.T) {
+	w := setupTestWatcher(t, Config{})
+	ctx := context.Background()
+
+	watch, err := w.Add(ctx, "https://example.com", time.Hour, 0, "")
+	require.NoError(t, err)
+
+	body := "content"
+	_, err = w.Check(ctx, watch, staticFetch(&body))
+	require.NoError(t, err)
+
+	require.NoError(t, w.Delete(ctx, watch.ID))
+	assert.ErrorIs(t, w.Delete(ctx, watch.ID), ErrNotFound)
+
+	_, err = w.Get(ctx, watch.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	versions, err := w.Versions(ctx, watch.ID)
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+
+	_, err = w.Check(ctx, watch, staticFetch(&body))
+	require.NoError(t, err)
+	_, err = w.Get(ctx, watch.ID)
+	assert.ErrorIs(t, err, ErrNotFound, "a check finishing after deletion should not recreate the watch")
+	versions, err = w.Versions(ctx, watch.ID)
+	require.NoError(t, err)
+	assert.Empty(t, versions, "a check finishing after deletion should not keep its version")
+}
+
+// TestWatcherRun verifies the scheduler checks due watches and notifies changes.
+func TestWatcherRun(t *testing.T) {
+	w := setupTestWatcher(t, Config{PollInterval: 10 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watch, err := w.Add(ctx, "https://example.com", 20*time.Millisecond, 0, "")
+	require.NoError(t, err)
+
+	var checks int
+	fetch := func(ctx context.Context, url string) (*Snapshot, error) {
+		checks++
+		return &Snapshot{StatusCode: 200, Body: fmt.Appendf(nil, "check %d\n", checks)}, nil
+	}
+
+	events := make(chan *Event, 10)
+	go w.Run(ctx, fetch, func(ctx context.Context, event *Event) {
+		events <- event
+	})
+
+	select {
+	case event := <-events:
+		assert.Equal(t, watch.ID, event.Watch.ID)
+		assert.Equal(t, "check 2\n", string(event.Current.Body))
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a change notification")
+	}
+}
@@ -0,0 +1,144 @@
+package wayback
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultAPIURL is the Wayback Machine availability API.
+	DefaultAPIURL = "https://archive.org/wayback/available"
+	// timestampLayout is the layout of Wayback snapshot timestamps.
+	timestampLayout = "20060102150405"
+	// defaultTimeout bounds each availability lookup.
+	defaultTimeout = 10 * time.Second
+	// maxResponseSize caps the availability API response read.
+	maxResponseSize = 64 * 1024
+)
+
+// ErrNoSnapshot is returned when the Wayback Machine has no usable snapshot of a URL.
+var ErrNoSnapshot = errors.New("no archived snapshot available")
+
+// Snapshot is an archived copy of a page.
+type Snapshot struct {
+	// URL is the snapshot as served by the Wayback Machine, with its toolbar and rewritten links.
+	URL string
+	// RawURL serves the snapshot's original bytes, without the Wayback toolbar.
+	RawURL    string
+	Timestamp time.Time
+}
+
+// Client looks up snapshots with the Wayback Machine availability API.
+type Client struct {
+	apiURL     string
+	httpClient *http.Client
+}
+
+// Option is a functional option for configuring the Client.
+type Option func(*Client)
+
+// WithAPIURL sets the availability API endpoint (default: DefaultAPIURL).
+func WithAPIURL(apiURL string) Option {
+	return func(c *Client) {
+		c.apiURL = apiURL
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for lookups.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// New creates a new Wayback Machine client.
+func New(opts ...Option) *Client {
+	c := &Client{
+		apiURL:     DefaultAPIURL,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// availabilityResponse is the availability API response body.
+type availabilityResponse struct {
+	ArchivedSnapshots struct {
+		Closest *struct {
+			Available bool   `json:"available"`
+			URL       string `json:"url"`
+			Timestamp string `json:"timestamp"`
+			Status    string `json:"status"`
+		} `json:"closest"`
+	} `json:"archived_snapshots"`
+}
+
+// Lookup returns the most recent successful snapshot of pageURL, or ErrNoSnapshot.
+func (c *Client) Lookup(ctx context.Context, pageURL string) (*Snapshot, error) {
+	endpoint := c.apiURL + "?" + url.Values{"url": {pageURL}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("wayback lookup failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("wayback lookup returned status %d", resp.StatusCode)
+	}
+
+	var body availabilityResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode wayback response: %w", err)
+	}
+
+	closest := body.ArchivedSnapshots.Closest
+	if closest == nil || !closest.Available || closest.URL == "" || (closest.Status != "" && closest.Status != "200") {
+		return nil, ErrNoSnapshot
+	}
+
+	timestamp, err := time.Parse(timestampLayout, closest.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot timestamp %q: %w", closest.Timestamp, err)
+	}
+
+	snapshotURL := closest.URL
+	if IsArchiveURL(snapshotURL) {
+		snapshotURL = strings.Replace(snapshotURL, "http://", "https://", 1)
+	}
+	return &Snapshot{
+		URL:       snapshotURL,
+		RawURL:    rawURL(snapshotURL, closest.Timestamp),
+		Timestamp: timestamp,
+	}, nil
+}
+
+// IsArchiveURL reports whether target is hosted by the Internet Archive, whose own missing
+// pages have no snapshots to fall back to.
+func IsArchiveURL(target string) bool {
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return host == "archive.org" || strings.HasSuffix(host, ".archive.org")
+}
+
+// rawURL rewrites a snapshot URL to its "id_" form, which serves the archived bytes unmodified.
+func rawURL(snapshotURL, timestamp string) string {
+	marker := "/web/" + timestamp + "/"
+	return strings.Replace(snapshotURL, marker, "/web/"+timestamp+"id_/", 1)
+}
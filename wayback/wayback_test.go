@@ -0,0 +1,50 @@
+package wayback
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLookup verifies the closest snapshot is returned with its raw URL and timestamp.
+func TestLookup(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "https://example.com/gone", r.URL.Query().Get("url"))
+		w.Write([]byte(`{"archived_snapshots":{"closest":{"status":"200","available":true,"url":"http://web.archive.org/web/20230115083000/https://example.com/gone","timestamp":"20230115083000"}}}`))
+	}))
+	defer api.Close()
+
+	snapshot, err := New(WithAPIURL(api.URL)).Lookup(context.Background(), "https://example.com/gone")
+	require.NoError(t, err)
+	assert.Equal(t, "https://web.archive.org/web/20230115083000/https://example.com/gone", snapshot.URL)
+	assert.Equal(t, "https://web.archive.org/web/20230115083000id_/https://example.com/gone", snapshot.RawURL)
+	assert.Equal(t, time.Date(2023, 1, 15, 8, 30, 0, 0, time.UTC), snapshot.Timestamp)
+}
+
+// TestLookupNoSnapshot verifies missing or unsuccessful snapshots return ErrNoSnapshot.
+func TestLookupNoSnapshot(t *testing.T) {
+	for _, body := range []string{
+		`{"archived_snapshots":{}}`,
+		`{"archived_snapshots":{"closest":{"status":"404","available":true,"url":"http://web.archive.org/web/2023/x","timestamp":"20230101000000"}}}`,
+	} {
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+
+		_, err := New(WithAPIURL(api.URL)).Lookup(context.Background(), "https://example.com")
+		assert.ErrorIs(t, err, ErrNoSnapshot, body)
+		api.Close()
+	}
+}
+
+// TestIsArchiveURL verifies Internet Archive hosts are recognized.
+func TestIsArchiveURL(t *testing.T) {
+	assert.True(t, IsArchiveURL("https://web.archive.org/web/2023/https://example.com"))
+	assert.True(t, IsArchiveURL("https://archive.org/details/x"))
+	assert.False(t, IsArchiveURL("https://notarchive.org"))
+}
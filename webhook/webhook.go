@@ -0,0 +1,174 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/joeychilson/websurfer/fetcher"
+	urlutil "github.com/joeychilson/websurfer/url"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 signature of the delivery, formatted as "sha256=<hex>".
+	SignatureHeader = "X-Websurfer-Signature"
+	// TimestampHeader carries the Unix timestamp included in the signature.
+	TimestampHeader = "X-Websurfer-Timestamp"
+
+	// defaultTimeout is the per-attempt timeout for webhook deliveries.
+	defaultTimeout = 30 * time.Second
+	// defaultMaxAttempts is the number of delivery attempts before giving up.
+	defaultMaxAttempts = 3
+	// defaultRetryDelay is the initial delay between delivery attempts, doubled after each failure.
+	defaultRetryDelay = time.Second
+)
+
+// Sender delivers signed JSON payloads to callback URLs.
+type Sender struct {
+	secret      []byte
+	client      *http.Client
+	maxAttempts int
+	retryDelay  time.Duration
+}
+
+// Option is a functional option for configuring the Sender.
+type Option func(*Sender)
+
+// WithHTTPClient sets the HTTP client used for deliveries, in place of the default client that
+// refuses private addresses and redirects.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *Sender) {
+		s.client = client
+	}
+}
+
+// New creates a Sender that signs payloads with secret. An empty secret sends unsigned payloads.
+func New(secret string, opts ...Option) *Sender {
+	s := &Sender{
+		secret:      []byte(secret),
+		client:      newClient(),
+		maxAttempts: defaultMaxAttempts,
+		retryDelay:  defaultRetryDelay,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// newClient returns the default delivery client. Callback URLs are validated when they are
+// submitted, but the host may re-resolve or redirect by the time a delivery is made, so
+// connections to private, loopback, and link-local addresses are refused at dial time, no proxy
+// is used, and redirects are not followed.
+func newClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   fetcher.SSRFDialControl,
+	}).DialContext
+
+	return &http.Client{
+		Timeout:   defaultTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Send POSTs payload as JSON to callbackURL, retrying on network errors and 5xx responses.
+func (s *Sender) Send(ctx context.Context, callbackURL string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	delay := s.retryDelay
+	var lastErr error
+	for attempt := 1; attempt <= s.maxAttempts; attempt++ {
+		retryable, err := s.deliver(ctx, callbackURL, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retryable || attempt == s.maxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+
+	return fmt.Errorf("webhook delivery to %s failed: %w", callbackURL, lastErr)
+}
+
+// deliver makes a single delivery attempt and reports whether a failure is worth retrying.
+func (s *Sender) deliver(ctx context.Context, callbackURL string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if len(s.secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, Sign(s.secret, timestamp, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return !errors.Is(err, urlutil.ErrBlockedAddress), err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	return resp.StatusCode >= 500, fmt.Errorf("callback returned status %d", resp.StatusCode)
+}
+
+// Sign returns the signature for a delivery: "sha256=" followed by the hex HMAC-SHA256 of
+// "<timestamp>.<body>".
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a delivery's signature and rejects timestamps older than maxAge (0 skips the age check).
+func Verify(secret []byte, timestamp string, body []byte, signature string, maxAge time.Duration) error {
+	if maxAge > 0 {
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid timestamp: %w", err)
+		}
+		if age := time.Since(time.Unix(unix, 0)); age > maxAge || age < -maxAge {
+			return fmt.Errorf("timestamp outside allowed window")
+		}
+	}
+
+	if !hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	urlutil "github.com/joeychilson/websurfer/url"
+)
+
+// TestSenderSignsPayload verifies deliveries carry a verifiable signature.
+func TestSenderSignsPayload(t *testing.T) {
+	secret := []byte("s3cret")
+	received := make(chan error, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- Verify(secret, r.Header.Get(TimestampHeader), body, r.Header.Get(SignatureHeader), time.Minute)
+
+		var payload map[string]string
+		assert.NoError(t, json.Unmarshal(body, &payload))
+		assert.Equal(t, "done", payload["status"])
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	}))
+	defer server.Close()
+
+	err := New(string(secret), WithHTTPClient(server.Client())).Send(context.Background(), server.URL, map[string]string{"status": "done"})
+
+	require.NoError(t, err)
+	assert.NoError(t, <-received, "signature should verify")
+}
+
+// TestSenderUnsigned verifies no signature headers are sent without a secret.
+func TestSenderUnsigned(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get(SignatureHeader))
+		assert.Empty(t, r.Header.Get(TimestampHeader))
+	}))
+	defer server.Close()
+
+	require.NoError(t, New("", WithHTTPClient(server.Client())).Send(context.Background(), server.URL, map[string]string{}))
+}
+
+// TestSenderRetriesServerErrors verifies 5xx responses are retried.
+func TestSenderRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	sender := New("", WithHTTPClient(server.Client()))
+	sender.retryDelay = time.Millisecond
+
+	require.NoError(t, sender.Send(context.Background(), server.URL, map[string]string{}))
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+// TestSenderNoRetryOnClientError verifies 4xx responses fail without retrying.
+func TestSenderNoRetryOnClientError(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	sender := New("", WithHTTPClient(server.Client()))
+	sender.retryDelay = time.Millisecond
+
+	err := sender.Send(context.Background(), server.URL, map[string]string{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// TestSenderBlocksPrivateAddresses verifies the default client refuses to deliver to private
+// addresses, without retrying.
+func TestSenderBlocksPrivateAddresses(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	sender := New("")
+	sender.retryDelay = time.Millisecond
+
+	err := sender.Send(context.Background(), server.URL, map[string]string{})
+	assert.ErrorIs(t, err, urlutil.ErrBlockedAddress)
+	assert.Equal(t, int32(0), calls.Load())
+}
+
+// TestSenderDoesNotFollowRedirects verifies the default client reports a redirect as a failed
+// delivery rather than following it.
+func TestSenderDoesNotFollowRedirects(t *testing.T) {
+	var redirected atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal" {
+			redirected.Add(1)
+			return
+		}
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	}))
+	defer server.Close()
+
+	sender := New("")
+	sender.client.Transport = http.DefaultTransport
+
+	err := sender.Send(context.Background(), server.URL, map[string]string{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "302")
+	assert.Equal(t, int32(0), redirected.Load())
+}
+
+// TestVerify verifies signature and timestamp checks.
+func TestVerify(t *testing.T) {
+	secret := []byte("s3cret")
+	body := []byte(`{"job_id":"abc"}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	assert.NoError(t, Verify(secret, now, body, Sign(secret, now, body), time.Minute))
+	assert.Error(t, Verify(secret, now, body, Sign([]byte("other"), now, body), time.Minute), "wrong secret")
+	assert.Error(t, Verify(secret, now, []byte(`{}`), Sign(secret, now, body), time.Minute), "tampered body")
+	assert.Error(t, Verify(secret, old, body, Sign(secret, old, body), time.Minute), "stale timestamp")
+	assert.NoError(t, Verify(secret, old, body, Sign(secret, old, body), 0), "age check disabled")
+}
@@ -0,0 +1,296 @@
+package youtube
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// paragraphInterval is how much of a video each paragraph of a transcript covers.
+const paragraphInterval = 30 * time.Second
+
+var (
+	// ErrNoPlayerResponse is returned when a watch page does not embed the video's details.
+	ErrNoPlayerResponse = errors.New("no player response in watch page")
+	// ErrUnplayable is returned when YouTube will not play a video, such as a private or
+	// removed one.
+	ErrUnplayable = errors.New("video unavailable")
+)
+
+var (
+	// videoIDPattern matches the 11-character IDs of YouTube videos.
+	videoIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	// playerResponseMarker precedes the player response JSON in a watch page.
+	playerResponseMarker = regexp.MustCompile(`ytInitialPlayerResponse\s*=\s*`)
+)
+
+// Video describes a YouTube video and its caption tracks.
+type Video struct {
+	ID          string
+	Title       string
+	Channel     string
+	Duration    time.Duration
+	Description string
+	// ThumbnailURL is the largest thumbnail of the video.
+	ThumbnailURL string
+	Tracks       []Track
+}
+
+// Track is a caption track of a video.
+type Track struct {
+	// URL serves the track's captions in YouTube's timed text XML format.
+	URL      string
+	Language string
+	Name     string
+	// Generated is set for tracks YouTube transcribed automatically.
+	Generated bool
+}
+
+// Cue is a caption shown from Start.
+type Cue struct {
+	Start time.Duration
+	Text  string
+}
+
+// VideoID returns the ID of the video a YouTube URL plays: a watch, short, live, or embed URL
+// of youtube.com, or a youtu.be short link.
+func VideoID(urlStr string) (string, bool) {
+	u, err := url.Parse(urlStr)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", false
+	}
+
+	var id string
+	switch strings.ToLower(u.Hostname()) {
+	case "youtu.be":
+		id = strings.Trim(u.Path, "/")
+	case "youtube.com", "www.youtube.com", "m.youtube.com", "music.youtube.com":
+		segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+		switch {
+		case segments[0] == "watch":
+			id = u.Query().Get("v")
+		case len(segments) == 2 && (segments[0] == "shorts" || segments[0] == "live" || segments[0] == "embed"):
+			id = segments[1]
+		}
+	}
+
+	if !videoIDPattern.MatchString(id) {
+		return "", false
+	}
+	return id, true
+}
+
+// WatchURL returns the canonical watch page URL of a video, seeking to start when it is
+// positive.
+func WatchURL(id string, start time.Duration) string {
+	watchURL := "https://www.youtube.com/watch?v=" + id
+	if start > 0 {
+		watchURL += "&t=" + strconv.Itoa(int(start.Seconds())) + "s"
+	}
+	return watchURL
+}
+
+// playerResponse is the part of a watch page's ytInitialPlayerResponse that describes the video.
+type playerResponse struct {
+	PlayabilityStatus struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	} `json:"playabilityStatus"`
+	VideoDetails struct {
+		VideoID          string `json:"videoId"`
+		Title            string `json:"title"`
+		Author           string `json:"author"`
+		LengthSeconds    string `json:"lengthSeconds"`
+		ShortDescription string `json:"shortDescription"`
+		Thumbnail        struct {
+			Thumbnails []struct {
+				URL   string `json:"url"`
+				Width int    `json:"width"`
+			} `json:"thumbnails"`
+		} `json:"thumbnail"`
+	} `json:"videoDetails"`
+	Captions struct {
+		Renderer struct {
+			CaptionTracks []struct {
+				BaseURL      string `json:"baseUrl"`
+				LanguageCode string `json:"languageCode"`
+				Kind         string `json:"kind"`
+				Name         struct {
+					SimpleText string `json:"simpleText"`
+					Runs       []struct {
+						Text string `json:"text"`
+					} `json:"runs"`
+				} `json:"name"`
+			} `json:"captionTracks"`
+		} `json:"playerCaptionsTracklistRenderer"`
+	} `json:"captions"`
+}
+
+// ParseWatchPage extracts the details and caption tracks of a video from its watch page.
+func ParseWatchPage(page []byte) (*Video, error) {
+	loc := playerResponseMarker.FindIndex(page)
+	if loc == nil {
+		return nil, ErrNoPlayerResponse
+	}
+
+	var resp playerResponse
+	if err := json.NewDecoder(bytes.NewReader(page[loc[1]:])).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNoPlayerResponse, err)
+	}
+	if status := resp.PlayabilityStatus.Status; status != "" && status != "OK" {
+		return nil, fmt.Errorf("%w: %s", ErrUnplayable, cmp.Or(resp.PlayabilityStatus.Reason, status))
+	}
+
+	details := resp.VideoDetails
+	video := &Video{
+		ID:          details.VideoID,
+		Title:       details.Title,
+		Channel:     details.Author,
+		Description: details.ShortDescription,
+	}
+	if seconds, err := strconv.Atoi(details.LengthSeconds); err == nil {
+		video.Duration = time.Duration(seconds) * time.Second
+	}
+	width := 0
+	for _, thumbnail := range details.Thumbnail.Thumbnails {
+		if thumbnail.Width > width {
+			video.ThumbnailURL, width = thumbnail.URL, thumbnail.Width
+		}
+	}
+
+	for _, track := range resp.Captions.Renderer.CaptionTracks {
+		if track.BaseURL == "" {
+			continue
+		}
+		name := track.Name.SimpleText
+		for _, run := range track.Name.Runs {
+			name += run.Text
+		}
+		video.Tracks = append(video.Tracks, Track{
+			URL:       track.BaseURL,
+			Language:  track.LanguageCode,
+			Name:      name,
+			Generated: track.Kind == "asr",
+		})
+	}
+	return video, nil
+}
+
+// Track returns the caption track a transcript is read from: the first track written by the
+// video's author, or else the first generated one.
+func (v *Video) Track() (Track, bool) {
+	for _, track := range v.Tracks {
+		if !track.Generated {
+			return track, true
+		}
+	}
+	if len(v.Tracks) > 0 {
+		return v.Tracks[0], true
+	}
+	return Track{}, false
+}
+
+// timedText is a caption track in either of YouTube's timed text formats: the legacy
+// <transcript> of <text> elements timed in seconds, or format 3, a <timedtext> body of <p>
+// elements timed in milliseconds whose words may be split into <s> elements.
+type timedText struct {
+	Texts []struct {
+		Start float64 `xml:"start,attr"`
+		Text  string  `xml:",chardata"`
+	} `xml:"text"`
+	Paragraphs []struct {
+		Start int64  `xml:"t,attr"`
+		Text  string `xml:",chardata"`
+		Words []struct {
+			Text string `xml:",chardata"`
+		} `xml:"s"`
+	} `xml:"body>p"`
+}
+
+// ParseCaptions parses a caption track into cues, skipping empty ones.
+func ParseCaptions(data []byte) ([]Cue, error) {
+	var track timedText
+	if err := xml.Unmarshal(data, &track); err != nil {
+		return nil, fmt.Errorf("failed to parse captions: %w", err)
+	}
+
+	var cues []Cue
+	add := func(start time.Duration, text string) {
+		if text = strings.Join(strings.Fields(html.UnescapeString(text)), " "); text != "" {
+			cues = append(cues, Cue{Start: start, Text: text})
+		}
+	}
+	for _, text := range track.Texts {
+		add(time.Duration(text.Start*float64(time.Second)), text.Text)
+	}
+	for _, p := range track.Paragraphs {
+		text := p.Text
+		for _, word := range p.Words {
+			text += word.Text
+		}
+		add(time.Duration(p.Start)*time.Millisecond, text)
+	}
+	return cues, nil
+}
+
+// Markdown renders a video's details and transcript. The transcript is split into paragraphs
+// of about paragraphInterval, each starting with a timestamp linking to that point of the
+// video. track is the caption track the cues were read from, if any.
+func Markdown(v *Video, track *Track, cues []Cue) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %s\n\n", v.Title)
+	if v.Channel != "" {
+		fmt.Fprintf(&b, "- Channel: %s\n", v.Channel)
+	}
+	if v.Duration > 0 {
+		fmt.Fprintf(&b, "- Duration: %s\n", timestamp(v.Duration))
+	}
+	if track != nil {
+		fmt.Fprintf(&b, "- Captions: %s\n", cmp.Or(track.Name, track.Language))
+	}
+
+	if description := strings.TrimSpace(v.Description); description != "" {
+		fmt.Fprintf(&b, "\n## Description\n\n%s\n", description)
+	}
+
+	b.WriteString("\n## Transcript\n\n")
+	if len(cues) == 0 {
+		b.WriteString("No transcript is available for this video.\n")
+		return b.Bytes()
+	}
+
+	var paragraph time.Duration
+	for i, cue := range cues {
+		switch {
+		case i == 0:
+		case cue.Start >= paragraph+paragraphInterval:
+			b.WriteString("\n\n")
+		default:
+			b.WriteString(" ")
+			b.WriteString(cue.Text)
+			continue
+		}
+		paragraph = cue.Start
+		fmt.Fprintf(&b, "[%s](%s) %s", timestamp(cue.Start), WatchURL(v.ID, cue.Start), cue.Text)
+	}
+	b.WriteString("\n")
+	return b.Bytes()
+}
+
+// timestamp formats a position in a video as M:SS, or H:MM:SS past an hour.
+func timestamp(d time.Duration) string {
+	seconds := int(d.Seconds())
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
@@ -0,0 +1,112 @@
+package youtube
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// watchPage is a trimmed-down watch page embedding a player response.
+const watchPage = `<html><head><title>Go Concurrency Patterns - YouTube</title></head><body><script>var ytInitialPlayerResponse = {"playabilityStatus":{"status":"OK"},"videoDetails":{"videoId":"f6kdp27TYZs","title":"Go Concurrency Patterns","lengthSeconds":"3106","author":"Google for Developers","shortDescription":"Rob Pike on concurrency.","thumbnail":{"thumbnails":[{"url":"https://i.ytimg.com/vi/f6kdp27TYZs/default.jpg","width":120},{"url":"https://i.ytimg.com/vi/f6kdp27TYZs/maxresdefault.jpg","width":1920}]}},"captions":{"playerCaptionsTracklistRenderer":{"captionTracks":[{"baseUrl":"https://www.youtube.com/api/timedtext?v=f6kdp27TYZs&lang=en&kind=asr","name":{"runs":[{"text":"English (auto-generated)"}]},"languageCode":"en","kind":"asr"},{"baseUrl":"https://www.youtube.com/api/timedtext?v=f6kdp27TYZs&lang=en","name":{"simpleText":"English"},"languageCode":"en"}]}}};var meta = document.createElement('meta');</script></body></html>`
+
+// TestVideoID verifies video IDs are read from each form of YouTube URL.
+func TestVideoID(t *testing.T) {
+	tests := []struct {
+		url string
+		id  string
+	}{
+		{"https://www.youtube.com/watch?v=f6kdp27TYZs", "f6kdp27TYZs"},
+		{"https://m.youtube.com/watch?feature=share&v=f6kdp27TYZs&t=42s", "f6kdp27TYZs"},
+		{"https://youtu.be/f6kdp27TYZs?si=abc", "f6kdp27TYZs"},
+		{"https://www.youtube.com/shorts/f6kdp27TYZs", "f6kdp27TYZs"},
+		{"https://www.youtube.com/embed/f6kdp27TYZs", "f6kdp27TYZs"},
+		{"https://www.youtube.com/live/f6kdp27TYZs", "f6kdp27TYZs"},
+		{"https://www.youtube.com/@golang", ""},
+		{"https://www.youtube.com/watch?v=short", ""},
+		{"https://www.youtube.com/", ""},
+		{"https://example.com/watch?v=f6kdp27TYZs", ""},
+	}
+
+	for _, tt := range tests {
+		id, ok := VideoID(tt.url)
+		assert.Equal(t, tt.id, id, tt.url)
+		assert.Equal(t, tt.id != "", ok, tt.url)
+	}
+}
+
+// TestParseWatchPage verifies a video's details and caption tracks are read from its watch
+// page, and the author's captions are preferred over generated ones.
+func TestParseWatchPage(t *testing.T) {
+	video, err := ParseWatchPage([]byte(watchPage))
+	require.NoError(t, err)
+
+	assert.Equal(t, "f6kdp27TYZs", video.ID)
+	assert.Equal(t, "Go Concurrency Patterns", video.Title)
+	assert.Equal(t, "Google for Developers", video.Channel)
+	assert.Equal(t, 3106*time.Second, video.Duration)
+	assert.Equal(t, "https://i.ytimg.com/vi/f6kdp27TYZs/maxresdefault.jpg", video.ThumbnailURL)
+	require.Len(t, video.Tracks, 2)
+	assert.Equal(t, Track{URL: "https://www.youtube.com/api/timedtext?v=f6kdp27TYZs&lang=en&kind=asr", Language: "en", Name: "English (auto-generated)", Generated: true}, video.Tracks[0])
+
+	track, ok := video.Track()
+	require.True(t, ok)
+	assert.Equal(t, "English", track.Name)
+
+	_, err = ParseWatchPage([]byte(`<html><body>Before you continue to YouTube</body></html>`))
+	assert.ErrorIs(t, err, ErrNoPlayerResponse)
+
+	_, err = ParseWatchPage([]byte(`<script>var ytInitialPlayerResponse = {"playabilityStatus":{"status":"ERROR","reason":"This video is private"}};</script>`))
+	assert.ErrorIs(t, err, ErrUnplayable)
+	assert.ErrorContains(t, err, "This video is private")
+}
+
+// TestParseCaptions verifies cues are read from both timed text formats.
+func TestParseCaptions(t *testing.T) {
+	legacy := `<?xml version="1.0" encoding="utf-8" ?><transcript><text start="0.5" dur="2.1">Hello &amp;amp; welcome</text><text start="2.6" dur="1">
+</text><text start="3.25" dur="2">it&amp;#39;s Go</text></transcript>`
+	cues, err := ParseCaptions([]byte(legacy))
+	require.NoError(t, err)
+	assert.Equal(t, []Cue{{Start: 500 * time.Millisecond, Text: "Hello & welcome"}, {Start: 3250 * time.Millisecond, Text: "it's Go"}}, cues)
+
+	srv3 := `<?xml version="1.0" encoding="utf-8" ?><timedtext format="3"><body><p t="1360" d="3280"><s ac="0">hey</s><s t="320" ac="0"> gophers</s></p><p t="4640" d="10" a="1">
+</p><p t="61000" d="2000">Channels</p></body></timedtext>`
+	cues, err = ParseCaptions([]byte(srv3))
+	require.NoError(t, err)
+	assert.Equal(t, []Cue{{Start: 1360 * time.Millisecond, Text: "hey gophers"}, {Start: 61 * time.Second, Text: "Channels"}}, cues)
+}
+
+// TestMarkdown verifies transcripts are rendered as timestamped paragraphs after the video's
+// details.
+func TestMarkdown(t *testing.T) {
+	video := &Video{ID: "f6kdp27TYZs", Title: "Go Concurrency Patterns", Channel: "Google for Developers", Duration: 3725 * time.Second, Description: "Rob Pike on concurrency."}
+	track := &Track{Name: "English", Language: "en"}
+	cues := []Cue{
+		{Start: 0, Text: "Hello."},
+		{Start: 12 * time.Second, Text: "Today, concurrency."},
+		{Start: 45 * time.Second, Text: "Goroutines first."},
+		{Start: 3700 * time.Second, Text: "Thanks."},
+	}
+
+	assert.Equal(t, `# Go Concurrency Patterns
+
+- Channel: Google for Developers
+- Duration: 1:02:05
+- Captions: English
+
+## Description
+
+Rob Pike on concurrency.
+
+## Transcript
+
+[0:00](https://www.youtube.com/watch?v=f6kdp27TYZs) Hello. Today, concurrency.
+
+[0:45](https://www.youtube.com/watch?v=f6kdp27TYZs&t=45s) Goroutines first.
+
+[1:01:40](https://www.youtube.com/watch?v=f6kdp27TYZs&t=3700s) Thanks.
+`, string(Markdown(video, track, cues)))
+
+	assert.Contains(t, string(Markdown(video, nil, nil)), "## Transcript\n\nNo transcript is available for this video.\n")
+}